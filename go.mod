@@ -13,6 +13,8 @@ require github.com/karamble/brmcp v0.1.1-0.20260706221912-61502343ed2e
 
 require github.com/karamble/satfetch v0.0.0-20260707203034-774667bcbb2c
 
+require golang.org/x/image v0.25.0
+
 require (
 	github.com/google/jsonschema-go v0.4.3 // indirect
 	github.com/modelcontextprotocol/go-sdk v1.6.1
@@ -37,7 +39,7 @@ require (
 	github.com/decred/dcrd/dcrutil/v4 v4.0.3
 	github.com/decred/dcrd/txscript/v4 v4.1.2 // indirect
 	github.com/decred/dcrd/wire v1.7.2 // indirect
-	github.com/decred/slog v1.2.0 // indirect
+	github.com/decred/slog v1.2.0
 	github.com/gorilla/websocket v1.5.3 // indirect
 	github.com/jrick/logrotate v1.1.2 // indirect
 	github.com/klauspost/cpuid/v2 v2.3.0 // indirect
@@ -0,0 +1,216 @@
+package assets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotCacheable is returned for results the cache does not keep: those
+// without an http(s) URL and those larger than the whole cache.
+var ErrNotCacheable = errors.New("result cannot be cached")
+
+// DefaultCache keeps local copies of delivered results when the operator
+// set mediacachemb=. It is nil otherwise.
+var DefaultCache *Cache
+
+// Cache keeps local copies of results in a directory, named after their
+// provider URL, so they can be sent again after the URL expired. Once the
+// files exceed the cache size the least recently used ones are removed.
+type Cache struct {
+	dir      string
+	maxBytes int64
+	client   *http.Client
+	mu       sync.Mutex // Serializes evictions
+}
+
+// NewCache creates a cache of up to maxBytes in dir.
+func NewCache(dir string, maxBytes int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create media cache directory: %v", err)
+	}
+	return &Cache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		client:   &http.Client{Timeout: 10 * time.Minute},
+	}, nil
+}
+
+// name returns the file name of srcURL's copy, or "" when srcURL is not an
+// http(s) URL.
+func (c *Cache) name(srcURL string) string {
+	u, err := url.Parse(srcURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(srcURL))
+	ext := path.Ext(u.Path)
+	if len(ext) > 6 || strings.ContainsAny(ext, "/%?#") {
+		ext = ""
+	}
+	return hex.EncodeToString(sum[:16]) + ext
+}
+
+// Add downloads the result at srcURL into the cache, unless it is cached
+// already, and returns the path of the copy.
+func (c *Cache) Add(ctx context.Context, srcURL string) (string, error) {
+	name := c.name(srcURL)
+	if name == "" {
+		return "", ErrNotCacheable
+	}
+	file := filepath.Join(c.dir, name)
+	if touch(file) {
+		return file, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srcURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download result: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download result: status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > c.maxBytes {
+		return "", ErrNotCacheable
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".cache-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create cache file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, io.LimitReader(resp.Body, c.maxBytes+1))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write cache file: %v", err)
+	}
+	if n > c.maxBytes {
+		return "", ErrNotCacheable
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return "", fmt.Errorf("failed to store cache file: %v", err)
+	}
+	if err := c.evict(); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// Open returns the cached copy of srcURL and its size, downloading it first
+// when it is not cached. The caller closes the file.
+func (c *Cache) Open(ctx context.Context, srcURL string) (*os.File, int64, error) {
+	file, err := c.Add(ctx, srcURL)
+	if err != nil {
+		return nil, 0, err
+	}
+	// A removed file stays readable while it is open, so eviction cannot
+	// pull it away from the caller
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open cache file: %v", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, fmt.Errorf("failed to open cache file: %v", err)
+	}
+	return f, fi.Size(), nil
+}
+
+// openResult opens the result at srcURL, from cache when one is set and it
+// can hold the result, and else downloads it with client.
+func openResult(ctx context.Context, client *http.Client, cache *Cache, srcURL string) (io.ReadCloser, error) {
+	if cache != nil {
+		f, _, err := cache.Open(ctx, srcURL)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, ErrNotCacheable) {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srcURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download result: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download result: status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// Has reports whether srcURL is cached.
+func (c *Cache) Has(srcURL string) bool {
+	name := c.name(srcURL)
+	if name == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(c.dir, name))
+	return err == nil
+}
+
+// evict removes the least recently used files until the cache fits its
+// size. The caller holds c.mu.
+func (c *Cache) evict() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list media cache: %v", err)
+	}
+	var files []os.FileInfo
+	var total int64
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, fi)
+		total += fi.Size()
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime().Before(files[j].ModTime()) })
+	for _, fi := range files {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, fi.Name())); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to evict %s: %v", fi.Name(), err)
+		}
+		total -= fi.Size()
+	}
+	return nil
+}
+
+// touch marks file as used now and reports whether it exists.
+func touch(file string) bool {
+	now := time.Now()
+	return os.Chtimes(file, now, now) == nil
+}
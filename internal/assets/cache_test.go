@@ -0,0 +1,114 @@
+package assets
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	gets := map[string]int{}
+	expired := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gets[r.URL.Path]++
+		if expired {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Path {
+		case "/big.mp4":
+			w.Write(make([]byte, 64))
+		default:
+			w.Write([]byte("0123456789"))
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewCache(t.TempDir(), 25)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	ctx := context.Background()
+	add := func(path string) string {
+		t.Helper()
+		file, err := c.Add(ctx, srv.URL+path)
+		if err != nil {
+			t.Fatalf("Add(%s): %v", path, err)
+		}
+		return file
+	}
+
+	a, b := add("/a.png"), add("/b.png")
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(a, old, old)
+	os.Chtimes(b, old, old)
+	// Using a again makes b the least recently used
+	add("/a.png")
+	if gets["/a.png"] != 1 || gets["/b.png"] != 1 {
+		t.Fatalf("downloads = %v, want each result once", gets)
+	}
+
+	add("/c.png")
+	if !c.Has(srv.URL+"/a.png") || c.Has(srv.URL+"/b.png") || !c.Has(srv.URL+"/c.png") {
+		t.Fatalf("cache holds a=%v b=%v c=%v; want b evicted", c.Has(srv.URL+"/a.png"), c.Has(srv.URL+"/b.png"), c.Has(srv.URL+"/c.png"))
+	}
+
+	if _, err := c.Add(ctx, srv.URL+"/big.mp4"); !errors.Is(err, ErrNotCacheable) {
+		t.Fatalf("Add of a result larger than the cache = %v, want ErrNotCacheable", err)
+	}
+	if _, err := c.Add(ctx, "data:image/png;base64,AAAA"); !errors.Is(err, ErrNotCacheable) {
+		t.Fatalf("Add of a data URI = %v, want ErrNotCacheable", err)
+	}
+
+	// Cached results outlive the provider's copy
+	expired = true
+	f, size, err := c.Open(ctx, srv.URL+"/c.png")
+	if err != nil {
+		t.Fatalf("Open after expiry: %v", err)
+	}
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if size != 10 || string(data) != "0123456789" {
+		t.Fatalf("Open = %q (%d bytes)", data, size)
+	}
+	if _, _, err := c.Open(ctx, srv.URL+"/b.png"); err == nil {
+		t.Fatal("Open of an evicted, expired result succeeded")
+	}
+}
+
+func TestPublisherCache(t *testing.T) {
+	var uploaded string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			data, _ := io.ReadAll(r.Body)
+			uploaded = string(data)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	c, err := NewCache(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	src := srv.URL + "/expired.png"
+	name := c.name(src)
+	if err := os.WriteFile(c.dir+"/"+name, []byte("cached bytes"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	p := NewPublisher(ServerStore{URL: srv.URL + "/assets", APIKey: "k"}, "k", time.Hour)
+	if _, _, err := p.PublishURL(context.Background(), src); err == nil {
+		t.Fatal("PublishURL of an expired result without a cache succeeded")
+	}
+	p.SetCache(c)
+	if _, _, err := p.PublishURL(context.Background(), src); err != nil || uploaded != "cached bytes" {
+		t.Fatalf("PublishURL from cache = %v, uploaded %q", err, uploaded)
+	}
+}
@@ -0,0 +1,163 @@
+// Package assets copies delivered results off the provider's short-lived
+// URLs into the operator's asset store, so re-deliveries and links keep
+// working after the provider's copy expires.
+package assets
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/internal/database"
+)
+
+// maxAssetBytes caps the size of a mirrored result.
+const maxAssetBytes = 1 << 30
+
+// Store keeps mirrored results and returns their durable URL.
+type Store interface {
+	Put(ctx context.Context, name string, r io.Reader) (string, error)
+}
+
+// DirStore stores assets in a directory that the operator serves at
+// BaseURL, e.g. through a web server or a synced bucket.
+type DirStore struct {
+	Dir     string
+	BaseURL string
+}
+
+// Put writes the asset to the directory and returns its public URL.
+func (s DirStore) Put(ctx context.Context, name string, r io.Reader) (string, error) {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create asset directory: %v", err)
+	}
+	tmp, err := os.CreateTemp(s.Dir, ".mirror-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create asset file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, io.LimitReader(r, maxAssetBytes+1))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write asset: %v", err)
+	}
+	if n > maxAssetBytes {
+		return "", fmt.Errorf("asset exceeds %d bytes", maxAssetBytes)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write asset: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.Dir, name)); err != nil {
+		return "", fmt.Errorf("failed to store asset: %v", err)
+	}
+	return strings.TrimSuffix(s.BaseURL, "/") + "/" + url.PathEscape(name), nil
+}
+
+// Mirror copies the results of recorded jobs into a Store and points the
+// jobs at the copies.
+type Mirror struct {
+	db     *database.DBManager
+	store  Store
+	client *http.Client
+	cache  *Cache
+}
+
+// NewMirror creates a mirror writing to store.
+func NewMirror(db *database.DBManager, store Store) *Mirror {
+	return &Mirror{
+		db:     db,
+		store:  store,
+		client: &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+// SetCache makes the mirror copy results from cache, so results whose
+// provider URL expired before they were mirrored are still copied.
+func (m *Mirror) SetCache(cache *Cache) {
+	m.cache = cache
+}
+
+// Run mirrors new jobs every interval until ctx is done. Failures are
+// reported to logf and retried on later sweeps.
+func (m *Mirror) Run(ctx context.Context, interval time.Duration, logf func(format string, args ...interface{})) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := m.Sweep(ctx, time.Now(), logf)
+		if err != nil {
+			logf("Asset mirror: %v", err)
+		} else if n > 0 {
+			logf("Asset mirror: mirrored %d results", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep mirrors the jobs that still point at the provider and returns how
+// many were mirrored.
+func (m *Mirror) Sweep(ctx context.Context, now time.Time, logf func(format string, args ...interface{})) (int, error) {
+	jobs, err := m.db.ListUnmirroredJobs(now, 20)
+	if err != nil {
+		return 0, err
+	}
+	mirrored := 0
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		durable, err := m.mirror(ctx, job)
+		if err != nil {
+			logf("Asset mirror: job %d: %v", job.ID, err)
+			if err := m.db.RecordMirrorFailure(job.ID); err != nil {
+				return mirrored, err
+			}
+			continue
+		}
+		if err := m.db.SetJobMirrored(job.ID, durable, now); err != nil {
+			return mirrored, err
+		}
+		mirrored++
+	}
+	return mirrored, nil
+}
+
+// mirror copies one job's result into the store.
+func (m *Mirror) mirror(ctx context.Context, job database.Job) (string, error) {
+	u, err := url.Parse(job.ResultURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("result is not an http(s) url")
+	}
+	body, err := openResult(ctx, m.client, m.cache, job.ResultURL)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	// A random suffix keeps the URLs of private jobs unguessable
+	var token [8]byte
+	if _, err := rand.Read(token[:]); err != nil {
+		return "", err
+	}
+	ext := path.Ext(u.Path)
+	if len(ext) > 6 || strings.ContainsAny(ext, "/%?#") {
+		ext = ""
+	}
+	name := fmt.Sprintf("%d-%s%s", job.ID, hex.EncodeToString(token[:]), ext)
+	return m.store.Put(ctx, name, body)
+}
@@ -0,0 +1,112 @@
+package assets
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/karamble/braibot/internal/database"
+)
+
+func TestMirrorSweep(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone.mp4" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("video bytes"))
+	}))
+	defer srv.Close()
+
+	db, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer db.Close()
+	ok, err := db.RecordJob("alice", "text2video", "m", srv.URL+"/result.mp4", time.Now())
+	if err != nil {
+		t.Fatalf("RecordJob: %v", err)
+	}
+	gone, err := db.RecordJob("alice", "text2video", "m", srv.URL+"/gone.mp4", time.Now())
+	if err != nil {
+		t.Fatalf("RecordJob: %v", err)
+	}
+
+	dir := t.TempDir()
+	m := NewMirror(db, DirStore{Dir: dir, BaseURL: "https://assets.example.com/braibot/"})
+	logf := func(format string, args ...interface{}) {}
+	ctx := context.Background()
+
+	n, err := m.Sweep(ctx, time.Now(), logf)
+	if err != nil || n != 1 {
+		t.Fatalf("Sweep = %d, %v; want 1, nil", n, err)
+	}
+	job, _, _ := db.GetJob(ok.ID)
+	if !strings.HasPrefix(job.ResultURL, "https://assets.example.com/braibot/") || !strings.HasSuffix(job.ResultURL, ".mp4") {
+		t.Fatalf("mirrored url = %q", job.ResultURL)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, filepath.Base(job.ResultURL)))
+	if err != nil || string(data) != "video bytes" {
+		t.Fatalf("mirrored file = %q, %v", data, err)
+	}
+	if job, _, _ := db.GetJob(gone.ID); job.ResultURL != gone.ResultURL {
+		t.Errorf("failed job url changed to %q", job.ResultURL)
+	}
+
+	// The failed job is retried a limited number of times; the mirrored one
+	// is never copied again.
+	for i := 0; i < 3; i++ {
+		if n, err := m.Sweep(ctx, time.Now(), logf); err != nil || n != 0 {
+			t.Fatalf("retry sweep = %d, %v; want 0, nil", n, err)
+		}
+	}
+	if pending, _ := db.ListUnmirroredJobs(time.Now(), 10); len(pending) != 0 {
+		t.Errorf("%d jobs still waiting to be mirrored after the retry limit", len(pending))
+	}
+}
+
+func TestPublisher(t *testing.T) {
+	uploads := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/result.png":
+			w.Write([]byte("image bytes"))
+		case r.Method == http.MethodPut && r.Header.Get("X-BRAIBOT-API-KEY") == "key":
+			data, _ := io.ReadAll(r.Body)
+			uploads[r.URL.Path] = string(data)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewPublisher(ServerStore{URL: srv.URL + "/assets/", APIKey: "key"}, "secret", time.Hour)
+	link, expires, err := p.PublishURL(context.Background(), srv.URL+"/result.png")
+	if err != nil {
+		t.Fatalf("PublishURL: %v", err)
+	}
+	u, _ := url.Parse(link)
+	if uploads[u.Path] != "image bytes" || !strings.HasSuffix(u.Path, ".png") {
+		t.Fatalf("link %q does not point at the upload (%v)", link, uploads)
+	}
+	want, _ := SignURL(srv.URL+u.Path, []byte("secret"), expires)
+	if link != want {
+		t.Errorf("link = %q, want %q", link, want)
+	}
+	if d := time.Until(expires); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("link expires in %v, want about an hour", d)
+	}
+
+	bad := NewPublisher(ServerStore{URL: srv.URL + "/assets/", APIKey: "wrong"}, "secret", time.Hour)
+	if _, _, err := bad.PublishURL(context.Background(), srv.URL+"/result.png"); err == nil {
+		t.Error("PublishURL succeeded with a rejected API key")
+	}
+}
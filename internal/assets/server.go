@@ -0,0 +1,127 @@
+package assets
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerStore uploads assets to an HTTP asset server. Each asset is PUT to
+// URL/name with the API key in the X-BRAIBOT-API-KEY header, and served
+// back from the same URL.
+type ServerStore struct {
+	URL    string
+	APIKey string
+	Client *http.Client // nil uses a client with a 10 minute timeout
+}
+
+// Put uploads the asset and returns its URL on the server.
+func (s ServerStore) Put(ctx context.Context, name string, r io.Reader) (string, error) {
+	assetURL := strings.TrimSuffix(s.URL, "/") + "/" + url.PathEscape(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, assetURL, io.LimitReader(r, maxAssetBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload request: %v", err)
+	}
+	req.Header.Set("X-BRAIBOT-API-KEY", s.APIKey)
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Minute}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload asset: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("failed to upload asset: status %d", resp.StatusCode)
+	}
+	return assetURL, nil
+}
+
+// Publisher copies results to a Store and hands out links that expire.
+type Publisher struct {
+	store  Store
+	secret []byte
+	ttl    time.Duration
+	client *http.Client
+	cache  *Cache
+}
+
+// NewPublisher creates a publisher whose links are signed with secret and
+// expire after ttl.
+func NewPublisher(store Store, secret string, ttl time.Duration) *Publisher {
+	return &Publisher{
+		store:  store,
+		secret: []byte(secret),
+		ttl:    ttl,
+		client: &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+// SetCache makes the publisher upload results from cache, so results are
+// still published after the provider's URLs expired.
+func (p *Publisher) SetCache(cache *Cache) {
+	p.cache = cache
+}
+
+// PublishURL copies the result at srcURL to the store and returns a signed
+// link to the copy along with the time the link expires.
+func (p *Publisher) PublishURL(ctx context.Context, srcURL string) (string, time.Time, error) {
+	u, err := url.Parse(srcURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", time.Time{}, fmt.Errorf("result is not an http(s) url")
+	}
+	body, err := openResult(ctx, p.client, p.cache, srcURL)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer body.Close()
+
+	// A random name keeps the links unguessable even without the signature
+	var token [12]byte
+	if _, err := rand.Read(token[:]); err != nil {
+		return "", time.Time{}, err
+	}
+	ext := path.Ext(u.Path)
+	if len(ext) > 6 || strings.ContainsAny(ext, "/%?#") {
+		ext = ""
+	}
+	stored, err := p.store.Put(ctx, hex.EncodeToString(token[:])+ext, body)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires := time.Now().Add(p.ttl).Truncate(time.Second)
+	signed, err := SignURL(stored, p.secret, expires)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expires, nil
+}
+
+// SignURL adds expires and sig query parameters to rawURL. sig is the hex
+// HMAC-SHA256 over the URL path, a newline and the expiry in Unix seconds,
+// which the asset server checks before serving the file.
+func SignURL(rawURL string, secret []byte, expires time.Time) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid asset url: %v", err)
+	}
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(u.EscapedPath() + "\n" + exp))
+	q := u.Query()
+	q.Set("expires", exp)
+	q.Set("sig", hex.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,208 @@
+// Package catalog keeps model prices and metadata in step with a model
+// catalog published as JSON, so operators can update prices or disable a
+// model that is broken upstream without a release or a restart.
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karamble/braibot/internal/faladapter"
+)
+
+// maxCatalogBytes caps the size of a fetched catalog.
+const maxCatalogBytes = 10 << 20
+
+// File is the JSON format of a catalog and of the local overrides file,
+// keyed by model name. Fields left out keep their built-in value:
+//
+//	{"models": {"flux/schnell": {"price_usd": 0.004}, "veo2": {"disabled": true}}}
+type File struct {
+	Models map[string]faladapter.ModelOverride `json:"models"`
+}
+
+// Summary describes the catalog currently in effect.
+type Summary struct {
+	Time     time.Time // When it was loaded; zero before the first load
+	Models   int       // Models with an override in effect
+	Disabled int       // Models the catalog disabled
+	Unknown  []string  // Entries for models this bot does not have, sorted
+}
+
+// String formats the summary for !admin models.
+func (s Summary) String() string {
+	if s.Time.IsZero() {
+		return "No model catalog loaded yet."
+	}
+	msg := fmt.Sprintf("Model catalog loaded %s: %d models updated, %d disabled.",
+		s.Time.UTC().Format("2006-01-02 15:04 MST"), s.Models, s.Disabled)
+	if len(s.Unknown) > 0 {
+		msg += fmt.Sprintf("\nSkipped %d unknown models: %s", len(s.Unknown), strings.Join(s.Unknown, ", "))
+	}
+	return msg
+}
+
+// Syncer loads a catalog and the operator's local overrides and applies
+// them to the model registry.
+type Syncer struct {
+	mu        sync.Mutex
+	source    string // URL or file path of the catalog, empty for none
+	overrides string // Path of the local overrides file, empty for none
+	client    *http.Client
+	last      Summary
+}
+
+// NewSyncer creates a syncer for the catalog at source, an http(s) URL or a
+// file path, and the local overrides file at overrides. Either may be empty.
+// Local overrides win over the catalog.
+func NewSyncer(source, overrides string) *Syncer {
+	return &Syncer{
+		source:    source,
+		overrides: overrides,
+		client:    &http.Client{Timeout: time.Minute},
+	}
+}
+
+// Default is the syncer behind !admin models. It is configured at startup.
+var Default = NewSyncer("", "")
+
+// Configure sets where the catalog and the local overrides are loaded from.
+func (s *Syncer) Configure(source, overrides string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.source, s.overrides = source, overrides
+}
+
+// Configured reports whether a catalog or an overrides file is set.
+func (s *Syncer) Configured() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.source != "" || s.overrides != ""
+}
+
+// Last returns the summary of the last successful reload.
+func (s *Syncer) Last() Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// Reload fetches the catalog and the local overrides and applies them to
+// the registry, replacing the previous ones. On error the models keep the
+// catalog loaded before.
+func (s *Syncer) Reload(ctx context.Context) (Summary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.source == "" && s.overrides == "" {
+		return Summary{}, fmt.Errorf("no model catalog configured")
+	}
+
+	merged := make(map[string]faladapter.ModelOverride)
+	for _, src := range []string{s.source, s.overrides} {
+		if src == "" {
+			continue
+		}
+		f, err := s.load(ctx, src)
+		if err != nil {
+			return Summary{}, err
+		}
+		for name, o := range f.Models {
+			merged[name] = merged[name].Merge(o)
+		}
+	}
+
+	unknown := faladapter.SetModelOverrides(merged)
+	sort.Strings(unknown)
+	sum := Summary{Time: time.Now(), Models: len(merged) - len(unknown), Unknown: unknown}
+	for name, o := range merged {
+		if o.Disabled != nil && *o.Disabled && !contains(unknown, name) {
+			sum.Disabled++
+		}
+	}
+	s.last = sum
+	return sum, nil
+}
+
+// Run reloads the catalog every interval until ctx is done. Failures are
+// reported to logf and the previous catalog stays in effect.
+func (s *Syncer) Run(ctx context.Context, interval time.Duration, logf func(format string, args ...interface{})) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		sum, err := s.Reload(ctx)
+		if err != nil {
+			logf("Model catalog: %v", err)
+			continue
+		}
+		logf("Model catalog: %d models updated, %d disabled", sum.Models, sum.Disabled)
+	}
+}
+
+// load reads and validates the catalog at src.
+func (s *Syncer) load(ctx context.Context, src string) (*File, error) {
+	data, err := s.read(ctx, src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model catalog %s: %v", src, err)
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse model catalog %s: %v", src, err)
+	}
+	for name, o := range f.Models {
+		for _, price := range []*float64{o.PriceUSD, o.BasePriceUSD} {
+			if price != nil && *price < 0 {
+				return nil, fmt.Errorf("invalid model catalog %s: negative price for %s", src, name)
+			}
+		}
+		if o.MaxTextChars != nil && *o.MaxTextChars < 0 {
+			return nil, fmt.Errorf("invalid model catalog %s: negative max_text_chars for %s", src, name)
+		}
+	}
+	return &f, nil
+}
+
+// read returns the contents of a URL or a file.
+func (s *Syncer) read(ctx context.Context, src string) ([]byte, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		return os.ReadFile(src)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCatalogBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCatalogBytes {
+		return nil, fmt.Errorf("catalog exceeds %d bytes", maxCatalogBytes)
+	}
+	return data, nil
+}
+
+// contains reports whether sorted list holds name.
+func contains(list []string, name string) bool {
+	i := sort.SearchStrings(list, name)
+	return i < len(list) && list[i] == name
+}
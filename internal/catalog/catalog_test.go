@@ -0,0 +1,67 @@
+package catalog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/karamble/braibot/internal/faladapter"
+)
+
+func TestReload(t *testing.T) {
+	defer faladapter.SetModelOverrides(nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"models": {
+			"flux/schnell": {"price_usd": 0.5, "description": "Synced"},
+			"flux/dev": {"disabled": true},
+			"no-such-model": {"price_usd": 1}
+		}}`))
+	}))
+	defer srv.Close()
+
+	overrides := filepath.Join(t.TempDir(), "overrides.json")
+	if err := os.WriteFile(overrides, []byte(`{"models": {"flux/schnell": {"price_usd": 0.25}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSyncer(srv.URL, overrides)
+	sum, err := s.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if sum.Models != 2 || sum.Disabled != 1 || !reflect.DeepEqual(sum.Unknown, []string{"no-such-model"}) {
+		t.Errorf("summary = %+v", sum)
+	}
+	if !reflect.DeepEqual(s.Last(), sum) {
+		t.Errorf("Last = %+v, want %+v", s.Last(), sum)
+	}
+
+	// Local overrides win over the catalog, per field
+	m, ok := faladapter.GetModel("flux/schnell", "text2image")
+	if !ok || m.PriceUSD != 0.25 || m.Description != "Synced" {
+		t.Errorf("flux/schnell = %+v, %v", m, ok)
+	}
+	if _, ok := faladapter.GetModel("flux/dev", "text2image"); ok {
+		t.Error("disabled flux/dev is still available")
+	}
+
+	// A broken catalog keeps the previous one in effect
+	if err := os.WriteFile(overrides, []byte(`{"models": {"flux/schnell": {"price_usd": -1}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Reload(context.Background()); err == nil {
+		t.Error("Reload accepted a negative price")
+	}
+	if m, _ := faladapter.GetModel("flux/schnell", "text2image"); m.PriceUSD != 0.25 {
+		t.Errorf("price after failed reload = %v, want 0.25", m.PriceUSD)
+	}
+
+	if _, err := NewSyncer("", "").Reload(context.Background()); err == nil {
+		t.Error("Reload without a catalog succeeded")
+	}
+}
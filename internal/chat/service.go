@@ -0,0 +1,308 @@
+// Package chat holds conversations with language models hosted on fal,
+// remembering the newest messages of each user's conversation.
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/decred/slog"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/jobevents"
+	"github.com/karamble/braibot/internal/joblog"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/pkg/fal"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+const (
+	// HistoryKept is how many messages of a conversation are remembered
+	// and sent along with each new message.
+	HistoryKept = 20
+
+	// replyTokensEstimate is the reply length the balance is checked
+	// against before the reply is known.
+	replyTokensEstimate = 1000
+
+	// minChunkRunes is the shortest part of a reply sent on its own while
+	// the reply is still being written.
+	minChunkRunes = 400
+
+	// maxChunkRunes is the longest part of a reply sent as one message.
+	maxChunkRunes = 3000
+)
+
+// systemPrompt tells the model where it is talking.
+const systemPrompt = "You are BraiBot, a helpful assistant chatting with users of Bison Relay, a private messaging network. Answer concisely in plain text or simple markdown."
+
+// ChatRequest represents an internal request to chat with a language model
+type ChatRequest struct {
+	braibottypes.GenerationRequest
+	Message string // What the user said
+}
+
+// ChatResult represents the reply of a language model
+type ChatResult struct {
+	Reply   string
+	Tokens  int // Billed tokens of prompt and reply
+	Success bool
+	Error   error
+}
+
+// IsSuccess checks if the chat was successful.
+func (r *ChatResult) IsSuccess() bool {
+	if r == nil {
+		return false
+	}
+	return r.Success
+}
+
+// GetError returns the error from the chat, if any.
+func (r *ChatResult) GetError() error {
+	if r == nil {
+		return nil
+	}
+	return r.Error
+}
+
+// ChatService handles conversations with language models
+type ChatService struct {
+	client         *fal.Client
+	dbManager      *database.DBManager
+	bot            *kit.Bot
+	sender         *braibottypes.MessageSender
+	debug          bool
+	billingEnabled atomic.Bool // Toggled at runtime by !admin billing
+	log            slog.Logger
+}
+
+// NewChatService creates a new ChatService
+func NewChatService(client *fal.Client, dbManager *database.DBManager, bot *kit.Bot, debug bool, billingEnabled bool) *ChatService {
+	s := &ChatService{
+		client:    client,
+		dbManager: dbManager,
+		bot:       bot,
+		sender:    braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot)),
+		debug:     debug,
+		log:       joblog.New(nil, joblog.Chat),
+	}
+	s.billingEnabled.Store(billingEnabled)
+	return s
+}
+
+// SetBillingEnabled turns charging for chats on or off.
+func (s *ChatService) SetBillingEnabled(enabled bool) {
+	s.billingEnabled.Store(enabled)
+}
+
+// SetLogger sets the logger the service logs its jobs to.
+func (s *ChatService) SetLogger(l slog.Logger) {
+	s.log = l
+}
+
+// Chat sends the request's message with the user's conversation so far to
+// the language model, sends the reply in parts as it is written, remembers
+// both and bills the tokens used.
+func (s *ChatService) Chat(ctx context.Context, req *ChatRequest) (*ChatResult, error) {
+	if strings.TrimSpace(req.Message) == "" {
+		err := fmt.Errorf("message is required")
+		return &ChatResult{Success: false, Error: err}, err
+	}
+	model, ok := faladapter.GetModel(req.ModelName, "text2text")
+	if !ok {
+		err := fmt.Errorf("model not found: %s", req.ModelName)
+		return &ChatResult{Success: false, Error: err}, err
+	}
+	uid := req.UserID.String()
+	history, err := s.dbManager.ChatHistory(uid)
+	if err != nil {
+		return &ChatResult{Success: false, Error: err}, err
+	}
+	prompt := BuildPrompt(history, req.Message)
+	jobevents.Default.Submit(&req.GenerationRequest)
+
+	// 1. CHECK balance against the prompt and a reply of typical length
+	req.PriceUSD = faladapter.PriceFor(model, faladapter.PriceParams{Tokens: EstimateTokens(systemPrompt+prompt) + replyTokensEstimate})
+	var currentBalanceDCR float64
+	if s.billingEnabled.Load() {
+		var checkErr error
+		_, currentBalanceDCR, checkErr = utils.CheckRequestBalance(ctx, s.dbManager, &req.GenerationRequest, req.PriceUSD, s.debug, s.billingEnabled.Load())
+		if checkErr != nil {
+			jobevents.Default.EmitFailed(&req.GenerationRequest, checkErr)
+			return &ChatResult{Success: false, Error: checkErr}, checkErr
+		}
+	}
+
+	// 2. Ask the model, sending finished paragraphs while it writes. Chats
+	// are short, so they do not wait for a slot of the fal job queue
+	c := &chunker{send: func(part string, first bool) {
+		s.sendPart(ctx, req, part, first)
+	}}
+	resp, genErr := s.client.Chat(ctx, &fal.ChatRequest{
+		Prompt:       prompt,
+		SystemPrompt: systemPrompt,
+		Progress:     req.Progress,
+	}, c.feed)
+	if genErr != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, genErr)
+		return &ChatResult{Success: false, Error: genErr}, genErr
+	}
+	c.flush(resp.Output)
+	jobevents.Default.EmitDelivered(&req.GenerationRequest, 1)
+
+	now := time.Now()
+	err = s.dbManager.AddChatMessages(uid, []database.ChatMessage{
+		{Role: database.ChatRoleUser, Content: req.Message, CreatedAt: now},
+		{Role: database.ChatRoleAssistant, Content: resp.Output, CreatedAt: now},
+	}, HistoryKept)
+	if err != nil {
+		joblog.For(s.log, &req.GenerationRequest).Warnf("Failed to remember the conversation: %v", err)
+	}
+
+	// 3. Bill the tokens the model reports, or an estimate of them
+	tokens := EstimateTokens(systemPrompt+prompt) + EstimateTokens(resp.Output)
+	if resp.Usage != nil && resp.Usage.PromptTokens+resp.Usage.CompletionTokens > 0 {
+		tokens = resp.Usage.PromptTokens + resp.Usage.CompletionTokens
+	}
+	req.PriceUSD = faladapter.PriceFor(model, faladapter.PriceParams{Tokens: tokens})
+	var chargedDCR float64
+	var finalBalanceDCR float64 = currentBalanceDCR
+	var billingSucceeded bool
+	var splitCharge *utils.SplitCharge
+	if s.billingEnabled.Load() {
+		deductChargedDCR, deductNewBalance, deductSplit, deductErr := utils.DeductRequestBalance(ctx, s.dbManager, &req.GenerationRequest, req.PriceUSD, s.debug, s.billingEnabled.Load())
+		if deductErr != nil {
+			if req.IsPM {
+				s.sender.SendMessage(ctx, req.MessageContext(), fmt.Sprintf("Error processing payment after sending the reply: %v. Please contact support.", deductErr))
+			}
+		} else {
+			billingSucceeded = true
+			chargedDCR = deductChargedDCR
+			finalBalanceDCR = deductNewBalance
+			splitCharge = deductSplit
+			jobevents.Default.EmitBilled(&req.GenerationRequest, chargedDCR)
+		}
+	}
+
+	joblog.For(s.log, &req.GenerationRequest).Infof("Replied with %d tokens in total, charged %.8f DCR", tokens, chargedDCR)
+
+	// 4. Send the billing confirmation
+	if req.IsPM && s.billingEnabled.Load() {
+		s.sender.SendMessage(ctx, req.MessageContext(), fmt.Sprintf("%d tokens. ", tokens)+
+			utils.FormatBillingConfirmation("reply", true, true, billingSucceeded, chargedDCR, req.PriceUSD, finalBalanceDCR)+utils.LowBalanceWarning(s.dbManager, req.UserID.String(), finalBalanceDCR, chargedDCR, req.PriceUSD))
+	} else if splitCharge != nil {
+		// The group sees the pot's share; the requester's share and balance
+		// go by PM
+		s.sender.SendMessage(ctx, req.MessageContext(), utils.FormatSplitPotReceipt(splitCharge))
+		s.sender.SendPrivateMessage(ctx, req.MessageContext(), utils.FormatSplitBillingConfirmation(splitCharge, req.PriceUSD)+utils.LowBalanceWarning(s.dbManager, req.UserID.String(), splitCharge.UserBalanceDCR, splitCharge.UserDCR+splitCharge.PotDCR, req.PriceUSD))
+	}
+
+	return &ChatResult{Reply: resp.Output, Tokens: tokens, Success: true}, nil
+}
+
+// sendPart sends a part of a reply to the PM or GC the request came from.
+// In a GC the first part names the user it answers.
+func (s *ChatService) sendPart(ctx context.Context, req *ChatRequest, part string, first bool) {
+	msg := utils.SanitizeUserText(part)
+	if first {
+		msg = "💬 " + msg
+		if !req.IsPM {
+			msg = fmt.Sprintf("%s, %s", utils.SanitizeUserText(req.UserNick), msg)
+		}
+	}
+	s.sender.SendMessage(ctx, req.MessageContext(), msg)
+}
+
+// Forget clears the conversation of uid and returns the number of messages
+// it had.
+func (s *ChatService) Forget(uid string) (int, error) {
+	return s.dbManager.ClearChatHistory(uid)
+}
+
+// BuildPrompt writes a conversation and the user's new message as the
+// transcript the model continues.
+func BuildPrompt(history []database.ChatMessage, message string) string {
+	var b strings.Builder
+	for _, m := range history {
+		if m.Role == database.ChatRoleAssistant {
+			b.WriteString("Assistant: ")
+		} else {
+			b.WriteString("User: ")
+		}
+		b.WriteString(m.Content)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("User: ")
+	b.WriteString(message)
+	b.WriteString("\n\nAssistant:")
+	return b.String()
+}
+
+// EstimateTokens estimates the tokens of text at four characters each, for
+// models that do not report their usage.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// chunker sends a streamed reply in parts: paragraphs as soon as enough of
+// them are written, and whatever is left once the reply is complete.
+type chunker struct {
+	sent  int // Bytes of the reply sent so far
+	parts int
+	send  func(part string, first bool)
+}
+
+// feed takes the reply written so far and sends the paragraphs finished
+// since the last part, once they are long enough to stand on their own.
+func (c *chunker) feed(output string) {
+	if len(output) <= c.sent {
+		return
+	}
+	pending := output[c.sent:]
+	end := strings.LastIndex(pending, "\n\n")
+	if end >= 0 && utf8.RuneCountInString(pending[:end]) >= minChunkRunes {
+		c.emit(pending[:end])
+		c.sent += end + 2
+		return
+	}
+	// Cut replies without paragraph breaks at a space
+	if utf8.RuneCountInString(pending) > maxChunkRunes {
+		cut := strings.LastIndex(pending[:len(pending)*maxChunkRunes/utf8.RuneCountInString(pending)], " ")
+		if cut > 0 {
+			c.emit(pending[:cut])
+			c.sent += cut + 1
+		}
+	}
+}
+
+// flush sends what is left of the complete reply.
+func (c *chunker) flush(output string) {
+	for len(output) > c.sent && utf8.RuneCountInString(output[c.sent:]) > maxChunkRunes {
+		before := c.sent
+		c.feed(output)
+		if c.sent == before {
+			break
+		}
+	}
+	if len(output) > c.sent {
+		c.emit(output[c.sent:])
+		c.sent = len(output)
+	}
+	if c.parts == 0 {
+		c.emit("(no reply)")
+	}
+}
+
+func (c *chunker) emit(part string) {
+	if part = strings.TrimSpace(part); part == "" {
+		return
+	}
+	c.send(part, c.parts == 0)
+	c.parts++
+}
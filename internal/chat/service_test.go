@@ -0,0 +1,65 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/karamble/braibot/internal/database"
+)
+
+func TestBuildPrompt(t *testing.T) {
+	history := []database.ChatMessage{
+		{Role: database.ChatRoleUser, Content: "Hi"},
+		{Role: database.ChatRoleAssistant, Content: "Hello! How can I help?"},
+	}
+	want := "User: Hi\n\nAssistant: Hello! How can I help?\n\nUser: What is DCR?\n\nAssistant:"
+	if got := BuildPrompt(history, "What is DCR?"); got != want {
+		t.Errorf("BuildPrompt = %q, want %q", got, want)
+	}
+}
+
+func TestChunker(t *testing.T) {
+	var parts []string
+	var firsts []bool
+	c := &chunker{send: func(part string, first bool) {
+		parts = append(parts, part)
+		firsts = append(firsts, first)
+	}}
+	long := strings.Repeat("word ", minChunkRunes/5+1)
+	reply := long + "\n\nshort tail"
+
+	// A short paragraph waits for more text
+	c.feed("Short.\n\nMore")
+	if len(parts) != 0 {
+		t.Fatalf("sent %q too early", parts)
+	}
+	c.feed(long)
+	c.feed(long + "\n\nshort")
+	if len(parts) != 1 || parts[0] != strings.TrimSpace(long) || !firsts[0] {
+		t.Fatalf("parts = %q after a finished paragraph", parts)
+	}
+	c.flush(reply)
+	if len(parts) != 2 || parts[1] != "short tail" || firsts[1] {
+		t.Fatalf("parts = %q after flush", parts)
+	}
+
+	// Replies without paragraph breaks are cut at a space
+	parts = nil
+	c = &chunker{send: func(part string, first bool) { parts = append(parts, part) }}
+	c.flush(strings.Repeat("abc ", maxChunkRunes))
+	if len(parts) < 2 {
+		t.Fatalf("sent a %d rune reply as %d parts", 4*maxChunkRunes, len(parts))
+	}
+	for _, p := range parts {
+		if len([]rune(p)) > maxChunkRunes {
+			t.Errorf("part of %d runes", len([]rune(p)))
+		}
+	}
+
+	parts = nil
+	c = &chunker{send: func(part string, first bool) { parts = append(parts, part) }}
+	c.flush("")
+	if len(parts) != 1 || parts[0] != "(no reply)" {
+		t.Errorf("empty reply sent as %q", parts)
+	}
+}
@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/pkg/fal"
+	"github.com/vctt94/bisonbotkit/config"
+)
+
+// AboutInfo is the bot's identity card, as sent by !about --json so other
+// tools and bots can discover what it offers.
+type AboutInfo struct {
+	Name       string          `json:"name"`
+	Version    string          `json:"version"`
+	Commit     string          `json:"commit,omitempty"`
+	Operator   string          `json:"operator,omitempty"`
+	Subsystems AboutSubsystems `json:"subsystems"`
+	Models     map[string]int  `json:"models"`
+	ModelCount int             `json:"modelCount"`
+	Commands   []string        `json:"commands"`
+}
+
+// AboutSubsystems reports which optional parts of the bot are enabled.
+type AboutSubsystems struct {
+	Billing bool `json:"billing"`
+	Webhook bool `json:"webhook"`
+	MCP     bool `json:"mcp"`
+}
+
+// AboutCommand returns the about command, which describes the bot, its
+// enabled subsystems and the models it can run.
+func AboutCommand(registry *Registry, cfg *config.BotConfig) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "about",
+		Description: "🪪 Show the bot's version, features and operator. Usage: !about [--json]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			info := aboutInfo(registry, cfg.ExtraConfig)
+			if len(args) == 0 {
+				return sender.SendMessage(ctx, msgCtx, formatAbout(info))
+			}
+			if len(args) > 1 || args[0] != "--json" {
+				return sender.SendMessage(ctx, msgCtx, "Usage: !about [--json]")
+			}
+			data, err := json.Marshal(info)
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			return sender.SendMessage(ctx, msgCtx, string(data))
+		}),
+	}
+}
+
+// aboutInfo collects the bot's identity card from the registry, the
+// registered models and the operator settings in braibot.conf.
+func aboutInfo(registry *Registry, extra map[string]string) AboutInfo {
+	version, commit := buildVersion()
+	webhook, _ := registry.GetWebhookEnabled()
+	mcp := strings.ToLower(extra["mcpenabled"])
+
+	info := AboutInfo{
+		Name:     "braibot",
+		Version:  version,
+		Commit:   commit,
+		Operator: strings.TrimPrefix(strings.TrimSpace(extra["operatornick"]), "@"),
+		Subsystems: AboutSubsystems{
+			Billing: registry.GetBillingEnabled(),
+			Webhook: webhook,
+			MCP:     mcp == "1" || mcp == "true",
+		},
+		Models: fal.ModelCounts(),
+	}
+	for _, n := range info.Models {
+		info.ModelCount += n
+	}
+	for _, cmd := range registry.ListCommands() {
+		info.Commands = append(info.Commands, cmd.Name)
+	}
+	sort.Strings(info.Commands)
+	return info
+}
+
+// buildVersion returns the module version and VCS revision the binary was
+// built from, as far as the Go toolchain recorded them.
+func buildVersion() (version, commit string) {
+	version = "unknown"
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return version, ""
+	}
+	if bi.Main.Version != "" {
+		version = bi.Main.Version
+	}
+	modified := false
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			commit = s.Value
+			if len(commit) > 12 {
+				commit = commit[:12]
+			}
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if commit != "" && modified {
+		commit += "-dirty"
+	}
+	return version, commit
+}
+
+// formatAbout renders the identity card for chat.
+func formatAbout(info AboutInfo) string {
+	onOff := func(b bool) string {
+		if b {
+			return "on"
+		}
+		return "off"
+	}
+
+	msg := fmt.Sprintf("🪪 **BraiBot %s**", info.Version)
+	if info.Commit != "" {
+		msg += fmt.Sprintf(" (%s)", info.Commit)
+	}
+	msg += "\n"
+	if info.Operator != "" {
+		msg += fmt.Sprintf("Operator: @%s\n", utils.SanitizeUserText(info.Operator))
+	}
+
+	msg += "\n**Subsystems:**\n"
+	msg += fmt.Sprintf("• Billing: %s\n", onOff(info.Subsystems.Billing))
+	msg += fmt.Sprintf("• Webhook (!ai): %s\n", onOff(info.Subsystems.Webhook))
+	msg += fmt.Sprintf("• MCP service: %s\n", onOff(info.Subsystems.MCP))
+
+	types := make([]string, 0, len(info.Models))
+	for t := range info.Models {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	msg += fmt.Sprintf("\n**Models (%d):**\n", info.ModelCount)
+	for _, t := range types {
+		msg += fmt.Sprintf("• %s: %d\n", t, info.Models[t])
+	}
+
+	msg += fmt.Sprintf("\n%d commands, see !help. Use !about --json for a machine-readable version.", len(info.Commands))
+	return msg
+}
@@ -0,0 +1,703 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/catalog"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/debuglog"
+	"github.com/karamble/braibot/internal/image"
+	"github.com/karamble/braibot/internal/jobs"
+	"github.com/karamble/braibot/internal/money"
+	"github.com/karamble/braibot/internal/queue"
+	"github.com/karamble/braibot/internal/ratelimit"
+	"github.com/karamble/braibot/internal/transcribe"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/pkg/fal"
+	kit "github.com/vctt94/bisonbotkit"
+	"github.com/vctt94/bisonbotkit/config"
+)
+
+// adminHelp lists the admin subcommands.
+const adminHelp = "Usage: !admin [subcommand]\n\n" +
+	"Subcommands:\n" +
+	"• dumpcommands: Machine-readable JSON of all commands, their models and flags\n" +
+	"• limits: Show the concurrency limit, running and queued jobs per job kind\n" +
+	"• setlimit [video|image|speech] [n]: Change a concurrency limit (0 = unlimited)\n" +
+	"• ratelimit [user|global] [n/duration]: Change how often generations may start, e.g. 5/1m (0/1m = unlimited)\n" +
+	"• leaderboard [gc] [on|off]: Opt a group chat in to or out of !leaderboard\n" +
+	"• nsfw [gc] [allow|warn|pm|block|default]: List the NSFW policies of group chats, or set one\n" +
+	"• gc [gc] [on|off|commands cmd,...|budget usd|delivery gc|pm|transcribe on|off|reset]: List group chat settings, or restrict the bot in one\n" +
+	"• debug [subsystem|all] [on|off]: Toggle debug logging of fal, billing, dispatch, delivery or db\n" +
+	"• credit [uid] [dcr]: Add to a user's balance\n" +
+	"• debit [uid] [dcr]: Subtract from a user's balance\n" +
+	"• topspenders [days]: Users with the highest charges (default: last 30 days)\n" +
+	"• users: How many unique users messaged the bot, in total and recently\n" +
+	"• spendlimit [uid] [daily|weekly|reset] [usd|default]: List users with spending limits of their own, or set one (0 = unlimited)\n" +
+	"• billing [on|off]: Turn charging for generations on or off\n" +
+	"• rate [set usd duration|clear]: Show the DCR/USD rate pricing uses, or override it for a while, e.g. set 25.50 24h\n" +
+	"• webhook [on|off]: Turn the !ai webhook on or off\n" +
+	"• broadcast [message]: Send an announcement to every user with a balance\n" +
+	"• refund [approve|deny] [request_id]: List pending refund requests, or decide one\n" +
+	"• promo [add code credit dcr|bonus percent [uses] [duration]|remove code]: List promo codes, add one (0 uses = unlimited) or remove one\n" +
+	"• models [reload]: Show the model catalog in effect, or fetch it again and apply its prices\n" +
+	"• raw [type] [endpoint] [json]: Send a request body to any fal endpoint, e.g. to try a new model (not billed)"
+
+// topSpendersSize is how many users !admin topspenders lists.
+const topSpendersSize = 10
+
+// AdminCommand returns the admin command. It is restricted to the user IDs
+// listed in the adminuids config key and only answers in private messages.
+func AdminCommand(registry *Registry, cfg *config.BotConfig, dbManager *database.DBManager, bot *kit.Bot, falClient *fal.Client) braibottypes.Command {
+	admins := adminUIDs(cfg)
+
+	return braibottypes.Command{
+		Name:        "admin",
+		Description: "🔧 Bot administration (admins only)",
+		Category:    "Admin",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if !msgCtx.IsPM || !admins[strings.ToLower(msgCtx.Sender.String())] {
+				return sender.SendMessage(ctx, msgCtx, "This command is restricted to bot admins.")
+			}
+			if len(args) == 0 {
+				return sender.SendMessage(ctx, msgCtx, adminHelp)
+			}
+
+			switch strings.ToLower(args[0]) {
+			case "dumpcommands":
+				data, err := DumpCommands(registry)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("failed to dump commands: %v", err))
+				}
+				return sender.SendMessage(ctx, msgCtx, "```json\n"+string(data)+"\n```")
+			case "limits":
+				return sender.SendMessage(ctx, msgCtx, formatQueueLimits())
+			case "setlimit":
+				if len(args) < 3 {
+					return sender.SendMessage(ctx, msgCtx, "Usage: !admin setlimit [video|image|speech] [n]")
+				}
+				kind := strings.ToLower(args[1])
+				if kind != queue.KindVideo && kind != queue.KindImage && kind != queue.KindSpeech {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Unknown job kind: %s (must be video, image or speech)", utils.SanitizeUserText(args[1])))
+				}
+				n, err := strconv.Atoi(args[2])
+				if err != nil || n < 0 {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid limit: %s", utils.SanitizeUserText(args[2])))
+				}
+				queue.Default.SetLimit(kind, n)
+				jobs.Default.SetKindLimit(kind, n)
+				return sender.SendMessage(ctx, msgCtx, "Limit updated.\n\n"+formatQueueLimits())
+			case "ratelimit":
+				if len(args) < 3 {
+					return sender.SendMessage(ctx, msgCtx, "Usage: !admin ratelimit [user|global] [n/duration]")
+				}
+				rate, err := ratelimit.ParseRate(args[2])
+				if err != nil {
+					return sender.SendMessage(ctx, msgCtx, utils.SanitizeUserText(err.Error()))
+				}
+				user, global := ratelimit.Default.Rates()
+				switch strings.ToLower(args[1]) {
+				case "user":
+					user = rate
+				case "global":
+					global = rate
+				default:
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Unknown rate limit: %s (must be user or global)", utils.SanitizeUserText(args[1])))
+				}
+				ratelimit.Default.SetRates(user, global)
+				return sender.SendMessage(ctx, msgCtx, "Rate limit updated.\n\n"+formatQueueLimits())
+			case "nsfw":
+				if len(args) < 3 {
+					return sender.SendMessage(ctx, msgCtx, formatNSFWPolicies(dbManager))
+				}
+				policy := strings.ToLower(args[2])
+				if policy == "default" {
+					policy = ""
+				} else if _, err := image.ParseNSFWAction(policy); err != nil {
+					return sender.SendMessage(ctx, msgCtx, utils.SanitizeUserText(err.Error()))
+				}
+				if err := dbManager.SetGCNSFWPolicy(args[1], policy); err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				return sender.SendMessage(ctx, msgCtx, "NSFW policy updated.\n\n"+formatNSFWPolicies(dbManager))
+			case "gc":
+				if len(args) < 2 {
+					return sender.SendMessage(ctx, msgCtx, formatGCSettingsList(dbManager))
+				}
+				settings, err := dbManager.GCSettings(args[1])
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				if len(args) < 3 {
+					return sender.SendMessage(ctx, msgCtx, formatGCSettings(settings))
+				}
+				if msg := applyGCSetting(registry, &settings, strings.ToLower(args[2]), args[3:]); msg != "" {
+					return sender.SendMessage(ctx, msgCtx, msg)
+				}
+				if err := dbManager.SetGCSettings(settings); err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				// Audio notes kept for !transcribe go with the permission
+				if !settings.Transcribe || settings.Disabled {
+					transcribe.DefaultGCNotes.Forget(settings.GC)
+				}
+				return sender.SendMessage(ctx, msgCtx, "Group chat settings updated.\n\n"+formatGCSettings(settings))
+			case "leaderboard":
+				if len(args) < 3 {
+					return sender.SendMessage(ctx, msgCtx, "Usage: !admin leaderboard [gc] [on|off]")
+				}
+				enabled, ok := parseOnOff(args[2])
+				if !ok {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid setting: %s (must be on or off)", utils.SanitizeUserText(args[2])))
+				}
+				if err := dbManager.SetLeaderboardEnabled(args[1], enabled); err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Leaderboard for %s turned %s.", utils.SanitizeUserText(args[1]), strings.ToLower(args[2])))
+			case "debug":
+				if len(args) < 3 {
+					return sender.SendMessage(ctx, msgCtx, "Usage: !admin debug [subsystem|all] [on|off]\n\nDebug logging: "+debuglog.Status())
+				}
+				on, ok := parseOnOff(args[2])
+				if !ok {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid setting: %s (must be on or off)", utils.SanitizeUserText(args[2])))
+				}
+				if strings.EqualFold(args[1], "all") {
+					debuglog.SetAll(on)
+				} else if err := debuglog.Set(args[1], on); err != nil {
+					return sender.SendMessage(ctx, msgCtx, utils.SanitizeUserText(err.Error()))
+				}
+				return sender.SendMessage(ctx, msgCtx, "Debug logging: "+debuglog.Status())
+			case "models":
+				if !catalog.Default.Configured() {
+					return sender.SendMessage(ctx, msgCtx, "No model catalog configured. Set modelcatalog= or modelcatalogoverrides= in braibot.conf.")
+				}
+				if len(args) < 2 {
+					return sender.SendMessage(ctx, msgCtx, catalog.Default.Last().String()+"\n\nUsage: !admin models reload")
+				}
+				if !strings.EqualFold(args[1], "reload") {
+					return sender.SendMessage(ctx, msgCtx, "Usage: !admin models reload")
+				}
+				sum, err := catalog.Default.Reload(ctx)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				return sender.SendMessage(ctx, msgCtx, sum.String())
+			case "raw":
+				if len(args) < 3 {
+					return sender.SendMessage(ctx, msgCtx, "Usage: !admin raw [type] [endpoint] [json]\nExample: !admin raw text2image /fal-ai/flux/dev {\"prompt\": \"a lighthouse\"}")
+				}
+				// Take the body from the raw message so its quotes survive
+				body := make(map[string]interface{})
+				if _, text, _ := strings.Cut(msgCtx.Message, args[2]); strings.TrimSpace(text) != "" {
+					if err := json.Unmarshal([]byte(text), &body); err != nil {
+						return sender.SendMessage(ctx, msgCtx, "Invalid JSON body: "+utils.SanitizeUserText(err.Error()))
+					}
+				}
+				req := &fal.GenericRequest{Endpoint: args[2], Type: strings.ToLower(args[1]), Body: body}
+				urls, err := sendRawRequest(ctx, falClient, req)
+				if err != nil {
+					return sender.SendMessage(ctx, msgCtx, "Raw request failed: "+utils.SanitizeUserText(err.Error()))
+				}
+				return sender.SendMessage(ctx, msgCtx, "Raw request done:\n"+strings.Join(urls, "\n"))
+			case "credit", "debit":
+				sub := strings.ToLower(args[0])
+				if len(args) < 3 {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Usage: !admin %s [uid] [dcr]", sub))
+				}
+				var uid zkidentity.ShortID
+				if err := uid.FromString(args[1]); err != nil {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid user id: %s", utils.SanitizeUserText(args[1])))
+				}
+				amountDCR, err := strconv.ParseFloat(args[2], 64)
+				if err != nil || amountDCR <= 0 {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid amount: %s", utils.SanitizeUserText(args[2])))
+				}
+				atoms, err := money.DCRToAtoms(amountDCR)
+				if err != nil || atoms <= 0 {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid amount: %s", utils.SanitizeUserText(args[2])))
+				}
+				if sub == "debit" {
+					atoms = -atoms
+				}
+				balance, err := dbManager.AdjustBalance(uid.String(), atoms, time.Now())
+				if err != nil {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Could not %s %s: %v", sub, uid, err))
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Balance of %s is now %.8f DCR.", uid, money.AtomsToDCR(balance)))
+			case "spendlimit":
+				if len(args) < 2 {
+					return sender.SendMessage(ctx, msgCtx, formatSpendLimitOverrides(dbManager))
+				}
+				var uid zkidentity.ShortID
+				if err := uid.FromString(args[1]); err != nil {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid user id: %s", utils.SanitizeUserText(args[1])))
+				}
+				o, err := dbManager.SpendLimitOverride(uid.String())
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				if len(args) == 3 && strings.EqualFold(args[2], "reset") {
+					o.DailyUSD, o.WeeklyUSD = nil, nil
+				} else if len(args) < 4 {
+					return sender.SendMessage(ctx, msgCtx, "Usage: !admin spendlimit [uid] [daily|weekly] [usd|default] or !admin spendlimit [uid] reset")
+				} else {
+					var limit *float64
+					if !strings.EqualFold(args[3], "default") {
+						usd, err := strconv.ParseFloat(strings.TrimPrefix(args[3], "$"), 64)
+						if err != nil || usd < 0 {
+							return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid limit: %s (must be a USD amount, 0 = unlimited, or default)", utils.SanitizeUserText(args[3])))
+						}
+						limit = &usd
+					}
+					switch strings.ToLower(args[2]) {
+					case "daily":
+						o.DailyUSD = limit
+					case "weekly":
+						o.WeeklyUSD = limit
+					default:
+						return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Unknown spending limit: %s (must be daily or weekly)", utils.SanitizeUserText(args[2])))
+					}
+				}
+				if err := dbManager.SetSpendLimitOverride(o); err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				a, err := utils.GetSpendAllowance(dbManager, o.UID, time.Now())
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Spending limits of %s updated.\n\n%s", o.UID, formatSpendAllowance(a)))
+			case "topspenders":
+				days := 30
+				if len(args) > 1 {
+					n, err := strconv.Atoi(args[1])
+					if err != nil || n <= 0 {
+						return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid number of days: %s", utils.SanitizeUserText(args[1])))
+					}
+					days = n
+				}
+				spenders, err := dbManager.TopSpenders(time.Now().AddDate(0, 0, -days), topSpendersSize)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				return sender.SendMessage(ctx, msgCtx, formatTopSpenders(spenders, days))
+			case "users":
+				// The zero time counts every user
+				var counts []int
+				for _, since := range append([]time.Time{{}}, userCountSince(time.Now())...) {
+					n, err := dbManager.CountContacts(since)
+					if err != nil {
+						return sender.SendErrorMessage(ctx, msgCtx, err)
+					}
+					counts = append(counts, n)
+				}
+				return sender.SendMessage(ctx, msgCtx, formatUserCounts(counts[0], counts[1:]))
+			case "rate":
+				if len(args) < 2 {
+					rate, err := utils.GetPricingRate()
+					if err != nil {
+						return sender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("failed to fetch DCR rates: %v", err))
+					}
+					return sender.SendMessage(ctx, msgCtx, "Usage: !admin rate [set usd duration|clear]\n\n"+formatRates(rate, 0, 0, 0))
+				}
+				switch strings.ToLower(args[1]) {
+				case "set":
+					if len(args) != 4 {
+						return sender.SendMessage(ctx, msgCtx, "Usage: !admin rate set [usd] [duration], e.g. !admin rate set 25.50 24h")
+					}
+					usd, err := strconv.ParseFloat(strings.TrimPrefix(args[2], "$"), 64)
+					if err != nil || usd <= 0 {
+						return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid rate: %s (must be USD per DCR above 0)", utils.SanitizeUserText(args[2])))
+					}
+					d, err := time.ParseDuration(args[3])
+					if err != nil || d <= 0 {
+						return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid duration: %s (e.g. 30m or 24h)", utils.SanitizeUserText(args[3])))
+					}
+					until := time.Now().Add(d)
+					utils.SetDCRRateOverride(usd, until)
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Pricing uses $%s USD per DCR until %s UTC, or until the bot restarts.",
+						utils.FormatUSDThousands(usd), until.UTC().Format("2006-01-02 15:04")))
+				case "clear":
+					utils.SetDCRRateOverride(0, time.Time{})
+					return sender.SendMessage(ctx, msgCtx, "Rate override cleared.")
+				}
+				return sender.SendMessage(ctx, msgCtx, "Usage: !admin rate [set usd duration|clear]")
+			case "billing", "webhook":
+				sub := strings.ToLower(args[0])
+				if len(args) < 2 {
+					enabled := registry.GetBillingEnabled()
+					if sub == "webhook" {
+						enabled, _ = registry.GetWebhookEnabled()
+					}
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Usage: !admin %s [on|off]\n\n%s is %s.", sub, sub, onOff(enabled)))
+				}
+				on, ok := parseOnOff(args[1])
+				if !ok {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid setting: %s (must be on or off)", utils.SanitizeUserText(args[1])))
+				}
+				if sub == "billing" {
+					registry.SetBillingEnabled(on)
+				} else {
+					registry.SetWebhookEnabled(on)
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("%s turned %s until the bot restarts.", sub, onOff(on)))
+			case "broadcast":
+				// Keep the announcement's formatting by taking it from the raw message
+				_, text, _ := strings.Cut(msgCtx.Message, args[0])
+				text = strings.TrimSpace(text)
+				if text == "" {
+					return sender.SendMessage(ctx, msgCtx, "Usage: !admin broadcast [message]")
+				}
+				sent, failed, err := broadcast(ctx, bot, dbManager, "📢 "+text)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Announcement sent to %d users (%d failed).", sent, failed))
+			case "refund":
+				if len(args) < 3 {
+					reqs, err := dbManager.ListRefundRequests(database.RefundPending)
+					if err != nil {
+						return sender.SendErrorMessage(ctx, msgCtx, err)
+					}
+					return sender.SendMessage(ctx, msgCtx, formatRefundRequests(reqs)+"\nUsage: !admin refund [approve|deny] [request_id]")
+				}
+				decision := strings.ToLower(args[1])
+				if decision != "approve" && decision != "deny" {
+					return sender.SendMessage(ctx, msgCtx, "Usage: !admin refund [approve|deny] [request_id]")
+				}
+				id, err := strconv.ParseInt(strings.TrimPrefix(args[2], "#"), 10, 64)
+				if err != nil || id <= 0 {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid refund request id: %s", utils.SanitizeUserText(args[2])))
+				}
+				req, err := dbManager.DecideRefund(id, decision == "approve", msgCtx.Sender.String(), time.Now())
+				if errors.Is(err, database.ErrRefundDecided) {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Refund request #%d was already %s.", id, req.Status))
+				}
+				if err != nil {
+					return sender.SendMessage(ctx, msgCtx, utils.SanitizeUserText(err.Error()))
+				}
+				notice := fmt.Sprintf("↩️ Your refund request for job #%d was denied.", req.JobID)
+				if req.Status == database.RefundApproved {
+					notice = fmt.Sprintf("↩️ Your refund request for job #%d was approved: %.8f DCR were credited back to your balance.", req.JobID, money.AtomsToDCR(req.Atoms))
+				}
+				if err := bot.SendPM(ctx, req.UID, notice); err != nil {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Refund request #%d %s, but the user could not be notified: %v", id, req.Status, err))
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Refund request #%d %s and the user notified.", id, req.Status))
+			case "promo":
+				return sender.SendMessage(ctx, msgCtx, adminPromo(dbManager, msgCtx.Sender.String(), args[1:], time.Now()))
+			default:
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Unknown admin subcommand: %s\n\n%s", utils.SanitizeUserText(args[0]), adminHelp))
+			}
+		}),
+	}
+}
+
+// adminUIDs returns the lowercased user ids listed in the adminuids config
+// key.
+func adminUIDs(cfg *config.BotConfig) map[string]bool {
+	admins := make(map[string]bool)
+	for _, uid := range strings.Split(cfg.ExtraConfig["adminuids"], ",") {
+		if uid = strings.TrimSpace(uid); uid != "" {
+			admins[strings.ToLower(uid)] = true
+		}
+	}
+	return admins
+}
+
+// sendRawRequest sends req with the Generate function for its type and
+// returns the URLs of the results.
+func sendRawRequest(ctx context.Context, client *fal.Client, req *fal.GenericRequest) ([]string, error) {
+	switch req.Type {
+	case "text2image", "image2image":
+		resp, err := client.GenerateImage(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		urls := make([]string, 0, len(resp.Images))
+		for _, img := range resp.Images {
+			urls = append(urls, img.URL)
+		}
+		return urls, nil
+	case "text2video", "image2video", "video2video", "multi2video":
+		resp, err := client.GenerateVideo(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return []string{resp.GetURL()}, nil
+	default:
+		resp, err := client.GenerateSpeech(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return []string{resp.AudioURL}, nil
+	}
+}
+
+// parseOnOff parses an on/off setting.
+func parseOnOff(s string) (on bool, ok bool) {
+	switch strings.ToLower(s) {
+	case "on":
+		return true, true
+	case "off":
+		return false, true
+	}
+	return false, false
+}
+
+// onOff formats a setting as on or off.
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// formatTopSpenders renders the top spenders as a table.
+func formatTopSpenders(spenders []database.Spender, days int) string {
+	if len(spenders) == 0 {
+		return fmt.Sprintf("No charges in the last %d days.", days)
+	}
+	msg := fmt.Sprintf("💸 **Top spenders, last %d days**\n\n| # | User | Jobs | Spent (DCR) |\n| - | ---- | ---- | ----------- |\n", days)
+	for i, s := range spenders {
+		msg += fmt.Sprintf("| %d | %s | %d | %.8f |\n", i+1, s.UID, s.Jobs, money.AtomsToDCR(s.Atoms))
+	}
+	return msg
+}
+
+// userCountPeriods are the periods !admin users counts new users over.
+var userCountPeriods = []struct {
+	label string
+	days  int
+}{{"24 hours", 1}, {"7 days", 7}, {"30 days", 30}}
+
+// userCountSince returns the start of each of userCountPeriods.
+func userCountSince(now time.Time) []time.Time {
+	since := make([]time.Time, len(userCountPeriods))
+	for i, p := range userCountPeriods {
+		since[i] = now.AddDate(0, 0, -p.days)
+	}
+	return since
+}
+
+// formatUserCounts formats the number of unique users and of the new ones
+// in each of userCountPeriods.
+func formatUserCounts(total int, recent []int) string {
+	msg := fmt.Sprintf("👥 **Unique users: %d**\n", total)
+	for i, n := range recent {
+		msg += fmt.Sprintf("\n• New in the last %s: %d", userCountPeriods[i].label, n)
+	}
+	return msg
+}
+
+// broadcast PMs text to every user with a balance, skipping users who muted
+// the bot.
+func broadcast(ctx context.Context, bot *kit.Bot, dbManager *database.DBManager, text string) (sent, failed int, err error) {
+	uids, err := dbManager.ListUserUIDs()
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, uid := range uids {
+		if muted, err := dbManager.GetMuted(uid); err == nil && muted {
+			continue
+		}
+		if err := bot.SendPM(ctx, uid, text); err != nil {
+			failed++
+			continue
+		}
+		sent++
+	}
+	return sent, failed, nil
+}
+
+// formatQueueLimits renders the job queue status as a table.
+// formatNSFWPolicies lists the group chats with an NSFW policy of their own.
+func formatNSFWPolicies(dbManager *database.DBManager) string {
+	policies, err := dbManager.GCNSFWPolicies()
+	if err != nil {
+		return fmt.Sprintf("Failed to list NSFW policies: %v", err)
+	}
+	if len(policies) == 0 {
+		return "No group chat has an NSFW policy of its own; all use nsfwgcpolicy= (default pm).\n\nUsage: !admin nsfw [gc] [allow|warn|pm|block|default]"
+	}
+	gcs := make([]string, 0, len(policies))
+	for gc := range policies {
+		gcs = append(gcs, gc)
+	}
+	sort.Strings(gcs)
+	msg := "| Group chat | NSFW policy |\n| ---------- | ----------- |\n"
+	for _, gc := range gcs {
+		msg += fmt.Sprintf("| %s | %s |\n", utils.SanitizeUserText(gc), policies[gc])
+	}
+	return msg
+}
+
+// formatSpendLimitOverrides renders the users with spending limits of their
+// own and the configured defaults.
+func formatSpendLimitOverrides(dbManager *database.DBManager) string {
+	list, err := dbManager.ListSpendLimitOverrides()
+	if err != nil {
+		return fmt.Sprintf("Failed to list spending limits: %v", err)
+	}
+	def := utils.DefaultSpendLimits()
+	msg := fmt.Sprintf("Default spending limits: %s per day, %s per week.\n\n", formatSpendLimit(&def.DailyUSD), formatSpendLimit(&def.WeeklyUSD))
+	if len(list) == 0 {
+		return msg + "No user has spending limits of their own.\n\nUsage: !admin spendlimit [uid] [daily|weekly] [usd|default]"
+	}
+	msg += "| User | Daily | Weekly |\n| ---- | ----- | ------ |\n"
+	for _, o := range list {
+		msg += fmt.Sprintf("| %s | %s | %s |\n", o.UID, formatSpendLimit(o.DailyUSD), formatSpendLimit(o.WeeklyUSD))
+	}
+	return msg
+}
+
+// formatSpendLimit formats a spending limit; nil is the default.
+func formatSpendLimit(usd *float64) string {
+	switch {
+	case usd == nil:
+		return "default"
+	case *usd == 0:
+		return "unlimited"
+	default:
+		return fmt.Sprintf("$%.2f", *usd)
+	}
+}
+
+// gcSettingsUsage is the usage of !admin gc.
+const gcSettingsUsage = "Usage: !admin gc [gc] [on|off|commands cmd,...|all|budget usd|delivery gc|pm|transcribe on|off|reset]"
+
+// applyGCSetting applies the !admin gc setting named by setting, with its
+// arguments, to s. It returns a message for the admin when the setting is
+// invalid, or "" when s was changed.
+func applyGCSetting(registry *Registry, s *database.GCSettings, setting string, args []string) string {
+	switch setting {
+	case "on", "off":
+		s.Disabled = setting == "off"
+	case "commands":
+		if len(args) == 0 {
+			return "Usage: !admin gc [gc] commands [cmd,...|all]"
+		}
+		list := strings.Join(args, ",")
+		if strings.EqualFold(list, "all") {
+			s.Commands = nil
+			break
+		}
+		var cmds []string
+		for _, c := range strings.Split(list, ",") {
+			c = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(c), "!"))
+			if c == "" {
+				continue
+			}
+			if _, ok := registry.Get(c); !ok {
+				return fmt.Sprintf("Unknown command: %s", utils.SanitizeUserText(c))
+			}
+			cmds = append(cmds, c)
+		}
+		if len(cmds) == 0 {
+			return "Usage: !admin gc [gc] commands [cmd,...|all]"
+		}
+		s.Commands = cmds
+	case "budget":
+		if len(args) == 0 {
+			return "Usage: !admin gc [gc] budget [usd] (0 = unlimited)"
+		}
+		usd, err := strconv.ParseFloat(strings.TrimPrefix(args[0], "$"), 64)
+		if err != nil || usd < 0 {
+			return fmt.Sprintf("Invalid budget: %s (must be a USD amount, 0 = unlimited)", utils.SanitizeUserText(args[0]))
+		}
+		s.DailyBudgetUSD = usd
+	case "delivery":
+		if len(args) == 0 {
+			return "Usage: !admin gc [gc] delivery [gc|pm]"
+		}
+		switch strings.ToLower(args[0]) {
+		case "gc":
+			s.DeliverPM = false
+		case "pm":
+			s.DeliverPM = true
+		default:
+			return fmt.Sprintf("Invalid delivery: %s (must be gc or pm)", utils.SanitizeUserText(args[0]))
+		}
+	case "transcribe":
+		if len(args) == 0 {
+			return "Usage: !admin gc [gc] transcribe [on|off]"
+		}
+		switch strings.ToLower(args[0]) {
+		case "on":
+			s.Transcribe = true
+		case "off":
+			s.Transcribe = false
+		default:
+			return fmt.Sprintf("Invalid transcribe setting: %s (must be on or off)", utils.SanitizeUserText(args[0]))
+		}
+	case "reset":
+		*s = database.GCSettings{GC: s.GC}
+	default:
+		return gcSettingsUsage
+	}
+	return ""
+}
+
+// formatGCSettings renders the settings of one GC.
+func formatGCSettings(s database.GCSettings) string {
+	commands, budget, delivery := gcSettingsColumns(s)
+	return fmt.Sprintf("Settings of %s:\n• Bot: %s\n• Commands: %s\n• Daily budget: %s\n• Results: %s\n• Transcribing audio notes: %s",
+		utils.SanitizeUserText(s.GC), onOff(!s.Disabled), commands, budget, delivery, onOff(s.Transcribe))
+}
+
+// formatGCSettingsList renders the GCs that have settings of their own.
+func formatGCSettingsList(dbManager *database.DBManager) string {
+	list, err := dbManager.ListGCSettings()
+	if err != nil {
+		return fmt.Sprintf("Failed to list group chat settings: %v", err)
+	}
+	if len(list) == 0 {
+		return "No group chat has settings of its own; the bot answers every command in all of them.\n\n" + gcSettingsUsage
+	}
+	msg := "| Group chat | Bot | Commands | Daily budget | Results | Transcribe |\n| ---------- | --- | -------- | ------------ | ------- | ---------- |\n"
+	for _, s := range list {
+		commands, budget, delivery := gcSettingsColumns(s)
+		msg += fmt.Sprintf("| %s | %s | %s | %s | %s | %s |\n", utils.SanitizeUserText(s.GC), onOff(!s.Disabled), commands, budget, delivery, onOff(s.Transcribe))
+	}
+	return msg
+}
+
+// gcSettingsColumns formats the commands, budget and delivery of s.
+func gcSettingsColumns(s database.GCSettings) (commands, budget, delivery string) {
+	commands = "all"
+	if len(s.Commands) > 0 {
+		commands = strings.Join(s.Commands, ", ")
+	}
+	budget = "unlimited"
+	if s.DailyBudgetUSD > 0 {
+		budget = fmt.Sprintf("$%.2f", s.DailyBudgetUSD)
+	}
+	delivery = "in the group chat"
+	if s.DeliverPM {
+		delivery = "by PM"
+	}
+	return commands, budget, delivery
+}
+
+func formatQueueLimits() string {
+	msg := "| Kind | Limit | Running | Queued |\n| ---- | ----- | ------- | ------ |\n"
+	for _, st := range queue.Default.Limits() {
+		limit := strconv.Itoa(st.Limit)
+		if st.Limit == 0 {
+			limit = "unlimited"
+		}
+		msg += fmt.Sprintf("| %s | %s | %d | %d |\n", st.Kind, limit, st.Running, st.Queued)
+	}
+	user, global := ratelimit.Default.Rates()
+	msg += fmt.Sprintf("\nRate limits: %s per user, %s overall", user, global)
+	return msg
+}
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
 
 	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
 	kit "github.com/vctt94/bisonbotkit"
 	botconfig "github.com/vctt94/bisonbotkit/config"
 )
@@ -22,8 +24,10 @@ type WebhookResponse struct {
 	IntermediateSteps []interface{} `json:"intermediateSteps"`
 }
 
-// AICommand returns the AI command that forwards messages to a webhook
-func AICommand(bot *kit.Bot, cfg *botconfig.BotConfig, debug bool) braibottypes.Command {
+// AICommand returns the AI command that forwards messages to a webhook. The
+// webhook can be toggled at runtime through the registry. Audio notes are
+// answered by voiceChat.
+func AICommand(registry *Registry, bot *kit.Bot, cfg *botconfig.BotConfig, voiceChat *VoiceChat, debug bool) braibottypes.Command {
 	return braibottypes.Command{
 		Name:        "ai",
 		Description: "🤖 Send a message to the AI for processing",
@@ -33,8 +37,7 @@ func AICommand(bot *kit.Bot, cfg *botconfig.BotConfig, debug bool) braibottypes.
 			msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
 
 			// Check if webhook is enabled
-			webhookEnabled, hasWebhookEnabled := cfg.ExtraConfig["webhookenabled"]
-			if !hasWebhookEnabled || webhookEnabled != "true" {
+			if webhookEnabled, _ := registry.GetWebhookEnabled(); !webhookEnabled {
 				return msgSender.SendMessage(ctx, msgCtx, "Webhook functionality is not enabled. Try again later.")
 			}
 
@@ -46,124 +49,138 @@ func AICommand(bot *kit.Bot, cfg *botconfig.BotConfig, debug bool) braibottypes.
 				return msgSender.SendMessage(ctx, msgCtx, "Webhook not properly configured. Try again later.")
 			}
 
-			// Get the full message content
-			fullMessage := msgCtx.Message
+			// Audio notes are answered with a spoken reply
+			if utils.IsAudioNote(msgCtx.Message) {
+				return voiceChat.Reply(ctx, msgCtx, webhookURL, webhookAPIKey)
+			}
 
-			// Create request body
-			requestBody := map[string]string{
-				"message": fullMessage,
-				"user":    msgCtx.Nick,
+			output, sessionID, err := askWebhook(ctx, webhookURL, webhookAPIKey, msgCtx.Message, msgCtx.Nick, debug)
+			if errors.Is(err, errNoWebhookOutput) {
+				return msgSender.SendMessage(ctx, msgCtx, "Unable to process your query: "+err.Error()+".")
 			}
-			jsonBody, err := json.Marshal(requestBody)
 			if err != nil {
-				return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("failed to marshal request body: %v", err))
+				return msgSender.SendErrorMessage(ctx, msgCtx, err)
 			}
 
-			// Create HTTP client with longer timeout
-			client := &http.Client{
-				Timeout: 120 * time.Second, // 120 second timeout (2 minutes)
+			// Send only the output field back to the appropriate channel based on the original message context
+			if msgCtx.IsPM {
+				return bot.SendPM(ctx, sessionID, output)
+			} else {
+				return bot.SendGC(ctx, msgCtx.GC, output)
 			}
+		}),
+	}
+}
 
-			// Create a context with timeout
-			ctx, cancel := context.WithTimeout(ctx, 120*time.Second)
-			defer cancel()
+// errNoWebhookOutput is returned by askWebhook when the webhook answered
+// without an output.
+var errNoWebhookOutput = errors.New("no output received")
+
+// askWebhook sends a message to the AI webhook and returns its output and
+// the session to reply to, which falls back to the user's nick.
+func askWebhook(ctx context.Context, webhookURL, webhookAPIKey, message, nick string, debug bool) (output, sessionID string, err error) {
+	// Create request body
+	requestBody := map[string]string{
+		"message": message,
+		"user":    nick,
+	}
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal request body: %v", err)
+	}
 
-			// Create request with context
-			req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewBuffer(jsonBody))
-			if err != nil {
-				return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("failed to create request: %v", err))
-			}
+	// Create HTTP client with longer timeout
+	client := &http.Client{
+		Timeout: 120 * time.Second, // 120 second timeout (2 minutes)
+	}
 
-			// Set headers
-			req.Header.Set("Content-Type", "application/json")
-			req.Header.Set("X-BRAIBOT-API-KEY", webhookAPIKey)
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 120*time.Second)
+	defer cancel()
 
-			if debug {
-				fmt.Printf("DEBUG [ai] User %s: Sending request to webhook\n", msgCtx.Nick)
-			}
+	// Create request with context
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create request: %v", err)
+	}
 
-			// Send request
-			resp, err := client.Do(req)
-			if err != nil {
-				return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("failed to send request to webhook: %v", err))
-			}
-			defer resp.Body.Close()
+	// Set headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-BRAIBOT-API-KEY", webhookAPIKey)
 
-			// Read response body
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("failed to read response body: %v", err))
-			}
+	if debug {
+		fmt.Printf("DEBUG [ai] User %s: Sending request to webhook\n", nick)
+	}
 
-			// Debug: Log the raw response
-			if debug {
-				fmt.Printf("DEBUG [ai] User %s: Webhook response body: %s\n", msgCtx.Nick, string(body))
-			}
+	// Send request
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to send request to webhook: %v", err)
+	}
+	defer resp.Body.Close()
 
-			// Check response status
-			if resp.StatusCode != http.StatusOK {
-				return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("webhook returned error status %d: %s", resp.StatusCode, string(body)))
-			}
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read response body: %v", err)
+	}
 
-			// Parse response as array of WebhookResponse
-			var responses []WebhookResponse
-			if err := json.Unmarshal(body, &responses); err != nil {
-				if debug {
-					fmt.Printf("DEBUG [ai] User %s: Failed to parse response as JSON: %v\n", msgCtx.Nick, err)
-				}
-				return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("failed to parse response as JSON: %v", err))
-			}
+	// Debug: Log the raw response
+	if debug {
+		fmt.Printf("DEBUG [ai] User %s: Webhook response body: %s\n", nick, string(body))
+	}
 
-			// Debug: Log the parsed responses
-			if debug {
-				fmt.Printf("DEBUG [ai] User %s: Number of responses: %d\n", msgCtx.Nick, len(responses))
-			}
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("webhook returned error status %d: %s", resp.StatusCode, string(body))
+	}
 
-			// Check if we have at least one response
-			if len(responses) == 0 {
-				return msgSender.SendMessage(ctx, msgCtx, "Unable to process your query: no response received.")
-			}
+	// Parse response as array of WebhookResponse
+	var responses []WebhookResponse
+	if err := json.Unmarshal(body, &responses); err != nil {
+		if debug {
+			fmt.Printf("DEBUG [ai] User %s: Failed to parse response as JSON: %v\n", nick, err)
+		}
+		return "", "", fmt.Errorf("failed to parse response as JSON: %v", err)
+	}
 
-			// Handle different response formats
-			var output string
-			var sessionID string
-			if len(responses) == 2 {
-				// Voice command format: second response contains the output
-				output = responses[1].Output
-				sessionID = responses[0].SessionID
-			} else {
-				// Text command format: first response contains the output
-				output = responses[0].Output
-				sessionID = responses[0].SessionID
-			}
+	// Debug: Log the parsed responses
+	if debug {
+		fmt.Printf("DEBUG [ai] User %s: Number of responses: %d\n", nick, len(responses))
+	}
 
-			// Validate output
-			if output == "" {
-				if debug {
-					fmt.Printf("DEBUG [ai] User %s: Missing output in response\n", msgCtx.Nick)
-				}
-				return msgSender.SendMessage(ctx, msgCtx, "Unable to process your query: no output received.")
-			}
+	// Check if we have at least one response
+	if len(responses) == 0 {
+		return "", "", fmt.Errorf("%w: no response received", errNoWebhookOutput)
+	}
 
-			// Validate session_id
-			if sessionID == "" {
-				if debug {
-					fmt.Printf("DEBUG [ai] User %s: Missing session_id in response\n", msgCtx.Nick)
-				}
-				// Fallback to original nick if session_id is missing
-				sessionID = msgCtx.Nick
-			}
+	// Handle different response formats
+	if len(responses) == 2 {
+		// Voice command format: second response contains the output
+		output = responses[1].Output
+		sessionID = responses[0].SessionID
+	} else {
+		// Text command format: first response contains the output
+		output = responses[0].Output
+		sessionID = responses[0].SessionID
+	}
 
-			if debug {
-				fmt.Printf("DEBUG [ai] User %s: Sending response output to session %s\n", msgCtx.Nick, sessionID)
-			}
+	// Validate output
+	if output == "" {
+		if debug {
+			fmt.Printf("DEBUG [ai] User %s: Missing output in response\n", nick)
+		}
+		return "", "", errNoWebhookOutput
+	}
 
-			// Send only the output field back to the appropriate channel based on the original message context
-			if msgCtx.IsPM {
-				return bot.SendPM(ctx, sessionID, output)
-			} else {
-				return bot.SendGC(ctx, msgCtx.GC, output)
-			}
-		}),
+	// Validate session_id
+	if sessionID == "" {
+		if debug {
+			fmt.Printf("DEBUG [ai] User %s: Missing session_id in response\n", nick)
+		}
+		// Fallback to original nick if session_id is missing
+		sessionID = nick
 	}
+
+	return output, sessionID, nil
 }
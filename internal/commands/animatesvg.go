@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	imgservice "github.com/karamble/braibot/internal/image"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+// animateSVGHelp documents the animate-svg command.
+const animateSVGHelp = "Usage: !animate-svg [svg_url|last]\n" +
+	"Example: !animate-svg https://example.com/logo.svg\n\n" +
+	"Turns an SVG logo into a looping draw-on animation: the outlines are traced, then the colors fade in. " +
+	"The GIF is rendered by the bot itself, so it is free.\n\n" +
+	"Parameters:\n" +
+	"• svg_url: URL of the SVG to animate\n" +
+	"• last: Animate the last SVG result the bot sent you, e.g. from star-vector\n\n" +
+	"Paths, basic shapes and solid colors are supported. Text and embedded images are left out, and gradients are drawn gray."
+
+// AnimateSVGCommand returns the animate-svg command
+func AnimateSVGCommand(bot *kit.Bot, imageService *imgservice.ImageService) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "animate-svg",
+		Description: "✏️ Animate an SVG logo into a draw-on GIF (free). Usage: !animate-svg [svg_url|last]",
+		Category:    "AI Generation",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			// Create a message sender using the adapter
+			msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
+
+			if len(args) < 1 {
+				return msgSender.SendMessage(ctx, msgCtx, animateSVGHelp)
+			}
+
+			var userID zkidentity.ShortID
+			userID.FromBytes(msgCtx.Uid)
+
+			svgURL := args[0]
+			if strings.EqualFold(svgURL, "last") {
+				last, ok := imageService.LastSVG(userID.String())
+				if !ok {
+					return msgSender.SendMessage(ctx, msgCtx, "No SVG result to animate yet. Generate one first or pass an SVG URL.")
+				}
+				svgURL = last
+			} else if parsedURL, err := url.Parse(svgURL); err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+				return msgSender.SendMessage(ctx, msgCtx, "Please provide a valid http:// or https:// URL for the SVG, or last.")
+			}
+
+			req := &imgservice.ImageRequest{
+				GenerationRequest: braibottypes.GenerationRequest{
+					ModelType: "image2image",
+					ModelName: "animate-svg",
+					UserNick:  msgCtx.Nick,
+					UserID:    userID,
+					IsPM:      msgCtx.IsPM,
+					GC:        msgCtx.GC,
+				},
+				ImageURL: svgURL,
+			}
+			err := imageService.AnimateSVG(ctx, req)
+			return utils.HandleServiceResultOrError(ctx, bot, msgCtx, "animate-svg", nil, err)
+		}),
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"fmt"
 
 	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/i18n"
+	"github.com/karamble/braibot/internal/money"
 	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
 )
 
 // BalanceCommand returns the balance command
-func BalanceCommand() braibottypes.Command {
+func BalanceCommand(dbManager *database.DBManager) braibottypes.Command {
 	return braibottypes.Command{
 		Name:        "balance",
 		Description: "💰 Show your current balance",
@@ -32,14 +35,14 @@ func BalanceCommand() braibottypes.Command {
 			}
 
 			// Convert atoms to DCR
-			balanceDCR := float64(balance) / 1e11
+			balanceDCR := money.AtomsToDCR(balance)
 
 			// Get current exchange rate for USD value
 			dcrPrice, _, err := utils.GetDCRPrice()
 			if err != nil {
 				// Log the error but continue, showing balance without USD value
 				fmt.Printf("ERROR [balance] Failed to get DCR price: %v\n", err)
-				balanceMsg := fmt.Sprintf("💰 Your Balance: %s DCR", utils.FormatThousands(balanceDCR))
+				balanceMsg := utils.UserText(dbManager, userIDStr, "balance", i18n.Args{"DCR": utils.FormatThousands(balanceDCR)})
 				return sender.SendMessage(ctx, msgCtx, balanceMsg)
 			}
 
@@ -47,9 +50,10 @@ func BalanceCommand() braibottypes.Command {
 			usdValue := balanceDCR * dcrPrice
 
 			// Format balance message with both DCR and USD values
-			balanceMsg := fmt.Sprintf("💰 Your Balance:\n• DCR: %s DCR\n• USD: $%s USD",
-				utils.FormatThousands(balanceDCR),
-				utils.FormatThousands(usdValue))
+			balanceMsg := utils.UserText(dbManager, userIDStr, "balance_usd", i18n.Args{
+				"DCR": utils.FormatThousands(balanceDCR),
+				"USD": utils.FormatThousands(usdValue),
+			})
 			return sender.SendMessage(ctx, msgCtx, balanceMsg)
 		}),
 	}
@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/jobs"
+	"github.com/karamble/braibot/internal/moderation"
+	"github.com/karamble/braibot/internal/money"
+	"github.com/karamble/braibot/internal/params"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// maxBatchPrompts is the most prompts a single !batch queues.
+const maxBatchPrompts = 20
+
+// batchCommands are the commands !batch runs prompts of.
+var batchCommands = []string{"text2image"}
+
+const batchUsage = "Usage: !batch text2image, followed by one prompt per line, or with a text file of prompts attached\n" +
+	"Each prompt becomes a separate job and may carry its own options, e.g.:\n" +
+	"!batch text2image\n" +
+	"a lighthouse at dusk --aspect 16:9\n" +
+	"a fox in the snow @noir"
+
+// batchPrompts returns the prompts of a !batch message: the lines of an
+// attached text file, or else the lines of the message after the command.
+// Empty lines are skipped. fromFile reports whether they came from a file.
+func batchPrompts(message string) (prompts []string, fromFile bool, err error) {
+	text := message
+	if start := strings.Index(message, "--embed["); start >= 0 {
+		end := strings.Index(message[start:], "]--")
+		if end < 0 {
+			return nil, false, fmt.Errorf("the attached file could not be read")
+		}
+		var typ, data string
+		for _, field := range strings.Split(message[start+len("--embed["):start+end], ",") {
+			key, value, _ := strings.Cut(field, "=")
+			switch key {
+			case "type":
+				typ = value
+			case "data":
+				data = value
+			}
+		}
+		if !strings.HasPrefix(typ, "text/") {
+			return nil, false, fmt.Errorf("attach the prompts as a text file, not %s", typ)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, false, fmt.Errorf("the attached file could not be read: %v", err)
+		}
+		text, fromFile = string(decoded), true
+	} else {
+		// Drop "!batch [command]"; a prompt may follow on the same line
+		first, rest, _ := strings.Cut(message, "\n")
+		text = rest
+		if fields := strings.Fields(first); len(fields) > 2 {
+			text = strings.Join(fields[2:], " ") + "\n" + rest
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			prompts = append(prompts, line)
+		}
+	}
+	return prompts, fromFile, nil
+}
+
+// batchRun follows the jobs of one !batch to report when all are done.
+type batchRun struct {
+	msgCtx    braibottypes.MessageContext
+	sender    *braibottypes.MessageSender
+	command   string
+	left      int
+	completed int
+	failed    int
+	canceled  int
+}
+
+// summary is the message sent once all jobs of the batch are done.
+func (b *batchRun) summary() string {
+	total := b.completed + b.failed + b.canceled
+	msg := fmt.Sprintf("📦 Your batch of %d !%s prompts is done: %d completed", total, b.command, b.completed)
+	if b.failed > 0 {
+		msg += fmt.Sprintf(", %d failed", b.failed)
+	}
+	if b.canceled > 0 {
+		msg += fmt.Sprintf(", %d cancelled", b.canceled)
+	}
+	return msg + "."
+}
+
+// batchTracker maps queued jobs to their batch.
+type batchTracker struct {
+	mu   sync.Mutex
+	runs map[int64]*batchRun
+}
+
+// done counts a finished job towards its batch and sends the summary after
+// the batch's last job.
+func (t *batchTracker) done(job database.QueuedJob, err error) {
+	t.mu.Lock()
+	run, ok := t.runs[job.ID]
+	if !ok {
+		t.mu.Unlock()
+		return
+	}
+	delete(t.runs, job.ID)
+	switch {
+	case err == nil:
+		run.completed++
+	case errors.Is(err, jobs.ErrCanceled), errors.Is(err, context.Canceled):
+		run.canceled++
+	default:
+		run.failed++
+	}
+	run.left--
+	last := run.left == 0
+	t.mu.Unlock()
+
+	if last {
+		if err := run.sender.SendMessage(context.Background(), run.msgCtx, run.summary()); err != nil {
+			fmt.Printf("WARN: Failed to send batch summary to %s: %v\n", run.msgCtx.Nick, err)
+		}
+	}
+}
+
+// BatchCommand returns the batch command, which queues a job for each of
+// several prompts and reports once all of them are done. The batch's jobs
+// are billed one by one like the command run on its own.
+func BatchCommand(registry *Registry, dbManager *database.DBManager) braibottypes.Command {
+	tracker := &batchTracker{runs: make(map[int64]*batchRun)}
+	jobs.Default.OnDone(tracker.done)
+
+	return braibottypes.Command{
+		Name:        "batch",
+		Description: "📦 Queue several prompts at once, one per line. Usage: !batch text2image [prompts...]",
+		Category:    "AI Generation",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if len(args) == 0 {
+				return sender.SendMessage(ctx, msgCtx, batchUsage)
+			}
+			command := strings.ToLower(args[0])
+			supported := false
+			for _, c := range batchCommands {
+				supported = supported || c == command
+			}
+			if !supported {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("!batch runs prompts of !%s.\n\n%s", strings.Join(batchCommands, ", !"), batchUsage))
+			}
+
+			prompts, fromFile, err := batchPrompts(msgCtx.Message)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+			switch {
+			case len(prompts) == 0:
+				return sender.SendMessage(ctx, msgCtx, batchUsage)
+			case len(prompts) > maxBatchPrompts:
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("A batch can have up to %d prompts, not %d.", maxBatchPrompts, len(prompts)))
+			}
+			// Prompts typed in the message were screened with it; a file's
+			// were not
+			if fromFile && moderation.Default != nil {
+				var rejection *moderation.Rejection
+				if err := moderation.Default.Check(ctx, strings.Join(prompts, "\n")); errors.As(err, &rejection) {
+					return sender.SendMessage(ctx, msgCtx, "The attached prompts were rejected by moderation.")
+				} else if err != nil {
+					fmt.Printf("WARN: Failed to moderate the batch file of %s: %v\n", msgCtx.Nick, err)
+					return sender.SendMessage(ctx, msgCtx, "The attached prompts could not be checked right now. Please try again later.")
+				}
+			}
+
+			var userIDStr string
+			if msgCtx.IsPM {
+				var uid zkidentity.ShortID
+				uid.FromBytes(msgCtx.Uid)
+				userIDStr = uid.String()
+			}
+			model, exists := faladapter.GetCurrentModel(command, userIDStr)
+			if !exists {
+				return sender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("no default model found for %s", command))
+			}
+
+			// Check every prompt before queueing any, and add up the cost
+			uid := msgCtx.Sender.String()
+			var totalUSD float64
+			batch := make([]database.QueuedJob, 0, len(prompts))
+			for i, prompt := range prompts {
+				promptArgs, err := ExpandSavedPrompts(dbManager, uid, params.Split(prompt))
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				parseArgs, _, err := extractSharedFlags(promptArgs, msgCtx.IsPM)
+				if err != nil {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Prompt %d: %s", i+1, utils.SanitizeUserText(err.Error())))
+				}
+				_, req, err := parseTextImageArgs(parseArgs)
+				if err == nil && req.Preview {
+					err = fmt.Errorf("previews cannot be batched")
+				}
+				if err != nil {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Prompt %d: %s", i+1, utils.SanitizeUserText(err.Error())))
+				}
+				totalUSD += faladapter.PriceFor(model, faladapter.PriceParams{NumImages: req.NumImages})
+				batch = append(batch, database.QueuedJob{
+					UID:     uid,
+					Nick:    msgCtx.Nick,
+					Command: command,
+					Args:    promptArgs,
+					IsPM:    msgCtx.IsPM,
+					GC:      msgCtx.GC,
+				})
+			}
+
+			confirm := fmt.Sprintf("📦 %d prompts for !%s with %s, $%.2f USD in total", len(batch), command, model.Name, totalUSD)
+			if registry.GetBillingEnabled() {
+				totalDCR, err := utils.USDToDCR(totalUSD)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				balance, err := dbManager.GetBalance(uid)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				if money.AtomsToDCR(balance) < totalDCR {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("The batch costs %.8f DCR ($%.2f USD) but your balance is %.8f DCR. Top up or send fewer prompts.",
+						totalDCR, totalUSD, money.AtomsToDCR(balance)))
+				}
+				confirm += fmt.Sprintf(" (%.8f DCR). Each job is billed when it is delivered", totalDCR)
+			}
+
+			// Hold the tracker until the jobs are registered, so a job
+			// finishing right away is still counted
+			tracker.mu.Lock()
+			statuses, err := jobs.Default.SubmitBatch(ctx, batch)
+			run := &batchRun{msgCtx: msgCtx, sender: sender, command: command, left: len(statuses)}
+			for _, st := range statuses {
+				tracker.runs[st.Job.ID] = run
+			}
+			tracker.mu.Unlock()
+			var limitErr *jobs.ErrUserLimit
+			switch {
+			case errors.As(err, &limitErr):
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("The batch does not fit your queue: %v", err))
+			case err != nil && len(statuses) == 0:
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			case err != nil:
+				fmt.Printf("WARN: Queued %d of %d batch prompts of %s: %v\n", len(statuses), len(batch), msgCtx.Nick, err)
+			}
+			return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("%s.\nQueued as jobs #%d to #%d; you get a summary once all are done. See !queue for progress.",
+				confirm, statuses[0].Job.ID, statuses[len(statuses)-1].Job.ID))
+		}),
+	}
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/karamble/braibot/internal/jobs"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// CancelCommand returns the cancel command, which aborts one of the user's
+// queued or running generations.
+func CancelCommand() braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "cancel",
+		Description: "🛑 Cancel one of your queued or running generations. Usage: !cancel [job_id]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if len(args) == 0 {
+				return sender.SendMessage(ctx, msgCtx, "Usage: !cancel [job_id]\n\nUse !queue to see the ids of your pending generations.")
+			}
+			jobID, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+			if err != nil || jobID <= 0 {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid job id: %s", utils.SanitizeUserText(args[0])))
+			}
+
+			job, running, err := jobs.Default.Cancel(msgCtx.Sender.String(), jobID)
+			if errors.Is(err, jobs.ErrJobNotFound) {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Job #%d is not queued or running. Use !queue to see your pending generations.", jobID))
+			}
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+
+			msg := fmt.Sprintf("🛑 Job #%d (!%s) was removed from the queue. You were not charged.", job.ID, job.Command)
+			if running {
+				msg = fmt.Sprintf("🛑 Job #%d (!%s) was cancelled. You are only charged if its result was already delivered.", job.ID, job.Command)
+			}
+			// Tell the chat the job was requested in, too
+			if job.IsPM != msgCtx.IsPM || !strings.EqualFold(job.GC, msgCtx.GC) {
+				origin := msgCtx
+				origin.IsPM, origin.GC = job.IsPM, job.GC
+				if err := sender.SendMessage(ctx, origin, msg); err != nil {
+					fmt.Printf("WARN: Failed to send cancellation of job %d to its chat: %v\n", job.ID, err)
+				}
+			}
+			return sender.SendMessage(ctx, msgCtx, msg)
+		}),
+	}
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/chat"
+	"github.com/karamble/braibot/internal/faladapter"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+// chatUsage documents the chat command.
+var chatUsage = fmt.Sprintf("Usage: !chat [message]\n"+
+	"Example: !chat explain proof of stake in two sentences\n\n"+
+	"The bot remembers the last %d messages of your conversation, so you can ask follow-up questions. "+
+	"Send !chat reset to start a new conversation.\n"+
+	"You are charged for the tokens of the conversation sent along and of the reply.", chat.HistoryKept)
+
+// ChatCommand returns the chat command, which talks with a language model
+// hosted on fal.
+func ChatCommand(bot *kit.Bot, chatService *chat.ChatService) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "chat",
+		Description: "💬 Chat with a language model that remembers your conversation. Usage: !chat [message] or !chat reset",
+		Category:    "AI Generation",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			var userID zkidentity.ShortID
+			userID.FromBytes(msgCtx.Uid)
+			var userIDStr string
+			if msgCtx.IsPM {
+				userIDStr = userID.String()
+			}
+			model, exists := faladapter.GetCurrentModel("text2text", userIDStr)
+			if !exists {
+				return sender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("no default model found for text2text"))
+			}
+			if len(args) == 0 {
+				header := utils.FormatCommandHelpHeader("chat", model, userID, msgCtx.IsPM, db)
+				return sender.SendMessage(ctx, msgCtx, header+chatUsage)
+			}
+			if len(args) == 1 && strings.EqualFold(args[0], "reset") {
+				n, err := chatService.Forget(msgCtx.Sender.String())
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				if n == 0 {
+					return sender.SendMessage(ctx, msgCtx, "You have no conversation to reset.")
+				}
+				return sender.SendMessage(ctx, msgCtx, "💬 Conversation reset. Your next !chat starts a new one.")
+			}
+
+			args, shared, err := extractSharedFlags(args, msgCtx.IsPM)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "chat")
+			req := &chat.ChatRequest{
+				GenerationRequest: braibottypes.GenerationRequest{
+					ModelType:    "text2text",
+					ModelName:    model.Name,
+					Progress:     progress,
+					UserNick:     msgCtx.Nick,
+					UserID:       userID,
+					IsPM:         msgCtx.IsPM,
+					GC:           msgCtx.GC,
+					SplitPercent: shared.SplitPercent,
+				},
+				Message: strings.Join(args, " "),
+			}
+			result, err := chatService.Chat(ctx, req)
+			return utils.HandleServiceResultOrError(ctx, bot, msgCtx, "chat", result, err)
+		}),
+	}
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/speech"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	kit "github.com/vctt94/bisonbotkit"
+	botconfig "github.com/vctt94/bisonbotkit/config"
+)
+
+// cleanAudioModel is the audio2audio model used by !cleanaudio.
+const cleanAudioModel = "elevenlabs-audio-isolation"
+
+// CleanAudioCommand returns the cleanaudio command
+func CleanAudioCommand(bot *kit.Bot, cfg *botconfig.BotConfig, speechService *speech.SpeechService, dbManager *database.DBManager, debug bool) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "cleanaudio",
+		Description: "🎧 Isolate voices and remove background noise. Usage: !cleanaudio [audio_url] or attach an audio note",
+		Category:    "AI Generation",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			// Create a message sender using the adapter
+			msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
+
+			// Swap "last" for the user's recent results
+			args, lastErr := resolveLastArgs(dbManager, msgCtx.Sender.String(), args, database.ResultAudio)
+			if lastErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(lastErr.Error()))
+			}
+
+			model, exists := faladapter.GetModel(cleanAudioModel, "audio2audio")
+			if !exists {
+				return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("model not found: %s", cleanAudioModel))
+			}
+
+			var userID zkidentity.ShortID
+			userID.FromBytes(msgCtx.Uid)
+
+			// An audio note attached to the command takes precedence over a URL argument
+			var audioURL string
+			if utils.IsAudioNote(msgCtx.Message) {
+				audioData, err := utils.ExtractAudioNoteData(msgCtx.Message)
+				if err != nil {
+					return msgSender.SendMessage(ctx, msgCtx, "Sorry, I couldn't read the attached audio note. Please try again.")
+				}
+				audioURL = "data:audio/ogg;base64," + audioData
+			} else if len(args) > 0 && !strings.HasPrefix(args[0], "--") {
+				audioURL = args[0]
+			}
+
+			if audioURL == "" {
+				header := utils.FormatCommandHelpHeader("cleanaudio", model, userID, msgCtx.IsPM, db)
+				helpDoc := model.HelpDoc
+				if helpDoc == "" {
+					helpDoc = "Usage: !cleanaudio [audio_url]\n(No specific documentation available for this model.)"
+				}
+				return msgSender.SendMessage(ctx, msgCtx, header+helpDoc)
+			}
+
+			// Create progress callback
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "cleanaudio")
+
+			req := &speech.CleanAudioRequest{
+				GenerationRequest: braibottypes.GenerationRequest{
+					ModelType: "audio2audio",
+					ModelName: model.Name,
+					Progress:  progress,
+					UserNick:  msgCtx.Nick,
+					UserID:    userID,
+					PriceUSD:  faladapter.PriceFor(model, faladapter.PriceParams{}),
+					IsPM:      msgCtx.IsPM,
+					GC:        msgCtx.GC,
+					AssetLink: prefersAssetLink(cfg, "cleanaudio"),
+				},
+				AudioURL: audioURL,
+			}
+
+			result, err := speechService.CleanAudio(ctx, req)
+
+			// Handle result/error using the utility function
+			if handleErr := utils.HandleServiceResultOrError(ctx, bot, msgCtx, "cleanaudio", result, err); handleErr != nil {
+				return handleErr
+			}
+
+			return nil
+		}),
+	}
+}
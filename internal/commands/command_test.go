@@ -2,12 +2,26 @@ package commands
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	imgservice "github.com/karamble/braibot/internal/image"
+	"github.com/karamble/braibot/internal/jobs"
+	"github.com/karamble/braibot/internal/model3d"
+	"github.com/karamble/braibot/internal/topup"
 	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/internal/video"
+	"github.com/karamble/braibot/pkg/fal"
+	"github.com/vctt94/bisonbotkit/config"
 )
 
 // MockBot implements BotInterface for testing
@@ -15,6 +29,7 @@ type MockBot struct {
 	lastPM        string
 	lastGC        string
 	lastGCChannel string
+	lastFile      string
 	lastError     error
 }
 
@@ -34,6 +49,11 @@ func (m *MockBot) SendGCMessage(ctx context.Context, gc string, channel string,
 	return m.lastError
 }
 
+func (m *MockBot) SendFile(ctx context.Context, uid zkidentity.ShortID, path string) error {
+	m.lastFile = path
+	return m.lastError
+}
+
 // MockDBManager implements DBManagerInterface for testing
 type MockDBManager struct {
 	balance int64
@@ -113,7 +133,7 @@ func TestCommandHandlers(t *testing.T) {
 		},
 		{
 			name:    "Balance Command - Success",
-			command: BalanceCommand(),
+			command: BalanceCommand(nil),
 			args:    []string{},
 			ctx: braibottypes.MessageContext{
 				Nick:    "testuser",
@@ -127,7 +147,7 @@ func TestCommandHandlers(t *testing.T) {
 		},
 		{
 			name:    "Balance Command - DB Error",
-			command: BalanceCommand(),
+			command: BalanceCommand(nil),
 			args:    []string{},
 			ctx: braibottypes.MessageContext{
 				Nick:    "testuser",
@@ -184,7 +204,7 @@ func TestCommandHandlers(t *testing.T) {
 		},
 		{
 			name:    "Set Model Command - Success",
-			command: SetModelCommand(registry),
+			command: SetModelCommand(registry, nil),
 			args:    []string{"text2image", "stable-diffusion-xl"},
 			ctx: braibottypes.MessageContext{
 				Nick:    "testuser",
@@ -198,7 +218,7 @@ func TestCommandHandlers(t *testing.T) {
 		},
 		{
 			name:    "Set Model Command - Invalid Model",
-			command: SetModelCommand(registry),
+			command: SetModelCommand(registry, nil),
 			args:    []string{"text2image", "invalid-model"},
 			ctx: braibottypes.MessageContext{
 				Nick:    "testuser",
@@ -256,3 +276,761 @@ func TestCommandHandlers(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatCommandList(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(BalanceCommand(nil))
+	registry.Register(braibottypes.Command{Name: "text2video", Description: "Generate a video", Category: "AI Generation"})
+	registry.Register(braibottypes.Command{Name: "text2image", Description: "Generate an image", Category: "AI Generation"})
+	registry.Register(braibottypes.Command{Name: "admin", Description: "Admin commands", Category: "Admin"})
+
+	all := formatCommandList(registry, "")
+	for _, want := range []string{"!balance", "!text2image", "!text2video"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("unfiltered list lacks %s:\n%s", want, all)
+		}
+	}
+	if strings.Contains(all, "!admin") || strings.Contains(all, "Flags:") {
+		t.Errorf("unfiltered list shows admin commands or flags:\n%s", all)
+	}
+
+	video := formatCommandList(registry, "Video")
+	if !strings.Contains(video, "!text2video") || strings.Contains(video, "!text2image") || strings.Contains(video, "!balance") {
+		t.Errorf("filter video matched the wrong commands:\n%s", video)
+	}
+	if !strings.Contains(video, "Flags: --") {
+		t.Errorf("filtered list lacks flags:\n%s", video)
+	}
+
+	if got := formatCommandList(registry, "nosuchthing"); !strings.Contains(got, "No commands or flags match") {
+		t.Errorf("unmatched filter = %q", got)
+	}
+}
+
+func TestGuestPaidRequests(t *testing.T) {
+	gen := braibottypes.Command{Name: "text2image", Category: "AI Generation"}
+	tests := []struct {
+		name        string
+		command     braibottypes.Command
+		args        []string
+		freePreview bool
+		want        bool
+	}{
+		{"generation", gen, []string{"a", "cat"}, false, true},
+		{"usage only", gen, nil, false, false},
+		{"free preview", gen, []string{"a", "cat", "--preview"}, true, false},
+		{"paid preview", gen, []string{"a", "cat", "--preview"}, false, true},
+		{"pot split", gen, []string{"a", "cat", "--split", "100"}, false, false},
+		{"webhook", braibottypes.Command{Name: "ai", Category: "AI Generation"}, []string{"hi"}, false, false},
+		{"local render", braibottypes.Command{Name: "animate-svg", Category: "AI Generation"}, []string{"last"}, false, false},
+		{"basic", braibottypes.Command{Name: "rate", Category: "Basic"}, []string{"x"}, false, false},
+	}
+	for _, tt := range tests {
+		if got := isPaidRequest(tt.command, tt.args, tt.freePreview); got != tt.want {
+			t.Errorf("%s: isPaidRequest = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	msg := formatGuestWalkthrough("text2video", 0.5, 0.02, true)
+	for _, want := range []string{"!text2video", "$0.50", "0.02000000 DCR", "/tip", "--preview"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("walkthrough lacks %q:\n%s", want, msg)
+		}
+	}
+	if msg := formatGuestWalkthrough("restore", -1, -1, false); strings.Contains(msg, "costs") || strings.Contains(msg, "--preview") {
+		t.Errorf("walkthrough without price or preview:\n%s", msg)
+	}
+}
+
+func TestFormatJobDetails(t *testing.T) {
+	seed := int64(7)
+	job := database.Job{ID: 3, Command: "text2video", Model: "kling", Prompt: "a *cat*", Seed: &seed, CreatedAt: time.Unix(0, 0)}
+	msg := formatJobDetails(job, true)
+	for _, want := range []string{"Job #3", "!text2video", "kling", "shared with you", "Prompt: ", "cat", "Seed: 7"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("details lack %q:\n%s", want, msg)
+		}
+	}
+	if msg := formatJobDetails(database.Job{ID: 4}, false); strings.Contains(msg, "Prompt") || strings.Contains(msg, "Seed") || strings.Contains(msg, "shared") {
+		t.Errorf("details of a job without prompt or seed:\n%s", msg)
+	}
+
+	if msg := formatJobShares(3, nil); !strings.Contains(msg, "not shared") {
+		t.Errorf("empty share list = %q", msg)
+	}
+	msg = formatJobShares(3, []database.KnownUser{{UID: "u1", Nick: "bob"}, {UID: "u2"}})
+	if !strings.Contains(msg, "bob (u1)") || !strings.Contains(msg, "• u2") {
+		t.Errorf("share list:\n%s", msg)
+	}
+}
+
+func TestAboutInfo(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(HelpCommand(registry, nil))
+	registry.Register(AboutCommand(registry, &config.BotConfig{}))
+	registry.SetBillingEnabled(true)
+
+	info := aboutInfo(registry, map[string]string{"mcpenabled": "1", "operatornick": "@alice"})
+	if !info.Subsystems.Billing || info.Subsystems.Webhook || !info.Subsystems.MCP {
+		t.Errorf("Subsystems = %+v", info.Subsystems)
+	}
+	if info.Operator != "alice" {
+		t.Errorf("Operator = %q, want alice", info.Operator)
+	}
+	if !reflect.DeepEqual(info.Commands, []string{"about", "help"}) {
+		t.Errorf("Commands = %v", info.Commands)
+	}
+	if info.Models["text2image"] == 0 || info.ModelCount < info.Models["text2image"] {
+		t.Errorf("Models = %v, ModelCount = %d", info.Models, info.ModelCount)
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	for _, key := range []string{"name", "version", "operator", "subsystems", "models", "modelCount", "commands"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("JSON is missing %q: %s", key, data)
+		}
+	}
+}
+
+func TestParseSpeech2TextArgs(t *testing.T) {
+	url, lang, task, err := parseSpeech2TextArgs([]string{"https://x/a.mp3", "--language", "DE", "--task", "translate"})
+	if err != nil || url != "https://x/a.mp3" || lang != "de" || task != "translate" {
+		t.Errorf("parseSpeech2TextArgs = %q, %q, %q, %v", url, lang, task, err)
+	}
+	// An attached audio note follows the options as an embed
+	url, lang, _, err = parseSpeech2TextArgs([]string{"--language", "en", "--embed[alt=Audio", "note,type=audio/ogg,data=AAAA]--"})
+	if err != nil || url != "" || lang != "en" {
+		t.Errorf("parseSpeech2TextArgs with audio note = %q, %q, %v", url, lang, err)
+	}
+	for _, args := range [][]string{{"--task", "summarize", "https://x/a.mp3"}, {"--language"}, {"a", "b"}, {"--speed", "2"}} {
+		if _, _, _, err := parseSpeech2TextArgs(args); err == nil {
+			t.Errorf("parseSpeech2TextArgs(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestSubtitleText(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+		burn bool
+	}{
+		{[]string{"a", "fox", "runs"}, "", false},
+		{[]string{"a", "fox", "runs", "--subtitles"}, "a fox runs", false},
+		{[]string{"a", "fox", "--script", "The fox speaks."}, "The fox speaks.", false},
+		{[]string{"a", "fox", "--burn_subtitles"}, "a fox", true},
+	}
+	for _, tt := range tests {
+		parsed, err := video.NewArgumentParser().Parse(tt.args, false)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.args, err)
+		}
+		if got := subtitleText(parsed); got != tt.want || parsed.BurnSubtitles != tt.burn {
+			t.Errorf("Parse(%q): subtitles %q, burn %v, want %q, %v", tt.args, got, parsed.BurnSubtitles, tt.want, tt.burn)
+		}
+	}
+}
+
+func TestParseVoiceSwapArgs(t *testing.T) {
+	voice, denoise, err := parseVoiceSwapArgs([]string{"george", "--denoise", "--embed[alt=Audio", "note,type=audio/ogg,data=AAAA]--"})
+	if err != nil || voice != "George" || !denoise {
+		t.Errorf("parseVoiceSwapArgs = %q, %v, %v", voice, denoise, err)
+	}
+	if voice, denoise, err := parseVoiceSwapArgs(nil); err != nil || voice != "" || denoise {
+		t.Errorf("parseVoiceSwapArgs(nil) = %q, %v, %v", voice, denoise, err)
+	}
+	for _, args := range [][]string{{"Darth"}, {"George", "Lily"}, {"--pitch"}} {
+		if _, _, err := parseVoiceSwapArgs(args); err == nil {
+			t.Errorf("parseVoiceSwapArgs(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestParseImage3DArgs(t *testing.T) {
+	var req model3d.Model3DRequest
+	args := []string{"https://example.com/chair.png", "--format", "OBJ", "--remove_background=false", "--mc_resolution", "512"}
+	if err := parseImage3DArgs(args, &fal.TripoSROptions{}, &req); err != nil {
+		t.Fatalf("parseImage3DArgs: %v", err)
+	}
+	if req.ImageURL != "https://example.com/chair.png" || req.Format != "obj" || req.RemoveBackground == nil || *req.RemoveBackground {
+		t.Errorf("parseImage3DArgs = %+v", req)
+	}
+	if n, ok := req.Options["mc_resolution"].(*int); !ok || *n != 512 || len(req.Options) != 1 {
+		t.Errorf("parseImage3DArgs options = %v", req.Options)
+	}
+	for _, args := range [][]string{{"--format", "glb"}, {"https://example.com/a.png", "--format", "stl"}, {"ftp://example.com/a.png"}, {"https://example.com/a.png", "extra"}, {"https://example.com/a.png", "--steps", "3"}} {
+		if err := parseImage3DArgs(args, &fal.TripoSROptions{}, &model3d.Model3DRequest{}); err == nil {
+			t.Errorf("parseImage3DArgs(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestParseRemoveBGArgs(t *testing.T) {
+	var req imgservice.ImageRequest
+	if err := parseRemoveBGArgs([]string{"--variant", "Portrait", "--resolution", "2048x2048", "--output-format", "webp"}, &req); err != nil {
+		t.Fatalf("parseRemoveBGArgs: %v", err)
+	}
+	if req.Variant != "portrait" || req.Resolution != "2048x2048" || req.OutputFormat != "webp" {
+		t.Errorf("parseRemoveBGArgs = %q, %q, %q", req.Variant, req.Resolution, req.OutputFormat)
+	}
+	for _, args := range [][]string{{"--variant", "huge"}, {"--resolution", "512x512"}, {"--output_format", "jpeg"}, {"--variant"}, {"cat"}} {
+		if err := parseRemoveBGArgs(args, &imgservice.ImageRequest{}); err == nil {
+			t.Errorf("parseRemoveBGArgs(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestUserSettingsCommands(t *testing.T) {
+	dm, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	bot := &MockBot{}
+	sender := braibottypes.NewMessageSender(bot)
+	msgCtx := braibottypes.MessageContext{Nick: "alice", IsPM: true}
+	run := func(cmd braibottypes.Command, args ...string) string {
+		t.Helper()
+		if err := cmd.Handler.Handle(context.Background(), msgCtx, args, sender, nil); err != nil {
+			t.Fatalf("!%s %v: %v", cmd.Name, args, err)
+		}
+		return bot.lastPM
+	}
+
+	if msg := run(SetCommand(dm), "--aspect-ratio", "16:9"); !strings.Contains(msg, "Saved aspect = 16:9") {
+		t.Errorf("!set reply = %q", msg)
+	}
+	run(SetCommand(dm), "negative_prompt", "blurry,", "low", "quality")
+	if msg := run(SetCommand(dm), "seed", "lucky"); !strings.Contains(msg, "Argument error") {
+		t.Errorf("!set with a bad seed = %q, want an argument error", msg)
+	}
+	if msg := run(SetCommand(dm), "color", "red"); !strings.Contains(msg, "unknown setting") {
+		t.Errorf("!set with an unknown key = %q", msg)
+	}
+
+	settings, _ := dm.GetUserSettings(msgCtx.Sender.String())
+	if len(settings) != 2 || settings["aspect"] != "16:9" || settings["negative_prompt"] != "blurry, low quality" {
+		t.Errorf("saved settings = %v", settings)
+	}
+	if msg := run(SettingsCommand(dm)); !strings.Contains(msg, "aspect: 16:9") || !strings.Contains(msg, "negative_prompt: blurry, low quality") {
+		t.Errorf("!settings reply = %q", msg)
+	}
+
+	run(UnsetCommand(dm), "aspect")
+	if msg := run(UnsetCommand(dm), "aspect"); !strings.Contains(msg, "no saved aspect") {
+		t.Errorf("second !unset reply = %q", msg)
+	}
+	if settings, _ := dm.GetUserSettings(msgCtx.Sender.String()); len(settings) != 1 {
+		t.Errorf("settings after !unset = %v", settings)
+	}
+}
+
+func TestResolveLastArgs(t *testing.T) {
+	dm, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	now := time.Now()
+	dm.RecordResult("u", database.ResultImage, "flux/dev", "https://x/cat.png", now)
+	dm.RecordResult("u", database.ResultVideo, "veo2", "https://x/cat.mp4", now)
+
+	args, err := resolveLastArgs(dm, "u", []string{"LAST", "the", "last", "cat", "--end_image", "last:1", "--seed", "last"}, database.ResultImage)
+	if err != nil {
+		t.Fatalf("resolveLastArgs: %v", err)
+	}
+	want := []string{"https://x/cat.png", "the", "last", "cat", "--end_image", "https://x/cat.mp4", "--seed", "last"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("resolveLastArgs = %q, want %q", args, want)
+	}
+
+	if _, err := resolveLastArgs(dm, "u", []string{"last"}, database.ResultAudio); err == nil || !strings.Contains(err.Error(), "no recent audio") {
+		t.Errorf("resolveLastArgs without an audio result = %v", err)
+	}
+	if _, err := resolveLastArgs(dm, "u", []string{"last:3"}, database.ResultImage); err == nil {
+		t.Error("resolveLastArgs with last:3 of 2 results succeeded, want an error")
+	}
+
+	bot := &MockBot{}
+	msgCtx := braibottypes.MessageContext{Nick: "alice", IsPM: true}
+	uid := msgCtx.Sender.String()
+	dm.RecordResult(uid, database.ResultAudio, "minimax-tts", "https://x/hello.mp3", now)
+	if err := LastCommand(dm).Handler.Handle(context.Background(), msgCtx, nil, braibottypes.NewMessageSender(bot), nil); err != nil {
+		t.Fatalf("!last: %v", err)
+	}
+	if !strings.Contains(bot.lastPM, "1. audio from minimax-tts") || !strings.Contains(bot.lastPM, "https://x/hello.mp3") {
+		t.Errorf("!last reply = %q", bot.lastPM)
+	}
+}
+
+func TestModelHelpDoc(t *testing.T) {
+	model := faladapter.AppModel{Model: fal.Model{
+		Name:    "minimax-tts/text-to-speech",
+		Options: &fal.MinimaxTTSOptions{},
+	}}
+	model.HelpDoc = "Usage: !text2speech [text]\n\nParameters:\n• text: Text to speak\n• --speed: Speech speed (0.5-2.0)\n• --voice_id: Voice to use\n• --retired: No longer accepted\n\nAvailable Voices:\n• Wise_Woman"
+
+	doc := modelHelpDoc("text2speech", model)
+	for _, want := range []string{
+		"• text: Text to speak\n",
+		"• --speed: Speech speed (number; must be between 0.5 and 2.0; default: 1)\n",
+		"• --emotion: one of: angry, disgusted, fearful, happy, neutral, sad, surprised\n",
+		"• --voice_id: Voice to use\n",
+		"\n\nAvailable Voices:\n• Wise_Woman",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("help doc lacks %q:\n%s", want, doc)
+		}
+	}
+	if strings.Contains(doc, "--retired") {
+		t.Errorf("help doc lists an option the command rejects:\n%s", doc)
+	}
+
+	// Commands without a shared flag table keep the hand-written text
+	if got := modelHelpDoc("video2video", model); got != model.HelpDoc {
+		t.Errorf("video2video help doc = %q", got)
+	}
+}
+
+func TestFormatStatus(t *testing.T) {
+	got := formatStatus(statusReport{
+		Uptime:      26*time.Hour + 5*time.Minute,
+		FalErr:      errors.New("dial tcp: timeout"),
+		RateAge:     3 * time.Minute,
+		RateFetched: true,
+		Running:     2,
+		Pending:     1,
+	})
+	for _, want := range []string{"Uptime: 1d 2h 5m", "Bison Relay: ✅ connected", "fal.ai: ❌ unreachable: dial tcp: timeout", "updated 3m ago", "2 running, 1 waiting", "webhooks: off"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("status lacks %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestApplyGCSetting(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(braibottypes.Command{Name: "text2image"})
+	registry.Register(braibottypes.Command{Name: "help"})
+
+	s := database.GCSettings{GC: "art"}
+	for _, step := range []struct {
+		setting string
+		args    []string
+	}{
+		{"commands", []string{"!text2image,", "help"}},
+		{"budget", []string{"$2.50"}},
+		{"delivery", []string{"PM"}},
+		{"transcribe", []string{"On"}},
+		{"off", nil},
+	} {
+		if msg := applyGCSetting(registry, &s, step.setting, step.args); msg != "" {
+			t.Fatalf("applying %s %v: %s", step.setting, step.args, msg)
+		}
+	}
+	want := database.GCSettings{GC: "art", Disabled: true, Commands: []string{"text2image", "help"}, DailyBudgetUSD: 2.5, DeliverPM: true, Transcribe: true}
+	if !reflect.DeepEqual(s, want) {
+		t.Fatalf("settings = %+v, want %+v", s, want)
+	}
+	got := formatGCSettings(s)
+	for _, w := range []string{"Bot: off", "Commands: text2image, help", "Daily budget: $2.50", "Results: by PM", "Transcribing audio notes: on"} {
+		if !strings.Contains(got, w) {
+			t.Errorf("settings lack %q:\n%s", w, got)
+		}
+	}
+
+	for _, bad := range []struct {
+		setting string
+		args    []string
+	}{
+		{"commands", []string{"text2video"}},
+		{"budget", []string{"-1"}},
+		{"delivery", []string{"email"}},
+		{"transcribe", []string{"maybe"}},
+		{"transcribe", nil},
+		{"colour", nil},
+	} {
+		if msg := applyGCSetting(registry, &s, bad.setting, bad.args); msg == "" {
+			t.Errorf("%s %v was accepted", bad.setting, bad.args)
+		}
+	}
+	if msg := applyGCSetting(registry, &s, "reset", nil); msg != "" || !s.IsDefault() || s.GC != "art" {
+		t.Fatalf("reset = %q, %+v", msg, s)
+	}
+}
+
+func TestFormatSpendAllowance(t *testing.T) {
+	if got := formatSpendAllowance(utils.SpendAllowance{}); !strings.Contains(got, "no spending limits") {
+		t.Errorf("allowance without limits = %q", got)
+	}
+	got := formatSpendAllowance(utils.SpendAllowance{Limits: utils.SpendLimits{DailyUSD: 2}, SpentDay: 0.5})
+	for _, want := range []string{"Daily: $0.50 spent of $2.00 in the last 24 hours, $1.50 left", "Weekly: unlimited"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("allowance lacks %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestFormatTopupInstructions(t *testing.T) {
+	inv := topup.Invoice{USD: 5, Atoms: 25_000_000_000}
+	msg := formatTopupInstructions(inv, time.Hour)
+	for _, want := range []string{"$5.00 USD = 0.25000000 DCR", "/tip [my nick] 0.25000000", "held for 60 minutes"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("instructions lack %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestFormatUserCounts(t *testing.T) {
+	got := formatUserCounts(42, []int{1, 5, 12})
+	for _, want := range []string{"Unique users: 42", "New in the last 24 hours: 1", "New in the last 7 days: 5", "New in the last 30 days: 12"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("user counts lack %q:\n%s", want, got)
+		}
+	}
+	if since := userCountSince(time.Unix(0, 0)); len(since) != len(userCountPeriods) || !since[0].Equal(time.Unix(-86400, 0)) {
+		t.Errorf("userCountSince = %v", since)
+	}
+}
+
+func TestParseModelsArgs(t *testing.T) {
+	f, err := parseModelsArgs([]string{"Text2Image", "--max-price", "$0.05", "--sort", "Name", "--search", "Flux"})
+	if err != nil {
+		t.Fatalf("parseModelsArgs: %v", err)
+	}
+	if want := (modelFilter{task: "text2image", maxPrice: 0.05, search: "flux", sortBy: "name"}); f != want {
+		t.Errorf("parseModelsArgs = %+v, want %+v", f, want)
+	}
+	if f, err := parseModelsArgs(nil); err != nil || f.task != "" || f.sortBy != "price" {
+		t.Errorf("parseModelsArgs(nil) = %+v, %v", f, err)
+	}
+	for _, args := range [][]string{{"text2image", "text2video"}, {"--max-price", "cheap"}, {"--max-price", "0"}, {"--sort", "speed"}, {"--search"}, {"--fast", "1"}} {
+		if _, err := parseModelsArgs(args); err == nil {
+			t.Errorf("parseModelsArgs(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestFormatModelList(t *testing.T) {
+	models := map[string]faladapter.AppModel{
+		"fast":  {Model: fal.Model{Name: "fast", Description: "Quick drafts"}, PriceUSD: 0.01},
+		"slow":  {Model: fal.Model{Name: "slow", Description: "Detailed renders"}, PriceUSD: 0.08},
+		"video": {Model: fal.Model{Name: "video", Description: "Clips"}, PriceUSD: 0.05, PerSecondPricing: true},
+		"tts":   {Model: fal.Model{Name: "tts", Description: "Voices"}, PriceUSD: 0.0125, PerThousandChars: true},
+	}
+	got := filterModels(models, modelFilter{task: "text2image", sortBy: "price", maxPrice: 0.05})
+	var names []string
+	for _, m := range got {
+		names = append(names, m.Name)
+	}
+	if strings.Join(names, ",") != "fast,tts,video" {
+		t.Fatalf("filterModels = %v, want fast,tts,video", names)
+	}
+	if got := filterModels(models, modelFilter{sortBy: "name", search: "render"}); len(got) != 1 || got[0].Name != "slow" {
+		t.Fatalf("filterModels by search = %+v", got)
+	}
+
+	f := modelFilter{task: "text2image", sortBy: "price", maxPrice: 0.05}
+	list := formatModelList(f, got, "fast", 20)
+	for _, want := range []string{
+		"📋 Models for text2image, by price, up to $0.05:",
+		"• video: $0.05 (0.00250000 DCR) ⏱️ per second — Clips",
+		"✅ = the model used for your requests here",
+	} {
+		if !strings.Contains(list, want) {
+			t.Errorf("formatModelList = %q, want it to contain %q", list, want)
+		}
+	}
+	list = formatModelList(f, filterModels(models, f), "fast", 0)
+	for _, want := range []string{"✅ fast: $0.01 — Quick drafts", "• tts: $0.0125 🔤 per 1000 characters", "• video: $0.05 ⏱️ per second"} {
+		if !strings.Contains(list, want) {
+			t.Errorf("formatModelList = %q, want it to contain %q", list, want)
+		}
+	}
+	if list := formatModelList(f, nil, "", 20); !strings.Contains(list, "No models match") {
+		t.Errorf("formatModelList without models = %q", list)
+	}
+}
+
+func TestParseVariationsArgs(t *testing.T) {
+	for _, tc := range []struct {
+		args  []string
+		job   int64
+		count int
+	}{
+		{[]string{"last"}, 0, 1},
+		{[]string{"LAST", "3"}, 0, 3},
+		{[]string{"#42", "4"}, 42, 4},
+	} {
+		job, count, err := parseVariationsArgs(tc.args)
+		if err != nil || job != tc.job || count != tc.count {
+			t.Errorf("parseVariationsArgs(%q) = %d, %d, %v; want %d, %d", tc.args, job, count, err, tc.job, tc.count)
+		}
+	}
+	for _, args := range [][]string{nil, {"first"}, {"0"}, {"last", "5"}, {"last", "0"}, {"last", "2", "extra"}} {
+		if _, _, err := parseVariationsArgs(args); err == nil {
+			t.Errorf("parseVariationsArgs(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestResolveSeedArg(t *testing.T) {
+	dm, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	if _, err := resolveSeedArg(dm, "u", []string{"a", "cat", "--seed", "last"}); err == nil {
+		t.Error("resolveSeedArg without a seeded generation succeeded, want an error")
+	}
+
+	msgCtx := braibottypes.MessageContext{Nick: "alice"}
+	uid := msgCtx.Sender.String()
+	seed := int64(1234)
+	rememberGeneration(dm, msgCtx, "text2image", "flux/dev", []string{"a", "cat", "--seed=7", "--num_images", "2"}, &seed, 0)
+	rememberGeneration(dm, msgCtx, "text2video", "veo2", []string{"a", "dog", "--seed", "9"}, nil, 5)
+
+	args, err := resolveSeedArg(dm, uid, []string{"a", "cat", "--seed", "LAST"})
+	if want := []string{"a", "cat", "--seed", "1234"}; err != nil || !reflect.DeepEqual(args, want) {
+		t.Errorf("resolveSeedArg = %q, %v; want %q", args, err, want)
+	}
+	args, err = resolveSeedArg(dm, uid, []string{"last", "--seed=last"})
+	if want := []string{"last", "--seed=1234"}; err != nil || !reflect.DeepEqual(args, want) {
+		t.Errorf("resolveSeedArg = %q, %v; want %q", args, err, want)
+	}
+	if args, err := resolveSeedArg(dm, uid, []string{"--seed", "42"}); err != nil || args[1] != "42" {
+		t.Errorf("resolveSeedArg of a number = %q, %v", args, err)
+	}
+
+	entry, found, err := dm.HistoryByJob(uid, 5)
+	if err != nil || !found || !reflect.DeepEqual(entry.Args, []string{"a", "dog"}) || entry.Seed != nil {
+		t.Errorf("HistoryByJob = %+v, %v, %v; want the video without its seed", entry, found, err)
+	}
+	entry, _, _ = dm.LastHistory(uid, true)
+	if !reflect.DeepEqual(entry.Args, []string{"a", "cat", "--num_images", "2"}) {
+		t.Errorf("remembered args = %q, want them without --seed", entry.Args)
+	}
+}
+
+func TestExpandSavedPrompts(t *testing.T) {
+	dm, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	if ok, err := dm.SavePrompt("u", database.SavedPrompt{Name: "noir", Text: "film noir,  high contrast --aspect 16:9", CreatedAt: time.Now()}, maxSavedPrompts); err != nil || !ok {
+		t.Fatalf("SavePrompt = %v, %v", ok, err)
+	}
+	args := []string{"a", "lighthouse", "@Noir", "for", "@bob", "@"}
+	got, err := ExpandSavedPrompts(dm, "u", args)
+	want := []string{"a", "lighthouse", "film", "noir,", "high", "contrast", "--aspect", "16:9", "for", "@bob", "@"}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandSavedPrompts = %q, %v; want %q", got, err, want)
+	}
+	if got, err := ExpandSavedPrompts(dm, "other", args); err != nil || !reflect.DeepEqual(got, args) {
+		t.Errorf("ExpandSavedPrompts of another user = %q, %v; want the arguments unchanged", got, err)
+	}
+
+	for name, want := range map[string]string{"@Noir": "noir", "my-style_2": "my-style_2"} {
+		if got, err := parsePromptName(name); err != nil || got != want {
+			t.Errorf("parsePromptName(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"", "a b", "émoji", strings.Repeat("x", 33)} {
+		if _, err := parsePromptName(name); err == nil {
+			t.Errorf("parsePromptName(%q) succeeded, want an error", name)
+		}
+	}
+}
+
+func TestBatchPrompts(t *testing.T) {
+	prompts, fromFile, err := batchPrompts("!batch text2image a lighthouse\n\n  a fox in the snow --aspect 16:9 \na cat @noir")
+	want := []string{"a lighthouse", "a fox in the snow --aspect 16:9", "a cat @noir"}
+	if err != nil || fromFile || !reflect.DeepEqual(prompts, want) {
+		t.Errorf("batchPrompts = %q, %v, %v; want %q", prompts, fromFile, err, want)
+	}
+	if prompts, _, err := batchPrompts("!batch text2image\na hat with a feather"); err != nil || !reflect.DeepEqual(prompts, []string{"a hat with a feather"}) {
+		t.Errorf("batchPrompts starting on the next line = %q, %v", prompts, err)
+	}
+
+	file := "!batch text2image --embed[name=prompts.txt,type=text/plain,data=" + base64.StdEncoding.EncodeToString([]byte("one\r\ntwo\n")) + "]--"
+	if prompts, fromFile, err := batchPrompts(file); err != nil || !fromFile || !reflect.DeepEqual(prompts, []string{"one", "two"}) {
+		t.Errorf("batchPrompts of a file = %q, %v, %v", prompts, fromFile, err)
+	}
+	if _, _, err := batchPrompts("!batch text2image --embed[type=image/png,data=AAAA]--"); err == nil {
+		t.Error("batchPrompts accepted an image")
+	}
+}
+
+func TestBatchTracker(t *testing.T) {
+	bot := &MockBot{}
+	tracker := &batchTracker{runs: make(map[int64]*batchRun)}
+	run := &batchRun{msgCtx: braibottypes.MessageContext{Nick: "alice", IsPM: true}, sender: braibottypes.NewMessageSender(bot), command: "text2image", left: 3}
+	for id := int64(1); id <= 3; id++ {
+		tracker.runs[id] = run
+	}
+
+	tracker.done(database.QueuedJob{ID: 1}, nil)
+	tracker.done(database.QueuedJob{ID: 2}, jobs.ErrCanceled)
+	tracker.done(database.QueuedJob{ID: 9}, nil) // Not part of a batch
+	if bot.lastPM != "" {
+		t.Fatalf("sent %q before the batch was done", bot.lastPM)
+	}
+	tracker.done(database.QueuedJob{ID: 3}, errors.New("fal is down"))
+	if want := "📦 Your batch of 3 !text2image prompts is done: 1 completed, 1 failed, 1 cancelled."; bot.lastPM != want {
+		t.Errorf("summary = %q, want %q", bot.lastPM, want)
+	}
+	if len(tracker.runs) != 0 {
+		t.Errorf("tracker still follows %d jobs", len(tracker.runs))
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	perSecond := faladapter.AppModel{Model: fal.Model{Name: "kling-video-v3-text"}, PriceUSD: 0.10, PerSecondPricing: true}
+	flat := faladapter.AppModel{Model: fal.Model{Name: "fast-sdxl"}, PriceUSD: 0.02}
+	tests := []struct {
+		name    string
+		command string
+		model   faladapter.AppModel
+		args    []string
+		wantUSD float64
+		wantErr bool
+	}{
+		{"text2image", "text2image", flat, []string{"a", "cat"}, 0.02, false},
+		{"several images", "text2image", flat, []string{"a", "cat", "--num_images", "3"}, 0.06, false},
+		{"preview", "text2image", flat, []string{"a", "cat", "--preview"}, 0, true},
+		{"requested duration", "text2video", perSecond, []string{"a", "city", "--duration", "10"}, 1.0, false},
+		{"default duration", "text2video", perSecond, []string{"a", "city"}, 0.5, false},
+		{"video2video", "video2video", perSecond, []string{"https://example.com/a.mp4", "make", "it", "snow", "--duration", "8"}, 0.8, false},
+		{"unsupported", "ai", flat, []string{"hello"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est, err := estimateCost(tt.command, tt.model, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("estimateCost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := est.USD - tt.wantUSD; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("estimateCost() USD = %v, want %v", est.USD, tt.wantUSD)
+			}
+		})
+	}
+}
+
+func TestFormatEstimate(t *testing.T) {
+	model := faladapter.AppModel{Model: fal.Model{Name: "fast-sdxl"}}
+	est := costEstimate{USD: 1}
+	got := formatEstimate("text2image", model, est, 0, 0.05, 0.04, true)
+	if !strings.Contains(got, "-0.01000000 DCR after the request") || !strings.Contains(got, "does not cover it") {
+		t.Errorf("short balance not reported:\n%s", got)
+	}
+	got = formatEstimate("text2image", model, est, 50, 0.05, 0.04, true)
+	if !strings.Contains(got, "you pay $0.50 USD") || !strings.Contains(got, "0.01500000 DCR after the request") || strings.Contains(got, "does not cover it") {
+		t.Errorf("split not applied:\n%s", got)
+	}
+	got = formatEstimate("text2image", model, est, 0, -1, -1, false)
+	if !strings.Contains(got, "Billing is off") || !strings.HasSuffix(got, "Nothing was generated or charged.") {
+		t.Errorf("unbilled estimate:\n%s", got)
+	}
+}
+
+func TestParseTranscribeArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{args: []string{"last"}, want: ""},
+		{args: []string{"LAST"}, want: ""},
+		{args: []string{"alice"}, want: "alice"},
+		{args: []string{"@alice"}, want: "alice"},
+		{args: []string{"@"}, wantErr: true},
+		{args: []string{"alice", "bob"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTranscribeArgs(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTranscribeArgs(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseTranscribeArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParseScheduleAdd(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantSpec string
+		wantCmd  string
+		wantArgs int
+		wantErr  bool
+	}{
+		{args: []string{"0 9 * * *", "text2image", "daily sunrise"}, wantSpec: "0 9 * * *", wantCmd: "text2image", wantArgs: 1},
+		{args: []string{"0", "9", "*", "*", "1-5", "!Text2Video", "waves", "--duration", "5"}, wantSpec: "0 9 * * 1-5", wantCmd: "text2video", wantArgs: 3},
+		{args: []string{"@daily", "text2speech", "good morning"}, wantSpec: "@daily", wantCmd: "text2speech", wantArgs: 1},
+		{args: nil, wantErr: true},
+		{args: []string{"0", "9", "*"}, wantErr: true},
+		{args: []string{"0 9 * * *"}, wantErr: true},
+		{args: []string{"0 9 * * *", "text2image"}, wantErr: true},
+		{args: []string{"0 9 * * *", "image2video", "last"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		spec, cmd, args, err := parseScheduleAdd(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseScheduleAdd(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if spec != tt.wantSpec || cmd != tt.wantCmd || len(args) != tt.wantArgs {
+			t.Errorf("parseScheduleAdd(%q) = %q, %q, %q", tt.args, spec, cmd, args)
+		}
+	}
+}
+
+func TestExtractSharedFlags(t *testing.T) {
+	for _, in := range [][]string{{"a", "fox", "--split", "50", "--num_images", "2"}, {"a", "fox", "--Split=50%", "--num_images", "2"}} {
+		args, shared, err := extractSharedFlags(in, false)
+		if err != nil || shared.SplitPercent != 50 || strings.Join(args, " ") != "a fox --num_images 2" {
+			t.Errorf("extractSharedFlags(%q) = %q, %+v, %v", in, args, shared, err)
+		}
+	}
+	for _, in := range [][]string{{"a", "fox", "--No-Cache"}, {"--no_cache", "true", "a", "fox"}, {"a", "--no-cache=true", "fox"}} {
+		if args, shared, err := extractSharedFlags(in, true); err != nil || !shared.NoCache || strings.Join(args, " ") != "a fox" {
+			t.Errorf("extractSharedFlags(%q) = %q, %+v, %v", in, args, shared, err)
+		}
+	}
+	if _, _, err := extractSharedFlags([]string{"--split=0"}, false); err == nil {
+		t.Error("extractSharedFlags accepted --split=0")
+	}
+	if _, _, err := extractSharedFlags([]string{"--split", "50"}, true); err == nil {
+		t.Error("extractSharedFlags accepted --split in a PM")
+	}
+}
+
+func TestFormatRates(t *testing.T) {
+	live := formatRates(utils.PricingRate{USD: 24.5, Source: utils.RateLive}, 24.5, 0.00031, 79000)
+	if !strings.Contains(live, "• DCR: $24.50 USD\n") || strings.Contains(live, "market") || !strings.Contains(live, "BTC") {
+		t.Errorf("live rates = %q", live)
+	}
+	fixed := formatRates(utils.PricingRate{USD: 25, Source: utils.RateFixed}, 24.5, 0, 0)
+	if !strings.Contains(fixed, "$25.00 USD (fixed rate used for pricing)") || !strings.Contains(fixed, "market rate: $24.50") || strings.Contains(fixed, "BTC") {
+		t.Errorf("fixed rates = %q", fixed)
+	}
+	until := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	override := formatRates(utils.PricingRate{USD: 30, Source: utils.RateOverride, Until: until}, 0, 0, 0)
+	if !strings.Contains(override, "until 2026-10-17 09:30 UTC") || strings.Contains(override, "market") {
+		t.Errorf("override rates = %q", override)
+	}
+}
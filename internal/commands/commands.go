@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	braibottypes "github.com/karamble/braibot/internal/types"
+)
+
+// CommandsCommand returns the commands command, a compact, searchable
+// alternative to !help that lists commands and their flags.
+func CommandsCommand(registry *Registry) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "commands",
+		Description: "🔎 List commands and flags matching a filter (e.g., !commands video). Usage: !commands [filter]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			return sender.SendMessage(ctx, msgCtx, formatCommandList(registry, strings.Join(args, " ")))
+		}),
+	}
+}
+
+// formatCommandList lists the user-facing commands whose name, model type or
+// flags contain filter. Without a filter only names and descriptions are
+// listed.
+func formatCommandList(registry *Registry, filter string) string {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+
+	cmds := registry.ListCommands()
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+
+	var b strings.Builder
+	for _, cmd := range cmds {
+		if cmd.Category == "Admin" {
+			continue
+		}
+		if filter == "" {
+			fmt.Fprintf(&b, "• !%s: %s\n", cmd.Name, cmd.Description)
+			continue
+		}
+		info := commandInfo(cmd)
+		flags := commandFlags(info)
+		if !strings.Contains(cmd.Name, filter) && !strings.Contains(info.ModelType, filter) && !containsAny(flags, filter) {
+			continue
+		}
+		fmt.Fprintf(&b, "• !%s: %s\n", cmd.Name, cmd.Description)
+		if len(flags) > 0 {
+			fmt.Fprintf(&b, "  Flags: %s\n", strings.Join(flags, " "))
+		}
+	}
+
+	if filter == "" {
+		return "🔎 **Commands**\n\n" + b.String() + "\nUse !commands [filter] to search commands and flags, or !help [command] for details."
+	}
+	if b.Len() == 0 {
+		return fmt.Sprintf("🔎 No commands or flags match %q. Use !commands to list all commands.", filter)
+	}
+	return fmt.Sprintf("🔎 **Commands matching %q**\n\n", filter) + b.String() + "\nFlags depend on the selected model; see !help [command] [model]."
+}
+
+// commandFlags returns the union of the flags of a command's models.
+func commandFlags(info CommandInfo) []string {
+	seen := make(map[string]bool)
+	var flags []string
+	for _, m := range info.Models {
+		for _, f := range m.Flags {
+			if !seen[f] {
+				seen[f] = true
+				flags = append(flags, f)
+			}
+		}
+	}
+	sort.Strings(flags)
+	return flags
+}
+
+// containsAny reports whether any of the strings contains substr.
+func containsAny(list []string, substr string) bool {
+	for _, s := range list {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/internal/confirm"
+	braibottypes "github.com/karamble/braibot/internal/types"
+)
+
+// ConfirmCommand returns the confirm command, which runs the expensive
+// request the user was asked to confirm.
+func ConfirmCommand() braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "confirm",
+		Description: "✔️ Run an expensive request you were asked to confirm. Usage: !confirm [cancel]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			s := confirm.Default
+			if s == nil {
+				return sender.SendMessage(ctx, msgCtx, "No request needs confirming on this bot.")
+			}
+			uid := msgCtx.Sender.String()
+			if len(args) > 0 && strings.EqualFold(args[0], "cancel") {
+				if !s.Cancel(uid, time.Now()) {
+					return sender.SendMessage(ctx, msgCtx, "You have no request to cancel.")
+				}
+				return sender.SendMessage(ctx, msgCtx, "Request cancelled. You were not charged.")
+			}
+
+			req, ok := s.Take(uid, time.Now())
+			if !ok {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("You have no request to confirm. Requests wait %d minutes for their confirmation.", int(confirm.TTL.Minutes())))
+			}
+			if err := sender.SendMessage(ctx, msgCtx, fmt.Sprintf("✔️ Confirmed, running your !%s request for $%.2f USD.", req.Command, req.CostUSD)); err != nil {
+				return err
+			}
+			req.Run(ctx)
+			return nil
+		}),
+	}
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/internal/vision"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+// describeModel is the image2text model used by !describe.
+const describeModel = "llava-next"
+
+// DescribeCommand returns the describe command, which captions and tags an
+// image.
+func DescribeCommand(bot *kit.Bot, visionService *vision.VisionService, dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "describe",
+		Description: "🔍 Caption and tag an image, e.g. to reuse as a prompt. Usage: !describe [image_url|last]",
+		Category:    "AI Generation",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			args, shared, err := extractSharedFlags(args, msgCtx.IsPM)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+
+			// Swap "last" for the user's recent results
+			args, err = resolveLastArgs(dbManager, msgCtx.Sender.String(), args, database.ResultImage)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+
+			model, exists := faladapter.GetModel(describeModel, "image2text")
+			if !exists {
+				return sender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("model not found: %s", describeModel))
+			}
+
+			var userID zkidentity.ShortID
+			userID.FromBytes(msgCtx.Uid)
+
+			if len(args) == 0 {
+				header := utils.FormatCommandHelpHeader("describe", model, userID, msgCtx.IsPM, db)
+				return sender.SendMessage(ctx, msgCtx, header+model.HelpDoc)
+			}
+			if len(args) > 1 {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(fmt.Sprintf("unexpected argument %s", args[1])))
+			}
+
+			parsedURL, err := url.Parse(args[0])
+			if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+				return sender.SendMessage(ctx, msgCtx, "Please provide a valid http:// or https:// URL for the image, or last for your newest image.")
+			}
+
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "describe")
+			req := &vision.DescribeRequest{
+				GenerationRequest: braibottypes.GenerationRequest{
+					ModelType:    "image2text",
+					ModelName:    model.Name,
+					Progress:     progress,
+					UserNick:     msgCtx.Nick,
+					UserID:       userID,
+					PriceUSD:     faladapter.PriceFor(model, faladapter.PriceParams{}),
+					IsPM:         msgCtx.IsPM,
+					GC:           msgCtx.GC,
+					SplitPercent: shared.SplitPercent,
+				},
+				ImageURL: args[0],
+			}
+			result, err := visionService.Describe(ctx, req)
+			return utils.HandleServiceResultOrError(ctx, bot, msgCtx, "describe", result, err)
+		}),
+	}
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/karamble/braibot/internal/faladapter"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+// CommandInfo is the machine-readable description of a command, as emitted by
+// --dump-commands and !admin dumpcommands.
+type CommandInfo struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Category    string      `json:"category"`
+	ModelType   string      `json:"modelType,omitempty"`
+	Models      []ModelInfo `json:"models,omitempty"`
+}
+
+// ModelInfo describes a model usable by a command and the flags it accepts.
+type ModelInfo struct {
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	PriceUSD         float64  `json:"priceUsd"`
+	PerSecondPricing bool     `json:"perSecond"`
+	Flags            []string `json:"flags,omitempty"`
+}
+
+// commandModels maps commands whose name is not a model type to the model
+// type they use and, optionally, the fixed models they run.
+var commandModels = map[string]struct {
+	modelType string
+	models    []string
+}{
+	"chat":        {"text2text", nil},
+	"cleanaudio":  {"audio2audio", []string{cleanAudioModel}},
+	"describe":    {"image2text", []string{describeModel}},
+	"inpaint":     {"image2image", []string{inpaintModel}},
+	"removebg":    {"image2image", []string{removeBGModel}},
+	"restore":     {"image2image", []string{restoreColorizeModel, restoreFaceModel, restoreUpscaleModel}},
+	"speech2text": {"audio2text", []string{transcribeModel}},
+	"transcribe":  {"audio2text", []string{transcribeModel}},
+	"voiceswap":   {"audio2audio", []string{voiceSwapModel}},
+}
+
+// DumpCommands returns JSON describing every registered command, its
+// category and the models (with their option flags) it can run.
+func DumpCommands(registry *Registry) ([]byte, error) {
+	cmds := registry.ListCommands()
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+
+	infos := make([]CommandInfo, 0, len(cmds))
+	for _, cmd := range cmds {
+		infos = append(infos, commandInfo(cmd))
+	}
+
+	return json.MarshalIndent(infos, "", "  ")
+}
+
+// commandInfo describes a command and the models (with their option flags)
+// it can run.
+func commandInfo(cmd braibottypes.Command) CommandInfo {
+	info := CommandInfo{
+		Name:        cmd.Name,
+		Description: cmd.Description,
+		Category:    cmd.Category,
+	}
+
+	modelType := cmd.Name
+	var only []string
+	if cm, ok := commandModels[cmd.Name]; ok {
+		modelType, only = cm.modelType, cm.models
+	}
+	models, ok := faladapter.GetModels(modelType)
+	if !ok {
+		return info
+	}
+	info.ModelType = modelType
+	names := only
+	if names == nil {
+		for name := range models {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	for _, name := range names {
+		m, ok := models[name]
+		if !ok {
+			continue
+		}
+		info.Models = append(info.Models, ModelInfo{
+			Name:             m.Name,
+			Description:      m.Description,
+			PriceUSD:         m.PriceUSD,
+			PerSecondPricing: m.PerSecondPricing,
+			Flags:            modelFlags(m.Options),
+		})
+	}
+	return info
+}
+
+// modelFlags lists the command-line flags derived from a model's option schema.
+func modelFlags(options interface{}) []string {
+	opts, ok := options.(fal.ModelOptions)
+	if !ok {
+		return nil
+	}
+	var flags []string
+	for key := range opts.GetDefaultValues() {
+		flags = append(flags, "--"+key)
+	}
+	sort.Strings(flags)
+	return flags
+}
@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/money"
+	"github.com/karamble/braibot/internal/speech"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/internal/video"
+)
+
+// estimateCommands are the commands !estimate prices, with the kinds of
+// their leading media arguments for "last".
+var estimateCommands = map[string][]string{
+	"text2image":  nil,
+	"image2image": {database.ResultImage},
+	"text2video":  nil,
+	"image2video": {database.ResultImage},
+	"video2video": {database.ResultVideo},
+	"multi2video": nil,
+	"text2speech": nil,
+}
+
+// costEstimate is what running a command would cost.
+type costEstimate struct {
+	USD       float64
+	Detail    string // How the cost adds up, e.g. "20 seconds at $0.10 per second"
+	Breakdown string // The model cost and service fee, empty without a markup
+}
+
+// serviceFeeNote is a line for pricing messages showing that their total
+// includes the operator's markup, empty without a markup.
+func serviceFeeNote(model faladapter.AppModel, params faladapter.PriceParams) string {
+	if breakdown := faladapter.PriceBreakdown(model, params); breakdown != "" {
+		return "\n🧾 The total is the " + breakdown
+	}
+	return ""
+}
+
+// priceEstimate prices running model with params.
+func priceEstimate(model faladapter.AppModel, params faladapter.PriceParams) costEstimate {
+	return costEstimate{USD: faladapter.PriceFor(model, params), Breakdown: faladapter.PriceBreakdown(model, params)}
+}
+
+// estimateCost runs the argument parsing of command and prices the request
+// with model, the way the command itself would, without running it.
+func estimateCost(command string, model faladapter.AppModel, args []string) (costEstimate, error) {
+	parser := video.NewArgumentParser()
+	var requested string
+	switch command {
+	case "text2image":
+		_, req, err := parseTextImageArgs(args)
+		if err != nil {
+			return costEstimate{}, err
+		}
+		if req.Preview {
+			return costEstimate{}, fmt.Errorf("previews are priced by the operator; estimate the request without --preview")
+		}
+		est := priceEstimate(model, faladapter.PriceParams{NumImages: req.NumImages})
+		if req.NumImages > 1 {
+			est.Detail = fmt.Sprintf("%d images at $%.2f each", req.NumImages, model.PriceUSD)
+		}
+		return est, nil
+	case "image2image":
+		if len(args) == 0 {
+			return costEstimate{}, fmt.Errorf("please provide the image URL")
+		}
+		return priceEstimate(model, faladapter.PriceParams{}), nil
+	case "text2speech":
+		var req speech.SpeechRequest
+		if err := parseTextSpeechArgs(args, model.Options, &req); err != nil {
+			return costEstimate{}, err
+		}
+		chars := utf8.RuneCountInString(req.Text)
+		if chars == 0 {
+			return costEstimate{}, fmt.Errorf("please provide the text to speak")
+		}
+		if model.MaxTextChars > 0 && chars > model.MaxTextChars {
+			return costEstimate{}, fmt.Errorf("text is %d characters; %s accepts at most %d", chars, model.Name, model.MaxTextChars)
+		}
+		est := priceEstimate(model, faladapter.PriceParams{TextChars: chars})
+		if model.PerThousandChars {
+			est.Detail = fmt.Sprintf("%d characters at $%.2f per 1000", chars, model.PriceUSD)
+		}
+		return est, nil
+	case "text2video", "image2video":
+		parsed, err := parser.Parse(args, command == "image2video")
+		if err != nil {
+			return costEstimate{}, err
+		}
+		requested = parsed.Duration
+	case "video2video":
+		parsed, err := parser.ParseVideo2Video(args)
+		if err != nil {
+			return costEstimate{}, err
+		}
+		requested = parsed.Duration
+	case "multi2video":
+		parsed, err := parser.ParseMulti2Video(args)
+		if err != nil {
+			return costEstimate{}, err
+		}
+		requested = parsed.Duration
+	default:
+		return costEstimate{}, fmt.Errorf("!estimate does not price !%s", command)
+	}
+
+	_, seconds := videoDuration(command, model.Name, requested)
+	est := priceEstimate(model, faladapter.PriceParams{Seconds: seconds})
+	if model.PerSecondPricing {
+		est.Detail = fmt.Sprintf("%d seconds at $%.2f per second", seconds, model.PriceUSD)
+	}
+	return est, nil
+}
+
+// formatEstimate renders an estimate. costDCR and balanceDCR are negative
+// when unknown; billing reports whether requests are billed at all.
+func formatEstimate(command string, model faladapter.AppModel, est costEstimate, splitPercent int, costDCR, balanceDCR float64, billing bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🧮 Estimate for !%s with %s:\n", command, model.Name)
+	if est.Detail != "" {
+		fmt.Fprintf(&b, "• %s\n", est.Detail)
+	}
+	if costDCR >= 0 {
+		fmt.Fprintf(&b, "• Cost: $%.2f USD (%.8f DCR)\n", est.USD, costDCR)
+	} else {
+		fmt.Fprintf(&b, "• Cost: $%.2f USD\n", est.USD)
+	}
+	if est.Breakdown != "" {
+		fmt.Fprintf(&b, "• That is the %s\n", est.Breakdown)
+	}
+
+	share := 1.0
+	if splitPercent > 0 {
+		share = float64(100-splitPercent) / 100
+		fmt.Fprintf(&b, "• The GC pot pays %d%%, you pay $%.2f USD\n", splitPercent, est.USD*share)
+	}
+	switch {
+	case !billing:
+		b.WriteString("• Billing is off, so the request would be free\n")
+	case balanceDCR >= 0 && costDCR >= 0:
+		after := balanceDCR - costDCR*share
+		fmt.Fprintf(&b, "• Your balance: %.8f DCR, %.8f DCR after the request\n", balanceDCR, after)
+		if after < 0 {
+			b.WriteString("⚠️ Your balance does not cover it. Add funds with !topup or a tip.\n")
+		}
+	}
+	b.WriteString("Nothing was generated or charged.")
+	return b.String()
+}
+
+// estimateRequest prices the arguments of command for the sender of msgCtx
+// with their current model, resolving --split, "last" and saved prompts the
+// way the command does.
+func estimateRequest(dbManager *database.DBManager, msgCtx braibottypes.MessageContext, command string, args []string) (faladapter.AppModel, costEstimate, int, error) {
+	kinds, ok := estimateCommands[command]
+	if !ok {
+		return faladapter.AppModel{}, costEstimate{}, 0, fmt.Errorf("!estimate does not price !%s", command)
+	}
+	uid := msgCtx.Sender.String()
+	args, shared, err := extractSharedFlags(args, msgCtx.IsPM)
+	if err == nil {
+		args, err = resolveLastArgs(dbManager, uid, args, kinds...)
+	}
+	if err == nil {
+		args, err = ExpandSavedPrompts(dbManager, uid, args)
+	}
+	if err != nil {
+		return faladapter.AppModel{}, costEstimate{}, 0, err
+	}
+
+	var userIDStr string
+	if msgCtx.IsPM {
+		var id zkidentity.ShortID
+		id.FromBytes(msgCtx.Uid)
+		userIDStr = id.String()
+	}
+	model, exists := faladapter.GetCurrentModel(command, userIDStr)
+	if !exists {
+		return faladapter.AppModel{}, costEstimate{}, 0, fmt.Errorf("no default model found for %s", command)
+	}
+	est, err := estimateCost(command, model, args)
+	return model, est, shared.SplitPercent, err
+}
+
+// RequestCostUSD returns what running command with args would cost the
+// sender of msgCtx in total, before a group chat pot pays its share. It
+// fails for commands that cannot be priced up front and for arguments the
+// command would reject.
+func RequestCostUSD(dbManager *database.DBManager, msgCtx braibottypes.MessageContext, command string, args []string) (float64, error) {
+	_, est, _, err := estimateRequest(dbManager, msgCtx, command, args)
+	return est.USD, err
+}
+
+// EstimateCommand returns the estimate command, which prices a generation
+// request without running it.
+func EstimateCommand(registry *Registry, dbManager *database.DBManager) braibottypes.Command {
+	usage := "Usage: !estimate [command] [arguments]\n" +
+		"Prices a request with your current model without running it, e.g. !estimate text2video a city at night --duration 10\n" +
+		"Works for !text2image, !image2image, !text2video, !image2video, !video2video, !multi2video and !text2speech."
+
+	return braibottypes.Command{
+		Name:        "estimate",
+		Description: "🧮 Show what a request would cost without running it. Usage: !estimate [command] [arguments]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if len(args) == 0 {
+				return sender.SendMessage(ctx, msgCtx, usage)
+			}
+			command := strings.ToLower(strings.TrimPrefix(args[0], "!"))
+			if _, ok := estimateCommands[command]; !ok {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("!estimate does not price !%s.\n\n%s", utils.SanitizeUserText(command), usage))
+			}
+
+			model, est, splitPercent, err := estimateRequest(dbManager, msgCtx, command, args[1:])
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+
+			costDCR, balanceDCR := -1.0, -1.0
+			if dcr, err := utils.USDToDCR(est.USD); err == nil {
+				costDCR = dcr
+			}
+			// Balances are private, so group chats only get the cost
+			if atoms, err := dbManager.GetBalance(msgCtx.Sender.String()); err == nil && msgCtx.IsPM {
+				balanceDCR = money.AtomsToDCR(atoms)
+			}
+			return sender.SendMessage(ctx, msgCtx, formatEstimate(command, model, est, splitPercent, costDCR, balanceDCR, registry.GetBillingEnabled()))
+		}),
+	}
+}
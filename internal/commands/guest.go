@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// GuestMode lets users without a balance explore the bot. Free commands run
+// as usual; paid generations are answered with a funding walkthrough instead
+// of an insufficient balance error.
+type GuestMode struct {
+	Enabled     bool
+	FreePreview bool // Whether --preview thumbnails are free to try
+}
+
+// NewGuestMode reads the guest mode settings. Guest mode is on unless
+// guestmode=false.
+func NewGuestMode(extra map[string]string) *GuestMode {
+	preview := previewPolicyFromConfig(extra)
+	return &GuestMode{
+		Enabled:     !strings.EqualFold(extra["guestmode"], "false"),
+		FreePreview: preview.Enabled && preview.PriceUSD == 0,
+	}
+}
+
+// Notice returns the funding walkthrough when a user without a balance runs
+// a paid command, and false when the command should run normally.
+func (g *GuestMode) Notice(registry *Registry, dbManager *database.DBManager, command braibottypes.Command, msgCtx braibottypes.MessageContext, args []string) (string, bool) {
+	if g == nil || !g.Enabled || !registry.GetBillingEnabled() || !isPaidRequest(command, args, g.FreePreview) {
+		return "", false
+	}
+	uid := msgCtx.Sender.String()
+	balance, err := dbManager.GetBalance(uid)
+	if err != nil || balance > 0 {
+		return "", false
+	}
+
+	priceUSD, priceDCR := -1.0, -1.0
+	if model, ok := faladapter.GetCurrentModel(command.Name, uid); ok {
+		priceUSD = faladapter.PriceFor(model, faladapter.PriceParams{})
+		if dcr, err := utils.USDToDCR(priceUSD); err == nil {
+			priceDCR = dcr
+		}
+	}
+	return formatGuestWalkthrough(command.Name, priceUSD, priceDCR, g.FreePreview), true
+}
+
+// freeGenerationCommands are generation commands that are never billed.
+var freeGenerationCommands = map[string]bool{"ai": true, "animate-svg": true}
+
+// isPaidRequest reports whether running a command with args is billed.
+// Without arguments generation commands only show their help; a free
+// --preview draws from the daily preview quota and --split lets a GC pot pay.
+func isPaidRequest(command braibottypes.Command, args []string, freePreview bool) bool {
+	if command.Category != "AI Generation" || freeGenerationCommands[command.Name] || len(args) == 0 {
+		return false
+	}
+	for _, arg := range args {
+		switch strings.ToLower(arg) {
+		case "--split":
+			return false
+		case "--preview":
+			if freePreview {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// formatGuestWalkthrough explains how to fund a balance. Negative prices are
+// left out.
+func formatGuestWalkthrough(cmd string, priceUSD, priceDCR float64, preview bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "👋 You're exploring BraiBot as a guest: **!%s** is a paid command and your balance is empty.\n\n", cmd)
+	if priceUSD >= 0 {
+		fmt.Fprintf(&b, "💰 !%s currently costs $%.2f USD", cmd, priceUSD)
+		if priceDCR >= 0 {
+			fmt.Fprintf(&b, " (about %.8f DCR)", priceDCR)
+		}
+		b.WriteString(" per request.\n\n")
+	}
+	b.WriteString("**How to fund your balance**\n" +
+		"1. Open a private chat with me in Bison Relay.\n" +
+		"2. Send me a tip of any amount, e.g. `/tip [my nick] 0.1`, or use **!topup [usd]** to learn how much DCR a USD amount is.\n" +
+		"3. Check **!balance** once the tip arrives (usually within a minute).\n" +
+		"4. Send your command again. You're only charged after results are delivered.\n\n")
+	b.WriteString("Meanwhile you can use **!help**, **!commands**, **!models** and **!rate** for free")
+	if preview {
+		b.WriteString(", and try **!text2image [prompt] --preview** for a free low-resolution preview")
+	}
+	b.WriteString(".")
+	return b.String()
+}
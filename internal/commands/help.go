@@ -7,6 +7,7 @@ import (
 
 	"github.com/companyzero/bisonrelay/zkidentity"
 	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/money"
 	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
 )
@@ -36,7 +37,7 @@ func HelpCommand(registry *Registry, dbManager braibottypes.DBManagerInterface)
 				if err != nil {
 					return sender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("failed to get balance: %v", err))
 				}
-				balanceDCR := float64(balance) / 1e11
+				balanceDCR := money.AtomsToDCR(balance)
 
 				// Get current exchange rate for USD value using utils
 				dcrPrice, _, err := utils.GetDCRPrice()
@@ -62,9 +63,14 @@ func HelpCommand(registry *Registry, dbManager braibottypes.DBManagerInterface)
 
 				// Get current model selections
 				helpMsg += "🎯 **Your Current Model Selections:**\n"
-				for _, cmdType := range []string{"text2image", "text2speech", "image2image", "image2video", "text2video", "video2video", "multi2video"} {
+				personal := faladapter.GetAllUserModels(userIDStr)
+				for _, cmdType := range []string{"text2image", "text2speech", "image2image", "image2video", "text2video", "video2video", "multi2video", "image23d"} {
 					if model, exists := faladapter.GetCurrentModel(cmdType, userIDStr); exists {
-						helpMsg += fmt.Sprintf("• %s: %s ($%.2f USD)\n", cmdType, model.Name, model.PriceUSD)
+						helpMsg += fmt.Sprintf("• %s: %s ($%.2f USD)", cmdType, model.Name, model.PriceUSD)
+						if _, ok := personal[cmdType]; ok {
+							helpMsg += " (your pick)"
+						}
+						helpMsg += "\n"
 					}
 				}
 				helpMsg += "\n"
@@ -73,7 +79,7 @@ func HelpCommand(registry *Registry, dbManager braibottypes.DBManagerInterface)
 				helpMsg += "## 🎯 Basic Commands\n"
 				helpMsg += "| Command | Description | Usage |\n"
 				helpMsg += "| ------- | ----------- | ----- |\n"
-				for _, cmdName := range []string{"help", "balance", "rate"} {
+				for _, cmdName := range []string{"help", "commands", "about", "balance", "estimate", "confirm", "rate", "notify", "redeliver", "resend", "share", "refund", "promo", "pot", "mute", "unmute", "set", "unset", "settings", "last", "prompt", "schedule", "leaderboard", "queue", "cancel"} {
 					if cmd, exists := registry.Get(cmdName); exists {
 						if cmd.Category == "Basic" {
 							helpMsg += fmt.Sprintf("| !%s | %s | !%s |\n", cmd.Name, cmd.Description, cmd.Name)
@@ -84,7 +90,7 @@ func HelpCommand(registry *Registry, dbManager braibottypes.DBManagerInterface)
 				helpMsg += "\n## 🔧 Model Configuration\n"
 				helpMsg += "| Command | Description | Usage |\n"
 				helpMsg += "| ------- | ----------- | ----- |\n"
-				for _, cmdName := range []string{"listmodels", "setmodel"} {
+				for _, cmdName := range []string{"models", "listmodels", "setmodel"} {
 					if cmd, exists := registry.Get(cmdName); exists {
 						if cmd.Category == "Model Configuration" {
 							usage := "!%s [task]"
@@ -109,6 +115,17 @@ func HelpCommand(registry *Registry, dbManager braibottypes.DBManagerInterface)
 					"text2speech": "Convert text to speech with AI",
 					"video2video": "Edit and transform videos with AI",
 					"multi2video": "Generate videos from multiple reference inputs",
+					"cleanaudio":  "Isolate voices and remove background noise",
+					"speech2text": "Transcribe audio and audio notes to text",
+					"transcribe":  "Transcribe an audio note sent in a group chat",
+					"restore":     "Restore, colorize and upscale old photos",
+					"removebg":    "Remove the background from images",
+					"inpaint":     "Repaint the masked part of an image",
+					"animate-svg": "Animate SVG logos into draw-on GIFs",
+					"chat":        "Chat with a language model",
+					"describe":    "Caption and tag an image",
+					"voiceswap":   "Convert an audio note to another voice",
+					"image23d":    "Turn an image into a 3D model",
 				}
 
 				// Add !ai command with conditional display
@@ -121,6 +138,52 @@ func HelpCommand(registry *Registry, dbManager braibottypes.DBManagerInterface)
 
 				for cmdName, description := range aiCommands {
 					if _, exists := registry.Get(cmdName); exists {
+						switch cmdName {
+						case "cleanaudio":
+							if model, exists := faladapter.GetModel(cleanAudioModel, "audio2audio"); exists {
+								helpMsg += fmt.Sprintf("| !%s | %s | $%.2f |\n", cmdName, description, model.PriceUSD)
+								continue
+							}
+						case "voiceswap":
+							if model, exists := faladapter.GetModel(voiceSwapModel, "audio2audio"); exists {
+								helpMsg += fmt.Sprintf("| !%s | %s | $%.2f/sec |\n", cmdName, description, model.PriceUSD)
+								continue
+							}
+						case "speech2text", "transcribe":
+							if model, exists := faladapter.GetModel(transcribeModel, "audio2text"); exists {
+								helpMsg += fmt.Sprintf("| !%s | %s | $%.3f/sec |\n", cmdName, description, model.PriceUSD)
+								continue
+							}
+						case "animate-svg":
+							helpMsg += fmt.Sprintf("| !%s | %s | Free |\n", cmdName, description)
+							continue
+						case "chat":
+							if model, exists := faladapter.GetCurrentModel("text2text", userIDStr); exists {
+								helpMsg += fmt.Sprintf("| !%s | %s | $%.2f/1M tokens |\n", cmdName, description, model.PriceUSD)
+								continue
+							}
+						case "describe":
+							if model, exists := faladapter.GetModel(describeModel, "image2text"); exists {
+								helpMsg += fmt.Sprintf("| !%s | %s | $%.2f |\n", cmdName, description, model.PriceUSD)
+								continue
+							}
+						case "removebg", "inpaint":
+							name := removeBGModel
+							if cmdName == "inpaint" {
+								name = inpaintModel
+							}
+							if model, exists := faladapter.GetModel(name, "image2image"); exists {
+								helpMsg += fmt.Sprintf("| !%s | %s | $%.2f |\n", cmdName, description, model.PriceUSD)
+								continue
+							}
+						case "restore":
+							face, faceOK := faladapter.GetModel(restoreFaceModel, "image2image")
+							upscale, upscaleOK := faladapter.GetModel(restoreUpscaleModel, "image2image")
+							if faceOK && upscaleOK {
+								helpMsg += fmt.Sprintf("| !%s | %s | $%.2f |\n", cmdName, description, face.PriceUSD+upscale.PriceUSD)
+								continue
+							}
+						}
 						if model, exists := faladapter.GetCurrentModel(cmdName, userIDStr); exists {
 							helpMsg += fmt.Sprintf("| !%s | %s | $%.2f |\n", cmdName, description, model.PriceUSD)
 						} else {
@@ -142,7 +205,7 @@ func HelpCommand(registry *Registry, dbManager braibottypes.DBManagerInterface)
 				commandName := strings.ToLower(args[0])
 				cmd, exists := registry.Get(commandName)
 				if !exists {
-					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Unknown command: %s. Use **!help** to see available commands.", commandName))
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Unknown command: %s. Use **!help** to see available commands.", utils.SanitizeUserText(commandName)))
 				}
 
 				// Get models for this command
@@ -163,12 +226,14 @@ func HelpCommand(registry *Registry, dbManager braibottypes.DBManagerInterface)
 					models, modelExists = faladapter.GetModels("video2video")
 				case "multi2video":
 					models, modelExists = faladapter.GetModels("multi2video")
+				case "image23d":
+					models, modelExists = faladapter.GetModels("image23d")
 				default:
 					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Command: !%s\nDescription: %s", cmd.Name, cmd.Description))
 				}
 
 				if !modelExists {
-					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("No models found for command: %s", commandName))
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("No models found for command: %s", utils.SanitizeUserText(commandName)))
 				}
 
 				// Get current model selection
@@ -184,7 +249,7 @@ func HelpCommand(registry *Registry, dbManager braibottypes.DBManagerInterface)
 						currentModel.Name,
 						currentModel.PriceUSD,
 						perSecondLine,
-						currentModel.HelpDoc)
+						modelHelpDoc(commandName, currentModel))
 				}
 
 				// Format command help with model list
@@ -197,6 +262,10 @@ func HelpCommand(registry *Registry, dbManager braibottypes.DBManagerInterface)
 					desc := model.Description
 					if model.PerSecondPricing {
 						desc += fmt.Sprintf(" 💰 $%.2f/sec", model.PriceUSD)
+					} else if model.PerThousandChars {
+						desc += fmt.Sprintf(" 💰 $%.2f/1000 chars", model.PriceUSD)
+					} else if model.PerMillionTokens {
+						desc += fmt.Sprintf(" 💰 $%.2f/1M tokens", model.PriceUSD)
 					} else {
 						desc += fmt.Sprintf(" 💰 Flat fee: $%.2f", model.PriceUSD)
 					}
@@ -215,7 +284,7 @@ func HelpCommand(registry *Registry, dbManager braibottypes.DBManagerInterface)
 				// Get the model information
 				model, exists := faladapter.GetModel(modelName, commandName)
 				if !exists {
-					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Unknown model: %s for command: %s. Use !help %s to see available models.", modelName, commandName, commandName))
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Unknown model: %s for command: %s. Use !help %s to see available models.", utils.SanitizeUserText(modelName), commandName, commandName))
 				}
 
 				// Get user ID
@@ -223,10 +292,10 @@ func HelpCommand(registry *Registry, dbManager braibottypes.DBManagerInterface)
 				userID.FromBytes(msgCtx.Uid)
 
 				// Format header using utility function
-				header := utils.FormatCommandHelpHeader(commandName, model, userID, db)
+				header := utils.FormatCommandHelpHeader(commandName, model, userID, msgCtx.IsPM, db)
 
 				// Get help doc
-				helpDoc := model.HelpDoc
+				helpDoc := modelHelpDoc(commandName, model)
 				if helpDoc == "" {
 					helpDoc = "(No specific documentation available for this model.)"
 				}
@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/params"
+	"github.com/karamble/braibot/internal/video"
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+// commandFlagSpec returns the flags a model's command accepts, or nil for
+// commands without a shared flag table.
+func commandFlagSpec(commandName string, model faladapter.AppModel) *params.Spec {
+	switch commandName {
+	case "text2image":
+		return params.NewSpec(text2imageFlags...)
+	case "image2image":
+		return params.NewSpec(imageEditFlags...)
+	case "text2video", "image2video":
+		return params.NewSpec(video.Flags()...)
+	case "text2speech":
+		return textSpeechSpec(model.Options)
+	}
+	return nil
+}
+
+// modelHelpDoc returns the model's HelpDoc with its "Parameters:" section
+// generated from the model's options struct, so options added to the code
+// show up in !help without editing the hand-written text. Descriptions are
+// kept from the hand-written bullets, and options the command does not
+// accept are left out.
+func modelHelpDoc(commandName string, model faladapter.AppModel) string {
+	spec := commandFlagSpec(commandName, model)
+	opts := fal.DescribeOptions(model.Options)
+	if spec == nil || len(opts) == 0 {
+		return model.HelpDoc
+	}
+
+	sections := strings.Split(model.HelpDoc, "\n\n")
+	paramIdx := -1
+	for i, section := range sections {
+		if strings.HasPrefix(section, "Parameters:") {
+			paramIdx = i
+			break
+		}
+	}
+
+	// Hand-written bullets: positional ones are kept as they are, flag
+	// ones lend their spelling and description to the generated lines
+	var positional []string
+	type bullet struct{ name, desc string }
+	written := make(map[string]bullet)
+	var writtenOrder []string
+	if paramIdx >= 0 {
+		for _, line := range strings.Split(sections[paramIdx], "\n")[1:] {
+			name, desc, ok := strings.Cut(strings.TrimPrefix(line, "• "), ": ")
+			if !ok || !strings.HasPrefix(name, "--") {
+				positional = append(positional, line)
+				continue
+			}
+			flag, ok := spec.Lookup(name)
+			if !ok {
+				continue
+			}
+			if _, dup := written[flag.Name]; !dup {
+				writtenOrder = append(writtenOrder, flag.Name)
+			}
+			written[flag.Name] = bullet{name: name, desc: desc}
+		}
+	}
+
+	lines := append([]string{"Parameters:"}, positional...)
+	described := make(map[string]bool)
+	for _, opt := range opts {
+		flag, ok := spec.Lookup(opt.Name)
+		if !ok || described[flag.Name] {
+			continue
+		}
+		described[flag.Name] = true
+		name := "--" + opt.Name
+		var desc string
+		if b, ok := written[flag.Name]; ok {
+			name, desc = b.name, shortDescription(b.desc, opt)
+		}
+		details := describeOption(opt)
+		if desc != "" {
+			details = desc + " (" + details + ")"
+		}
+		lines = append(lines, fmt.Sprintf("• %s: %s", name, details))
+	}
+	// Flags the command handles itself, such as --voice_id
+	for _, name := range writtenOrder {
+		if !described[name] {
+			b := written[name]
+			lines = append(lines, fmt.Sprintf("• %s: %s", b.name, b.desc))
+		}
+	}
+
+	if paramIdx >= 0 {
+		sections[paramIdx] = strings.Join(lines, "\n")
+	} else {
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// shortDescription strips the value details from a hand-written option
+// description, which describeOption generates instead, and drops
+// descriptions that only list the accepted values.
+func shortDescription(desc string, opt fal.OptionInfo) string {
+	for _, sep := range []string{" (", ". ", ", default"} {
+		if i := strings.Index(desc, sep); i >= 0 {
+			desc = desc[:i]
+		}
+	}
+	desc = strings.TrimSpace(desc)
+	if len(opt.Allowed) > 0 && strings.Contains(desc, opt.Allowed[0]) {
+		return ""
+	}
+	return desc
+}
+
+// describeOption lists an option's accepted values and default.
+func describeOption(opt fal.OptionInfo) string {
+	var parts []string
+	if len(opt.Allowed) > 0 {
+		parts = append(parts, "one of: "+strings.Join(opt.Allowed, ", "))
+	} else {
+		parts = append(parts, opt.Type)
+		if opt.Constraint != "" {
+			parts = append(parts, opt.Constraint)
+		}
+	}
+	if opt.Default != "" {
+		parts = append(parts, "default: "+opt.Default)
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/model3d"
+	"github.com/karamble/braibot/internal/params"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+// image3DFlags are the options !image23d takes on top of the model's own.
+var image3DFlags = []params.Flag{
+	params.NewFlag(params.Lower, "format", "output_format").OneOf("glb", "obj"),
+	params.NewFlag(params.Bool, "remove_background", "do_remove_background"),
+	params.NewFlag(params.Bool, "textured", "textured_mesh"),
+	params.NewFlag(params.Int, "seed"),
+}
+
+// parseImage3DArgs parses "[image_url] [--options]" of !image23d into req.
+// Options of the model without a field of their own end up in req.Options.
+func parseImage3DArgs(args []string, modelOptions interface{}, req *model3d.Model3DRequest) error {
+	parsed, err := params.Parse(args, params.NewSpec(image3DFlags...).Merge(params.FromOptions(modelOptions)...))
+	if err != nil {
+		return err
+	}
+	switch len(parsed.Args) {
+	case 0:
+		return fmt.Errorf("please provide the image URL")
+	case 1:
+	default:
+		return fmt.Errorf("unexpected argument %s", parsed.Args[1])
+	}
+	parsedURL, err := url.Parse(parsed.Args[0])
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return fmt.Errorf("please provide a valid http:// or https:// URL for the image, or last for your newest image")
+	}
+
+	req.ImageURL = parsed.Args[0]
+	req.Format = parsed.String("format")
+	req.RemoveBackground = parsed.Bool("remove_background")
+	if textured := parsed.Bool("textured"); textured != nil {
+		req.Textured = *textured
+	}
+	req.Seed = parsed.Int("seed")
+	req.Options = parsed.Options()
+	for _, f := range image3DFlags {
+		delete(req.Options, f.Name)
+	}
+	return nil
+}
+
+// Image23DCommand returns the image23d command, which turns an image into a
+// 3D model file.
+func Image23DCommand(bot *kit.Bot, model3DService *model3d.Model3DService, dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "image23d",
+		Description: "🧊 Turn an image into a 3D model (GLB/OBJ). Usage: !image23d [image_url|last] [--format glb|obj]",
+		Category:    "AI Generation",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			args, shared, err := extractSharedFlags(args, msgCtx.IsPM)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+
+			// Swap "last" for the user's recent results
+			args, err = resolveLastArgs(dbManager, msgCtx.Sender.String(), args, database.ResultImage)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+
+			var userIDStr string
+			if msgCtx.IsPM {
+				var uid zkidentity.ShortID
+				uid.FromBytes(msgCtx.Uid)
+				userIDStr = uid.String()
+			}
+			model, exists := faladapter.GetCurrentModel("image23d", userIDStr)
+			if !exists {
+				return sender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("no default model found for image23d"))
+			}
+
+			var userID zkidentity.ShortID
+			userID.FromBytes(msgCtx.Uid)
+
+			if len(args) == 0 {
+				header := utils.FormatCommandHelpHeader("image23d", model, userID, msgCtx.IsPM, db)
+				return sender.SendMessage(ctx, msgCtx, header+modelHelpDoc("image23d", model))
+			}
+
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "image23d")
+			req := &model3d.Model3DRequest{
+				GenerationRequest: braibottypes.GenerationRequest{
+					ModelType:    "image23d",
+					ModelName:    model.Name,
+					Progress:     progress,
+					UserNick:     msgCtx.Nick,
+					UserID:       userID,
+					PriceUSD:     faladapter.PriceFor(model, faladapter.PriceParams{}),
+					IsPM:         msgCtx.IsPM,
+					GC:           msgCtx.GC,
+					SplitPercent: shared.SplitPercent,
+				},
+			}
+			if err := parseImage3DArgs(args, model.Options, req); err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+			result, err := model3DService.Generate(ctx, req)
+			return utils.HandleServiceResultOrError(ctx, bot, msgCtx, "image23d", result, err)
+		}),
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
 	"github.com/karamble/braibot/internal/faladapter"
 	imgservice "github.com/karamble/braibot/internal/image"
+	"github.com/karamble/braibot/internal/params"
 	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
 	"github.com/karamble/braibot/pkg/fal"
@@ -19,7 +22,7 @@ import (
 
 // Image2ImageCommand returns the image2image command
 // It now requires an ImageService instance.
-func Image2ImageCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *imgservice.ImageService, debug bool) braibottypes.Command {
+func Image2ImageCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *imgservice.ImageService, dbManager *database.DBManager, debug bool) braibottypes.Command {
 	// Get the current model to use its description
 	model, exists := faladapter.GetCurrentModel("image2image", "") // Empty string for global default
 	if !exists {
@@ -41,6 +44,17 @@ func Image2ImageCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *im
 			// Create a message sender using the adapter
 			msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
 
+			args, shared, sharedErr := extractSharedFlags(args, msgCtx.IsPM)
+			if sharedErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(sharedErr.Error()))
+			}
+
+			// Swap "last" for the user's recent results
+			args, lastErr := resolveLastArgs(dbManager, msgCtx.Sender.String(), args, database.ResultImage)
+			if lastErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(lastErr.Error()))
+			}
+
 			if len(args) < 1 {
 				// Get the current model
 				var userIDStr string
@@ -59,10 +73,10 @@ func Image2ImageCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *im
 				userID.FromBytes(msgCtx.Uid)
 
 				// Format header using utility function
-				header := utils.FormatCommandHelpHeader("image2image", model, userID, db)
+				header := utils.FormatCommandHelpHeader("image2image", model, userID, msgCtx.IsPM, db)
 
 				// Get help doc
-				helpDoc := model.HelpDoc
+				helpDoc := modelHelpDoc("image2image", model)
 				if helpDoc == "" {
 					helpDoc = "Usage: !image2image [image_url] [prompt] [--options...]\n(No specific documentation available for this model.)"
 				}
@@ -79,10 +93,12 @@ func Image2ImageCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *im
 				return msgSender.SendMessage(ctx, msgCtx, "Please provide a valid http:// or https:// URL for the image.")
 			}
 
-			// Remaining args after the URL form the prompt (optional for most models, required for flux-2-pro/edit)
-			var prompt string
-			if len(args) > 1 {
-				prompt = strings.Join(args[1:], " ")
+			// Remaining args after the URL form the prompt (optional for most models, required for flux-2-pro/edit),
+			// with restoration/upscale options pulled out into the request
+			var editOpts imgservice.ImageRequest
+			prompt, err := parseImageEditArgs(args[1:], &editOpts)
+			if err != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
 			}
 
 			// Get model configuration
@@ -98,34 +114,46 @@ func Image2ImageCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *im
 			}
 
 			// Create progress callback
-			progress := NewCommandProgressCallback(bot, msgCtx.Nick, msgCtx.Sender, "image2image", msgCtx.IsPM, msgCtx.GC)
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "image2image")
 
 			// Create image request
 			var userID zkidentity.ShortID
 			userID.FromBytes(msgCtx.Uid)
 			req := &imgservice.ImageRequest{
 				GenerationRequest: braibottypes.GenerationRequest{
-					ModelType: "image2image",
-					ModelName: model.Name,
-					Progress:  progress,
-					UserNick:  msgCtx.Nick,
-					UserID:    userID,
-					PriceUSD:  model.PriceUSD,
-					IsPM:      msgCtx.IsPM,
-					GC:        msgCtx.GC,
+					ModelType:    "image2image",
+					ModelName:    model.Name,
+					Progress:     progress,
+					UserNick:     msgCtx.Nick,
+					UserID:       userID,
+					PriceUSD:     faladapter.PriceFor(model, faladapter.PriceParams{}),
+					IsPM:         msgCtx.IsPM,
+					GC:           msgCtx.GC,
+					SplitPercent: shared.SplitPercent,
+					NoCache:      shared.NoCache,
+					AssetLink:    prefersAssetLink(cfg, "image2image"),
 				},
-				Prompt:   prompt,
-				ImageURL: imageURL,
+				Prompt:       prompt,
+				ImageURL:     imageURL,
+				Seed:         editOpts.Seed,
+				OutputFormat: editOpts.OutputFormat,
+				Fidelity:     editOpts.Fidelity,
+				Scale:        editOpts.Scale,
+				FaceEnhance:  editOpts.FaceEnhance,
 			}
 
 			// Generate image using the service
 			result, err := imageService.GenerateImage(ctx, req)
 			if err != nil {
 				var insufficientBalanceErr *utils.ErrInsufficientBalance // Define variable outside switch
+				var apiErr *fal.APIError
 				switch {
 				case errors.As(err, &insufficientBalanceErr):
 					// Send specific message for insufficient balance
 					return msgSender.SendMessage(ctx, msgCtx, fmt.Sprintf("Image generation failed: %s", insufficientBalanceErr.Error()))
+				case errors.As(err, &apiErr) && apiErr.Kind != nil:
+					// fal rejected the request for a reason the user can act on
+					return msgSender.SendMessage(ctx, msgCtx, utils.FormatAPIError("image2image", apiErr))
 				case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
 					// Context was cancelled (likely due to shutdown signal), log and return nil
 					fmt.Printf("INFO [image2image] User %s: Context canceled/deadline exceeded: %v\n", msgCtx.Nick, err)
@@ -155,3 +183,43 @@ func Image2ImageCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *im
 		}),
 	}
 }
+
+// imageEditFlags are the restoration and upscale options of image2image.
+var imageEditFlags = []params.Flag{
+	params.NewFlag(params.Float, "fidelity").Between(0, 1),
+	params.NewFlag(params.String, "scale"),
+	params.NewFlag(params.Bool, "face"),
+	params.NewFlag(params.Int, "seed"),
+	params.NewFlag(params.Lower, "output_format"),
+}
+
+// parseImageEditArgs extracts the image2image restoration and upscale flags
+// (--fidelity, --scale, --face, --seed, --output_format) into req and returns
+// the remaining args joined as the prompt.
+func parseImageEditArgs(args []string, req *imgservice.ImageRequest) (string, error) {
+	parsed, err := params.Parse(args, params.NewSpec(imageEditFlags...))
+	if err != nil {
+		return "", err
+	}
+	if err := applyImageEditFlags(parsed, req); err != nil {
+		return "", err
+	}
+	return parsed.Prompt(), nil
+}
+
+// applyImageEditFlags copies the parsed imageEditFlags into req.
+func applyImageEditFlags(parsed *params.Result, req *imgservice.ImageRequest) error {
+	if scale := parsed.String("scale"); scale != "" {
+		// Upscale factors are often written as 4x
+		f, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(scale), "x"), 64)
+		if err != nil || f < 1 {
+			return fmt.Errorf("invalid value for --scale: %s", scale)
+		}
+		req.Scale = &f
+	}
+	req.Fidelity = parsed.Float("fidelity")
+	req.FaceEnhance = parsed.Bool("face")
+	req.Seed = parsed.Int("seed")
+	req.OutputFormat = parsed.String("output_format")
+	return nil
+}
@@ -3,9 +3,9 @@ package commands
 import (
 	"context"
 	"fmt"
-	"strconv"
 
 	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
 	"github.com/karamble/braibot/internal/faladapter"
 	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
@@ -17,7 +17,7 @@ import (
 
 // Image2VideoCommand returns the image2video command
 // It now requires a VideoService instance.
-func Image2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *video.VideoService, debug bool) braibottypes.Command {
+func Image2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *video.VideoService, dbManager *database.DBManager, debug bool) braibottypes.Command {
 	// Get the current model to use its description
 	model, exists := faladapter.GetCurrentModel("image2video", "") // Empty string for global default
 	if !exists {
@@ -39,6 +39,23 @@ func Image2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *vi
 			// Create a message sender using the adapter
 			msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
 
+			args, shared, sharedErr := extractSharedFlags(args, msgCtx.IsPM)
+			if sharedErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(sharedErr.Error()))
+			}
+
+			// Swap "last" for the user's recent results
+			args, lastErr := resolveLastArgs(dbManager, msgCtx.Sender.String(), args, database.ResultImage)
+			if lastErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(lastErr.Error()))
+			}
+
+			// Swap --seed last for the seed of the user's previous generation
+			args, seedErr := resolveSeedArg(dbManager, msgCtx.Sender.String(), args)
+			if seedErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(seedErr.Error()))
+			}
+
 			if len(args) < 1 {
 				// Get the current model
 				var userIDStr string
@@ -57,10 +74,10 @@ func Image2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *vi
 				userID.FromBytes(msgCtx.Uid)
 
 				// Format header using utility function
-				header := utils.FormatCommandHelpHeader("image2video", model, userID, db)
+				header := utils.FormatCommandHelpHeader("image2video", model, userID, msgCtx.IsPM, db)
 
 				// Get help doc
-				helpDoc := model.HelpDoc
+				helpDoc := modelHelpDoc("image2video", model)
 				if helpDoc == "" {
 					helpDoc = "Usage: !image2video [image_url] [prompt] [--options...]\n(No specific documentation available for this model.)"
 				}
@@ -73,7 +90,7 @@ func Image2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *vi
 			parser := video.NewArgumentParser()
 			parsed, err := parser.Parse(args, true) // Expect Image URL
 			if err != nil {
-				return msgSender.SendMessage(ctx, msgCtx, fmt.Sprintf("Argument error: %v", err))
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
 			}
 			if parsed.ImageURL == "" { // Image URL is required for image2video
 				return msgSender.SendMessage(ctx, msgCtx, "Please provide an image URL as the first argument.")
@@ -81,6 +98,9 @@ func Image2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *vi
 			if parsed.Prompt == "" {
 				return msgSender.SendMessage(ctx, msgCtx, "Please provide a text prompt describing the desired animation.")
 			}
+			if parsed.Subtitles && !msgCtx.IsPM {
+				return msgSender.SendMessage(ctx, msgCtx, subtitlesPMOnly)
+			}
 
 			// Get model configuration
 			var userIDStr string
@@ -100,46 +120,16 @@ func Image2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *vi
 			// Video service is now passed in
 			// videoService := video.NewVideoService(client, dbManager, bot, debug)
 
-			// Create progress callback
-			progress := NewCommandProgressCallback(bot, msgCtx.Nick, msgCtx.Sender, "image2video", msgCtx.IsPM, msgCtx.GC)
-
 			// Determine effective duration for per-second pricing
-			duration := parsed.Duration
-			originalUserDuration := duration
-			durInt := 0
-			if duration != "" {
-				durInt, err = strconv.Atoi(duration)
-				if err != nil || durInt <= 0 {
-					durInt = 0 // fallback to check model default
-				}
-			}
-			if durInt == 0 {
-				// Check for known model default durations
-				modelDefault := 0
-				switch model.Name {
-				case "grok-imagine-video":
-					modelDefault = 6
-				case "kling-video-v25-image":
-					modelDefault = 5
-				case "veo3", "veo31fast":
-					modelDefault = 8
-				case "kling-video-v3-image", "kling-video-v3-pro-image":
-					modelDefault = 5
-				case "seedance-2.0-image":
-					modelDefault = 5
-				}
-				if modelDefault > 0 {
-					durInt = modelDefault
-					duration = strconv.Itoa(modelDefault)
-				} else {
-					durInt = 6 // fallback to hardcoded default
-					duration = "6"
-				}
-			}
+			originalUserDuration := parsed.Duration
+			duration, durInt := videoDuration("image2video", model.Name, parsed.Duration)
 
-			totalCost := model.PriceUSD
+			totalCost := faladapter.PriceFor(model, faladapter.PriceParams{Seconds: durInt})
+
+			// Create progress callback
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "image2video")
 			if model.PerSecondPricing {
-				totalCost = model.PriceUSD * float64(durInt)
+				progress.SetCostTicker(model.Name, durInt, totalCost)
 			}
 
 			// Create video request using parsed values
@@ -147,16 +137,19 @@ func Image2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *vi
 			userID.FromBytes(msgCtx.Uid)
 			req := &video.VideoRequest{
 				GenerationRequest: braibottypes.GenerationRequest{
-					ModelType: "image2video",
-					Progress:  progress,
-					UserNick:  msgCtx.Nick,
-					UserID:    userID,
-					PriceUSD:  totalCost,
-					IsPM:      msgCtx.IsPM,
-					GC:        msgCtx.GC,
+					ModelType:    "image2video",
+					Progress:     progress,
+					UserNick:     msgCtx.Nick,
+					UserID:       userID,
+					PriceUSD:     totalCost,
+					IsPM:         msgCtx.IsPM,
+					GC:           msgCtx.GC,
+					SplitPercent: shared.SplitPercent,
+					NoCache:      shared.NoCache,
 				},
 				Prompt:          parsed.Prompt,
 				Duration:        duration,
+				BilledSeconds:   durInt,
 				AspectRatio:     parsed.AspectRatio,
 				Resolution:      parsed.Resolution,
 				NegativePrompt:  parsed.NegativePrompt,
@@ -165,6 +158,8 @@ func Image2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *vi
 				GenerateAudio:   parsed.GenerateAudio,
 				EndImageURL:     parsed.EndImageURL,
 				Seed:            parsed.Seed,
+				Subtitles:       subtitleText(parsed),
+				BurnSubtitles:   parsed.BurnSubtitles,
 			}
 
 			// Set the correct image URL field based on the model
@@ -178,8 +173,8 @@ func Image2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *vi
 			if msgCtx.IsPM {
 				if model.PerSecondPricing {
 					msg := fmt.Sprintf(
-						"Model: %s\n💰 Price: $%.2f per video second\nRequested duration: %d seconds\nTotal cost: $%.2f = $%.2f/sec × %d sec",
-						model.Name, model.PriceUSD, durInt, totalCost, model.PriceUSD, durInt,
+						"Model: %s\n💰 Price: $%.2f per video second\nRequested duration: %d seconds\nTotal cost: $%.2f = $%.2f/sec × %d sec%s",
+						model.Name, model.PriceUSD, durInt, totalCost, model.PriceUSD, durInt, serviceFeeNote(model, faladapter.PriceParams{Seconds: durInt}),
 					)
 					if originalUserDuration == "" {
 						msg += fmt.Sprintf("\n(No duration specified, using default duration of %d seconds.)", durInt)
@@ -187,8 +182,8 @@ func Image2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *vi
 					msgSender.SendMessage(ctx, msgCtx, msg)
 				} else {
 					msgSender.SendMessage(ctx, msgCtx, fmt.Sprintf(
-						"Model: %s\n💰 Flat fee: $%.2f per video",
-						model.Name, model.PriceUSD,
+						"Model: %s\n💰 Flat fee: $%.2f per video%s",
+						model.Name, model.PriceUSD, serviceFeeNote(model, faladapter.PriceParams{}),
 					))
 				}
 			}
@@ -201,6 +196,11 @@ func Image2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *vi
 				return handleErr // Propagate error if not handled by the utility function
 			}
 
+			// Remember the generation for !variations and --seed last
+			if err == nil && result.IsSuccess() {
+				rememberGeneration(dbManager, msgCtx, "image2video", model.Name, args, req.Seed, result.JobID)
+			}
+
 			// If we reach here, the operation was successful and errors were handled
 			return nil
 		}),
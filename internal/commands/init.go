@@ -1,21 +1,46 @@
 package commands
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/internal/assets"
+	"github.com/karamble/braibot/internal/chat"
 	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/debuglog"
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/falhook"
 	"github.com/karamble/braibot/internal/image"
+	"github.com/karamble/braibot/internal/joblog"
+	"github.com/karamble/braibot/internal/model3d"
 	"github.com/karamble/braibot/internal/speech"
+	"github.com/karamble/braibot/internal/transcribe"
+	"github.com/karamble/braibot/internal/transfer"
 	"github.com/karamble/braibot/internal/video"
+	"github.com/karamble/braibot/internal/vision"
 	"github.com/karamble/braibot/pkg/fal"
+	"github.com/karamble/braibot/pkg/genai"
 	kit "github.com/vctt94/bisonbotkit"
 	"github.com/vctt94/bisonbotkit/config"
 )
 
-// InitializeCommands creates and registers all available commands
-func InitializeCommands(dbManager *database.DBManager, cfg *config.BotConfig, bot *kit.Bot, debug bool) *Registry {
+// InitializeCommands creates and registers all available commands. The
+// services log to their loggers of logs; nil logs to stdout.
+func InitializeCommands(dbManager *database.DBManager, cfg *config.BotConfig, bot *kit.Bot, logs debuglog.Backend, debug bool) *Registry {
 	registry := NewRegistry()
+	faladapter.SetLogger(joblog.New(logs, joblog.Adapter))
+
+	// Restore the model selections users made before the last restart
+	if models, err := dbManager.GetAllUserModels(); err != nil {
+		fmt.Printf("WARN: Failed to load model selections: %v\n", err)
+	} else {
+		faladapter.LoadUserModels(models)
+	}
 
 	// Create Fal client (assuming API key is in extra config)
-	falClient := fal.NewClient(cfg.ExtraConfig["falapikey"], fal.WithDebug(debug))
+	falClient := fal.NewClient(cfg.ExtraConfig["falapikey"], FalClientOptions(cfg.ExtraConfig)...)
 
 	// Get billing enabled flag from config (defaulting to true)
 	billingEnabledStr := cfg.ExtraConfig["billingenabled"] // Already validated in config check
@@ -28,37 +53,263 @@ func InitializeCommands(dbManager *database.DBManager, cfg *config.BotConfig, bo
 	registry.SetWebhookEnabled(webhookEnabled)
 
 	// Create Services, passing the billing flag
-	imageService := image.NewImageService(falClient, dbManager, bot, debug, billingEnabled)
-	videoService := video.NewVideoService(falClient, dbManager, bot, debug, billingEnabled)    // Assuming NewVideoService signature is updated
+	imageService := image.NewImageService(ImageGeneratorFromConfig(cfg.ExtraConfig, falClient), dbManager, bot, debug, billingEnabled)
+	imageService.SetLogger(joblog.New(logs, joblog.Image))
+	if v, err := strconv.Atoi(cfg.ExtraConfig["maxembedbytes"]); err == nil && v > 0 {
+		imageService.SetMaxEmbedBytes(v)
+	}
+	imageService.SetPreviewPolicy(previewPolicyFromConfig(cfg.ExtraConfig))
+	imageService.SetNSFWPolicy(nsfwPolicyFromConfig(cfg.ExtraConfig))
+	videoService := video.NewVideoService(falClient, dbManager, bot, debug, billingEnabled) // Assuming NewVideoService signature is updated
+	videoService.SetTransferLimits(transferLimitsFromConfig(cfg.ExtraConfig))
+	videoService.SetLogger(joblog.New(logs, joblog.Video))
 	speechService := speech.NewSpeechService(falClient, dbManager, bot, debug, billingEnabled) // Assuming NewSpeechService signature is updated
+	speechService.SetLogger(joblog.New(logs, joblog.Speech))
+	if v, err := strconv.Atoi(cfg.ExtraConfig["maxttschars"]); err == nil && v > 0 {
+		speechService.SetMaxTextChars(v)
+	}
+	transcribeService := transcribe.NewTranscribeService(falClient, dbManager, bot, debug, billingEnabled)
+	transcribeService.SetLogger(joblog.New(logs, joblog.Transcribe))
+	chatService := chat.NewChatService(falClient, dbManager, bot, debug, billingEnabled)
+	chatService.SetLogger(joblog.New(logs, joblog.Chat))
+	visionService := vision.NewVisionService(falClient, dbManager, bot, debug, billingEnabled)
+	visionService.SetLogger(joblog.New(logs, joblog.Vision))
+	model3DService := model3d.NewModel3DService(falClient, dbManager, bot, debug, billingEnabled)
+	model3DService.SetTransferLimits(transferLimitsFromConfig(cfg.ExtraConfig))
+	model3DService.SetLogger(joblog.New(logs, joblog.Model3D))
+	if publisher := assetPublisherFromConfig(cfg.ExtraConfig); publisher != nil {
+		imageService.SetAssetPublisher(publisher)
+		videoService.SetAssetPublisher(publisher)
+		speechService.SetAssetPublisher(publisher)
+		model3DService.SetAssetPublisher(publisher)
+	}
+	if v, err := strconv.Atoi(cfg.ExtraConfig["gcmaxembedbytes"]); err == nil && v > 0 {
+		imageService.SetGCMaxEmbedBytes(v)
+		speechService.SetGCMaxEmbedBytes(v)
+	}
+	// !admin billing toggles charging in every service
+	registry.OnBillingChange(imageService.SetBillingEnabled)
+	registry.OnBillingChange(videoService.SetBillingEnabled)
+	registry.OnBillingChange(speechService.SetBillingEnabled)
+	registry.OnBillingChange(transcribeService.SetBillingEnabled)
+	registry.OnBillingChange(chatService.SetBillingEnabled)
+	registry.OnBillingChange(visionService.SetBillingEnabled)
+	registry.OnBillingChange(model3DService.SetBillingEnabled)
 
 	// Register help command
 	registry.Register(HelpCommand(registry, dbManager))
+	registry.Register(CommandsCommand(registry))
+	registry.Register(AboutCommand(registry, cfg))
 
 	// Register model-related commands
 	registry.Register(ListModelsCommand())
-	registry.Register(SetModelCommand(registry))
+	registry.Register(ModelsCommand())
+	registry.Register(SetModelCommand(registry, dbManager))
 
 	// Register AI commands (using services)
 	// Pass the billingEnabled flag to commands that might need it directly (like balance)
 
-	registry.Register(Image2ImageCommand(bot, cfg, imageService, debug))
-	registry.Register(Image2VideoCommand(bot, cfg, videoService, debug))
+	registry.Register(Image2ImageCommand(bot, cfg, imageService, dbManager, debug))
+	registry.Register(RestoreCommand(bot, cfg, imageService, dbManager, debug))
+	registry.Register(RemoveBGCommand(bot, cfg, imageService, dbManager, debug))
+	registry.Register(InpaintCommand(bot, cfg, imageService, dbManager, debug))
+	registry.Register(AnimateSVGCommand(bot, imageService))
+	registry.Register(Image2VideoCommand(bot, cfg, videoService, dbManager, debug))
 
-	registry.Register(AICommand(bot, cfg, debug))
+	voiceChat := NewVoiceChat(registry, bot, dbManager, transcribeService, speechService, debug)
+	registry.Register(AICommand(registry, bot, cfg, voiceChat, debug))
 
-	registry.Register(BalanceCommand())
+	registry.Register(BalanceCommand(dbManager))
+	registry.Register(TopupCommand())
+	registry.Register(WithdrawCommand())
 	registry.Register(RateCommand())
+	registry.Register(EstimateCommand(registry, dbManager))
+	registry.Register(ConfirmCommand())
+	registry.Register(NotifyCommand(dbManager))
+	registry.Register(RedeliverCommand(dbManager, videoService))
+	registry.Register(ResendCommand(dbManager, videoService))
+	registry.Register(RefundCommand(dbManager, bot, cfg))
+	registry.Register(PromoCommand(dbManager, cfg))
+	registry.Register(ShareCommand(dbManager, bot))
+	registry.Register(PotCommand(dbManager))
+	registry.Register(MuteCommand(dbManager))
+	registry.Register(UnmuteCommand(dbManager))
+	registry.Register(SetCommand(dbManager))
+	registry.Register(UnsetCommand(dbManager))
+	registry.Register(SettingsCommand(dbManager))
+	registry.Register(LastCommand(dbManager))
+	registry.Register(VariationsCommand(registry, dbManager))
+	registry.Register(PromptCommand(dbManager))
+	registry.Register(BatchCommand(registry, dbManager))
+	registry.Register(ScheduleCommand(dbManager))
+	registry.Register(LeaderboardCommand(dbManager))
+	registry.Register(QueueCommand())
+	registry.Register(StatusCommand(bot, falClient))
+	registry.Register(LimitsCommand(dbManager))
+	registry.Register(CancelCommand())
 
-	registry.Register(Text2ImageCommand(bot, cfg, imageService, debug))
+	registry.Register(Text2ImageCommand(bot, cfg, imageService, dbManager, debug))
 
 	registry.Register(Text2SpeechCommand(bot, cfg, speechService, debug))
 
-	registry.Register(Text2VideoCommand(bot, cfg, videoService, debug))
+	registry.Register(CleanAudioCommand(bot, cfg, speechService, dbManager, debug))
+
+	registry.Register(VoiceSwapCommand(bot, cfg, speechService, dbManager))
+
+	registry.Register(Speech2TextCommand(bot, transcribeService, dbManager))
+	registry.Register(TranscribeCommand(bot, transcribeService, dbManager, transcribe.DefaultGCNotes))
+
+	registry.Register(ChatCommand(bot, chatService))
+
+	registry.Register(DescribeCommand(bot, visionService, dbManager))
 
-	registry.Register(Video2VideoCommand(bot, cfg, videoService, debug))
+	registry.Register(Image23DCommand(bot, model3DService, dbManager))
 
-	registry.Register(Multi2VideoCommand(bot, cfg, videoService, debug))
+	registry.Register(Text2VideoCommand(bot, cfg, videoService, dbManager, debug))
+
+	registry.Register(Video2VideoCommand(bot, cfg, videoService, dbManager, debug))
+
+	registry.Register(Multi2VideoCommand(bot, cfg, videoService, dbManager, debug))
+
+	// Register admin command
+	registry.Register(AdminCommand(registry, cfg, dbManager, bot, falClient))
 
 	return registry
 }
+
+// previewPolicyFromConfig reads the text2image preview settings, keeping the
+// defaults for absent or invalid keys.
+func previewPolicyFromConfig(extra map[string]string) image.PreviewPolicy {
+	p := image.DefaultPreviewPolicy
+	if v := strings.ToLower(extra["previewenabled"]); v == "1" || v == "true" {
+		p.Enabled = true
+	}
+	if v := extra["previewmodel"]; v != "" {
+		p.Model = v
+	}
+	if v := extra["previewsize"]; v != "" {
+		p.ImageSize = v
+	}
+	if v, err := strconv.ParseFloat(extra["previewprice"], 64); err == nil && v >= 0 {
+		p.PriceUSD = v
+	}
+	if v, err := strconv.Atoi(extra["previewdaily"]); err == nil && v >= 0 {
+		p.DailyLimit = v
+	}
+	if v, err := strconv.ParseFloat(extra["previewminprice"], 64); err == nil && v >= 0 {
+		p.MinPriceUSD = v
+	}
+	return p
+}
+
+// FalClientOptions returns the options of the bot's fal clients: debug
+// logging, webhooks when they are on, and the retry policy and request
+// timeout set in braibot.conf.
+func FalClientOptions(extra map[string]string) []fal.ClientOption {
+	retry := fal.DefaultRetryPolicy
+	if v, err := strconv.Atoi(extra["falretries"]); err == nil && v >= 0 {
+		retry.MaxAttempts = v + 1
+	}
+	if v, err := time.ParseDuration(extra["falretrydelay"]); err == nil && v > 0 {
+		retry.BaseDelay = v
+	}
+	if v, err := time.ParseDuration(extra["falretrymaxdelay"]); err == nil && v > 0 {
+		retry.MaxDelay = v
+	}
+	opts := []fal.ClientOption{
+		fal.WithDebugLogger(debuglog.EnabledFunc(debuglog.Fal), debuglog.Logf(debuglog.Fal)),
+		fal.WithRetry(retry),
+	}
+	if v, err := time.ParseDuration(extra["falrequesttimeout"]); err == nil && v > 0 {
+		opts = append(opts, fal.WithRequestTimeout(v))
+	}
+	return append(opts, falhook.ClientOptions()...)
+}
+
+// nsfwPolicyFromConfig reads how images flagged as NSFW are delivered in PMs
+// (nsfwpolicy=) and GCs (nsfwgcpolicy=).
+func nsfwPolicyFromConfig(extra map[string]string) image.NSFWPolicy {
+	p := image.DefaultNSFWPolicy
+	if a, err := image.ParseNSFWAction(extra["nsfwpolicy"]); err == nil {
+		p.PM = a
+	}
+	if a, err := image.ParseNSFWAction(extra["nsfwgcpolicy"]); err == nil {
+		p.GC = a
+	}
+	return p
+}
+
+// ImageGeneratorFromConfig returns what the image services generate with:
+// falClient, with the models the model definition file routes to other
+// providers sent there. Models of providers without credentials in
+// braibot.conf (replicateapikey=) keep running on fal.
+func ImageGeneratorFromConfig(extra map[string]string, falClient *fal.Client) genai.ImageGenerator {
+	routes := faladapter.ProviderRoutes()
+	if len(routes) == 0 {
+		return falClient
+	}
+	router := genai.NewRouter(falClient)
+	var replicate *genai.Replicate
+	if token := strings.TrimSpace(extra["replicateapikey"]); token != "" {
+		replicate = genai.NewReplicate(token)
+	}
+	for name, route := range routes {
+		switch {
+		case route.Provider == "replicate" && replicate != nil:
+			router.RouteImages(name, replicate.Images(route.Model))
+		default:
+			fmt.Printf("WARN: %s runs on fal: no credentials for %s\n", name, route.Provider)
+		}
+	}
+	return router
+}
+
+// assetPublisherFromConfig returns the asset server set with
+// assetserverurl= and assetserverkey=, or nil when it is not configured.
+func assetPublisherFromConfig(extra map[string]string) *assets.Publisher {
+	serverURL, key := extra["assetserverurl"], extra["assetserverkey"]
+	if serverURL == "" || key == "" {
+		return nil
+	}
+	ttl := time.Hour
+	if v, err := time.ParseDuration(extra["assetlinkttl"]); err == nil && v > 0 {
+		ttl = v
+	}
+	publisher := assets.NewPublisher(assets.ServerStore{URL: serverURL, APIKey: key}, key, ttl)
+	publisher.SetCache(assets.DefaultCache)
+	return publisher
+}
+
+// prefersAssetLink reports whether the command's results in group chats
+// are posted as asset-server links, as listed in assetlinks=.
+func prefersAssetLink(cfg *config.BotConfig, command string) bool {
+	for _, c := range strings.Split(cfg.ExtraConfig["assetlinks"], ",") {
+		if strings.EqualFold(strings.TrimSpace(c), command) {
+			return true
+		}
+	}
+	return false
+}
+
+// transferLimitsFromConfig reads the video and 3D model delivery limits from
+// braibot.conf.
+func transferLimitsFromConfig(extra map[string]string) transfer.Limits {
+	l := transfer.DefaultLimits
+	if v, err := strconv.ParseInt(extra["maxdownloadbytes"], 10, 64); err == nil && v > 0 {
+		l.MaxFileBytes = v
+	}
+	if v, err := strconv.ParseInt(extra["maxattachbytes"], 10, 64); err == nil && v > 0 {
+		l.AttachLimitBytes = v
+	}
+	if v, ok := extra["ffmpegpath"]; ok {
+		if strings.EqualFold(v, "off") {
+			v = ""
+		}
+		l.FFmpegPath = v
+	}
+	if v, err := time.ParseDuration(extra["linklifetime"]); err == nil && v > 0 {
+		l.LinkLifetime = v
+	}
+	l.Cache = assets.DefaultCache
+	return l
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	imgservice "github.com/karamble/braibot/internal/image"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	kit "github.com/vctt94/bisonbotkit"
+	botconfig "github.com/vctt94/bisonbotkit/config"
+)
+
+// inpaintModel is the image2image model used by !inpaint.
+const inpaintModel = "flux-pro/v1/fill"
+
+// InpaintCommand returns the inpaint command
+func InpaintCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *imgservice.ImageService, dbManager *database.DBManager, debug bool) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "inpaint",
+		Description: "🖌️ Repaint the masked part of an image. Usage: !inpaint [image_url] [mask_url] [prompt]",
+		Category:    "AI Generation",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			// Create a message sender using the adapter
+			msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
+
+			args, shared, sharedErr := extractSharedFlags(args, msgCtx.IsPM)
+			if sharedErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(sharedErr.Error()))
+			}
+
+			// Swap "last" for the user's recent results
+			args, lastErr := resolveLastArgs(dbManager, msgCtx.Sender.String(), args, database.ResultImage, database.ResultImage)
+			if lastErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(lastErr.Error()))
+			}
+
+			model, exists := faladapter.GetModel(inpaintModel, "image2image")
+			if !exists {
+				return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("model not found: %s", inpaintModel))
+			}
+
+			var userID zkidentity.ShortID
+			userID.FromBytes(msgCtx.Uid)
+
+			if len(args) < 3 {
+				header := utils.FormatCommandHelpHeader("inpaint", model, userID, msgCtx.IsPM, db)
+				return msgSender.SendMessage(ctx, msgCtx, header+model.HelpDoc)
+			}
+
+			// Validate URLs
+			imageURL, maskURL := args[0], args[1]
+			for _, u := range []string{imageURL, maskURL} {
+				parsedURL, err := url.Parse(u)
+				if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+					return msgSender.SendMessage(ctx, msgCtx, "Please provide valid http:// or https:// URLs for the image and the mask.")
+				}
+			}
+
+			req := &imgservice.ImageRequest{ImageURL: imageURL, MaskURL: maskURL}
+			prompt, err := parseImageEditArgs(args[2:], req)
+			if err != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+			if prompt == "" {
+				return msgSender.SendMessage(ctx, msgCtx, "Please describe what to paint into the masked area.")
+			}
+			req.Prompt = prompt
+
+			// Create progress callback
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "inpaint")
+
+			req.GenerationRequest = braibottypes.GenerationRequest{
+				ModelType:    "image2image",
+				ModelName:    model.Name,
+				Progress:     progress,
+				UserNick:     msgCtx.Nick,
+				UserID:       userID,
+				PriceUSD:     faladapter.PriceFor(model, faladapter.PriceParams{}),
+				IsPM:         msgCtx.IsPM,
+				GC:           msgCtx.GC,
+				SplitPercent: shared.SplitPercent,
+				NoCache:      shared.NoCache,
+				AssetLink:    prefersAssetLink(cfg, "inpaint"),
+			}
+
+			result, err := imageService.GenerateImage(ctx, req)
+
+			// Handle result/error using the utility function
+			if handleErr := utils.HandleServiceResultOrError(ctx, bot, msgCtx, "inpaint", result, err); handleErr != nil {
+				return handleErr
+			}
+
+			return nil
+		}),
+	}
+}
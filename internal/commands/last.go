@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/karamble/braibot/internal/database"
+	braibottypes "github.com/karamble/braibot/internal/types"
+)
+
+// parseLastArg reports whether arg refers to a recent result: "last" for
+// the newest result of the kind the command expects, or "last:N" for the
+// Nth entry listed by !last. n is 0 for plain "last".
+func parseLastArg(arg string) (n int, ok bool) {
+	lower := strings.ToLower(arg)
+	if lower == "last" {
+		return 0, true
+	}
+	rest, found := strings.CutPrefix(lower, "last:")
+	if !found {
+		return 0, false
+	}
+	n, err := strconv.Atoi(rest)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
+
+// flagResultKind returns the kind of result a media flag such as
+// --end_image or --video1 takes, or "" for other flags.
+func flagResultKind(flag string) string {
+	flag = strings.ToLower(flag)
+	switch {
+	case strings.Contains(flag, "image"):
+		return database.ResultImage
+	case strings.Contains(flag, "video"):
+		return database.ResultVideo
+	case strings.Contains(flag, "audio"):
+		return database.ResultAudio
+	}
+	return ""
+}
+
+// resolveLastArgs replaces "last" and "last:N" with the URLs of the user's
+// recent results. The keyword is recognized in the leading positional
+// arguments, whose kinds are given in order, and as the value of media
+// flags, so a prompt mentioning "last" is left alone.
+func resolveLastArgs(dbManager *database.DBManager, uid string, args []string, kinds ...string) ([]string, error) {
+	resolved := make([]string, len(args))
+	copy(resolved, args)
+
+	resolve := func(i int, kind string) error {
+		n, ok := parseLastArg(resolved[i])
+		if !ok || kind == "" {
+			return nil
+		}
+		if dbManager == nil {
+			return fmt.Errorf("recent results are not available")
+		}
+		if n > 0 {
+			kind = ""
+		}
+		results, err := dbManager.RecentResults(uid, kind)
+		if err != nil {
+			return err
+		}
+		switch {
+		case n == 0 && len(results) == 0:
+			return fmt.Errorf("you have no recent %s to use as last. Generate one first or pass a URL", kind)
+		case n > len(results):
+			return fmt.Errorf("there is no result %d in your !last list", n)
+		}
+		resolved[i] = results[max(n-1, 0)].URL
+		return nil
+	}
+
+	positional := 0
+	for i := 0; i < len(resolved); i++ {
+		arg := resolved[i]
+		if strings.HasPrefix(arg, "--") {
+			if strings.Contains(arg, "=") || i+1 >= len(resolved) || strings.HasPrefix(resolved[i+1], "--") {
+				continue
+			}
+			i++ // The flag's value
+			if err := resolve(i, flagResultKind(arg)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if positional < len(kinds) {
+			if err := resolve(i, kinds[positional]); err != nil {
+				return nil, err
+			}
+		}
+		positional++
+	}
+	return resolved, nil
+}
+
+// LastCommand returns the last command, which lists the user's recent
+// results for reuse with "last".
+func LastCommand(dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "last",
+		Description: "🕘 List your recent results to reuse with last. Usage: !last [image|video|audio]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			var kind string
+			if len(args) > 0 {
+				kind = strings.ToLower(args[0])
+				if kind != database.ResultImage && kind != database.ResultVideo && kind != database.ResultAudio {
+					return sender.SendMessage(ctx, msgCtx, "Usage: !last [image|video|audio]")
+				}
+			}
+			results, err := dbManager.RecentResults(msgCtx.Sender.String(), kind)
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			if len(results) == 0 {
+				return sender.SendMessage(ctx, msgCtx, "You have no recent results yet.")
+			}
+			return sender.SendMessage(ctx, msgCtx, formatRecentResults(results, kind == ""))
+		}),
+	}
+}
+
+// formatRecentResults lists results newest first. Entries are numbered for
+// last:N only when the list is unfiltered, since N counts every kind.
+func formatRecentResults(results []database.RecentResult, numbered bool) string {
+	var b strings.Builder
+	b.WriteString("🕘 Your recent results, newest first:\n")
+	for i, r := range results {
+		prefix := "•"
+		if numbered {
+			prefix = fmt.Sprintf("%d.", i+1)
+		}
+		fmt.Fprintf(&b, "%s %s from %s, %s: %s\n", prefix, r.Kind, r.Model, r.CreatedAt.UTC().Format("Jan 2 15:04 MST"), r.URL)
+	}
+	b.WriteString("\nPass last to a command to reuse your newest image, video or audio, e.g. !image2image last make it a watercolor. Use last:N to pick entry N of this list.")
+	return b.String()
+}
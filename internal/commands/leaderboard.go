@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/internal/database"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// leaderboardSize is how many users and models a leaderboard lists.
+const leaderboardSize = 5
+
+// leaderboardHelp documents the leaderboard command.
+const leaderboardHelp = "Usage: !leaderboard [week|month|hide|show]\n\n" +
+	"Shows this group chat's top requesters, most used models and total artworks.\n" +
+	"• !leaderboard: Last 7 days\n" +
+	"• !leaderboard month: Last 30 days\n" +
+	"• !leaderboard hide: Never list me on leaderboards\n" +
+	"• !leaderboard show: List me again\n\n" +
+	"Group chats have to be opted in by a bot admin."
+
+// LeaderboardCommand returns the leaderboard command, which shows generation
+// stats for group chats that opted in.
+func LeaderboardCommand(dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "leaderboard",
+		Description: "🏆 Top requesters and models in this group chat. Usage: !leaderboard [week|month|hide|show]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			period, days := "week", 7
+			if len(args) > 0 {
+				switch strings.ToLower(args[0]) {
+				case "hide", "show":
+					hidden := strings.ToLower(args[0]) == "hide"
+					if err := dbManager.SetLeaderboardHidden(msgCtx.Sender.String(), hidden); err != nil {
+						return sender.SendErrorMessage(ctx, msgCtx, err)
+					}
+					if hidden {
+						return sender.SendMessage(ctx, msgCtx, "You won't be listed on leaderboards. Your generations still count toward the totals.")
+					}
+					return sender.SendMessage(ctx, msgCtx, "You can be listed on leaderboards again.")
+				case "week":
+				case "month":
+					period, days = "month", 30
+				default:
+					return sender.SendMessage(ctx, msgCtx, leaderboardHelp)
+				}
+			}
+
+			if msgCtx.IsPM {
+				return sender.SendMessage(ctx, msgCtx, "Leaderboards are shown in group chats.\n\n"+leaderboardHelp)
+			}
+			enabled, err := dbManager.LeaderboardEnabled(msgCtx.GC)
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			if !enabled {
+				return sender.SendMessage(ctx, msgCtx, "Leaderboards are not enabled in this group chat. A bot admin can turn them on.")
+			}
+
+			lb, err := dbManager.GetLeaderboard(msgCtx.GC, time.Now().AddDate(0, 0, -days), leaderboardSize)
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			return sender.SendMessage(ctx, msgCtx, formatLeaderboard(lb, period, days))
+		}),
+	}
+}
+
+// formatLeaderboard renders a leaderboard as a message.
+func formatLeaderboard(lb database.Leaderboard, period string, days int) string {
+	if lb.Jobs == 0 {
+		return fmt.Sprintf("🏆 Nothing was generated here in the last %d days.", days)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "🏆 **Leaderboard of the %s** (last %d days)\n\n", period, days)
+	fmt.Fprintf(&b, "%d artworks from %d requests.\n", lb.Items, lb.Jobs)
+	if len(lb.TopUsers) > 0 {
+		b.WriteString("\n**Top requesters**\n")
+		for i, e := range lb.TopUsers {
+			fmt.Fprintf(&b, "%d. %s: %d\n", i+1, utils.SanitizeUserText(e.Name), e.Count)
+		}
+	}
+	b.WriteString("\n**Most used models**\n")
+	for i, e := range lb.TopModels {
+		fmt.Fprintf(&b, "%d. %s: %d\n", i+1, e.Name, e.Count)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
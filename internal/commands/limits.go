@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/internal/database"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// LimitsCommand returns the limits command, which shows a user's spending
+// limits and what is left of them.
+func LimitsCommand(dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "limits",
+		Description: "📏 Show your daily and weekly spending limits and what is left of them. Usage: !limits",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			a, err := utils.GetSpendAllowance(dbManager, msgCtx.Sender.String(), time.Now())
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			return sender.SendMessage(ctx, msgCtx, formatSpendAllowance(a))
+		}),
+	}
+}
+
+// formatSpendAllowance renders a user's spending limits.
+func formatSpendAllowance(a utils.SpendAllowance) string {
+	if a.Limits.DailyUSD == 0 && a.Limits.WeeklyUSD == 0 {
+		return "📏 You have no spending limits; generations are only limited by your balance."
+	}
+	var b strings.Builder
+	b.WriteString("📏 **Your spending limits**\n\n")
+	writeLimit := func(label, window string, limit, spent, left float64) {
+		if limit == 0 {
+			fmt.Fprintf(&b, "• %s: unlimited\n", label)
+			return
+		}
+		fmt.Fprintf(&b, "• %s: $%.2f spent of $%.2f in the last %s, $%.2f left\n", label, spent, limit, window, left)
+	}
+	writeLimit("Daily", "24 hours", a.Limits.DailyUSD, a.SpentDay, a.RemainingDay())
+	writeLimit("Weekly", "7 days", a.Limits.WeeklyUSD, a.SpentWeek, a.RemainingWeek())
+	return strings.TrimSuffix(b.String(), "\n")
+}
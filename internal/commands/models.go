@@ -3,11 +3,17 @@ package commands
 import (
 	"context"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
 	"github.com/karamble/braibot/internal/faladapter"
 	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/pkg/fal"
 )
 
 // ListModelsCommand returns the listmodels command
@@ -34,8 +40,199 @@ func ListModelsCommand() braibottypes.Command {
 	}
 }
 
-// SetModelCommand returns the setmodel command
-func SetModelCommand(registry *Registry) braibottypes.Command {
+// modelFilter selects and orders the models !models lists.
+type modelFilter struct {
+	task     string
+	maxPrice float64 // Highest unit price in USD; 0 lists every price
+	search   string  // Text the name or description must contain
+	sortBy   string  // "price" or "name"
+}
+
+// parseModelsArgs parses the task and flags of !models.
+func parseModelsArgs(args []string) (modelFilter, error) {
+	f := modelFilter{sortBy: "price"}
+	for i := 0; i < len(args); i++ {
+		arg := strings.ToLower(args[i])
+		if !strings.HasPrefix(arg, "--") {
+			if f.task != "" {
+				return f, fmt.Errorf("unexpected argument %s", args[i])
+			}
+			f.task = arg
+			continue
+		}
+		switch arg {
+		case "--max-price", "--max_price", "--sort", "--search":
+		default:
+			return f, fmt.Errorf("unknown flag %s (use --max-price, --sort or --search)", args[i])
+		}
+		if i+1 >= len(args) {
+			return f, fmt.Errorf("missing value for %s", arg)
+		}
+		value := args[i+1]
+		i++
+
+		switch arg {
+		case "--sort":
+			value = strings.ToLower(value)
+			if value != "price" && value != "name" {
+				return f, fmt.Errorf("invalid value for --sort: %s (must be price or name)", value)
+			}
+			f.sortBy = value
+		case "--search":
+			f.search = strings.ToLower(value)
+		default:
+			price, err := strconv.ParseFloat(strings.TrimPrefix(value, "$"), 64)
+			if err != nil || price <= 0 {
+				return f, fmt.Errorf("invalid value for --max-price: %s (must be a USD amount such as 0.05)", value)
+			}
+			f.maxPrice = price
+		}
+	}
+	return f, nil
+}
+
+// filterModels returns the models of f.task matching f, in f's order. Models
+// are compared by their unit price: per request, per second or per 1000
+// characters.
+func filterModels(models map[string]faladapter.AppModel, f modelFilter) []faladapter.AppModel {
+	var matched []faladapter.AppModel
+	for _, m := range models {
+		if f.maxPrice > 0 && m.PriceUSD > f.maxPrice+1e-9 {
+			continue
+		}
+		if f.search != "" && !strings.Contains(strings.ToLower(m.Name+" "+m.Description), f.search) {
+			continue
+		}
+		matched = append(matched, m)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if f.sortBy == "price" && matched[i].PriceUSD != matched[j].PriceUSD {
+			return matched[i].PriceUSD < matched[j].PriceUSD
+		}
+		return matched[i].Name < matched[j].Name
+	})
+	return matched
+}
+
+// formatModelPrice shows the unit price of m in USD and, when the exchange
+// rate is known, DCR, with a badge for prices that scale with the request
+// and the service fee charged on top.
+func formatModelPrice(m faladapter.AppModel, dcrPrice float64) string {
+	usd := fmt.Sprintf("$%.2f", m.PriceUSD)
+	if cents := m.PriceUSD * 100; math.Abs(cents-math.Round(cents)) > 1e-9 {
+		usd = fmt.Sprintf("$%.4f", m.PriceUSD)
+	}
+	if dcrPrice > 0 {
+		usd += fmt.Sprintf(" (%.8f DCR)", m.PriceUSD/dcrPrice)
+	}
+	switch {
+	case m.PerSecondPricing:
+		usd += " ⏱️ per second"
+	case m.PerThousandChars:
+		usd += " 🔤 per 1000 characters"
+	case m.PerMillionTokens:
+		usd += " 💬 per million tokens"
+	}
+	if m.BasePriceUSD > 0 {
+		usd += fmt.Sprintf(" + $%.2f base", m.BasePriceUSD)
+	}
+	if markup := faladapter.MarkupFor(m.Name); !markup.IsZero() {
+		usd += " + " + markup.String()
+	}
+	return usd
+}
+
+// formatModelList lists models for !models, marking the one current runs
+// with. dcrPrice is 0 when the exchange rate is unknown.
+func formatModelList(f modelFilter, models []faladapter.AppModel, current string, dcrPrice float64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📋 Models for %s, by %s", f.task, f.sortBy)
+	if f.maxPrice > 0 {
+		fmt.Fprintf(&b, ", up to $%g", f.maxPrice)
+	}
+	if f.search != "" {
+		fmt.Fprintf(&b, ", matching %q", f.search)
+	}
+	b.WriteString(":\n")
+	if len(models) == 0 {
+		b.WriteString("No models match. Try a higher --max-price or another --search.")
+		return b.String()
+	}
+	for _, m := range models {
+		marker := "•"
+		if m.Name == current {
+			marker = "✅"
+		}
+		fmt.Fprintf(&b, "%s %s: %s — %s\n", marker, m.Name, formatModelPrice(m, dcrPrice), m.Description)
+	}
+	if current != "" {
+		fmt.Fprintf(&b, "\n✅ = the model used for your requests here. Change it with !setmodel %s [model].", f.task)
+	}
+	return b.String()
+}
+
+// formatModelTasks lists the tasks !models can list models for, with their
+// number of models.
+func formatModelTasks() string {
+	counts := fal.ModelCounts()
+	tasks := make([]string, 0, len(counts))
+	for task := range counts {
+		if models, ok := faladapter.GetModels(task); ok && len(models) > 0 {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Strings(tasks)
+	var b strings.Builder
+	b.WriteString("📋 Tasks with models:\n")
+	for _, task := range tasks {
+		models, _ := faladapter.GetModels(task)
+		fmt.Fprintf(&b, "• %s (%d)\n", task, len(models))
+	}
+	b.WriteString("\nUsage: !models [task] [--max-price USD] [--sort price|name] [--search text]")
+	return b.String()
+}
+
+// ModelsCommand returns the models command, listing the models of a task
+// with their prices and the caller's current pick.
+func ModelsCommand() braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "models",
+		Description: "📋 Browse the models of a task with prices in USD and DCR. Usage: !models [task] [--max-price USD] [--sort price|name] [--search text]",
+		Category:    "Model Configuration",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			f, err := parseModelsArgs(args)
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			if f.task == "" {
+				return sender.SendMessage(ctx, msgCtx, formatModelTasks())
+			}
+			models, exists := faladapter.GetModels(f.task)
+			if !exists || len(models) == 0 {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("No models found for %s. Use !models to see the tasks.", utils.SanitizeUserText(f.task)))
+			}
+
+			// Personal picks only apply in PMs, like !setmodel
+			var userID string
+			if msgCtx.IsPM {
+				userID = msgCtx.Sender.String()
+			}
+			var current string
+			if m, ok := faladapter.GetCurrentModel(f.task, userID); ok {
+				current = m.Name
+			}
+			dcrPrice, _, err := utils.GetDCRPrice()
+			if err != nil {
+				dcrPrice = 0
+			}
+			return sender.SendMessage(ctx, msgCtx, formatModelList(f, filterModels(models, f), current, dcrPrice))
+		}),
+	}
+}
+
+// SetModelCommand returns the setmodel command. Personal selections made in
+// PMs are saved to dbManager so they survive restarts.
+func SetModelCommand(registry *Registry, dbManager *database.DBManager) braibottypes.Command {
 	return braibottypes.Command{
 		Name:        "setmodel",
 		Description: "⚙️ Set the default AI model for a specific task",
@@ -58,6 +255,11 @@ func SetModelCommand(registry *Registry) braibottypes.Command {
 			if err := faladapter.SetCurrentModel(task, modelName, userID); err != nil {
 				return sender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("failed to set model: %v", err))
 			}
+			if userID != "" && dbManager != nil {
+				if err := dbManager.SetUserModel(userID, task, modelName); err != nil {
+					fmt.Printf("WARN: Failed to save model selection for %s: %v\n", msgCtx.Nick, err)
+				}
+			}
 
 			// Different message based on context
 			if msgCtx.IsPM {
@@ -3,9 +3,9 @@ package commands
 import (
 	"context"
 	"fmt"
-	"strconv"
 
 	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
 	"github.com/karamble/braibot/internal/faladapter"
 	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
@@ -18,7 +18,7 @@ import (
 // Multi2VideoCommand returns the multi2video (reference-to-video) command.
 // It accepts a prompt plus any combination of reference images (up to 9),
 // videos (up to 3), and audio files (up to 3).
-func Multi2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *video.VideoService, debug bool) braibottypes.Command {
+func Multi2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *video.VideoService, dbManager *database.DBManager, debug bool) braibottypes.Command {
 	// Get the current model to use its description
 	model, exists := faladapter.GetCurrentModel("multi2video", "")
 	if !exists {
@@ -37,6 +37,17 @@ func Multi2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vi
 			// Create a message sender using the adapter
 			msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
 
+			args, shared, sharedErr := extractSharedFlags(args, msgCtx.IsPM)
+			if sharedErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(sharedErr.Error()))
+			}
+
+			// Swap "last" for the user's recent results
+			args, lastErr := resolveLastArgs(dbManager, msgCtx.Sender.String(), args)
+			if lastErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(lastErr.Error()))
+			}
+
 			if len(args) < 1 {
 				// Get the current model
 				var userIDStr string
@@ -55,7 +66,7 @@ func Multi2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vi
 				userID.FromBytes(msgCtx.Uid)
 
 				// Format header using utility function
-				header := utils.FormatCommandHelpHeader("multi2video", model, userID, db)
+				header := utils.FormatCommandHelpHeader("multi2video", model, userID, msgCtx.IsPM, db)
 
 				// Get help doc
 				helpDoc := model.HelpDoc
@@ -71,7 +82,7 @@ func Multi2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vi
 			parser := video.NewArgumentParser()
 			parsed, err := parser.ParseMulti2Video(args)
 			if err != nil {
-				return msgSender.SendMessage(ctx, msgCtx, fmt.Sprintf("Argument error: %v", err))
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
 			}
 			if parsed.Prompt == "" {
 				return msgSender.SendMessage(ctx, msgCtx, "Please provide a text prompt describing the desired video.")
@@ -96,54 +107,36 @@ func Multi2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vi
 			}
 
 			// Determine effective duration for per-second pricing
-			duration := parsed.Duration
-			originalUserDuration := duration
-			durInt := 0
-			if duration != "" {
-				durInt, err = strconv.Atoi(duration)
-				if err != nil || durInt <= 0 {
-					durInt = 0
-				}
-			}
-			if durInt == 0 {
-				modelDefault := 0
-				switch model.Name {
-				case "seedance-2.0-reference":
-					modelDefault = 5
-				}
-				if modelDefault > 0 {
-					durInt = modelDefault
-					duration = strconv.Itoa(modelDefault)
-				} else {
-					durInt = 5
-					duration = "5"
-				}
-			}
+			originalUserDuration := parsed.Duration
+			duration, durInt := videoDuration("multi2video", model.Name, parsed.Duration)
 
-			totalCost := model.PriceUSD
-			if model.PerSecondPricing {
-				totalCost = model.PriceUSD * float64(durInt)
-			}
+			totalCost := faladapter.PriceFor(model, faladapter.PriceParams{Seconds: durInt})
 
 			// Create progress callback
-			progress := NewCommandProgressCallback(bot, msgCtx.Nick, msgCtx.Sender, "multi2video", msgCtx.IsPM, msgCtx.GC)
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "multi2video")
+			if model.PerSecondPricing {
+				progress.SetCostTicker(model.Name, durInt, totalCost)
+			}
 
 			// Create video request using parsed values
 			var userID zkidentity.ShortID
 			userID.FromBytes(msgCtx.Uid)
 			req := &video.VideoRequest{
 				GenerationRequest: braibottypes.GenerationRequest{
-					ModelType: "multi2video",
-					ModelName: model.Name,
-					Progress:  progress,
-					UserNick:  msgCtx.Nick,
-					UserID:    userID,
-					PriceUSD:  totalCost,
-					IsPM:      msgCtx.IsPM,
-					GC:        msgCtx.GC,
+					ModelType:    "multi2video",
+					ModelName:    model.Name,
+					Progress:     progress,
+					UserNick:     msgCtx.Nick,
+					UserID:       userID,
+					PriceUSD:     totalCost,
+					IsPM:         msgCtx.IsPM,
+					GC:           msgCtx.GC,
+					SplitPercent: shared.SplitPercent,
+					NoCache:      shared.NoCache,
 				},
 				Prompt:        parsed.Prompt,
 				Duration:      duration,
+				BilledSeconds: durInt,
 				AspectRatio:   parsed.AspectRatio,
 				Resolution:    parsed.Resolution,
 				GenerateAudio: parsed.GenerateAudio,
@@ -157,8 +150,8 @@ func Multi2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vi
 			if msgCtx.IsPM {
 				if model.PerSecondPricing {
 					msg := fmt.Sprintf(
-						"Model: %s\n💰 Price: $%.2f per video second\nRequested duration: %d seconds\nTotal cost: $%.2f = $%.2f/sec × %d sec\nReference inputs: %d image(s), %d video(s), %d audio(s)",
-						model.Name, model.PriceUSD, durInt, totalCost, model.PriceUSD, durInt,
+						"Model: %s\n💰 Price: $%.2f per video second\nRequested duration: %d seconds\nTotal cost: $%.2f = $%.2f/sec × %d sec%s\nReference inputs: %d image(s), %d video(s), %d audio(s)",
+						model.Name, model.PriceUSD, durInt, totalCost, model.PriceUSD, durInt, serviceFeeNote(model, faladapter.PriceParams{Seconds: durInt}),
 						len(parsed.ImageURLs), len(parsed.VideoURLs), len(parsed.AudioURLs),
 					)
 					if originalUserDuration == "" {
@@ -167,8 +160,8 @@ func Multi2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vi
 					msgSender.SendMessage(ctx, msgCtx, msg)
 				} else {
 					msgSender.SendMessage(ctx, msgCtx, fmt.Sprintf(
-						"Model: %s\n💰 Flat fee: $%.2f per video",
-						model.Name, model.PriceUSD,
+						"Model: %s\n💰 Flat fee: $%.2f per video%s",
+						model.Name, model.PriceUSD, serviceFeeNote(model, faladapter.PriceParams{}),
 					))
 				}
 			}
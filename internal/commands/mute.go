@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/karamble/braibot/internal/database"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// MuteCommand returns the mute command, which stops the bot's unsolicited
+// messages (welcome prompts, tip thank-yous, job notifications, low balance
+// reminders). Command results are still delivered.
+func MuteCommand(dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "mute",
+		Description: "🔇 Stop the bot's non-essential messages. Command results are still sent. Usage: !mute",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if err := dbManager.SetMuted(msgCtx.Sender.String(), true); err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			return sender.SendMessage(ctx, msgCtx, utils.UserText(dbManager, msgCtx.Sender.String(), "muted", nil))
+		}),
+	}
+}
+
+// UnmuteCommand returns the unmute command, which restores the messages
+// stopped by !mute.
+func UnmuteCommand(dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "unmute",
+		Description: "🔊 Receive the bot's non-essential messages again. Usage: !unmute",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if err := dbManager.SetMuted(msgCtx.Sender.String(), false); err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			return sender.SendMessage(ctx, msgCtx, utils.UserText(dbManager, msgCtx.Sender.String(), "unmuted", nil))
+		}),
+	}
+}
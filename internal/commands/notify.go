@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/karamble/braibot/internal/database"
+	braibottypes "github.com/karamble/braibot/internal/types"
+)
+
+// NotifyCommand returns the notify command, which toggles the "job ready" PM
+// sent when a long-running generation finishes.
+func NotifyCommand(dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "notify",
+		Description: "🔔 Get a PM when long jobs are ready. Usage: !notify [on|off]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			userIDStr := msgCtx.Sender.String()
+
+			if len(args) == 0 {
+				notify, err := dbManager.GetNotifyReady(userIDStr)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				state := "off"
+				if notify {
+					state = "on"
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Job ready notifications are %s.\nUsage: !notify [on|off]", state))
+			}
+
+			var notify bool
+			switch strings.ToLower(args[0]) {
+			case "on", "true", "1":
+				notify = true
+			case "off", "false", "0":
+				notify = false
+			default:
+				return sender.SendMessage(ctx, msgCtx, "Usage: !notify [on|off]")
+			}
+
+			if err := dbManager.SetNotifyReady(userIDStr, notify); err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			if notify {
+				return sender.SendMessage(ctx, msgCtx, "🔔 You'll get a PM when a long-running job is ready, even if you started it in a group chat.")
+			}
+			return sender.SendMessage(ctx, msgCtx, "🔕 Job ready notifications turned off.")
+		}),
+	}
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/money"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// potHelp documents the pot command.
+const potHelp = "Usage: !pot [fund amount]\n\n" +
+	"Every group chat has a shared pot that members can fund from their own balance.\n" +
+	"• !pot: Show the pot balance\n" +
+	"• !pot fund [amount]: Move DCR from your balance into the pot\n\n" +
+	"Add --split [percent] to an AI generation command to have the pot pay that share of the request."
+
+// PotCommand returns the pot command, which shows and funds a GC's shared pot.
+func PotCommand(dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "pot",
+		Description: "🏦 Show or fund this group chat's shared pot. Usage: !pot [fund amount]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if msgCtx.IsPM {
+				return sender.SendMessage(ctx, msgCtx, "GC pots only exist in group chats.\n\n"+potHelp)
+			}
+			potUID := database.GCPotUID(msgCtx.GC)
+
+			if len(args) == 0 {
+				balance, err := dbManager.GetBalance(potUID)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("🏦 GC pot balance: %.8f DCR", money.AtomsToDCR(balance)))
+			}
+
+			if strings.ToLower(args[0]) != "fund" || len(args) < 2 {
+				return sender.SendMessage(ctx, msgCtx, potHelp)
+			}
+			amountDCR, err := strconv.ParseFloat(args[1], 64)
+			if err != nil || amountDCR <= 0 {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid amount: %s", utils.SanitizeUserText(args[1])))
+			}
+
+			amountAtoms, err := money.DCRToAtoms(amountDCR)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid amount: %s", utils.SanitizeUserText(args[1])))
+			}
+			if err := dbManager.TransferBalance(msgCtx.Sender.String(), potUID, amountAtoms); err != nil {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("%s, could not fund the pot: %v", utils.SanitizeUserText(msgCtx.Nick), err))
+			}
+			balance, err := dbManager.GetBalance(potUID)
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("🏦 %s added %.8f DCR to the pot. Pot balance: %.8f DCR", utils.SanitizeUserText(msgCtx.Nick), amountDCR, money.AtomsToDCR(balance)))
+		}),
+	}
+}
@@ -0,0 +1,248 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/money"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/vctt94/bisonbotkit/config"
+)
+
+// promoUsage explains the promo command.
+const promoUsage = "Usage:\n" +
+	"• !promo redeem [code]: Redeem a promo code or someone's referral code\n" +
+	"• !promo referral: Show your referral code"
+
+// referralCredit returns the atoms both parties of a referral get, from the
+// referralcredit config key in DCR. It is 0, turning referrals off, when the
+// key is absent or invalid.
+func referralCredit(cfg *config.BotConfig) int64 {
+	dcr, err := strconv.ParseFloat(cfg.ExtraConfig["referralcredit"], 64)
+	if err != nil || dcr <= 0 {
+		return 0
+	}
+	atoms, err := money.DCRToAtoms(dcr)
+	if err != nil {
+		return 0
+	}
+	return atoms
+}
+
+// PromoCommand returns the promo command, which redeems the promo codes
+// admins add with !admin promo and the referral codes users share.
+func PromoCommand(dbManager *database.DBManager, cfg *config.BotConfig) braibottypes.Command {
+	referralAtoms := referralCredit(cfg)
+
+	return braibottypes.Command{
+		Name:        "promo",
+		Description: "🎟️ Redeem a promo or referral code, or get your referral code. Usage: !promo [redeem code|referral]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if len(args) == 0 {
+				return sender.SendMessage(ctx, msgCtx, promoUsage)
+			}
+			uid := msgCtx.Sender.String()
+
+			switch strings.ToLower(args[0]) {
+			case "referral", "code":
+				if referralAtoms == 0 {
+					return sender.SendMessage(ctx, msgCtx, "Referrals are not enabled on this bot.")
+				}
+				code, err := dbManager.ReferralCode(uid)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("🎟️ Your referral code is **%s**.\n\nNew users who redeem it with !promo redeem %s before their first tip get %.8f DCR, and so do you.",
+					code, code, money.AtomsToDCR(referralAtoms)))
+			case "redeem":
+				if len(args) != 2 {
+					return sender.SendMessage(ctx, msgCtx, "Usage: !promo redeem [code]")
+				}
+				code := database.NormalizePromoCode(args[1])
+				now := time.Now()
+				p, err := dbManager.RedeemPromoCode(uid, code, now)
+				if errors.Is(err, database.ErrPromoNotFound) && referralAtoms > 0 {
+					return redeemReferral(ctx, msgCtx, sender, dbManager, code, referralAtoms, now)
+				}
+				switch {
+				case errors.Is(err, database.ErrPromoNotFound):
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Unknown promo code: %s", utils.SanitizeUserText(code)))
+				case errors.Is(err, database.ErrPromoExpired):
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Promo code %s has expired.", code))
+				case errors.Is(err, database.ErrPromoUsedUp):
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Promo code %s has been used up.", code))
+				case errors.Is(err, database.ErrPromoRedeemed):
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("You already redeemed promo code %s.", code))
+				case errors.Is(err, database.ErrTipBonusPending):
+					return sender.SendMessage(ctx, msgCtx, "You already have a tip bonus waiting for your next tip. Redeem this code after tipping.")
+				case err != nil:
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				if p.Kind == database.PromoTipBonus {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("🎟️ Promo code %s redeemed: your next tip is topped up by %d%%.", code, p.Amount))
+				}
+				msg := fmt.Sprintf("🎟️ Promo code %s redeemed: %.8f DCR were credited to your balance.", code, money.AtomsToDCR(p.Amount))
+				if balance, err := dbManager.GetBalance(uid); err == nil {
+					msg += fmt.Sprintf("\nNew balance: %.8f DCR", money.AtomsToDCR(balance))
+				}
+				return sender.SendMessage(ctx, msgCtx, msg)
+			}
+			return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Unknown promo subcommand: %s\n\n%s", utils.SanitizeUserText(args[0]), promoUsage))
+		}),
+	}
+}
+
+// redeemReferral redeems a referral code for the sender. The credit for both
+// sides is paid with the sender's first tip.
+func redeemReferral(ctx context.Context, msgCtx braibottypes.MessageContext, sender *braibottypes.MessageSender, dbManager *database.DBManager, code string, atoms int64, now time.Time) error {
+	_, err := dbManager.RedeemReferral(msgCtx.Sender.String(), code, atoms, now)
+	switch {
+	case errors.Is(err, database.ErrPromoNotFound):
+		return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Unknown promo code: %s", utils.SanitizeUserText(code)))
+	case errors.Is(err, database.ErrOwnReferral):
+		return sender.SendMessage(ctx, msgCtx, "You cannot redeem your own referral code. Share it with new users instead.")
+	case errors.Is(err, database.ErrNotNewUser):
+		return sender.SendMessage(ctx, msgCtx, "Referral codes can only be redeemed before your first tip or charge.")
+	case errors.Is(err, database.ErrPromoRedeemed):
+		return sender.SendMessage(ctx, msgCtx, "You already redeemed a referral code.")
+	case err != nil:
+		return sender.SendErrorMessage(ctx, msgCtx, err)
+	}
+	return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("🎟️ Referral code %s redeemed: with your first tip, %.8f DCR are credited to your balance, and the same to the user who referred you.",
+		code, money.AtomsToDCR(atoms)))
+}
+
+// adminPromoUsage explains !admin promo.
+const adminPromoUsage = "Usage: !admin promo [add code credit dcr|bonus percent [uses] [duration]|remove code]\n" +
+	"e.g. !admin promo add WELCOME credit 0.05 100 720h, or !admin promo add DOUBLE bonus 100"
+
+// adminPromo runs !admin promo for the admin uid and returns the reply.
+func adminPromo(dbManager *database.DBManager, uid string, args []string, now time.Time) string {
+	if len(args) == 0 {
+		codes, err := dbManager.ListPromoCodes()
+		if err != nil {
+			return utils.SanitizeUserText(err.Error())
+		}
+		return formatPromoCodes(codes, now) + "\n" + adminPromoUsage
+	}
+	switch strings.ToLower(args[0]) {
+	case "add":
+		p, err := parsePromoCode(args[1:], now)
+		if err != nil {
+			return "Argument error: " + utils.SanitizeUserText(err.Error()) + "\n\n" + adminPromoUsage
+		}
+		p.CreatedBy = uid
+		if err := dbManager.AddPromoCode(p); errors.Is(err, database.ErrPromoExists) {
+			return fmt.Sprintf("Promo code %s already exists.", p.Code)
+		} else if err != nil {
+			return utils.SanitizeUserText(err.Error())
+		}
+		return fmt.Sprintf("Promo code %s added: %s.", p.Code, describePromo(p, now))
+	case "remove":
+		if len(args) != 2 {
+			return "Usage: !admin promo remove [code]"
+		}
+		code := database.NormalizePromoCode(args[1])
+		removed, err := dbManager.DeletePromoCode(code)
+		if err != nil {
+			return utils.SanitizeUserText(err.Error())
+		}
+		if !removed {
+			return fmt.Sprintf("Unknown promo code: %s", utils.SanitizeUserText(code))
+		}
+		return fmt.Sprintf("Promo code %s removed.", code)
+	}
+	return adminPromoUsage
+}
+
+// parsePromoCode parses the arguments of !admin promo add.
+func parsePromoCode(args []string, now time.Time) (database.PromoCode, error) {
+	if len(args) < 3 || len(args) > 5 {
+		return database.PromoCode{}, fmt.Errorf("expected a code, a kind and an amount")
+	}
+	p := database.PromoCode{Code: database.NormalizePromoCode(args[0]), CreatedAt: now}
+	for _, r := range p.Code {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return p, fmt.Errorf("codes may only contain letters, digits, - and _")
+		}
+	}
+	if len(p.Code) > 32 {
+		return p, fmt.Errorf("codes may be at most 32 characters long")
+	}
+	switch strings.ToLower(args[1]) {
+	case "credit":
+		dcr, err := strconv.ParseFloat(args[2], 64)
+		if err != nil || dcr <= 0 {
+			return p, fmt.Errorf("invalid credit %s (must be DCR above 0)", args[2])
+		}
+		atoms, err := money.DCRToAtoms(dcr)
+		if err != nil || atoms <= 0 {
+			return p, fmt.Errorf("invalid credit %s (must be DCR above 0)", args[2])
+		}
+		p.Kind, p.Amount = database.PromoCredit, atoms
+	case "bonus":
+		percent, err := strconv.ParseInt(strings.TrimSuffix(args[2], "%"), 10, 64)
+		if err != nil || percent <= 0 || percent > 1000 {
+			return p, fmt.Errorf("invalid bonus %s (must be a percentage from 1 to 1000)", args[2])
+		}
+		p.Kind, p.Amount = database.PromoTipBonus, percent
+	default:
+		return p, fmt.Errorf("unknown kind %s (must be credit or bonus)", args[1])
+	}
+	if len(args) > 3 {
+		uses, err := strconv.Atoi(args[3])
+		if err != nil || uses < 0 {
+			return p, fmt.Errorf("invalid number of uses %s", args[3])
+		}
+		p.MaxUses = uses
+	}
+	if len(args) > 4 {
+		d, err := time.ParseDuration(args[4])
+		if err != nil || d <= 0 {
+			return p, fmt.Errorf("invalid duration %s (e.g. 24h or 720h)", args[4])
+		}
+		p.ExpiresAt = now.Add(d)
+	}
+	return p, nil
+}
+
+// describePromo summarizes what a promo code grants and its limits.
+func describePromo(p database.PromoCode, now time.Time) string {
+	desc := fmt.Sprintf("%.8f DCR credit", money.AtomsToDCR(p.Amount))
+	if p.Kind == database.PromoTipBonus {
+		desc = fmt.Sprintf("%d%% bonus on the next tip", p.Amount)
+	}
+	if p.MaxUses > 0 {
+		desc += fmt.Sprintf(", %d/%d uses", p.Uses, p.MaxUses)
+	} else {
+		desc += fmt.Sprintf(", %d uses", p.Uses)
+	}
+	switch {
+	case p.ExpiresAt.IsZero():
+	case !now.Before(p.ExpiresAt):
+		desc += ", expired"
+	default:
+		desc += ", expires " + p.ExpiresAt.UTC().Format("2006-01-02 15:04") + " UTC"
+	}
+	return desc
+}
+
+// formatPromoCodes lists promo codes for admins.
+func formatPromoCodes(codes []database.PromoCode, now time.Time) string {
+	if len(codes) == 0 {
+		return "No promo codes."
+	}
+	var b strings.Builder
+	b.WriteString("🎟️ **Promo codes**\n\n")
+	for _, p := range codes {
+		fmt.Fprintf(&b, "• %s: %s\n", p.Code, describePromo(p, now))
+	}
+	return b.String()
+}
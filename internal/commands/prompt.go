@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/karamble/braibot/internal/database"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// Limits of the prompt library.
+const (
+	maxSavedPrompts     = 50   // Prompts per user
+	maxSavedPromptRunes = 1000 // Length of a prompt
+)
+
+// promptNamePattern is what a saved prompt may be named, so @name can be
+// told apart from the rest of a prompt.
+var promptNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+const promptUsage = "Usage:\n" +
+	"• !prompt save [name] [text] — save a prompt, replacing one of the same name\n" +
+	"• !prompt list — list your prompts\n" +
+	"• !prompt use [name] — show a prompt\n" +
+	"• !prompt delete [name] — delete a prompt\n" +
+	"Write @name in any generation command to insert the prompt, e.g. !text2image a lighthouse @noir"
+
+// parsePromptName returns the lowercased name, or an error when it is not
+// a valid name.
+func parsePromptName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimPrefix(name, "@"))
+	if !promptNamePattern.MatchString(name) {
+		return "", fmt.Errorf("prompt names are 1 to 32 letters, digits, - or _")
+	}
+	return name, nil
+}
+
+// ExpandSavedPrompts replaces each @name argument with the words of the
+// user's saved prompt of that name. Arguments naming no saved prompt, such
+// as a mention of a nick, are left alone.
+func ExpandSavedPrompts(dbManager *database.DBManager, uid string, args []string) ([]string, error) {
+	var expanded []string
+	for i, arg := range args {
+		name, ok := strings.CutPrefix(arg, "@")
+		if !ok || !promptNamePattern.MatchString(strings.ToLower(name)) {
+			if expanded != nil {
+				expanded = append(expanded, arg)
+			}
+			continue
+		}
+		p, found, err := dbManager.SavedPromptByName(uid, strings.ToLower(name))
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			if expanded != nil {
+				expanded = append(expanded, arg)
+			}
+			continue
+		}
+		if expanded == nil {
+			expanded = append([]string(nil), args[:i]...)
+		}
+		expanded = append(expanded, strings.Fields(p.Text)...)
+	}
+	if expanded == nil {
+		return args, nil
+	}
+	return expanded, nil
+}
+
+// formatSavedPrompts lists the prompts with the start of their text.
+func formatSavedPrompts(prompts []database.SavedPrompt) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📝 Your saved prompts (%d of %d):\n", len(prompts), maxSavedPrompts)
+	for _, p := range prompts {
+		fmt.Fprintf(&b, "• @%s: %s\n", p.Name, utils.PreviewUserText(p.Text, 60))
+	}
+	b.WriteString("\nWrite @name in a generation command to use one, or !prompt use [name] to see it in full.")
+	return b.String()
+}
+
+// PromptCommand returns the prompt command, which manages the user's
+// library of saved prompts.
+func PromptCommand(dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "prompt",
+		Description: "📝 Save prompts and reuse them as @name. Usage: !prompt [save|list|use|delete] [name] [text]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if len(args) == 0 {
+				return sender.SendMessage(ctx, msgCtx, promptUsage)
+			}
+			uid := msgCtx.Sender.String()
+			sub := strings.ToLower(args[0])
+
+			if sub == "list" {
+				prompts, err := dbManager.SavedPrompts(uid)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				if len(prompts) == 0 {
+					return sender.SendMessage(ctx, msgCtx, "You have no saved prompts yet. Save one with !prompt save [name] [text].")
+				}
+				return sender.SendMessage(ctx, msgCtx, formatSavedPrompts(prompts))
+			}
+
+			if (sub != "save" && sub != "use" && sub != "delete") || len(args) < 2 {
+				return sender.SendMessage(ctx, msgCtx, promptUsage)
+			}
+			name, err := parsePromptName(args[1])
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+err.Error())
+			}
+
+			switch sub {
+			case "save":
+				text := strings.Join(args[2:], " ")
+				if text == "" {
+					return sender.SendMessage(ctx, msgCtx, "Usage: !prompt save [name] [text]")
+				}
+				if n := utf8.RuneCountInString(text); n > maxSavedPromptRunes {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("That prompt is %d characters long; saved prompts can have up to %d.", n, maxSavedPromptRunes))
+				}
+				ok, err := dbManager.SavePrompt(uid, database.SavedPrompt{Name: name, Text: text, CreatedAt: time.Now()}, maxSavedPrompts)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				if !ok {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("You already saved %d prompts. Delete one with !prompt delete [name] first.", maxSavedPrompts))
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("📝 Saved @%s. Write @%s in a generation command to use it.", name, name))
+			case "use":
+				p, found, err := dbManager.SavedPromptByName(uid, name)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				if !found {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("You have no prompt named @%s. See !prompt list.", name))
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("📝 @%s:\n%s\n\nUse it as e.g. !text2image @%s, adding words or options around it.", name, utils.SanitizeUserText(p.Text), name))
+			default:
+				ok, err := dbManager.DeletePrompt(uid, name)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				if !ok {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("You have no prompt named @%s.", name))
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Deleted @%s.", name))
+			}
+		}),
+	}
+}
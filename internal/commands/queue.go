@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/karamble/braibot/internal/jobs"
+	braibottypes "github.com/karamble/braibot/internal/types"
+)
+
+// QueueCommand returns the queue command, which shows the user's pending and
+// running generation jobs.
+func QueueCommand() braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "queue",
+		Description: "⏳ Show your pending generations, their place in line and ETA. Usage: !queue",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			statuses := jobs.Default.UserStatus(msgCtx.Sender.String())
+			running, pending := jobs.Default.Counts()
+			return sender.SendMessage(ctx, msgCtx, formatQueue(statuses, running, pending))
+		}),
+	}
+}
+
+// formatQueue renders a user's jobs and the overall queue size.
+func formatQueue(statuses []jobs.Status, running, pending int) string {
+	var b strings.Builder
+	b.WriteString("⏳ **Job queue**\n\n")
+	if len(statuses) == 0 {
+		b.WriteString("You have no pending generations.\n")
+	}
+	for _, s := range statuses {
+		if s.Running {
+			fmt.Fprintf(&b, "• #%d !%s: running, done in %s\n", s.Job.ID, s.Job.Command, jobs.FormatETA(s.ETA))
+			continue
+		}
+		fmt.Fprintf(&b, "• #%d !%s: %s in line, done in %s\n", s.Job.ID, s.Job.Command, ordinal(s.Position), jobs.FormatETA(s.ETA))
+	}
+	fmt.Fprintf(&b, "\n%d running, %d waiting overall.", running, pending)
+	if len(statuses) > 0 {
+		b.WriteString(" Use !cancel [job_id] to cancel a job.")
+	}
+	return b.String()
+}
+
+// ordinal formats a place in line, e.g. 1st or 12th.
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
@@ -3,11 +3,37 @@ package commands
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
 )
 
+// formatRates renders the exchange rates. liveUSD, dcrBTC and btcUSD are 0
+// when they could not be fetched.
+func formatRates(rate utils.PricingRate, liveUSD, dcrBTC, btcUSD float64) string {
+	var b strings.Builder
+	b.WriteString("Current Exchange Rates:\n")
+	switch rate.Source {
+	case utils.RateFixed:
+		fmt.Fprintf(&b, "• DCR: $%s USD (fixed rate used for pricing)\n", utils.FormatUSDThousands(rate.USD))
+	case utils.RateOverride:
+		fmt.Fprintf(&b, "• DCR: $%s USD (set by an admin for pricing until %s UTC)\n", utils.FormatUSDThousands(rate.USD), rate.Until.UTC().Format("2006-01-02 15:04"))
+	default:
+		fmt.Fprintf(&b, "• DCR: $%s USD\n", utils.FormatUSDThousands(rate.USD))
+	}
+	if rate.Source != utils.RateLive && liveUSD > 0 {
+		fmt.Fprintf(&b, "• DCR market rate: $%s USD\n", utils.FormatUSDThousands(liveUSD))
+	}
+	if dcrBTC > 0 {
+		fmt.Fprintf(&b, "• DCR: %s BTC\n", utils.FormatThousands(dcrBTC))
+	}
+	if btcUSD > 0 {
+		fmt.Fprintf(&b, "• BTC: $%s USD\n", utils.FormatUSDThousands(btcUSD))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
 // RateCommand returns the rate command
 func RateCommand() braibottypes.Command {
 	return braibottypes.Command{
@@ -15,23 +41,25 @@ func RateCommand() braibottypes.Command {
 		Description: "💱 Show current DCR exchange rates",
 		Category:    "Basic",
 		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
-			// Get DCR prices in USD and BTC
-			dcrUsdPrice, dcrBtcPrice, err := utils.GetDCRPrice()
+			rate, err := utils.GetPricingRate()
 			if err != nil {
 				return sender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("failed to fetch DCR rates: %v", err))
 			}
 
+			// The market rates are informational when pricing does not
+			// follow them, so they may be missing then
+			liveUSD, dcrBtcPrice, err := utils.LiveDCRPrice()
+			if err != nil && rate.Source == utils.RateLive {
+				return sender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("failed to fetch DCR rates: %v", err))
+			}
+
 			// Get BTC price in USD
 			btcUsdPrice, err := utils.GetBTCPrice()
-			if err != nil {
+			if err != nil && rate.Source == utils.RateLive {
 				return sender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("failed to fetch BTC rate: %v", err))
 			}
 
-			msg := fmt.Sprintf("Current Exchange Rates:\n• DCR: $%s USD\n• DCR: %s BTC\n• BTC: $%s USD",
-				utils.FormatUSDThousands(dcrUsdPrice),
-				utils.FormatThousands(dcrBtcPrice),
-				utils.FormatUSDThousands(btcUsdPrice))
-			return sender.SendMessage(ctx, msgCtx, msg)
+			return sender.SendMessage(ctx, msgCtx, formatRates(rate, liveUSD, dcrBtcPrice, btcUsdPrice))
 		}),
 	}
 }
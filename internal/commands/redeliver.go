@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/karamble/braibot/internal/assets"
+	"github.com/karamble/braibot/internal/database"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/internal/video"
+)
+
+// RedeliverCommand returns the redeliver command, which sends the result of
+// a finished job again, to its owner or to users it was shared with.
+func RedeliverCommand(dbManager *database.DBManager, videoService *video.VideoService) braibottypes.Command {
+	return redeliverCommand("redeliver", "📦 Send the result of a finished job again. Usage: !redeliver [job_id]", dbManager, videoService)
+}
+
+// ResendCommand returns the resend command, another name for !redeliver.
+// Results in the media cache are sent from there, so they can be resent
+// after the provider's URLs expired.
+func ResendCommand(dbManager *database.DBManager, videoService *video.VideoService) braibottypes.Command {
+	return redeliverCommand("resend", "📦 Send the result of a finished job again, from the media cache when its link expired. Usage: !resend [job_id]", dbManager, videoService)
+}
+
+func redeliverCommand(name, description string, dbManager *database.DBManager, videoService *video.VideoService) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        name,
+		Description: description,
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if len(args) == 0 {
+				return sender.SendMessage(ctx, msgCtx, "Usage: !"+name+" [job_id]")
+			}
+			jobID, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil || jobID <= 0 {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid job id: %s", utils.SanitizeUserText(args[0])))
+			}
+
+			job, exists, err := dbManager.GetJob(jobID)
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			// Jobs are private to the user who ran them unless shared
+			allowed := false
+			if exists {
+				if allowed, err = dbManager.CanAccessJob(job, msgCtx.Sender.String()); err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+			}
+			if !allowed {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Job #%d not found.", jobID))
+			}
+			if job.Expired(time.Now()) {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Job #%d expired on %s and is no longer available.", jobID, job.ExpiresAt.UTC().Format("Jan 2 15:04 MST")))
+			}
+
+			shared := job.UID != msgCtx.Sender.String()
+			if shared || job.Prompt != "" || job.Seed != nil {
+				if err := sender.SendMessage(ctx, msgCtx, formatJobDetails(job, shared)); err != nil {
+					return err
+				}
+			}
+			if err := videoService.RedeliverVideo(ctx, msgCtx.Sender.String(), job.ResultURL); err != nil {
+				if assets.DefaultCache != nil && !assets.DefaultCache.Has(job.ResultURL) {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Could not re-deliver job #%d: %v\nThe result is not in the media cache, so the provider's copy may have expired.", jobID, err))
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Could not re-deliver job #%d, the result may have expired: %v\nLink: %s", jobID, err, job.ResultURL))
+			}
+			return nil
+		}),
+	}
+}
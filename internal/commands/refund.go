@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/money"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	kit "github.com/vctt94/bisonbotkit"
+	"github.com/vctt94/bisonbotkit/config"
+)
+
+// maxRefundReasonRunes caps the reason stored with a refund request.
+const maxRefundReasonRunes = 300
+
+// RefundCommand returns the refund command, which files a refund request for
+// a charged video job. Only video jobs are recorded with their charge, so
+// other results cannot be refunded this way. Bot admins are told about new requests and decide them with
+// !admin refund.
+func RefundCommand(dbManager *database.DBManager, bot *kit.Bot, cfg *config.BotConfig) braibottypes.Command {
+	admins := adminUIDs(cfg)
+
+	return braibottypes.Command{
+		Name:        "refund",
+		Description: "↩️ Request a refund for a failed or unsatisfactory video. Usage: !refund [job_id] [reason]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if len(args) == 0 {
+				return sender.SendMessage(ctx, msgCtx, "Usage: !refund [job_id] [reason]\n\nRefunds are available for videos; the job id is shown when a video is delivered.")
+			}
+			jobID, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+			if err != nil || jobID <= 0 {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid job id: %s", utils.SanitizeUserText(args[0])))
+			}
+			reason := utils.PreviewUserText(strings.Join(args[1:], " "), maxRefundReasonRunes)
+
+			req, err := dbManager.RequestRefund(jobID, msgCtx.Sender.String(), reason, time.Now())
+			switch {
+			case errors.Is(err, database.ErrJobNotRefundable):
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Job #%d not found or you were not charged for it. Only video jobs can be refunded.", jobID))
+			case errors.Is(err, database.ErrRefundRequested):
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("A refund for job #%d was already requested.", jobID))
+			case err != nil:
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+
+			notice := fmt.Sprintf("↩️ Refund request #%d from %s (%s) for job #%d: %.8f DCR.\nReason: %s\n\nUse !admin refund approve %d or !admin refund deny %d.",
+				req.ID, utils.SanitizeUserText(msgCtx.Nick), req.UID, req.JobID, money.AtomsToDCR(req.Atoms), refundReason(req), req.ID, req.ID)
+			for uid := range admins {
+				bot.SendPM(ctx, uid, notice)
+			}
+			return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Refund request #%d for job #%d (%.8f DCR) was sent to the bot admins. You'll get a PM once it is decided.",
+				req.ID, req.JobID, money.AtomsToDCR(req.Atoms)))
+		}),
+	}
+}
+
+// refundReason returns a request's reason for display.
+func refundReason(r database.RefundRequest) string {
+	if r.Reason == "" {
+		return "(none given)"
+	}
+	return utils.SanitizeUserText(r.Reason)
+}
+
+// formatRefundRequests lists pending refund requests for admins.
+func formatRefundRequests(reqs []database.RefundRequest) string {
+	if len(reqs) == 0 {
+		return "No pending refund requests."
+	}
+	msg := "↩️ **Pending refund requests**\n\n| # | Job | User | DCR | Requested | Reason |\n| - | --- | ---- | --- | --------- | ------ |\n"
+	for _, r := range reqs {
+		msg += fmt.Sprintf("| %d | %d | %s | %.8f | %s | %s |\n", r.ID, r.JobID, r.UID, money.AtomsToDCR(r.Atoms),
+			r.CreatedAt.UTC().Format("Jan 2 15:04"), strings.ReplaceAll(refundReason(r), "|", "/"))
+	}
+	return msg
+}
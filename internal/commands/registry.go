@@ -3,24 +3,27 @@ package commands
 import (
 	"fmt"
 	"strings"
+	"sync/atomic"
 
+	"github.com/karamble/braibot/internal/params"
 	braibottypes "github.com/karamble/braibot/internal/types"
 )
 
 // Registry holds all available commands
 type Registry struct {
 	commands       map[string]braibottypes.Command
-	webhookEnabled bool
-	billingEnabled bool
+	webhookEnabled atomic.Bool
+	billingEnabled atomic.Bool
+	billingHooks   []func(bool) // Called when billing is toggled
 }
 
 // NewRegistry creates a new command registry
 func NewRegistry() *Registry {
-	return &Registry{
-		commands:       make(map[string]braibottypes.Command),
-		webhookEnabled: false,
-		billingEnabled: true, // Default to true
+	r := &Registry{
+		commands: make(map[string]braibottypes.Command),
 	}
+	r.billingEnabled.Store(true) // Default to true
+	return r
 }
 
 // Register adds a command to the registry
@@ -50,31 +53,43 @@ func (r *Registry) ListCommands() []braibottypes.Command {
 
 // GetWebhookEnabled returns whether the webhook is enabled
 func (r *Registry) GetWebhookEnabled() (bool, bool) {
-	return r.webhookEnabled, true
+	return r.webhookEnabled.Load(), true
 }
 
 // SetWebhookEnabled sets whether the webhook is enabled
 func (r *Registry) SetWebhookEnabled(enabled bool) {
-	r.webhookEnabled = enabled
+	r.webhookEnabled.Store(enabled)
 }
 
 // GetBillingEnabled returns whether billing is enabled
 func (r *Registry) GetBillingEnabled() bool {
-	return r.billingEnabled
+	return r.billingEnabled.Load()
 }
 
-// SetBillingEnabled sets whether billing is enabled
+// SetBillingEnabled sets whether billing is enabled and notifies the
+// services registered with OnBillingChange
 func (r *Registry) SetBillingEnabled(enabled bool) {
-	r.billingEnabled = enabled
+	r.billingEnabled.Store(enabled)
+	for _, hook := range r.billingHooks {
+		hook(enabled)
+	}
+}
+
+// OnBillingChange registers a function called whenever billing is toggled.
+// Hooks must be registered before the bot starts handling messages.
+func (r *Registry) OnBillingChange(hook func(enabled bool)) {
+	r.billingHooks = append(r.billingHooks, hook)
 }
 
-// IsCommand checks if a message is a command (starts with !)
+// IsCommand checks if a message is a command (starts with !) and splits
+// its arguments, keeping quoted text such as "blurry hands, extra fingers"
+// together as one argument
 func IsCommand(msg string) (string, []string, bool) {
 	if !strings.HasPrefix(msg, "!") {
 		return "", nil, false
 	}
 
-	parts := strings.Fields(msg[1:]) // Remove ! and split
+	parts := params.Split(msg[1:]) // Remove ! and split, keeping quoted text together
 	if len(parts) == 0 {
 		return "", nil, false
 	}
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	imgservice "github.com/karamble/braibot/internal/image"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/pkg/fal"
+	kit "github.com/vctt94/bisonbotkit"
+	botconfig "github.com/vctt94/bisonbotkit/config"
+)
+
+// removeBGModel is the image2image model used by !removebg.
+const removeBGModel = "birefnet"
+
+// RemoveBGCommand returns the removebg command
+func RemoveBGCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *imgservice.ImageService, dbManager *database.DBManager, debug bool) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "removebg",
+		Description: "✂️ Remove the background from an image. Usage: !removebg [image_url] [--variant portrait]",
+		Category:    "AI Generation",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			// Create a message sender using the adapter
+			msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
+
+			args, shared, sharedErr := extractSharedFlags(args, msgCtx.IsPM)
+			if sharedErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(sharedErr.Error()))
+			}
+
+			// Swap "last" for the user's recent results
+			args, lastErr := resolveLastArgs(dbManager, msgCtx.Sender.String(), args, database.ResultImage)
+			if lastErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(lastErr.Error()))
+			}
+
+			model, exists := faladapter.GetModel(removeBGModel, "image2image")
+			if !exists {
+				return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("model not found: %s", removeBGModel))
+			}
+
+			var userID zkidentity.ShortID
+			userID.FromBytes(msgCtx.Uid)
+
+			if len(args) < 1 {
+				header := utils.FormatCommandHelpHeader("removebg", model, userID, msgCtx.IsPM, db)
+				return msgSender.SendMessage(ctx, msgCtx, header+model.HelpDoc)
+			}
+
+			imageURL := args[0]
+
+			// Validate URL
+			parsedURL, err := url.Parse(imageURL)
+			if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+				return msgSender.SendMessage(ctx, msgCtx, "Please provide a valid http:// or https:// URL for the image.")
+			}
+
+			req := &imgservice.ImageRequest{ImageURL: imageURL}
+			if err := parseRemoveBGArgs(args[1:], req); err != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+
+			// Create progress callback
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "removebg")
+
+			req.GenerationRequest = braibottypes.GenerationRequest{
+				ModelType:    "image2image",
+				ModelName:    model.Name,
+				Progress:     progress,
+				UserNick:     msgCtx.Nick,
+				UserID:       userID,
+				PriceUSD:     faladapter.PriceFor(model, faladapter.PriceParams{}),
+				IsPM:         msgCtx.IsPM,
+				GC:           msgCtx.GC,
+				SplitPercent: shared.SplitPercent,
+				NoCache:      shared.NoCache,
+				AssetLink:    prefersAssetLink(cfg, "removebg"),
+			}
+
+			result, err := imageService.GenerateImage(ctx, req)
+
+			// Handle result/error using the utility function
+			if handleErr := utils.HandleServiceResultOrError(ctx, bot, msgCtx, "removebg", result, err); handleErr != nil {
+				return handleErr
+			}
+
+			return nil
+		}),
+	}
+}
+
+// parseRemoveBGArgs reads the options of !removebg into req.
+func parseRemoveBGArgs(args []string, req *imgservice.ImageRequest) error {
+	for i := 0; i < len(args); i++ {
+		flag := strings.ToLower(args[i])
+		switch flag {
+		case "--variant", "--resolution", "--output_format", "--output-format":
+		default:
+			return fmt.Errorf("unexpected argument %s", args[i])
+		}
+		if i+1 >= len(args) {
+			return fmt.Errorf("missing value for %s", flag)
+		}
+		value := strings.ToLower(args[i+1])
+		i++
+
+		switch flag {
+		case "--variant":
+			if _, ok := fal.BiRefNetVariants[value]; !ok {
+				return fmt.Errorf("invalid value for --variant: %s (must be light, light2k, heavy, matting or portrait)", value)
+			}
+			req.Variant = value
+		case "--resolution":
+			if value != "1024x1024" && value != "2048x2048" {
+				return fmt.Errorf("invalid value for --resolution: %s (must be 1024x1024 or 2048x2048)", value)
+			}
+			req.Resolution = value
+		default:
+			if value != "png" && value != "webp" {
+				return fmt.Errorf("invalid value for %s: %s (must be png or webp)", flag, value)
+			}
+			req.OutputFormat = value
+		}
+	}
+	return nil
+}
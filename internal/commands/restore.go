@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	imgservice "github.com/karamble/braibot/internal/image"
+	"github.com/karamble/braibot/internal/params"
+	"github.com/karamble/braibot/internal/pipeline"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	kit "github.com/vctt94/bisonbotkit"
+	botconfig "github.com/vctt94/bisonbotkit/config"
+)
+
+// Models chained by !restore. Colorization is optional and runs first so the
+// face restorer and upscaler work on the colorized image.
+const (
+	restoreColorizeModel = "ddcolor"
+	restoreFaceModel     = "codeformer"
+	restoreUpscaleModel  = "esrgan"
+)
+
+// restoreHelp documents the restore command options.
+const restoreHelp = "Usage: !restore [image_url] [options]\n" +
+	"Example: !restore https://example.com/old-photo.jpg --colorize true --scale 4\n\n" +
+	"Restores faces in old or damaged photos and upscales the result (codeformer → esrgan).\n\n" +
+	"Parameters:\n" +
+	"• image_url: URL of the photo to restore (required)\n" +
+	"• --colorize: Colorize a black and white photo first with ddcolor (default: false)\n" +
+	"• --fidelity: Face restoration fidelity 0 (quality) to 1 (faithful). Default: 0.5\n" +
+	"• --scale: Upscale factor 1-8 (default: 2)\n" +
+	"• --face: Extra face enhancement while upscaling (default: false)\n" +
+	"• --max-cost: Stop before a step that would take the chain over this many USD"
+
+// RestoreCommand returns the restore command
+func RestoreCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *imgservice.ImageService, dbManager *database.DBManager, debug bool) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "restore",
+		Description: "🖼️ Restore and upscale old photos. Usage: !restore [image_url] [--colorize true]",
+		Category:    "AI Generation",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			// Create a message sender using the adapter
+			msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
+
+			// Swap "last" for the user's recent results
+			args, lastErr := resolveLastArgs(dbManager, msgCtx.Sender.String(), args, database.ResultImage)
+			if lastErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(lastErr.Error()))
+			}
+
+			var userID zkidentity.ShortID
+			userID.FromBytes(msgCtx.Uid)
+
+			if len(args) < 1 {
+				model, exists := faladapter.GetModel(restoreFaceModel, "image2image")
+				if !exists {
+					return msgSender.SendMessage(ctx, msgCtx, "Error: restore models not found.")
+				}
+				header := utils.FormatCommandHelpHeader("restore", model, userID, msgCtx.IsPM, db)
+				return msgSender.SendMessage(ctx, msgCtx, header+restoreHelp)
+			}
+
+			imageURL := args[0]
+
+			// Validate URL
+			parsedURL, err := url.Parse(imageURL)
+			if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+				return msgSender.SendMessage(ctx, msgCtx, "Please provide a valid http:// or https:// URL for the image.")
+			}
+
+			// --max-cost may only lower the configured ceiling of the chain
+			rest, maxCost, err := pipeline.ExtractMaxCost(args[1:])
+			if err != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+
+			// --colorize comes on top of the shared edit flags
+			spec := params.NewSpec(params.NewFlag(params.Bool, "colorize")).Merge(imageEditFlags...)
+			parsed, err := params.Parse(rest, spec)
+			if err != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+			colorize := parsed.Bool("colorize") != nil && *parsed.Bool("colorize")
+
+			req := &imgservice.RestoreRequest{}
+			if err := applyImageEditFlags(parsed, &req.ImageRequest); err != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+
+			req.Steps = []string{restoreFaceModel, restoreUpscaleModel}
+			if colorize {
+				req.Steps = append([]string{restoreColorizeModel}, req.Steps...)
+			}
+
+			// The chain is billed once, at the sum of its step prices
+			var totalCost float64
+			for _, step := range req.Steps {
+				model, exists := faladapter.GetModel(step, "image2image")
+				if !exists {
+					return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("model not found: %s", step))
+				}
+				price := faladapter.PriceFor(model, faladapter.PriceParams{})
+				req.StepPricesUSD = append(req.StepPricesUSD, price)
+				totalCost += price
+			}
+			req.CeilingUSD = pipeline.Ceiling(maxCost)
+
+			// Create progress callback
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "restore")
+
+			req.GenerationRequest = braibottypes.GenerationRequest{
+				ModelType: "image2image",
+				ModelName: restoreFaceModel,
+				Progress:  progress,
+				UserNick:  msgCtx.Nick,
+				UserID:    userID,
+				PriceUSD:  totalCost,
+				IsPM:      msgCtx.IsPM,
+				GC:        msgCtx.GC,
+				AssetLink: prefersAssetLink(cfg, "restore"),
+			}
+			req.ImageURL = imageURL
+
+			result, err := imageService.RestoreImage(ctx, req)
+
+			// Handle result/error using the utility function
+			if handleErr := utils.HandleServiceResultOrError(ctx, bot, msgCtx, "restore", result, err); handleErr != nil {
+				return handleErr
+			}
+
+			return nil
+		}),
+	}
+}
@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/moderation"
+	"github.com/karamble/braibot/internal/schedule"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// Limits of scheduled generations.
+const (
+	maxSchedules        = 5         // Schedules per user
+	minScheduleInterval = time.Hour // Shortest time between two runs
+)
+
+// scheduleCommands are the commands !schedule runs.
+var scheduleCommands = []string{"text2image", "text2video", "text2speech"}
+
+const scheduleUsage = "Usage:\n" +
+	"• !schedule add \"[cron spec]\" [command] [arguments] — run a generation on a schedule\n" +
+	"• !schedule list — list your schedules\n" +
+	"• !schedule remove [id] — remove a schedule\n" +
+	"The cron spec has five fields, minute hour day month weekday, in UTC, or is @hourly, @daily, @weekly or @monthly, e.g.:\n" +
+	"!schedule add \"0 9 * * *\" text2image \"daily sunrise over decred mountains\"\n" +
+	"Works for !text2image, !text2video and !text2speech. Each run is billed like the command and delivered by PM."
+
+// parseScheduleAdd splits the arguments of !schedule add into the cron spec,
+// the command and its arguments. The spec is one quoted argument, an alias,
+// or else its five fields unquoted.
+func parseScheduleAdd(args []string) (spec, command string, cmdArgs []string, err error) {
+	if len(args) == 0 {
+		return "", "", nil, fmt.Errorf("missing the cron spec, e.g. \"0 9 * * *\"")
+	}
+	rest := args[1:]
+	spec = args[0]
+	if !strings.ContainsAny(spec, " \t") && !strings.HasPrefix(spec, "@") {
+		if len(args) < 5 {
+			return "", "", nil, fmt.Errorf("a cron spec has 5 fields, e.g. \"0 9 * * *\"")
+		}
+		spec, rest = strings.Join(args[:5], " "), args[5:]
+	}
+	if len(rest) == 0 {
+		return "", "", nil, fmt.Errorf("missing the command to run, e.g. text2image")
+	}
+	command = strings.ToLower(strings.TrimPrefix(rest[0], "!"))
+	supported := false
+	for _, c := range scheduleCommands {
+		supported = supported || c == command
+	}
+	if !supported {
+		return "", "", nil, fmt.Errorf("!schedule runs !%s, not !%s", strings.Join(scheduleCommands, ", !"), command)
+	}
+	if len(rest) == 1 {
+		return "", "", nil, fmt.Errorf("missing the arguments of !%s", command)
+	}
+	return spec, command, rest[1:], nil
+}
+
+// formatSchedules lists the user's schedules.
+func formatSchedules(schedules []database.Schedule) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "⏰ Your schedules (%d of %d):\n", len(schedules), maxSchedules)
+	for _, s := range schedules {
+		fmt.Fprintf(&b, "• #%d %s: !%s %s, next run %s\n", s.ID, s.Spec, s.Command,
+			utils.PreviewUserText(strings.Join(s.Args, " "), 60), schedule.FormatTime(s.NextRun))
+	}
+	b.WriteString("\nRemove one with !schedule remove [id].")
+	return b.String()
+}
+
+// ScheduleCommand returns the schedule command, which runs generations on
+// cron schedules. The runs are queued by a schedule.Runner.
+func ScheduleCommand(dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "schedule",
+		Description: "⏰ Run a generation on a schedule. Usage: !schedule [add|list|remove] [cron spec] [command] [arguments]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if !msgCtx.IsPM {
+				return sender.SendMessage(ctx, msgCtx, "Schedules can only be managed in a private message with the bot.")
+			}
+			if len(args) == 0 {
+				return sender.SendMessage(ctx, msgCtx, scheduleUsage)
+			}
+			uid := msgCtx.Sender.String()
+
+			switch strings.ToLower(args[0]) {
+			case "list":
+				schedules, err := dbManager.Schedules(uid)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				if len(schedules) == 0 {
+					return sender.SendMessage(ctx, msgCtx, "You have no schedules yet.\n\n"+scheduleUsage)
+				}
+				return sender.SendMessage(ctx, msgCtx, formatSchedules(schedules))
+			case "remove", "delete":
+				if len(args) != 2 {
+					return sender.SendMessage(ctx, msgCtx, "Usage: !schedule remove [id]")
+				}
+				id, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+				if err != nil || id <= 0 {
+					return sender.SendMessage(ctx, msgCtx, "Argument error: invalid schedule id "+utils.SanitizeUserText(args[1]))
+				}
+				ok, err := dbManager.DeleteSchedule(uid, id)
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				if !ok {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("You have no schedule #%d. See !schedule list.", id))
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Removed schedule #%d.", id))
+			case "add":
+			default:
+				return sender.SendMessage(ctx, msgCtx, scheduleUsage)
+			}
+
+			specText, command, cmdArgs, err := parseScheduleAdd(args[1:])
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+			spec, err := schedule.Parse(specText)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+			now := time.Now()
+			next, ok := spec.Next(now)
+			if !ok {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("%q never runs. Check the day and month.", specText))
+			}
+			if gap := spec.MinInterval(now, 48); gap > 0 && gap < minScheduleInterval {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Schedules may run at most once every %s; %q runs every %s.", minScheduleInterval, specText, gap))
+			}
+
+			// Saved prompts are resolved now, so later edits do not change
+			// the schedule, and the runs skip the checks of typed commands
+			cmdArgs, err = ExpandSavedPrompts(dbManager, uid, cmdArgs)
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			costUSD, err := RequestCostUSD(dbManager, msgCtx, command, cmdArgs)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+			if moderation.Default != nil {
+				var rejection *moderation.Rejection
+				if err := moderation.Default.Check(ctx, strings.Join(cmdArgs, " ")); errors.As(err, &rejection) {
+					return sender.SendMessage(ctx, msgCtx, "That prompt was rejected by moderation.")
+				} else if err != nil {
+					fmt.Printf("WARN: Failed to moderate the schedule of %s: %v\n", msgCtx.Nick, err)
+					return sender.SendMessage(ctx, msgCtx, "The prompt could not be checked right now. Please try again later.")
+				}
+			}
+
+			id, err := dbManager.AddSchedule(database.Schedule{
+				UID:       uid,
+				Nick:      msgCtx.Nick,
+				Spec:      specText,
+				Command:   command,
+				Args:      cmdArgs,
+				NextRun:   next,
+				CreatedAt: now,
+			}, maxSchedules)
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			if id == 0 {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("You already have %d schedules. Remove one with !schedule remove [id] first.", maxSchedules))
+			}
+			return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("⏰ Scheduled !%s as #%d (%s, UTC), about $%.2f USD per run. Next run: %s.\nEach run is billed when it is delivered; remove it with !schedule remove %d.",
+				command, id, specText, costUSD, schedule.FormatTime(next), id))
+		}),
+	}
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/karamble/braibot/internal/database"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// setUsage documents the set command and the settings it accepts.
+func setUsage() string {
+	var b strings.Builder
+	b.WriteString("Usage: !set [setting] [value]\nExample: !set aspect 16:9\n\nSettings:\n")
+	for _, s := range utils.UserSettingHelp {
+		fmt.Fprintf(&b, "• %s: %s\n", s.Key, s.Help)
+	}
+	b.WriteString("\nFlags given with a command override your saved defaults. Use !unset [setting] to remove one.")
+	return b.String()
+}
+
+// settingKey normalizes a setting name, so --aspect and aspect-ratio name
+// the same setting as aspect.
+func settingKey(arg string) string {
+	key := strings.ReplaceAll(strings.TrimLeft(strings.ToLower(arg), "-"), "-", "_")
+	switch key {
+	case "aspect_ratio":
+		return utils.SettingAspect
+	case "voice":
+		return utils.SettingVoice
+	case "format":
+		return utils.SettingOutputFormat
+	case "lang":
+		return utils.SettingLanguage
+	case "receipts":
+		return utils.SettingTipReceipts
+	case "summary":
+		return utils.SettingWeeklySummary
+	}
+	return key
+}
+
+// SetCommand returns the set command, which saves a default option for the
+// user's generations.
+func SetCommand(dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "set",
+		Description: "⚙️ Save a default option for your generations. Usage: !set [setting] [value]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if len(args) < 2 {
+				return sender.SendMessage(ctx, msgCtx, setUsage())
+			}
+			key := settingKey(args[0])
+			value, err := utils.NormalizeUserSetting(key, strings.Join(args[1:], " "))
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+			if err := dbManager.SetUserSetting(msgCtx.Sender.String(), key, value); err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("⚙️ Saved %s = %s. It applies whenever you leave the option out.", key, utils.SanitizeUserText(value)))
+		}),
+	}
+}
+
+// UnsetCommand returns the unset command, which removes a default saved
+// with !set.
+func UnsetCommand(dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "unset",
+		Description: "⚙️ Remove a saved default option. Usage: !unset [setting]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if len(args) != 1 {
+				return sender.SendMessage(ctx, msgCtx, "Usage: !unset [setting]. Use !settings to see your saved defaults.")
+			}
+			key := settingKey(args[0])
+			removed, err := dbManager.DeleteUserSetting(msgCtx.Sender.String(), key)
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			if !removed {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("You have no saved %s.", utils.SanitizeUserText(key)))
+			}
+			return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("⚙️ Removed your saved %s.", key))
+		}),
+	}
+}
+
+// SettingsCommand returns the settings command, which lists the user's
+// saved defaults.
+func SettingsCommand(dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "settings",
+		Description: "⚙️ Show your saved default options. Usage: !settings",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			settings, err := dbManager.GetUserSettings(msgCtx.Sender.String())
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			if len(settings) == 0 {
+				return sender.SendMessage(ctx, msgCtx, "You have no saved defaults.\n\n"+setUsage())
+			}
+			var b strings.Builder
+			b.WriteString("⚙️ Your saved defaults:\n")
+			for _, s := range utils.UserSettingHelp {
+				if v, ok := settings[s.Key]; ok {
+					fmt.Fprintf(&b, "• %s: %s\n", s.Key, utils.SanitizeUserText(v))
+				}
+			}
+			b.WriteString("\nChange one with !set [setting] [value] or remove it with !unset [setting].")
+			return sender.SendMessage(ctx, msgCtx, b.String())
+		}),
+	}
+}
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+// shareUsage documents the share command.
+const shareUsage = "Usage: !share [job_id] [nick|uid]\n" +
+	"Lets another user get the job's result with !redeliver and see its prompt and seed.\n\n" +
+	"• !share [job_id]: list who the job is shared with\n" +
+	"• !share [job_id] [nick|uid] off: stop sharing it with them"
+
+// ShareCommand returns the share command, which lets the owner of a job
+// grant other users access to it.
+func ShareCommand(dbManager *database.DBManager, bot *kit.Bot) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "share",
+		Description: "🤝 Share a finished job with another user. Usage: !share [job_id] [nick|uid]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if len(args) == 0 || len(args) > 3 {
+				return sender.SendMessage(ctx, msgCtx, shareUsage)
+			}
+			jobID, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+			if err != nil || jobID <= 0 {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid job id: %s", utils.SanitizeUserText(args[0])))
+			}
+			owner := msgCtx.Sender.String()
+			notFound := fmt.Sprintf("Job #%d not found.", jobID)
+
+			if len(args) == 1 {
+				users, err := dbManager.ListJobShares(jobID, owner)
+				if errors.Is(err, database.ErrNotJobOwner) {
+					return sender.SendMessage(ctx, msgCtx, notFound)
+				}
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				return sender.SendMessage(ctx, msgCtx, formatJobShares(jobID, users))
+			}
+
+			revoke := false
+			if len(args) == 3 {
+				on, ok := parseOnOff(args[2])
+				if !ok {
+					return sender.SendMessage(ctx, msgCtx, shareUsage)
+				}
+				revoke = !on
+			}
+			target := utils.SanitizeUserText(args[1])
+			uid, err := resolveUser(dbManager, args[1])
+			switch {
+			case errors.Is(err, database.ErrUnknownNick):
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("I don't know anyone called %s. They need to have sent me a command, or you can use their user id.", target))
+			case errors.Is(err, database.ErrAmbiguousNick):
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Several users go by %s. Use their user id instead.", target))
+			case err != nil:
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			if uid == owner {
+				return sender.SendMessage(ctx, msgCtx, "You already have access to your own jobs.")
+			}
+
+			if revoke {
+				removed, err := dbManager.UnshareJob(jobID, owner, uid)
+				if errors.Is(err, database.ErrNotJobOwner) {
+					return sender.SendMessage(ctx, msgCtx, notFound)
+				}
+				if err != nil {
+					return sender.SendErrorMessage(ctx, msgCtx, err)
+				}
+				if !removed {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Job #%d is not shared with %s.", jobID, target))
+				}
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Job #%d is no longer shared with %s.", jobID, target))
+			}
+
+			err = dbManager.ShareJob(jobID, owner, uid, time.Now())
+			if errors.Is(err, database.ErrNotJobOwner) {
+				return sender.SendMessage(ctx, msgCtx, notFound)
+			}
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			notice := fmt.Sprintf("🤝 %s shared job #%d with you. Use !redeliver %d to get it.", utils.SanitizeUserText(msgCtx.Nick), jobID, jobID)
+			if err := utils.SendNoticePM(ctx, bot, dbManager, uid, notice); err != nil {
+				fmt.Printf("WARN: Failed to tell %s about shared job %d: %v\n", uid, jobID, err)
+			}
+			return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Job #%d is now shared with %s. They can get it with !redeliver %d.", jobID, target, jobID))
+		}),
+	}
+}
+
+// resolveUser turns a user id or a nick the bot has seen into a user id.
+func resolveUser(dbManager *database.DBManager, arg string) (string, error) {
+	var id zkidentity.ShortID
+	if err := id.FromString(arg); err == nil {
+		return id.String(), nil
+	}
+	return dbManager.LookupNick(strings.TrimPrefix(arg, "@"))
+}
+
+// formatJobShares lists the users a job is shared with.
+func formatJobShares(jobID int64, users []database.KnownUser) string {
+	if len(users) == 0 {
+		return fmt.Sprintf("Job #%d is not shared with anyone.", jobID)
+	}
+	msg := fmt.Sprintf("🤝 Job #%d is shared with:\n", jobID)
+	for _, u := range users {
+		if u.Nick == "" {
+			msg += fmt.Sprintf("• %s\n", u.UID)
+		} else {
+			msg += fmt.Sprintf("• %s (%s)\n", utils.SanitizeUserText(u.Nick), u.UID)
+		}
+	}
+	return msg
+}
+
+// formatJobDetails describes a job being re-delivered, with its prompt and
+// seed when they were recorded.
+func formatJobDetails(job database.Job, shared bool) string {
+	msg := fmt.Sprintf("📦 Job #%d: !%s with %s, %s", job.ID, job.Command, job.Model, job.CreatedAt.UTC().Format("Jan 2 15:04 MST"))
+	if shared {
+		msg += " (shared with you)"
+	}
+	if job.Prompt != "" {
+		msg += "\nPrompt: " + utils.SanitizeUserText(job.Prompt)
+	}
+	if job.Seed != nil {
+		msg += fmt.Sprintf("\nSeed: %d", *job.Seed)
+	}
+	return msg
+}
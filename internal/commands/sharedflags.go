@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/karamble/braibot/internal/params"
+)
+
+// sharedFlags are the flags every paid command accepts on top of its own.
+var sharedFlags = params.NewSpec(
+	params.NewFlag(params.Percent, "split").Between(1, 100),
+	params.NewFlag(params.Bool, "no_cache"),
+)
+
+// sharedArgs holds the values of the shared flags.
+type sharedArgs struct {
+	SplitPercent int  // Share of the cost the GC pot pays, 0 for none
+	NoCache      bool // Generate anew instead of sending a cached result
+}
+
+// extractSharedFlags removes the shared flags from a command's arguments,
+// leaving the rest for the command's own parsing. Splits are only allowed
+// in group chats.
+func extractSharedFlags(args []string, isPM bool) ([]string, sharedArgs, error) {
+	var shared sharedArgs
+	r, err := params.Extract(args, sharedFlags)
+	if err != nil {
+		return nil, shared, err
+	}
+	if split := r.Int("split"); split != nil {
+		if isPM {
+			return nil, shared, fmt.Errorf("--split is only available in group chats with a funded pot")
+		}
+		shared.SplitPercent = *split
+	}
+	if noCache := r.Bool("no_cache"); noCache != nil {
+		shared.NoCache = *noCache
+	}
+	return r.Args, shared, nil
+}
@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/transcribe"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+// transcribeModel is the audio2text model used by !speech2text.
+const transcribeModel = "elevenlabs/speech-to-text/scribe-v2"
+
+// Speech2TextCommand returns the speech2text command
+func Speech2TextCommand(bot *kit.Bot, transcribeService *transcribe.TranscribeService, dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "speech2text",
+		Description: "📝 Transcribe speech to text. Usage: !speech2text [audio_url] [--language xx] [--task transcribe|translate] or attach an audio note",
+		Category:    "AI Generation",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			// Create a message sender using the adapter
+			msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
+
+			args, shared, sharedErr := extractSharedFlags(args, msgCtx.IsPM)
+			if sharedErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(sharedErr.Error()))
+			}
+
+			// Swap "last" for the user's recent results
+			args, lastErr := resolveLastArgs(dbManager, msgCtx.Sender.String(), args, database.ResultAudio)
+			if lastErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(lastErr.Error()))
+			}
+
+			model, exists := faladapter.GetModel(transcribeModel, "audio2text")
+			if !exists {
+				return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("model not found: %s", transcribeModel))
+			}
+
+			var userID zkidentity.ShortID
+			userID.FromBytes(msgCtx.Uid)
+
+			audioURL, language, task, err := parseSpeech2TextArgs(args)
+			if err != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+
+			// An audio note attached to the command takes precedence over a
+			// URL argument, and its length is known up front
+			estimate := 0
+			if utils.IsAudioNote(msgCtx.Message) {
+				audioData, err := utils.ExtractAudioNoteData(msgCtx.Message)
+				if err != nil {
+					return msgSender.SendMessage(ctx, msgCtx, "Sorry, I couldn't read the attached audio note. Please try again.")
+				}
+				audioURL = "data:audio/ogg;base64," + audioData
+				if raw, err := base64.StdEncoding.DecodeString(audioData); err == nil {
+					estimate, _ = transcribe.OggOpusSeconds(raw)
+				}
+			}
+
+			if audioURL == "" {
+				header := utils.FormatCommandHelpHeader("speech2text", model, userID, msgCtx.IsPM, db)
+				return msgSender.SendMessage(ctx, msgCtx, header+speech2TextUsage)
+			}
+
+			// Create progress callback
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "speech2text")
+
+			req := &transcribe.TranscribeRequest{
+				GenerationRequest: braibottypes.GenerationRequest{
+					ModelType:    "audio2text",
+					ModelName:    model.Name,
+					Progress:     progress,
+					UserNick:     msgCtx.Nick,
+					UserID:       userID,
+					IsPM:         msgCtx.IsPM,
+					GC:           msgCtx.GC,
+					SplitPercent: shared.SplitPercent,
+				},
+				AudioURL:         audioURL,
+				Language:         language,
+				Task:             task,
+				EstimatedSeconds: estimate,
+			}
+
+			result, err := transcribeService.Transcribe(ctx, req)
+
+			// Handle result/error using the utility function
+			if handleErr := utils.HandleServiceResultOrError(ctx, bot, msgCtx, "speech2text", result, err); handleErr != nil {
+				return handleErr
+			}
+
+			return nil
+		}),
+	}
+}
+
+// speech2TextUsage documents the speech2text command.
+const speech2TextUsage = "Usage: !speech2text [audio_url] [options], or attach an audio note to the command\n" +
+	"Example: !speech2text https://example.com/interview.mp3 --language en\n\n" +
+	"Parameters:\n" +
+	"• audio_url: URL of the audio to transcribe (or attach an audio note)\n" +
+	"• --language: ISO 639-1 language code (default: auto-detected)\n" +
+	"• --task: transcribe, or translate to English (default: transcribe)\n\n" +
+	"You are charged for the length of the transcribed audio."
+
+// parseSpeech2TextArgs reads the audio URL and options of !speech2text. An
+// attached audio note arrives as an embed after the options and is ignored.
+func parseSpeech2TextArgs(args []string) (audioURL, language, task string, err error) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "--embed") {
+			break
+		}
+		switch arg {
+		case "--language", "--task":
+			if i+1 >= len(args) {
+				return "", "", "", fmt.Errorf("missing value for %s", arg)
+			}
+			i++
+			if arg == "--language" {
+				language = strings.ToLower(args[i])
+			} else {
+				task = strings.ToLower(args[i])
+			}
+		default:
+			if strings.HasPrefix(arg, "--") {
+				return "", "", "", fmt.Errorf("unknown option %s", arg)
+			}
+			if audioURL != "" {
+				return "", "", "", fmt.Errorf("unexpected argument %s", arg)
+			}
+			audioURL = arg
+		}
+	}
+	if task != "" && task != "transcribe" && task != "translate" {
+		return "", "", "", fmt.Errorf("--task must be transcribe or translate")
+	}
+	return audioURL, language, task, nil
+}
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karamble/braibot/internal/falhook"
+	"github.com/karamble/braibot/internal/health"
+	"github.com/karamble/braibot/internal/jobs"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/pkg/fal"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+// statusProbeTimeout bounds the reachability checks of !status.
+const statusProbeTimeout = 5 * time.Second
+
+// statusReport is what !status reports.
+type statusReport struct {
+	Uptime      time.Duration
+	BRErr       error         // Bison Relay client check; nil when connected
+	FalErr      error         // fal API check; nil when reachable
+	FalLatency  time.Duration // How long fal took to answer
+	RateAge     time.Duration // Age of the cached exchange rate
+	RateFetched bool          // Whether the exchange rate was fetched yet
+	Running     int
+	Pending     int
+	Webhooks    bool // fal posts completions instead of being polled
+}
+
+// StatusCommand returns the status command, which reports the health of the
+// bot and the services it depends on.
+func StatusCommand(bot *kit.Bot, falClient *fal.Client) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "status",
+		Description: "🩺 Show the bot's uptime, connections and job load. Usage: !status",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			return sender.SendMessage(ctx, msgCtx, formatStatus(checkStatus(ctx, bot, falClient)))
+		}),
+	}
+}
+
+// checkStatus collects the status report, checking Bison Relay and fal at
+// the same time.
+func checkStatus(ctx context.Context, bot *kit.Bot, falClient *fal.Client) statusReport {
+	r := statusReport{
+		Uptime:   health.Uptime(),
+		Webhooks: falhook.Default != nil,
+	}
+	r.RateAge, r.RateFetched = utils.DCRPriceAge()
+	r.Running, r.Pending = jobs.Default.Counts()
+
+	ctx, cancel := context.WithTimeout(ctx, statusProbeTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if bot == nil {
+			r.BRErr = fmt.Errorf("no client")
+			return
+		}
+		// Listing the GCs is the cheapest call that needs the client
+		_, r.BRErr = bot.GetGCs(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		if falClient == nil {
+			r.FalErr = fmt.Errorf("no client")
+			return
+		}
+		r.FalLatency, r.FalErr = falClient.Ping(ctx)
+	}()
+	wg.Wait()
+	return r
+}
+
+// formatStatus renders the status report.
+func formatStatus(r statusReport) string {
+	var b strings.Builder
+	b.WriteString("🩺 **Bot status**\n\n")
+	fmt.Fprintf(&b, "• Uptime: %s\n", formatUptime(r.Uptime))
+	if r.BRErr != nil {
+		fmt.Fprintf(&b, "• Bison Relay: ❌ %v\n", r.BRErr)
+	} else {
+		b.WriteString("• Bison Relay: ✅ connected\n")
+	}
+	if r.FalErr != nil {
+		fmt.Fprintf(&b, "• fal.ai: ❌ unreachable: %v\n", r.FalErr)
+	} else {
+		fmt.Fprintf(&b, "• fal.ai: ✅ reachable (%d ms)\n", r.FalLatency.Milliseconds())
+	}
+	if r.RateFetched {
+		fmt.Fprintf(&b, "• Exchange rate: updated %s ago\n", formatUptime(r.RateAge))
+	} else {
+		b.WriteString("• Exchange rate: not fetched yet\n")
+	}
+	fmt.Fprintf(&b, "• Jobs: %d running, %d waiting\n", r.Running, r.Pending)
+	if r.Webhooks {
+		b.WriteString("• fal webhooks: on")
+	} else {
+		b.WriteString("• fal webhooks: off (polling)")
+	}
+	return b.String()
+}
+
+// formatUptime renders a duration as days, hours and minutes, e.g. "2d 3h
+// 4m", or in seconds when it is under a minute.
+func formatUptime(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	mins := int(d.Minutes())
+	days, hours, mins := mins/(24*60), mins/60%24, mins%60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh %dm", days, hours, mins)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, mins)
+	default:
+		return fmt.Sprintf("%dm", mins)
+	}
+}
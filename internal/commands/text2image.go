@@ -3,12 +3,12 @@ package commands
 import (
 	"context"
 	"fmt"
-	"strconv"
-	"strings"
 
 	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
 	"github.com/karamble/braibot/internal/faladapter"
 	"github.com/karamble/braibot/internal/image"
+	"github.com/karamble/braibot/internal/params"
 	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
 	"github.com/karamble/braibot/pkg/fal"
@@ -18,7 +18,7 @@ import (
 
 // Text2ImageCommand returns the text2image command
 // It now requires an ImageService instance.
-func Text2ImageCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *image.ImageService, debug bool) braibottypes.Command {
+func Text2ImageCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *image.ImageService, dbManager *database.DBManager, debug bool) braibottypes.Command {
 	// Get the current model to use its description
 	model, exists := faladapter.GetCurrentModel("text2image", "") // Empty string for global default
 	if !exists {
@@ -40,6 +40,23 @@ func Text2ImageCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *ima
 			// Create a message sender using the adapter
 			msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
 
+			args, shared, sharedErr := extractSharedFlags(args, msgCtx.IsPM)
+			if sharedErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(sharedErr.Error()))
+			}
+
+			// Swap "last" for the user's recent results
+			args, lastErr := resolveLastArgs(dbManager, msgCtx.Sender.String(), args)
+			if lastErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(lastErr.Error()))
+			}
+
+			// Swap --seed last for the seed of the user's previous generation
+			args, seedErr := resolveSeedArg(dbManager, msgCtx.Sender.String(), args)
+			if seedErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(seedErr.Error()))
+			}
+
 			if len(args) < 1 {
 				// Get the current model
 				var userIDStr string
@@ -58,10 +75,10 @@ func Text2ImageCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *ima
 				userID.FromBytes(msgCtx.Uid)
 
 				// Format header using utility function
-				header := utils.FormatCommandHelpHeader("text2image", model, userID, db)
+				header := utils.FormatCommandHelpHeader("text2image", model, userID, msgCtx.IsPM, db)
 
 				// Get help doc
-				helpDoc := model.HelpDoc
+				helpDoc := modelHelpDoc("text2image", model)
 				if helpDoc == "" {
 					helpDoc = "Usage: !text2image [prompt] [--options...]\n(No specific documentation available for this model.)"
 				}
@@ -73,7 +90,7 @@ func Text2ImageCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *ima
 			// Parse arguments and prompt
 			prompt, parsedReq, err := parseTextImageArgs(args)
 			if err != nil {
-				return msgSender.SendMessage(ctx, msgCtx, err.Error())
+				return msgSender.SendMessage(ctx, msgCtx, utils.SanitizeUserText(err.Error()))
 			}
 
 			// Model config is needed for PriceUSD
@@ -89,21 +106,24 @@ func Text2ImageCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *ima
 			}
 
 			// Create progress callback
-			progress := NewCommandProgressCallback(bot, msgCtx.Nick, msgCtx.Sender, "text2image", msgCtx.IsPM, msgCtx.GC)
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "text2image")
 
 			// Create image request
 			var userID zkidentity.ShortID
 			userID.FromBytes(msgCtx.Uid)
 			req := &image.ImageRequest{
 				GenerationRequest: braibottypes.GenerationRequest{
-					ModelType: "text2image",
-					ModelName: model.Name,
-					Progress:  progress,
-					UserNick:  msgCtx.Nick,
-					UserID:    userID,
-					PriceUSD:  model.PriceUSD,
-					IsPM:      msgCtx.IsPM,
-					GC:        msgCtx.GC,
+					ModelType:    "text2image",
+					ModelName:    model.Name,
+					Progress:     progress,
+					UserNick:     msgCtx.Nick,
+					UserID:       userID,
+					PriceUSD:     faladapter.PriceFor(model, faladapter.PriceParams{}),
+					IsPM:         msgCtx.IsPM,
+					GC:           msgCtx.GC,
+					SplitPercent: shared.SplitPercent,
+					NoCache:      shared.NoCache,
+					AssetLink:    prefersAssetLink(cfg, "text2image"),
 				},
 				Prompt:              prompt,
 				NumImages:           parsedReq.NumImages,
@@ -119,209 +139,105 @@ func Text2ImageCommand(bot *kit.Bot, cfg *botconfig.BotConfig, imageService *ima
 				Raw:                   parsedReq.Raw,
 				Acceleration:          parsedReq.Acceleration,
 				EnablePromptExpansion: parsedReq.EnablePromptExpansion,
+				ControlType:           parsedReq.ControlType,
+				ControlImageURL:       parsedReq.ControlImageURL,
+				ControlScale:          parsedReq.ControlScale,
+				Gallery:               parsedReq.Gallery,
 			}
 
-			// Generate image using the service
-			result, err := imageService.GenerateImage(ctx, req)
+			// Generate image using the service; --preview renders a cheap thumbnail instead
+			var result *image.ImageResult
+			if parsedReq.Preview {
+				result, err = imageService.GeneratePreview(ctx, req)
+			} else {
+				result, err = imageService.GenerateImage(ctx, req)
+			}
 
 			// Handle result/error using the utility function
 			if handleErr := utils.HandleServiceResultOrError(ctx, bot, msgCtx, "text2image", result, err); handleErr != nil {
 				return handleErr // Propagate error if not handled by the utility function
 			}
 
+			// Remember the generation for !variations and --seed last
+			if err == nil && result.IsSuccess() {
+				var seed *int64
+				if result.Seed != 0 {
+					s := int64(result.Seed)
+					seed = &s
+				}
+				rememberGeneration(dbManager, msgCtx, "text2image", model.Name, args, seed, 0)
+			}
+
 			// If we reach here, the operation was successful and errors were handled
 			return nil
 		}),
 	}
 }
 
+// text2imageFlags are the options text2image takes.
+var text2imageFlags = []params.Flag{
+	params.NewFlag(params.Int, "num_images").AtLeast(1),
+	params.NewFlag(params.String, "image_size"),
+	params.NewFlag(params.Int, "seed"),
+	params.NewFlag(params.Int, "num_inference_steps").AtLeast(1),
+	params.NewFlag(params.Bool, "enable_safety_checker"),
+	params.NewFlag(params.String, "safety_tolerance"),
+	params.NewFlag(params.Lower, "output_format"),
+	params.NewFlag(params.String, "negative_prompt"),
+	params.NewFlag(params.Float, "guidance_scale"),
+	params.NewFlag(params.String, "aspect_ratio", "aspect"),
+	params.NewFlag(params.Bool, "raw"),
+	params.NewFlag(params.Lower, "acceleration"),
+	params.NewFlag(params.Bool, "enable_prompt_expansion"),
+	params.NewFlag(params.Lower, "control").OneOf("canny", "depth", "pose", "openpose", "normal", "segmentation", "teed"),
+	params.NewFlag(params.String, "control_image"),
+	params.NewFlag(params.Float, "control_scale").Between(0, 1),
+	params.NewFlag(params.Bool, "preview"),
+	params.NewFlag(params.Bool, "gallery"),
+}
+
 // parseTextImageArgs parses the command arguments for text2image, separating the prompt
 // from known options.
 // It returns the prompt string, a partially populated ImageRequest struct containing
 // parsed options, and an error if parsing fails.
 func parseTextImageArgs(args []string) (string, *image.ImageRequest, error) {
-	var promptParts []string
+	parsed, err := params.Parse(args, params.NewSpec(text2imageFlags...))
+	if err != nil {
+		return "", nil, err
+	}
 	parsedReq := &image.ImageRequest{
-		NumImages: 1, // Default
-		// Initialize pointers/zero values for optional fields
-		ImageSize:           "",
-		Seed:                nil,
-		NumInferenceSteps:   nil,
-		EnableSafetyChecker: nil,
-		SafetyTolerance:     "",
-		OutputFormat:        "",
-		NegativePrompt:      "",
-		GuidanceScale:       nil,
-		AspectRatio:           "",
-		Raw:                   nil,
-		Acceleration:          "",
-		EnablePromptExpansion: nil,
+		NumImages:             1, // Default
+		ImageSize:             parsed.String("image_size"),
+		Seed:                  parsed.Int("seed"),
+		NumInferenceSteps:     parsed.Int("num_inference_steps"),
+		EnableSafetyChecker:   parsed.Bool("enable_safety_checker"),
+		SafetyTolerance:       parsed.String("safety_tolerance"),
+		OutputFormat:          parsed.String("output_format"),
+		NegativePrompt:        parsed.String("negative_prompt"),
+		GuidanceScale:         parsed.Float("guidance_scale"),
+		AspectRatio:           parsed.String("aspect_ratio"),
+		Raw:                   parsed.Bool("raw"),
+		Acceleration:          parsed.String("acceleration"),
+		EnablePromptExpansion: parsed.Bool("enable_prompt_expansion"),
+		ControlType:           parsed.String("control"),
+		ControlImageURL:       parsed.String("control_image"),
+		ControlScale:          parsed.Float("control_scale"),
+		Gallery:               parsed.Bool("gallery"),
 	}
-
-	i := 0
-	for i < len(args) {
-		arg := args[i]
-		argLower := strings.ToLower(arg)
-
-		// Handle boolean flags like --flag=value
-		var flagValue string
-		if strings.Contains(argLower, "=") {
-			parts := strings.SplitN(argLower, "=", 2)
-			argLower = parts[0]
-			if len(parts) > 1 {
-				flagValue = parts[1]
-			}
-		}
-
-		switch argLower {
-		case "--num_images":
-			if i+1 < len(args) {
-				val, err := strconv.Atoi(args[i+1])
-				if err != nil || val <= 0 {
-					return "", nil, fmt.Errorf("invalid value for --num_images: '%s'. Must be a positive integer", args[i+1])
-				}
-				parsedReq.NumImages = val
-				i += 2
-			} else {
-				return "", nil, fmt.Errorf("missing value for --num_images argument")
-			}
-		case "--image_size":
-			if i+1 < len(args) {
-				parsedReq.ImageSize = args[i+1]
-				i += 2
-			} else {
-				return "", nil, fmt.Errorf("missing value for --image_size argument")
-			}
-		case "--seed":
-			if i+1 < len(args) {
-				val, err := strconv.Atoi(args[i+1])
-				if err != nil {
-					return "", nil, fmt.Errorf("invalid value for --seed: '%s'. Must be an integer", args[i+1])
-				}
-				parsedReq.Seed = &val
-				i += 2
-			} else {
-				return "", nil, fmt.Errorf("missing value for --seed argument")
-			}
-		case "--num_inference_steps":
-			if i+1 < len(args) {
-				val, err := strconv.Atoi(args[i+1])
-				if err != nil || val <= 0 {
-					return "", nil, fmt.Errorf("invalid value for --num_inference_steps: '%s'. Must be a positive integer", args[i+1])
-				}
-				parsedReq.NumInferenceSteps = &val
-				i += 2
-			} else {
-				return "", nil, fmt.Errorf("missing value for --num_inference_steps argument")
-			}
-		case "--enable_safety_checker":
-			var val bool
-			var err error
-			if flagValue != "" { // Handle --flag=value
-				val, err = strconv.ParseBool(flagValue)
-				if err != nil {
-					return "", nil, fmt.Errorf("invalid value for --enable_safety_checker: '%s'. Must be true or false", flagValue)
-				}
-				i++ // Consume only the flag=value arg
-			} else if i+1 < len(args) && (strings.ToLower(args[i+1]) == "true" || strings.ToLower(args[i+1]) == "false") {
-				val, _ = strconv.ParseBool(args[i+1])
-				i += 2 // Consume flag and value
-			} else {
-				val = true // Assume --flag means true
-				i++        // Consume only the flag
-			}
-			parsedReq.EnableSafetyChecker = &val
-		case "--safety_tolerance":
-			if i+1 < len(args) {
-				parsedReq.SafetyTolerance = args[i+1]
-				i += 2
-			} else {
-				return "", nil, fmt.Errorf("missing value for --safety_tolerance argument")
-			}
-		case "--output_format":
-			if i+1 < len(args) {
-				parsedReq.OutputFormat = strings.ToLower(args[i+1])
-				i += 2
-			} else {
-				return "", nil, fmt.Errorf("missing value for --output_format argument")
-			}
-		case "--negative_prompt", "--negative-prompt":
-			if i+1 < len(args) {
-				parsedReq.NegativePrompt = args[i+1] // Keep original case for prompt
-				i += 2
-			} else {
-				return "", nil, fmt.Errorf("missing value for --negative_prompt argument")
-			}
-		case "--guidance_scale", "--guidance-scale":
-			if i+1 < len(args) {
-				val, err := strconv.ParseFloat(args[i+1], 64)
-				if err != nil {
-					return "", nil, fmt.Errorf("invalid value for --guidance_scale: %s", args[i+1])
-				}
-				parsedReq.GuidanceScale = &val
-				i += 2
-			} else {
-				return "", nil, fmt.Errorf("missing value for --guidance_scale argument")
-			}
-		case "--aspect_ratio", "--aspect-ratio":
-			if i+1 < len(args) {
-				parsedReq.AspectRatio = args[i+1]
-				i += 2
-			} else {
-				return "", nil, fmt.Errorf("missing value for --aspect_ratio argument")
-			}
-		case "--acceleration":
-			if i+1 < len(args) {
-				parsedReq.Acceleration = strings.ToLower(args[i+1])
-				i += 2
-			} else {
-				return "", nil, fmt.Errorf("missing value for --acceleration argument")
-			}
-		case "--enable_prompt_expansion", "--enable-prompt-expansion":
-			var val bool
-			var err error
-			if flagValue != "" {
-				val, err = strconv.ParseBool(flagValue)
-				if err != nil {
-					return "", nil, fmt.Errorf("invalid value for --enable_prompt_expansion: '%s'. Must be true or false", flagValue)
-				}
-				i++
-			} else if i+1 < len(args) && (strings.ToLower(args[i+1]) == "true" || strings.ToLower(args[i+1]) == "false") {
-				val, _ = strconv.ParseBool(args[i+1])
-				i += 2
-			} else {
-				val = true
-				i++
-			}
-			parsedReq.EnablePromptExpansion = &val
-		case "--raw":
-			var val bool
-			var err error
-			if flagValue != "" { // Handle --flag=value
-				val, err = strconv.ParseBool(flagValue)
-				if err != nil {
-					return "", nil, fmt.Errorf("invalid value for --raw: '%s'. Must be true or false", flagValue)
-				}
-				i++
-			} else if i+1 < len(args) && (strings.ToLower(args[i+1]) == "true" || strings.ToLower(args[i+1]) == "false") {
-				val, _ = strconv.ParseBool(args[i+1])
-				i += 2
-			} else {
-				val = true // Assume --raw means true
-				i++
-			}
-			parsedReq.Raw = &val
-		default:
-			// Assume it's part of the prompt
-			promptParts = append(promptParts, args[i]) // Use original arg with case preserved
-			i++
-		}
+	if n := parsed.Int("num_images"); n != nil {
+		parsedReq.NumImages = *n
+	}
+	if preview := parsed.Bool("preview"); preview != nil {
+		parsedReq.Preview = *preview
 	}
 
-	prompt := strings.Join(promptParts, " ")
+	prompt := parsed.Prompt()
 	if prompt == "" {
 		return "", nil, fmt.Errorf("please provide a prompt text")
 	}
+	if parsedReq.ControlType != "" && parsedReq.ControlImageURL == "" {
+		return "", nil, fmt.Errorf("--control requires a reference image via --control_image [url]")
+	}
 
 	return prompt, parsedReq, nil
 }
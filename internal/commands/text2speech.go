@@ -3,11 +3,11 @@ package commands
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/companyzero/bisonrelay/zkidentity"
 	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/params"
 	"github.com/karamble/braibot/internal/speech"
 	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
@@ -33,6 +33,11 @@ func Text2SpeechCommand(bot *kit.Bot, cfg *botconfig.BotConfig, speechService *s
 		Description: description,
 		Category:    "AI Generation",
 		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			args, shared, sharedErr := extractSharedFlags(args, msgCtx.IsPM)
+			if sharedErr != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(sharedErr.Error()))
+			}
+
 			if len(args) < 1 {
 				// Get the current model
 				var userIDStr string
@@ -51,10 +56,10 @@ func Text2SpeechCommand(bot *kit.Bot, cfg *botconfig.BotConfig, speechService *s
 				userID.FromBytes(msgCtx.Uid)
 
 				// Format header using utility function
-				header := utils.FormatCommandHelpHeader("text2speech", model, userID, db)
+				header := utils.FormatCommandHelpHeader("text2speech", model, userID, msgCtx.IsPM, db)
 
 				// Get help doc
-				helpDoc := model.HelpDoc
+				helpDoc := modelHelpDoc("text2speech", model)
 				if helpDoc == "" {
 					helpDoc = "Usage: !text2speech [text] [--options...]\n(No specific documentation available for this model.)"
 				}
@@ -63,9 +68,6 @@ func Text2SpeechCommand(bot *kit.Bot, cfg *botconfig.BotConfig, speechService *s
 				return sender.SendMessage(ctx, msgCtx, header+helpDoc)
 			}
 
-			// Get the text from the arguments
-			text := strings.Join(args, " ")
-
 			// Get model configuration
 			var userIDStr string
 			if msgCtx.IsPM {
@@ -79,13 +81,21 @@ func Text2SpeechCommand(bot *kit.Bot, cfg *botconfig.BotConfig, speechService *s
 			}
 
 			// Create the speech request
+			var userID zkidentity.ShortID
+			userID.FromBytes(msgCtx.Uid)
 			req := speech.SpeechRequest{
 				GenerationRequest: braibottypes.GenerationRequest{
-					ModelName: model.Name,
-					IsPM:      msgCtx.IsPM,
-					GC:        msgCtx.GC,
+					ModelName:    model.Name,
+					UserNick:     msgCtx.Nick,
+					UserID:       userID,
+					IsPM:         msgCtx.IsPM,
+					GC:           msgCtx.GC,
+					SplitPercent: shared.SplitPercent,
+					NoCache:      shared.NoCache,
 				},
-				Text: text,
+			}
+			if err := parseTextSpeechArgs(args, model.Options, &req); err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
 			}
 
 			// Process the speech
@@ -100,102 +110,32 @@ func Text2SpeechCommand(bot *kit.Bot, cfg *botconfig.BotConfig, speechService *s
 	}
 }
 
-// parseTextSpeechArgs parses the command arguments for text2speech.
-// It requires voice_id to be specified with --voice_id parameter.
-// Returns the text, voice_id (or default), and parsed options map, and error.
-func parseTextSpeechArgs(args []string) (text, voiceID string, options map[string]interface{}, err error) {
-	defaultVoiceID := "Wise_Woman"
-	options = make(map[string]interface{})
-	var promptParts []string
-
-	if len(args) == 0 {
-		err = fmt.Errorf("please provide text to convert to speech")
-		return
-	}
-
-	// Initialize with default voice
-	voiceID = defaultVoiceID
-
-	// Parse args for flags and text
-	i := 0
-	for i < len(args) {
-		arg := args[i]
-		argLower := strings.ToLower(arg)
-
-		// Handle flags like --flag=value
-		var flagValue string
-		if strings.Contains(argLower, "=") {
-			parts := strings.SplitN(argLower, "=", 2)
-			argLower = parts[0]
-			if len(parts) > 1 {
-				flagValue = parts[1]
-			}
-		}
-
-		if strings.HasPrefix(argLower, "--") {
-			flagName := strings.TrimPrefix(argLower, "--")
-			var value string
-			if flagValue != "" {
-				value = flagValue
-				i++ // Consume the flag=value arg
-			} else if i+1 < len(args) {
-				value = args[i+1]
-				i += 2 // Consume flag and value
-			} else {
-				err = fmt.Errorf("missing value for argument: %s", arg)
-				return
-			}
+// textSpeechSpec returns the flags text2speech accepts for a model:
+// --voice_id plus the model's options.
+func textSpeechSpec(modelOptions interface{}) *params.Spec {
+	return params.NewSpec(params.NewFlag(params.String, "voice_id", "voice")).Merge(params.FromOptions(modelOptions)...)
+}
 
-			switch flagName {
-			case "speed":
-				fVal, parseErr := strconv.ParseFloat(value, 64)
-				if parseErr != nil {
-					err = fmt.Errorf("invalid value for --speed: %s", value)
-					return
-				}
-				options["speed"] = &fVal
-			case "vol":
-				fVal, parseErr := strconv.ParseFloat(value, 64)
-				if parseErr != nil {
-					err = fmt.Errorf("invalid value for --vol: %s", value)
-					return
-				}
-				options["vol"] = &fVal
-			case "pitch":
-				iVal, parseErr := strconv.Atoi(value)
-				if parseErr != nil {
-					err = fmt.Errorf("invalid value for --pitch: %s", value)
-					return
-				}
-				options["pitch"] = &iVal
-			case "voice_id":
-				voiceID = value
-			case "emotion":
-				options["emotion"] = value
-			case "sample_rate":
-				options["sample_rate"] = value
-			case "bitrate":
-				options["bitrate"] = value
-			case "format":
-				options["format"] = strings.ToLower(value)
-			case "channel":
-				options["channel"] = value
-			default:
-				err = fmt.Errorf("unknown argument: %s", arg)
-				return
-			}
-		} else {
-			// Assume it's part of the prompt
-			promptParts = append(promptParts, arg)
-			i++
-		}
+// parseTextSpeechArgs parses the text and --flags of a text2speech command
+// into req.
+func parseTextSpeechArgs(args []string, modelOptions interface{}, req *speech.SpeechRequest) error {
+	parsed, err := params.Parse(args, textSpeechSpec(modelOptions))
+	if err != nil {
+		return err
 	}
 
-	text = strings.Join(promptParts, " ")
-	if text == "" {
-		err = fmt.Errorf("please provide text to convert to speech")
-		return
+	req.Text = parsed.Prompt()
+	if req.Text == "" {
+		return fmt.Errorf("please provide text to convert to speech")
 	}
-
-	return text, voiceID, options, nil
+	req.VoiceID = parsed.String("voice_id")
+	req.Speed = parsed.Float("speed")
+	req.Vol = parsed.Float("vol")
+	req.Pitch = parsed.Int("pitch")
+	req.Emotion = strings.ToLower(parsed.String("emotion"))
+	req.SampleRate = parsed.String("sample_rate")
+	req.Bitrate = parsed.String("bitrate")
+	req.Format = strings.ToLower(parsed.String("format"))
+	req.Channel = parsed.String("channel")
+	return nil
 }
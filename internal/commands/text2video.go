@@ -3,9 +3,9 @@ package commands
 import (
 	"context"
 	"fmt"
-	"strconv"
 
 	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
 	"github.com/karamble/braibot/internal/faladapter"
 	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
@@ -17,7 +17,7 @@ import (
 
 // Text2VideoCommand returns the text2video command
 // It now requires a VideoService instance.
-func Text2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *video.VideoService, debug bool) braibottypes.Command {
+func Text2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *video.VideoService, dbManager *database.DBManager, debug bool) braibottypes.Command {
 	// Get the current model to use its description
 	model, exists := faladapter.GetCurrentModel("text2video", "") // Empty string for global default
 	if !exists {
@@ -36,6 +36,17 @@ func Text2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vid
 			// Create a message sender using the adapter
 			msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
 
+			args, shared, sharedErr := extractSharedFlags(args, msgCtx.IsPM)
+			if sharedErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(sharedErr.Error()))
+			}
+
+			// Swap --seed last for the seed of the user's previous generation
+			args, seedErr := resolveSeedArg(dbManager, msgCtx.Sender.String(), args)
+			if seedErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(seedErr.Error()))
+			}
+
 			if len(args) < 1 {
 				// Get the current model
 				var userIDStr string
@@ -54,10 +65,10 @@ func Text2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vid
 				userID.FromBytes(msgCtx.Uid)
 
 				// Format header using utility function
-				header := utils.FormatCommandHelpHeader("text2video", model, userID, db)
+				header := utils.FormatCommandHelpHeader("text2video", model, userID, msgCtx.IsPM, db)
 
 				// Get help doc
-				helpDoc := model.HelpDoc
+				helpDoc := modelHelpDoc("text2video", model)
 				if helpDoc == "" {
 					helpDoc = "Usage: !text2video [prompt] [--options...]\n(No specific documentation available for this model.)"
 				}
@@ -70,11 +81,14 @@ func Text2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vid
 			parser := video.NewArgumentParser()
 			parsed, err := parser.Parse(args, false) // No Image URL expected
 			if err != nil {
-				return msgSender.SendMessage(ctx, msgCtx, fmt.Sprintf("Argument error: %v", err))
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
 			}
 			if parsed.Prompt == "" {
 				return msgSender.SendMessage(ctx, msgCtx, "Please provide a text prompt describing the desired video.")
 			}
+			if parsed.Subtitles && !msgCtx.IsPM {
+				return msgSender.SendMessage(ctx, msgCtx, subtitlesPMOnly)
+			}
 
 			// Get model configuration
 			var userIDStr string
@@ -89,68 +103,36 @@ func Text2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vid
 			}
 
 			// Determine effective duration
-			duration := parsed.Duration
-			originalUserDuration := duration
-			durInt := 0
-			if duration != "" {
-				durInt, err = strconv.Atoi(duration)
-				if err != nil || durInt <= 0 {
-					durInt = 0 // fallback to check model default
-				}
-			}
-			if durInt == 0 {
-				// Try to get model default duration (if available)
-				modelDefault := 0
-				// Check for known model default durations
-				switch model.Name {
-				case "kling-video-text":
-					modelDefault = 5
-				case "minimax/hailuo-02":
-					modelDefault = 6
-				case "minimax/video-01", "minimax/video-01-director":
-					modelDefault = 6
-				case "grok-imagine-video-text":
-					modelDefault = 6
-				case "kling-video-v3-text", "kling-video-v3-pro-text":
-					modelDefault = 5
-				case "kling-video-o3-text", "kling-video-o3-pro-text":
-					modelDefault = 5
-				case "seedance-2.0-text":
-					modelDefault = 5
-				}
-				if modelDefault > 0 {
-					durInt = modelDefault
-					duration = strconv.Itoa(modelDefault)
-				} else {
-					durInt = 6 // fallback to hardcoded default
-					duration = "6"
-				}
-			}
+			originalUserDuration := parsed.Duration
+			duration, durInt := videoDuration("text2video", model.Name, parsed.Duration)
 
-			totalCost := model.PriceUSD
-			if model.PerSecondPricing {
-				totalCost = model.PriceUSD * float64(durInt)
-			}
+			totalCost := faladapter.PriceFor(model, faladapter.PriceParams{Seconds: durInt})
 
 			// Create progress callback
-			progress := NewCommandProgressCallback(bot, msgCtx.Nick, msgCtx.Sender, "text2video", msgCtx.IsPM, msgCtx.GC)
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "text2video")
+			if model.PerSecondPricing {
+				progress.SetCostTicker(model.Name, durInt, totalCost)
+			}
 
 			// Create video request using parsed values
 			var userID zkidentity.ShortID
 			userID.FromBytes(msgCtx.Uid)
 			req := &video.VideoRequest{
 				GenerationRequest: braibottypes.GenerationRequest{
-					ModelType: "text2video",
-					ModelName: model.Name,
-					Progress:  progress,
-					UserNick:  msgCtx.Nick,
-					UserID:    userID,
-					PriceUSD:  totalCost,
-					IsPM:      msgCtx.IsPM,
-					GC:        msgCtx.GC,
+					ModelType:    "text2video",
+					ModelName:    model.Name,
+					Progress:     progress,
+					UserNick:     msgCtx.Nick,
+					UserID:       userID,
+					PriceUSD:     totalCost,
+					IsPM:         msgCtx.IsPM,
+					GC:           msgCtx.GC,
+					SplitPercent: shared.SplitPercent,
+					NoCache:      shared.NoCache,
 				},
 				Prompt:          parsed.Prompt,
 				Duration:        duration,
+				BilledSeconds:   durInt,
 				AspectRatio:     parsed.AspectRatio,
 				Resolution:      parsed.Resolution,
 				NegativePrompt:  parsed.NegativePrompt,
@@ -158,14 +140,16 @@ func Text2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vid
 				PromptOptimizer: parsed.PromptOptimizer,
 				GenerateAudio:   parsed.GenerateAudio,
 				Seed:            parsed.Seed,
+				Subtitles:       subtitleText(parsed),
+				BurnSubtitles:   parsed.BurnSubtitles,
 			}
 
 			// Inform user of pricing and total cost
 			if msgCtx.IsPM {
 				if model.PerSecondPricing {
 					msg := fmt.Sprintf(
-						"Model: %s\n💰 Price: $%.2f per video second\nRequested duration: %d seconds\nTotal cost: $%.2f = $%.2f/sec × %d sec",
-						model.Name, model.PriceUSD, durInt, totalCost, model.PriceUSD, durInt,
+						"Model: %s\n💰 Price: $%.2f per video second\nRequested duration: %d seconds\nTotal cost: $%.2f = $%.2f/sec × %d sec%s",
+						model.Name, model.PriceUSD, durInt, totalCost, model.PriceUSD, durInt, serviceFeeNote(model, faladapter.PriceParams{Seconds: durInt}),
 					)
 					if originalUserDuration == "" {
 						msg += fmt.Sprintf("\n(No duration specified, using default duration of %d seconds.)", durInt)
@@ -173,8 +157,8 @@ func Text2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vid
 					msgSender.SendMessage(ctx, msgCtx, msg)
 				} else {
 					msgSender.SendMessage(ctx, msgCtx, fmt.Sprintf(
-						"Model: %s\n💰 Flat fee: $%.2f per video",
-						model.Name, model.PriceUSD,
+						"Model: %s\n💰 Flat fee: $%.2f per video%s",
+						model.Name, model.PriceUSD, serviceFeeNote(model, faladapter.PriceParams{}),
 					))
 				}
 			}
@@ -187,7 +171,27 @@ func Text2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vid
 				return handleErr
 			}
 
+			// Remember the generation for !variations and --seed last
+			if err == nil && result.IsSuccess() {
+				rememberGeneration(dbManager, msgCtx, "text2video", model.Name, args, req.Seed, result.JobID)
+			}
+
 			return nil
 		}),
 	}
 }
+
+// subtitlesPMOnly explains why --subtitles is refused in group chats.
+const subtitlesPMOnly = "Subtitles are sent as files, which group chats cannot receive. Use --subtitles in a private message."
+
+// subtitleText returns the text to subtitle a video with: the --script, or
+// else the prompt. It is empty when no subtitles were asked for.
+func subtitleText(parsed *video.ParseResult) string {
+	if !parsed.Subtitles {
+		return ""
+	}
+	if parsed.Script != "" {
+		return parsed.Script
+	}
+	return parsed.Prompt
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/internal/money"
+	"github.com/karamble/braibot/internal/topup"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// TopupCommand returns the topup command, which tells a user how much DCR to
+// tip for a USD amount and confirms the tip with a receipt when it arrives.
+func TopupCommand() braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "topup",
+		Description: "🧾 Get tip instructions for adding a USD amount to your balance. Usage: !topup [usd_amount]",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if len(args) == 0 {
+				return sender.SendMessage(ctx, msgCtx, "Usage: !topup [usd_amount], e.g. !topup 5")
+			}
+			usd, err := strconv.ParseFloat(strings.TrimPrefix(args[0], "$"), 64)
+			if err != nil || !(usd > 0) || math.IsInf(usd, 0) {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Invalid amount: %s (must be a USD amount, e.g. 5)", utils.SanitizeUserText(args[0])))
+			}
+			atoms, err := utils.USDToAtoms(usd)
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("failed to get the exchange rate: %v", err))
+			}
+			// Round up to an amount a tip can carry
+			atoms = (atoms + money.AtomsPerChainAtom - 1) / money.AtomsPerChainAtom * money.AtomsPerChainAtom
+			inv := topup.Default.Open(msgCtx.Sender.String(), usd, atoms, time.Now())
+
+			instructions := formatTopupInstructions(inv, topup.Default.TTL())
+			if msgCtx.IsPM {
+				return sender.SendMessage(ctx, msgCtx, instructions)
+			}
+			if err := sender.SendPrivateMessage(ctx, msgCtx, instructions); err != nil {
+				return err
+			}
+			return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("%s, I've sent you a PM on how to top up $%.2f.", utils.SanitizeUserText(msgCtx.Nick), usd))
+		}),
+	}
+}
+
+// formatTopupInstructions explains how to pay a top-up.
+func formatTopupInstructions(inv topup.Invoice, ttl time.Duration) string {
+	dcr := money.AtomsToDCR(inv.Atoms)
+	var b strings.Builder
+	fmt.Fprintf(&b, "🧾 **Top-up of $%.2f USD = %.8f DCR**\n\n", inv.USD, dcr)
+	b.WriteString("1. Open this private chat with me in Bison Relay.\n")
+	fmt.Fprintf(&b, "2. Send me a tip of exactly %.8f DCR, e.g. `/tip [my nick] %.8f`.\n", dcr, dcr)
+	b.WriteString("3. Wait for my receipt; tips usually arrive within a minute.\n\n")
+	fmt.Fprintf(&b, "The amount is based on the current exchange rate and is held for %d minutes. Tips of any other amount are still added to your balance.", int(ttl.Minutes()))
+	return b.String()
+}
@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/transcribe"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+// transcribeUsage documents the transcribe command.
+const transcribeUsage = "Usage: !transcribe [last|nick]\n" +
+	"Posts the transcript of an audio note sent in this group chat in the last hour: the newest one with last, or the newest one of nick.\n" +
+	"You are charged for the length of the transcribed audio."
+
+// parseTranscribeArgs returns whose audio note !transcribe asks for: "" for
+// the newest note of anyone, or else the nick.
+func parseTranscribeArgs(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("name one member, or last for the newest audio note")
+	}
+	if strings.EqualFold(args[0], "last") {
+		return "", nil
+	}
+	nick := strings.TrimPrefix(args[0], "@")
+	if nick == "" {
+		return "", fmt.Errorf("name one member, or last for the newest audio note")
+	}
+	return nick, nil
+}
+
+// formatGCNotes lists the audio notes !transcribe can pick from.
+func formatGCNotes(notes []transcribe.GCNote, now time.Time) string {
+	if len(notes) == 0 {
+		return "No audio notes were sent here in the last hour.\n\n" + transcribeUsage
+	}
+	var b strings.Builder
+	b.WriteString("🎙️ Audio notes sent here recently:\n")
+	for _, note := range notes {
+		seconds := 0
+		if raw, err := base64.StdEncoding.DecodeString(note.Data); err == nil {
+			seconds, _ = transcribe.OggOpusSeconds(raw)
+		}
+		fmt.Fprintf(&b, "• %s, %ds long, %s ago\n", utils.SanitizeUserText(note.Nick), seconds, now.Sub(note.PostedAt).Round(time.Minute))
+	}
+	b.WriteString("\n" + transcribeUsage)
+	return b.String()
+}
+
+// TranscribeCommand returns the transcribe command, which posts the
+// transcript of an audio note a member sent in a group chat. The audio
+// notes are kept by the dispatcher in notes, and only in GCs whose admins
+// allow transcribing there.
+func TranscribeCommand(bot *kit.Bot, transcribeService *transcribe.TranscribeService, dbManager *database.DBManager, notes *transcribe.GCNotes) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "transcribe",
+		Description: "📝 Post the transcript of an audio note sent in this group chat. Usage: !transcribe [last|nick]",
+		Category:    "AI Generation",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
+			if msgCtx.IsPM {
+				return msgSender.SendMessage(ctx, msgCtx, "!transcribe works in group chats. To transcribe your own audio, send it with !speech2text.")
+			}
+			settings, err := dbManager.GCSettings(msgCtx.GC)
+			if err != nil {
+				return msgSender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			if !settings.Transcribe {
+				return msgSender.SendMessage(ctx, msgCtx, fmt.Sprintf("Transcribing audio notes is off in this group chat. An admin can turn it on with !admin gc %s transcribe on.", msgCtx.GC))
+			}
+
+			now := time.Now()
+			if len(args) == 0 {
+				return msgSender.SendMessage(ctx, msgCtx, formatGCNotes(notes.Recent(msgCtx.GC, now), now))
+			}
+
+			args, shared, sharedErr := extractSharedFlags(args, msgCtx.IsPM)
+			if sharedErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(sharedErr.Error()))
+			}
+			nick, err := parseTranscribeArgs(args)
+			if err != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+			note, found := notes.Latest(msgCtx.GC, nick, now)
+			if !found {
+				if nick != "" {
+					return msgSender.SendMessage(ctx, msgCtx, fmt.Sprintf("%s sent no audio note here in the last hour.", utils.SanitizeUserText(nick)))
+				}
+				return msgSender.SendMessage(ctx, msgCtx, "No audio notes were sent here in the last hour.")
+			}
+
+			model, exists := faladapter.GetModel(transcribeModel, "audio2text")
+			if !exists {
+				return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("model not found: %s", transcribeModel))
+			}
+
+			var userID zkidentity.ShortID
+			userID.FromBytes(msgCtx.Uid)
+
+			estimate := 0
+			if raw, err := base64.StdEncoding.DecodeString(note.Data); err == nil {
+				estimate, _ = transcribe.OggOpusSeconds(raw)
+			}
+
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "transcribe")
+
+			req := &transcribe.TranscribeRequest{
+				GenerationRequest: braibottypes.GenerationRequest{
+					ModelType:    "audio2text",
+					ModelName:    model.Name,
+					Progress:     progress,
+					UserNick:     msgCtx.Nick,
+					UserID:       userID,
+					IsPM:         msgCtx.IsPM,
+					GC:           msgCtx.GC,
+					SplitPercent: shared.SplitPercent,
+				},
+				AudioURL:         "data:audio/ogg;base64," + note.Data,
+				Speaker:          note.Nick,
+				EstimatedSeconds: estimate,
+			}
+
+			result, err := transcribeService.Transcribe(ctx, req)
+			return utils.HandleServiceResultOrError(ctx, bot, msgCtx, "transcribe", result, err)
+		}),
+	}
+}
@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// maxVariations is the most takes a single !variations asks for.
+const maxVariations = 4
+
+// seedFlagValue returns the index of the value of the --seed flag in args,
+// or -1 when args have no --seed. For --seed=N the index is the flag's own.
+func seedFlagValue(args []string) int {
+	for i, arg := range args {
+		lower := strings.ToLower(arg)
+		if strings.HasPrefix(lower, "--seed=") {
+			return i
+		}
+		if lower == "--seed" && i+1 < len(args) {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// resolveSeedArg replaces "--seed last" with the seed of the user's newest
+// generation whose seed is known.
+func resolveSeedArg(dbManager *database.DBManager, uid string, args []string) ([]string, error) {
+	i := seedFlagValue(args)
+	if i < 0 {
+		return args, nil
+	}
+	value, joined := strings.CutPrefix(strings.ToLower(args[i]), "--seed=")
+	if !joined {
+		value = strings.ToLower(args[i])
+	}
+	if value != "last" {
+		return args, nil
+	}
+	if dbManager == nil {
+		return nil, fmt.Errorf("your previous seeds are not available")
+	}
+	entry, found, err := dbManager.LastHistory(uid, true)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("none of your recent generations reported a seed to use as --seed last")
+	}
+
+	resolved := make([]string, len(args))
+	copy(resolved, args)
+	resolved[i] = strconv.FormatInt(*entry.Seed, 10)
+	if joined {
+		resolved[i] = "--seed=" + resolved[i]
+	}
+	return resolved, nil
+}
+
+// withoutSeed returns args without their --seed flag, so a generation can be
+// run again with another seed.
+func withoutSeed(args []string) []string {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		lower := strings.ToLower(args[i])
+		if strings.HasPrefix(lower, "--seed=") {
+			continue
+		}
+		if lower == "--seed" {
+			i++ // The flag's value
+			continue
+		}
+		out = append(out, args[i])
+	}
+	return out
+}
+
+// rememberGeneration records a finished generation for !variations and
+// --seed last. seed is nil when the provider did not report it.
+func rememberGeneration(dbManager *database.DBManager, msgCtx braibottypes.MessageContext, command, model string, args []string, seed *int64, jobID int64) {
+	if dbManager == nil {
+		return
+	}
+	_, err := dbManager.RecordHistory(database.HistoryEntry{
+		UID:       msgCtx.Sender.String(),
+		Command:   command,
+		Model:     model,
+		Args:      withoutSeed(args),
+		Seed:      seed,
+		JobID:     jobID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		fmt.Printf("WARN: Failed to record %s generation of %s: %v\n", command, msgCtx.Nick, err)
+	}
+}
+
+// parseVariationsArgs parses "[job-id|last] [count]". jobID is 0 for last.
+func parseVariationsArgs(args []string) (jobID int64, count int, err error) {
+	if len(args) == 0 || len(args) > 2 {
+		return 0, 0, fmt.Errorf("usage: !variations [job-id|last] [count]")
+	}
+	if !strings.EqualFold(args[0], "last") {
+		jobID, err = strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+		if err != nil || jobID <= 0 {
+			return 0, 0, fmt.Errorf("invalid job id: %s", args[0])
+		}
+	}
+	count = 1
+	if len(args) == 2 {
+		count, err = strconv.Atoi(args[1])
+		if err != nil || count < 1 || count > maxVariations {
+			return 0, 0, fmt.Errorf("count must be between 1 and %d", maxVariations)
+		}
+	}
+	return jobID, count, nil
+}
+
+// VariationsCommand returns the variations command, which runs one of the
+// user's previous generations again with new seeds.
+func VariationsCommand(registry *Registry, dbManager *database.DBManager) braibottypes.Command {
+	usage := fmt.Sprintf("Usage: !variations [job-id|last] [count]\nRuns your previous generation, or the one of a video job, again with the same model and prompt and a new seed. count is 1 to %d; each take is billed like the original.", maxVariations)
+	return braibottypes.Command{
+		Name:        "variations",
+		Description: "🎲 Re-run a previous generation with new seeds. Usage: !variations [job-id|last] [count]",
+		Category:    "AI Generation",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if len(args) == 0 {
+				return sender.SendMessage(ctx, msgCtx, usage)
+			}
+			jobID, count, err := parseVariationsArgs(args)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+
+			uid := msgCtx.Sender.String()
+			var entry database.HistoryEntry
+			var found bool
+			if jobID > 0 {
+				entry, found, err = dbManager.HistoryByJob(uid, jobID)
+			} else {
+				entry, found, err = dbManager.LastHistory(uid, false)
+			}
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			if !found {
+				if jobID > 0 {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Job #%d is not one of your recent generations.", jobID))
+				}
+				return sender.SendMessage(ctx, msgCtx, "You have no recent generations to make variations of.")
+			}
+
+			cmd, exists := registry.Get(entry.Command)
+			if !exists {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("!%s is no longer available.", entry.Command))
+			}
+			// The commands generate with the current model, so a changed
+			// selection would not give variations of the same model
+			var userIDStr string
+			if msgCtx.IsPM {
+				var uid zkidentity.ShortID
+				uid.FromBytes(msgCtx.Uid)
+				userIDStr = uid.String()
+			}
+			if model, ok := faladapter.GetCurrentModel(entry.Command, userIDStr); !ok || model.Name != entry.Model {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("That generation used %s. Switch back with !setmodel %s %s to make variations of it.", entry.Model, entry.Command, entry.Model))
+			}
+
+			for i := 0; i < count; i++ {
+				seed := rand.Int64N(math.MaxInt32)
+				if err := sender.SendMessage(ctx, msgCtx, fmt.Sprintf("🎲 Variation %d of %d: !%s with seed %d", i+1, count, entry.Command, seed)); err != nil {
+					return err
+				}
+				runArgs := append(append([]string(nil), entry.Args...), "--seed", strconv.FormatInt(seed, 10))
+				if err := cmd.Handler.Handle(ctx, msgCtx, runArgs, sender, db); err != nil {
+					return err
+				}
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+			}
+			return nil
+		}),
+	}
+}
@@ -3,9 +3,9 @@ package commands
 import (
 	"context"
 	"fmt"
-	"strconv"
 
 	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
 	"github.com/karamble/braibot/internal/faladapter"
 	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
@@ -16,7 +16,7 @@ import (
 )
 
 // Video2VideoCommand returns the video2video command
-func Video2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *video.VideoService, debug bool) braibottypes.Command {
+func Video2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *video.VideoService, dbManager *database.DBManager, debug bool) braibottypes.Command {
 	// Get the current model to use its description
 	model, exists := faladapter.GetCurrentModel("video2video", "")
 	if !exists {
@@ -35,6 +35,17 @@ func Video2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vi
 			// Create a message sender using the adapter
 			msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
 
+			args, shared, sharedErr := extractSharedFlags(args, msgCtx.IsPM)
+			if sharedErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(sharedErr.Error()))
+			}
+
+			// Swap "last" for the user's recent results
+			args, lastErr := resolveLastArgs(dbManager, msgCtx.Sender.String(), args, database.ResultVideo)
+			if lastErr != nil {
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(lastErr.Error()))
+			}
+
 			if len(args) < 1 {
 				// Get the current model
 				var userIDStr string
@@ -53,7 +64,7 @@ func Video2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vi
 				userID.FromBytes(msgCtx.Uid)
 
 				// Format header using utility function
-				header := utils.FormatCommandHelpHeader("video2video", model, userID, db)
+				header := utils.FormatCommandHelpHeader("video2video", model, userID, msgCtx.IsPM, db)
 
 				// Get help doc
 				helpDoc := model.HelpDoc
@@ -69,12 +80,8 @@ func Video2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vi
 			parser := video.NewArgumentParser()
 			parsed, err := parser.ParseVideo2Video(args)
 			if err != nil {
-				return msgSender.SendMessage(ctx, msgCtx, fmt.Sprintf("Argument error: %v", err))
-			}
-			if parsed.Prompt == "" {
-				return msgSender.SendMessage(ctx, msgCtx, "Please provide a prompt describing the desired video edit.")
+				return msgSender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
 			}
-
 			// Get model configuration
 			var userIDStr string
 			if msgCtx.IsPM {
@@ -87,60 +94,95 @@ func Video2VideoCommand(bot *kit.Bot, cfg *botconfig.BotConfig, videoService *vi
 				return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("no default model found for video2video"))
 			}
 
-			// Determine effective duration for billing
-			duration := parsed.Duration
-			durInt := 0
-			if duration != "" {
-				durInt, err = strconv.Atoi(duration)
-				if err != nil || durInt <= 0 {
-					durInt = 0
-				}
+			// Only the edit models need a prompt; upscaling, lipsync, motion
+			// control and frame interpolation work on their media inputs
+			interpolation := model.Name == "rife-video" || model.Name == "film-video"
+			edit := model.Name == "kling-video-o3-edit" || model.Name == "kling-video-o3-pro-edit"
+			if parsed.Prompt == "" && edit {
+				return msgSender.SendMessage(ctx, msgCtx, "Please provide a prompt describing the desired video edit.")
 			}
-			if durInt == 0 {
-				durInt = 5 // Default billing duration
-				duration = "5"
+			if parsed.Factor != 0 && !interpolation {
+				return msgSender.SendMessage(ctx, msgCtx, fmt.Sprintf("--factor is only supported by frame interpolation models, not %s.", model.Name))
 			}
 
-			totalCost := model.PriceUSD
-			if model.PerSecondPricing {
-				totalCost = model.PriceUSD * float64(durInt)
+			// The URL after the video is the audio to lipsync to or the
+			// character image to animate
+			audioURL, imageURL := parsed.AudioURL, parsed.ImageURL
+			switch model.Name {
+			case "sync-lipsync-v2":
+				if audioURL == "" {
+					audioURL = parsed.SecondURL
+				}
+				if audioURL == "" {
+					return msgSender.SendMessage(ctx, msgCtx, "Please provide the audio to sync: !video2video [video_url] [audio_url]")
+				}
+			case "kling-video-v26-motion-control":
+				if imageURL == "" {
+					imageURL = parsed.SecondURL
+				}
+				if imageURL == "" {
+					return msgSender.SendMessage(ctx, msgCtx, "Please provide the character image: !video2video [video_url] [image_url]")
+				}
+			default:
+				if parsed.SecondURL != "" {
+					return msgSender.SendMessage(ctx, msgCtx, fmt.Sprintf("%s takes a single video URL. Use --image1 to add reference images.", model.Name))
+				}
 			}
 
+			// Determine effective duration for billing
+			duration, durInt := videoDuration("video2video", model.Name, parsed.Duration)
+
+			totalCost := faladapter.PriceFor(model, faladapter.PriceParams{Seconds: durInt})
+
 			// Create progress callback
-			progress := NewCommandProgressCallback(bot, msgCtx.Nick, msgCtx.Sender, "video2video", msgCtx.IsPM, msgCtx.GC)
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "video2video")
+			if model.PerSecondPricing {
+				progress.SetCostTicker(model.Name, durInt, totalCost)
+			}
 
 			// Create video request using parsed values
 			var userID zkidentity.ShortID
 			userID.FromBytes(msgCtx.Uid)
 			req := &video.VideoRequest{
 				GenerationRequest: braibottypes.GenerationRequest{
-					ModelType: "video2video",
-					ModelName: model.Name,
-					Progress:  progress,
-					UserNick:  msgCtx.Nick,
-					UserID:    userID,
-					PriceUSD:  totalCost,
-					IsPM:      msgCtx.IsPM,
-					GC:        msgCtx.GC,
+					ModelType:    "video2video",
+					ModelName:    model.Name,
+					Progress:     progress,
+					UserNick:     msgCtx.Nick,
+					UserID:       userID,
+					PriceUSD:     totalCost,
+					IsPM:         msgCtx.IsPM,
+					GC:           msgCtx.GC,
+					SplitPercent: shared.SplitPercent,
+					NoCache:      shared.NoCache,
 				},
-				Prompt:    parsed.Prompt,
-				VideoURL:  parsed.VideoURL,
-				KeepAudio: parsed.KeepAudio,
-				ImageURLs: parsed.ImageURLs,
-				Duration:  duration,
+				Prompt:        parsed.Prompt,
+				VideoURL:      parsed.VideoURL,
+				KeepAudio:     parsed.KeepAudio,
+				ImageURLs:     parsed.ImageURLs,
+				Duration:      duration,
+				BilledSeconds: durInt,
+				Factor:        parsed.Factor,
+				SlowMotion:    parsed.SlowMotion,
+
+				AudioURL:             audioURL,
+				ImageURL:             imageURL,
+				CharacterOrientation: parsed.Orientation,
+				ProviderModel:        parsed.ProviderModel,
+				OutputType:           parsed.OutputType,
 			}
 
 			// Inform user of pricing and total cost
 			if msgCtx.IsPM {
 				if model.PerSecondPricing {
 					msgSender.SendMessage(ctx, msgCtx, fmt.Sprintf(
-						"Model: %s\n💰 Price: $%.2f per video second\nEstimated duration: %d seconds\nEstimated cost: $%.2f = $%.2f/sec × %d sec",
-						model.Name, model.PriceUSD, durInt, totalCost, model.PriceUSD, durInt,
+						"Model: %s\n💰 Price: $%.2f per video second\nEstimated duration: %d seconds\nEstimated cost: $%.2f = $%.2f/sec × %d sec%s",
+						model.Name, model.PriceUSD, durInt, totalCost, model.PriceUSD, durInt, serviceFeeNote(model, faladapter.PriceParams{Seconds: durInt}),
 					))
 				} else {
 					msgSender.SendMessage(ctx, msgCtx, fmt.Sprintf(
-						"Model: %s\n💰 Flat fee: $%.2f per video",
-						model.Name, model.PriceUSD,
+						"Model: %s\n💰 Flat fee: $%.2f per video%s",
+						model.Name, model.PriceUSD, serviceFeeNote(model, faladapter.PriceParams{}),
 					))
 				}
 			}
@@ -0,0 +1,56 @@
+package commands
+
+import "strconv"
+
+// videoDefaultSeconds are the clip lengths video models render when no
+// --duration is given, per command. Models not listed render the command's
+// fallbackVideoSeconds.
+var videoDefaultSeconds = map[string]map[string]int{
+	"text2video": {
+		"kling-video-text":          5,
+		"minimax/hailuo-02":         6,
+		"minimax/video-01":          6,
+		"minimax/video-01-director": 6,
+		"grok-imagine-video-text":   6,
+		"kling-video-v3-text":       5,
+		"kling-video-v3-pro-text":   5,
+		"kling-video-o3-text":       5,
+		"kling-video-o3-pro-text":   5,
+		"seedance-2.0-text":         5,
+	},
+	"image2video": {
+		"grok-imagine-video":       6,
+		"kling-video-v25-image":    5,
+		"veo3":                     8,
+		"veo31fast":                8,
+		"kling-video-v3-image":     5,
+		"kling-video-v3-pro-image": 5,
+		"seedance-2.0-image":       5,
+	},
+	"multi2video": {
+		"seedance-2.0-reference": 5,
+	},
+}
+
+// fallbackVideoSeconds is the billed length of videos of models without a
+// known default, per command.
+var fallbackVideoSeconds = map[string]int{
+	"text2video":  6,
+	"image2video": 6,
+	"multi2video": 5,
+	"video2video": 5,
+}
+
+// videoDuration returns the duration to request from model for a video
+// command and the seconds to bill: the requested duration, or the model's
+// default when none or an invalid one was given.
+func videoDuration(command, model, requested string) (string, int) {
+	if seconds, err := strconv.Atoi(requested); err == nil && seconds > 0 {
+		return requested, seconds
+	}
+	seconds, ok := videoDefaultSeconds[command][model]
+	if !ok {
+		seconds = fallbackVideoSeconds[command]
+	}
+	return strconv.Itoa(seconds), seconds
+}
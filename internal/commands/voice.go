@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/jobevents"
+	"github.com/karamble/braibot/internal/speech"
+	"github.com/karamble/braibot/internal/transcribe"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+// voiceReplyModel is the text2speech model that speaks AI replies; it is
+// the one able to return raw PCM for the Opus encoder.
+const voiceReplyModel = "minimax-tts/text-to-speech"
+
+// defaultReplyVoice speaks AI replies to users who have not picked a voice
+// with !text2speech.
+const defaultReplyVoice = "Wise_Woman"
+
+// VoiceChat answers audio notes sent to the AI: the note is transcribed,
+// the transcript sent to the AI webhook and the reply spoken back as an
+// audio note in the user's text2speech voice.
+type VoiceChat struct {
+	registry          *Registry
+	bot               *kit.Bot
+	dbManager         *database.DBManager
+	transcribeService *transcribe.TranscribeService
+	speechService     *speech.SpeechService
+	debug             bool
+}
+
+// NewVoiceChat creates a new VoiceChat
+func NewVoiceChat(registry *Registry, bot *kit.Bot, dbManager *database.DBManager, transcribeService *transcribe.TranscribeService, speechService *speech.SpeechService, debug bool) *VoiceChat {
+	return &VoiceChat{
+		registry:          registry,
+		bot:               bot,
+		dbManager:         dbManager,
+		transcribeService: transcribeService,
+		speechService:     speechService,
+		debug:             debug,
+	}
+}
+
+// Reply answers the audio note in msgCtx with a text and a spoken reply.
+// Transcription and speech are billed together once the reply is sent.
+func (v *VoiceChat) Reply(ctx context.Context, msgCtx braibottypes.MessageContext, webhookURL, webhookAPIKey string) error {
+	msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(v.bot))
+
+	sttModel, ok := faladapter.GetModel(transcribeModel, "audio2text")
+	if !ok {
+		return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("model not found: %s", transcribeModel))
+	}
+	ttsModel, ok := faladapter.GetModel(voiceReplyModel, "text2speech")
+	if !ok {
+		return msgSender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("model not found: %s", voiceReplyModel))
+	}
+
+	audioData, err := utils.ExtractAudioNoteData(msgCtx.Message)
+	if err != nil {
+		return msgSender.SendMessage(ctx, msgCtx, "Sorry, I couldn't read your audio note. Please try again.")
+	}
+	seconds := 0
+	if raw, err := base64.StdEncoding.DecodeString(audioData); err == nil {
+		seconds, _ = transcribe.OggOpusSeconds(raw)
+	}
+
+	var userID zkidentity.ShortID
+	userID.FromBytes(msgCtx.Uid)
+	req := braibottypes.GenerationRequest{
+		ModelType: "audio2text",
+		ModelName: sttModel.Name,
+		UserNick:  msgCtx.Nick,
+		UserID:    userID,
+		IsPM:      msgCtx.IsPM,
+		GC:        msgCtx.GC,
+	}
+	jobevents.Default.Submit(&req)
+
+	// The reply is not known yet, so the balance is checked against the
+	// longest reply that is spoken
+	billingEnabled := v.registry.GetBillingEnabled()
+	var currentBalanceDCR float64
+	if billingEnabled {
+		estimate := faladapter.PriceFor(sttModel, faladapter.PriceParams{Seconds: max(seconds, 1)}) +
+			faladapter.PriceFor(ttsModel, faladapter.PriceParams{TextChars: ttsModel.MaxTextChars})
+		_, balance, checkErr := utils.CheckRequestBalance(ctx, v.dbManager, &req, estimate, v.debug, billingEnabled)
+		if checkErr != nil {
+			jobevents.Default.EmitFailed(&req, checkErr)
+			return utils.HandleServiceResultOrError(ctx, v.bot, msgCtx, "ai", nil, checkErr)
+		}
+		currentBalanceDCR = balance
+	}
+
+	// Wait for a free slot for this kind of job
+	release, slotErr := utils.AcquireJobSlot(ctx, v.bot, &req)
+	if slotErr != nil {
+		jobevents.Default.EmitFailed(&req, slotErr)
+		return utils.HandleServiceResultOrError(ctx, v.bot, msgCtx, "ai", nil, slotErr)
+	}
+	defer release()
+
+	// 1. Transcribe the note
+	heard, err := v.transcribeService.Run(ctx, &transcribe.TranscribeRequest{
+		GenerationRequest: req,
+		AudioURL:          "data:audio/ogg;base64," + audioData,
+	})
+	if err != nil {
+		jobevents.Default.EmitFailed(&req, err)
+		return utils.HandleServiceResultOrError(ctx, v.bot, msgCtx, "ai", heard, err)
+	}
+	req.PriceUSD = faladapter.PriceFor(sttModel, faladapter.PriceParams{Seconds: heard.Seconds})
+	if strings.TrimSpace(heard.Text) == "" {
+		v.bill(ctx, msgCtx, &req, billingEnabled, currentBalanceDCR)
+		return msgSender.SendMessage(ctx, msgCtx, "I couldn't hear any speech in your audio note.")
+	}
+	if v.debug {
+		fmt.Printf("DEBUG [ai] User %s: Transcribed %d seconds of audio\n", msgCtx.Nick, heard.Seconds)
+	}
+
+	// 2. Ask the AI and send its reply as text
+	output, sessionID, err := askWebhook(ctx, webhookURL, webhookAPIKey, heard.Text, msgCtx.Nick, v.debug)
+	if err != nil {
+		jobevents.Default.EmitFailed(&req, err)
+		v.bill(ctx, msgCtx, &req, billingEnabled, currentBalanceDCR)
+		return msgSender.SendMessage(ctx, msgCtx, "Unable to process your query: "+utils.SanitizeUserText(err.Error())+".")
+	}
+	replyTo := msgCtx.Nick
+	if msgCtx.IsPM {
+		replyTo = sessionID
+	}
+	if err := utils.SendToUser(ctx, v.bot, msgCtx.IsPM, replyTo, msgCtx.GC, output); err != nil {
+		fmt.Printf("WARN: Failed to send AI reply to %s: %v\n", msgCtx.Nick, err)
+	}
+
+	// 3. Speak the reply in the user's voice
+	voice := v.speechService.Voice(userID.String())
+	if voice == "" {
+		voice = defaultReplyVoice
+	}
+	note, spoken, err := v.speechService.VoiceNote(ctx, &speech.SpeechRequest{
+		GenerationRequest: braibottypes.GenerationRequest{
+			ModelType: "text2speech",
+			UserNick:  msgCtx.Nick,
+			UserID:    userID,
+			IsPM:      msgCtx.IsPM,
+			GC:        msgCtx.GC,
+		},
+		Text:    output,
+		VoiceID: voice,
+	})
+	if err != nil {
+		jobevents.Default.EmitFailed(&req, fmt.Errorf("failed to speak reply: %w", err))
+		v.bill(ctx, msgCtx, &req, billingEnabled, currentBalanceDCR)
+		return msgSender.SendMessage(ctx, msgCtx, "Sorry, I couldn't speak my reply this time.")
+	}
+	embed := fmt.Sprintf("--embed[alt=Audio note,type=audio/ogg,data=%s]--", base64.StdEncoding.EncodeToString(note))
+	if err := utils.SendToUser(ctx, v.bot, msgCtx.IsPM, replyTo, msgCtx.GC, embed); err != nil {
+		jobevents.Default.EmitFailed(&req, fmt.Errorf("failed to send spoken reply: %w", err))
+		v.bill(ctx, msgCtx, &req, billingEnabled, currentBalanceDCR)
+		return err
+	}
+	jobevents.Default.EmitDelivered(&req, 1)
+
+	// 4. Bill the transcription and the spoken reply together
+	req.PriceUSD += faladapter.PriceFor(ttsModel, faladapter.PriceParams{TextChars: spoken})
+	v.bill(ctx, msgCtx, &req, billingEnabled, currentBalanceDCR)
+	return nil
+}
+
+// bill charges req.PriceUSD for a voice reply and confirms the charge in
+// the PM. Nothing is sent when billing is disabled.
+func (v *VoiceChat) bill(ctx context.Context, msgCtx braibottypes.MessageContext, req *braibottypes.GenerationRequest, billingEnabled bool, balanceDCR float64) {
+	if !billingEnabled {
+		return
+	}
+	chargedDCR, newBalanceDCR, split, err := utils.DeductRequestBalance(ctx, v.dbManager, req, req.PriceUSD, v.debug, billingEnabled)
+	billingSucceeded := err == nil
+	if err != nil {
+		if msgCtx.IsPM {
+			v.bot.SendPM(ctx, msgCtx.Nick, fmt.Sprintf("Error processing payment after your voice reply: %v. Please contact support.", err))
+		}
+	} else {
+		balanceDCR = newBalanceDCR
+		jobevents.Default.EmitBilled(req, chargedDCR)
+	}
+	if msgCtx.IsPM {
+		v.bot.SendPM(ctx, msgCtx.Nick, utils.FormatBillingConfirmation("voice reply", billingEnabled, true, billingSucceeded, chargedDCR, req.PriceUSD, balanceDCR)+utils.LowBalanceWarning(v.dbManager, msgCtx.Sender.String(), balanceDCR, chargedDCR, req.PriceUSD))
+	} else if split != nil {
+		// The group sees the pot's share; the requester's share and balance
+		// go by PM
+		v.bot.SendGC(ctx, msgCtx.GC, utils.FormatSplitPotReceipt(split))
+		v.bot.SendPM(ctx, msgCtx.Nick, utils.FormatSplitBillingConfirmation(split, req.PriceUSD)+utils.LowBalanceWarning(v.dbManager, msgCtx.Sender.String(), split.UserBalanceDCR, split.UserDCR+split.PotDCR, req.PriceUSD))
+	}
+}
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/speech"
+	"github.com/karamble/braibot/internal/transcribe"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/pkg/fal"
+	kit "github.com/vctt94/bisonbotkit"
+	botconfig "github.com/vctt94/bisonbotkit/config"
+)
+
+// voiceSwapModel is the audio2audio model used by !voiceswap.
+const voiceSwapModel = "elevenlabs-voice-changer"
+
+// voiceSwapVoices lists the voices !voiceswap speaks in, sorted.
+func voiceSwapVoices() []string {
+	voices := make([]string, 0, len(fal.ElevenLabsVoices))
+	for v := range fal.ElevenLabsVoices {
+		voices = append(voices, v)
+	}
+	sort.Strings(voices)
+	return voices
+}
+
+// parseVoiceSwapArgs reads the voice and options of !voiceswap. The voice
+// is matched regardless of case. An attached audio note arrives as an embed
+// after the options and is ignored.
+func parseVoiceSwapArgs(args []string) (voice string, denoise bool, err error) {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--embed") {
+			break
+		}
+		switch {
+		case strings.EqualFold(arg, "--denoise"):
+			denoise = true
+		case strings.HasPrefix(arg, "--"):
+			return "", false, fmt.Errorf("unknown option %s", arg)
+		case voice != "":
+			return "", false, fmt.Errorf("unexpected argument %s", arg)
+		default:
+			for v := range fal.ElevenLabsVoices {
+				if strings.EqualFold(v, arg) {
+					voice = v
+				}
+			}
+			if voice == "" {
+				return "", false, fmt.Errorf("unknown voice %s. Voices: %s", arg, strings.Join(voiceSwapVoices(), ", "))
+			}
+		}
+	}
+	return voice, denoise, nil
+}
+
+// VoiceSwapCommand returns the voiceswap command, which says the speech of
+// an attached audio note again in another voice.
+func VoiceSwapCommand(bot *kit.Bot, cfg *botconfig.BotConfig, speechService *speech.SpeechService, dbManager *database.DBManager) braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "voiceswap",
+		Description: "🎭 Convert an audio note to another voice. Usage: !voiceswap [voice] [--denoise] with an audio note attached",
+		Category:    "AI Generation",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			args, shared, err := extractSharedFlags(args, msgCtx.IsPM)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+
+			model, exists := faladapter.GetModel(voiceSwapModel, "audio2audio")
+			if !exists {
+				return sender.SendErrorMessage(ctx, msgCtx, fmt.Errorf("model not found: %s", voiceSwapModel))
+			}
+
+			var userID zkidentity.ShortID
+			userID.FromBytes(msgCtx.Uid)
+
+			if !utils.IsAudioNote(msgCtx.Message) {
+				header := utils.FormatCommandHelpHeader("voiceswap", model, userID, msgCtx.IsPM, db)
+				return sender.SendMessage(ctx, msgCtx, header+model.HelpDoc)
+			}
+
+			voice, denoise, err := parseVoiceSwapArgs(args)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Argument error: "+utils.SanitizeUserText(err.Error()))
+			}
+
+			audioData, err := utils.ExtractAudioNoteData(msgCtx.Message)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Sorry, I couldn't read the attached audio note. Please try again.")
+			}
+			note, err := base64.StdEncoding.DecodeString(audioData)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, "Sorry, I couldn't read the attached audio note. Please try again.")
+			}
+			// The note is billed by its length, so a note without one is
+			// not converted
+			seconds, ok := transcribe.OggOpusSeconds(note)
+			if !ok {
+				return sender.SendMessage(ctx, msgCtx, "Sorry, I couldn't tell how long the attached audio note is. Please record it again.")
+			}
+
+			progress := faladapter.NewProgressCallback(ctx, bot, msgCtx, "voiceswap")
+			req := &speech.VoiceSwapRequest{
+				GenerationRequest: braibottypes.GenerationRequest{
+					ModelType:    "audio2audio",
+					ModelName:    model.Name,
+					Progress:     progress,
+					UserNick:     msgCtx.Nick,
+					UserID:       userID,
+					IsPM:         msgCtx.IsPM,
+					GC:           msgCtx.GC,
+					SplitPercent: shared.SplitPercent,
+					AssetLink:    prefersAssetLink(cfg, "voiceswap"),
+				},
+				AudioNote:             note,
+				Seconds:               seconds,
+				Voice:                 voice,
+				RemoveBackgroundNoise: denoise,
+			}
+			result, err := speechService.VoiceSwap(ctx, req)
+			return utils.HandleServiceResultOrError(ctx, bot, msgCtx, "voiceswap", result, err)
+		}),
+	}
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/internal/money"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/internal/withdraw"
+)
+
+// WithdrawCommand returns the withdraw command, which sends unused balance
+// back to the user as a tip after they confirm it.
+func WithdrawCommand() braibottypes.Command {
+	return braibottypes.Command{
+		Name:        "withdraw",
+		Description: "🏧 Send unused balance back to you as a tip. Usage: !withdraw [amount|all], then !withdraw confirm",
+		Category:    "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			if !msgCtx.IsPM {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("%s, please send !withdraw in a private message.", utils.SanitizeUserText(msgCtx.Nick)))
+			}
+			s := withdraw.Default
+			if s == nil {
+				return sender.SendMessage(ctx, msgCtx, "Withdrawals are not enabled on this bot.")
+			}
+			uid := msgCtx.Sender.String()
+			if len(args) == 0 {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Usage: !withdraw [amount|all]\n\nSends DCR from your balance back to you as a tip. The smallest withdrawal is %.8f DCR.",
+					money.AtomsToDCR(s.MinAtoms())))
+			}
+
+			switch strings.ToLower(args[0]) {
+			case "confirm":
+				req, ahead, err := s.Confirm(uid, time.Now())
+				if errors.Is(err, withdraw.ErrNoWithdrawal) {
+					return sender.SendMessage(ctx, msgCtx, "You have no withdrawal to confirm. Start one with !withdraw [amount|all].")
+				}
+				if err != nil {
+					return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Withdrawal not sent: %v.", err))
+				}
+				msg := fmt.Sprintf("⏳ Sending %.8f DCR to you. I'll let you know when the tip went through.", money.AtomsToDCR(req.Atoms))
+				if ahead > 0 {
+					msg += fmt.Sprintf(" %d withdrawals are ahead of yours.", ahead)
+				}
+				return sender.SendMessage(ctx, msgCtx, msg)
+			case "cancel":
+				if !s.Cancel(uid) {
+					return sender.SendMessage(ctx, msgCtx, "You have no withdrawal to cancel.")
+				}
+				return sender.SendMessage(ctx, msgCtx, "Withdrawal cancelled.")
+			}
+
+			balance, withdrawable, err := s.Withdrawable(uid)
+			if err != nil {
+				return sender.SendErrorMessage(ctx, msgCtx, err)
+			}
+			atoms, err := withdraw.ParseAmount(args[0], withdrawable)
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, utils.SanitizeUserText(err.Error()))
+			}
+			req, err := s.Prepare(uid, atoms, time.Now())
+			if err != nil {
+				return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("Withdrawal not possible: %v.", err))
+			}
+			return sender.SendMessage(ctx, msgCtx, fmt.Sprintf("🏧 You are about to withdraw %.8f DCR of your %.8f DCR balance. "+
+				"Send **!withdraw confirm** within 5 minutes to receive it as a tip, or **!withdraw cancel**.",
+				money.AtomsToDCR(req.Atoms), money.AtomsToDCR(balance)))
+		}),
+	}
+}
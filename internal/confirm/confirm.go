@@ -0,0 +1,73 @@
+// Package confirm holds expensive generation requests until their user
+// confirms them with !confirm, so a typo cannot start a costly job.
+package confirm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TTL is how long a request waits for its confirmation.
+const TTL = 2 * time.Minute
+
+// Request is a generation held for confirmation. Run queues it.
+type Request struct {
+	Command   string
+	CostUSD   float64
+	CreatedAt time.Time
+	Run       func(ctx context.Context)
+}
+
+// Store holds the requests waiting for confirmation, one per user.
+type Store struct {
+	thresholdUSD float64
+
+	mu      sync.Mutex
+	pending map[string]Request // By uid
+}
+
+// Default is the store of !confirm. It is nil while confirmations are
+// disabled.
+var Default *Store
+
+// New returns a store for requests costing more than thresholdUSD.
+func New(thresholdUSD float64) *Store {
+	return &Store{thresholdUSD: thresholdUSD, pending: make(map[string]Request)}
+}
+
+// ThresholdUSD returns the cost above which requests need confirmation.
+func (s *Store) ThresholdUSD() float64 {
+	return s.thresholdUSD
+}
+
+// Needs reports whether a request costing costUSD needs confirmation.
+func (s *Store) Needs(costUSD float64) bool {
+	return costUSD > s.thresholdUSD
+}
+
+// Hold keeps req of uid until Take. It replaces a request uid held before.
+func (s *Store) Hold(uid string, req Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[uid] = req
+}
+
+// Take removes and returns the request uid holds. It returns false when
+// there is none or it expired.
+func (s *Store) Take(uid string, now time.Time) (Request, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	req, ok := s.pending[uid]
+	delete(s.pending, uid)
+	if !ok || now.Sub(req.CreatedAt) > TTL {
+		return Request{}, false
+	}
+	return req, true
+}
+
+// Cancel drops the request uid holds, reporting whether there was one.
+func (s *Store) Cancel(uid string, now time.Time) bool {
+	_, ok := s.Take(uid, now)
+	return ok
+}
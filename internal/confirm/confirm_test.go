@@ -0,0 +1,42 @@
+package confirm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	s := New(2)
+	if s.Needs(2) || !s.Needs(3.5) {
+		t.Fatal("Needs does not compare against the threshold")
+	}
+	now := time.Now()
+	if _, ok := s.Take("alice", now); ok {
+		t.Fatal("Take without Hold succeeded")
+	}
+
+	ran := ""
+	s.Hold("alice", Request{Command: "text2video", CostUSD: 3.5, CreatedAt: now, Run: func(context.Context) { ran = "first" }})
+	s.Hold("alice", Request{Command: "image2video", CostUSD: 4, CreatedAt: now, Run: func(context.Context) { ran = "second" }})
+	req, ok := s.Take("alice", now.Add(time.Minute))
+	if !ok || req.Command != "image2video" {
+		t.Fatalf("Take = %+v, %v; want the newer request", req, ok)
+	}
+	req.Run(context.Background())
+	if ran != "second" {
+		t.Errorf("ran the %s request", ran)
+	}
+	if _, ok := s.Take("alice", now); ok {
+		t.Error("a request was taken twice")
+	}
+
+	s.Hold("bob", Request{Command: "text2video", CreatedAt: now})
+	if _, ok := s.Take("bob", now.Add(TTL+time.Second)); ok {
+		t.Error("an expired request was taken")
+	}
+	s.Hold("bob", Request{Command: "text2video", CreatedAt: now})
+	if !s.Cancel("bob", now) || s.Cancel("bob", now) {
+		t.Error("Cancel did not drop the request exactly once")
+	}
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/internal/money"
+)
+
+// Ledger reasons for charges and admin balance adjustments.
+const (
+	LedgerCharge      = "charge"
+	LedgerAdminCredit = "admin_credit"
+	LedgerAdminDebit  = "admin_debit"
+)
+
+// Spender is a user's total charges over a period.
+type Spender struct {
+	UID   string
+	Atoms int64
+	Jobs  int
+}
+
+// ChargeBalance deducts atoms from a balance and records the charge in the
+// ledger under chargeID, failing without a change when the balance is
+// insufficient. A charge whose id is already in the user's ledger is not
+// applied again, so retrying the charge of a job cannot bill it twice; an
+// empty chargeID is never deduplicated. It returns the new balance and
+// whether the charge was applied now rather than found in the ledger.
+func (dm *DBManager) ChargeBalance(uid string, atoms int64, chargeID string) (int64, bool, error) {
+	return dm.adjustBalance(uid, -atoms, LedgerCharge, chargeID, time.Now())
+}
+
+// AdjustBalance credits (positive atoms) or debits (negative atoms) a
+// balance on behalf of an admin and records it in the ledger. Debits may not
+// take the balance below zero. It returns the new balance.
+func (dm *DBManager) AdjustBalance(uid string, atoms int64, now time.Time) (int64, error) {
+	if atoms == 0 {
+		return 0, fmt.Errorf("invalid adjustment amount: 0")
+	}
+	reason := LedgerAdminCredit
+	if atoms < 0 {
+		reason = LedgerAdminDebit
+	}
+	balance, _, err := dm.adjustBalance(uid, atoms, reason, "", now)
+	return balance, err
+}
+
+// adjustBalance adds atoms to a balance and writes a ledger entry in one
+// transaction. A non-empty chargeID already in the ledger leaves the balance
+// unchanged, and adjustBalance reports that nothing was applied.
+func (dm *DBManager) adjustBalance(uid string, atoms int64, reason, chargeID string, now time.Time) (int64, bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	tx, err := dm.db.Begin()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	balance, err := balanceTx(tx, uid)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get balance: %v", err)
+	}
+	if chargeID != "" {
+		charged, err := chargeRecordedTx(tx, uid, chargeID)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to check charge %s: %v", chargeID, err)
+		}
+		if charged {
+			return balance, false, nil
+		}
+	}
+	if balance+atoms < 0 {
+		return 0, false, fmt.Errorf("insufficient balance. Required: %.8f DCR, Current: %.8f DCR", money.AtomsToDCR(-atoms), money.AtomsToDCR(balance))
+	}
+	if err := addBalanceTx(tx, uid, atoms); err != nil {
+		return 0, false, fmt.Errorf("failed to update balance: %v", err)
+	}
+	if err := insertLedgerTx(tx, uid, atoms, reason, chargeID, now); err != nil {
+		return 0, false, fmt.Errorf("failed to record ledger entry: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("failed to commit balance update: %v", err)
+	}
+	return balance + atoms, true, nil
+}
+
+// TopSpenders returns the users with the highest charges since the given
+// time, highest first. GC pots are not included.
+func (dm *DBManager) TopSpenders(since time.Time, limit int) ([]Spender, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query(`SELECT uid, -SUM(amount) AS spent, COUNT(*) FROM balance_ledger
+		WHERE reason = ? AND created_at >= ? AND uid NOT LIKE 'gc:%'
+		GROUP BY uid ORDER BY spent DESC, uid LIMIT ?`, LedgerCharge, since.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list top spenders: %v", err)
+	}
+	defer rows.Close()
+
+	var spenders []Spender
+	for rows.Next() {
+		var s Spender
+		if err := rows.Scan(&s.UID, &s.Atoms, &s.Jobs); err != nil {
+			return nil, fmt.Errorf("failed to scan spender: %v", err)
+		}
+		spenders = append(spenders, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list top spenders: %v", err)
+	}
+	return spenders, nil
+}
+
+// ListUserUIDs returns the ids of every user with a balance row, skipping
+// GC pots.
+func (dm *DBManager) ListUserUIDs() ([]string, error) {
+	balances, err := dm.ListBalances()
+	if err != nil {
+		return nil, err
+	}
+	var uids []string
+	for _, b := range balances {
+		if !strings.HasPrefix(b.UID, "gc:") {
+			uids = append(uids, b.UID)
+		}
+	}
+	return uids, nil
+}
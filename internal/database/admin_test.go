@@ -0,0 +1,117 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdjustBalanceAndTopSpenders(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	now := time.Now()
+	if balance, err := dm.AdjustBalance("alice", 1000, now); err != nil || balance != 1000 {
+		t.Fatalf("AdjustBalance credit = %d, %v; want 1000, nil", balance, err)
+	}
+	if _, err := dm.AdjustBalance("alice", -2000, now); err == nil {
+		t.Fatal("debit below zero succeeded")
+	}
+	if balance, err := dm.AdjustBalance("alice", -400, now); err != nil || balance != 600 {
+		t.Fatalf("AdjustBalance debit = %d, %v; want 600, nil", balance, err)
+	}
+	if err := dm.UpdateBalance("bob", 1000); err != nil {
+		t.Fatalf("UpdateBalance: %v", err)
+	}
+
+	// Only charges count as spending; the admin debit does not.
+	if _, _, err := dm.ChargeBalance("alice", 100, ""); err != nil {
+		t.Fatalf("ChargeBalance: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, _, err := dm.ChargeBalance("bob", 150, ""); err != nil {
+			t.Fatalf("ChargeBalance: %v", err)
+		}
+	}
+	pot := GCPotUID("lounge")
+	if err := dm.TransferBalance("bob", pot, 500); err != nil {
+		t.Fatalf("TransferBalance: %v", err)
+	}
+	if _, err := dm.DeductSplit("alice", pot, 50, 50, ""); err != nil {
+		t.Fatalf("DeductSplit: %v", err)
+	}
+
+	spenders, err := dm.TopSpenders(now.Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatalf("TopSpenders: %v", err)
+	}
+	want := []Spender{{UID: "bob", Atoms: 300, Jobs: 2}, {UID: "alice", Atoms: 150, Jobs: 2}}
+	if len(spenders) != len(want) {
+		t.Fatalf("TopSpenders = %+v; want %+v", spenders, want)
+	}
+	for i := range want {
+		if spenders[i] != want[i] {
+			t.Errorf("spender %d = %+v; want %+v", i, spenders[i], want[i])
+		}
+	}
+
+	uids, err := dm.ListUserUIDs()
+	if err != nil || len(uids) != 2 {
+		t.Errorf("ListUserUIDs = %v, %v; want alice and bob", uids, err)
+	}
+}
+
+func TestChargeIDs(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	if err := dm.UpdateBalance("alice", 1000); err != nil {
+		t.Fatalf("UpdateBalance: %v", err)
+	}
+	pot := GCPotUID("lounge")
+	if err := dm.TransferBalance("alice", pot, 400); err != nil {
+		t.Fatalf("TransferBalance: %v", err)
+	}
+
+	// A retried charge returns the balance without deducting again
+	for i := 0; i < 2; i++ {
+		if balance, applied, err := dm.ChargeBalance("alice", 100, "job-1"); err != nil || balance != 500 || applied != (i == 0) {
+			t.Fatalf("ChargeBalance attempt %d = %d, %v, %v; want 500, %v, nil", i+1, balance, applied, err, i == 0)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if applied, err := dm.DeductSplit("alice", pot, 50, 50, "job-2"); err != nil || applied != (i == 0) {
+			t.Fatalf("DeductSplit attempt %d = %v, %v; want %v, nil", i+1, applied, err, i == 0)
+		}
+	}
+	if balance, _ := dm.GetBalance("alice"); balance != 450 {
+		t.Errorf("balance = %d, want 450", balance)
+	}
+	if balance, _ := dm.GetBalance(pot); balance != 350 {
+		t.Errorf("pot balance = %d, want 350", balance)
+	}
+
+	ledger, err := dm.GetLedger("alice")
+	if err != nil {
+		t.Fatalf("GetLedger: %v", err)
+	}
+	want := []LedgerEntry{
+		{Amount: 1000, Reason: LedgerCredit},
+		{Amount: -400, Reason: LedgerTransfer},
+		{Amount: -100, Reason: LedgerCharge, ChargeID: "job-1"},
+		{Amount: -50, Reason: LedgerCharge, ChargeID: "job-2"},
+	}
+	if len(ledger) != len(want) {
+		t.Fatalf("ledger = %+v", ledger)
+	}
+	for i, e := range ledger {
+		if e.Amount != want[i].Amount || e.Reason != want[i].Reason || e.ChargeID != want[i].ChargeID {
+			t.Errorf("ledger[%d] = %+v; want %+v", i, e, want[i])
+		}
+	}
+}
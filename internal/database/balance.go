@@ -4,55 +4,42 @@ import (
 	"fmt"
 
 	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/debuglog"
+	"github.com/karamble/braibot/internal/money"
 )
 
-// CheckAndDeductBalance checks if a user has sufficient balance and deducts the cost if they do.
-// costAtoms is the cost in atoms (1 DCR = 1e11 atoms). The caller is responsible for
-// converting from USD/DCR to atoms before calling this function.
-// Returns true if the operation was successful, false otherwise.
-func (db *DBManager) CheckAndDeductBalance(uid []byte, costAtoms int64, debug bool) (bool, error) {
+// CheckAndDeductBalance deducts costAtoms from a user's balance if it covers
+// them and returns the new balance. costAtoms is the cost in atoms (see
+// money.AtomsPerDCR); the caller converts from USD/DCR to atoms. The charge
+// is recorded in the ledger under chargeID, the id of the job it bills, and
+// a charge retried with the same id returns the balance without deducting
+// again, reporting that nothing was applied. Debug output is controlled by
+// the db debug subsystem; debug is kept for existing callers.
+func (db *DBManager) CheckAndDeductBalance(uid []byte, costAtoms int64, chargeID string, debug bool) (int64, bool, error) {
 	// Convert UID to string ID for database
 	var userID zkidentity.ShortID
 	userID.FromBytes(uid)
 	userIDStr := userID.String()
 
-	// Get current balance
-	balance, err := db.GetBalance(userIDStr)
-	if err != nil {
-		return false, fmt.Errorf("failed to get balance: %v", err)
-	}
-
 	// Debug information
-	if debug {
-		fmt.Printf("DEBUG - Balance check:\n")
-		fmt.Printf("  User ID: %s\n", userIDStr)
-		fmt.Printf("  Current balance (atoms): %d\n", balance)
-		fmt.Printf("  Cost in atoms: %d\n", costAtoms)
-		fmt.Printf("  Cost in DCR: %.8f\n", float64(costAtoms)/1e11)
-		fmt.Printf("  Balance in DCR: %.8f\n", float64(balance)/1e11)
-	}
-
-	// Check if user has sufficient balance
-	if balance < costAtoms {
-		balanceDCR := float64(balance) / 1e11
-		costDCR := float64(costAtoms) / 1e11
-		return false, fmt.Errorf("insufficient balance. Required: %.8f DCR, Current: %.8f DCR", costDCR, balanceDCR)
+	if debuglog.Enabled(debuglog.DB) {
+		debuglog.Debugf(debuglog.DB, "Charge %s: user %s, cost %d atoms (%.8f DCR)",
+			chargeID, userIDStr, costAtoms, money.AtomsToDCR(costAtoms))
 	}
 
-	// Deduct the cost from the user's balance (negative amount)
-	err = db.UpdateBalance(userIDStr, -costAtoms)
+	// Check and deduct in one transaction, recording the charge
+	newBalance, applied, err := db.ChargeBalance(userIDStr, costAtoms, chargeID)
 	if err != nil {
-		return false, fmt.Errorf("failed to deduct balance: %v", err)
+		return 0, false, err
 	}
 
 	// Debug information after deduction
-	if debug {
-		fmt.Printf("DEBUG - After deduction:\n")
-		fmt.Printf("  New balance (atoms): %d\n", balance-costAtoms)
-		fmt.Printf("  New balance in DCR: %.8f\n", float64(balance-costAtoms)/1e11)
+	if debuglog.Enabled(debuglog.DB) {
+		debuglog.Debugf(debuglog.DB, "After deduction: user %s, new balance %d atoms (%.8f DCR)",
+			userIDStr, newBalance, money.AtomsToDCR(newBalance))
 	}
 
-	return true, nil
+	return newBalance, applied, nil
 }
 
 // GetUserBalance gets the current balance of a user in DCR
@@ -69,5 +56,5 @@ func (db *DBManager) GetUserBalance(uid []byte) (float64, error) {
 	}
 
 	// Convert atoms to DCR
-	return float64(balanceAtoms) / 1e11, nil
+	return money.AtomsToDCR(balanceAtoms), nil
 }
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Roles of chat messages.
+const (
+	ChatRoleUser      = "user"
+	ChatRoleAssistant = "assistant"
+)
+
+// ChatMessage is a message of a user's !chat conversation.
+type ChatMessage struct {
+	Role      string // ChatRoleUser or ChatRoleAssistant
+	Content   string
+	CreatedAt time.Time
+}
+
+// AddChatMessages appends msgs to the user's conversation and drops the
+// messages beyond the newest keep.
+func (dm *DBManager) AddChatMessages(uid string, msgs []ChatMessage, keep int) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	tx, err := dm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to add chat messages: %v", err)
+	}
+	defer tx.Rollback()
+	for _, m := range msgs {
+		if _, err := tx.Exec("INSERT INTO chat_messages (uid, role, content, created_at) VALUES (?, ?, ?, ?)",
+			uid, m.Role, m.Content, m.CreatedAt.Unix()); err != nil {
+			return fmt.Errorf("failed to add chat message: %v", err)
+		}
+	}
+	_, err = tx.Exec(`DELETE FROM chat_messages WHERE uid = ? AND id NOT IN
+		(SELECT id FROM chat_messages WHERE uid = ? ORDER BY id DESC LIMIT ?)`, uid, uid, keep)
+	if err != nil {
+		return fmt.Errorf("failed to trim chat messages: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to add chat messages: %v", err)
+	}
+	return nil
+}
+
+// ChatHistory returns the user's conversation, oldest message first.
+func (dm *DBManager) ChatHistory(uid string) ([]ChatMessage, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query("SELECT role, content, created_at FROM chat_messages WHERE uid = ? ORDER BY id", uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat messages: %v", err)
+	}
+	defer rows.Close()
+
+	var msgs []ChatMessage
+	for rows.Next() {
+		var m ChatMessage
+		var createdAt int64
+		if err := rows.Scan(&m.Role, &m.Content, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chat message: %v", err)
+		}
+		m.CreatedAt = time.Unix(createdAt, 0)
+		msgs = append(msgs, m)
+	}
+	return msgs, rows.Err()
+}
+
+// ClearChatHistory forgets the user's conversation. It returns the number of
+// messages removed.
+func (dm *DBManager) ClearChatHistory(uid string) (int, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	res, err := dm.db.Exec("DELETE FROM chat_messages WHERE uid = ?", uid)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear chat messages: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear chat messages: %v", err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestChatHistory(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	now := time.Unix(1_700_000_000, 0)
+	for i := 0; i < 3; i++ {
+		err := dm.AddChatMessages("a", []ChatMessage{
+			{Role: ChatRoleUser, Content: fmt.Sprintf("question %d", i), CreatedAt: now},
+			{Role: ChatRoleAssistant, Content: fmt.Sprintf("answer %d", i), CreatedAt: now},
+		}, 4)
+		if err != nil {
+			t.Fatalf("AddChatMessages: %v", err)
+		}
+	}
+	if err := dm.AddChatMessages("b", []ChatMessage{{Role: ChatRoleUser, Content: "hi", CreatedAt: now}}, 4); err != nil {
+		t.Fatalf("AddChatMessages of another user: %v", err)
+	}
+
+	msgs, err := dm.ChatHistory("a")
+	if err != nil || len(msgs) != 4 {
+		t.Fatalf("ChatHistory = %+v, %v; want the newest 4", msgs, err)
+	}
+	if msgs[0].Content != "question 1" || msgs[3].Content != "answer 2" || msgs[3].Role != ChatRoleAssistant || !msgs[0].CreatedAt.Equal(now) {
+		t.Errorf("ChatHistory = %+v", msgs)
+	}
+
+	if n, err := dm.ClearChatHistory("a"); err != nil || n != 4 {
+		t.Fatalf("ClearChatHistory = %d, %v", n, err)
+	}
+	if msgs, _ := dm.ChatHistory("a"); len(msgs) != 0 {
+		t.Errorf("history left after clearing: %+v", msgs)
+	}
+	if msgs, _ := dm.ChatHistory("b"); len(msgs) != 1 {
+		t.Errorf("cleared another user's history: %+v", msgs)
+	}
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RecordContact remembers that uid messaged the bot, keeping the time of
+// their first contact.
+func (dm *DBManager) RecordContact(uid string, now time.Time) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	_, err := dm.db.Exec("INSERT OR IGNORE INTO user_contacts (uid, first_seen) VALUES (?, ?)", uid, now.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record contact: %v", err)
+	}
+	return nil
+}
+
+// NeedsWelcome reports whether uid should be greeted: when they were never
+// welcomed, or when every is positive and they were last welcomed that long
+// ago.
+func (dm *DBManager) NeedsWelcome(uid string, every time.Duration, now time.Time) (bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var welcomedAt int64
+	err := dm.db.QueryRow("SELECT welcomed_at FROM user_contacts WHERE uid = ?", uid).Scan(&welcomedAt)
+	if err == sql.ErrNoRows || (err == nil && welcomedAt == 0) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get welcome time: %v", err)
+	}
+	return every > 0 && now.Sub(time.Unix(welcomedAt, 0)) >= every, nil
+}
+
+// MarkWelcomed records that uid was welcomed, or used a command and needs
+// no welcome, at now.
+func (dm *DBManager) MarkWelcomed(uid string, now time.Time) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	_, err := dm.db.Exec(`INSERT INTO user_contacts (uid, first_seen, welcomed_at) VALUES (?, ?, ?)
+		ON CONFLICT(uid) DO UPDATE SET welcomed_at = excluded.welcomed_at`,
+		uid, now.Unix(), now.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record welcome: %v", err)
+	}
+	return nil
+}
+
+// CountContacts returns the number of unique users who first messaged the
+// bot at or after since. The zero time counts all of them.
+func (dm *DBManager) CountContacts(since time.Time) (int, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var from int64
+	if !since.IsZero() {
+		from = since.Unix()
+	}
+	var n int
+	if err := dm.db.QueryRow("SELECT COUNT(*) FROM user_contacts WHERE first_seen >= ?", from).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count contacts: %v", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestContacts(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	start := time.Unix(1_700_000_000, 0)
+	if err := dm.RecordContact("a", start); err != nil {
+		t.Fatalf("RecordContact: %v", err)
+	}
+	if need, err := dm.NeedsWelcome("a", 0, start); err != nil || !need {
+		t.Fatalf("NeedsWelcome before the welcome = %v, %v", need, err)
+	}
+	if err := dm.MarkWelcomed("a", start); err != nil {
+		t.Fatalf("MarkWelcomed: %v", err)
+	}
+	if need, _ := dm.NeedsWelcome("a", 0, start.Add(1000*time.Hour)); need {
+		t.Error("welcomed again without a re-welcome interval")
+	}
+	if need, _ := dm.NeedsWelcome("a", 24*time.Hour, start.Add(23*time.Hour)); need {
+		t.Error("welcomed again within the re-welcome interval")
+	}
+	if need, _ := dm.NeedsWelcome("a", 24*time.Hour, start.Add(24*time.Hour)); !need {
+		t.Error("not welcomed again after the re-welcome interval")
+	}
+
+	// Later contacts keep the first contact time
+	dm.RecordContact("a", start.Add(48*time.Hour))
+	dm.MarkWelcomed("b", start.Add(48*time.Hour))
+	if n, err := dm.CountContacts(time.Time{}); err != nil || n != 2 {
+		t.Fatalf("CountContacts = %d, %v; want 2", n, err)
+	}
+	if n, _ := dm.CountContacts(start.Add(time.Hour)); n != 1 {
+		t.Fatalf("CountContacts since the first contact = %d, want 1", n)
+	}
+}
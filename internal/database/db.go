@@ -9,7 +9,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -17,13 +19,16 @@ import (
 // UserBalance represents a user's balance in the database
 type UserBalance struct {
 	UID     string
-	Balance int64 // Balance in atoms (1 DCR = 1e11 atoms)
+	Balance int64 // Balance in atoms (money.AtomsPerDCR per DCR)
 }
 
 // DBManager handles database operations
 type DBManager struct {
-	db *sql.DB
-	mu sync.Mutex
+	db        *sql.DB
+	mu        sync.Mutex
+	retention RetentionPolicy
+	expiry    ExpiryPolicy
+	migrated  []string // Migrations applied when the database was opened
 }
 
 // NewDBManager creates a new database manager
@@ -53,8 +58,94 @@ func NewDBManager(appRoot string) (*DBManager, error) {
 		return nil, fmt.Errorf("failed to create table: %v", err)
 	}
 
+	if _, err := db.Exec(createProcessedTipsTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create processed_tips table: %v", err)
+	}
+
+	if _, err := db.Exec(createJobsTables); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create jobs tables: %v", err)
+	}
+	if _, err := db.Exec(createPreviewUsageTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create preview_usage table: %v", err)
+	}
+	if _, err := db.Exec(createBalanceExpiryTables); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create balance expiry tables: %v", err)
+	}
+	if _, err := db.Exec(createLeaderboardTables); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create leaderboard tables: %v", err)
+	}
+	if _, err := db.Exec(createJobQueueTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create job_queue table: %v", err)
+	}
+	if _, err := db.Exec(createRefundRequestsTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create refund_requests table: %v", err)
+	}
+	if _, err := db.Exec(createJobSharesTables); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create job sharing tables: %v", err)
+	}
+	if _, err := db.Exec(createUserSettingsTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create user_settings table: %v", err)
+	}
+	if _, err := db.Exec(createUserModelsTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create user_models table: %v", err)
+	}
+	if _, err := db.Exec(createRecentResultsTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create recent_results table: %v", err)
+	}
+
+	// Job tables created before retention tiers lack expires_at
+	if _, err := db.Exec("ALTER TABLE jobs ADD COLUMN expires_at INTEGER NOT NULL DEFAULT 0"); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate jobs table: %v", err)
+	}
+	// Job tables created before asset mirroring and refunds lack these columns
+	for _, col := range []string{"mirrored_at", "mirror_attempts", "charged_atoms"} {
+		if _, err := db.Exec("ALTER TABLE jobs ADD COLUMN " + col + " INTEGER NOT NULL DEFAULT 0"); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate jobs table: %v", err)
+		}
+	}
+	// Job tables created before !share lack the prompt and seed
+	for _, col := range []string{"prompt TEXT NOT NULL DEFAULT ''", "seed INTEGER"} {
+		if _, err := db.Exec("ALTER TABLE jobs ADD COLUMN " + col); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate jobs table: %v", err)
+		}
+	}
+	// Preference rows created before !mute lack the muted flag
+	if _, err := db.Exec("ALTER TABLE user_prefs ADD COLUMN muted INTEGER NOT NULL DEFAULT 0"); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate user_prefs table: %v", err)
+	}
+	// Preference rows created before leaderboards lack the opt-out flag
+	if _, err := db.Exec("ALTER TABLE user_prefs ADD COLUMN leaderboard_hidden INTEGER NOT NULL DEFAULT 0"); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate user_prefs table: %v", err)
+	}
+
+	// Later schema changes are versioned migrations
+	migrated, err := migrate(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	return &DBManager{
-		db: db,
+		db:        db,
+		retention: DefaultRetentionPolicy,
+		expiry:    DefaultExpiryPolicy,
+		migrated:  migrated,
 	}, nil
 }
 
@@ -105,31 +196,37 @@ func (dm *DBManager) ListBalances() ([]UserBalance, error) {
 	return balances, nil
 }
 
-// UpdateBalance updates a user's balance
+// Ledger reasons of balance changes made through UpdateBalance.
+const (
+	LedgerCredit = "credit"
+	LedgerDebit  = "debit"
+)
+
+// UpdateBalance adds amount, which may be negative, to a user's balance and
+// records it in the ledger.
 func (dm *DBManager) UpdateBalance(uid string, amount int64) error {
 	dm.mu.Lock()
 	defer dm.mu.Unlock()
 
-	// Check if user exists
-	var exists bool
-	err := dm.db.QueryRow("SELECT EXISTS(SELECT 1 FROM user_balances WHERE uid = ?)", uid).Scan(&exists)
+	tx, err := dm.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to check if user exists: %v", err)
+		return fmt.Errorf("failed to begin transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	if exists {
-		// Update existing user's balance
-		_, err = dm.db.Exec("UPDATE user_balances SET balance = balance + ? WHERE uid = ?", amount, uid)
-		if err != nil {
-			return fmt.Errorf("failed to update balance: %v", err)
-		}
-	} else {
-		// Insert new user
-		_, err = dm.db.Exec("INSERT INTO user_balances (uid, balance) VALUES (?, ?)", uid, amount)
-		if err != nil {
-			return fmt.Errorf("failed to insert user: %v", err)
-		}
+	if err := addBalanceTx(tx, uid, amount); err != nil {
+		return fmt.Errorf("failed to update balance: %v", err)
+	}
+	reason := LedgerCredit
+	if amount < 0 {
+		reason = LedgerDebit
+	}
+	if err := insertLedgerTx(tx, uid, amount, reason, "", time.Now()); err != nil {
+		return fmt.Errorf("failed to record ledger entry: %v", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit balance update: %v", err)
+	}
 	return nil
 }
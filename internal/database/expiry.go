@@ -0,0 +1,230 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const createBalanceExpiryTables = `
+	CREATE TABLE IF NOT EXISTS balance_activity (
+		uid TEXT PRIMARY KEY,
+		last_active INTEGER NOT NULL,
+		warnings INTEGER NOT NULL DEFAULT 0,
+		warned_at INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS balance_ledger (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		uid TEXT NOT NULL,
+		amount INTEGER NOT NULL,
+		reason TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	)
+`
+
+// Ledger reasons written by the balance expiry sweep.
+const (
+	LedgerExpiryWarning = "expiry_warning"
+	LedgerExpired       = "expired_inactive"
+)
+
+// ExpiryPolicy expires the balances of users who have been inactive for
+// After. Users are warned FirstWarning and again SecondWarning before their
+// balance expires. A zero After disables expiry.
+type ExpiryPolicy struct {
+	After         time.Duration
+	FirstWarning  time.Duration
+	SecondWarning time.Duration
+}
+
+// DefaultExpiryPolicy never expires balances. When enabled, users are warned
+// 30 days and 7 days ahead.
+var DefaultExpiryPolicy = ExpiryPolicy{
+	FirstWarning:  30 * 24 * time.Hour,
+	SecondWarning: 7 * 24 * time.Hour,
+}
+
+// SetExpiryPolicy replaces the balance expiry policy.
+func (dm *DBManager) SetExpiryPolicy(p ExpiryPolicy) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.expiry = p
+}
+
+// LedgerEntry is a balance change, or notice, recorded for auditing.
+type LedgerEntry struct {
+	ID        int64
+	UID       string
+	Amount    int64 // Atoms; negative for debits, 0 for notices
+	Reason    string
+	ChargeID  string // Id of the job a charge bills, empty for other entries
+	CreatedAt time.Time
+}
+
+// ExpiryNotice is a warning to send, or an expiry that was applied, by
+// SweepInactiveBalances.
+type ExpiryNotice struct {
+	UID       string
+	Balance   int64     // Atoms at stake, or expired
+	Warning   int       // 1 or 2 for a warning, 0 once the balance expired
+	ExpiresAt time.Time // Earliest time the balance expires
+}
+
+// TouchActivity records that the user was active at now, which restarts
+// their inactivity period and clears any expiry warnings.
+func (dm *DBManager) TouchActivity(uid string, now time.Time) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	_, err := dm.db.Exec(`INSERT INTO balance_activity (uid, last_active) VALUES (?, ?)
+		ON CONFLICT(uid) DO UPDATE SET last_active = excluded.last_active, warnings = 0, warned_at = 0`,
+		uid, now.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record activity: %v", err)
+	}
+	return nil
+}
+
+// SweepInactiveBalances advances every inactive funded user through the
+// expiry policy: a first warning, a second warning, then expiry of the whole
+// balance. Each step is written to the balance ledger. Users are moved at
+// most one step per sweep, and a balance never expires earlier than
+// SecondWarning after the second warning. Balances without recorded activity
+// start their inactivity period now; GC pots are never expired.
+func (dm *DBManager) SweepInactiveBalances(now time.Time) ([]ExpiryNotice, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	p := dm.expiry
+	if p.After <= 0 {
+		return nil, nil
+	}
+
+	tx, err := dm.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT OR IGNORE INTO balance_activity (uid, last_active)
+		SELECT uid, ? FROM user_balances WHERE uid NOT LIKE 'gc:%'`, now.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to seed activity: %v", err)
+	}
+
+	rows, err := tx.Query(`SELECT b.uid, b.balance, a.last_active, a.warnings, a.warned_at
+		FROM user_balances b JOIN balance_activity a ON a.uid = b.uid
+		WHERE b.balance > 0 AND b.uid NOT LIKE 'gc:%' AND a.last_active <= ?`,
+		now.Add(-(p.After - max(p.FirstWarning, p.SecondWarning))).Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inactive balances: %v", err)
+	}
+	type candidate struct {
+		uid                  string
+		balance              int64
+		lastActive, warnedAt int64
+		warnings             int
+	}
+	var candidates []candidate
+	for rows.Next() {
+		var c candidate
+		if err := rows.Scan(&c.uid, &c.balance, &c.lastActive, &c.warnings, &c.warnedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan inactive balance: %v", err)
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list inactive balances: %v", err)
+	}
+
+	var notices []ExpiryNotice
+	for _, c := range candidates {
+		expiresAt := time.Unix(c.lastActive, 0).Add(p.After)
+		var n ExpiryNotice
+		switch {
+		case c.warnings == 0 && !now.Before(expiresAt.Add(-p.FirstWarning)):
+			n = ExpiryNotice{Warning: 1, ExpiresAt: expiresAt}
+		case c.warnings == 1 && !now.Before(expiresAt.Add(-p.SecondWarning)):
+			n = ExpiryNotice{Warning: 2, ExpiresAt: expiresAt}
+			if floor := now.Add(p.SecondWarning); floor.After(expiresAt) {
+				n.ExpiresAt = floor
+			}
+		case c.warnings >= 2 && !now.Before(expiresAt) &&
+			!now.Before(time.Unix(c.warnedAt, 0).Add(p.SecondWarning)):
+			n = ExpiryNotice{ExpiresAt: now}
+		default:
+			continue
+		}
+		n.UID = c.uid
+		n.Balance = c.balance
+
+		if n.Warning > 0 {
+			_, err = tx.Exec("UPDATE balance_activity SET warnings = ?, warned_at = ? WHERE uid = ?", n.Warning, now.Unix(), c.uid)
+			if err == nil {
+				err = insertLedgerTx(tx, c.uid, 0, fmt.Sprintf("%s_%d", LedgerExpiryWarning, n.Warning), "", now)
+			}
+		} else {
+			err = addBalanceTx(tx, c.uid, -c.balance)
+			if err == nil {
+				_, err = tx.Exec("UPDATE balance_activity SET warnings = 0, warned_at = 0 WHERE uid = ?", c.uid)
+			}
+			if err == nil {
+				err = insertLedgerTx(tx, c.uid, -c.balance, LedgerExpired, "", now)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply expiry step for %s: %v", c.uid, err)
+		}
+		notices = append(notices, n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit expiry sweep: %v", err)
+	}
+	return notices, nil
+}
+
+// insertLedgerTx appends a ledger entry inside a transaction. chargeID is
+// empty for entries that are not charges.
+func insertLedgerTx(tx *sql.Tx, uid string, amount int64, reason, chargeID string, now time.Time) error {
+	_, err := tx.Exec("INSERT INTO balance_ledger (uid, amount, reason, charge_id, created_at) VALUES (?, ?, ?, ?, ?)",
+		uid, amount, reason, sql.NullString{String: chargeID, Valid: chargeID != ""}, now.Unix())
+	return err
+}
+
+// chargeRecordedTx reports whether the charge chargeID is already in the
+// user's ledger.
+func chargeRecordedTx(tx *sql.Tx, uid, chargeID string) (bool, error) {
+	var exists bool
+	err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM balance_ledger WHERE uid = ? AND charge_id = ?)", uid, chargeID).Scan(&exists)
+	return exists, err
+}
+
+// GetLedger returns the user's ledger entries, oldest first.
+func (dm *DBManager) GetLedger(uid string) ([]LedgerEntry, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query("SELECT id, uid, amount, reason, COALESCE(charge_id, ''), created_at FROM balance_ledger WHERE uid = ? ORDER BY id", uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []LedgerEntry
+	for rows.Next() {
+		var e LedgerEntry
+		var createdAt int64
+		if err := rows.Scan(&e.ID, &e.UID, &e.Amount, &e.Reason, &e.ChargeID, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger entry: %v", err)
+		}
+		e.CreatedAt = time.Unix(createdAt, 0)
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get ledger: %v", err)
+	}
+	return entries, nil
+}
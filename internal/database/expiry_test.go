@@ -0,0 +1,127 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSweepInactiveBalances(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	day := 24 * time.Hour
+	start := time.Unix(1_700_000_000, 0)
+	for _, uid := range []string{"idle", "active", GCPotUID("room")} {
+		if err := dm.UpdateBalance(uid, 5000); err != nil {
+			t.Fatalf("UpdateBalance: %v", err)
+		}
+	}
+
+	// Disabled by default: nothing happens however long users are idle.
+	if n, err := dm.SweepInactiveBalances(start.Add(1000 * day)); err != nil || len(n) != 0 {
+		t.Fatalf("disabled sweep = %v, %v", n, err)
+	}
+
+	dm.SetExpiryPolicy(ExpiryPolicy{After: 100 * day, FirstWarning: 10 * day, SecondWarning: 2 * day})
+	if n, err := dm.SweepInactiveBalances(start); err != nil || len(n) != 0 {
+		t.Fatalf("first sweep = %v, %v; want no notices", n, err)
+	}
+
+	sweep := func(at time.Time) []ExpiryNotice {
+		t.Helper()
+		if err := dm.TouchActivity("active", at); err != nil {
+			t.Fatalf("TouchActivity: %v", err)
+		}
+		n, err := dm.SweepInactiveBalances(at)
+		if err != nil {
+			t.Fatalf("SweepInactiveBalances: %v", err)
+		}
+		return n
+	}
+
+	if n := sweep(start.Add(89 * day)); len(n) != 0 {
+		t.Fatalf("notices before the first warning: %v", n)
+	}
+	n := sweep(start.Add(90 * day))
+	if len(n) != 1 || n[0].UID != "idle" || n[0].Warning != 1 || !n[0].ExpiresAt.Equal(start.Add(100*day)) {
+		t.Fatalf("first warning = %+v", n)
+	}
+	if n := sweep(start.Add(91 * day)); len(n) != 0 {
+		t.Fatalf("repeated warning: %v", n)
+	}
+	if n := sweep(start.Add(98 * day)); len(n) != 1 || n[0].Warning != 2 {
+		t.Fatalf("second warning = %+v", n)
+	}
+	if n := sweep(start.Add(99 * day)); len(n) != 0 {
+		t.Fatalf("expired early: %v", n)
+	}
+	n = sweep(start.Add(100 * day))
+	if len(n) != 1 || n[0].Warning != 0 || n[0].Balance != 5000 {
+		t.Fatalf("expiry = %+v", n)
+	}
+
+	for uid, want := range map[string]int64{"idle": 0, "active": 5000, GCPotUID("room"): 5000} {
+		if b, _ := dm.GetBalance(uid); b != want {
+			t.Errorf("balance of %s = %d, want %d", uid, b, want)
+		}
+	}
+	ledger, err := dm.GetLedger("idle")
+	if err != nil {
+		t.Fatalf("GetLedger: %v", err)
+	}
+	reasons := []string{LedgerCredit, LedgerExpiryWarning + "_1", LedgerExpiryWarning + "_2", LedgerExpired}
+	if len(ledger) != len(reasons) {
+		t.Fatalf("ledger = %+v", ledger)
+	}
+	for i, e := range ledger {
+		if e.Reason != reasons[i] {
+			t.Errorf("ledger[%d].Reason = %s, want %s", i, e.Reason, reasons[i])
+		}
+	}
+	if ledger[3].Amount != -5000 {
+		t.Errorf("expiry ledger amount = %d, want -5000", ledger[2].Amount)
+	}
+}
+
+func TestExpiryWarningsResetOnActivity(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	day := 24 * time.Hour
+	start := time.Unix(1_700_000_000, 0)
+	dm.SetExpiryPolicy(ExpiryPolicy{After: 100 * day, FirstWarning: 10 * day, SecondWarning: 2 * day})
+	if err := dm.UpdateBalance("u", 100); err != nil {
+		t.Fatalf("UpdateBalance: %v", err)
+	}
+	if err := dm.TouchActivity("u", start); err != nil {
+		t.Fatalf("TouchActivity: %v", err)
+	}
+
+	// A user who only shows up after the nominal expiry still gets both
+	// warnings and the full second warning period.
+	late := start.Add(200 * day)
+	if n, _ := dm.SweepInactiveBalances(late); len(n) != 1 || n[0].Warning != 1 {
+		t.Fatalf("first warning = %+v", n)
+	}
+	n, _ := dm.SweepInactiveBalances(late.Add(time.Hour))
+	if len(n) != 1 || n[0].Warning != 2 || !n[0].ExpiresAt.Equal(late.Add(time.Hour+2*day)) {
+		t.Fatalf("second warning = %+v", n)
+	}
+
+	// Activity restarts the clock and clears the warnings.
+	if err := dm.TouchActivity("u", late.Add(day)); err != nil {
+		t.Fatalf("TouchActivity: %v", err)
+	}
+	if n, _ := dm.SweepInactiveBalances(late.Add(3 * day)); len(n) != 0 {
+		t.Fatalf("notices after activity: %v", n)
+	}
+	if b, _ := dm.GetBalance("u"); b != 100 {
+		t.Errorf("balance = %d, want 100", b)
+	}
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GCSettings are the settings admins made for a GC. The zero value, which
+// GCs without settings have, lets the bot answer every command without a
+// budget, post results in the GC and leave members' audio notes alone.
+type GCSettings struct {
+	GC             string
+	Disabled       bool     // The bot ignores commands sent in the GC
+	Commands       []string // Commands allowed in the GC; empty allows all
+	DailyBudgetUSD float64  // What generations may cost per UTC day; 0 is unlimited
+	DeliverPM      bool     // Results are sent to the requester in a PM
+	Transcribe     bool     // !transcribe may transcribe audio notes posted in the GC
+}
+
+// IsDefault reports whether s has no setting of its own.
+func (s GCSettings) IsDefault() bool {
+	return !s.Disabled && len(s.Commands) == 0 && s.DailyBudgetUSD == 0 && !s.DeliverPM && !s.Transcribe
+}
+
+// AllowsCommand reports whether the command cmd may be run in the GC.
+func (s GCSettings) AllowsCommand(cmd string) bool {
+	if s.Disabled {
+		return false
+	}
+	if len(s.Commands) == 0 {
+		return true
+	}
+	for _, c := range s.Commands {
+		if strings.EqualFold(c, cmd) {
+			return true
+		}
+	}
+	return false
+}
+
+// GCSettings returns the settings of a GC.
+func (dm *DBManager) GCSettings(gc string) (GCSettings, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	s := GCSettings{GC: gcKey(gc)}
+	var commands string
+	err := dm.db.QueryRow("SELECT disabled, commands, daily_budget_usd, deliver_pm, transcribe FROM gc_settings WHERE gc = ?", gcKey(gc)).
+		Scan(&s.Disabled, &commands, &s.DailyBudgetUSD, &s.DeliverPM, &s.Transcribe)
+	if err == sql.ErrNoRows {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to get GC settings: %v", err)
+	}
+	s.Commands = splitCommands(commands)
+	return s, nil
+}
+
+// SetGCSettings stores the settings of s.GC. Default settings remove the
+// GC's row.
+func (dm *DBManager) SetGCSettings(s GCSettings) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var err error
+	if s.IsDefault() {
+		_, err = dm.db.Exec("DELETE FROM gc_settings WHERE gc = ?", gcKey(s.GC))
+	} else {
+		_, err = dm.db.Exec(`INSERT INTO gc_settings (gc, disabled, commands, daily_budget_usd, deliver_pm, transcribe) VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(gc) DO UPDATE SET disabled = excluded.disabled, commands = excluded.commands,
+			daily_budget_usd = excluded.daily_budget_usd, deliver_pm = excluded.deliver_pm, transcribe = excluded.transcribe`,
+			gcKey(s.GC), s.Disabled, strings.ToLower(strings.Join(s.Commands, ",")), s.DailyBudgetUSD, s.DeliverPM, s.Transcribe)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set GC settings: %v", err)
+	}
+	return nil
+}
+
+// ListGCSettings returns the settings of the GCs that have any, by GC name.
+func (dm *DBManager) ListGCSettings() ([]GCSettings, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query("SELECT gc, disabled, commands, daily_budget_usd, deliver_pm, transcribe FROM gc_settings ORDER BY gc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GC settings: %v", err)
+	}
+	defer rows.Close()
+
+	var list []GCSettings
+	for rows.Next() {
+		var s GCSettings
+		var commands string
+		if err := rows.Scan(&s.GC, &s.Disabled, &commands, &s.DailyBudgetUSD, &s.DeliverPM, &s.Transcribe); err != nil {
+			return nil, fmt.Errorf("failed to scan GC settings: %v", err)
+		}
+		s.Commands = splitCommands(commands)
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// AddGCSpend adds what a generation requested in a GC cost to the GC's
+// spending of the UTC day of at.
+func (dm *DBManager) AddGCSpend(gc string, usd float64, at time.Time) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	_, err := dm.db.Exec("INSERT INTO gc_spend (gc, day, usd) VALUES (?, ?, ?) ON CONFLICT(gc, day) DO UPDATE SET usd = usd + excluded.usd",
+		gcKey(gc), spendDay(at), usd)
+	if err != nil {
+		return fmt.Errorf("failed to record GC spending: %v", err)
+	}
+	return nil
+}
+
+// GCSpend returns what generations requested in a GC cost on the UTC day of
+// at.
+func (dm *DBManager) GCSpend(gc string, at time.Time) (float64, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var usd float64
+	err := dm.db.QueryRow("SELECT usd FROM gc_spend WHERE gc = ? AND day = ?", gcKey(gc), spendDay(at)).Scan(&usd)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to get GC spending: %v", err)
+	}
+	return usd, nil
+}
+
+// spendDay returns the UTC day GC spending at t is counted for.
+func spendDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// splitCommands parses a stored comma-separated command list.
+func splitCommands(s string) []string {
+	var out []string
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
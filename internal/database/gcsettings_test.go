@@ -0,0 +1,80 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGCSettings(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	s, err := dm.GCSettings("Art")
+	if err != nil || !s.IsDefault() || !s.AllowsCommand("text2video") {
+		t.Fatalf("GCSettings = %+v, %v; want the default", s, err)
+	}
+	s.Commands = []string{"text2image", "Help"}
+	s.DailyBudgetUSD = 2.5
+	s.DeliverPM = true
+	s.Transcribe = true
+	if err := dm.SetGCSettings(s); err != nil {
+		t.Fatalf("SetGCSettings: %v", err)
+	}
+	got, err := dm.GCSettings("ART")
+	if err != nil {
+		t.Fatalf("GCSettings: %v", err)
+	}
+	if got.GC != "art" || got.DailyBudgetUSD != 2.5 || !got.DeliverPM || !got.Transcribe || len(got.Commands) != 2 {
+		t.Fatalf("GCSettings = %+v", got)
+	}
+	if !got.AllowsCommand("TEXT2IMAGE") || !got.AllowsCommand("help") || got.AllowsCommand("text2video") {
+		t.Fatalf("AllowsCommand does not follow %v", got.Commands)
+	}
+	got.Disabled = true
+	if err := dm.SetGCSettings(got); err != nil {
+		t.Fatalf("SetGCSettings: %v", err)
+	}
+	if got, _ = dm.GCSettings("art"); got.AllowsCommand("text2image") {
+		t.Fatal("disabled GC allows commands")
+	}
+	if list, err := dm.ListGCSettings(); err != nil || len(list) != 1 || list[0].GC != "art" {
+		t.Fatalf("ListGCSettings = %+v, %v", list, err)
+	}
+
+	if err := dm.SetGCSettings(GCSettings{GC: "Art"}); err != nil {
+		t.Fatalf("resetting settings: %v", err)
+	}
+	if list, _ := dm.ListGCSettings(); len(list) != 0 {
+		t.Fatalf("ListGCSettings after reset = %+v", list)
+	}
+}
+
+func TestGCSpend(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	day := time.Date(2025, 3, 1, 23, 0, 0, 0, time.UTC)
+	for _, usd := range []float64{0.25, 0.5} {
+		if err := dm.AddGCSpend("Art", usd, day); err != nil {
+			t.Fatalf("AddGCSpend: %v", err)
+		}
+	}
+	if err := dm.AddGCSpend("art", 1, day.Add(2*time.Hour)); err != nil {
+		t.Fatalf("AddGCSpend next day: %v", err)
+	}
+	if usd, err := dm.GCSpend("ART", day); err != nil || usd != 0.75 {
+		t.Fatalf("GCSpend = %v, %v; want 0.75", usd, err)
+	}
+	if usd, _ := dm.GCSpend("art", day.Add(2*time.Hour)); usd != 1 {
+		t.Fatalf("GCSpend next day = %v, want 1", usd)
+	}
+	if usd, _ := dm.GCSpend("other", day); usd != 0 {
+		t.Fatalf("GCSpend of other GC = %v", usd)
+	}
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// GenerationHistoryKept is how many generations are kept per user for
+// !variations and --seed last.
+const GenerationHistoryKept = 20
+
+// HistoryEntry is a generation a user ran, with what it takes to run it
+// again.
+type HistoryEntry struct {
+	ID        int64
+	UID       string
+	Command   string
+	Model     string
+	Args      []string // Arguments of the command, without --seed
+	Seed      *int64   // Nil when the seed is unknown
+	JobID     int64    // Id of the re-deliverable job, 0 for none
+	CreatedAt time.Time
+}
+
+// RecordHistory stores a generation and drops the user's generations beyond
+// the newest GenerationHistoryKept. It returns the entry's id.
+func (dm *DBManager) RecordHistory(e HistoryEntry) (int64, error) {
+	args, err := json.Marshal(e.Args)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode generation args: %v", err)
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	res, err := dm.db.Exec("INSERT INTO generation_history (uid, command, model, args, seed, job_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		e.UID, e.Command, e.Model, string(args), e.Seed, e.JobID, e.CreatedAt.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to record generation: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to record generation: %v", err)
+	}
+	_, err = dm.db.Exec(`DELETE FROM generation_history WHERE uid = ? AND id NOT IN
+		(SELECT id FROM generation_history WHERE uid = ? ORDER BY id DESC LIMIT ?)`, e.UID, e.UID, GenerationHistoryKept)
+	if err != nil {
+		return 0, fmt.Errorf("failed to trim generation history: %v", err)
+	}
+	return id, nil
+}
+
+// LastHistory returns the user's newest generation. With seeded set, only
+// generations whose seed is known are considered. It returns false when
+// there is none.
+func (dm *DBManager) LastHistory(uid string, seeded bool) (HistoryEntry, bool, error) {
+	return dm.queryHistory(`SELECT id, uid, command, model, args, seed, job_id, created_at FROM generation_history
+		WHERE uid = ? AND (? = 0 OR seed IS NOT NULL) ORDER BY id DESC LIMIT 1`, uid, seeded)
+}
+
+// HistoryByJob returns the user's generation that produced job jobID. It
+// returns false when there is none.
+func (dm *DBManager) HistoryByJob(uid string, jobID int64) (HistoryEntry, bool, error) {
+	return dm.queryHistory(`SELECT id, uid, command, model, args, seed, job_id, created_at FROM generation_history
+		WHERE uid = ? AND job_id = ? ORDER BY id DESC LIMIT 1`, uid, jobID)
+}
+
+func (dm *DBManager) queryHistory(query string, args ...interface{}) (HistoryEntry, bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var e HistoryEntry
+	var rawArgs string
+	var seed sql.NullInt64
+	var createdAt int64
+	err := dm.db.QueryRow(query, args...).Scan(&e.ID, &e.UID, &e.Command, &e.Model, &rawArgs, &seed, &e.JobID, &createdAt)
+	if err == sql.ErrNoRows {
+		return HistoryEntry{}, false, nil
+	}
+	if err != nil {
+		return HistoryEntry{}, false, fmt.Errorf("failed to get generation: %v", err)
+	}
+	if err := json.Unmarshal([]byte(rawArgs), &e.Args); err != nil {
+		return HistoryEntry{}, false, fmt.Errorf("failed to decode generation args: %v", err)
+	}
+	if seed.Valid {
+		e.Seed = &seed.Int64
+	}
+	e.CreatedAt = time.Unix(createdAt, 0)
+	return e, true, nil
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGenerationHistory(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	if _, ok, err := dm.LastHistory("a", false); err != nil || ok {
+		t.Fatalf("LastHistory without generations = %v, %v", ok, err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	seed := int64(42)
+	if _, err := dm.RecordHistory(HistoryEntry{UID: "a", Command: "text2image", Model: "flux/dev", Args: []string{"a", "cat"}, Seed: &seed, CreatedAt: now}); err != nil {
+		t.Fatalf("RecordHistory: %v", err)
+	}
+	if _, err := dm.RecordHistory(HistoryEntry{UID: "a", Command: "text2video", Model: "veo2", Args: []string{"a", "dog"}, JobID: 7, CreatedAt: now}); err != nil {
+		t.Fatalf("RecordHistory: %v", err)
+	}
+
+	e, ok, err := dm.LastHistory("a", false)
+	if err != nil || !ok || e.Command != "text2video" || e.Seed != nil || e.JobID != 7 || !e.CreatedAt.Equal(now) {
+		t.Fatalf("LastHistory = %+v, %v, %v", e, ok, err)
+	}
+	e, ok, err = dm.LastHistory("a", true)
+	if err != nil || !ok || e.Model != "flux/dev" || e.Seed == nil || *e.Seed != 42 || !reflect.DeepEqual(e.Args, []string{"a", "cat"}) {
+		t.Fatalf("LastHistory of seeded generations = %+v, %v, %v", e, ok, err)
+	}
+	if e, ok, _ := dm.HistoryByJob("a", 7); !ok || e.Command != "text2video" {
+		t.Errorf("HistoryByJob(7) = %+v, %v", e, ok)
+	}
+	if _, ok, _ := dm.HistoryByJob("b", 7); ok {
+		t.Error("HistoryByJob found another user's job")
+	}
+
+	for i := 0; i < GenerationHistoryKept+5; i++ {
+		if _, err := dm.RecordHistory(HistoryEntry{UID: "a", Command: "text2image", Model: "flux/dev", CreatedAt: now}); err != nil {
+			t.Fatalf("RecordHistory: %v", err)
+		}
+	}
+	if _, ok, _ := dm.LastHistory("a", true); ok {
+		t.Error("the oldest generations were kept beyond GenerationHistoryKept")
+	}
+}
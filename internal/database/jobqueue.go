@@ -0,0 +1,138 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const createJobQueueTable = `
+	CREATE TABLE IF NOT EXISTS job_queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		uid TEXT NOT NULL,
+		nick TEXT NOT NULL,
+		command TEXT NOT NULL,
+		args TEXT NOT NULL,
+		message TEXT NOT NULL,
+		is_pm INTEGER NOT NULL,
+		gc TEXT NOT NULL DEFAULT '',
+		state TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		started_at INTEGER NOT NULL DEFAULT 0
+	)
+`
+
+// Queued job states.
+const (
+	JobPending = "pending"
+	JobRunning = "running"
+)
+
+// QueuedJob is a generation command waiting for, or holding, a worker.
+type QueuedJob struct {
+	ID        int64
+	UID       string
+	Nick      string
+	Command   string
+	Args      []string
+	Message   string // The original message, for commands that read attachments
+	IsPM      bool
+	GC        string
+	State     string
+	CreatedAt time.Time
+	StartedAt time.Time // Zero while pending
+	// ResponseURL is the fal request the running job waits for, empty
+	// until it was queued at fal.
+	ResponseURL string
+}
+
+// EnqueueJob persists a pending job and returns its id.
+func (dm *DBManager) EnqueueJob(job QueuedJob) (int64, error) {
+	args, err := json.Marshal(job.Args)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode job args: %v", err)
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	res, err := dm.db.Exec(`INSERT INTO job_queue (uid, nick, command, args, message, is_pm, gc, state, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.UID, job.Nick, job.Command, string(args), job.Message, job.IsPM, job.GC, JobPending, job.CreatedAt.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue job: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue job: %v", err)
+	}
+	return id, nil
+}
+
+// MarkJobRunning records that a queued job was handed to a worker.
+func (dm *DBManager) MarkJobRunning(id int64, startedAt time.Time) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	_, err := dm.db.Exec("UPDATE job_queue SET state = ?, started_at = ? WHERE id = ?", JobRunning, startedAt.Unix(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark job running: %v", err)
+	}
+	return nil
+}
+
+// SetQueuedJobResponseURL records the fal request a running job waits for.
+func (dm *DBManager) SetQueuedJobResponseURL(id int64, responseURL string) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if _, err := dm.db.Exec("UPDATE job_queue SET response_url = ? WHERE id = ?", responseURL, id); err != nil {
+		return fmt.Errorf("failed to set response URL of queued job: %v", err)
+	}
+	return nil
+}
+
+// DeleteQueuedJob removes a finished job from the queue.
+func (dm *DBManager) DeleteQueuedJob(id int64) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if _, err := dm.db.Exec("DELETE FROM job_queue WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete queued job: %v", err)
+	}
+	return nil
+}
+
+// ListQueuedJobs returns every queued job in submission order.
+func (dm *DBManager) ListQueuedJobs() ([]QueuedJob, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query("SELECT id, uid, nick, command, args, message, is_pm, gc, state, created_at, started_at, response_url FROM job_queue ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queued jobs: %v", err)
+	}
+	defer rows.Close()
+
+	var jobs []QueuedJob
+	for rows.Next() {
+		var j QueuedJob
+		var args string
+		var createdAt, startedAt int64
+		if err := rows.Scan(&j.ID, &j.UID, &j.Nick, &j.Command, &args, &j.Message, &j.IsPM, &j.GC, &j.State, &createdAt, &startedAt, &j.ResponseURL); err != nil {
+			return nil, fmt.Errorf("failed to scan queued job: %v", err)
+		}
+		if err := json.Unmarshal([]byte(args), &j.Args); err != nil {
+			return nil, fmt.Errorf("failed to decode args of queued job %d: %v", j.ID, err)
+		}
+		j.CreatedAt = time.Unix(createdAt, 0)
+		if startedAt > 0 {
+			j.StartedAt = time.Unix(startedAt, 0)
+		}
+		jobs = append(jobs, j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list queued jobs: %v", err)
+	}
+	return jobs, nil
+}
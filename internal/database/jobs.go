@@ -0,0 +1,262 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// createJobsTables holds finished generation jobs, so results can be
+// re-delivered, and the per-user ready-notification preference.
+const createJobsTables = `
+	CREATE TABLE IF NOT EXISTS jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		uid TEXT NOT NULL,
+		command TEXT NOT NULL,
+		model TEXT NOT NULL,
+		result_url TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL DEFAULT 0,
+		mirrored_at INTEGER NOT NULL DEFAULT 0,
+		mirror_attempts INTEGER NOT NULL DEFAULT 0,
+		charged_atoms INTEGER NOT NULL DEFAULT 0,
+		prompt TEXT NOT NULL DEFAULT '',
+		seed INTEGER
+	);
+	CREATE TABLE IF NOT EXISTS user_prefs (
+		uid TEXT PRIMARY KEY,
+		notify_ready INTEGER NOT NULL DEFAULT 0,
+		muted INTEGER NOT NULL DEFAULT 0,
+		leaderboard_hidden INTEGER NOT NULL DEFAULT 0
+	)
+`
+
+// Job is a finished generation job.
+type Job struct {
+	ID        int64
+	UID       string
+	Command   string
+	Model     string
+	ResultURL string
+	CreatedAt time.Time // When the job was requested
+	ExpiresAt time.Time // When the result stops being re-deliverable; zero keeps it forever
+	// ChargedAtoms is what the user paid for the job, the basis of refunds
+	ChargedAtoms int64
+	Prompt       string
+	Seed         *int64 // Nil when the job had no explicit seed
+}
+
+// Expired reports whether the job's retention period is over.
+func (j Job) Expired(now time.Time) bool {
+	return !j.ExpiresAt.IsZero() && now.After(j.ExpiresAt)
+}
+
+// RetentionPolicy sets how long finished jobs stay re-deliverable. Users who
+// have ever funded their balance get the Funded period, everyone else Free.
+// A zero period keeps jobs forever.
+type RetentionPolicy struct {
+	Free   time.Duration
+	Funded time.Duration
+}
+
+// DefaultRetentionPolicy keeps free users' jobs for a day and funded users'
+// jobs for 30 days.
+var DefaultRetentionPolicy = RetentionPolicy{
+	Free:   24 * time.Hour,
+	Funded: 30 * 24 * time.Hour,
+}
+
+// SetRetentionPolicy replaces the retention policy applied to new jobs.
+func (dm *DBManager) SetRetentionPolicy(p RetentionPolicy) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.retention = p
+}
+
+// RecordJob stores a finished job, stamping its expiry from the retention
+// policy for the user's tier.
+func (dm *DBManager) RecordJob(uid, command, model, resultURL string, createdAt time.Time) (Job, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var funded bool
+	err := dm.db.QueryRow("SELECT EXISTS(SELECT 1 FROM processed_tips WHERE uid = ?)", uid).Scan(&funded)
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to check user tier: %v", err)
+	}
+	keep := dm.retention.Free
+	if funded {
+		keep = dm.retention.Funded
+	}
+
+	job := Job{UID: uid, Command: command, Model: model, ResultURL: resultURL, CreatedAt: createdAt}
+	var expiresAt int64
+	if keep > 0 {
+		job.ExpiresAt = time.Now().Add(keep)
+		expiresAt = job.ExpiresAt.Unix()
+	}
+
+	res, err := dm.db.Exec("INSERT INTO jobs (uid, command, model, result_url, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		uid, command, model, resultURL, createdAt.Unix(), expiresAt)
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to record job: %v", err)
+	}
+	if job.ID, err = res.LastInsertId(); err != nil {
+		return Job{}, fmt.Errorf("failed to record job: %v", err)
+	}
+	return job, nil
+}
+
+// SetJobCharge records what the user was charged for a job.
+func (dm *DBManager) SetJobCharge(id, atoms int64) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if _, err := dm.db.Exec("UPDATE jobs SET charged_atoms = ? WHERE id = ?", atoms, id); err != nil {
+		return fmt.Errorf("failed to set job charge: %v", err)
+	}
+	return nil
+}
+
+// SetJobPrompt records the prompt and seed a job was generated with, so the
+// owner and users it is shared with can look them up.
+func (dm *DBManager) SetJobPrompt(id int64, prompt string, seed *int64) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if _, err := dm.db.Exec("UPDATE jobs SET prompt = ?, seed = ? WHERE id = ?", prompt, seed, id); err != nil {
+		return fmt.Errorf("failed to set job prompt: %v", err)
+	}
+	return nil
+}
+
+// PurgeExpiredJobs deletes jobs whose retention period ended before now and
+// returns how many were removed.
+func (dm *DBManager) PurgeExpiredJobs(now time.Time) (int64, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	res, err := dm.db.Exec("DELETE FROM jobs WHERE expires_at > 0 AND expires_at < ?", now.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired jobs: %v", err)
+	}
+	// Shares of purged jobs go with them
+	if _, err := dm.db.Exec("DELETE FROM job_shares WHERE job_id NOT IN (SELECT id FROM jobs)"); err != nil {
+		return 0, fmt.Errorf("failed to purge job shares: %v", err)
+	}
+	return res.RowsAffected()
+}
+
+// GetJob retrieves a job by id. It returns false when no such job exists.
+func (dm *DBManager) GetJob(id int64) (Job, bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var job Job
+	var createdAt, expiresAt int64
+	var seed sql.NullInt64
+	err := dm.db.QueryRow("SELECT id, uid, command, model, result_url, created_at, expires_at, charged_atoms, prompt, seed FROM jobs WHERE id = ?", id).
+		Scan(&job.ID, &job.UID, &job.Command, &job.Model, &job.ResultURL, &createdAt, &expiresAt, &job.ChargedAtoms, &job.Prompt, &seed)
+	if err == sql.ErrNoRows {
+		return Job{}, false, nil
+	}
+	if err != nil {
+		return Job{}, false, fmt.Errorf("failed to get job: %v", err)
+	}
+	job.CreatedAt = time.Unix(createdAt, 0)
+	if expiresAt > 0 {
+		job.ExpiresAt = time.Unix(expiresAt, 0)
+	}
+	if seed.Valid {
+		job.Seed = &seed.Int64
+	}
+	return job, true, nil
+}
+
+// maxMirrorAttempts is how often mirroring a job's result is tried before
+// the job keeps its provider URL.
+const maxMirrorAttempts = 3
+
+// ListUnmirroredJobs returns up to limit unexpired jobs whose result still
+// points at the provider, oldest first.
+func (dm *DBManager) ListUnmirroredJobs(now time.Time, limit int) ([]Job, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query(`SELECT id, uid, command, model, result_url, created_at, expires_at FROM jobs
+		WHERE mirrored_at = 0 AND mirror_attempts < ? AND (expires_at = 0 OR expires_at > ?)
+		ORDER BY id LIMIT ?`, maxMirrorAttempts, now.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unmirrored jobs: %v", err)
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var job Job
+		var createdAt, expiresAt int64
+		if err := rows.Scan(&job.ID, &job.UID, &job.Command, &job.Model, &job.ResultURL, &createdAt, &expiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan job: %v", err)
+		}
+		job.CreatedAt = time.Unix(createdAt, 0)
+		if expiresAt > 0 {
+			job.ExpiresAt = time.Unix(expiresAt, 0)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list unmirrored jobs: %v", err)
+	}
+	return jobs, nil
+}
+
+// SetJobMirrored replaces a job's result URL with its durable mirror.
+func (dm *DBManager) SetJobMirrored(id int64, url string, now time.Time) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if _, err := dm.db.Exec("UPDATE jobs SET result_url = ?, mirrored_at = ? WHERE id = ?", url, now.Unix(), id); err != nil {
+		return fmt.Errorf("failed to store mirrored url: %v", err)
+	}
+	return nil
+}
+
+// RecordMirrorFailure counts a failed attempt to mirror a job's result.
+func (dm *DBManager) RecordMirrorFailure(id int64) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if _, err := dm.db.Exec("UPDATE jobs SET mirror_attempts = mirror_attempts + 1 WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to record mirror failure: %v", err)
+	}
+	return nil
+}
+
+// GetNotifyReady reports whether the user wants a PM when a long job is ready.
+func (dm *DBManager) GetNotifyReady(uid string) (bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var notify bool
+	err := dm.db.QueryRow("SELECT notify_ready FROM user_prefs WHERE uid = ?", uid).Scan(&notify)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get notification preference: %v", err)
+	}
+	return notify, nil
+}
+
+// SetNotifyReady sets the user's ready-notification preference.
+func (dm *DBManager) SetNotifyReady(uid string, notify bool) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	_, err := dm.db.Exec(`INSERT INTO user_prefs (uid, notify_ready) VALUES (?, ?)
+		ON CONFLICT(uid) DO UPDATE SET notify_ready = excluded.notify_ready`, uid, notify)
+	if err != nil {
+		return fmt.Errorf("failed to set notification preference: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJobRetentionTiers(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+	dm.SetRetentionPolicy(RetentionPolicy{Free: time.Hour, Funded: 48 * time.Hour})
+
+	if _, _, err := dm.CreditTip(1, "funded", 1000); err != nil {
+		t.Fatalf("CreditTip: %v", err)
+	}
+	free, err := dm.RecordJob("free", "text2video", "m", "https://x/1.mp4", time.Now())
+	if err != nil {
+		t.Fatalf("RecordJob: %v", err)
+	}
+	funded, err := dm.RecordJob("funded", "text2video", "m", "https://x/2.mp4", time.Now())
+	if err != nil {
+		t.Fatalf("RecordJob: %v", err)
+	}
+	if d := time.Until(free.ExpiresAt); d > time.Hour || d < 59*time.Minute {
+		t.Errorf("free job expires in %v, want ~1h", d)
+	}
+	if d := time.Until(funded.ExpiresAt); d > 48*time.Hour || d < 47*time.Hour {
+		t.Errorf("funded job expires in %v, want ~48h", d)
+	}
+
+	// Two hours on, only the free job is reaped.
+	n, err := dm.PurgeExpiredJobs(time.Now().Add(2 * time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("PurgeExpiredJobs = %d, %v; want 1, nil", n, err)
+	}
+	if _, exists, _ := dm.GetJob(free.ID); exists {
+		t.Error("free job still present after purge")
+	}
+	job, exists, err := dm.GetJob(funded.ID)
+	if err != nil || !exists {
+		t.Fatalf("GetJob(funded) = %v, %v", exists, err)
+	}
+	if job.ResultURL != "https://x/2.mp4" || job.Expired(time.Now()) {
+		t.Errorf("unexpected funded job %+v", job)
+	}
+}
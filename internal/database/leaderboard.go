@@ -0,0 +1,181 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const createLeaderboardTables = `
+	CREATE TABLE IF NOT EXISTS generations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		uid TEXT NOT NULL,
+		nick TEXT NOT NULL,
+		gc TEXT NOT NULL DEFAULT '',
+		command TEXT NOT NULL,
+		model TEXT NOT NULL,
+		items INTEGER NOT NULL DEFAULT 1,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS generations_gc_created ON generations (gc, created_at);
+	CREATE TABLE IF NOT EXISTS leaderboard_gcs (
+		gc TEXT PRIMARY KEY
+	)
+`
+
+// Generation is a delivered generation job, as recorded in the generations
+// ledger.
+type Generation struct {
+	UID       string
+	Nick      string
+	GC        string // Empty for jobs requested in PMs
+	Command   string
+	Model     string
+	Items     int // Outputs delivered, e.g. images in a batch
+	CreatedAt time.Time
+}
+
+// RankEntry is one row of a leaderboard ranking.
+type RankEntry struct {
+	Name  string
+	Count int
+}
+
+// Leaderboard summarizes a GC's generations since a point in time.
+type Leaderboard struct {
+	Jobs      int         // Generation jobs delivered
+	Items     int         // Outputs delivered across those jobs
+	TopUsers  []RankEntry // By jobs; users who opted out are left out
+	TopModels []RankEntry // By jobs
+}
+
+// gcKey normalizes a GC name the way pots are keyed.
+func gcKey(gc string) string {
+	return strings.ToLower(gc)
+}
+
+// RecordGeneration appends a delivered job to the generations ledger.
+func (dm *DBManager) RecordGeneration(g Generation) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	_, err := dm.db.Exec("INSERT INTO generations (uid, nick, gc, command, model, items, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		g.UID, g.Nick, gcKey(g.GC), g.Command, g.Model, g.Items, g.CreatedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record generation: %v", err)
+	}
+	return nil
+}
+
+// LeaderboardEnabled reports whether a GC opted in to leaderboards.
+func (dm *DBManager) LeaderboardEnabled(gc string) (bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var enabled bool
+	err := dm.db.QueryRow("SELECT EXISTS(SELECT 1 FROM leaderboard_gcs WHERE gc = ?)", gcKey(gc)).Scan(&enabled)
+	if err != nil {
+		return false, fmt.Errorf("failed to get leaderboard setting: %v", err)
+	}
+	return enabled, nil
+}
+
+// SetLeaderboardEnabled opts a GC in to or out of leaderboards.
+func (dm *DBManager) SetLeaderboardEnabled(gc string, enabled bool) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var err error
+	if enabled {
+		_, err = dm.db.Exec("INSERT OR IGNORE INTO leaderboard_gcs (gc) VALUES (?)", gcKey(gc))
+	} else {
+		_, err = dm.db.Exec("DELETE FROM leaderboard_gcs WHERE gc = ?", gcKey(gc))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set leaderboard setting: %v", err)
+	}
+	return nil
+}
+
+// GetLeaderboardHidden reports whether the user opted out of being listed
+// on leaderboards.
+func (dm *DBManager) GetLeaderboardHidden(uid string) (bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var hidden bool
+	err := dm.db.QueryRow("SELECT leaderboard_hidden FROM user_prefs WHERE uid = ?", uid).Scan(&hidden)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get leaderboard preference: %v", err)
+	}
+	return hidden, nil
+}
+
+// SetLeaderboardHidden sets whether the user is left out of leaderboards.
+func (dm *DBManager) SetLeaderboardHidden(uid string, hidden bool) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	_, err := dm.db.Exec(`INSERT INTO user_prefs (uid, leaderboard_hidden) VALUES (?, ?)
+		ON CONFLICT(uid) DO UPDATE SET leaderboard_hidden = excluded.leaderboard_hidden`, uid, hidden)
+	if err != nil {
+		return fmt.Errorf("failed to set leaderboard preference: %v", err)
+	}
+	return nil
+}
+
+// GetLeaderboard computes a GC's leaderboard from the generations recorded
+// since the given time, listing at most limit users and models. Users are
+// shown under the nick they used most recently.
+func (dm *DBManager) GetLeaderboard(gc string, since time.Time, limit int) (Leaderboard, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var lb Leaderboard
+	key := gcKey(gc)
+	err := dm.db.QueryRow("SELECT COUNT(*), COALESCE(SUM(items), 0) FROM generations WHERE gc = ? AND created_at >= ?",
+		key, since.Unix()).Scan(&lb.Jobs, &lb.Items)
+	if err != nil {
+		return Leaderboard{}, fmt.Errorf("failed to get leaderboard totals: %v", err)
+	}
+
+	lb.TopUsers, err = queryRanking(dm.db, `SELECT
+			(SELECT nick FROM generations l WHERE l.uid = g.uid ORDER BY l.id DESC LIMIT 1),
+			COUNT(*) AS n
+		FROM generations g LEFT JOIN user_prefs p ON p.uid = g.uid
+		WHERE g.gc = ? AND g.created_at >= ? AND COALESCE(p.leaderboard_hidden, 0) = 0
+		GROUP BY g.uid ORDER BY n DESC, MIN(g.id) LIMIT ?`, key, since.Unix(), limit)
+	if err != nil {
+		return Leaderboard{}, fmt.Errorf("failed to get top requesters: %v", err)
+	}
+	lb.TopModels, err = queryRanking(dm.db, `SELECT model, COUNT(*) AS n FROM generations
+		WHERE gc = ? AND created_at >= ?
+		GROUP BY model ORDER BY n DESC, model LIMIT ?`, key, since.Unix(), limit)
+	if err != nil {
+		return Leaderboard{}, fmt.Errorf("failed to get top models: %v", err)
+	}
+	return lb, nil
+}
+
+// queryRanking runs a query returning (name, count) rows.
+func queryRanking(db *sql.DB, query string, args ...interface{}) ([]RankEntry, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranking []RankEntry
+	for rows.Next() {
+		var e RankEntry
+		if err := rows.Scan(&e.Name, &e.Count); err != nil {
+			return nil, err
+		}
+		ranking = append(ranking, e)
+	}
+	return ranking, rows.Err()
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeaderboard(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	if on, err := dm.LeaderboardEnabled("Art"); err != nil || on {
+		t.Fatalf("LeaderboardEnabled = %v, %v; want off by default", on, err)
+	}
+	if err := dm.SetLeaderboardEnabled("Art", true); err != nil {
+		t.Fatalf("SetLeaderboardEnabled: %v", err)
+	}
+	if on, _ := dm.LeaderboardEnabled("art"); !on {
+		t.Fatal("leaderboard not enabled after opting in")
+	}
+
+	now := time.Now()
+	gens := []Generation{
+		{UID: "u1", Nick: "alice", GC: "art", Model: "flux/schnell", Items: 4, CreatedAt: now},
+		{UID: "u1", Nick: "alice2", GC: "Art", Model: "flux/schnell", Items: 1, CreatedAt: now},
+		{UID: "u2", Nick: "bob", GC: "art", Model: "veo2", Items: 1, CreatedAt: now},
+		{UID: "u3", Nick: "carol", GC: "art", Model: "veo2", Items: 1, CreatedAt: now},
+		{UID: "u3", Nick: "carol", GC: "art", Model: "veo2", Items: 1, CreatedAt: now},
+		{UID: "u2", Nick: "bob", GC: "art", Model: "veo2", Items: 1, CreatedAt: now.AddDate(0, 0, -10)},
+		{UID: "u2", Nick: "bob", GC: "other", Model: "veo2", Items: 1, CreatedAt: now},
+		{UID: "u2", Nick: "bob", Model: "veo2", Items: 1, CreatedAt: now},
+	}
+	for _, g := range gens {
+		if err := dm.RecordGeneration(g); err != nil {
+			t.Fatalf("RecordGeneration: %v", err)
+		}
+	}
+	if err := dm.SetLeaderboardHidden("u3", true); err != nil {
+		t.Fatalf("SetLeaderboardHidden: %v", err)
+	}
+
+	lb, err := dm.GetLeaderboard("ART", now.AddDate(0, 0, -7), 5)
+	if err != nil {
+		t.Fatalf("GetLeaderboard: %v", err)
+	}
+	if lb.Jobs != 5 || lb.Items != 8 {
+		t.Errorf("totals = %d jobs, %d items; want 5, 8", lb.Jobs, lb.Items)
+	}
+	wantUsers := []RankEntry{{"alice2", 2}, {"bob", 1}}
+	if len(lb.TopUsers) != len(wantUsers) {
+		t.Fatalf("TopUsers = %+v, want %+v", lb.TopUsers, wantUsers)
+	}
+	for i, e := range wantUsers {
+		if lb.TopUsers[i] != e {
+			t.Errorf("TopUsers[%d] = %+v, want %+v", i, lb.TopUsers[i], e)
+		}
+	}
+	if len(lb.TopModels) != 2 || lb.TopModels[0] != (RankEntry{"veo2", 3}) || lb.TopModels[1] != (RankEntry{"flux/schnell", 2}) {
+		t.Errorf("TopModels = %+v", lb.TopModels)
+	}
+
+	// The month view includes the older job.
+	if lb, _ := dm.GetLeaderboard("art", now.AddDate(0, 0, -30), 5); lb.Jobs != 6 {
+		t.Errorf("month jobs = %d, want 6", lb.Jobs)
+	}
+}
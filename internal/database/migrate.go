@@ -0,0 +1,135 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the schema changes made since versioning was added,
+// named NNNN_description.sql and applied in order. Add new tables and
+// columns as a new file instead of changing NewDBManager.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const createSchemaVersionTable = `
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at INTEGER NOT NULL
+	)
+`
+
+// migration is one embedded schema change.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations reads the embedded migrations, which must be numbered 1, 2,
+// ... without gaps.
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []migration
+	for _, e := range entries {
+		num, _, ok := strings.Cut(e.Name(), "_")
+		version, err := strconv.Atoi(num)
+		if !ok || err != nil || version < 1 {
+			return nil, fmt.Errorf("migration %s is not named NNNN_description.sql", e.Name())
+		}
+		data, err := migrationFiles.ReadFile(path.Join("migrations", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: version, name: strings.TrimSuffix(e.Name(), ".sql"), sql: string(data)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	for i, m := range migrations {
+		if m.version != i+1 {
+			return nil, fmt.Errorf("migration %s out of sequence, want version %d", m.name, i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// LatestSchemaVersion returns the schema version this build migrates to.
+func LatestSchemaVersion() int {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0
+	}
+	return len(migrations)
+}
+
+// migrate applies the migrations the database lacks, each in a transaction
+// together with its schema_version row, and returns the versions applied.
+func migrate(db *sql.DB) ([]string, error) {
+	if _, err := db.Exec(createSchemaVersionTable); err != nil {
+		return nil, fmt.Errorf("failed to create schema_version table: %v", err)
+	}
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("invalid migrations: %v", err)
+	}
+	current, err := schemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if current > len(migrations) {
+		return nil, fmt.Errorf("database schema version %d is newer than this build supports (%d)", current, len(migrations))
+	}
+
+	var applied []string
+	for _, m := range migrations[current:] {
+		tx, err := db.Begin()
+		if err != nil {
+			return applied, fmt.Errorf("failed to begin migration %s: %v", m.name, err)
+		}
+		if _, err := tx.Exec(m.sql); err != nil {
+			tx.Rollback()
+			return applied, fmt.Errorf("failed to apply migration %s: %v", m.name, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)", m.version, m.name, time.Now().Unix()); err != nil {
+			tx.Rollback()
+			return applied, fmt.Errorf("failed to record migration %s: %v", m.name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return applied, fmt.Errorf("failed to commit migration %s: %v", m.name, err)
+		}
+		applied = append(applied, m.name)
+	}
+	return applied, nil
+}
+
+// schemaVersion returns the highest migration applied to db.
+func schemaVersion(db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %v", err)
+	}
+	return version, nil
+}
+
+// SchemaVersion returns the highest migration applied to the database.
+func (dm *DBManager) SchemaVersion() (int, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	return schemaVersion(dm.db)
+}
+
+// Migrations returns the migrations NewDBManager applied when it opened the
+// database, oldest first.
+func (dm *DBManager) Migrations() []string {
+	return dm.migrated
+}
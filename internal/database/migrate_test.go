@@ -0,0 +1,50 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	root := t.TempDir()
+	dm, err := NewDBManager(root)
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	latest := LatestSchemaVersion()
+	if latest < 1 {
+		t.Fatalf("LatestSchemaVersion = %d, want embedded migrations", latest)
+	}
+	if v, err := dm.SchemaVersion(); err != nil || v != latest {
+		t.Fatalf("SchemaVersion = %d, %v; want %d", v, err, latest)
+	}
+	if got := len(dm.Migrations()); got != latest {
+		t.Fatalf("applied %d migrations on a new database, want %d", got, latest)
+	}
+	dm.Close()
+
+	// Reopening applies nothing
+	dm, err = NewDBManager(root)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	if got := dm.Migrations(); len(got) != 0 {
+		t.Fatalf("reopening applied %v", got)
+	}
+	dm.Close()
+
+	// A database migrated by a newer build is refused
+	db, err := sql.Open("sqlite3", filepath.Join(root, "data", "balances.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO schema_version (version, name, applied_at) VALUES (?, 'future', 0)", latest+1); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if dm, err := NewDBManager(root); err == nil {
+		dm.Close()
+		t.Fatal("NewDBManager accepted a newer schema")
+	}
+}
@@ -0,0 +1,6 @@
+-- NSFW policies admins set for single GCs, overriding the configured GC
+-- default.
+CREATE TABLE IF NOT EXISTS gc_nsfw_policies (
+	gc TEXT PRIMARY KEY,
+	policy TEXT NOT NULL
+);
@@ -0,0 +1,3 @@
+-- Response URL of the fal request a running job waits for, so the job can
+-- resume polling it after a restart instead of being dropped.
+ALTER TABLE job_queue ADD COLUMN response_url TEXT NOT NULL DEFAULT '';
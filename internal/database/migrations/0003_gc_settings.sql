@@ -0,0 +1,17 @@
+-- Settings admins made for single GCs: whether the bot answers there, which
+-- commands it may run, its daily budget and where results are delivered.
+CREATE TABLE IF NOT EXISTS gc_settings (
+	gc TEXT PRIMARY KEY,
+	disabled INTEGER NOT NULL DEFAULT 0,
+	commands TEXT NOT NULL DEFAULT '',
+	daily_budget_usd REAL NOT NULL DEFAULT 0,
+	deliver_pm INTEGER NOT NULL DEFAULT 0
+);
+-- What generations requested in a GC cost per UTC day, checked against its
+-- daily budget.
+CREATE TABLE IF NOT EXISTS gc_spend (
+	gc TEXT NOT NULL,
+	day TEXT NOT NULL,
+	usd REAL NOT NULL DEFAULT 0,
+	PRIMARY KEY (gc, day)
+);
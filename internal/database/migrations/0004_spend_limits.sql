@@ -0,0 +1,15 @@
+-- Spending limits admins set for single users, overriding the configured
+-- defaults. A NULL limit uses the default; 0 is unlimited.
+CREATE TABLE IF NOT EXISTS user_spend_limits (
+	uid TEXT PRIMARY KEY,
+	daily_usd REAL,
+	weekly_usd REAL
+);
+-- What users were charged for generations in USD, kept for a week so their
+-- spending limits can be checked over rolling windows.
+CREATE TABLE IF NOT EXISTS user_spend (
+	uid TEXT NOT NULL,
+	usd REAL NOT NULL,
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS user_spend_uid_created ON user_spend (uid, created_at);
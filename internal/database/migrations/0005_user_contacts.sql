@@ -0,0 +1,11 @@
+-- Every user who ever messaged the bot, when they first did and when they
+-- were last welcomed or used a command. Users with recorded activity were
+-- already greeted before this table existed.
+CREATE TABLE IF NOT EXISTS user_contacts (
+	uid TEXT PRIMARY KEY,
+	first_seen INTEGER NOT NULL,
+	welcomed_at INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS user_contacts_first_seen ON user_contacts (first_seen);
+INSERT OR IGNORE INTO user_contacts (uid, first_seen, welcomed_at)
+	SELECT uid, last_active, last_active FROM balance_activity;
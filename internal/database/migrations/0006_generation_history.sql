@@ -0,0 +1,14 @@
+-- The arguments and seed of each user's recent generations, so they can be
+-- re-run with new seeds by !variations or reused with --seed last. job_id
+-- links generations kept as re-deliverable jobs.
+CREATE TABLE IF NOT EXISTS generation_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	uid TEXT NOT NULL,
+	command TEXT NOT NULL,
+	model TEXT NOT NULL,
+	args TEXT NOT NULL,
+	seed INTEGER,
+	job_id INTEGER NOT NULL DEFAULT 0,
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS generation_history_uid ON generation_history (uid, id);
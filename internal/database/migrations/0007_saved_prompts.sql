@@ -0,0 +1,8 @@
+-- Prompts users saved with !prompt save, reused with @name.
+CREATE TABLE IF NOT EXISTS saved_prompts (
+	uid TEXT NOT NULL,
+	name TEXT NOT NULL,
+	text TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	PRIMARY KEY (uid, name)
+);
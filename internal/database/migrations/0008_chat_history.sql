@@ -0,0 +1,9 @@
+-- Conversations of !chat, trimmed to the newest messages of each user.
+CREATE TABLE IF NOT EXISTS chat_messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	uid TEXT NOT NULL,
+	role TEXT NOT NULL,
+	content TEXT NOT NULL,
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS chat_messages_uid ON chat_messages (uid, id);
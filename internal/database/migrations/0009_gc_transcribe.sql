@@ -0,0 +1,3 @@
+-- Whether !transcribe may transcribe the audio notes members post in a GC.
+-- Off unless an admin turns it on, as it shares what members said aloud.
+ALTER TABLE gc_settings ADD COLUMN transcribe INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,14 @@
+-- Generations users scheduled with !schedule add, run by the scheduler at
+-- next_run and then rescheduled by their cron spec.
+CREATE TABLE IF NOT EXISTS schedules (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	uid TEXT NOT NULL,
+	nick TEXT NOT NULL,
+	spec TEXT NOT NULL,
+	command TEXT NOT NULL,
+	args TEXT NOT NULL,
+	next_run INTEGER NOT NULL,
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS schedules_uid ON schedules (uid, id);
+CREATE INDEX IF NOT EXISTS schedules_next_run ON schedules (next_run);
@@ -0,0 +1,8 @@
+-- Responses of recent generation requests by their hash, so an identical
+-- request within the cache TTL reuses the response instead of paying fal.
+CREATE TABLE IF NOT EXISTS request_cache (
+	key TEXT PRIMARY KEY,
+	response TEXT NOT NULL,
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS request_cache_created_at ON request_cache (created_at);
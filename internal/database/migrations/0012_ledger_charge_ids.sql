@@ -0,0 +1,4 @@
+-- Charges record the id of the job they bill, so a charge that is retried
+-- is recognized and not applied twice. Other ledger entries have no id.
+ALTER TABLE balance_ledger ADD COLUMN charge_id TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS balance_ledger_charge_id ON balance_ledger (uid, charge_id);
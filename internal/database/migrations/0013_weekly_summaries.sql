@@ -0,0 +1,6 @@
+-- When each user who opted into weekly summaries last got one, so a
+-- restart neither repeats nor skips a summary.
+CREATE TABLE IF NOT EXISTS weekly_summaries (
+	uid TEXT PRIMARY KEY,
+	sent_at INTEGER NOT NULL
+);
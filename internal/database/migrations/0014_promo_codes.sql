@@ -0,0 +1,41 @@
+-- Promo codes admins hand out: a fixed credit, or a percentage bonus on the
+-- redeeming user's next tip. max_uses and expires_at are 0 when unlimited.
+CREATE TABLE IF NOT EXISTS promo_codes (
+	code TEXT PRIMARY KEY,
+	kind TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	max_uses INTEGER NOT NULL DEFAULT 0,
+	uses INTEGER NOT NULL DEFAULT 0,
+	expires_at INTEGER NOT NULL DEFAULT 0,
+	created_by TEXT NOT NULL,
+	created_at INTEGER NOT NULL
+);
+
+-- Each user can redeem a promo code once.
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+	code TEXT NOT NULL,
+	uid TEXT NOT NULL,
+	redeemed_at INTEGER NOT NULL,
+	PRIMARY KEY (code, uid)
+);
+
+-- Tip bonuses redeemed but not yet paid out, at most one per user.
+CREATE TABLE IF NOT EXISTS pending_tip_bonuses (
+	uid TEXT PRIMARY KEY,
+	code TEXT NOT NULL,
+	percent INTEGER NOT NULL
+);
+
+-- The referral code of each user who asked for one.
+CREATE TABLE IF NOT EXISTS referral_codes (
+	uid TEXT PRIMARY KEY,
+	code TEXT NOT NULL UNIQUE
+);
+
+-- Who referred whom; a user can be referred only once.
+CREATE TABLE IF NOT EXISTS referrals (
+	uid TEXT PRIMARY KEY,
+	referrer TEXT NOT NULL,
+	atoms INTEGER NOT NULL,
+	redeemed_at INTEGER NOT NULL
+);
@@ -0,0 +1,5 @@
+-- Referral credit is paid on the referred user's first tip instead of on
+-- redemption; paid_at is 0 until then. Referrals redeemed before were paid
+-- right away.
+ALTER TABLE referrals ADD COLUMN paid_at INTEGER NOT NULL DEFAULT 0;
+UPDATE referrals SET paid_at = redeemed_at;
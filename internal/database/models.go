@@ -0,0 +1,58 @@
+package database
+
+import (
+	"fmt"
+)
+
+// createUserModelsTable holds the model each user picked per command type
+// with !setmodel, so the choice survives restarts.
+const createUserModelsTable = `
+	CREATE TABLE IF NOT EXISTS user_models (
+		uid TEXT NOT NULL,
+		model_type TEXT NOT NULL,
+		model_name TEXT NOT NULL,
+		PRIMARY KEY (uid, model_type)
+	)
+`
+
+// SetUserModel saves the user's model for a command type.
+func (dm *DBManager) SetUserModel(uid, modelType, modelName string) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	_, err := dm.db.Exec(`INSERT INTO user_models (uid, model_type, model_name) VALUES (?, ?, ?)
+		ON CONFLICT(uid, model_type) DO UPDATE SET model_name = excluded.model_name`, uid, modelType, modelName)
+	if err != nil {
+		return fmt.Errorf("failed to save model selection: %v", err)
+	}
+	return nil
+}
+
+// GetAllUserModels returns every saved model selection, keyed by user ID
+// and then command type.
+func (dm *DBManager) GetAllUserModels() (map[string]map[string]string, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query("SELECT uid, model_type, model_name FROM user_models")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get model selections: %v", err)
+	}
+	defer rows.Close()
+
+	models := make(map[string]map[string]string)
+	for rows.Next() {
+		var uid, modelType, modelName string
+		if err := rows.Scan(&uid, &modelType, &modelName); err != nil {
+			return nil, fmt.Errorf("failed to scan model selection: %v", err)
+		}
+		if models[uid] == nil {
+			models[uid] = make(map[string]string)
+		}
+		models[uid][modelType] = modelName
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get model selections: %v", err)
+	}
+	return models, nil
+}
@@ -0,0 +1,39 @@
+package database
+
+import "testing"
+
+func TestUserModels(t *testing.T) {
+	dir := t.TempDir()
+	dm, err := NewDBManager(dir)
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	if err := dm.SetUserModel("u", "text2image", "flux/dev"); err != nil {
+		t.Fatalf("SetUserModel: %v", err)
+	}
+	if err := dm.SetUserModel("u", "text2image", "flux-2"); err != nil {
+		t.Fatalf("SetUserModel: %v", err)
+	}
+	if err := dm.SetUserModel("u", "text2video", "veo2"); err != nil {
+		t.Fatalf("SetUserModel: %v", err)
+	}
+	if err := dm.SetUserModel("v", "text2image", "flux/dev"); err != nil {
+		t.Fatalf("SetUserModel: %v", err)
+	}
+	dm.Close()
+
+	// Selections survive reopening the database
+	dm, err = NewDBManager(dir)
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+	models, err := dm.GetAllUserModels()
+	if err != nil {
+		t.Fatalf("GetAllUserModels: %v", err)
+	}
+	if len(models) != 2 || len(models["u"]) != 2 || models["u"]["text2image"] != "flux-2" ||
+		models["u"]["text2video"] != "veo2" || models["v"]["text2image"] != "flux/dev" {
+		t.Errorf("GetAllUserModels = %v", models)
+	}
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetMuted reports whether the user muted the bot's non-essential messages.
+func (dm *DBManager) GetMuted(uid string) (bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var muted bool
+	err := dm.db.QueryRow("SELECT muted FROM user_prefs WHERE uid = ?", uid).Scan(&muted)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get mute preference: %v", err)
+	}
+	return muted, nil
+}
+
+// SetMuted sets the user's mute preference.
+func (dm *DBManager) SetMuted(uid string, muted bool) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	_, err := dm.db.Exec(`INSERT INTO user_prefs (uid, muted) VALUES (?, ?)
+		ON CONFLICT(uid) DO UPDATE SET muted = excluded.muted`, uid, muted)
+	if err != nil {
+		return fmt.Errorf("failed to set mute preference: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package database
+
+import "testing"
+
+func TestMutePreference(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	if muted, err := dm.GetMuted("u"); err != nil || muted {
+		t.Fatalf("GetMuted for new user = %v, %v; want false, nil", muted, err)
+	}
+	if err := dm.SetNotifyReady("u", true); err != nil {
+		t.Fatalf("SetNotifyReady: %v", err)
+	}
+	if err := dm.SetMuted("u", true); err != nil {
+		t.Fatalf("SetMuted: %v", err)
+	}
+	if muted, _ := dm.GetMuted("u"); !muted {
+		t.Error("user not muted after SetMuted(true)")
+	}
+	// Muting keeps the other preferences intact.
+	if notify, _ := dm.GetNotifyReady("u"); !notify {
+		t.Error("SetMuted reset the notify preference")
+	}
+	if err := dm.SetMuted("u", false); err != nil {
+		t.Fatalf("SetMuted: %v", err)
+	}
+	if muted, _ := dm.GetMuted("u"); muted {
+		t.Error("user still muted after SetMuted(false)")
+	}
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GCNSFWPolicy returns the NSFW policy set for a GC, or "" when it uses the
+// default.
+func (dm *DBManager) GCNSFWPolicy(gc string) (string, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var policy string
+	err := dm.db.QueryRow("SELECT policy FROM gc_nsfw_policies WHERE gc = ?", gcKey(gc)).Scan(&policy)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get NSFW policy: %v", err)
+	}
+	return policy, nil
+}
+
+// SetGCNSFWPolicy sets the NSFW policy of a GC; "" returns it to the default.
+func (dm *DBManager) SetGCNSFWPolicy(gc, policy string) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var err error
+	if policy == "" {
+		_, err = dm.db.Exec("DELETE FROM gc_nsfw_policies WHERE gc = ?", gcKey(gc))
+	} else {
+		_, err = dm.db.Exec("INSERT INTO gc_nsfw_policies (gc, policy) VALUES (?, ?) ON CONFLICT(gc) DO UPDATE SET policy = excluded.policy", gcKey(gc), policy)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set NSFW policy: %v", err)
+	}
+	return nil
+}
+
+// GCNSFWPolicies returns the NSFW policies set for single GCs, by GC.
+func (dm *DBManager) GCNSFWPolicies() (map[string]string, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query("SELECT gc, policy FROM gc_nsfw_policies")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list NSFW policies: %v", err)
+	}
+	defer rows.Close()
+
+	policies := make(map[string]string)
+	for rows.Next() {
+		var gc, policy string
+		if err := rows.Scan(&gc, &policy); err != nil {
+			return nil, fmt.Errorf("failed to scan NSFW policy: %v", err)
+		}
+		policies[gc] = policy
+	}
+	return policies, rows.Err()
+}
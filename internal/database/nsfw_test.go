@@ -0,0 +1,33 @@
+package database
+
+import "testing"
+
+func TestGCNSFWPolicy(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	if p, err := dm.GCNSFWPolicy("Art"); err != nil || p != "" {
+		t.Fatalf("GCNSFWPolicy = %q, %v; want the default", p, err)
+	}
+	if err := dm.SetGCNSFWPolicy("Art", "block"); err != nil {
+		t.Fatalf("SetGCNSFWPolicy: %v", err)
+	}
+	if err := dm.SetGCNSFWPolicy("art", "pm"); err != nil {
+		t.Fatalf("SetGCNSFWPolicy again: %v", err)
+	}
+	if p, _ := dm.GCNSFWPolicy("ART"); p != "pm" {
+		t.Fatalf("GCNSFWPolicy = %q, want pm", p)
+	}
+	if all, err := dm.GCNSFWPolicies(); err != nil || len(all) != 1 || all["art"] != "pm" {
+		t.Fatalf("GCNSFWPolicies = %v, %v", all, err)
+	}
+	if err := dm.SetGCNSFWPolicy("Art", ""); err != nil {
+		t.Fatalf("resetting policy: %v", err)
+	}
+	if p, _ := dm.GCNSFWPolicy("Art"); p != "" {
+		t.Fatalf("GCNSFWPolicy after reset = %q", p)
+	}
+}
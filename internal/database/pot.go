@@ -0,0 +1,139 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/internal/money"
+)
+
+// GCPotUID returns the balance key of a group chat's shared pot. Pots live in
+// user_balances next to user balances under a "gc:" prefix that can never
+// collide with a hex user id.
+func GCPotUID(gc string) string {
+	return "gc:" + strings.ToLower(gc)
+}
+
+// balanceTx reads a balance inside a transaction, treating a missing row as 0.
+func balanceTx(tx *sql.Tx, uid string) (int64, error) {
+	var balance int64
+	err := tx.QueryRow("SELECT balance FROM user_balances WHERE uid = ?", uid).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return balance, err
+}
+
+// addBalanceTx adds amount (which may be negative) to a balance inside a transaction.
+func addBalanceTx(tx *sql.Tx, uid string, amount int64) error {
+	_, err := tx.Exec(`INSERT INTO user_balances (uid, balance) VALUES (?, ?)
+		ON CONFLICT(uid) DO UPDATE SET balance = balance + excluded.balance`, uid, amount)
+	return err
+}
+
+// LedgerTransfer is the ledger reason of both sides of a balance transfer.
+const LedgerTransfer = "transfer"
+
+// TransferBalance moves atoms from one balance to another atomically,
+// failing when the source balance is insufficient. Both sides are recorded
+// in the ledger.
+func (dm *DBManager) TransferBalance(fromUID, toUID string, atoms int64) error {
+	if atoms <= 0 {
+		return fmt.Errorf("invalid transfer amount: %d", atoms)
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	tx, err := dm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	balance, err := balanceTx(tx, fromUID)
+	if err != nil {
+		return fmt.Errorf("failed to get balance: %v", err)
+	}
+	if balance < atoms {
+		return fmt.Errorf("insufficient balance. Required: %.8f DCR, Current: %.8f DCR", money.AtomsToDCR(atoms), money.AtomsToDCR(balance))
+	}
+	if err := addBalanceTx(tx, fromUID, -atoms); err != nil {
+		return fmt.Errorf("failed to debit balance: %v", err)
+	}
+	if err := addBalanceTx(tx, toUID, atoms); err != nil {
+		return fmt.Errorf("failed to credit balance: %v", err)
+	}
+	now := time.Now()
+	if err := insertLedgerTx(tx, fromUID, -atoms, LedgerTransfer, "", now); err != nil {
+		return fmt.Errorf("failed to record transfer: %v", err)
+	}
+	if err := insertLedgerTx(tx, toUID, atoms, LedgerTransfer, "", now); err != nil {
+		return fmt.Errorf("failed to record transfer: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transfer: %v", err)
+	}
+	return nil
+}
+
+// DeductSplit deducts userAtoms from the user's balance and potAtoms from a
+// GC pot in one transaction. Nothing is deducted unless both balances cover
+// their share. Both charges are recorded under chargeID, and a split whose
+// charge is already in the user's ledger is not deducted again. It reports
+// whether the split was applied now rather than found in the ledger.
+func (dm *DBManager) DeductSplit(userUID, potUID string, userAtoms, potAtoms int64, chargeID string) (bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	tx, err := dm.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if chargeID != "" {
+		charged, err := chargeRecordedTx(tx, userUID, chargeID)
+		if err != nil {
+			return false, fmt.Errorf("failed to check charge %s: %v", chargeID, err)
+		}
+		if charged {
+			return false, nil
+		}
+	}
+	userBalance, err := balanceTx(tx, userUID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get balance: %v", err)
+	}
+	potBalance, err := balanceTx(tx, potUID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get pot balance: %v", err)
+	}
+	if userBalance < userAtoms {
+		return false, fmt.Errorf("insufficient balance for your share. Required: %.8f DCR, Current: %.8f DCR", money.AtomsToDCR(userAtoms), money.AtomsToDCR(userBalance))
+	}
+	if potBalance < potAtoms {
+		return false, fmt.Errorf("insufficient GC pot balance. Required: %.8f DCR, Current: %.8f DCR", money.AtomsToDCR(potAtoms), money.AtomsToDCR(potBalance))
+	}
+	if err := addBalanceTx(tx, userUID, -userAtoms); err != nil {
+		return false, fmt.Errorf("failed to deduct balance: %v", err)
+	}
+	if err := addBalanceTx(tx, potUID, -potAtoms); err != nil {
+		return false, fmt.Errorf("failed to deduct pot balance: %v", err)
+	}
+	now := time.Now()
+	if err := insertLedgerTx(tx, userUID, -userAtoms, LedgerCharge, chargeID, now); err != nil {
+		return false, fmt.Errorf("failed to record charge: %v", err)
+	}
+	if err := insertLedgerTx(tx, potUID, -potAtoms, LedgerCharge, chargeID, now); err != nil {
+		return false, fmt.Errorf("failed to record charge: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit split charge: %v", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,37 @@
+package database
+
+import "testing"
+
+func TestDeductSplitAtomic(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	pot := GCPotUID("Lounge")
+	if err := dm.UpdateBalance("user", 1000); err != nil {
+		t.Fatalf("UpdateBalance: %v", err)
+	}
+	if err := dm.TransferBalance("user", pot, 400); err != nil {
+		t.Fatalf("TransferBalance: %v", err)
+	}
+
+	// The pot cannot cover its share, so neither balance may change.
+	if _, err := dm.DeductSplit("user", pot, 100, 500, ""); err == nil {
+		t.Fatal("DeductSplit succeeded with an underfunded pot")
+	}
+	if _, err := dm.DeductSplit("user", pot, 300, 300, ""); err != nil {
+		t.Fatalf("DeductSplit: %v", err)
+	}
+
+	for uid, want := range map[string]int64{"user": 300, pot: 100} {
+		got, err := dm.GetBalance(uid)
+		if err != nil {
+			t.Fatalf("GetBalance(%s): %v", uid, err)
+		}
+		if got != want {
+			t.Errorf("balance of %s = %d, want %d", uid, got, want)
+		}
+	}
+}
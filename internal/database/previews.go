@@ -0,0 +1,67 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// createPreviewUsageTable counts the preview thumbnails each user rendered
+// per UTC day.
+const createPreviewUsageTable = `
+	CREATE TABLE IF NOT EXISTS preview_usage (
+		uid TEXT NOT NULL,
+		day TEXT NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (uid, day)
+	)
+`
+
+// previewDay returns the UTC day key preview usage is counted under.
+func previewDay(now time.Time) string {
+	return now.UTC().Format("2006-01-02")
+}
+
+// TakePreview uses one of the user's daily previews. It returns the number
+// of previews left today, or false when the user already used limit previews.
+func (dm *DBManager) TakePreview(uid string, now time.Time, limit int) (bool, int, error) {
+	if limit <= 0 {
+		return false, 0, nil
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	res, err := dm.db.Exec(`
+		INSERT INTO preview_usage (uid, day, count) VALUES (?, ?, 1)
+		ON CONFLICT(uid, day) DO UPDATE SET count = count + 1 WHERE count < ?`,
+		uid, previewDay(now), limit)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to record preview: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to record preview: %v", err)
+	}
+	if n == 0 {
+		return false, 0, nil
+	}
+
+	var used int
+	err = dm.db.QueryRow("SELECT count FROM preview_usage WHERE uid = ? AND day = ?", uid, previewDay(now)).Scan(&used)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to read preview usage: %v", err)
+	}
+	return true, max(0, limit-used), nil
+}
+
+// ReturnPreview gives back a preview taken for a render that failed.
+func (dm *DBManager) ReturnPreview(uid string, now time.Time) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	_, err := dm.db.Exec("UPDATE preview_usage SET count = count - 1 WHERE uid = ? AND day = ? AND count > 0", uid, previewDay(now))
+	if err != nil {
+		return fmt.Errorf("failed to return preview: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTakePreviewDailyLimit(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for want := 1; want >= 0; want-- {
+		ok, left, err := dm.TakePreview("u", now, 2)
+		if err != nil || !ok || left != want {
+			t.Fatalf("TakePreview = %v, %d, %v; want true, %d, nil", ok, left, err, want)
+		}
+	}
+	if ok, _, err := dm.TakePreview("u", now, 2); err != nil || ok {
+		t.Fatalf("TakePreview over limit = %v, %v; want false, nil", ok, err)
+	}
+
+	// A failed render gives its preview back.
+	if err := dm.ReturnPreview("u", now); err != nil {
+		t.Fatalf("ReturnPreview: %v", err)
+	}
+	if ok, _, _ := dm.TakePreview("u", now, 2); !ok {
+		t.Error("returned preview not available again")
+	}
+
+	// Usage resets the next UTC day and is tracked per user.
+	if ok, _, _ := dm.TakePreview("u", now.Add(24*time.Hour), 2); !ok {
+		t.Error("preview refused on a new day")
+	}
+	if ok, _, _ := dm.TakePreview("other", now, 2); !ok {
+		t.Error("preview refused for another user")
+	}
+	if ok, _, _ := dm.TakePreview("u", now, 0); ok {
+		t.Error("preview allowed with a zero limit")
+	}
+}
@@ -0,0 +1,410 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Promo code kinds.
+const (
+	PromoCredit   = "credit"    // Amount is atoms credited on redemption
+	PromoTipBonus = "tip_bonus" // Amount is the percentage added to the next tip
+)
+
+// Ledger reasons of promo codes and referrals.
+const (
+	LedgerPromoCredit = "promo_credit"
+	LedgerPromoBonus  = "promo_bonus"
+	LedgerReferral    = "referral"
+)
+
+var (
+	// ErrPromoNotFound is returned for codes that are neither promo nor
+	// referral codes.
+	ErrPromoNotFound = errors.New("promo code not found")
+	// ErrPromoExists is returned when adding a code that is already taken.
+	ErrPromoExists = errors.New("promo code already exists")
+	// ErrPromoExpired is returned for promo codes past their expiry.
+	ErrPromoExpired = errors.New("promo code expired")
+	// ErrPromoUsedUp is returned for promo codes redeemed as often as
+	// allowed.
+	ErrPromoUsedUp = errors.New("promo code used up")
+	// ErrPromoRedeemed is returned when a user redeems a code, or a
+	// referral, a second time.
+	ErrPromoRedeemed = errors.New("promo code already redeemed")
+	// ErrTipBonusPending is returned when redeeming a tip bonus while
+	// another one waits for the user's next tip.
+	ErrTipBonusPending = errors.New("tip bonus already pending")
+	// ErrOwnReferral is returned when users redeem their own referral code.
+	ErrOwnReferral = errors.New("own referral code")
+	// ErrNotNewUser is returned when a user who already tipped or was
+	// charged redeems a referral code.
+	ErrNotNewUser = errors.New("referral codes are for new users")
+)
+
+// PromoCode is a code admins hand out for credit or a tip bonus.
+type PromoCode struct {
+	Code      string
+	Kind      string
+	Amount    int64 // Atoms for PromoCredit, percent for PromoTipBonus
+	MaxUses   int   // 0 when unlimited
+	Uses      int
+	ExpiresAt time.Time // Zero when the code does not expire
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// NormalizePromoCode returns code the way codes are stored: trimmed and
+// uppercased, so users can type them in any case.
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// referralCodeExistsTx reports whether code is a referral code.
+func referralCodeExistsTx(tx *sql.Tx, code string) (bool, error) {
+	var exists bool
+	err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM referral_codes WHERE code = ?)", code).Scan(&exists)
+	return exists, err
+}
+
+// AddPromoCode adds a promo code, failing with ErrPromoExists when the code
+// is already a promo or referral code.
+func (dm *DBManager) AddPromoCode(p PromoCode) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	tx, err := dm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	code := NormalizePromoCode(p.Code)
+	taken, err := referralCodeExistsTx(tx, code)
+	if err != nil {
+		return fmt.Errorf("failed to check referral codes: %v", err)
+	}
+	if taken {
+		return ErrPromoExists
+	}
+	var expiresAt int64
+	if !p.ExpiresAt.IsZero() {
+		expiresAt = p.ExpiresAt.Unix()
+	}
+	res, err := tx.Exec(`INSERT OR IGNORE INTO promo_codes (code, kind, amount, max_uses, expires_at, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, code, p.Kind, p.Amount, p.MaxUses, expiresAt, p.CreatedBy, p.CreatedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to add promo code: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to add promo code: %v", err)
+	}
+	if n == 0 {
+		return ErrPromoExists
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit promo code: %v", err)
+	}
+	return nil
+}
+
+// DeletePromoCode removes a promo code. Tip bonuses already redeemed with it
+// are still paid out. It returns false when there was no such code.
+func (dm *DBManager) DeletePromoCode(code string) (bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	res, err := dm.db.Exec("DELETE FROM promo_codes WHERE code = ?", NormalizePromoCode(code))
+	if err != nil {
+		return false, fmt.Errorf("failed to delete promo code: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete promo code: %v", err)
+	}
+	return n > 0, nil
+}
+
+// ListPromoCodes returns all promo codes, newest first.
+func (dm *DBManager) ListPromoCodes() ([]PromoCode, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query(`SELECT code, kind, amount, max_uses, uses, expires_at, created_by, created_at
+		FROM promo_codes ORDER BY created_at DESC, code`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list promo codes: %v", err)
+	}
+	defer rows.Close()
+
+	var codes []PromoCode
+	for rows.Next() {
+		var p PromoCode
+		var expiresAt, createdAt int64
+		if err := rows.Scan(&p.Code, &p.Kind, &p.Amount, &p.MaxUses, &p.Uses, &expiresAt, &p.CreatedBy, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan promo code: %v", err)
+		}
+		if expiresAt != 0 {
+			p.ExpiresAt = time.Unix(expiresAt, 0)
+		}
+		p.CreatedAt = time.Unix(createdAt, 0)
+		codes = append(codes, p)
+	}
+	return codes, rows.Err()
+}
+
+// RedeemPromoCode redeems a promo code for the user: credit codes are added
+// to the balance right away, tip bonuses wait for the user's next tip.
+func (dm *DBManager) RedeemPromoCode(uid, code string, now time.Time) (PromoCode, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	tx, err := dm.db.Begin()
+	if err != nil {
+		return PromoCode{}, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	p := PromoCode{Code: NormalizePromoCode(code)}
+	var expiresAt int64
+	err = tx.QueryRow("SELECT kind, amount, max_uses, uses, expires_at FROM promo_codes WHERE code = ?", p.Code).
+		Scan(&p.Kind, &p.Amount, &p.MaxUses, &p.Uses, &expiresAt)
+	if err == sql.ErrNoRows {
+		return PromoCode{}, ErrPromoNotFound
+	}
+	if err != nil {
+		return PromoCode{}, fmt.Errorf("failed to get promo code: %v", err)
+	}
+	if expiresAt != 0 {
+		p.ExpiresAt = time.Unix(expiresAt, 0)
+		if !now.Before(p.ExpiresAt) {
+			return p, ErrPromoExpired
+		}
+	}
+	if p.MaxUses > 0 && p.Uses >= p.MaxUses {
+		return p, ErrPromoUsedUp
+	}
+
+	res, err := tx.Exec("INSERT OR IGNORE INTO promo_redemptions (code, uid, redeemed_at) VALUES (?, ?, ?)", p.Code, uid, now.Unix())
+	if err != nil {
+		return p, fmt.Errorf("failed to record redemption: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return p, fmt.Errorf("failed to record redemption: %v", err)
+	}
+	if n == 0 {
+		return p, ErrPromoRedeemed
+	}
+
+	switch p.Kind {
+	case PromoCredit:
+		if err := addBalanceTx(tx, uid, p.Amount); err != nil {
+			return p, fmt.Errorf("failed to update balance: %v", err)
+		}
+		if err := insertLedgerTx(tx, uid, p.Amount, LedgerPromoCredit, "", now); err != nil {
+			return p, fmt.Errorf("failed to record ledger entry: %v", err)
+		}
+	case PromoTipBonus:
+		res, err := tx.Exec("INSERT OR IGNORE INTO pending_tip_bonuses (uid, code, percent) VALUES (?, ?, ?)", uid, p.Code, p.Amount)
+		if err != nil {
+			return p, fmt.Errorf("failed to record tip bonus: %v", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return p, fmt.Errorf("failed to record tip bonus: %v", err)
+		}
+		if n == 0 {
+			return p, ErrTipBonusPending
+		}
+	default:
+		return p, fmt.Errorf("unknown promo code kind %q", p.Kind)
+	}
+
+	if _, err := tx.Exec("UPDATE promo_codes SET uses = uses + 1 WHERE code = ?", p.Code); err != nil {
+		return p, fmt.Errorf("failed to count redemption: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return p, fmt.Errorf("failed to commit redemption: %v", err)
+	}
+	p.Uses++
+	return p, nil
+}
+
+// applyTipBonusTx pays out the user's pending tip bonus, if any, on a tip of
+// tipAtoms and returns the bonus.
+func applyTipBonusTx(tx *sql.Tx, uid string, tipAtoms int64, now time.Time) (int64, error) {
+	var percent int64
+	err := tx.QueryRow("SELECT percent FROM pending_tip_bonuses WHERE uid = ?", uid).Scan(&percent)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM pending_tip_bonuses WHERE uid = ?", uid); err != nil {
+		return 0, err
+	}
+	bonus := tipAtoms * percent / 100
+	if bonus <= 0 {
+		return 0, nil
+	}
+	if err := addBalanceTx(tx, uid, bonus); err != nil {
+		return 0, err
+	}
+	if err := insertLedgerTx(tx, uid, bonus, LedgerPromoBonus, "", now); err != nil {
+		return 0, err
+	}
+	return bonus, nil
+}
+
+// PendingTipBonus returns the percentage the user's next tip is topped up
+// by, or 0.
+func (dm *DBManager) PendingTipBonus(uid string) (int64, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var percent int64
+	err := dm.db.QueryRow("SELECT percent FROM pending_tip_bonuses WHERE uid = ?", uid).Scan(&percent)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tip bonus: %v", err)
+	}
+	return percent, nil
+}
+
+// referralCodeChars avoids characters that are easily confused when typed.
+const referralCodeChars = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// newReferralCode returns a random referral code.
+func newReferralCode() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = referralCodeChars[int(b)%len(referralCodeChars)]
+	}
+	return "REF" + string(buf), nil
+}
+
+// ReferralCode returns the user's referral code, creating it on first use.
+func (dm *DBManager) ReferralCode(uid string) (string, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var code string
+	err := dm.db.QueryRow("SELECT code FROM referral_codes WHERE uid = ?", uid).Scan(&code)
+	if err == nil {
+		return code, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to get referral code: %v", err)
+	}
+
+	// Retry the rare code that is already taken
+	for attempt := 0; attempt < 5; attempt++ {
+		if code, err = newReferralCode(); err != nil {
+			return "", fmt.Errorf("failed to generate referral code: %v", err)
+		}
+		res, err := dm.db.Exec(`INSERT OR IGNORE INTO referral_codes (uid, code)
+			SELECT ?, ? WHERE NOT EXISTS (SELECT 1 FROM promo_codes WHERE code = ?)`, uid, code, code)
+		if err != nil {
+			return "", fmt.Errorf("failed to add referral code: %v", err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n > 0 {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("failed to generate a unique referral code")
+}
+
+// RedeemReferral redeems someone's referral code for a new user. Both the
+// user and the referrer are credited atoms once the user's first tip is
+// processed (see CreditTip), so identities that never fund their balance
+// earn nothing. Users are new until they tip or are charged, and can be
+// referred only once. It returns the referrer.
+func (dm *DBManager) RedeemReferral(uid, code string, atoms int64, now time.Time) (string, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	tx, err := dm.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var referrer string
+	err = tx.QueryRow("SELECT uid FROM referral_codes WHERE code = ?", NormalizePromoCode(code)).Scan(&referrer)
+	if err == sql.ErrNoRows {
+		return "", ErrPromoNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get referral code: %v", err)
+	}
+	if referrer == uid {
+		return referrer, ErrOwnReferral
+	}
+	var used bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM balance_ledger WHERE uid = ? AND reason IN (?, ?))",
+		uid, LedgerTip, LedgerCharge).Scan(&used)
+	if err != nil {
+		return referrer, fmt.Errorf("failed to check ledger: %v", err)
+	}
+	if used {
+		return referrer, ErrNotNewUser
+	}
+
+	res, err := tx.Exec("INSERT OR IGNORE INTO referrals (uid, referrer, atoms, redeemed_at) VALUES (?, ?, ?, ?)", uid, referrer, atoms, now.Unix())
+	if err != nil {
+		return referrer, fmt.Errorf("failed to record referral: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return referrer, fmt.Errorf("failed to record referral: %v", err)
+	}
+	if n == 0 {
+		return referrer, ErrPromoRedeemed
+	}
+
+	if err := tx.Commit(); err != nil {
+		return referrer, fmt.Errorf("failed to commit referral: %v", err)
+	}
+	return referrer, nil
+}
+
+// payReferralTx pays out the unpaid referral of uid, if any, crediting both
+// uid and the referrer. It returns the credit and the referrer.
+func payReferralTx(tx *sql.Tx, uid string, now time.Time) (int64, string, error) {
+	var referrer string
+	var atoms int64
+	err := tx.QueryRow("SELECT referrer, atoms FROM referrals WHERE uid = ? AND paid_at = 0", uid).Scan(&referrer, &atoms)
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	if _, err := tx.Exec("UPDATE referrals SET paid_at = ? WHERE uid = ?", now.Unix(), uid); err != nil {
+		return 0, "", err
+	}
+	if atoms <= 0 {
+		return 0, "", nil
+	}
+	for _, credited := range []string{uid, referrer} {
+		if err := addBalanceTx(tx, credited, atoms); err != nil {
+			return 0, "", err
+		}
+		if err := insertLedgerTx(tx, credited, atoms, LedgerReferral, "", now); err != nil {
+			return 0, "", err
+		}
+	}
+	return atoms, referrer, nil
+}
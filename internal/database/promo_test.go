@@ -0,0 +1,128 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPromoCodes(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	now := time.Now()
+	if err := dm.AddPromoCode(PromoCode{Code: "welcome", Kind: PromoCredit, Amount: 300, MaxUses: 1, CreatedAt: now}); err != nil {
+		t.Fatalf("AddPromoCode: %v", err)
+	}
+	if err := dm.AddPromoCode(PromoCode{Code: "WELCOME", Kind: PromoCredit, Amount: 1, CreatedAt: now}); !errors.Is(err, ErrPromoExists) {
+		t.Errorf("duplicate AddPromoCode: %v", err)
+	}
+	if err := dm.AddPromoCode(PromoCode{Code: "OLD", Kind: PromoCredit, Amount: 1, ExpiresAt: now.Add(-time.Minute), CreatedAt: now}); err != nil {
+		t.Fatalf("AddPromoCode: %v", err)
+	}
+	if err := dm.AddPromoCode(PromoCode{Code: "HALF", Kind: PromoTipBonus, Amount: 50, CreatedAt: now}); err != nil {
+		t.Fatalf("AddPromoCode: %v", err)
+	}
+
+	if _, err := dm.RedeemPromoCode("alice", "nope", now); !errors.Is(err, ErrPromoNotFound) {
+		t.Errorf("unknown code: %v", err)
+	}
+	if _, err := dm.RedeemPromoCode("alice", "old", now); !errors.Is(err, ErrPromoExpired) {
+		t.Errorf("expired code: %v", err)
+	}
+	if p, err := dm.RedeemPromoCode("alice", "Welcome", now); err != nil || p.Uses != 1 {
+		t.Fatalf("RedeemPromoCode = %+v, %v", p, err)
+	}
+	if _, err := dm.RedeemPromoCode("bob", "WELCOME", now); !errors.Is(err, ErrPromoUsedUp) {
+		t.Errorf("used up code: %v", err)
+	}
+	if balance, _ := dm.GetBalance("alice"); balance != 300 {
+		t.Errorf("balance after credit code = %d, want 300", balance)
+	}
+
+	// Tip bonuses wait for the next tip and are paid out once.
+	if _, err := dm.RedeemPromoCode("alice", "HALF", now); err != nil {
+		t.Fatalf("RedeemPromoCode: %v", err)
+	}
+	if _, err := dm.RedeemPromoCode("alice", "HALF", now); !errors.Is(err, ErrPromoRedeemed) {
+		t.Errorf("second redemption: %v", err)
+	}
+	if percent, _ := dm.PendingTipBonus("alice"); percent != 50 {
+		t.Errorf("PendingTipBonus = %d, want 50", percent)
+	}
+	if _, extras, err := dm.CreditTip(1, "alice", 1000); err != nil || extras.Bonus != 500 {
+		t.Fatalf("CreditTip bonus = %d, %v; want 500", extras.Bonus, err)
+	}
+	if _, extras, err := dm.CreditTip(2, "alice", 1000); err != nil || extras.Bonus != 0 {
+		t.Fatalf("second CreditTip bonus = %d, %v; want 0", extras.Bonus, err)
+	}
+	if balance, _ := dm.GetBalance("alice"); balance != 2800 {
+		t.Errorf("balance = %d, want 2800", balance)
+	}
+
+	if removed, err := dm.DeletePromoCode("half"); err != nil || !removed {
+		t.Errorf("DeletePromoCode = %v, %v", removed, err)
+	}
+	codes, err := dm.ListPromoCodes()
+	if err != nil || len(codes) != 2 {
+		t.Errorf("ListPromoCodes = %+v, %v", codes, err)
+	}
+}
+
+func TestReferrals(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	code, err := dm.ReferralCode("alice")
+	if err != nil {
+		t.Fatalf("ReferralCode: %v", err)
+	}
+	if again, _ := dm.ReferralCode("alice"); again != code {
+		t.Errorf("ReferralCode changed from %s to %s", code, again)
+	}
+	if err := dm.AddPromoCode(PromoCode{Code: code, Kind: PromoCredit, Amount: 1, CreatedAt: time.Now()}); !errors.Is(err, ErrPromoExists) {
+		t.Errorf("promo code shadowing a referral code: %v", err)
+	}
+
+	now := time.Now()
+	if _, err := dm.RedeemReferral("alice", code, 100, now); !errors.Is(err, ErrOwnReferral) {
+		t.Errorf("own referral: %v", err)
+	}
+	if _, _, err := dm.CreditTip(1, "carol", 1000); err != nil {
+		t.Fatalf("CreditTip: %v", err)
+	}
+	if _, err := dm.RedeemReferral("carol", code, 100, now); !errors.Is(err, ErrNotNewUser) {
+		t.Errorf("referral of a tipper: %v", err)
+	}
+	referrer, err := dm.RedeemReferral("bob", code, 100, now)
+	if err != nil || referrer != "alice" {
+		t.Fatalf("RedeemReferral = %q, %v", referrer, err)
+	}
+	if _, err := dm.RedeemReferral("bob", code, 100, now); !errors.Is(err, ErrPromoRedeemed) {
+		t.Errorf("second referral: %v", err)
+	}
+	// Nothing is paid until bob's first tip
+	for _, uid := range []string{"alice", "bob"} {
+		if balance, _ := dm.GetBalance(uid); balance != 0 {
+			t.Errorf("balance of %s before bob's tip = %d, want 0", uid, balance)
+		}
+	}
+	if _, extras, err := dm.CreditTip(2, "bob", 1000); err != nil || extras.Referral != 100 || extras.Referrer != "alice" {
+		t.Fatalf("CreditTip extras = %+v, %v; want the referral to alice", extras, err)
+	}
+	if _, extras, err := dm.CreditTip(3, "bob", 1000); err != nil || extras.Referral != 0 {
+		t.Fatalf("second CreditTip extras = %+v, %v; want no referral", extras, err)
+	}
+	if balance, _ := dm.GetBalance("alice"); balance != 100 {
+		t.Errorf("balance of alice = %d, want 100", balance)
+	}
+	if balance, _ := dm.GetBalance("bob"); balance != 2100 {
+		t.Errorf("balance of bob = %d, want 2100", balance)
+	}
+}
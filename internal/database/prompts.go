@@ -0,0 +1,105 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SavedPrompt is a prompt a user saved under a name.
+type SavedPrompt struct {
+	Name      string
+	Text      string
+	CreatedAt time.Time
+}
+
+// SavePrompt stores the prompt under its name, replacing a prompt of the
+// same name. It returns false when the name is new and the user already
+// saved limit prompts.
+func (dm *DBManager) SavePrompt(uid string, p SavedPrompt, limit int) (bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	res, err := dm.db.Exec("UPDATE saved_prompts SET text = ?, created_at = ? WHERE uid = ? AND name = ?",
+		p.Text, p.CreatedAt.Unix(), uid, p.Name)
+	if err != nil {
+		return false, fmt.Errorf("failed to save prompt: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return false, fmt.Errorf("failed to save prompt: %v", err)
+	} else if n > 0 {
+		return true, nil
+	}
+
+	res, err = dm.db.Exec(`INSERT INTO saved_prompts (uid, name, text, created_at)
+		SELECT ?, ?, ?, ? WHERE (SELECT COUNT(*) FROM saved_prompts WHERE uid = ?) < ?`,
+		uid, p.Name, p.Text, p.CreatedAt.Unix(), uid, limit)
+	if err != nil {
+		return false, fmt.Errorf("failed to save prompt: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to save prompt: %v", err)
+	}
+	return n > 0, nil
+}
+
+// SavedPromptByName returns the user's prompt of that name. It returns false
+// when there is none.
+func (dm *DBManager) SavedPromptByName(uid, name string) (SavedPrompt, bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	p := SavedPrompt{Name: name}
+	var createdAt int64
+	err := dm.db.QueryRow("SELECT text, created_at FROM saved_prompts WHERE uid = ? AND name = ?", uid, name).Scan(&p.Text, &createdAt)
+	if err == sql.ErrNoRows {
+		return SavedPrompt{}, false, nil
+	}
+	if err != nil {
+		return SavedPrompt{}, false, fmt.Errorf("failed to get prompt: %v", err)
+	}
+	p.CreatedAt = time.Unix(createdAt, 0)
+	return p, true, nil
+}
+
+// SavedPrompts returns the user's prompts, sorted by name.
+func (dm *DBManager) SavedPrompts(uid string) ([]SavedPrompt, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query("SELECT name, text, created_at FROM saved_prompts WHERE uid = ? ORDER BY name", uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompts: %v", err)
+	}
+	defer rows.Close()
+
+	var prompts []SavedPrompt
+	for rows.Next() {
+		var p SavedPrompt
+		var createdAt int64
+		if err := rows.Scan(&p.Name, &p.Text, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt: %v", err)
+		}
+		p.CreatedAt = time.Unix(createdAt, 0)
+		prompts = append(prompts, p)
+	}
+	return prompts, rows.Err()
+}
+
+// DeletePrompt removes the user's prompt of that name. It returns false when
+// there is none.
+func (dm *DBManager) DeletePrompt(uid, name string) (bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	res, err := dm.db.Exec("DELETE FROM saved_prompts WHERE uid = ? AND name = ?", uid, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete prompt: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete prompt: %v", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSavedPrompts(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	now := time.Unix(1_700_000_000, 0)
+	for _, name := range []string{"noir", "anime"} {
+		if ok, err := dm.SavePrompt("a", SavedPrompt{Name: name, Text: name + " style", CreatedAt: now}, 2); err != nil || !ok {
+			t.Fatalf("SavePrompt(%s) = %v, %v", name, ok, err)
+		}
+	}
+	if ok, err := dm.SavePrompt("a", SavedPrompt{Name: "third", Text: "x", CreatedAt: now}, 2); err != nil || ok {
+		t.Fatalf("SavePrompt beyond the limit = %v, %v; want false", ok, err)
+	}
+	// Replacing a prompt does not count against the limit
+	if ok, err := dm.SavePrompt("a", SavedPrompt{Name: "noir", Text: "film noir, high contrast", CreatedAt: now}, 2); err != nil || !ok {
+		t.Fatalf("SavePrompt replacing noir = %v, %v", ok, err)
+	}
+	if ok, err := dm.SavePrompt("b", SavedPrompt{Name: "noir", Text: "b's noir", CreatedAt: now}, 2); err != nil || !ok {
+		t.Fatalf("SavePrompt of another user = %v, %v", ok, err)
+	}
+
+	p, ok, err := dm.SavedPromptByName("a", "noir")
+	if err != nil || !ok || p.Text != "film noir, high contrast" || !p.CreatedAt.Equal(now) {
+		t.Fatalf("SavedPromptByName = %+v, %v, %v", p, ok, err)
+	}
+	prompts, err := dm.SavedPrompts("a")
+	if err != nil || len(prompts) != 2 || prompts[0].Name != "anime" || prompts[1].Name != "noir" {
+		t.Fatalf("SavedPrompts = %+v, %v", prompts, err)
+	}
+
+	if ok, err := dm.DeletePrompt("a", "anime"); err != nil || !ok {
+		t.Fatalf("DeletePrompt = %v, %v", ok, err)
+	}
+	if ok, err := dm.DeletePrompt("a", "anime"); err != nil || ok {
+		t.Fatalf("DeletePrompt twice = %v, %v; want false", ok, err)
+	}
+	if _, ok, err := dm.SavedPromptByName("a", "anime"); err != nil || ok {
+		t.Fatalf("SavedPromptByName after delete = %v, %v", ok, err)
+	}
+}
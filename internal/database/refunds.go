@@ -0,0 +1,188 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// createRefundRequestsTable holds users' refund requests. A job can only be
+// refunded once, so its id is unique.
+const createRefundRequestsTable = `
+	CREATE TABLE IF NOT EXISTS refund_requests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id INTEGER NOT NULL UNIQUE,
+		uid TEXT NOT NULL,
+		atoms INTEGER NOT NULL,
+		reason TEXT NOT NULL,
+		status TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		decided_at INTEGER NOT NULL DEFAULT 0,
+		decided_by TEXT NOT NULL DEFAULT ''
+	)
+`
+
+// Refund request states.
+const (
+	RefundPending  = "pending"
+	RefundApproved = "approved"
+	RefundDenied   = "denied"
+)
+
+// LedgerRefund is the ledger reason of approved refunds.
+const LedgerRefund = "refund"
+
+var (
+	// ErrJobNotRefundable is returned for jobs that do not exist, belong to
+	// someone else or were not charged.
+	ErrJobNotRefundable = errors.New("job not refundable")
+	// ErrRefundRequested is returned when a job already has a refund request.
+	ErrRefundRequested = errors.New("refund already requested")
+	// ErrRefundDecided is returned when deciding a request that is not
+	// pending.
+	ErrRefundDecided = errors.New("refund request already decided")
+)
+
+// RefundRequest is a user's request to get the charge of a job back.
+type RefundRequest struct {
+	ID        int64
+	JobID     int64
+	UID       string
+	Atoms     int64
+	Reason    string
+	Status    string
+	CreatedAt time.Time
+	DecidedAt time.Time // Zero while pending
+	DecidedBy string
+}
+
+// RequestRefund files a refund request for the charge of one of the user's
+// jobs.
+func (dm *DBManager) RequestRefund(jobID int64, uid, reason string, now time.Time) (RefundRequest, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var owner string
+	var charged int64
+	err := dm.db.QueryRow("SELECT uid, charged_atoms FROM jobs WHERE id = ?", jobID).Scan(&owner, &charged)
+	if err == sql.ErrNoRows || (err == nil && (owner != uid || charged <= 0)) {
+		return RefundRequest{}, ErrJobNotRefundable
+	}
+	if err != nil {
+		return RefundRequest{}, fmt.Errorf("failed to get job: %v", err)
+	}
+
+	res, err := dm.db.Exec(`INSERT OR IGNORE INTO refund_requests (job_id, uid, atoms, reason, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`, jobID, uid, charged, reason, RefundPending, now.Unix())
+	if err != nil {
+		return RefundRequest{}, fmt.Errorf("failed to request refund: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return RefundRequest{}, fmt.Errorf("failed to request refund: %v", err)
+	}
+	if n == 0 {
+		return RefundRequest{}, ErrRefundRequested
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return RefundRequest{}, fmt.Errorf("failed to request refund: %v", err)
+	}
+	return RefundRequest{ID: id, JobID: jobID, UID: uid, Atoms: charged, Reason: reason, Status: RefundPending, CreatedAt: now}, nil
+}
+
+// ListRefundRequests returns the refund requests in a state, oldest first.
+func (dm *DBManager) ListRefundRequests(status string) ([]RefundRequest, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query(`SELECT id, job_id, uid, atoms, reason, status, created_at, decided_at, decided_by
+		FROM refund_requests WHERE status = ? ORDER BY id`, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refund requests: %v", err)
+	}
+	defer rows.Close()
+
+	var reqs []RefundRequest
+	for rows.Next() {
+		r, err := scanRefundRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list refund requests: %v", err)
+	}
+	return reqs, nil
+}
+
+// DecideRefund approves or denies a pending refund request. Approving
+// credits the charge back to the user and records it in the ledger.
+func (dm *DBManager) DecideRefund(id int64, approve bool, admin string, now time.Time) (RefundRequest, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	tx, err := dm.db.Begin()
+	if err != nil {
+		return RefundRequest{}, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	r, err := scanRefundRequest(tx.QueryRow(`SELECT id, job_id, uid, atoms, reason, status, created_at, decided_at, decided_by
+		FROM refund_requests WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return RefundRequest{}, fmt.Errorf("refund request #%d not found", id)
+	}
+	if err != nil {
+		return RefundRequest{}, err
+	}
+	if r.Status != RefundPending {
+		return r, ErrRefundDecided
+	}
+
+	r.Status = RefundDenied
+	if approve {
+		r.Status = RefundApproved
+		if err := addBalanceTx(tx, r.UID, r.Atoms); err != nil {
+			return RefundRequest{}, fmt.Errorf("failed to credit refund: %v", err)
+		}
+		if err := insertLedgerTx(tx, r.UID, r.Atoms, LedgerRefund, "", now); err != nil {
+			return RefundRequest{}, fmt.Errorf("failed to record refund: %v", err)
+		}
+	}
+	r.DecidedAt, r.DecidedBy = now, admin
+	if _, err := tx.Exec("UPDATE refund_requests SET status = ?, decided_at = ?, decided_by = ? WHERE id = ?",
+		r.Status, now.Unix(), admin, id); err != nil {
+		return RefundRequest{}, fmt.Errorf("failed to update refund request: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return RefundRequest{}, fmt.Errorf("failed to commit refund decision: %v", err)
+	}
+	return r, nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanRefundRequest reads a refund_requests row.
+func scanRefundRequest(row rowScanner) (RefundRequest, error) {
+	var r RefundRequest
+	var createdAt, decidedAt int64
+	err := row.Scan(&r.ID, &r.JobID, &r.UID, &r.Atoms, &r.Reason, &r.Status, &createdAt, &decidedAt, &r.DecidedBy)
+	if err == sql.ErrNoRows {
+		return RefundRequest{}, err
+	}
+	if err != nil {
+		return RefundRequest{}, fmt.Errorf("failed to scan refund request: %v", err)
+	}
+	r.CreatedAt = time.Unix(createdAt, 0)
+	if decidedAt > 0 {
+		r.DecidedAt = time.Unix(decidedAt, 0)
+	}
+	return r, nil
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRefundFlow(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	now := time.Now()
+	job, err := dm.RecordJob("alice", "text2video", "kling", "https://example.com/v.mp4", now)
+	if err != nil {
+		t.Fatalf("RecordJob: %v", err)
+	}
+	free, err := dm.RecordJob("alice", "text2video", "kling", "https://example.com/f.mp4", now)
+	if err != nil {
+		t.Fatalf("RecordJob: %v", err)
+	}
+	if err := dm.SetJobCharge(job.ID, 500); err != nil {
+		t.Fatalf("SetJobCharge: %v", err)
+	}
+
+	// Uncharged jobs and other users' jobs cannot be refunded.
+	if _, err := dm.RequestRefund(free.ID, "alice", "", now); !errors.Is(err, ErrJobNotRefundable) {
+		t.Errorf("refund of uncharged job: %v", err)
+	}
+	if _, err := dm.RequestRefund(job.ID, "bob", "", now); !errors.Is(err, ErrJobNotRefundable) {
+		t.Errorf("refund of someone else's job: %v", err)
+	}
+
+	req, err := dm.RequestRefund(job.ID, "alice", "blurry", now)
+	if err != nil || req.Atoms != 500 {
+		t.Fatalf("RequestRefund = %+v, %v", req, err)
+	}
+	if _, err := dm.RequestRefund(job.ID, "alice", "again", now); !errors.Is(err, ErrRefundRequested) {
+		t.Errorf("second request: %v", err)
+	}
+	pending, err := dm.ListRefundRequests(RefundPending)
+	if err != nil || len(pending) != 1 || pending[0].Reason != "blurry" {
+		t.Fatalf("ListRefundRequests = %+v, %v", pending, err)
+	}
+
+	decided, err := dm.DecideRefund(req.ID, true, "admin", now)
+	if err != nil || decided.Status != RefundApproved {
+		t.Fatalf("DecideRefund = %+v, %v", decided, err)
+	}
+	if balance, _ := dm.GetBalance("alice"); balance != 500 {
+		t.Errorf("balance after refund = %d, want 500", balance)
+	}
+	// A decided request cannot be decided again, so refunds are paid once.
+	if _, err := dm.DecideRefund(req.ID, true, "admin", now); !errors.Is(err, ErrRefundDecided) {
+		t.Errorf("second decision: %v", err)
+	}
+	if balance, _ := dm.GetBalance("alice"); balance != 500 {
+		t.Errorf("balance after second decision = %d, want 500", balance)
+	}
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CachedResponse returns the response stored under key since since, and when
+// it was stored. It returns false when there is none.
+func (dm *DBManager) CachedResponse(key string, since time.Time) (string, time.Time, bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var response string
+	var createdAt int64
+	err := dm.db.QueryRow("SELECT response, created_at FROM request_cache WHERE key = ? AND created_at >= ?",
+		key, since.Unix()).Scan(&response, &createdAt)
+	if err == sql.ErrNoRows {
+		return "", time.Time{}, false, nil
+	}
+	if err != nil {
+		return "", time.Time{}, false, fmt.Errorf("failed to get cached response: %v", err)
+	}
+	return response, time.Unix(createdAt, 0), true, nil
+}
+
+// StoreCachedResponse stores response under key, replacing an older one.
+func (dm *DBManager) StoreCachedResponse(key, response string, createdAt time.Time) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if _, err := dm.db.Exec("INSERT OR REPLACE INTO request_cache (key, response, created_at) VALUES (?, ?, ?)",
+		key, response, createdAt.Unix()); err != nil {
+		return fmt.Errorf("failed to cache response: %v", err)
+	}
+	return nil
+}
+
+// PurgeCachedResponses deletes the responses stored before before and
+// returns how many were removed.
+func (dm *DBManager) PurgeCachedResponses(before time.Time) (int64, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	res, err := dm.db.Exec("DELETE FROM request_cache WHERE created_at < ?", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge cached responses: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge cached responses: %v", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequestCache(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	now := time.Unix(1_700_000_000, 0)
+	if err := dm.StoreCachedResponse("k", `{"v":1}`, now.Add(-time.Hour)); err != nil {
+		t.Fatalf("StoreCachedResponse: %v", err)
+	}
+	if _, _, ok, err := dm.CachedResponse("k", now.Add(-time.Minute)); err != nil || ok {
+		t.Fatalf("CachedResponse of a stale response = %v, %v; want none", ok, err)
+	}
+	if err := dm.StoreCachedResponse("k", `{"v":2}`, now); err != nil {
+		t.Fatalf("StoreCachedResponse: %v", err)
+	}
+	resp, at, ok, err := dm.CachedResponse("k", now.Add(-time.Minute))
+	if err != nil || !ok || resp != `{"v":2}` || !at.Equal(now) {
+		t.Fatalf("CachedResponse = %q, %v, %v, %v", resp, at, ok, err)
+	}
+	if _, _, ok, err := dm.CachedResponse("other", time.Time{}); err != nil || ok {
+		t.Fatalf("CachedResponse of an unknown key = %v, %v", ok, err)
+	}
+
+	if err := dm.StoreCachedResponse("old", "{}", now.Add(-2*time.Hour)); err != nil {
+		t.Fatalf("StoreCachedResponse: %v", err)
+	}
+	if n, err := dm.PurgeCachedResponses(now.Add(-time.Hour)); err != nil || n != 1 {
+		t.Fatalf("PurgeCachedResponses = %d, %v; want 1", n, err)
+	}
+}
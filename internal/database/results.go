@@ -0,0 +1,86 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kinds of recent results.
+const (
+	ResultImage = "image"
+	ResultVideo = "video"
+	ResultAudio = "audio"
+)
+
+// RecentResultsKept is how many results are kept per user for !last and
+// the "last" keyword.
+const RecentResultsKept = 10
+
+// createRecentResultsTable holds each user's latest delivered results, so
+// they can be fed into the next command with "last".
+const createRecentResultsTable = `
+	CREATE TABLE IF NOT EXISTS recent_results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		uid TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		model TEXT NOT NULL,
+		url TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS recent_results_uid ON recent_results (uid, id)
+`
+
+// RecentResult is a result delivered to a user.
+type RecentResult struct {
+	Kind      string
+	Model     string
+	URL       string
+	CreatedAt time.Time
+}
+
+// RecordResult stores a delivered result and drops the user's results
+// beyond the newest RecentResultsKept.
+func (dm *DBManager) RecordResult(uid, kind, model, url string, createdAt time.Time) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if _, err := dm.db.Exec("INSERT INTO recent_results (uid, kind, model, url, created_at) VALUES (?, ?, ?, ?, ?)",
+		uid, kind, model, url, createdAt.Unix()); err != nil {
+		return fmt.Errorf("failed to record result: %v", err)
+	}
+	_, err := dm.db.Exec(`DELETE FROM recent_results WHERE uid = ? AND id NOT IN
+		(SELECT id FROM recent_results WHERE uid = ? ORDER BY id DESC LIMIT ?)`, uid, uid, RecentResultsKept)
+	if err != nil {
+		return fmt.Errorf("failed to trim results: %v", err)
+	}
+	return nil
+}
+
+// RecentResults returns the user's recent results of a kind, newest first.
+// An empty kind returns results of every kind.
+func (dm *DBManager) RecentResults(uid, kind string) ([]RecentResult, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query(`SELECT kind, model, url, created_at FROM recent_results
+		WHERE uid = ? AND (? = '' OR kind = ?) ORDER BY id DESC`, uid, kind, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent results: %v", err)
+	}
+	defer rows.Close()
+
+	var results []RecentResult
+	for rows.Next() {
+		var r RecentResult
+		var createdAt int64
+		if err := rows.Scan(&r.Kind, &r.Model, &r.URL, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan result: %v", err)
+		}
+		r.CreatedAt = time.Unix(createdAt, 0)
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get recent results: %v", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRecentResults(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	now := time.Now()
+	for i := 0; i < RecentResultsKept+2; i++ {
+		if err := dm.RecordResult("u", ResultImage, "flux/dev", fmt.Sprintf("https://x/%d.png", i), now); err != nil {
+			t.Fatalf("RecordResult: %v", err)
+		}
+	}
+	if err := dm.RecordResult("u", ResultVideo, "kling-video-image", "https://x/v.mp4", now); err != nil {
+		t.Fatalf("RecordResult: %v", err)
+	}
+	if err := dm.RecordResult("other", ResultImage, "flux/dev", "https://x/other.png", now); err != nil {
+		t.Fatalf("RecordResult: %v", err)
+	}
+
+	all, err := dm.RecentResults("u", "")
+	if err != nil {
+		t.Fatalf("RecentResults: %v", err)
+	}
+	if len(all) != RecentResultsKept || all[0].URL != "https://x/v.mp4" || all[0].Kind != ResultVideo {
+		t.Fatalf("RecentResults = %+v, want %d results starting with the video", all, RecentResultsKept)
+	}
+	images, err := dm.RecentResults("u", ResultImage)
+	if err != nil {
+		t.Fatalf("RecentResults: %v", err)
+	}
+	want := fmt.Sprintf("https://x/%d.png", RecentResultsKept+1)
+	if len(images) != RecentResultsKept-1 || images[0].URL != want || images[0].Model != "flux/dev" {
+		t.Errorf("RecentResults(image) = %+v, want newest %s", images, want)
+	}
+	if audio, err := dm.RecentResults("u", ResultAudio); err != nil || len(audio) != 0 {
+		t.Errorf("RecentResults(audio) = %v, %v; want none", audio, err)
+	}
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Schedule is a generation a user runs on a cron schedule.
+type Schedule struct {
+	ID        int64
+	UID       string
+	Nick      string
+	Spec      string // Cron spec, in UTC
+	Command   string
+	Args      []string
+	NextRun   time.Time
+	CreatedAt time.Time
+}
+
+// AddSchedule stores a schedule and returns its id. It returns 0 when the
+// user already has limit schedules.
+func (dm *DBManager) AddSchedule(s Schedule, limit int) (int64, error) {
+	args, err := json.Marshal(s.Args)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode schedule args: %v", err)
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	res, err := dm.db.Exec(`INSERT INTO schedules (uid, nick, spec, command, args, next_run, created_at)
+		SELECT ?, ?, ?, ?, ?, ?, ? WHERE (SELECT COUNT(*) FROM schedules WHERE uid = ?) < ?`,
+		s.UID, s.Nick, s.Spec, s.Command, string(args), s.NextRun.Unix(), s.CreatedAt.Unix(), s.UID, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add schedule: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return 0, fmt.Errorf("failed to add schedule: %v", err)
+	} else if n == 0 {
+		return 0, nil
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to add schedule: %v", err)
+	}
+	return id, nil
+}
+
+// Schedules returns the user's schedules, oldest first.
+func (dm *DBManager) Schedules(uid string) ([]Schedule, error) {
+	return dm.querySchedules(`SELECT id, uid, nick, spec, command, args, next_run, created_at FROM schedules
+		WHERE uid = ? ORDER BY id`, uid)
+}
+
+// DueSchedules returns the schedules whose next run is at or before now,
+// the most overdue first.
+func (dm *DBManager) DueSchedules(now time.Time) ([]Schedule, error) {
+	return dm.querySchedules(`SELECT id, uid, nick, spec, command, args, next_run, created_at FROM schedules
+		WHERE next_run <= ? ORDER BY next_run, id`, now.Unix())
+}
+
+// SetScheduleNextRun moves the next run of schedule id.
+func (dm *DBManager) SetScheduleNextRun(id int64, next time.Time) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if _, err := dm.db.Exec("UPDATE schedules SET next_run = ? WHERE id = ?", next.Unix(), id); err != nil {
+		return fmt.Errorf("failed to reschedule: %v", err)
+	}
+	return nil
+}
+
+// DeleteSchedule removes the user's schedule id. It returns false when the
+// user has no such schedule.
+func (dm *DBManager) DeleteSchedule(uid string, id int64) (bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	res, err := dm.db.Exec("DELETE FROM schedules WHERE uid = ? AND id = ?", uid, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete schedule: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete schedule: %v", err)
+	}
+	return n > 0, nil
+}
+
+func (dm *DBManager) querySchedules(query string, args ...interface{}) ([]Schedule, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedules: %v", err)
+	}
+	defer rows.Close()
+
+	var schedules []Schedule
+	for rows.Next() {
+		var s Schedule
+		var rawArgs string
+		var nextRun, createdAt int64
+		if err := rows.Scan(&s.ID, &s.UID, &s.Nick, &s.Spec, &s.Command, &rawArgs, &nextRun, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %v", err)
+		}
+		if err := json.Unmarshal([]byte(rawArgs), &s.Args); err != nil {
+			return nil, fmt.Errorf("failed to decode schedule args: %v", err)
+		}
+		s.NextRun = time.Unix(nextRun, 0)
+		s.CreatedAt = time.Unix(createdAt, 0)
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
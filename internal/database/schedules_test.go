@@ -0,0 +1,60 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedules(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	now := time.Unix(1_700_000_000, 0)
+	add := func(uid string, next time.Time) int64 {
+		t.Helper()
+		id, err := dm.AddSchedule(Schedule{UID: uid, Nick: uid, Spec: "0 9 * * *", Command: "text2image",
+			Args: []string{"daily", "sunrise"}, NextRun: next, CreatedAt: now}, 2)
+		if err != nil {
+			t.Fatalf("AddSchedule: %v", err)
+		}
+		return id
+	}
+	first := add("a", now.Add(time.Hour))
+	second := add("a", now.Add(-time.Minute))
+	if first == 0 || second == 0 {
+		t.Fatalf("AddSchedule ids = %d, %d", first, second)
+	}
+	if id := add("a", now); id != 0 {
+		t.Fatalf("AddSchedule beyond the limit = %d, want 0", id)
+	}
+	other := add("b", now.Add(-time.Hour))
+
+	schedules, err := dm.Schedules("a")
+	if err != nil || len(schedules) != 2 || schedules[0].ID != first || schedules[0].Args[1] != "sunrise" || !schedules[0].NextRun.Equal(now.Add(time.Hour)) {
+		t.Fatalf("Schedules = %+v, %v", schedules, err)
+	}
+
+	due, err := dm.DueSchedules(now)
+	if err != nil || len(due) != 2 || due[0].ID != other || due[1].ID != second {
+		t.Fatalf("DueSchedules = %+v, %v; want b's, then a's second", due, err)
+	}
+	if err := dm.SetScheduleNextRun(second, now.Add(24*time.Hour)); err != nil {
+		t.Fatalf("SetScheduleNextRun: %v", err)
+	}
+	if due, err := dm.DueSchedules(now); err != nil || len(due) != 1 || due[0].ID != other {
+		t.Fatalf("DueSchedules after rescheduling = %+v, %v", due, err)
+	}
+
+	if ok, err := dm.DeleteSchedule("b", first); err != nil || ok {
+		t.Fatalf("DeleteSchedule of another user's schedule = %v, %v; want false", ok, err)
+	}
+	if ok, err := dm.DeleteSchedule("a", first); err != nil || !ok {
+		t.Fatalf("DeleteSchedule = %v, %v", ok, err)
+	}
+	if schedules, err := dm.Schedules("a"); err != nil || len(schedules) != 1 {
+		t.Fatalf("Schedules after delete = %+v, %v", schedules, err)
+	}
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"fmt"
+)
+
+// createUserSettingsTable holds the generation defaults users save with
+// !set, one row per setting.
+const createUserSettingsTable = `
+	CREATE TABLE IF NOT EXISTS user_settings (
+		uid TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		PRIMARY KEY (uid, key)
+	)
+`
+
+// SetUserSetting saves one of the user's defaults, replacing any earlier value.
+func (dm *DBManager) SetUserSetting(uid, key, value string) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	_, err := dm.db.Exec(`INSERT INTO user_settings (uid, key, value) VALUES (?, ?, ?)
+		ON CONFLICT(uid, key) DO UPDATE SET value = excluded.value`, uid, key, value)
+	if err != nil {
+		return fmt.Errorf("failed to save setting: %v", err)
+	}
+	return nil
+}
+
+// DeleteUserSetting removes one of the user's defaults. It reports whether
+// the setting was set.
+func (dm *DBManager) DeleteUserSetting(uid, key string) (bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	res, err := dm.db.Exec("DELETE FROM user_settings WHERE uid = ? AND key = ?", uid, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove setting: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to remove setting: %v", err)
+	}
+	return n > 0, nil
+}
+
+// GetUserSettings returns the user's defaults by key. The map is empty when
+// the user saved none.
+func (dm *DBManager) GetUserSettings(uid string) (map[string]string, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query("SELECT key, value FROM user_settings WHERE uid = ?", uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %v", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan setting: %v", err)
+		}
+		settings[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get settings: %v", err)
+	}
+	return settings, nil
+}
@@ -0,0 +1,48 @@
+package database
+
+import "testing"
+
+func TestUserSettings(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	if settings, err := dm.GetUserSettings("u"); err != nil || len(settings) != 0 {
+		t.Fatalf("GetUserSettings for new user = %v, %v; want empty, nil", settings, err)
+	}
+	if err := dm.SetUserSetting("u", "aspect", "16:9"); err != nil {
+		t.Fatalf("SetUserSetting: %v", err)
+	}
+	if err := dm.SetUserSetting("u", "aspect", "9:16"); err != nil {
+		t.Fatalf("SetUserSetting: %v", err)
+	}
+	if err := dm.SetUserSetting("u", "seed", "42"); err != nil {
+		t.Fatalf("SetUserSetting: %v", err)
+	}
+	if err := dm.SetUserSetting("other", "seed", "7"); err != nil {
+		t.Fatalf("SetUserSetting: %v", err)
+	}
+
+	settings, err := dm.GetUserSettings("u")
+	if err != nil {
+		t.Fatalf("GetUserSettings: %v", err)
+	}
+	if len(settings) != 2 || settings["aspect"] != "9:16" || settings["seed"] != "42" {
+		t.Errorf("settings = %v; want aspect 9:16 and seed 42", settings)
+	}
+
+	if removed, err := dm.DeleteUserSetting("u", "seed"); err != nil || !removed {
+		t.Fatalf("DeleteUserSetting = %v, %v; want true, nil", removed, err)
+	}
+	if removed, _ := dm.DeleteUserSetting("u", "seed"); removed {
+		t.Error("DeleteUserSetting removed an unset setting")
+	}
+	if settings, _ := dm.GetUserSettings("u"); len(settings) != 1 {
+		t.Errorf("settings after delete = %v; want only aspect", settings)
+	}
+	if settings, _ := dm.GetUserSettings("other"); settings["seed"] != "7" {
+		t.Errorf("other user's settings = %v; want seed 7", settings)
+	}
+}
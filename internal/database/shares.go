@@ -0,0 +1,198 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// createJobSharesTables holds the users a job's owner shared it with, and the
+// nicks the bot has seen, so a job can be shared by nick.
+const createJobSharesTables = `
+	CREATE TABLE IF NOT EXISTS job_shares (
+		job_id INTEGER NOT NULL,
+		uid TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		PRIMARY KEY (job_id, uid)
+	);
+	CREATE TABLE IF NOT EXISTS known_nicks (
+		uid TEXT PRIMARY KEY,
+		nick TEXT NOT NULL,
+		seen_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS known_nicks_nick ON known_nicks (nick COLLATE NOCASE)
+`
+
+var (
+	// ErrNotJobOwner is returned when sharing a job that does not exist or
+	// belongs to someone else.
+	ErrNotJobOwner = errors.New("job not found")
+	// ErrUnknownNick is returned for nicks the bot has not seen.
+	ErrUnknownNick = errors.New("unknown nick")
+	// ErrAmbiguousNick is returned when several users go by the same nick.
+	ErrAmbiguousNick = errors.New("ambiguous nick")
+)
+
+// KnownUser is a user id with its last known nick, empty if never seen.
+type KnownUser struct {
+	UID  string
+	Nick string
+}
+
+// RememberNick records the nick a user was last seen with.
+func (dm *DBManager) RememberNick(uid, nick string, now time.Time) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	_, err := dm.db.Exec(`INSERT INTO known_nicks (uid, nick, seen_at) VALUES (?, ?, ?)
+		ON CONFLICT(uid) DO UPDATE SET nick = excluded.nick, seen_at = excluded.seen_at`, uid, nick, now.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to remember nick: %v", err)
+	}
+	return nil
+}
+
+// KnownNick returns the nick a user was last seen with, or "" when the bot
+// has not seen them.
+func (dm *DBManager) KnownNick(uid string) (string, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var nick string
+	err := dm.db.QueryRow("SELECT nick FROM known_nicks WHERE uid = ?", uid).Scan(&nick)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get nick: %v", err)
+	}
+	return nick, nil
+}
+
+// LookupNick returns the user currently known by a nick, ignoring case.
+func (dm *DBManager) LookupNick(nick string) (string, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query("SELECT uid FROM known_nicks WHERE nick = ? COLLATE NOCASE LIMIT 2", nick)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up nick: %v", err)
+	}
+	defer rows.Close()
+
+	var uids []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return "", fmt.Errorf("failed to look up nick: %v", err)
+		}
+		uids = append(uids, uid)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to look up nick: %v", err)
+	}
+	switch len(uids) {
+	case 0:
+		return "", ErrUnknownNick
+	case 1:
+		return uids[0], nil
+	default:
+		return "", ErrAmbiguousNick
+	}
+}
+
+// ShareJob lets uid re-deliver one of owner's jobs and see its prompt.
+func (dm *DBManager) ShareJob(jobID int64, owner, uid string, now time.Time) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if err := dm.checkJobOwner(jobID, owner); err != nil {
+		return err
+	}
+	if _, err := dm.db.Exec("INSERT OR IGNORE INTO job_shares (job_id, uid, created_at) VALUES (?, ?, ?)", jobID, uid, now.Unix()); err != nil {
+		return fmt.Errorf("failed to share job: %v", err)
+	}
+	return nil
+}
+
+// UnshareJob revokes uid's access to one of owner's jobs. It reports whether
+// the job was shared with uid.
+func (dm *DBManager) UnshareJob(jobID int64, owner, uid string) (bool, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if err := dm.checkJobOwner(jobID, owner); err != nil {
+		return false, err
+	}
+	res, err := dm.db.Exec("DELETE FROM job_shares WHERE job_id = ? AND uid = ?", jobID, uid)
+	if err != nil {
+		return false, fmt.Errorf("failed to unshare job: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to unshare job: %v", err)
+	}
+	return n > 0, nil
+}
+
+// ListJobShares returns the users one of owner's jobs is shared with, with
+// their last known nick when there is one.
+func (dm *DBManager) ListJobShares(jobID int64, owner string) ([]KnownUser, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if err := dm.checkJobOwner(jobID, owner); err != nil {
+		return nil, err
+	}
+	rows, err := dm.db.Query(`SELECT s.uid, COALESCE(n.nick, '') FROM job_shares s
+		LEFT JOIN known_nicks n ON n.uid = s.uid WHERE s.job_id = ? ORDER BY s.created_at, s.uid`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job shares: %v", err)
+	}
+	defer rows.Close()
+
+	var users []KnownUser
+	for rows.Next() {
+		var u KnownUser
+		if err := rows.Scan(&u.UID, &u.Nick); err != nil {
+			return nil, fmt.Errorf("failed to list job shares: %v", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list job shares: %v", err)
+	}
+	return users, nil
+}
+
+// CanAccessJob reports whether uid owns a job or had it shared with them.
+func (dm *DBManager) CanAccessJob(job Job, uid string) (bool, error) {
+	if job.UID == uid {
+		return true, nil
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var shared bool
+	err := dm.db.QueryRow("SELECT EXISTS(SELECT 1 FROM job_shares WHERE job_id = ? AND uid = ?)", job.ID, uid).Scan(&shared)
+	if err != nil {
+		return false, fmt.Errorf("failed to check job access: %v", err)
+	}
+	return shared, nil
+}
+
+// checkJobOwner returns ErrNotJobOwner unless owner owns the job. The
+// caller must hold dm.mu.
+func (dm *DBManager) checkJobOwner(jobID int64, owner string) error {
+	var owns bool
+	err := dm.db.QueryRow("SELECT EXISTS(SELECT 1 FROM jobs WHERE id = ? AND uid = ?)", jobID, owner).Scan(&owns)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %v", err)
+	}
+	if !owns {
+		return ErrNotJobOwner
+	}
+	return nil
+}
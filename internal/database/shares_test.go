@@ -0,0 +1,69 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJobShares(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	now := time.Now()
+	for uid, nick := range map[string]string{"alice": "Alice", "bob": "bob", "carol": "twin", "dave": "twin"} {
+		if err := dm.RememberNick(uid, nick, now); err != nil {
+			t.Fatalf("RememberNick: %v", err)
+		}
+	}
+	if uid, err := dm.LookupNick("BOB"); err != nil || uid != "bob" {
+		t.Errorf("LookupNick(BOB) = %q, %v; want bob", uid, err)
+	}
+	if _, err := dm.LookupNick("twin"); !errors.Is(err, ErrAmbiguousNick) {
+		t.Errorf("LookupNick(twin) error = %v; want ErrAmbiguousNick", err)
+	}
+	if _, err := dm.LookupNick("nobody"); !errors.Is(err, ErrUnknownNick) {
+		t.Errorf("LookupNick(nobody) error = %v; want ErrUnknownNick", err)
+	}
+
+	job, err := dm.RecordJob("alice", "text2video", "m", "https://x/1.mp4", now)
+	if err != nil {
+		t.Fatalf("RecordJob: %v", err)
+	}
+	seed := int64(42)
+	if err := dm.SetJobPrompt(job.ID, "a cat", &seed); err != nil {
+		t.Fatalf("SetJobPrompt: %v", err)
+	}
+	if job, _, err = dm.GetJob(job.ID); err != nil || job.Prompt != "a cat" || job.Seed == nil || *job.Seed != 42 {
+		t.Fatalf("GetJob = %+v, %v; want prompt and seed", job, err)
+	}
+
+	if err := dm.ShareJob(job.ID, "bob", "carol", now); !errors.Is(err, ErrNotJobOwner) {
+		t.Errorf("sharing someone else's job: error = %v; want ErrNotJobOwner", err)
+	}
+	if ok, _ := dm.CanAccessJob(job, "bob"); ok {
+		t.Error("bob can access an unshared job")
+	}
+	if err := dm.ShareJob(job.ID, "alice", "bob", now); err != nil {
+		t.Fatalf("ShareJob: %v", err)
+	}
+	if ok, err := dm.CanAccessJob(job, "bob"); err != nil || !ok {
+		t.Errorf("CanAccessJob(bob) = %v, %v; want true", ok, err)
+	}
+	if users, err := dm.ListJobShares(job.ID, "alice"); err != nil || len(users) != 1 || users[0] != (KnownUser{"bob", "bob"}) {
+		t.Errorf("ListJobShares = %+v, %v", users, err)
+	}
+
+	if removed, err := dm.UnshareJob(job.ID, "alice", "bob"); err != nil || !removed {
+		t.Fatalf("UnshareJob = %v, %v; want true", removed, err)
+	}
+	if ok, _ := dm.CanAccessJob(job, "bob"); ok {
+		t.Error("bob can still access the job after unsharing")
+	}
+	if removed, _ := dm.UnshareJob(job.ID, "alice", "bob"); removed {
+		t.Error("unsharing twice reported a removal")
+	}
+}
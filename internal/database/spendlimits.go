@@ -0,0 +1,118 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SpendRetention is how long charges are kept for checking spending limits,
+// the longest window a limit covers.
+const SpendRetention = 7 * 24 * time.Hour
+
+// SpendLimitOverride holds the spending limits an admin set for a user. A nil
+// limit uses the configured default; 0 is unlimited.
+type SpendLimitOverride struct {
+	UID       string
+	DailyUSD  *float64
+	WeeklyUSD *float64
+}
+
+// SpendLimitOverride returns the spending limits set for a user.
+func (dm *DBManager) SpendLimitOverride(uid string) (SpendLimitOverride, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	o := SpendLimitOverride{UID: uid}
+	var daily, weekly sql.NullFloat64
+	err := dm.db.QueryRow("SELECT daily_usd, weekly_usd FROM user_spend_limits WHERE uid = ?", uid).Scan(&daily, &weekly)
+	if err == sql.ErrNoRows {
+		return o, nil
+	}
+	if err != nil {
+		return o, fmt.Errorf("failed to get spending limits: %v", err)
+	}
+	o.DailyUSD, o.WeeklyUSD = nullFloat(daily), nullFloat(weekly)
+	return o, nil
+}
+
+// SetSpendLimitOverride stores the spending limits of o.UID. Setting both
+// limits to nil returns the user to the defaults.
+func (dm *DBManager) SetSpendLimitOverride(o SpendLimitOverride) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var err error
+	if o.DailyUSD == nil && o.WeeklyUSD == nil {
+		_, err = dm.db.Exec("DELETE FROM user_spend_limits WHERE uid = ?", o.UID)
+	} else {
+		_, err = dm.db.Exec(`INSERT INTO user_spend_limits (uid, daily_usd, weekly_usd) VALUES (?, ?, ?)
+			ON CONFLICT(uid) DO UPDATE SET daily_usd = excluded.daily_usd, weekly_usd = excluded.weekly_usd`,
+			o.UID, o.DailyUSD, o.WeeklyUSD)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set spending limits: %v", err)
+	}
+	return nil
+}
+
+// ListSpendLimitOverrides returns the users with spending limits of their
+// own, by uid.
+func (dm *DBManager) ListSpendLimitOverrides() ([]SpendLimitOverride, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query("SELECT uid, daily_usd, weekly_usd FROM user_spend_limits ORDER BY uid")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spending limits: %v", err)
+	}
+	defer rows.Close()
+
+	var list []SpendLimitOverride
+	for rows.Next() {
+		var o SpendLimitOverride
+		var daily, weekly sql.NullFloat64
+		if err := rows.Scan(&o.UID, &daily, &weekly); err != nil {
+			return nil, fmt.Errorf("failed to scan spending limits: %v", err)
+		}
+		o.DailyUSD, o.WeeklyUSD = nullFloat(daily), nullFloat(weekly)
+		list = append(list, o)
+	}
+	return list, rows.Err()
+}
+
+// RecordSpend records a charge of a user in USD at the given time and drops
+// the user's charges older than SpendRetention.
+func (dm *DBManager) RecordSpend(uid string, usd float64, at time.Time) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if _, err := dm.db.Exec("INSERT INTO user_spend (uid, usd, created_at) VALUES (?, ?, ?)", uid, usd, at.Unix()); err != nil {
+		return fmt.Errorf("failed to record spending: %v", err)
+	}
+	if _, err := dm.db.Exec("DELETE FROM user_spend WHERE uid = ? AND created_at < ?", uid, at.Add(-SpendRetention).Unix()); err != nil {
+		return fmt.Errorf("failed to prune spending: %v", err)
+	}
+	return nil
+}
+
+// SpentSince returns what a user was charged in USD since the given time.
+func (dm *DBManager) SpentSince(uid string, since time.Time) (float64, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var usd float64
+	err := dm.db.QueryRow("SELECT COALESCE(SUM(usd), 0) FROM user_spend WHERE uid = ? AND created_at >= ?", uid, since.Unix()).Scan(&usd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get spending: %v", err)
+	}
+	return usd, nil
+}
+
+// nullFloat returns the value of f, or nil when it is NULL.
+func nullFloat(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpendLimitOverride(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	if o, err := dm.SpendLimitOverride("alice"); err != nil || o.DailyUSD != nil || o.WeeklyUSD != nil {
+		t.Fatalf("SpendLimitOverride = %+v, %v; want the defaults", o, err)
+	}
+	daily := 0.0
+	if err := dm.SetSpendLimitOverride(SpendLimitOverride{UID: "alice", DailyUSD: &daily}); err != nil {
+		t.Fatalf("SetSpendLimitOverride: %v", err)
+	}
+	o, err := dm.SpendLimitOverride("alice")
+	if err != nil || o.DailyUSD == nil || *o.DailyUSD != 0 || o.WeeklyUSD != nil {
+		t.Fatalf("SpendLimitOverride = %+v, %v; want an unlimited day and the default week", o, err)
+	}
+	if list, err := dm.ListSpendLimitOverrides(); err != nil || len(list) != 1 || list[0].UID != "alice" {
+		t.Fatalf("ListSpendLimitOverrides = %+v, %v", list, err)
+	}
+	if err := dm.SetSpendLimitOverride(SpendLimitOverride{UID: "alice"}); err != nil {
+		t.Fatalf("resetting limits: %v", err)
+	}
+	if list, _ := dm.ListSpendLimitOverrides(); len(list) != 0 {
+		t.Fatalf("ListSpendLimitOverrides after reset = %+v", list)
+	}
+}
+
+func TestSpentSince(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	now := time.Unix(1_700_000_000, 0)
+	for _, c := range []struct {
+		uid string
+		usd float64
+		ago time.Duration
+	}{
+		{"alice", 4, 8 * 24 * time.Hour},
+		{"alice", 2, 3 * 24 * time.Hour},
+		{"alice", 0.5, time.Hour},
+		{"bob", 1, time.Hour},
+	} {
+		if err := dm.RecordSpend(c.uid, c.usd, now.Add(-c.ago)); err != nil {
+			t.Fatalf("RecordSpend: %v", err)
+		}
+	}
+	if usd, err := dm.SpentSince("alice", now.Add(-24*time.Hour)); err != nil || usd != 0.5 {
+		t.Fatalf("SpentSince(day) = %v, %v; want 0.5", usd, err)
+	}
+	// The charge of eight days ago was pruned by the later ones
+	if usd, _ := dm.SpentSince("alice", now.Add(-30*24*time.Hour)); usd != 2.5 {
+		t.Fatalf("SpentSince(month) = %v, want 2.5", usd)
+	}
+	if usd, _ := dm.SpentSince("carol", now.Add(-24*time.Hour)); usd != 0 {
+		t.Fatalf("SpentSince of a user without charges = %v", usd)
+	}
+}
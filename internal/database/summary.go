@@ -0,0 +1,76 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// SummaryInterval is the time between two weekly summaries of a user.
+const SummaryInterval = 7 * 24 * time.Hour
+
+// WeeklySummary is a user's spending since their last summary, or over the
+// last week for their first one.
+type WeeklySummary struct {
+	UID     string
+	Since   time.Time
+	Spent   int64 // Atoms charged, GC pot shares excluded
+	Jobs    int   // Charged requests
+	Balance int64 // Atoms left
+}
+
+// DueWeeklySummaries returns the summaries due at now: those of users whose
+// setting key is "on" and who got no summary within SummaryInterval.
+func (dm *DBManager) DueWeeklySummaries(key string, now time.Time) ([]WeeklySummary, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	rows, err := dm.db.Query(`SELECT s.uid, COALESCE(b.balance, 0), COALESCE(w.sent_at, 0) FROM user_settings s
+		LEFT JOIN user_balances b ON b.uid = s.uid
+		LEFT JOIN weekly_summaries w ON w.uid = s.uid
+		WHERE s.key = ? AND s.value = 'on' AND COALESCE(w.sent_at, 0) <= ?
+		ORDER BY s.uid`, key, now.Add(-SummaryInterval).Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due summaries: %v", err)
+	}
+	var due []WeeklySummary
+	for rows.Next() {
+		var s WeeklySummary
+		var sentAt int64
+		if err := rows.Scan(&s.UID, &s.Balance, &sentAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan due summary: %v", err)
+		}
+		s.Since = now.Add(-SummaryInterval)
+		if last := time.Unix(sentAt, 0); sentAt > 0 && last.Before(s.Since) {
+			s.Since = last
+		}
+		due = append(due, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list due summaries: %v", err)
+	}
+
+	for i := range due {
+		err := dm.db.QueryRow(`SELECT COALESCE(-SUM(amount), 0), COUNT(*) FROM balance_ledger
+			WHERE uid = ? AND reason = ? AND created_at >= ? AND created_at < ?`,
+			due[i].UID, LedgerCharge, due[i].Since.Unix(), now.Unix()).Scan(&due[i].Spent, &due[i].Jobs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum spending of %s: %v", due[i].UID, err)
+		}
+	}
+	return due, nil
+}
+
+// MarkWeeklySummarySent records that the user got their summary at now.
+func (dm *DBManager) MarkWeeklySummarySent(uid string, now time.Time) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	_, err := dm.db.Exec(`INSERT INTO weekly_summaries (uid, sent_at) VALUES (?, ?)
+		ON CONFLICT(uid) DO UPDATE SET sent_at = excluded.sent_at`, uid, now.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record summary: %v", err)
+	}
+	return nil
+}
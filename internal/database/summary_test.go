@@ -0,0 +1,46 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDueWeeklySummaries(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	for uid, value := range map[string]string{"alice": "on", "bob": "off"} {
+		if err := dm.SetUserSetting(uid, "weekly_summary", value); err != nil {
+			t.Fatalf("SetUserSetting: %v", err)
+		}
+		if err := dm.UpdateBalance(uid, 1000); err != nil {
+			t.Fatalf("UpdateBalance: %v", err)
+		}
+		if _, _, err := dm.ChargeBalance(uid, 300, ""); err != nil {
+			t.Fatalf("ChargeBalance: %v", err)
+		}
+	}
+
+	now := time.Now().Add(time.Minute)
+	due, err := dm.DueWeeklySummaries("weekly_summary", now)
+	if err != nil {
+		t.Fatalf("DueWeeklySummaries: %v", err)
+	}
+	if len(due) != 1 || due[0].UID != "alice" || due[0].Spent != 300 || due[0].Jobs != 1 || due[0].Balance != 700 {
+		t.Fatalf("due = %+v", due)
+	}
+
+	if err := dm.MarkWeeklySummarySent("alice", now); err != nil {
+		t.Fatalf("MarkWeeklySummarySent: %v", err)
+	}
+	if due, _ := dm.DueWeeklySummaries("weekly_summary", now.Add(SummaryInterval-time.Hour)); len(due) != 0 {
+		t.Fatalf("summary due again within a week: %+v", due)
+	}
+	due, _ = dm.DueWeeklySummaries("weekly_summary", now.Add(SummaryInterval))
+	if len(due) != 1 || due[0].Spent != 0 || !due[0].Since.Equal(time.Unix(now.Unix(), 0)) {
+		t.Fatalf("next summary = %+v", due)
+	}
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// createProcessedTipsTable records every credited tip. The sequence id is the
+// primary key, so a tip redelivered after a crash cannot be credited twice.
+const createProcessedTipsTable = `
+	CREATE TABLE IF NOT EXISTS processed_tips (
+		sequence_id INTEGER PRIMARY KEY,
+		uid TEXT NOT NULL,
+		amount INTEGER NOT NULL,
+		processed_at INTEGER NOT NULL
+	)
+`
+
+// LedgerTip is the ledger reason of credited tips.
+const LedgerTip = "tip"
+
+// TipExtras is the credit a tip paid out on top of itself.
+type TipExtras struct {
+	Bonus    int64  // Pending promo code tip bonus
+	Referral int64  // Referral credit paid on a referred user's first tip
+	Referrer string // User credited the referral credit as well
+}
+
+// CreditTip credits a received tip to the user's balance exactly once. The
+// tip record, the balance update and the payout of a pending tip bonus and
+// referral credit are committed in a single transaction. It returns false
+// without changing the balance when the sequence id has already been
+// processed, and what was paid out on top of the tip otherwise.
+func (dm *DBManager) CreditTip(sequenceID uint64, uid string, amount int64) (bool, TipExtras, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var extras TipExtras
+	tx, err := dm.db.Begin()
+	if err != nil {
+		return false, extras, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	res, err := tx.Exec("INSERT OR IGNORE INTO processed_tips (sequence_id, uid, amount, processed_at) VALUES (?, ?, ?, ?)",
+		int64(sequenceID), uid, amount, now.Unix())
+	if err != nil {
+		return false, extras, fmt.Errorf("failed to record tip: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, extras, fmt.Errorf("failed to record tip: %v", err)
+	}
+	if n == 0 {
+		// Already credited; only the acknowledgement was lost.
+		return false, extras, nil
+	}
+
+	if err := addBalanceTx(tx, uid, amount); err != nil {
+		return false, extras, fmt.Errorf("failed to update balance: %v", err)
+	}
+	if err := insertLedgerTx(tx, uid, amount, LedgerTip, "", now); err != nil {
+		return false, extras, fmt.Errorf("failed to record ledger entry: %v", err)
+	}
+
+	if extras.Bonus, err = applyTipBonusTx(tx, uid, amount, now); err != nil {
+		return false, TipExtras{}, fmt.Errorf("failed to pay out tip bonus: %v", err)
+	}
+	if extras.Referral, extras.Referrer, err = payReferralTx(tx, uid, now); err != nil {
+		return false, TipExtras{}, fmt.Errorf("failed to pay out referral: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, TipExtras{}, fmt.Errorf("failed to commit tip: %v", err)
+	}
+	return true, extras, nil
+}
@@ -0,0 +1,31 @@
+package database
+
+import "testing"
+
+func TestCreditTipIdempotent(t *testing.T) {
+	dm, err := NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+
+	credited, _, err := dm.CreditTip(42, "user", 1000)
+	if err != nil || !credited {
+		t.Fatalf("first CreditTip = %v, %v; want true, nil", credited, err)
+	}
+	credited, _, err = dm.CreditTip(42, "user", 1000)
+	if err != nil || credited {
+		t.Fatalf("redelivered CreditTip = %v, %v; want false, nil", credited, err)
+	}
+	if _, _, err := dm.CreditTip(43, "user", 500); err != nil {
+		t.Fatalf("CreditTip: %v", err)
+	}
+
+	balance, err := dm.GetBalance("user")
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if balance != 1500 {
+		t.Fatalf("balance = %d, want 1500", balance)
+	}
+}
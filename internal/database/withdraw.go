@@ -0,0 +1,53 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Ledger reasons of withdrawals.
+const (
+	LedgerWithdrawal         = "withdrawal"
+	LedgerWithdrawalReversal = "withdrawal_reversal"
+)
+
+// DebitWithdrawal deducts a withdrawal from a balance before it is paid out
+// and records it in the ledger, failing without a change when the balance is
+// insufficient. It returns the new balance.
+func (dm *DBManager) DebitWithdrawal(uid string, atoms int64, now time.Time) (int64, error) {
+	balance, _, err := dm.adjustBalance(uid, -atoms, LedgerWithdrawal, "", now)
+	return balance, err
+}
+
+// ReverseWithdrawal credits back a withdrawal whose payout failed. It returns
+// the new balance.
+func (dm *DBManager) ReverseWithdrawal(uid string, atoms int64, now time.Time) (int64, error) {
+	balance, _, err := dm.adjustBalance(uid, atoms, LedgerWithdrawalReversal, "", now)
+	return balance, err
+}
+
+// WithdrawableBalance returns a user's balance and the part of it that can be
+// withdrawn: what they paid in, less what they spent. Promo credit, tip
+// bonuses, referral credit and admin grants can only be spent, and spending
+// is taken from paid-in atoms first.
+func (dm *DBManager) WithdrawableBalance(uid string) (int64, int64, error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	var balance, granted int64
+	err := dm.db.QueryRow("SELECT COALESCE((SELECT balance FROM user_balances WHERE uid = ?), 0)", uid).Scan(&balance)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get balance: %v", err)
+	}
+	err = dm.db.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM balance_ledger
+		WHERE uid = ? AND amount > 0 AND reason IN (?, ?, ?, ?)`,
+		uid, LedgerPromoCredit, LedgerPromoBonus, LedgerReferral, LedgerAdminCredit).Scan(&granted)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to sum granted credit: %v", err)
+	}
+	withdrawable := balance - granted
+	if withdrawable < 0 {
+		withdrawable = 0
+	}
+	return balance, withdrawable, nil
+}
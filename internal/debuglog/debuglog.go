@@ -0,0 +1,178 @@
+// Package debuglog holds the per-subsystem debug toggles. Each subsystem logs
+// through its own logger of the bot's log backend, so turning one on does not
+// flood the log with the output of the others. Toggles can be flipped at
+// runtime with !admin debug.
+package debuglog
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/decred/slog"
+)
+
+// Debug subsystems.
+const (
+	Fal      = "fal"      // fal HTTP requests, responses and queue polling
+	Billing  = "billing"  // Balance checks and deductions
+	Dispatch = "dispatch" // Command routing and the job queue
+	Delivery = "delivery" // Downloading and sending results
+	DB       = "db"       // Balance updates in the database
+)
+
+// loggerNames maps subsystems to the name of their logger.
+var loggerNames = map[string]string{
+	Fal:      "FAL",
+	Billing:  "BILL",
+	Dispatch: "DISP",
+	Delivery: "DLVR",
+	DB:       "DB",
+}
+
+// Backend creates loggers; it is implemented by the bisonbotkit log backend.
+type Backend interface {
+	Logger(subsys string) slog.Logger
+}
+
+var (
+	mu      sync.RWMutex
+	enabled = make(map[string]bool)
+	loggers = make(map[string]slog.Logger)
+)
+
+// Subsystems returns the debug subsystems in alphabetical order.
+func Subsystems() []string {
+	names := make([]string, 0, len(loggerNames))
+	for name := range loggerNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Attach routes debug output of every subsystem to its logger of backend.
+// Until Attach is called debug output goes to stdout.
+func Attach(backend Backend) {
+	mu.Lock()
+	defer mu.Unlock()
+	for subsys, name := range loggerNames {
+		l := backend.Logger(name)
+		loggers[subsys] = l
+		setLevel(subsys, l)
+	}
+}
+
+// setLevel lowers a subsystem's logger to debug while its toggle is on.
+// Callers hold mu.
+func setLevel(subsys string, l slog.Logger) {
+	if enabled[subsys] {
+		l.SetLevel(slog.LevelDebug)
+	} else {
+		l.SetLevel(slog.LevelInfo)
+	}
+}
+
+// Set turns debug output of a subsystem on or off.
+func Set(subsys string, on bool) error {
+	subsys = strings.ToLower(subsys)
+	if _, ok := loggerNames[subsys]; !ok {
+		return fmt.Errorf("unknown debug subsystem %q (one of: %s)", subsys, strings.Join(Subsystems(), ", "))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	enabled[subsys] = on
+	if l, ok := loggers[subsys]; ok {
+		setLevel(subsys, l)
+	}
+	return nil
+}
+
+// SetAll turns debug output of every subsystem on or off.
+func SetAll(on bool) {
+	for _, subsys := range Subsystems() {
+		Set(subsys, on)
+	}
+}
+
+// Configure enables the subsystems in a comma-separated list such as
+// "fal,billing". "all" enables every subsystem.
+func Configure(list string) error {
+	for _, subsys := range strings.Split(list, ",") {
+		subsys = strings.TrimSpace(subsys)
+		switch {
+		case subsys == "":
+		case strings.EqualFold(subsys, "all"):
+			SetAll(true)
+		default:
+			if err := Set(subsys, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether debug output of a subsystem is on.
+func Enabled(subsys string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return enabled[subsys]
+}
+
+// EnabledFunc returns a function reporting whether a subsystem's debug
+// output is on, for clients that check it on every call.
+func EnabledFunc(subsys string) func() bool {
+	return func() bool { return Enabled(subsys) }
+}
+
+// Debugf logs a debug message for a subsystem if its toggle is on.
+func Debugf(subsys, format string, args ...interface{}) {
+	mu.RLock()
+	on, l := enabled[subsys], loggers[subsys]
+	mu.RUnlock()
+	if !on {
+		return
+	}
+	if l == nil {
+		fmt.Printf("DEBUG [%s] "+strings.TrimSuffix(format, "\n")+"\n", append([]interface{}{subsys}, args...)...)
+		return
+	}
+	l.Debugf(strings.TrimSuffix(format, "\n"), args...)
+}
+
+// Logger returns the logger of a subsystem, for warnings and errors that are
+// logged whether or not its debug toggle is on. Until Attach is called it
+// logs to stdout.
+func Logger(subsys string) slog.Logger {
+	mu.RLock()
+	l := loggers[subsys]
+	mu.RUnlock()
+	if l == nil {
+		return slog.NewBackend(os.Stdout).Logger(loggerNames[subsys])
+	}
+	return l
+}
+
+// Logf returns a printf-style function logging for a subsystem, e.g. for
+// clients that take a debug logger.
+func Logf(subsys string) func(format string, args ...interface{}) {
+	return func(format string, args ...interface{}) {
+		Debugf(subsys, format, args...)
+	}
+}
+
+// Status formats the toggles as "fal=on billing=off ...".
+func Status() string {
+	var parts []string
+	for _, subsys := range Subsystems() {
+		state := "off"
+		if Enabled(subsys) {
+			state = "on"
+		}
+		parts = append(parts, subsys+"="+state)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,51 @@
+package debuglog
+
+import (
+	"testing"
+
+	"github.com/decred/slog"
+)
+
+type testBackend struct {
+	bknd    *slog.Backend
+	loggers map[string]slog.Logger
+}
+
+func (b *testBackend) Logger(subsys string) slog.Logger {
+	l := b.bknd.Logger(subsys)
+	b.loggers[subsys] = l
+	return l
+}
+
+func TestToggles(t *testing.T) {
+	defer SetAll(false)
+
+	if err := Configure("fal, Billing"); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	if !Enabled(Fal) || !Enabled(Billing) || Enabled(DB) {
+		t.Fatalf("after Configure: %s", Status())
+	}
+	if err := Configure("fal,nosuch"); err == nil {
+		t.Error("Configure accepted an unknown subsystem")
+	}
+
+	b := &testBackend{bknd: slog.NewBackend(nil), loggers: make(map[string]slog.Logger)}
+	Attach(b)
+	if lvl := b.loggers["FAL"].Level(); lvl != slog.LevelDebug {
+		t.Errorf("fal logger level = %v, want debug", lvl)
+	}
+	if lvl := b.loggers["DB"].Level(); lvl != slog.LevelInfo {
+		t.Errorf("db logger level = %v, want info", lvl)
+	}
+
+	if err := Set(Fal, false); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if Enabled(Fal) || b.loggers["FAL"].Level() != slog.LevelInfo {
+		t.Error("turning fal off left it enabled")
+	}
+	if want := "billing=on db=off delivery=off dispatch=off fal=off"; Status() != want {
+		t.Errorf("Status() = %q, want %q", Status(), want)
+	}
+}
@@ -0,0 +1,196 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/internal/commands"
+	"github.com/karamble/braibot/internal/confirm"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/debuglog"
+	"github.com/karamble/braibot/internal/i18n"
+	"github.com/karamble/braibot/internal/jobs"
+	"github.com/karamble/braibot/internal/moderation"
+	"github.com/karamble/braibot/internal/ratelimit"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// ReportCommandError tells the requester that a command failed.
+func (r *MessageRouter) ReportCommandError(ctx context.Context, msgCtx braibottypes.MessageContext, cmd string, handleErr error) {
+	// Check if the error is specifically ErrInsufficientBalance
+	var insufErr *utils.ErrInsufficientBalance
+	if errors.Is(handleErr, insufErr) {
+		// Send the specific error message, don't log as warning
+		if err := r.cfg.Sender.SendMessage(ctx, msgCtx, handleErr.Error()); err != nil {
+			r.cfg.Log.Warnf("Failed to send insufficient balance message to %s: %v", msgCtx.Nick, err)
+		}
+		return
+	}
+	// Send user-friendly error message
+	if msgCtx.IsPM {
+		r.cfg.Bot.SendPM(ctx, msgCtx.Nick, r.text(msgCtx, "command_failed", nil))
+		r.cfg.Log.Warnf("Error executing command %s for user %s: %v", cmd, msgCtx.Nick, handleErr)
+	} else {
+		r.cfg.Bot.SendGC(ctx, msgCtx.GC, r.text(msgCtx, "command_failed_gc", nickArgs(msgCtx)))
+		r.cfg.Log.Warnf("Error executing command %s for user %s in GC %s: %v", cmd, msgCtx.Nick, msgCtx.GC, handleErr)
+	}
+}
+
+// RunCommand executes a command, queueing generation requests. Commands
+// without arguments only print their usage and run right away.
+func (r *MessageRouter) RunCommand(ctx context.Context, command braibottypes.Command, msgCtx braibottypes.MessageContext, cmd string, args []string) {
+	debuglog.Debugf(debuglog.Dispatch, "Dispatching !%s for %s (pm=%v gc=%q, %d args)", cmd, msgCtx.Nick, msgCtx.IsPM, msgCtx.GC, len(args))
+	// Remember who goes by which nick, so jobs can be shared by nick
+	if err := r.cfg.DB.RememberNick(msgCtx.Sender.String(), msgCtx.Nick, time.Now()); err != nil {
+		r.cfg.Log.Warnf("Failed to remember nick of %s: %v", msgCtx.Nick, err)
+	}
+	// Admins can turn the bot off in a GC or limit what it runs there
+	var gcSettings database.GCSettings
+	if !msgCtx.IsPM {
+		var err error
+		if gcSettings, err = r.cfg.DB.GCSettings(msgCtx.GC); err != nil {
+			r.cfg.Log.Warnf("Failed to get the settings of GC %s: %v", msgCtx.GC, err)
+		}
+		if gcSettings.Disabled {
+			debuglog.Debugf(debuglog.Dispatch, "Ignoring !%s in disabled GC %s", cmd, msgCtx.GC)
+			return
+		}
+		if !gcSettings.AllowsCommand(cmd) {
+			r.cfg.Sender.SendMessage(ctx, msgCtx, r.text(msgCtx, "gc_command_unavailable",
+				i18n.Args{"Command": cmd, "Available": strings.Join(gcSettings.Commands, ", !")}))
+			return
+		}
+	}
+	if notice, ok := r.cfg.Guests.Notice(r.cfg.Registry, r.cfg.DB, command, msgCtx, args); ok {
+		debuglog.Debugf(debuglog.Dispatch, "Sending the funding walkthrough to guest %s for !%s", msgCtx.Nick, cmd)
+		if msgCtx.IsPM {
+			r.cfg.Sender.SendMessage(ctx, msgCtx, notice)
+			return
+		}
+		r.cfg.Sender.SendMessage(ctx, msgCtx, r.text(msgCtx, "guest_gc_notice", commandArgs(msgCtx, cmd)))
+		r.cfg.Bot.SendPM(ctx, msgCtx.Nick, notice)
+		return
+	}
+	if command.Category != "AI Generation" || len(args) == 0 {
+		if handleErr := command.Handler.Handle(ctx, msgCtx, args, r.cfg.Sender, r.cfg.DB); handleErr != nil {
+			r.ReportCommandError(ctx, msgCtx, cmd, handleErr)
+		}
+		return
+	}
+	// Insert saved prompts before the prompt is moderated and queued
+	expanded, err := commands.ExpandSavedPrompts(r.cfg.DB, msgCtx.Sender.String(), args)
+	if err != nil {
+		r.cfg.Log.Warnf("Failed to expand the saved prompts of %s: %v", msgCtx.Nick, err)
+	} else {
+		args = expanded
+	}
+	if gcSettings.DailyBudgetUSD > 0 {
+		spent, err := r.cfg.DB.GCSpend(msgCtx.GC, time.Now())
+		if err != nil {
+			r.cfg.Log.Warnf("Failed to get the spending of GC %s: %v", msgCtx.GC, err)
+		} else if spent >= gcSettings.DailyBudgetUSD {
+			debuglog.Debugf(debuglog.Dispatch, "GC %s spent $%.2f of its $%.2f budget, refusing !%s", msgCtx.GC, spent, gcSettings.DailyBudgetUSD, cmd)
+			args := nickArgs(msgCtx)
+			args["Budget"] = gcSettings.DailyBudgetUSD
+			r.cfg.Sender.SendMessage(ctx, msgCtx, r.text(msgCtx, "gc_budget_used", args))
+			return
+		}
+	}
+	if err := ratelimit.Default.Allow(msgCtx.Sender.String()); err != nil {
+		debuglog.Debugf(debuglog.Dispatch, "Rate limited !%s for %s: %v", cmd, msgCtx.Nick, err)
+		r.cfg.Sender.SendMessage(ctx, msgCtx, r.text(msgCtx, "please_wait", reasonArgs(msgCtx, err)))
+		return
+	}
+	if moderation.Default != nil {
+		err := moderation.Default.Check(ctx, strings.Join(args, " "))
+		var rejection *moderation.Rejection
+		if err != nil && !errors.As(err, &rejection) {
+			r.cfg.Log.Warnf("Failed to moderate !%s from %s: %v", cmd, msgCtx.Nick, err)
+			if r.cfg.ModerationFailClosed {
+				r.cfg.Sender.SendMessage(ctx, msgCtx, r.text(msgCtx, "moderation_unavailable", nickArgs(msgCtx)))
+				return
+			}
+		}
+		if rejection != nil {
+			r.cfg.Log.Infof("Rejected !%s from %s (%s): %v", cmd, msgCtx.Nick, msgCtx.Sender, rejection)
+			r.cfg.Sender.SendMessage(ctx, msgCtx, r.text(msgCtx, "moderation_rejected", commandArgs(msgCtx, cmd)))
+			return
+		}
+	}
+	job := database.QueuedJob{
+		UID:     msgCtx.Sender.String(),
+		Nick:    msgCtx.Nick,
+		Command: cmd,
+		Args:    args,
+		Message: msgCtx.Message,
+		IsPM:    msgCtx.IsPM || gcSettings.DeliverPM,
+		GC:      msgCtx.GC,
+	}
+	// Expensive requests wait for !confirm. Requests that cannot be priced
+	// up front are run, their handler reports what is wrong with them
+	if confirm.Default != nil {
+		cost, err := commands.RequestCostUSD(r.cfg.DB, msgCtx, cmd, args)
+		if err == nil && confirm.Default.Needs(cost) {
+			debuglog.Debugf(debuglog.Dispatch, "Holding !%s of %s for confirmation ($%.2f)", cmd, msgCtx.Nick, cost)
+			confirm.Default.Hold(job.UID, confirm.Request{
+				Command:   cmd,
+				CostUSD:   cost,
+				CreatedAt: time.Now(),
+				Run:       func(ctx context.Context) { r.submitJob(ctx, msgCtx, job, gcSettings.DeliverPM) },
+			})
+			args := commandArgs(msgCtx, cmd)
+			args["USD"] = cost
+			r.cfg.Sender.SendMessage(ctx, msgCtx, r.text(msgCtx, "confirm_cost", args))
+			return
+		}
+	}
+	r.submitJob(ctx, msgCtx, job, gcSettings.DeliverPM)
+}
+
+// submitJob queues job and tells the requester when it has to wait.
+// deliverPM is set when the group chat of msgCtx has results sent by PM.
+func (r *MessageRouter) submitJob(ctx context.Context, msgCtx braibottypes.MessageContext, job database.QueuedJob, deliverPM bool) {
+	cmd := job.Command
+	status, err := jobs.Default.Submit(job)
+	var limitErr *jobs.ErrUserLimit
+	switch {
+	case errors.As(err, &limitErr):
+		r.cfg.Sender.SendMessage(ctx, msgCtx, r.text(msgCtx, "please_wait", reasonArgs(msgCtx, err)))
+	case err != nil:
+		r.cfg.Log.Warnf("Failed to queue command %s for user %s: %v", cmd, msgCtx.Nick, err)
+		r.cfg.Sender.SendMessage(ctx, msgCtx, r.text(msgCtx, "queue_failed", nil))
+	case status.Position > 0:
+		r.cfg.Sender.SendMessage(ctx, msgCtx, r.text(msgCtx, "queued",
+			i18n.Args{"Command": cmd, "Job": status.Job.ID, "Position": status.Position, "ETA": jobs.FormatETA(status.ETA)}))
+	}
+	if err == nil && !msgCtx.IsPM && deliverPM {
+		r.cfg.Sender.SendMessage(ctx, msgCtx, r.text(msgCtx, "gc_results_by_pm", commandArgs(msgCtx, cmd)))
+	}
+}
+
+// text returns the message key in the language of the sender of msgCtx.
+func (r *MessageRouter) text(msgCtx braibottypes.MessageContext, key string, args i18n.Args) string {
+	return utils.UserText(r.cfg.DB, msgCtx.Sender.String(), key, args)
+}
+
+// nickArgs returns the message arguments naming the sender of msgCtx.
+func nickArgs(msgCtx braibottypes.MessageContext) i18n.Args {
+	return i18n.Args{"Nick": utils.SanitizeUserText(msgCtx.Nick)}
+}
+
+// commandArgs returns nickArgs with the command the sender ran.
+func commandArgs(msgCtx braibottypes.MessageContext, cmd string) i18n.Args {
+	args := nickArgs(msgCtx)
+	args["Command"] = cmd
+	return args
+}
+
+// reasonArgs returns nickArgs with the reason the sender has to wait.
+func reasonArgs(msgCtx braibottypes.MessageContext, err error) i18n.Args {
+	args := nickArgs(msgCtx)
+	args["Reason"] = err.Error()
+	return args
+}
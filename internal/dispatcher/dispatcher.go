@@ -0,0 +1,282 @@
+// Package dispatcher consumes the messages, tips and tip progress events the
+// Bison Relay client delivers and routes them: commands to their handlers
+// or the job queue, MCP frames to the MCP harness, tips to the tip handler
+// and tip progress to the outbound tip matcher.
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/companyzero/bisonrelay/clientrpc/types"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/slog"
+	"github.com/karamble/braibot/internal/commands"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/debuglog"
+	"github.com/karamble/braibot/internal/transcribe"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/brmcp"
+)
+
+// Bot is the part of the Bison Relay client replies are sent with. Both
+// methods address users and group chats by nick or alias.
+type Bot interface {
+	SendPM(ctx context.Context, nick, msg string) error
+	SendGC(ctx context.Context, gc, msg string) error
+}
+
+// TipHandler credits a received tip.
+type TipHandler interface {
+	Handle(ctx context.Context, tip *types.ReceivedTip)
+}
+
+// TipResolver settles outbound tips waiting for their outcome.
+type TipResolver interface {
+	Resolve(payeeUID string, matoms int64, res error) bool
+}
+
+// MCPRouter takes MCP envelope frames received by PM.
+type MCPRouter interface {
+	HandlePM(peer, text string)
+}
+
+// Config holds what a MessageRouter routes messages to.
+type Config struct {
+	Bot         Bot
+	DB          *database.DBManager
+	Registry    *commands.Registry
+	Sender      *braibottypes.MessageSender
+	Guard       *utils.BotGuard
+	Guests      *commands.GuestMode
+	Tips        TipHandler
+	TipResolver TipResolver
+	Log         slog.Logger
+
+	// GCNotes keeps the audio notes posted in GCs whose admins allow
+	// !transcribe. Nil keeps none.
+	GCNotes *transcribe.GCNotes
+
+	// ModerationFailClosed refuses generations whose prompt could not be
+	// checked by the content filter.
+	ModerationFailClosed bool
+
+	// WelcomeEvery is how long after their last welcome or command users
+	// are welcomed again. Zero welcomes every user only once.
+	WelcomeEvery time.Duration
+}
+
+// Channels are the channels the Bison Relay client delivers to.
+type Channels struct {
+	PMs         <-chan *types.ReceivedPM
+	GCs         <-chan *types.GCReceivedMsg
+	Tips        <-chan *types.ReceivedTip
+	TipProgress <-chan *types.TipProgressEvent
+}
+
+// MessageRouter routes received messages and tips.
+type MessageRouter struct {
+	cfg Config
+	mcp MCPRouter
+}
+
+// NewMessageRouter returns a router for cfg.
+func NewMessageRouter(cfg Config) *MessageRouter {
+	return &MessageRouter{cfg: cfg}
+}
+
+// SetMCPRouter routes MCP envelope frames to m. It must be called before
+// Start.
+func (r *MessageRouter) SetMCPRouter(m MCPRouter) {
+	r.mcp = m
+}
+
+// Start consumes ch, one goroutine per channel, until the channels are
+// closed.
+func (r *MessageRouter) Start(ctx context.Context, ch Channels) {
+	go consume(ctx, ch.PMs, r.HandlePM)
+	go consume(ctx, ch.GCs, r.HandleGC)
+	go consume(ctx, ch.Tips, r.cfg.Tips.Handle)
+	go consume(ctx, ch.TipProgress, r.HandleTipProgress)
+}
+
+// consume passes the values received on ch to handle. During shutdown it
+// keeps draining ch so bisonbotkit handlers don't block on their unbuffered
+// sends, but skips processing. Tips left unprocessed are not acknowledged,
+// so they are redelivered.
+func consume[T any](ctx context.Context, ch <-chan *T, handle func(context.Context, *T)) {
+	for v := range ch {
+		if ctx.Err() != nil || v == nil {
+			continue
+		}
+		handle(ctx, v)
+	}
+}
+
+// HandlePM routes a private message.
+func (r *MessageRouter) HandlePM(ctx context.Context, pm *types.ReceivedPM) {
+	uid := utils.GetUserIDString(pm.Uid)
+	// MCP envelope frames are harness protocol traffic, not chat: route
+	// them and skip command parsing and welcomes.
+	if r.mcp != nil && brmcp.IsEnvelope(pm.Msg.Message) {
+		r.mcp.HandlePM(uid, pm.Msg.Message)
+		return
+	}
+
+	r.cfg.Log.Infof("Received PM from %s: %s", pm.Nick, pm.Msg.Message)
+	if r.cfg.Guard.IsBot(uid, pm.Msg.Message) {
+		r.cfg.Log.Infof("Ignoring PM from bot %s", pm.Nick)
+		return
+	}
+	now := time.Now()
+	r.recordContact(uid, now)
+
+	msgCtx := messageContext(pm.Nick, pm.Uid, pm.Msg.Message, "")
+	switch cmd, args, isCmd := commands.IsCommand(pm.Msg.Message); {
+	case isCmd:
+		// Users who know a command need no welcome
+		if err := r.cfg.DB.MarkWelcomed(uid, now); err != nil {
+			r.cfg.Log.Warnf("Failed to record welcome of %s: %v", uid, err)
+		}
+		if command, exists := r.cfg.Registry.Get(cmd); exists {
+			r.RunCommand(ctx, command, msgCtx, cmd, args)
+		} else {
+			r.cfg.Bot.SendPM(ctx, pm.Nick, r.text(msgCtx, "unknown_command", nickArgs(msgCtx)))
+		}
+	case utils.IsAudioNote(pm.Msg.Message):
+		r.handleAudioNote(ctx, msgCtx)
+	default:
+		r.welcome(ctx, msgCtx, now)
+	}
+}
+
+// welcome greets uid unless they were welcomed or used a command recently
+// enough.
+func (r *MessageRouter) welcome(ctx context.Context, msgCtx braibottypes.MessageContext, now time.Time) {
+	uid := msgCtx.Sender.String()
+	needed, err := r.cfg.DB.NeedsWelcome(uid, r.cfg.WelcomeEvery, now)
+	if err != nil {
+		r.cfg.Log.Warnf("Failed to check the welcome of %s: %v", uid, err)
+		return
+	}
+	if !needed {
+		return
+	}
+	welcomeMsg := r.text(msgCtx, "welcome", nickArgs(msgCtx))
+	if err := utils.SendNoticePM(ctx, r.cfg.Bot, r.cfg.DB, uid, welcomeMsg); err != nil {
+		r.cfg.Log.Warnf("Error sending welcome message: %v", err)
+		return
+	}
+	if err := r.cfg.DB.MarkWelcomed(uid, now); err != nil {
+		r.cfg.Log.Warnf("Failed to record welcome of %s: %v", uid, err)
+	}
+}
+
+// recordContact records the activity and first contact of uid.
+func (r *MessageRouter) recordContact(uid string, now time.Time) {
+	if err := r.cfg.DB.TouchActivity(uid, now); err != nil {
+		r.cfg.Log.Warnf("Failed to record activity of %s: %v", uid, err)
+	}
+	if err := r.cfg.DB.RecordContact(uid, now); err != nil {
+		r.cfg.Log.Warnf("Failed to record contact of %s: %v", uid, err)
+	}
+}
+
+// handleAudioNote runs an audio note sent by PM through !ai.
+func (r *MessageRouter) handleAudioNote(ctx context.Context, msgCtx braibottypes.MessageContext) {
+	audioData, err := utils.ExtractAudioNoteData(msgCtx.Message)
+	if err != nil {
+		r.cfg.Log.Warnf("Failed to extract audio data from message: %v", err)
+		r.cfg.Bot.SendPM(ctx, msgCtx.Nick, r.text(msgCtx, "audio_note_failed", nil))
+		return
+	}
+	aiCommand, exists := r.cfg.Registry.Get("ai")
+	if !exists {
+		r.cfg.Log.Warnf("AI command not found in registry")
+		r.cfg.Bot.SendPM(ctx, msgCtx.Nick, r.text(msgCtx, "audio_note_unavailable", nil))
+		return
+	}
+	if err := aiCommand.Handler.Handle(ctx, msgCtx, []string{audioData}, r.cfg.Sender, r.cfg.DB); err != nil {
+		r.cfg.Log.Warnf("Error processing audio note: %v", err)
+		r.cfg.Bot.SendPM(ctx, msgCtx.Nick, r.text(msgCtx, "audio_note_failed", nil))
+	}
+}
+
+// HandleGC routes a group chat message. Only commands are answered; audio
+// notes are kept for !transcribe where it is allowed.
+func (r *MessageRouter) HandleGC(ctx context.Context, gc *types.GCReceivedMsg) {
+	r.cfg.Log.Infof("Received GC message from %s in %s: %s", gc.Nick, gc.GcAlias, gc.Msg.Message)
+	uid := utils.GetUserIDString(gc.Uid)
+	if r.cfg.Guard.IsBot(uid, gc.Msg.Message) {
+		r.cfg.Log.Infof("Ignoring GC message from bot %s in %s", gc.Nick, gc.GcAlias)
+		return
+	}
+	cmd, args, isCmd := commands.IsCommand(gc.Msg.Message)
+	if !isCmd {
+		if utils.IsAudioNote(gc.Msg.Message) {
+			r.rememberGCAudioNote(gc)
+		}
+		return
+	}
+	r.recordContact(uid, time.Now())
+	msgCtx := messageContext(gc.Nick, gc.Uid, gc.Msg.Message, gc.GcAlias)
+	command, exists := r.cfg.Registry.Get(cmd)
+	if !exists {
+		r.cfg.Bot.SendGC(ctx, gc.GcAlias, r.text(msgCtx, "unknown_command", nickArgs(msgCtx)))
+		return
+	}
+	r.RunCommand(ctx, command, msgCtx, cmd, args)
+}
+
+// rememberGCAudioNote keeps an audio note posted in a GC for !transcribe,
+// if the GC's admins allow transcribing there.
+func (r *MessageRouter) rememberGCAudioNote(gc *types.GCReceivedMsg) {
+	if r.cfg.GCNotes == nil {
+		return
+	}
+	settings, err := r.cfg.DB.GCSettings(gc.GcAlias)
+	if err != nil {
+		r.cfg.Log.Warnf("Failed to get the settings of GC %s: %v", gc.GcAlias, err)
+		return
+	}
+	if settings.Disabled || !settings.Transcribe {
+		return
+	}
+	data, err := utils.ExtractAudioNoteData(gc.Msg.Message)
+	if err != nil {
+		r.cfg.Log.Warnf("Failed to extract the audio note of %s in %s: %v", gc.Nick, gc.GcAlias, err)
+		return
+	}
+	r.cfg.GCNotes.Remember(gc.GcAlias, transcribe.GCNote{Nick: gc.Nick, Data: data, PostedAt: time.Now()})
+	debuglog.Debugf(debuglog.Dispatch, "Kept the audio note of %s in %s for !transcribe", gc.Nick, gc.GcAlias)
+}
+
+// HandleTipProgress settles the outbound tip an event reports the outcome
+// of. Events of tips that will be retried are ignored.
+func (r *MessageRouter) HandleTipProgress(ctx context.Context, ev *types.TipProgressEvent) {
+	if !ev.Completed && ev.WillRetry {
+		return
+	}
+	var res error
+	if !ev.Completed {
+		res = errors.New(ev.AttemptErr)
+	}
+	r.cfg.TipResolver.Resolve(utils.GetUserIDString(ev.Uid), ev.AmountMatoms, res)
+}
+
+// messageContext returns the context of a message from nick, received in
+// gc or by PM when gc is empty.
+func messageContext(nick string, uid []byte, message, gc string) braibottypes.MessageContext {
+	var senderID zkidentity.ShortID
+	senderID.FromBytes(uid)
+	return braibottypes.MessageContext{
+		Nick:    nick,
+		Uid:     uid,
+		Message: message,
+		IsPM:    gc == "",
+		Sender:  senderID,
+		GC:      gc,
+	}
+}
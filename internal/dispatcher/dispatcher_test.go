@@ -0,0 +1,345 @@
+package dispatcher
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/clientrpc/types"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/slog"
+	"github.com/karamble/braibot/internal/commands"
+	"github.com/karamble/braibot/internal/confirm"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/money"
+	"github.com/karamble/braibot/internal/transcribe"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// mockBot records the PMs and GC messages sent by nick or alias.
+type mockBot struct {
+	mu  sync.Mutex
+	pms []string
+	gcs []string
+}
+
+func (b *mockBot) SendPM(ctx context.Context, nick, msg string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pms = append(b.pms, nick+": "+msg)
+	return nil
+}
+
+func (b *mockBot) SendGC(ctx context.Context, gc, msg string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gcs = append(b.gcs, gc+": "+msg)
+	return nil
+}
+
+func (b *mockBot) sent() (pms, gcs []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.pms...), append([]string(nil), b.gcs...)
+}
+
+// mockSenderBot is the bot behind the MessageSender commands reply with.
+type mockSenderBot struct{ mockBot }
+
+func (b *mockSenderBot) SendPM(ctx context.Context, uid zkidentity.ShortID, msg string) error {
+	return b.mockBot.SendPM(ctx, uid.String(), msg)
+}
+
+func (b *mockSenderBot) SendGCMessage(ctx context.Context, gc, channel, msg string) error {
+	return b.mockBot.SendGC(ctx, gc, msg)
+}
+
+func (b *mockSenderBot) SendFile(ctx context.Context, uid zkidentity.ShortID, path string) error {
+	return nil
+}
+
+type mockTips struct{ got chan uint64 }
+
+func (m mockTips) Handle(ctx context.Context, tip *types.ReceivedTip) {
+	m.got <- tip.SequenceId
+}
+
+type resolution struct {
+	uid    string
+	matoms int64
+	res    error
+}
+
+type mockResolver struct{ got []resolution }
+
+func (m *mockResolver) Resolve(uid string, matoms int64, res error) bool {
+	m.got = append(m.got, resolution{uid, matoms, res})
+	return true
+}
+
+type testRouter struct {
+	*MessageRouter
+	bot      *mockBot
+	replies  *mockSenderBot
+	db       *database.DBManager
+	resolver *mockResolver
+	ran      []braibottypes.MessageContext
+}
+
+func newTestRouter(t *testing.T) *testRouter {
+	db, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	tr := &testRouter{bot: &mockBot{}, replies: &mockSenderBot{}, db: db, resolver: &mockResolver{}}
+	registry := commands.NewRegistry()
+	registry.Register(braibottypes.Command{
+		Name:     "ping",
+		Category: "Basic",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			tr.ran = append(tr.ran, msgCtx)
+			return sender.SendMessage(ctx, msgCtx, "pong")
+		}),
+	})
+	tr.MessageRouter = NewMessageRouter(Config{
+		Bot:         tr.bot,
+		DB:          db,
+		Registry:    registry,
+		Sender:      braibottypes.NewMessageSender(tr.replies),
+		Guard:       utils.NewBotGuard([]string{uidOf(9)}, false),
+		Guests:      commands.NewGuestMode(nil),
+		Tips:        mockTips{got: make(chan uint64, 1)},
+		TipResolver: tr.resolver,
+		Log:         slog.NewBackend(os.Stdout).Logger("TEST"),
+	})
+	return tr
+}
+
+func uidBytes(b byte) []byte {
+	var id zkidentity.ShortID
+	id[0] = b
+	return id.Bytes()
+}
+
+func uidOf(b byte) string {
+	return utils.GetUserIDString(uidBytes(b))
+}
+
+func pm(uid byte, nick, msg string) *types.ReceivedPM {
+	return &types.ReceivedPM{Uid: uidBytes(uid), Nick: nick, Msg: &types.RMPrivateMessage{Message: msg}}
+}
+
+func gcMsg(uid byte, nick, gc, msg string) *types.GCReceivedMsg {
+	return &types.GCReceivedMsg{Uid: uidBytes(uid), Nick: nick, GcAlias: gc, Msg: &types.RMGroupMessage{Message: msg}}
+}
+
+func TestHandlePM(t *testing.T) {
+	tr := newTestRouter(t)
+	ctx := context.Background()
+
+	tr.HandlePM(ctx, pm(1, "alice", "hello"))
+	tr.HandlePM(ctx, pm(1, "alice", "hello again"))
+	if pms, _ := tr.bot.sent(); len(pms) != 1 || !strings.Contains(pms[0], "Hi alice! I'm BraiBot") {
+		t.Fatalf("welcome PMs = %q, want one", pms)
+	}
+
+	tr.HandlePM(ctx, pm(2, "bob", "!nosuchcommand"))
+	if pms, _ := tr.bot.sent(); len(pms) != 2 || !strings.HasPrefix(pms[1], "bob: ") || !strings.Contains(pms[1], "I don't recognize that command") {
+		t.Fatalf("unknown command reply = %q", pms[1:])
+	}
+	// Replies are in the user's language
+	if err := tr.db.SetUserSetting(uidOf(4), utils.SettingLanguage, "de"); err != nil {
+		t.Fatalf("SetUserSetting: %v", err)
+	}
+	tr.HandlePM(ctx, pm(4, "dora", "!nosuchcommand"))
+	if pms, _ := tr.bot.sent(); !strings.Contains(pms[len(pms)-1], "Diesen Befehl kenne ich nicht") {
+		t.Fatalf("German reply = %q", pms[len(pms)-1])
+	}
+
+	// A user who sent a command is not welcomed afterwards
+	tr.HandlePM(ctx, pm(2, "bob", "thanks"))
+	if pms, _ := tr.bot.sent(); len(pms) != 3 {
+		t.Fatalf("welcomed a user who sent a command: %q", pms[3:])
+	}
+
+	tr.HandlePM(ctx, pm(3, "carol", "!ping"))
+	if len(tr.ran) != 1 || !tr.ran[0].IsPM || tr.ran[0].Nick != "carol" || tr.ran[0].Sender.String() != uidOf(3) {
+		t.Fatalf("ran %+v", tr.ran)
+	}
+	if replies, _ := tr.replies.sent(); len(replies) != 1 || replies[0] != uidOf(3)+": pong" {
+		t.Fatalf("replies = %q", replies)
+	}
+
+	// Welcomes survive a restart of the router
+	restarted := NewMessageRouter(tr.cfg)
+	restarted.HandlePM(ctx, pm(1, "alice", "back again"))
+	if pms, _ := tr.bot.sent(); len(pms) != 3 {
+		t.Fatalf("welcomed alice again after a restart: %q", pms[3:])
+	}
+
+	tr.HandlePM(ctx, pm(9, "otherbot", "!ping"))
+	tr.HandlePM(ctx, pm(9, "otherbot", "hi"))
+	if pms, _ := tr.bot.sent(); len(tr.ran) != 1 || len(pms) != 3 {
+		t.Fatalf("answered a bot: ran %d commands, PMs %q", len(tr.ran), pms)
+	}
+}
+
+func TestWelcomeEvery(t *testing.T) {
+	tr := newTestRouter(t)
+	ctx := context.Background()
+	if err := tr.db.MarkWelcomed(uidOf(1), time.Now().Add(-48*time.Hour)); err != nil {
+		t.Fatalf("MarkWelcomed: %v", err)
+	}
+
+	tr.HandlePM(ctx, pm(1, "alice", "hello"))
+	if pms, _ := tr.bot.sent(); len(pms) != 0 {
+		t.Fatalf("welcomed again without a re-welcome interval: %q", pms)
+	}
+	tr.cfg.WelcomeEvery = 24 * time.Hour
+	tr.HandlePM(ctx, pm(1, "alice", "hello"))
+	tr.HandlePM(ctx, pm(1, "alice", "hello"))
+	if pms, _ := tr.bot.sent(); len(pms) != 1 {
+		t.Fatalf("welcome PMs after the interval = %q, want one", pms)
+	}
+	if n, err := tr.db.CountContacts(time.Time{}); err != nil || n != 1 {
+		t.Fatalf("CountContacts = %d, %v; want 1", n, err)
+	}
+}
+
+func TestHandleGC(t *testing.T) {
+	tr := newTestRouter(t)
+	ctx := context.Background()
+
+	tr.HandleGC(ctx, gcMsg(1, "alice", "art", "hello everyone"))
+	tr.HandleGC(ctx, gcMsg(1, "alice", "art", "!nosuchcommand"))
+	if pms, gcs := tr.bot.sent(); len(pms) != 0 || len(gcs) != 1 || !strings.HasPrefix(gcs[0], "art: 👋 Hi alice!") {
+		t.Fatalf("PMs %q, GC messages %q; want only the unknown command reply", pms, gcs)
+	}
+
+	tr.HandleGC(ctx, gcMsg(1, "alice", "art", "!ping"))
+	if len(tr.ran) != 1 || tr.ran[0].IsPM || tr.ran[0].GC != "art" {
+		t.Fatalf("ran %+v", tr.ran)
+	}
+
+	if err := tr.db.SetGCSettings(database.GCSettings{GC: "art", Disabled: true}); err != nil {
+		t.Fatalf("SetGCSettings: %v", err)
+	}
+	tr.HandleGC(ctx, gcMsg(1, "alice", "art", "!ping"))
+	if len(tr.ran) != 1 {
+		t.Fatal("ran a command in a disabled GC")
+	}
+}
+
+func TestRememberGCAudioNote(t *testing.T) {
+	tr := newTestRouter(t)
+	tr.cfg.GCNotes = transcribe.NewGCNotes(5, time.Hour)
+	ctx := context.Background()
+	note := "--embed[alt=Audio note,type=audio/ogg,data=T2dnUw==]--"
+
+	tr.HandleGC(ctx, gcMsg(1, "alice", "art", note))
+	if notes := tr.cfg.GCNotes.Recent("art", time.Now()); len(notes) != 0 {
+		t.Fatalf("kept %d notes of a GC without transcribing", len(notes))
+	}
+
+	if err := tr.db.SetGCSettings(database.GCSettings{GC: "art", Transcribe: true}); err != nil {
+		t.Fatalf("SetGCSettings: %v", err)
+	}
+	tr.HandleGC(ctx, gcMsg(1, "alice", "art", note))
+	tr.HandleGC(ctx, gcMsg(2, "bob", "art", "nice"))
+	notes := tr.cfg.GCNotes.Recent("art", time.Now())
+	if len(notes) != 1 || notes[0].Nick != "alice" || notes[0].Data != "T2dnUw==" {
+		t.Fatalf("notes = %+v, want alice's", notes)
+	}
+	if pms, gcs := tr.bot.sent(); len(pms) != 0 || len(gcs) != 0 {
+		t.Fatalf("answered an audio note: PMs %q, GC messages %q", pms, gcs)
+	}
+}
+
+func TestHandleTipProgress(t *testing.T) {
+	tr := newTestRouter(t)
+	ctx := context.Background()
+
+	tr.HandleTipProgress(ctx, &types.TipProgressEvent{Uid: uidBytes(1), AmountMatoms: 5, WillRetry: true, AttemptErr: "offline"})
+	tr.HandleTipProgress(ctx, &types.TipProgressEvent{Uid: uidBytes(1), AmountMatoms: 5, AttemptErr: "offline"})
+	tr.HandleTipProgress(ctx, &types.TipProgressEvent{Uid: uidBytes(2), AmountMatoms: 7, Completed: true})
+	got := tr.resolver.got
+	if len(got) != 2 {
+		t.Fatalf("resolved %+v, want the failed and the completed tip", got)
+	}
+	if got[0].uid != uidOf(1) || got[0].matoms != 5 || got[0].res == nil || got[0].res.Error() != "offline" {
+		t.Fatalf("failed tip resolved as %+v", got[0])
+	}
+	if got[1].uid != uidOf(2) || got[1].res != nil {
+		t.Fatalf("completed tip resolved as %+v", got[1])
+	}
+}
+
+func TestStart(t *testing.T) {
+	tr := newTestRouter(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	pms := make(chan *types.ReceivedPM)
+	tips := make(chan *types.ReceivedTip)
+	tr.Start(ctx, Channels{PMs: pms, GCs: make(chan *types.GCReceivedMsg), Tips: tips, TipProgress: make(chan *types.TipProgressEvent)})
+
+	tips <- &types.ReceivedTip{Uid: uidBytes(1), SequenceId: 42}
+	select {
+	case seq := <-tr.cfg.Tips.(mockTips).got:
+		if seq != 42 {
+			t.Fatalf("handled tip %d, want 42", seq)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tip was not handled")
+	}
+
+	// After shutdown the channels are still drained but nothing is handled
+	cancel()
+	select {
+	case tips <- &types.ReceivedTip{SequenceId: 43}:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tip channel is not drained after shutdown")
+	}
+	pms <- pm(1, "alice", "!ping")
+	if len(tr.ran) != 0 {
+		t.Fatal("ran a command after shutdown")
+	}
+	select {
+	case seq := <-tr.cfg.Tips.(mockTips).got:
+		t.Fatalf("handled tip %d after shutdown", seq)
+	default:
+	}
+}
+
+func TestConfirmExpensive(t *testing.T) {
+	tr := newTestRouter(t)
+	ctx := context.Background()
+	confirm.Default = confirm.New(0)
+	t.Cleanup(func() { confirm.Default = nil })
+	tr.cfg.Registry.Register(braibottypes.Command{
+		Name:     "text2image",
+		Category: "AI Generation",
+		Handler: braibottypes.CommandFunc(func(ctx context.Context, msgCtx braibottypes.MessageContext, args []string, sender *braibottypes.MessageSender, db braibottypes.DBManagerInterface) error {
+			tr.ran = append(tr.ran, msgCtx)
+			return nil
+		}),
+	})
+	if err := tr.db.UpdateBalance(uidOf(3), money.AtomsPerDCR); err != nil {
+		t.Fatalf("UpdateBalance: %v", err)
+	}
+
+	tr.HandlePM(ctx, pm(3, "carol", "!text2image a lighthouse"))
+	if len(tr.ran) != 0 {
+		t.Fatal("ran a request waiting for confirmation")
+	}
+	if replies, _ := tr.replies.sent(); len(replies) != 1 || !strings.Contains(replies[0], "Reply **!confirm** within 2 minutes") {
+		t.Fatalf("replies = %q", replies)
+	}
+	req, ok := confirm.Default.Take(uidOf(3), time.Now())
+	if !ok || req.Command != "text2image" || req.CostUSD <= 0 {
+		t.Fatalf("held %+v, %v", req, ok)
+	}
+}
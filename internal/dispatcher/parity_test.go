@@ -0,0 +1,623 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/clientrpc/jsonrpc"
+	"github.com/companyzero/bisonrelay/clientrpc/types"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/slog"
+	"github.com/karamble/braibot/internal/commands"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/falhook"
+	"github.com/karamble/braibot/internal/jobs"
+	"github.com/karamble/braibot/internal/money"
+	"github.com/karamble/braibot/internal/outbox"
+	"github.com/karamble/braibot/internal/transcribe"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	kit "github.com/vctt94/bisonbotkit"
+	"github.com/vctt94/bisonbotkit/config"
+)
+
+// The parity audit runs every command the bot registers through the real
+// dispatcher, job queue and services, once by PM and once in a group chat,
+// against a fake Bison Relay client and a fake fal. It checks that replies,
+// progress, results and receipts reach the right destination: everything
+// for a PM goes back to the requester, and for a group chat progress and
+// results go to the GC, nothing lands in another GC and nothing private
+// (receipts, balances) lands in the GC.
+
+// sentMessage is a PM, GC message or file the bot sent.
+type sentMessage struct {
+	To   string // Nick or uid a PM or file was sent to
+	GC   string // GC a message was sent to
+	File string // Path of a sent file
+	Msg  string
+}
+
+// fakeClientRPC is the chat service of a Bison Relay client, recording what
+// the bot sends instead of sending it. Methods the bot is not expected to
+// call are left to the nil embedded interface.
+type fakeClientRPC struct {
+	types.ChatServiceServer
+
+	mu   sync.Mutex
+	sent []sentMessage
+}
+
+func (f *fakeClientRPC) record(m sentMessage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, m)
+}
+
+// take returns the messages sent since the last call.
+func (f *fakeClientRPC) take() []sentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sent := f.sent
+	f.sent = nil
+	return sent
+}
+
+func (f *fakeClientRPC) PM(ctx context.Context, req *types.PMRequest, res *types.PMResponse) error {
+	f.record(sentMessage{To: req.User, Msg: req.Msg.Message})
+	return nil
+}
+
+func (f *fakeClientRPC) GCM(ctx context.Context, req *types.GCMRequest, res *types.GCMResponse) error {
+	f.record(sentMessage{GC: req.Gc, Msg: req.Msg})
+	return nil
+}
+
+func (f *fakeClientRPC) SendFile(ctx context.Context, req *types.SendFileRequest, res *types.SendFileResponse) error {
+	f.record(sentMessage{To: req.User, File: req.Filename})
+	return nil
+}
+
+// startFakeClientRPC serves a fakeClientRPC and returns a bot connected to
+// it.
+func startFakeClientRPC(t *testing.T) (*kit.Bot, *fakeClientRPC) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	chat := &fakeClientRPC{}
+	var services types.ServersMap
+	defn := types.ChatServiceDefn()
+	services.Bind(defn.Name, defn, chat)
+	srv := jsonrpc.NewServer(jsonrpc.WithServices(&services), jsonrpc.WithListeners([]net.Listener{l}),
+		jsonrpc.WithAuth("braibot", "test", "basic"))
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go srv.Run(ctx)
+
+	// The bot keeps logging after the test, so its log directory is not
+	// one the test removes and checks
+	dir, err := os.MkdirTemp("", "braibot-parity-")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	bot, err := kit.NewBot(&config.BotConfig{
+		RPCURL:  "ws://" + l.Addr().String() + "/ws",
+		RPCUser: "braibot",
+		RPCPass: "test",
+		DataDir: dir,
+		LogFile: filepath.Join(dir, "bot.log"),
+		Debug:   "off",
+	})
+	if err != nil {
+		t.Fatalf("NewBot: %v", err)
+	}
+	return bot, chat
+}
+
+// fakeOutput marks the text results of the fake fal.
+const fakeOutput = "FAKE-OUTPUT"
+
+// fakeFal answers every fal request, queued or streamed, with a result
+// holding an output of each kind, and serves the result and input files.
+type fakeFal struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	submits  int
+	requests int
+	polled   map[string]bool
+}
+
+func newFakeFal(t *testing.T) *fakeFal {
+	t.Helper()
+	var img bytes.Buffer
+	pic := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for i := range pic.Pix {
+		pic.Pix[i] = 0x80
+	}
+	pic.Set(0, 0, color.White)
+	if err := png.Encode(&img, pic); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	files := map[string][]byte{
+		".png": img.Bytes(),
+		".svg": []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="8" height="8"><path d="M1 1 L7 7" stroke="black"/></svg>`),
+		".mp4": []byte("\x00\x00\x00\x18ftypmp42fake video"),
+		".mp3": []byte("ID3fake audio"),
+		".glb": []byte("glTFfake model"),
+	}
+
+	f := &fakeFal{polled: make(map[string]bool)}
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if data, ok := files[filepath.Ext(path)]; ok && strings.HasPrefix(path, "/files/") {
+			w.Write(data)
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && strings.HasSuffix(path, "/stream"):
+			f.submits++
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"output\": %q, \"partial\": false}\n\n", fakeOutput)
+		case r.Method == http.MethodPost:
+			f.submits++
+			f.requests++
+			id := fmt.Sprintf("req-%d", f.requests)
+			fmt.Fprintf(w, `{"request_id": %q, "response_url": "%s/requests/%s", "status_url": "%s/requests/%s/status"}`,
+				id, f.srv.URL, id, f.srv.URL, id)
+		case strings.HasSuffix(path, "/status"):
+			// Report progress once, so progress updates are sent
+			if !f.polled[path] {
+				f.polled[path] = true
+				w.Write([]byte(`{"status": "IN_PROGRESS", "position": 0}`))
+				return
+			}
+			w.Write([]byte(`{"status": "COMPLETED"}`))
+		case strings.HasPrefix(path, "/requests/"):
+			fmt.Fprint(w, strings.NewReplacer("FILES", f.srv.URL+"/files", "OUTPUT", fakeOutput).Replace(`{
+				"images": [{"url": "FILES/out.png", "content_type": "image/png", "width": 8, "height": 8}],
+				"image": {"url": "FILES/out.png", "content_type": "image/png"},
+				"video": {"url": "FILES/out.mp4"},
+				"audio": {"url": "FILES/out.mp3", "content_type": "audio/mpeg"},
+				"audio_url": "FILES/out.mp3",
+				"model_mesh": {"url": "FILES/out.glb"},
+				"output": "OUTPUT",
+				"text": "OUTPUT",
+				"seed": 42
+			}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// file returns the URL of a served file with ext.
+func (f *fakeFal) file(name string) string {
+	return f.srv.URL + "/files/" + name
+}
+
+// submitted returns how many requests were sent to fal since the last call.
+func (f *fakeFal) submitted() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := f.submits
+	f.submits = 0
+	return n
+}
+
+// audioNote returns a message carrying an Ogg audio note of the given
+// length, made of bare page headers.
+func audioNote(seconds int) string {
+	var data []byte
+	for _, granule := range []int64{0, int64(seconds) * 48000} {
+		page := make([]byte, 27)
+		copy(page, "OggS")
+		binary.LittleEndian.PutUint64(page[6:], uint64(granule))
+		data = append(data, page...)
+	}
+	return "--embed[alt=Audio note,type=audio/ogg,data=" + base64.StdEncoding.EncodeToString(data) + "]--"
+}
+
+// redirectTransport sends every request, to fal or elsewhere, to the fake
+// fal server.
+type redirectTransport struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (rt redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme, r.URL.Host, r.Host = rt.target.Scheme, rt.target.Host, rt.target.Host
+	return rt.next.RoundTrip(r)
+}
+
+// parityCase is how a command is run in the audit.
+type parityCase struct {
+	cmdline string
+	// generates is set for requests expected to reach fal, deliver a
+	// result and charge the requester.
+	generates bool
+	// delivers is set for free requests the bot renders itself, expected
+	// to deliver a result without reaching fal or charging anyone.
+	delivers bool
+	// pmOnly is set for commands that ignore group chats.
+	pmOnly bool
+	// gcOnly is set for requests that only generate in group chats and
+	// are just answered by PM.
+	gcOnly bool
+}
+
+// generatesIn reports whether c is expected to generate when sent by PM
+// (gc empty) or in gc.
+func (c parityCase) generatesIn(gc string) bool {
+	return c.generates && (gc != "" || !c.gcOnly)
+}
+
+// parityHarness is a bot wired like main.go, talking to fakes.
+type parityHarness struct {
+	router   *MessageRouter
+	registry *commands.Registry
+	db       *database.DBManager
+	chat     *fakeClientRPC
+	fal      *fakeFal
+	uid      string
+}
+
+const (
+	parityNick = "alice"
+	parityGC   = "studio"
+)
+
+func newParityHarness(t *testing.T) *parityHarness {
+	t.Helper()
+	fal := newFakeFal(t)
+	target, _ := url.Parse(fal.srv.URL)
+	transport := http.DefaultTransport
+	http.DefaultTransport = redirectTransport{target: target, next: transport}
+	t.Cleanup(func() { http.DefaultTransport = transport })
+
+	// Poll fal quickly instead of every 5 seconds
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if err := falhook.Start(ctx, "127.0.0.1:0", "http://127.0.0.1/fal/webhook", 10*time.Millisecond, t.Logf); err != nil {
+		t.Fatalf("falhook.Start: %v", err)
+	}
+	t.Cleanup(func() { falhook.Default = nil })
+	utils.SetFixedDCRRate(20)
+	t.Cleanup(func() { utils.SetFixedDCRRate(0) })
+
+	db, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	uid := uidOf(1)
+	if _, err := db.AdjustBalance(uid, 10*money.AtomsPerDCR, time.Now()); err != nil {
+		t.Fatalf("AdjustBalance: %v", err)
+	}
+	// The GC has a funded pot for --split and keeps audio notes for
+	// !transcribe
+	if _, err := db.AdjustBalance(database.GCPotUID(parityGC), money.AtomsPerDCR, time.Now()); err != nil {
+		t.Fatalf("AdjustBalance: %v", err)
+	}
+	if err := db.SetGCSettings(database.GCSettings{GC: parityGC, Transcribe: true}); err != nil {
+		t.Fatalf("SetGCSettings: %v", err)
+	}
+
+	// Generation commands run on the job queue, as main.go runs them. The
+	// manager is replaced before the commands are built, so !batch hears
+	// of its jobs finishing
+	manager := jobs.Default
+	jobs.Default = jobs.NewManager()
+	t.Cleanup(func() { jobs.Default = manager })
+
+	bot, chat := startFakeClientRPC(t)
+	cfg := &config.BotConfig{ExtraConfig: map[string]string{
+		"falapikey":      "test",
+		"billingenabled": "true",
+		"adminuids":      uid,
+	}}
+	registry := commands.InitializeCommands(db, cfg, bot, nil, false)
+	sender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
+	h := &parityHarness{registry: registry, db: db, chat: chat, fal: fal, uid: uid}
+	h.router = NewMessageRouter(Config{
+		Bot:         bot,
+		DB:          db,
+		Registry:    registry,
+		Sender:      sender,
+		Guard:       utils.NewBotGuard(nil, false),
+		Guests:      commands.NewGuestMode(nil),
+		Tips:        mockTips{got: make(chan uint64, 1)},
+		TipResolver: &mockResolver{},
+		Log:         slog.Disabled,
+		GCNotes:     transcribe.DefaultGCNotes,
+	})
+
+	run := func(ctx context.Context, job database.QueuedJob) error {
+		command, _ := registry.Get(job.Command)
+		var senderID zkidentity.ShortID
+		if err := senderID.FromString(job.UID); err != nil {
+			return err
+		}
+		msgCtx := braibottypes.MessageContext{Nick: job.Nick, Uid: senderID.Bytes(), Message: job.Message, IsPM: job.IsPM, Sender: senderID, GC: job.GC}
+		err := command.Handler.Handle(ctx, msgCtx, job.Args, sender, db)
+		if err != nil && ctx.Err() == nil {
+			h.router.ReportCommandError(ctx, msgCtx, job.Command, err)
+		}
+		return err
+	}
+	if err := jobs.Default.Start(ctx, db, 2, 0, run, func(database.QueuedJob) {}); err != nil {
+		t.Fatalf("jobs.Start: %v", err)
+	}
+	return h
+}
+
+// run sends cmdline by PM, or in gc when it is not empty, and returns what
+// the bot sent once the command and the jobs it queued are done.
+func (h *parityHarness) run(t *testing.T, gc, cmdline string) []sentMessage {
+	t.Helper()
+	ctx := context.Background()
+	if gc == "" {
+		h.router.HandlePM(ctx, pm(1, parityNick, cmdline))
+	} else {
+		h.router.HandleGC(ctx, gcMsg(1, parityNick, gc, cmdline))
+	}
+	deadline := time.Now().Add(20 * time.Second)
+	for {
+		running, pending := jobs.Default.Counts()
+		if running == 0 && pending == 0 && outbox.Default.Idle() {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: jobs still running after 20s", cmdline)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return h.chat.take()
+}
+
+var (
+	// Progress updates, which go where the request was made
+	progressMarkers = []string{"Status: ", "Queue position:", "Log: ", "generation is in process"}
+	// Receipts, which only the requester may see
+	privateMarkers = []string{"Billing Information", "New Balance", "Your balance"}
+	// Any balance with an amount, e.g. "Requester balance: 9.5 DCR", which
+	// is private too unless it is the GC pot's
+	balanceFigure = regexp.MustCompile(`(?i)(\w+ )?balance\b[^\n]*?\d DCR`)
+)
+
+// private reports whether msg shows a receipt or a balance other than the
+// GC pot's.
+func private(msg string) bool {
+	for _, marker := range privateMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	for _, m := range balanceFigure.FindAllStringSubmatch(msg, -1) {
+		if !strings.EqualFold(m[1], "pot ") {
+			return true
+		}
+	}
+	return false
+}
+
+// delivered reports whether m carries a result of the fake fal.
+func (h *parityHarness) delivered(m sentMessage) bool {
+	return m.File != "" || strings.Contains(m.Msg, "--embed[") || strings.Contains(m.Msg, h.fal.srv.URL) ||
+		strings.Contains(m.Msg, fakeOutput)
+}
+
+// checkDestinations reports messages of a command sent by PM (gc empty) or
+// in gc that went to the wrong place.
+func (h *parityHarness) checkDestinations(t *testing.T, gc string, c parityCase, sent []sentMessage) {
+	t.Helper()
+	generates := c.generatesIn(gc)
+	var toOrigin, results, receipts int
+	for _, m := range sent {
+		switch {
+		case m.GC != "" && gc == "":
+			t.Errorf("%s by PM sent to GC %s: %q", c.cmdline, m.GC, m.Msg)
+		case m.GC != "" && m.GC != gc:
+			t.Errorf("%s in %s sent to GC %s: %q", c.cmdline, gc, m.GC, m.Msg)
+		case m.GC == "" && m.To != h.uid && m.To != parityNick:
+			t.Errorf("%s sent a PM to %s instead of the requester: %q", c.cmdline, m.To, m.Msg)
+		}
+		if m.GC != "" && private(m.Msg) {
+			t.Errorf("%s in %s posted a private message in the GC: %q", c.cmdline, gc, m.Msg)
+		}
+		for _, marker := range progressMarkers {
+			if m.GC != gc && strings.Contains(m.Msg, marker) {
+				t.Errorf("%s in %s sent progress by PM: %q", c.cmdline, gc, m.Msg)
+			}
+		}
+		if m.GC == gc {
+			toOrigin++
+			if h.delivered(m) {
+				results++
+			}
+		}
+		if m.GC == "" && strings.Contains(m.Msg, "Billing Information") {
+			receipts++
+		}
+	}
+	if c.pmOnly && gc != "" {
+		if len(sent) != 0 {
+			t.Errorf("%s answered in %s: %+v", c.cmdline, gc, sent)
+		}
+		return
+	}
+	if len(sent) == 0 {
+		t.Errorf("%s got no answer", c.cmdline)
+	}
+	if !generates && !c.delivers {
+		return
+	}
+	if toOrigin == 0 {
+		t.Errorf("%s: nothing was sent to where it was requested", c.cmdline)
+	}
+	if results == 0 {
+		t.Errorf("%s: the result was not delivered to where it was requested", c.cmdline)
+	}
+	if generates && gc == "" && receipts == 0 {
+		t.Errorf("%s by PM: no billing receipt", c.cmdline)
+	}
+}
+
+func TestPMGCParity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs every command against fakes")
+	}
+	h := newParityHarness(t)
+	png, svg, mp4, mp3 := h.fal.file("in.png"), h.fal.file("in.svg"), h.fal.file("in.mp4"), h.fal.file("in.mp3")
+
+	// Each registered command, in the order they run; later ones may use the
+	// results of earlier ones
+	cases := []parityCase{
+		{cmdline: "!help"},
+		{cmdline: "!commands"},
+		{cmdline: "!about"},
+		{cmdline: "!listmodels"},
+		{cmdline: "!models"},
+		{cmdline: "!setmodel"},
+		{cmdline: "!balance", pmOnly: true},
+		{cmdline: "!topup"},
+		{cmdline: "!withdraw"},
+		{cmdline: "!rate"},
+		{cmdline: "!estimate text2image a lighthouse"},
+		{cmdline: "!confirm"},
+		{cmdline: "!notify"},
+		{cmdline: "!settings"},
+		{cmdline: "!set"},
+		{cmdline: "!unset"},
+		{cmdline: "!mute"},
+		{cmdline: "!unmute"},
+		{cmdline: "!limits"},
+		{cmdline: "!queue"},
+		{cmdline: "!cancel"},
+		{cmdline: "!status"},
+		{cmdline: "!pot"},
+		{cmdline: "!promo"},
+		{cmdline: "!prompt"},
+		{cmdline: "!schedule"},
+		{cmdline: "!leaderboard"},
+		{cmdline: "!admin"},
+		{cmdline: "!ai hello"},
+		{cmdline: "!text2image a lighthouse at dusk", generates: true},
+		// The GC pot pays half; --split is refused by PM
+		{cmdline: "!text2image a lighthouse at dawn --split 50", generates: true, gcOnly: true},
+		{cmdline: "!variations last", generates: true},
+		{cmdline: "!last"},
+		{cmdline: "!image2image " + png + " make it snowy", generates: true},
+		{cmdline: "!restore " + png, generates: true},
+		// The ceiling stops the chain after codeformer ($0.03)
+		{cmdline: "!restore " + png + " --max-cost 0.04", generates: true},
+		{cmdline: "!removebg " + png, generates: true},
+		{cmdline: "!inpaint " + png + " " + png + " a red door", generates: true},
+		{cmdline: "!animate-svg " + svg, delivers: true},
+		{cmdline: "!describe " + png, generates: true},
+		{cmdline: "!image23d " + png, generates: true},
+		{cmdline: "!text2video waves on a beach", generates: true},
+		{cmdline: "!image2video " + png + " the waves roll in", generates: true},
+		{cmdline: "!video2video " + mp4 + " make it noir", generates: true},
+		{cmdline: "!multi2video a dance --image1 " + png, generates: true},
+		{cmdline: "!redeliver"},
+		{cmdline: "!resend"},
+		{cmdline: "!share"},
+		{cmdline: "!refund"},
+		{cmdline: "!text2speech hello there", generates: true},
+		{cmdline: "!cleanaudio " + mp3, generates: true},
+		{cmdline: "!voiceswap"},
+		{cmdline: "!voiceswap " + audioNote(3), generates: true},
+		{cmdline: "!speech2text " + mp3, generates: true},
+		{cmdline: "!transcribe"},
+		// Transcribes the note posted in the GC before the cases run
+		{cmdline: "!transcribe last", generates: true, gcOnly: true},
+		{cmdline: "!chat what is decred", generates: true},
+		{cmdline: "!batch"},
+		{cmdline: "!batch text2image\na red boat\na blue boat", generates: true},
+	}
+
+	covered := make(map[string]bool)
+	for _, c := range cases {
+		cmd, _, _ := commands.IsCommand(c.cmdline)
+		covered[cmd] = true
+	}
+	for name := range h.registry.GetAll() {
+		if !covered[name] {
+			t.Errorf("!%s is not audited; add it to the cases", name)
+		}
+	}
+
+	if sent := h.run(t, parityGC, audioNote(3)); len(sent) != 0 {
+		t.Fatalf("answered an audio note: %+v", sent)
+	}
+
+	potUID := database.GCPotUID(parityGC)
+	for _, c := range cases {
+		for _, gc := range []string{"", parityGC} {
+			before, err := h.db.GetBalance(h.uid)
+			if err != nil {
+				t.Fatalf("GetBalance: %v", err)
+			}
+			potBefore, err := h.db.GetBalance(potUID)
+			if err != nil {
+				t.Fatalf("GetBalance: %v", err)
+			}
+			sent := h.run(t, gc, c.cmdline)
+			h.checkDestinations(t, gc, c, sent)
+			submitted := h.fal.submitted()
+			after, err := h.db.GetBalance(h.uid)
+			if err != nil {
+				t.Fatalf("GetBalance: %v", err)
+			}
+			potAfter, err := h.db.GetBalance(potUID)
+			if err != nil {
+				t.Fatalf("GetBalance: %v", err)
+			}
+			generates := c.generatesIn(gc)
+			if generates && submitted == 0 {
+				t.Errorf("%s (gc %q) did not reach fal", c.cmdline, gc)
+			}
+			if c.delivers && submitted != 0 {
+				t.Errorf("%s (gc %q) reached fal", c.cmdline, gc)
+			}
+			if generates && after >= before {
+				t.Errorf("%s (gc %q) was not charged: balance %d → %d", c.cmdline, gc, before, after)
+			}
+			if !generates && after != before {
+				t.Errorf("%s (gc %q) changed the balance: %d → %d", c.cmdline, gc, before, after)
+			}
+			split := generates && strings.Contains(c.cmdline, "--split")
+			if split && potAfter >= potBefore {
+				t.Errorf("%s (gc %q) was not charged to the pot: pot balance %d → %d", c.cmdline, gc, potBefore, potAfter)
+			}
+			if !split && potAfter != potBefore {
+				t.Errorf("%s (gc %q) changed the pot balance: %d → %d", c.cmdline, gc, potBefore, potAfter)
+			}
+		}
+	}
+}
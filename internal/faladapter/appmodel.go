@@ -12,6 +12,9 @@ type AppModel struct {
 	fal.Model
 	PriceUSD         float64
 	PerSecondPricing bool
+	PerThousandChars bool
+	PerMillionTokens bool
+	BasePriceUSD     float64
 	MaxTextChars     int
 	HelpDoc          string
 }
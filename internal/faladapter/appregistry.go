@@ -2,6 +2,7 @@ package faladapter
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/karamble/braibot/pkg/fal"
 )
@@ -10,10 +11,23 @@ import (
 type appModelMeta struct {
 	PriceUSD         float64
 	PerSecondPricing bool
+	// PerThousandChars bills PriceUSD per 1000 characters of input text
+	// instead of per request.
+	PerThousandChars bool
+	// PerMillionTokens bills PriceUSD per million tokens of prompt and
+	// reply, for language models.
+	PerMillionTokens bool
+	// BasePriceUSD is a flat fee added to the per-second or per-character
+	// cost of a request.
+	BasePriceUSD float64
 	// MaxTextChars caps accepted text length for per-character upstream
 	// billing (0 = no cap), so a flat resale price keeps its margin.
 	MaxTextChars int
 	HelpDoc      string
+	// Description replaces the fal model's description when set.
+	Description string
+	// Disabled hides the model from every command.
+	Disabled bool
 }
 
 var (
@@ -27,10 +41,13 @@ var (
 		"audio2text":  "elevenlabs/speech-to-text/scribe-v2",
 		"video2video": "kling-video-o3-edit",
 		"multi2video": "seedance-2.0-reference",
+		"text2text":   "any-llm",
+		"image23d":    "triposr",
 	}
 
 	// userModels stores per-user model selections: map[userID]map[modelType]modelName
-	userModels = make(map[string]map[string]string)
+	userModels   = make(map[string]map[string]string)
+	userModelsMu sync.RWMutex
 
 	// modelMeta maps model name → braibot-specific metadata (pricing, help docs).
 	modelMeta = map[string]appModelMeta{
@@ -39,6 +56,7 @@ var (
 		"hidream-i1-full": {PriceUSD: 0.10, HelpDoc: "Usage: !text2image [prompt] [--option value]...\nExample: !text2image a futuristic city --negative_prompt blur --guidance_scale 7\n\nParameters:\n• prompt: Text description (required)\n• --negative_prompt: Things to avoid (optional, default: \"\")\n• --image_size: Output dimensions (default: square_hd). Options: square_hd, square, portrait_4_3, portrait_16_9, landscape_4_3, landscape_16_9\n• --num_inference_steps: Number of steps (default: 50)\n• --seed: Specific seed (optional)\n• --guidance_scale: Prompt adherence (default: 5.0)\n• --num_images: Number of images (default: 1, max: 4)\n• --enable_safety_checker: Enable safety filter (default: true)\n• --output_format: jpeg, png (default: jpeg)"},
 		"hidream-i1-dev": {PriceUSD: 0.06, HelpDoc: "Usage: !text2image [prompt] [--option value]...\nExample: !text2image a futuristic city --negative_prompt blur\n\nParameters:\n• prompt: Text description (required)\n• --negative_prompt: Things to avoid (optional, default: \"\")\n• --image_size: Output dimensions (default: square_hd). Options: square_hd, square, portrait_4_3, portrait_16_9, landscape_4_3, landscape_16_9\n• --num_inference_steps: Number of steps (default: 28)\n• --seed: Specific seed (optional)\n• --num_images: Number of images (default: 1, max: 4)\n• --enable_safety_checker: Enable safety filter (default: true)\n• --output_format: jpeg, png (default: jpeg)"},
 		"hidream-i1-fast": {PriceUSD: 0.03, HelpDoc: "Usage: !text2image [prompt] [--option value]...\nExample: !text2image a futuristic city --negative_prompt blur\n\nParameters:\n• prompt: Text description (required)\n• --negative_prompt: Things to avoid (optional, default: \"\")\n• --image_size: Output dimensions (default: square_hd). Options: square_hd, square, portrait_4_3, portrait_16_9, landscape_4_3, landscape_16_9\n• --num_inference_steps: Number of steps (default: 16)\n• --seed: Specific seed (optional)\n• --num_images: Number of images (default: 1, max: 4)\n• --enable_safety_checker: Enable safety filter (default: true)\n• --output_format: jpeg, png (default: jpeg)"},
+		"sdxl-controlnet-union": {PriceUSD: 0.04, HelpDoc: "Usage: !text2image [prompt] --control [type] --control_image [url] [--option value]...\nExample: !text2image a knight in a misty forest --control pose --control_image https://example.com/pose.jpg\n\nGuides the composition from a reference image.\n\nParameters:\n• prompt: Text description (required)\n• --control: Conditioning type (default: canny). Options: canny, depth, pose, normal, segmentation, teed\n• --control_image: Reference image URL (required)\n• --control_scale: Conditioning strength 0-1 (default: 0.5)\n• --negative_prompt: Things to avoid (optional)\n• --image_size: Output dimensions (default: square_hd). Options: square_hd, square, portrait_4_3, portrait_16_9, landscape_4_3, landscape_16_9\n• --num_inference_steps: Number of steps (default: 35)\n• --seed: Specific seed (optional)\n• --guidance_scale: Prompt adherence (default: 7.5)\n• --num_images: Number of images (default: 1, max: 4)\n• --enable_safety_checker: Enable safety filter (default: true)\n• --output_format: jpeg, png (default: jpeg)"},
 		"flux-pro/v1.1": {PriceUSD: 0.08, HelpDoc: "Usage: !text2image [prompt] [--option value]...\nExample: !text2image a hyperrealistic cat --num_images 2 --image_size square\n\nParameters:\n• prompt: Text description of the image (required)\n• --image_size: Output dimensions (default: landscape_4_3). Options: square_hd, square, portrait_4_3, portrait_16_9, landscape_4_3, landscape_16_9\n• --seed: Specific seed for reproducibility (optional)\n• --num_images: Number of images to generate (default: 1, max: 4)\n• --enable_safety_checker: Enable safety filter (default: true). Use --enable_safety_checker=false to disable.\n• --safety_tolerance: Safety strictness (1-6, default: 2)\n• --output_format: Image format (jpeg, png. default: jpeg)"},
 		"flux-pro/v1.1-ultra": {PriceUSD: 0.12, HelpDoc: "Usage: !text2image [prompt] [--option value]...\nExample: !text2image cinematic photo --aspect_ratio 9:16 --raw=true\n\nParameters:\n• prompt: Text description (required)\n• --seed: Specific seed (optional)\n• --num_images: Number of images (default: 1, max: 4)\n• --enable_safety_checker: Enable safety filter (default: true)\n• --safety_tolerance: Safety strictness (1-6, default: 2)\n• --output_format: jpeg, png (default: jpeg)\n• --aspect_ratio: Output aspect ratio (default: 16:9). Options: 21:9, 16:9, 4:3, 3:2, 1:1, 2:3, 3:4, 9:16, 9:21\n• --raw: Generate less processed image (default: false)"},
 		"flux/schnell": {PriceUSD: 0.02, HelpDoc: "Usage: !text2image [prompt] [--option value]...\nExample: !text2image a hyperrealistic cat --num_images 2 --image_size square\n\nParameters:\n• prompt: Text description of the image (required)\n• --image_size: Output dimensions (default: landscape_4_3). Options: square_hd, square, portrait_4_3, portrait_16_9, landscape_4_3, landscape_16_9\n• --num_inference_steps: Number of steps (default: 4)\n• --seed: Specific seed for reproducibility (optional)\n• --num_images: Number of images to generate (default: 1, max: 4)\n• --enable_safety_checker: Enable safety filter (default: true). Use --enable_safety_checker=false to disable."},
@@ -54,6 +72,12 @@ var (
 		"flux-2/edit": {PriceUSD: 0.06, HelpDoc: "Usage: !image2image [image_url] [prompt]\nExample: !image2image https://example.com/photo.jpg Add sunglasses to the person\n\nParameters:\n• image_url: URL of the source image (required, max 4 images)\n• prompt: Description of the desired edit (required)\n• --image_size: Output dimensions (default: landscape_4_3). Options: square_hd, square, portrait_4_3, portrait_16_9, landscape_4_3, landscape_16_9\n• --guidance_scale: Prompt adherence (default: 2.5)\n• --num_inference_steps: Number of steps (default: 28)\n• --seed: Specific seed for reproducibility (optional)\n• --num_images: Number of images to generate (default: 1, max: 4)\n• --acceleration: Speed level: none, regular, high (default: regular)\n• --enable_prompt_expansion: Expand prompt for better results (default: false)\n• --enable_safety_checker: Enable safety filter (default: true)\n• --output_format: Image format (jpeg, png, webp. default: png)"},
 		"flux-2-pro/edit": {PriceUSD: 0.09, HelpDoc: "Usage: !image2image [image_url] [prompt]\nExample: !image2image https://example.com/photo.jpg Place realistic flames emerging from the top of the coffee cup\n\nParameters:\n• image_url: URL of the source image (required)\n• prompt: Description of the desired edit (required)\n• --image_size: Output dimensions (default: auto). Options: auto, square_hd, square, portrait_4_3, portrait_16_9, landscape_4_3, landscape_16_9\n• --seed: Specific seed for reproducibility (optional)\n• --enable_safety_checker: Enable safety filter (default: true)\n• --safety_tolerance: Safety strictness (1-5, default: 2)\n• --output_format: Image format (jpeg, png. default: jpeg)"},
 		"nano-banana-2/edit": {PriceUSD: 0.20, HelpDoc: "Usage: !image2image [image_url] [prompt] [--option value]...\nExample: !image2image https://example.com/photo.jpg make it a watercolor painting\n\n\U0001f4b0 **Price: $0.20 per image\n\nParameters:\n\u2022 image_url: URL of the source image (required)\n\u2022 prompt: Description of the desired edit (required)\n\u2022 --aspect_ratio: auto, 21:9, 16:9, 3:2, 4:3, 5:4, 1:1, 4:5, 3:4, 2:3, 9:16, 4:1, 1:4, 8:1, 1:8 (default: auto)\n\u2022 --num_images: Number of images (default: 1, max: 4)\n\u2022 --resolution: 0.5K, 1K, 2K, 4K (default: 1K)\n\u2022 --output_format: png, jpeg, webp (default: jpeg)\n\u2022 --seed: Specific seed (optional)"},
+		"ddcolor":    {PriceUSD: 0.03, HelpDoc: "Usage: !image2image [image_url] [--option value]...\nExample: !image2image https://example.com/old-photo.jpg\n\nColorizes black and white photos. Use !restore --colorize true to colorize, restore and upscale in one go.\n\nParameters:\n• image_url: URL of the black and white photo (required)\n• --seed: Specific seed (optional)"},
+		"codeformer": {PriceUSD: 0.03, HelpDoc: "Usage: !image2image [image_url] [--option value]...\nExample: !image2image https://example.com/old-photo.jpg --fidelity 0.7 --scale 2\n\nRestores faces in old, blurry or damaged photos.\n\nParameters:\n• image_url: URL of the photo to restore (required)\n• --fidelity: 0 (best quality) to 1 (most faithful to the input). Default: 0.5\n• --scale: Upscaling factor 1-4 (default: 2)\n• --seed: Specific seed (optional)"},
+		"esrgan":     {PriceUSD: 0.02, HelpDoc: "Usage: !image2image [image_url] [--option value]...\nExample: !image2image https://example.com/photo.jpg --scale 4 --face true\n\nUpscales images with Real-ESRGAN.\n\nParameters:\n• image_url: URL of the image to upscale (required)\n• --scale: Upscale factor 1-8 (default: 2)\n• --face: Apply face enhancement (default: false)\n• --output_format: png, jpeg (default: png)"},
+
+		"birefnet":         {PriceUSD: 0.02, HelpDoc: "Usage: !removebg [image_url] [--option value]...\nExample: !removebg https://example.com/product.jpg --variant portrait\n\nRemoves the background, leaving the subject on a transparent background.\n\nParameters:\n• image_url: URL of the image (required)\n• --variant: light, light2k, heavy, matting, portrait (default: light)\n• --resolution: 1024x1024, 2048x2048 (default: 1024x1024)\n• --output_format: png, webp (default: png)"},
+		"flux-pro/v1/fill": {PriceUSD: 0.06, HelpDoc: "Usage: !inpaint [image_url] [mask_url] [prompt] [--option value]...\nExample: !inpaint https://example.com/room.jpg https://example.com/mask.png a red leather armchair\n\nRepaints the white areas of the mask from the prompt and keeps the rest of the image.\n\nParameters:\n• image_url: URL of the image to edit (required)\n• mask_url: URL of a black and white mask the size of the image; white marks the area to repaint (required)\n• prompt: What to paint into the masked area (required)\n• --seed: Specific seed (optional)\n• --output_format: jpeg, png (default: jpeg)"},
 
 		// ── text2video ──────────────────────────────────────────
 		"kling-video-text":            {PriceUSD: 0.4, PerSecondPricing: true, HelpDoc: "Usage: !text2video [prompt] [options]\n\n\U0001f4b0 **Price: $0.40 per video."},
@@ -85,20 +109,22 @@ var (
 
 		"seedance-2.0-fast-image":             {PriceUSD: 0.40, PerSecondPricing: true, HelpDoc: "Usage: !image2video [image_url] [prompt] [options]\n\n\U0001f4b0 **Price: $0.40 per second**\nExample: A 5-second video will cost $2.00.\nTotal cost = price per second \u00d7 duration.\n\nParameters:\n\u2022 image_url: URL of the source image (required)\n\u2022 prompt: Description of the desired motion/action (required)\n\u2022 --duration: Video duration in seconds (4-15, default: 5)\n\u2022 --aspect: Aspect ratio (auto, 21:9, 16:9, 4:3, 1:1, 3:4, 9:16). Default: auto\n\u2022 --resolution: Video resolution (480p, 720p). Default: 720p\n\u2022 --audio: Enable audio generation (default: true)\n\u2022 --seed: Seed for reproducibility (optional)"},
 		// ── video2video ─────────────────────────────────────────
-		"topaz-upscale-video":            {PriceUSD: 2.00, HelpDoc: "Usage: !video2video [video_url] [options]\n\n\U0001f4b0 **Price: $2.00 per video\n\nParameters:\n• video_url: URL of the video to upscale\n• --model: Upscaling model (default: auto)\n• --output_type: Output format mp4 or mov (default: mp4)"},
-		"sync-lipsync-v2":                {PriceUSD: 0.10, PerSecondPricing: true, HelpDoc: "Usage: !video2video [video_url] [audio_url] [options]\n\n\U0001f4b0 **Price: $0.10 per second\n\nParameters:\n• video_url: URL of the video with face\n• audio_url: URL of the audio to sync\n• --model: wav2lip or wav2lip_gan (default: wav2lip)\n• --output_type: Output format mp4 or webm (default: mp4)"},
-		"kling-video-v26-motion-control": {PriceUSD: 0.10, PerSecondPricing: true, HelpDoc: "Usage: !video2video [image_url] [video_url] [options]\n\n\U0001f4b0 **Price: $0.10 per second\n\nParameters:\n• image_url: Reference image URL (character/background source)\n• video_url: Reference video URL (motion source)\n• --prompt: Text description (optional)\n• --orientation: 'image' (max 10s) or 'video' (max 30s). Default: video\n• --keep-sound: Keep original audio (default: true)\n\nConstraints:\n• Character must occupy >5% of image with visible body"},
+		"topaz-upscale-video":            {PriceUSD: 2.00, HelpDoc: "Usage: !video2video [video_url] [options]\n\n\U0001f4b0 **Price: $2.00 per video\n\nParameters:\n• video_url: URL of the video to upscale\n• --model: Topaz model such as Proteus, Artemis, Nyx, Gaia or Starlight (default: Proteus)\n• --output_type: Output format mp4 or mov (default: mp4)"},
+		"sync-lipsync-v2":                {PriceUSD: 0.10, PerSecondPricing: true, HelpDoc: "Usage: !video2video [video_url] [audio_url] [options]\n\n\U0001f4b0 **Price: $0.10 per second\n\nParameters:\n• video_url: URL of the video with face\n• audio_url: URL of the audio to sync\n• --model: lipsync-2 or lipsync-2-pro (default: lipsync-2)\n• --audio: Audio URL, instead of the second positional URL\n• --output_type: Output format mp4 or webm (default: mp4)"},
+		"kling-video-v26-motion-control": {PriceUSD: 0.10, PerSecondPricing: true, HelpDoc: "Usage: !video2video [video_url] [image_url] [prompt] [options]\n\n\U0001f4b0 **Price: $0.10 per second\n\nParameters:\n• video_url: Reference video URL (motion source)\n• image_url: Reference image URL (character/background source), or --image\n• prompt: Text description (optional)\n• --orientation: 'image' (max 10s) or 'video' (max 30s). Default: video\n• --keep-sound: Keep original audio (default: true)\n\nConstraints:\n• Character must occupy >5% of image with visible body"},
 		"kling-video-o3-edit":            {PriceUSD: 0.30, PerSecondPricing: true, HelpDoc: "Usage: !video2video [video_url] [prompt] [options]\n\n\U0001f4b0 **Price: $0.30 per second**\nExample: A 5-second video will cost $1.50.\nTotal cost = price per second \u00d7 duration.\n\nParameters:\n• video_url: URL of the source video (.mp4/.mov, 3-10s, 720-2160px)\n• prompt: Edit description (required, use @Image1-4 to reference images)\n• --keep_audio: Keep original audio (default: true)\n• --image1..--image4: Up to 4 reference image URLs\n• --duration: Duration for billing estimation (default: 5)"},
 		"kling-video-o3-pro-edit":        {PriceUSD: 0.39, PerSecondPricing: true, HelpDoc: "Usage: !video2video [video_url] [prompt] [options]\n\n\U0001f4b0 **Price: $0.39 per second**\nExample: A 5-second video will cost $1.95.\nTotal cost = price per second \u00d7 duration.\n\nParameters:\n• video_url: URL of the source video (.mp4/.mov, 3-10s, 720-2160px)\n• prompt: Edit description (required, use @Image1-4 to reference images)\n• --keep_audio: Keep original audio (default: true)\n• --image1..--image4: Up to 4 reference image URLs\n• --duration: Duration for billing estimation (default: 5)"},
+		"rife-video":                     {PriceUSD: 0.10, HelpDoc: "Usage: !video2video [video_url] [options]\nExample: !video2video https://example.com/clip.mp4 --factor 4 --slowmo true\n\n\U0001f4b0 **Price: $0.10 per video**\n\nParameters:\n• video_url: URL of the source video (required)\n• --factor: Frame multiplier 2 or 4 (default: 2)\n• --slowmo: Keep the original frame rate so the clip plays 2x/4x slower (default: false, output is smoothed at a higher frame rate)"},
+		"film-video":                     {PriceUSD: 0.15, HelpDoc: "Usage: !video2video [video_url] [options]\nExample: !video2video https://example.com/clip.mp4 --factor 2\n\n\U0001f4b0 **Price: $0.15 per video**\n\nParameters:\n• video_url: URL of the source video (required)\n• --factor: Frame multiplier 2 or 4 (default: 2)\n• --slowmo: Keep the original frame rate so the clip plays 2x/4x slower (default: false, output is smoothed at a higher frame rate)\n\nNote: FILM handles large motion better than RIFE but is slower."},
 
 		// ── multi2video ─────────────────────────────────────────
 		"seedance-2.0-reference": {PriceUSD: 0.80, PerSecondPricing: true, HelpDoc: "Usage: !multi2video [prompt] [options]\n\n\U0001f4b0 **Price: $0.80 per second**\nExample: A 5-second video will cost $4.00.\nTotal cost = price per second \u00d7 duration.\n\nParameters:\n• prompt: Text description of the desired video (required)\n• --image1..--image9: Reference image URLs (up to 9, JPEG/PNG/WebP, max 30MB each)\n• --video1..--video3: Reference video URLs (up to 3, MP4/MOV, 2-15s combined duration, <50MB total, 480p-720p)\n• --audio1..--audio3: Reference audio URLs (up to 3, MP3/WAV, \u226415s combined, max 15MB each)\n• --duration: Output video duration in seconds (4-15, default: 5)\n• --aspect: Aspect ratio (auto, 21:9, 16:9, 4:3, 1:1, 3:4, 9:16). Default: auto\n• --resolution: Output video resolution (480p, 720p). Default: 720p\n• --audio: Enable generated audio output (default: true)\n• --seed: Seed for reproducibility (optional)\n\nConstraints:\n• At least one reference input (image, video, or audio) is required\n• Total reference files must not exceed 12\n• Reference audio requires at least one reference image or video"},
 
 		// ── text2speech ─────────────────────────────────────────
-		"minimax-tts/text-to-speech": {PriceUSD: 0.10, MaxTextChars: 800, HelpDoc: "Usage: !text2speech [text] --voice_id [voice_id] [--option value]...\nExample: !text2speech Hello world --voice_id Wise_Woman --speed 0.8 --format flac\n\nParameters:\n• text: Text to convert to speech (required, max 800 chars)\n• --voice_id: Voice ID to use (defaults to Wise_Woman if not specified). See list below.\n• --speed: Speech speed (0.5-2.0, default: 1.0)\n• --vol: Volume (0-10, default: 1.0)\n• --pitch: Voice pitch (-12 to 12, optional)\n• --emotion: happy, sad, angry, fearful, disgusted, surprised, neutral (optional)\n• --sample_rate: 8000, 16000, 22050, 24000, 32000, 44100 (default: 32000)\n• --bitrate: 32000, 64000, 128000, 256000 (default: 128000)\n• --format: mp3, pcm, flac (default: mp3)\n• --channel: 1 (mono), 2 (stereo) (default: 1)\n\nAvailable Voices:\n• Wise_Woman, Friendly_Person, Inspirational_girl\n• Deep_Voice_Man, Calm_Woman, Casual_Guy\n• Lively_Girl, Patient_Man, Young_Knight\n• Determined_Man, Lovely_Girl, Decent_Boy\n• Imposing_Manner, Elegant_Man, Abbess\n• Sweet_Girl_2, Exuberant_Girl"},
+		"minimax-tts/text-to-speech": {PriceUSD: 0.10, PerThousandChars: true, MaxTextChars: 800, HelpDoc: "Usage: !text2speech [text] --voice_id [voice_id] [--option value]...\nExample: !text2speech Hello world --voice_id Wise_Woman --speed 0.8 --format flac\n\n\U0001f4b0 **Price: $0.10 per 1000 characters\n\nParameters:\n• text: Text to convert to speech (required, max 800 chars)\n• --voice_id: Voice ID to use (defaults to Wise_Woman if not specified). See list below.\n• --speed: Speech speed (0.5-2.0, default: 1.0)\n• --vol: Volume (0-10, default: 1.0)\n• --pitch: Voice pitch (-12 to 12, optional)\n• --emotion: happy, sad, angry, fearful, disgusted, surprised, neutral (optional)\n• --sample_rate: 8000, 16000, 22050, 24000, 32000, 44100 (default: 32000)\n• --bitrate: 32000, 64000, 128000, 256000 (default: 128000)\n• --format: mp3, pcm, flac (default: mp3)\n• --channel: 1 (mono), 2 (stereo) (default: 1)\n\nAvailable Voices:\n• Wise_Woman, Friendly_Person, Inspirational_girl\n• Deep_Voice_Man, Calm_Woman, Casual_Guy\n• Lively_Girl, Patient_Man, Young_Knight\n• Determined_Man, Lovely_Girl, Decent_Boy\n• Imposing_Manner, Elegant_Man, Abbess\n• Sweet_Girl_2, Exuberant_Girl"},
 		"chatterbox-tts":             {PriceUSD: 0.05, MaxTextChars: 2000, HelpDoc: "Usage: !text2speech [text] [options]\n\n\U0001f4b0 **Price: $0.05 per message\n\nParameters:\n• text: Text to convert to speech (required, max 2000 chars)\n• --audio_prompt_url: Reference audio URL for voice cloning (optional)\n• --exaggeration: Expression intensity 0-1 (default: 0.5)\n• --cfg_weight: Adherence to prompt 0-1 (default: 0.5)"},
 		"elevenlabs-dialog":          {PriceUSD: 0.30, MaxTextChars: 2400, HelpDoc: "Usage: !text2speech [text] [options]\n\n\U0001f4b0 **Price: $0.30 per message\n\nParameters:\n• text: Dialogue text with speaker labels (required, max 2400 chars)\n• --voice_id: Voice ID (default: Rachel)\n• --output_format: Audio format (default: mp3_22050_32)\n• --stability: Voice stability 0-1 (default: 0.5)\n• --similarity_boost: Voice similarity 0-1 (default: 0.75)"},
-		"elevenlabs/tts/turbo-v2.5":  {PriceUSD: 0.05, MaxTextChars: 800, HelpDoc: "Usage: !text2speech [text] [options]\n\n\U0001f4b0 **Price: $0.05 per message\n\nParameters:\n• text: Text to convert to speech (required, max 800 chars)\n• --voice: Voice name (default: Rachel)\n• --stability: Voice stability 0-1 (default: 0.5)\n• --similarity_boost: Voice similarity 0-1 (default: 0.75)\n• --style: Style exaggeration 0-1 (default: 0.0)\n• --speed: Speech speed 0.25-4.0 (default: 1.0)\n• --language_code: Language code (optional)\n\nAvailable Voices:\n• Aria, Roger, Sarah, Laura, Charlie, George, Callum\n• River, Liam, Charlotte, Alice, Matilda, Will, Jessica\n• Eric, Chris, Brian, Daniel, Lily, Bill"},
+		"elevenlabs/tts/turbo-v2.5":  {PriceUSD: 0.05, PerThousandChars: true, MaxTextChars: 800, HelpDoc: "Usage: !text2speech [text] [options]\n\n\U0001f4b0 **Price: $0.05 per 1000 characters\n\nParameters:\n• text: Text to convert to speech (required, max 800 chars)\n• --voice: Voice name (default: Rachel)\n• --stability: Voice stability 0-1 (default: 0.5)\n• --similarity_boost: Voice similarity 0-1 (default: 0.75)\n• --style: Style exaggeration 0-1 (default: 0.0)\n• --speed: Speech speed 0.25-4.0 (default: 1.0)\n• --language_code: Language code (optional)\n\nAvailable Voices:\n• Aria, Roger, Sarah, Laura, Charlie, George, Callum\n• River, Liam, Charlotte, Alice, Matilda, Will, Jessica\n• Eric, Chris, Brian, Daniel, Lily, Bill"},
 
 		// ── audio2text ──────────────────────────────────────────
 		"elevenlabs/speech-to-text/scribe-v2": {PriceUSD: 0.001, PerSecondPricing: true, HelpDoc: "Usage: Transcribe audio to text with word-level timestamps\n\nPrice: $0.001 per second of audio ($0.06 per minute)\n\nParameters:\n- audio_url: URL to audio file (required)\n- task: transcribe (default) or translate\n- language: ISO 639-1 code (auto-detected if not specified)\n- chunk_level: segment (default) or word\n- diarize: Enable speaker diarization (default: true)\n- num_speakers: Number of speakers (optional, 1-50)\n\nSupported formats: mp3, wav, m4a, ogg, flac, webm"},
@@ -108,32 +134,52 @@ var (
 		"stable-audio-25":  {PriceUSD: 0.02, PerSecondPricing: true, HelpDoc: "Usage: !text2music [prompt] [options]\n\n\U0001f4b0 **Price: $0.02 per second of audio\n\nParameters:\n• prompt: Description of the audio (required)\n• --duration: Duration in seconds 1-180 (default: 30)\n• --sample_rate: Sample rate (default: 44100)\n• --output_format: wav, mp3, ogg (default: wav)\n• --seed: Specific seed (optional)"},
 
 		// ── audio2audio ─────────────────────────────────────────
-		"elevenlabs-voice-changer": {PriceUSD: 0.02, PerSecondPricing: true, HelpDoc: "Usage: !audio2audio [audio_url] [options]\n\nPrice: $0.02 per second of audio ($1.20 per minute)\n\nParameters:\n- audio_url: URL of audio to transform (required)\n- --voice: Voice name (default: Rachel)\n- --remove_background_noise: Remove background noise (optional)\n- --seed: Random seed for reproducibility (optional)\n- --output_format: Output format (default: mp3_44100_128)\n\nAvailable Voices:\n- Aria, Roger, Sarah, Laura, Charlie, George, Callum\n- River, Liam, Charlotte, Alice, Matilda, Will, Jessica\n- Eric, Chris, Brian, Daniel, Lily, Bill, Rachel"},
+		"elevenlabs-voice-changer":   {PriceUSD: 0.02, PerSecondPricing: true, HelpDoc: "Usage: !voiceswap [voice] [--denoise], with an audio note attached\nExample: !voiceswap George\n\nPrice: $0.02 per second of audio ($1.20 per minute)\n\nParameters:\n- voice: Voice to speak in (default: Rachel)\n- --denoise: Remove background noise from the note first\n\nAvailable Voices:\n- Aria, Roger, Sarah, Laura, Charlie, George, Callum\n- River, Liam, Charlotte, Alice, Matilda, Will, Jessica\n- Eric, Chris, Brian, Daniel, Lily, Bill, Rachel"},
+		"elevenlabs-audio-isolation": {PriceUSD: 0.05, HelpDoc: "Usage: !cleanaudio [audio_url]\nOr attach an audio note to the !cleanaudio message.\n\nPrice: $0.05 per clip\n\nIsolates voices and removes background noise. The cleaned audio is returned as an embed, which makes it a good preprocessing step before transcription or lipsync.\n\nParameters:\n- audio_url: URL of the audio to clean (required unless an audio note is attached)"},
+
+		// ── text2text ───────────────────────────────────────────
+		"any-llm": {PriceUSD: 2.00, PerMillionTokens: true, BasePriceUSD: 0.001, HelpDoc: "Usage: !chat [message]\nExample: !chat explain proof of stake in two sentences\n\n\U0001f4b0 **Price: $2.00 per million tokens of your conversation and the reply, plus $0.001 per message\n\nThe bot remembers the last messages of your conversation. Use !chat reset to start over.\n\nParameters:\n• message: What to say (required)"},
+
+		// ── image2text ──────────────────────────────────────────
+		"llava-next": {PriceUSD: 0.01, HelpDoc: "Usage: !describe [image_url|last]\nExample: !describe https://example.com/photo.jpg\n\n\U0001f4b0 **Price: $0.01 per image\n\nReturns a caption and tags for the image, ready to use as a prompt for !text2image or !image2video.\n\nParameters:\n• image_url: URL of the image to describe, or last for your newest image (required)"},
+
+		// ── image23d ────────────────────────────────────────────
+		"triposr": {PriceUSD: 0.07, HelpDoc: "Usage: !image23d [image_url|last] [--option value]...\nExample: !image23d https://example.com/chair.png --format obj\n\n\U0001f4b0 **Price: $0.07 per model\n\nTurns a photo of an object into a 3D model, sent as a file. Works best with a single object on a plain background.\n\nParameters:\n• image_url: URL of the image, or last for your newest image (required)\n• --format: 3D file format: glb, obj (default: glb)\n• --remove_background: Cut the object out first (default: true)\n• --foreground_ratio: Share of the frame the object fills, 0.5-1 (default: 0.9)\n• --mc_resolution: Mesh resolution, 32-1024 (default: 256)"},
+		"hunyuan3d-v2": {PriceUSD: 0.16, HelpDoc: "Usage: !image23d [image_url|last] [--option value]...\nExample: !image23d https://example.com/statue.png --textured\n\n\U0001f4b0 **Price: $0.16 per model, $0.48 textured\n\nTurns an image into a detailed 3D model, sent as a GLB file.\n\nParameters:\n• image_url: URL of the image, or last for your newest image (required)\n• --textured: Paint textures onto the mesh, at three times the price (default: false)\n• --seed: Specific seed (optional)\n• --num_inference_steps: Number of steps, 1-50 (default: 50)\n• --guidance_scale: Image adherence, 0-20 (default: 7.5)\n• --octree_resolution: Mesh resolution, 1-1024 (default: 256)"},
 
 		// ── video2audio ─────────────────────────────────────────
 		"mmaudio-v2": {PriceUSD: 0.20, HelpDoc: "Usage: !video2audio [video_url] [prompt] [options]\n\n\U0001f4b0 **Price: $0.20 per video\n\nParameters:\n• video_url: URL of the source video\n• prompt: Description of the desired audio (optional)\n• --duration: Output duration in seconds (default: video duration)\n• --num_inference_steps: Number of steps (default: 25)\n• --seed: Specific seed (optional)"},
 	}
 )
 
-// mergeAppModel combines a fal.Model with its braibot-specific metadata.
+// mergeAppModel combines a fal.Model with its braibot-specific metadata
+// and any catalog override.
 func mergeAppModel(m fal.Model) AppModel {
-	meta, ok := modelMeta[m.Name]
-	if !ok {
-		return AppModel{Model: m}
-	}
-	return AppModel{
-		Model:            m,
-		PriceUSD:         meta.PriceUSD,
-		PerSecondPricing: meta.PerSecondPricing,
-		MaxTextChars:     meta.MaxTextChars,
-		HelpDoc:          meta.HelpDoc,
+	am := AppModel{Model: m}
+	if meta, ok := modelMeta[m.Name]; ok {
+		am.PriceUSD = meta.PriceUSD
+		am.PerSecondPricing = meta.PerSecondPricing
+		am.PerThousandChars = meta.PerThousandChars
+		am.PerMillionTokens = meta.PerMillionTokens
+		am.BasePriceUSD = meta.BasePriceUSD
+		am.MaxTextChars = meta.MaxTextChars
+		am.HelpDoc = meta.HelpDoc
+		if meta.Description != "" {
+			am.Description = meta.Description
+		}
 	}
+	overridesMu.RLock()
+	o := overrides[m.Name]
+	overridesMu.RUnlock()
+	o.apply(&am)
+	return am
 }
 
-// GetModel returns an AppModel by name and type.
+// GetModel returns an AppModel by name and type. Models disabled by the
+// catalog are not found.
 func GetModel(name, modelType string) (AppModel, bool) {
 	m, ok := fal.GetModel(name, modelType)
-	if !ok {
+	if !ok || isDisabled(name) {
 		return AppModel{}, false
 	}
 	return mergeAppModel(m), true
@@ -147,6 +193,9 @@ func GetModels(commandType string) (map[string]AppModel, bool) {
 	}
 	result := make(map[string]AppModel, len(falModels))
 	for name, m := range falModels {
+		if isDisabled(name) {
+			continue
+		}
 		result[name] = mergeAppModel(m)
 	}
 	return result, true
@@ -159,39 +208,78 @@ func GetCurrentModel(commandType string, userID string) (AppModel, bool) {
 
 	// Check user-specific model if userID is provided
 	if userID != "" {
+		userModelsMu.RLock()
 		if um, ok := userModels[userID]; ok {
 			if name, ok := um[commandType]; ok {
 				modelName = name
 			}
 		}
+		userModelsMu.RUnlock()
 	}
 
-	// Fall back to global default
-	if modelName == "" {
-		var ok bool
-		modelName, ok = defaultModels[commandType]
-		if !ok {
-			return AppModel{}, false
+	// A pick the catalog has since disabled falls back to the default
+	if modelName != "" {
+		if m, ok := GetModel(modelName, commandType); ok {
+			return m, true
 		}
 	}
 
+	// Fall back to global default
+	modelName, ok := defaultModels[commandType]
+	if !ok {
+		return AppModel{}, false
+	}
 	return GetModel(modelName, commandType)
 }
 
 // SetCurrentModel sets the current model for a command type.
 func SetCurrentModel(commandType, modelName string, userID string) error {
-	// Verify the model exists in the fal registry
-	if _, ok := fal.GetModel(modelName, commandType); !ok {
+	// Verify the model exists in the fal registry and is not disabled
+	if _, ok := GetModel(modelName, commandType); !ok {
 		return fmt.Errorf("model not found: %s", modelName)
 	}
 
 	if userID != "" {
+		userModelsMu.Lock()
 		if _, ok := userModels[userID]; !ok {
 			userModels[userID] = make(map[string]string)
 		}
 		userModels[userID][commandType] = modelName
+		userModelsMu.Unlock()
 	} else {
 		defaultModels[commandType] = modelName
 	}
 	return nil
 }
+
+// LoadUserModels restores per-user model selections saved in the database,
+// keyed by user ID and then command type. Selections of models that are no
+// longer registered are skipped, leaving those users on the default.
+func LoadUserModels(models map[string]map[string]string) {
+	userModelsMu.Lock()
+	defer userModelsMu.Unlock()
+	for userID, selections := range models {
+		for commandType, modelName := range selections {
+			if _, ok := fal.GetModel(modelName, commandType); !ok {
+				adapterLog.Infof("Dropping the %s model selection of %s: %s is no longer available", commandType, userID, modelName)
+				continue
+			}
+			if _, ok := userModels[userID]; !ok {
+				userModels[userID] = make(map[string]string)
+			}
+			userModels[userID][commandType] = modelName
+		}
+	}
+}
+
+// GetAllUserModels returns a copy of the user's model selections by command
+// type. Command types the user never changed are absent.
+func GetAllUserModels(userID string) map[string]string {
+	userModelsMu.RLock()
+	defer userModelsMu.RUnlock()
+	models := make(map[string]string, len(userModels[userID]))
+	for commandType, modelName := range userModels[userID] {
+		models[commandType] = modelName
+	}
+	return models
+}
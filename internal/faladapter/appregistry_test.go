@@ -0,0 +1,133 @@
+package faladapter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+func TestLoadUserModels(t *testing.T) {
+	LoadUserModels(map[string]map[string]string{
+		"loaded-user": {"text2image": "flux/dev", "text2video": "no-such-model"},
+	})
+
+	if m, ok := GetCurrentModel("text2image", "loaded-user"); !ok || m.Name != "flux/dev" {
+		t.Errorf("GetCurrentModel after load = %q, %v; want flux/dev", m.Name, ok)
+	}
+	models := GetAllUserModels("loaded-user")
+	if len(models) != 1 || models["text2image"] != "flux/dev" {
+		t.Errorf("GetAllUserModels = %v; want only the registered text2image pick", models)
+	}
+	// The returned map is a copy
+	models["text2image"] = "flux-2"
+	if m, _ := GetCurrentModel("text2image", "loaded-user"); m.Name != "flux/dev" {
+		t.Errorf("changing the GetAllUserModels result changed the selection to %q", m.Name)
+	}
+	if models := GetAllUserModels("someone-else"); len(models) != 0 {
+		t.Errorf("GetAllUserModels for a user without picks = %v", models)
+	}
+}
+
+func TestModelOverrides(t *testing.T) {
+	defer SetModelOverrides(nil)
+
+	price, disabled := 9.5, true
+	unknown := SetModelOverrides(map[string]ModelOverride{
+		"flux/dev":      {PriceUSD: &price},
+		"flux-2":        {Disabled: &disabled},
+		"no-such-model": {PriceUSD: &price},
+	})
+	if len(unknown) != 1 || unknown[0] != "no-such-model" {
+		t.Errorf("unknown = %v", unknown)
+	}
+	if m, ok := GetModel("flux/dev", "text2image"); !ok || m.PriceUSD != 9.5 || m.HelpDoc == "" {
+		t.Errorf("GetModel(flux/dev) = %+v, %v; want the overridden price and built-in help", m, ok)
+	}
+	if models, _ := GetModels("text2image"); models["flux-2"].Name != "" {
+		t.Error("GetModels lists the disabled flux-2")
+	}
+	if err := SetCurrentModel("text2image", "flux-2", "override-user"); err == nil {
+		t.Error("SetCurrentModel accepted the disabled flux-2")
+	}
+
+	// A user whose pick is disabled later falls back to the default
+	LoadUserModels(map[string]map[string]string{"override-user": {"text2image": "flux-2"}})
+	if m, ok := GetCurrentModel("text2image", "override-user"); !ok || m.Name != defaultModels["text2image"] {
+		t.Errorf("GetCurrentModel = %q, %v; want the default", m.Name, ok)
+	}
+}
+
+func TestLoadDefinitions(t *testing.T) {
+	saved := modelMeta["flux/schnell"]
+	defer func() {
+		modelMeta["flux/schnell"] = saved
+		delete(providerRoutes, "flux/schnell")
+	}()
+
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	if added, changed, err := LoadDefinitions(filepath.Join(dir, "missing.json")); err != nil || added+changed != 0 {
+		t.Errorf("missing file = %d, %d, %v; want nothing loaded", added, changed, err)
+	}
+
+	// An invalid entry keeps the valid one before it from being applied
+	const early = `{"name": "def-early", "type": "text2image", "endpoint": "/fal-ai/def-early", "price_usd": 0.1}`
+	for name, entry := range map[string]string{
+		"no price":       `{"name": "def-bad", "type": "text2image", "endpoint": "/fal-ai/def-bad"}`,
+		"bad type":       `{"name": "def-bad", "type": "text2speech", "endpoint": "/fal-ai/def-bad", "price_usd": 0.1}`,
+		"bad endpoint":   `{"name": "def-bad", "type": "text2image", "endpoint": "fal-ai/def-bad", "price_usd": 0.1}`,
+		"built-in type":  `{"name": "flux/schnell", "type": "text2video"}`,
+		"built-in opts":  `{"name": "flux/schnell", "options": {"seed": 1}}`,
+		"defined twice":  early,
+		"negative price": `{"name": "flux/schnell", "price_usd": -1}`,
+		"bad provider":   `{"name": "flux/schnell", "provider": "acme", "provider_model": "acme/flux"}`,
+		"no provider id": `{"name": "flux/schnell", "provider": "replicate"}`,
+		"routed video":   `{"name": "veo2", "provider": "replicate", "provider_model": "google/veo-2"}`,
+	} {
+		path := write("bad.json", `{"models": [`+early+`, `+entry+`]}`)
+		if _, _, err := LoadDefinitions(path); err == nil {
+			t.Errorf("%s: LoadDefinitions accepted the file", name)
+		}
+		if fal.IsRegistered("def-early") {
+			t.Fatalf("%s: an invalid file registered models", name)
+		}
+	}
+
+	path := write("models.json", `{"models": [
+		{"name": "def-image", "type": "text2image", "endpoint": "/fal-ai/def-image",
+		 "options": {"image_size": "square"}, "price_usd": 0.03, "help_doc": "Usage: !text2image [prompt]"},
+		{"name": "flux/schnell", "price_usd": 0.5, "disabled": true,
+		 "provider": "replicate", "provider_model": "black-forest-labs/flux-schnell"}
+	]}`)
+	added, changed, err := LoadDefinitions(path)
+	if err != nil || added != 1 || changed != 1 {
+		t.Fatalf("LoadDefinitions = %d, %d, %v; want 1, 1, nil", added, changed, err)
+	}
+	m, ok := GetModel("def-image", "text2image")
+	if !ok || m.PriceUSD != 0.03 || m.HelpDoc != "Usage: !text2image [prompt]" || !m.Generic {
+		t.Errorf("GetModel(def-image) = %+v, %v", m, ok)
+	}
+	if _, ok := GetModel("flux/schnell", "text2image"); ok {
+		t.Error("flux/schnell is still available after being disabled")
+	}
+	if routes := ProviderRoutes(); len(routes) != 1 || routes["flux/schnell"] != (ProviderRoute{Provider: "replicate", Model: "black-forest-labs/flux-schnell"}) {
+		t.Errorf("ProviderRoutes = %v", routes)
+	}
+
+	// A catalog override can enable the model again
+	enabled := false
+	defer SetModelOverrides(nil)
+	SetModelOverrides(map[string]ModelOverride{"flux/schnell": {Disabled: &enabled}})
+	if m, ok := GetModel("flux/schnell", "text2image"); !ok || m.PriceUSD != 0.5 {
+		t.Errorf("GetModel(flux/schnell) = %+v, %v; want the defined price", m, ok)
+	}
+}
@@ -0,0 +1,198 @@
+package faladapter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+// Definition is one model in the operator's model definition file. Entries
+// naming a built-in model change its metadata, e.g. its price, or disable
+// it. Other entries add a model that needs no code: one of the types fal
+// accepts generic requests for, sent to Endpoint with the prompt, input
+// URLs and flags of the command plus the option defaults in Options.
+// Image models may run on another provider than fal: Provider names it and
+// ProviderModel the model there. MarkupPercent and MarkupFeeUSD replace the
+// global markup for the model.
+type Definition struct {
+	Name          string                 `json:"name"`
+	Type          string                 `json:"type,omitempty"`
+	Endpoint      string                 `json:"endpoint,omitempty"`
+	Options       map[string]interface{} `json:"options,omitempty"`
+	Provider      string                 `json:"provider,omitempty"`
+	ProviderModel string                 `json:"provider_model,omitempty"`
+	MarkupPercent *float64               `json:"markup_percent,omitempty"`
+	MarkupFeeUSD  *float64               `json:"markup_fee_usd,omitempty"`
+	ModelOverride
+}
+
+// Providers are the backends models can be routed to besides fal.
+var Providers = []string{"replicate"}
+
+// ProviderRoute is where a model runs when it does not run on fal.
+type ProviderRoute struct {
+	Provider string // One of Providers
+	Model    string // The model's name at the provider
+}
+
+// providerRoutes maps model name → route, for models not run on fal.
+var providerRoutes = make(map[string]ProviderRoute)
+
+// ProviderRoutes returns the models the model definition file routed to
+// other providers than fal.
+func ProviderRoutes() map[string]ProviderRoute {
+	routes := make(map[string]ProviderRoute, len(providerRoutes))
+	for name, route := range providerRoutes {
+		routes[name] = route
+	}
+	return routes
+}
+
+// DefinitionFile is the JSON format of the model definition file.
+type DefinitionFile struct {
+	Models []Definition `json:"models"`
+}
+
+// LoadDefinitions applies the model definition file at path and returns how
+// many models it added and changed. A missing file is not an error. The
+// file is checked as a whole before anything is applied, so an invalid
+// entry leaves the registry untouched. Definitions are meant to be loaded at
+// startup, before the commands are set up.
+func LoadDefinitions(path string) (added, changed int, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read model definitions: %v", err)
+	}
+	var f DefinitionFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse model definitions %s: %v", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for _, def := range f.Models {
+		if err := checkDefinition(def); err != nil {
+			return 0, 0, fmt.Errorf("invalid model definitions %s: %v", path, err)
+		}
+		if seen[def.Name] {
+			return 0, 0, fmt.Errorf("invalid model definitions %s: %s is defined twice", path, def.Name)
+		}
+		seen[def.Name] = true
+	}
+
+	for _, def := range f.Models {
+		if !fal.IsRegistered(def.Name) {
+			if err := fal.RegisterModel(def.model()); err != nil {
+				return added, changed, err
+			}
+			added++
+		} else {
+			if def.Endpoint != "" {
+				if err := fal.SetEndpointOverride(def.Name, def.Endpoint); err != nil {
+					return added, changed, err
+				}
+			}
+			changed++
+		}
+		meta := modelMeta[def.Name]
+		def.ModelOverride.applyMeta(&meta)
+		modelMeta[def.Name] = meta
+		if def.Provider != "" {
+			providerRoutes[def.Name] = ProviderRoute{Provider: def.Provider, Model: def.ProviderModel}
+		}
+		setModelMarkup(def.Name, markupOverride{Percent: def.MarkupPercent, FeeUSD: def.MarkupFeeUSD})
+	}
+	return added, changed, nil
+}
+
+// checkDefinition reports what keeps def from being applied.
+func checkDefinition(def Definition) error {
+	if def.Name == "" {
+		return fmt.Errorf("model without a name")
+	}
+	for _, price := range []*float64{def.PriceUSD, def.BasePriceUSD} {
+		if price != nil && *price < 0 {
+			return fmt.Errorf("negative price for %s", def.Name)
+		}
+	}
+	for _, markup := range []*float64{def.MarkupPercent, def.MarkupFeeUSD} {
+		if markup != nil && *markup < 0 {
+			return fmt.Errorf("negative markup for %s", def.Name)
+		}
+	}
+	if def.MaxTextChars != nil && *def.MaxTextChars < 0 {
+		return fmt.Errorf("negative max_text_chars for %s", def.Name)
+	}
+	if err := checkProvider(def); err != nil {
+		return err
+	}
+
+	if fal.IsRegistered(def.Name) {
+		// Built-in models keep their type and options, which their code
+		// depends on
+		if len(def.Options) > 0 {
+			return fmt.Errorf("options of the built-in model %s cannot be changed", def.Name)
+		}
+		if def.Type != "" {
+			if _, ok := fal.GetModel(def.Name, def.Type); !ok {
+				return fmt.Errorf("the built-in model %s is not a %s model", def.Name, def.Type)
+			}
+		}
+		return nil
+	}
+
+	// New models run on whatever they cost, so the price must be given
+	if def.PriceUSD == nil {
+		return fmt.Errorf("new model %s needs a price_usd", def.Name)
+	}
+	return fal.CheckGenericModel(def.model())
+}
+
+// checkProvider reports what keeps def from being routed to its provider.
+// Only image models can be routed yet.
+func checkProvider(def Definition) error {
+	if def.Provider == "" {
+		if def.ProviderModel != "" {
+			return fmt.Errorf("provider_model of %s without a provider", def.Name)
+		}
+		return nil
+	}
+	if !slices.Contains(Providers, def.Provider) {
+		return fmt.Errorf("unknown provider %q for %s (must be one of %s)", def.Provider, def.Name, strings.Join(Providers, ", "))
+	}
+	if def.ProviderModel == "" {
+		return fmt.Errorf("%s runs on %s but has no provider_model", def.Name, def.Provider)
+	}
+	typ := def.Type
+	if fal.IsRegistered(def.Name) {
+		typ = ""
+		for _, t := range []string{"text2image", "image2image"} {
+			if _, ok := fal.GetModel(def.Name, t); ok {
+				typ = t
+			}
+		}
+	}
+	if typ != "text2image" && typ != "image2image" {
+		return fmt.Errorf("%s cannot run on %s: only image models can", def.Name, def.Provider)
+	}
+	return nil
+}
+
+// model returns the fal model a definition of a new model registers.
+func (def Definition) model() fal.Model {
+	model := fal.Model{Name: def.Name, Type: def.Type, Endpoint: def.Endpoint}
+	if def.Description != nil {
+		model.Description = *def.Description
+	}
+	if len(def.Options) > 0 {
+		model.Options = fal.GenericOptions(def.Options)
+	}
+	return model
+}
@@ -0,0 +1,15 @@
+package faladapter
+
+import (
+	"github.com/decred/slog"
+	"github.com/karamble/braibot/internal/joblog"
+)
+
+// adapterLog is the logger of the adapter. It logs to stdout until SetLogger
+// gives it one of the bot's log backend.
+var adapterLog = joblog.New(nil, joblog.Adapter)
+
+// SetLogger sets the logger the adapter logs to.
+func SetLogger(l slog.Logger) {
+	adapterLog = l
+}
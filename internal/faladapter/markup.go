@@ -0,0 +1,116 @@
+package faladapter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Markup is the service fee the operator adds to what a model costs at fal:
+// Percent of the model cost plus a flat FeeUSD per request.
+type Markup struct {
+	Percent float64
+	FeeUSD  float64
+}
+
+// markupOverride is the markup the model definition file sets for one
+// model. Nil fields keep the global markup.
+type markupOverride struct {
+	Percent *float64
+	FeeUSD  *float64
+}
+
+var (
+	// globalMarkup applies to every model without a markup of its own.
+	globalMarkup Markup
+	// modelMarkups maps model name → markup from the definition file.
+	modelMarkups = make(map[string]markupOverride)
+	markupMu     sync.RWMutex
+)
+
+// SetMarkup sets the markup of every model whose definition does not set
+// its own.
+func SetMarkup(m Markup) {
+	markupMu.Lock()
+	defer markupMu.Unlock()
+	globalMarkup = m
+}
+
+// setModelMarkup sets the markup the definition file gives a model.
+func setModelMarkup(name string, o markupOverride) {
+	markupMu.Lock()
+	defer markupMu.Unlock()
+	if o.Percent == nil && o.FeeUSD == nil {
+		delete(modelMarkups, name)
+		return
+	}
+	modelMarkups[name] = o
+}
+
+// MarkupFor returns the markup charged on top of the model's cost.
+func MarkupFor(name string) Markup {
+	markupMu.RLock()
+	defer markupMu.RUnlock()
+	m := globalMarkup
+	if o, ok := modelMarkups[name]; ok {
+		if o.Percent != nil {
+			m.Percent = *o.Percent
+		}
+		if o.FeeUSD != nil {
+			m.FeeUSD = *o.FeeUSD
+		}
+	}
+	return m
+}
+
+// IsZero reports whether the markup adds nothing.
+func (m Markup) IsZero() bool {
+	return m.Percent == 0 && m.FeeUSD == 0
+}
+
+// Apply returns what a request costing costUSD at fal is charged. Requests
+// that cost nothing, like free previews, stay free.
+func (m Markup) Apply(costUSD float64) float64 {
+	if costUSD <= 0 {
+		return costUSD
+	}
+	return costUSD*(1+m.Percent/100) + m.FeeUSD
+}
+
+// String describes the markup, e.g. "10% + $0.01 service fee". It is empty
+// for a zero markup.
+func (m Markup) String() string {
+	var parts []string
+	if m.Percent != 0 {
+		parts = append(parts, strconv.FormatFloat(m.Percent, 'f', -1, 64)+"%")
+	}
+	if m.FeeUSD != 0 {
+		parts = append(parts, formatUSD(m.FeeUSD))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, " + ") + " service fee"
+}
+
+// PriceBreakdown shows how the price of running m with params adds up, e.g.
+// "model cost $0.08 + 10% service fee". It is empty when no markup applies.
+func PriceBreakdown(m AppModel, params PriceParams) string {
+	cost := CostFor(m, params)
+	markup := MarkupFor(m.Name)
+	if markup.IsZero() || cost <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("model cost %s + %s", formatUSD(cost), markup)
+}
+
+// formatUSD shows a USD amount in cents, or with more digits when it has
+// fractions of a cent.
+func formatUSD(usd float64) string {
+	if cents := usd * 100; math.Abs(cents-math.Round(cents)) > 1e-9 {
+		return fmt.Sprintf("$%.4f", usd)
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
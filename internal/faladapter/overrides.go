@@ -0,0 +1,150 @@
+package faladapter
+
+import (
+	"sync"
+
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+// ModelOverride replaces parts of a model's built-in metadata, e.g. with
+// current prices from a model catalog. Nil fields keep the built-in value.
+type ModelOverride struct {
+	Description      *string  `json:"description,omitempty"`
+	PriceUSD         *float64 `json:"price_usd,omitempty"`
+	PerSecondPricing *bool    `json:"per_second_pricing,omitempty"`
+	PerThousandChars *bool    `json:"per_thousand_chars,omitempty"`
+	PerMillionTokens *bool    `json:"per_million_tokens,omitempty"`
+	BasePriceUSD     *float64 `json:"base_price_usd,omitempty"`
+	MaxTextChars     *int     `json:"max_text_chars,omitempty"`
+	HelpDoc          *string  `json:"help_doc,omitempty"`
+	// Disabled hides the model from every command, e.g. while it is broken
+	// upstream. Users who picked it fall back to the default model.
+	Disabled *bool `json:"disabled,omitempty"`
+}
+
+var (
+	// overrides maps model name → override currently in effect.
+	overrides   = make(map[string]ModelOverride)
+	overridesMu sync.RWMutex
+)
+
+// Merge returns o with the fields set in other replacing its own.
+func (o ModelOverride) Merge(other ModelOverride) ModelOverride {
+	if other.Description != nil {
+		o.Description = other.Description
+	}
+	if other.PriceUSD != nil {
+		o.PriceUSD = other.PriceUSD
+	}
+	if other.PerSecondPricing != nil {
+		o.PerSecondPricing = other.PerSecondPricing
+	}
+	if other.PerThousandChars != nil {
+		o.PerThousandChars = other.PerThousandChars
+	}
+	if other.PerMillionTokens != nil {
+		o.PerMillionTokens = other.PerMillionTokens
+	}
+	if other.BasePriceUSD != nil {
+		o.BasePriceUSD = other.BasePriceUSD
+	}
+	if other.MaxTextChars != nil {
+		o.MaxTextChars = other.MaxTextChars
+	}
+	if other.HelpDoc != nil {
+		o.HelpDoc = other.HelpDoc
+	}
+	if other.Disabled != nil {
+		o.Disabled = other.Disabled
+	}
+	return o
+}
+
+// applyMeta copies the fields set in o into meta.
+func (o ModelOverride) applyMeta(meta *appModelMeta) {
+	if o.Description != nil {
+		meta.Description = *o.Description
+	}
+	if o.PriceUSD != nil {
+		meta.PriceUSD = *o.PriceUSD
+	}
+	if o.PerSecondPricing != nil {
+		meta.PerSecondPricing = *o.PerSecondPricing
+	}
+	if o.PerThousandChars != nil {
+		meta.PerThousandChars = *o.PerThousandChars
+	}
+	if o.PerMillionTokens != nil {
+		meta.PerMillionTokens = *o.PerMillionTokens
+	}
+	if o.BasePriceUSD != nil {
+		meta.BasePriceUSD = *o.BasePriceUSD
+	}
+	if o.MaxTextChars != nil {
+		meta.MaxTextChars = *o.MaxTextChars
+	}
+	if o.HelpDoc != nil {
+		meta.HelpDoc = *o.HelpDoc
+	}
+	if o.Disabled != nil {
+		meta.Disabled = *o.Disabled
+	}
+}
+
+// apply copies the fields set in o into am.
+func (o ModelOverride) apply(am *AppModel) {
+	if o.Description != nil {
+		am.Description = *o.Description
+	}
+	if o.PriceUSD != nil {
+		am.PriceUSD = *o.PriceUSD
+	}
+	if o.PerSecondPricing != nil {
+		am.PerSecondPricing = *o.PerSecondPricing
+	}
+	if o.PerThousandChars != nil {
+		am.PerThousandChars = *o.PerThousandChars
+	}
+	if o.PerMillionTokens != nil {
+		am.PerMillionTokens = *o.PerMillionTokens
+	}
+	if o.BasePriceUSD != nil {
+		am.BasePriceUSD = *o.BasePriceUSD
+	}
+	if o.MaxTextChars != nil {
+		am.MaxTextChars = *o.MaxTextChars
+	}
+	if o.HelpDoc != nil {
+		am.HelpDoc = *o.HelpDoc
+	}
+}
+
+// SetModelOverrides replaces every model override at once, so a reloaded
+// catalog takes effect for the next request without a restart. Overrides
+// of models that are not registered are returned and not applied, since
+// new models need code to build their requests.
+func SetModelOverrides(o map[string]ModelOverride) (unknown []string) {
+	next := make(map[string]ModelOverride, len(o))
+	for name, override := range o {
+		if !fal.IsRegistered(name) {
+			unknown = append(unknown, name)
+			continue
+		}
+		next[name] = override
+	}
+	overridesMu.Lock()
+	overrides = next
+	overridesMu.Unlock()
+	return unknown
+}
+
+// isDisabled reports whether the model definition file or an override
+// disabled the model. An override can enable a model again.
+func isDisabled(name string) bool {
+	overridesMu.RLock()
+	defer overridesMu.RUnlock()
+	if d := overrides[name].Disabled; d != nil {
+		return *d
+	}
+	return modelMeta[name].Disabled
+}
@@ -0,0 +1,36 @@
+package faladapter
+
+// PriceParams holds the parts of a request that scale its price.
+type PriceParams struct {
+	Seconds   int // Billed duration, for per-second models
+	NumImages int // Images requested; 0 counts as one
+	TextChars int // Characters of input text, for per-character models
+	Tokens    int // Tokens of prompt and reply, for language models
+}
+
+// PriceFor returns the USD price users pay for running m with params: its
+// cost at fal plus the operator's markup for the model.
+func PriceFor(m AppModel, params PriceParams) float64 {
+	return MarkupFor(m.Name).Apply(CostFor(m, params))
+}
+
+// CostFor returns the USD cost at fal of running m with params. Per-second
+// models cost PriceUSD per second, per-character models PriceUSD per 1000
+// characters and language models PriceUSD per million tokens, all on top of
+// BasePriceUSD; other models cost PriceUSD. The
+// cost is multiplied by the number of images requested.
+func CostFor(m AppModel, params PriceParams) float64 {
+	price := m.PriceUSD
+	switch {
+	case m.PerSecondPricing:
+		price = m.BasePriceUSD + m.PriceUSD*float64(max(params.Seconds, 0))
+	case m.PerThousandChars:
+		price = m.BasePriceUSD + m.PriceUSD*float64(max(params.TextChars, 0))/1000
+	case m.PerMillionTokens:
+		price = m.BasePriceUSD + m.PriceUSD*float64(max(params.Tokens, 0))/1e6
+	}
+	if params.NumImages > 1 {
+		price *= float64(params.NumImages)
+	}
+	return price
+}
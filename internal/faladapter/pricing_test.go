@@ -0,0 +1,67 @@
+package faladapter
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPriceFor(t *testing.T) {
+	flat := AppModel{PriceUSD: 0.05}
+	perSecond := AppModel{PriceUSD: 0.40, PerSecondPricing: true}
+	withBase := AppModel{PriceUSD: 0.50, BasePriceUSD: 1.00, PerSecondPricing: true}
+	perChars := AppModel{PriceUSD: 0.10, PerThousandChars: true}
+	perTokens := AppModel{PriceUSD: 2.00, BasePriceUSD: 0.001, PerMillionTokens: true}
+
+	tests := []struct {
+		name   string
+		model  AppModel
+		params PriceParams
+		want   float64
+	}{
+		{"flat", flat, PriceParams{Seconds: 10, TextChars: 500}, 0.05},
+		{"flat images", flat, PriceParams{NumImages: 4}, 0.20},
+		{"per second", perSecond, PriceParams{Seconds: 5}, 2.00},
+		{"per second without duration", perSecond, PriceParams{}, 0},
+		{"base plus per second", withBase, PriceParams{Seconds: 10}, 6.00},
+		{"per character", perChars, PriceParams{TextChars: 250}, 0.025},
+		{"per token", perTokens, PriceParams{Tokens: 1500}, 0.004},
+	}
+	for _, tc := range tests {
+		if got := PriceFor(tc.model, tc.params); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: PriceFor = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMarkup(t *testing.T) {
+	SetMarkup(Markup{Percent: 10})
+	defer SetMarkup(Markup{})
+	fee := 0.02
+	setModelMarkup("marked-up", markupOverride{FeeUSD: &fee})
+	defer setModelMarkup("marked-up", markupOverride{})
+
+	perSecond := AppModel{PriceUSD: 0.40, PerSecondPricing: true}
+	perSecond.Name = "plain"
+	marked := AppModel{PriceUSD: 0.08}
+	marked.Name = "marked-up"
+
+	tests := []struct {
+		name      string
+		model     AppModel
+		params    PriceParams
+		want      float64
+		breakdown string
+	}{
+		{"global percent", perSecond, PriceParams{Seconds: 5}, 2.20, "model cost $2.00 + 10% service fee"},
+		{"free stays free", perSecond, PriceParams{}, 0, ""},
+		{"model fee", marked, PriceParams{}, 0.108, "model cost $0.08 + 10% + $0.02 service fee"},
+	}
+	for _, tc := range tests {
+		if got := PriceFor(tc.model, tc.params); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: PriceFor = %v, want %v", tc.name, got, tc.want)
+		}
+		if got := PriceBreakdown(tc.model, tc.params); got != tc.breakdown {
+			t.Errorf("%s: PriceBreakdown = %q, want %q", tc.name, got, tc.breakdown)
+		}
+	}
+}
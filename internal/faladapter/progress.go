@@ -0,0 +1,228 @@
+package faladapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/karamble/braibot/internal/outbox"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+// progressIDs numbers progress callbacks, so the updates of different jobs
+// are not coalesced with each other.
+var progressIDs atomic.Uint64
+
+// ProgressCallback implements fal.ProgressCallback by sending throttled
+// updates to where a job was requested: the user's PM or the group chat.
+// Updates go through outbox.Default, which sends them one at a time per chat
+// and drops the ones still waiting once the job's context ends, e.g. when
+// the job is cancelled or the bot shuts down.
+type ProgressCallback struct {
+	ctx     context.Context
+	bot     braibottypes.BotInterface
+	msgCtx  braibottypes.MessageContext
+	cmdType string
+	id      uint64
+
+	// Throttling fields
+	lastQueueUpdate    time.Time
+	lastProgressUpdate time.Time
+	lastLogMessage     time.Time
+	lastSpecialMessage time.Time
+
+	// Minimum interval between updates
+	queueUpdateInterval    time.Duration
+	progressUpdateInterval time.Duration
+	logMessageInterval     time.Duration
+	specialMessageInterval time.Duration
+
+	// Track the last sent message to avoid duplicates for each type
+	lastSentQueueMessage    string
+	lastSentProgressMessage string
+	lastSentLogMessage      string
+
+	// Cost ticker for per-second priced jobs, empty when not set
+	costLabel string
+	startedAt time.Time
+}
+
+// NewProgressCallback creates a ProgressCallback for the job of cmdType
+// requested in msgCtx, running under ctx, with default throttling intervals.
+func NewProgressCallback(ctx context.Context, bot *kit.Bot, msgCtx braibottypes.MessageContext, cmdType string) *ProgressCallback {
+	return newProgressCallback(ctx, braibottypes.NewBisonBotAdapter(bot), msgCtx, cmdType)
+}
+
+func newProgressCallback(ctx context.Context, bot braibottypes.BotInterface, msgCtx braibottypes.MessageContext, cmdType string) *ProgressCallback {
+	return &ProgressCallback{
+		ctx:     ctx,
+		bot:     bot,
+		msgCtx:  msgCtx,
+		cmdType: cmdType,
+		id:      progressIDs.Add(1),
+		// Default intervals: 30 seconds for queue updates, 20 seconds for progress, 15 seconds for logs, 2 minutes for special messages
+		queueUpdateInterval:    30 * time.Second,
+		progressUpdateInterval: 20 * time.Second,
+		logMessageInterval:     15 * time.Second,
+		specialMessageInterval: 2 * time.Minute,
+	}
+}
+
+// SetCostTicker makes queue and progress updates carry the locked-in cost and
+// duration of the job along with the elapsed time, e.g.
+// "kling 10s — $4.00 — rendering 03:12 elapsed".
+func (c *ProgressCallback) SetCostTicker(modelName string, durationSecs int, costUSD float64) {
+	c.costLabel = fmt.Sprintf("%s %ds — $%.2f", modelName, durationSecs, costUSD)
+	c.startedAt = time.Now()
+}
+
+// tickerMessage formats a cost ticker line for the given activity.
+func (c *ProgressCallback) tickerMessage(activity string) string {
+	elapsed := time.Since(c.startedAt).Round(time.Second)
+	return fmt.Sprintf("%s — %s %02d:%02d elapsed", c.costLabel, activity, int(elapsed.Minutes()), int(elapsed.Seconds())%60)
+}
+
+// sendMessage queues msg for the chat the job was requested in. Messages of
+// the same kind replace each other while they wait; an empty kind is never
+// replaced.
+func (c *ProgressCallback) sendMessage(kind, msg string) {
+	dest, send := "gc:"+c.msgCtx.GC, func(ctx context.Context, text string) error {
+		return c.bot.SendGC(ctx, c.msgCtx.GC, text)
+	}
+	if c.msgCtx.IsPM {
+		dest, send = "pm:"+c.msgCtx.Sender.String(), func(ctx context.Context, text string) error {
+			return c.bot.SendPM(ctx, c.msgCtx.Sender, text)
+		}
+	}
+	key := ""
+	if kind != "" {
+		key = fmt.Sprintf("%d/%s", c.id, kind)
+	}
+	outbox.Default.Queue(c.ctx, dest, key, msg, send)
+}
+
+// OnQueueUpdate sends queue position updates to the user with throttling.
+func (c *ProgressCallback) OnQueueUpdate(position int, eta time.Duration) {
+	msg := fmt.Sprintf("Queue position: %d, ETA: %v", position, eta)
+	if c.costLabel != "" {
+		msg = c.tickerMessage(fmt.Sprintf("queue position %d,", position))
+	}
+
+	// Check if enough time has passed since the last update and whether
+	// this is the same message we last sent
+	if time.Since(c.lastQueueUpdate) < c.queueUpdateInterval || msg == c.lastSentQueueMessage {
+		return
+	}
+
+	c.sendMessage("queue", msg)
+	c.lastQueueUpdate = time.Now()
+	c.lastSentQueueMessage = msg
+}
+
+// OnProgress sends progress updates to the user with throttling.
+func (c *ProgressCallback) OnProgress(status string) {
+	msg := fmt.Sprintf("Status: %s", status)
+	if c.costLabel != "" {
+		activity := strings.ToLower(status)
+		switch status {
+		case "IN_QUEUE":
+			activity = "queued"
+		case "IN_PROGRESS":
+			activity = "rendering"
+		}
+		msg = c.tickerMessage(activity)
+	}
+
+	// Check if enough time has passed since the last update and whether
+	// this is the same message we last sent
+	if time.Since(c.lastProgressUpdate) < c.progressUpdateInterval || msg == c.lastSentProgressMessage {
+		return
+	}
+
+	c.sendMessage("status", msg)
+	c.lastProgressUpdate = time.Now()
+	c.lastSentProgressMessage = msg
+
+	// If status is IN_PROGRESS, send a special message about the expected processing time
+	// but only once every 2 minutes at maximum
+	if status == "IN_PROGRESS" && time.Since(c.lastSpecialMessage) >= c.specialMessageInterval {
+		c.sendMessage("special", inProcessMessage(c.cmdType))
+		c.lastSpecialMessage = time.Now()
+	}
+}
+
+// inProcessMessage tells how long a job of cmdType may take.
+func inProcessMessage(cmdType string) string {
+	const keepUsing = "\nYou can keep using other commands meanwhile; check !queue for your pending jobs"
+	switch cmdType {
+	case "image2video", "video2video", "multi2video":
+		return "The video generation is in process\nVideo generation can take a long time, up to 20 minutes" + keepUsing
+	case "text2image", "image2image":
+		return "The image generation is in process\nImage generation can take a few minutes" + keepUsing
+	case "text2speech":
+		return "The speech generation is in process\nSpeech generation can take a few minutes" + keepUsing
+	default:
+		return "The generation is in process\nThis may take a few minutes" + keepUsing
+	}
+}
+
+// OnError sends error messages to the user (no throttling for errors).
+func (c *ProgressCallback) OnError(err error) {
+	c.sendMessage("", fmt.Sprintf("Error: %v", err))
+}
+
+// OnLogMessage sends log messages to the user with throttling.
+func (c *ProgressCallback) OnLogMessage(message string) {
+	msg := logLine(message)
+	if msg == "" {
+		return
+	}
+
+	// Check if enough time has passed since the last update and whether
+	// this is the same message we last sent
+	if time.Since(c.lastLogMessage) < c.logMessageInterval || msg == c.lastSentLogMessage {
+		return
+	}
+
+	c.sendMessage("log", msg)
+	c.lastLogMessage = time.Now()
+	c.lastSentLogMessage = msg
+}
+
+// logLine formats a provider log message for the user: the last line of
+// plain messages, or the newest entry of a JSON list of logs. It returns ""
+// for a JSON list without a usable entry.
+func logLine(message string) string {
+	if strings.Contains(message, `"logs":`) {
+		var response struct {
+			Logs []struct {
+				Message string `json:"message"`
+				Labels  struct {
+					LoggedAt string `json:"logged_at"`
+				} `json:"labels"`
+			} `json:"logs"`
+		}
+		if err := json.Unmarshal([]byte(message), &response); err == nil {
+			var latestTime time.Time
+			var latestMessage string
+			for _, log := range response.Logs {
+				t, err := time.Parse(time.RFC3339Nano, log.Labels.LoggedAt)
+				if err == nil && t.After(latestTime) {
+					latestTime, latestMessage = t, log.Message
+				}
+			}
+			if latestMessage == "" {
+				return ""
+			}
+			return fmt.Sprintf("Progress: %s", latestMessage)
+		}
+	}
+
+	// For non-JSON messages, split into lines and take the last line
+	lines := strings.Split(message, "\n")
+	return fmt.Sprintf("Log: %s", lines[len(lines)-1])
+}
@@ -0,0 +1,111 @@
+package faladapter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/outbox"
+	braibottypes "github.com/karamble/braibot/internal/types"
+)
+
+// chatRecorder records the PMs and GC messages sent.
+type chatRecorder struct {
+	mu  sync.Mutex
+	pms []string
+	gcs []string
+}
+
+func (r *chatRecorder) SendPM(ctx context.Context, uid zkidentity.ShortID, msg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pms = append(r.pms, msg)
+	return nil
+}
+
+func (r *chatRecorder) SendGC(ctx context.Context, gc string, msg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gcs = append(r.gcs, gc+": "+msg)
+	return nil
+}
+
+func (r *chatRecorder) SendGCMessage(ctx context.Context, gc string, channel string, msg string) error {
+	return r.SendGC(ctx, gc, msg)
+}
+
+func (r *chatRecorder) SendFile(ctx context.Context, uid zkidentity.ShortID, path string) error {
+	return nil
+}
+
+// sent returns the PMs and GC messages sent once the outbox is idle.
+func (r *chatRecorder) sent(t *testing.T) ([]string, []string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !outbox.Default.Idle() {
+		if time.Now().After(deadline) {
+			t.Fatal("outbox not idle after 5s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pms, r.gcs
+}
+
+func TestProgressCallbackDestination(t *testing.T) {
+	ctx := context.Background()
+	r := &chatRecorder{}
+	pm := newProgressCallback(ctx, r, braibottypes.MessageContext{IsPM: true}, "image2video")
+	pm.OnQueueUpdate(3, time.Minute)
+	pm.OnProgress("IN_PROGRESS")
+	// Throttled: too soon after the last status
+	pm.OnProgress("IN_QUEUE")
+	pm.OnLogMessage("step 1\nstep 2")
+	pm.OnError(errors.New("boom"))
+	pms, gcs := r.sent(t)
+	if len(gcs) != 0 || len(pms) != 5 || pms[0] != "Queue position: 3, ETA: 1m0s" || pms[1] != "Status: IN_PROGRESS" ||
+		!strings.HasPrefix(pms[2], "The video generation is in process") || pms[3] != "Log: step 2" || pms[4] != "Error: boom" {
+		t.Errorf("PM job sent PMs %q and GC messages %q", pms, gcs)
+	}
+
+	r = &chatRecorder{}
+	gc := newProgressCallback(ctx, r, braibottypes.MessageContext{GC: "studio"}, "text2video")
+	gc.SetCostTicker("kling", 10, 4)
+	gc.OnProgress("IN_PROGRESS")
+	pms, gcs = r.sent(t)
+	if len(pms) != 0 || len(gcs) != 2 || !strings.HasPrefix(gcs[0], "studio: kling 10s — $4.00 — rendering 00:00 elapsed") {
+		t.Errorf("GC job sent PMs %q and GC messages %q", pms, gcs)
+	}
+}
+
+func TestProgressCallbackCancelled(t *testing.T) {
+	// Updates of a job that was cancelled or shut down are not sent
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := &chatRecorder{}
+	c := newProgressCallback(ctx, r, braibottypes.MessageContext{IsPM: true}, "text2image")
+	c.OnQueueUpdate(1, time.Second)
+	c.OnProgress("IN_PROGRESS")
+	if pms, gcs := r.sent(t); len(pms)+len(gcs) != 0 {
+		t.Errorf("cancelled job sent PMs %q and GC messages %q", pms, gcs)
+	}
+}
+
+func TestLogLine(t *testing.T) {
+	tests := []struct{ message, want string }{
+		{"loading model", "Log: loading model"},
+		{"step 1\nstep 2", "Log: step 2"},
+		{`{"logs": [{"message": "old", "labels": {"logged_at": "2025-01-01T00:00:00Z"}}, {"message": "new", "labels": {"logged_at": "2025-01-01T00:00:05Z"}}]}`, "Progress: new"},
+		{`{"logs": []}`, ""},
+	}
+	for _, tt := range tests {
+		if got := logLine(tt.message); got != tt.want {
+			t.Errorf("logLine(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Package falhook serves the webhooks fal.ai posts when a request completes,
+// so generation jobs finish as soon as their result is ready instead of on
+// the next status poll.
+package falhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/karamble/braibot/internal/health"
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+var (
+	// Default receives the webhooks of the bot's fal clients; nil while
+	// webhooks are off.
+	Default *fal.WebhookReceiver
+
+	// pollInterval is how often requests still poll while webhooks are on.
+	pollInterval time.Duration
+)
+
+// Start creates Default for webhooks posted to publicURL and serves them on
+// addr until ctx is done, next to the /healthz liveness endpoint. Requests still check their status every poll
+// (0 uses fal.DefaultWebhookPollInterval) in case a webhook never arrives.
+// It must be called before the fal clients are created.
+func Start(ctx context.Context, addr, publicURL string, poll time.Duration, logf func(format string, args ...interface{})) error {
+	recv, err := fal.NewWebhookReceiver(publicURL)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(publicURL)
+	if err != nil {
+		return err
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, recv)
+	if path != "/healthz" {
+		mux.Handle("/healthz", health.Handler())
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for fal webhooks: %v", err)
+	}
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logf("Fal webhook listener stopped: %v", err)
+		}
+	}()
+
+	Default, pollInterval = recv, poll
+	return nil
+}
+
+// ClientOptions returns the options that make a fal client use Default, or
+// none while webhooks are off.
+func ClientOptions() []fal.ClientOption {
+	if Default == nil {
+		return nil
+	}
+	return []fal.ClientOption{fal.WithWebhook(Default, pollInterval)}
+}
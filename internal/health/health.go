@@ -0,0 +1,47 @@
+// Package health reports whether the bot is alive, for !status and the
+// /healthz endpoint container orchestrators probe.
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/karamble/braibot/internal/jobs"
+)
+
+// started is when the bot started.
+var started = time.Now()
+
+// Uptime returns how long the bot has been running.
+func Uptime() time.Duration {
+	return time.Since(started)
+}
+
+// Liveness is the response of the /healthz endpoint.
+type Liveness struct {
+	Status        string `json:"status"`
+	UptimeSeconds int64  `json:"uptimeSeconds"`
+	RunningJobs   int    `json:"runningJobs"`
+	PendingJobs   int    `json:"pendingJobs"`
+}
+
+// Handler serves /healthz. It answers 200 while the process serves requests
+// and checks nothing outside of it, so a slow fal or Bison Relay does not get
+// the bot restarted; !status reports those.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		running, pending := jobs.Default.Counts()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Liveness{
+			Status:        "ok",
+			UptimeSeconds: int64(Uptime() / time.Second),
+			RunningJobs:   running,
+			PendingJobs:   pending,
+		})
+	})
+}
@@ -0,0 +1,188 @@
+// Package i18n translates the messages the bot sends users. Messages are
+// text/template strings looked up by key in one catalog per language. The
+// built-in catalogs are embedded; operators add languages or reword messages
+// with JSON files named after the language, e.g. de.json, in
+// approot/locales.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// DefaultLanguage is the language of users without one of their own, and
+// the catalog messages missing from other languages are taken from.
+const DefaultLanguage = "en"
+
+// builtinLocales are the catalogs shipped with the bot.
+//
+//go:embed locales/*.json
+var builtinLocales embed.FS
+
+// languagePattern matches the language codes catalogs are named after, such
+// as de or pt-br.
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
+
+// Args are the values a message template refers to, e.g. {{.Nick}}.
+type Args map[string]any
+
+// Catalog holds the message templates of every language.
+type Catalog struct {
+	mu       sync.RWMutex
+	messages map[string]map[string]*template.Template // By language, then key
+}
+
+// Default is the catalog of the bot's messages.
+var Default = mustBuiltin()
+
+// NewCatalog returns a catalog of the built-in messages.
+func NewCatalog() (*Catalog, error) {
+	c := &Catalog{messages: make(map[string]map[string]*template.Template)}
+	entries, err := fs.ReadDir(builtinLocales, "locales")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		data, err := builtinLocales.ReadFile(path.Join("locales", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := c.load(e.Name(), data); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func mustBuiltin() *Catalog {
+	c, err := NewCatalog()
+	if err != nil {
+		panic(fmt.Sprintf("i18n: invalid built-in catalog: %v", err))
+	}
+	return c
+}
+
+// LoadDir adds the catalogs in dir, replacing the messages they define. It
+// returns the number of messages loaded.
+func (c *Catalog) LoadDir(dir string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	if len(files) == 0 {
+		if _, err := os.Stat(dir); err != nil {
+			return 0, err
+		}
+	}
+	total := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return total, err
+		}
+		n, err := c.load(filepath.Base(file), data)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// load adds the catalog in data, read from the file name.
+func (c *Catalog) load(name string, data []byte) (int, error) {
+	lang := strings.ToLower(strings.TrimSuffix(name, ".json"))
+	if !languagePattern.MatchString(lang) {
+		return 0, fmt.Errorf("%s: file name is not a language code such as de.json", name)
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return 0, fmt.Errorf("%s: %v", name, err)
+	}
+	parsed := make(map[string]*template.Template, len(raw))
+	for key, text := range raw {
+		t, err := template.New(key).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %v", name, err)
+		}
+		parsed[key] = t
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.messages[lang] == nil {
+		c.messages[lang] = make(map[string]*template.Template)
+	}
+	for key, t := range parsed {
+		c.messages[lang][key] = t
+	}
+	return len(parsed), nil
+}
+
+// Has reports whether there is a catalog for lang.
+func (c *Catalog) Has(lang string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.messages[strings.ToLower(lang)]
+	return ok
+}
+
+// Languages returns the languages with a catalog, DefaultLanguage first.
+func (c *Catalog) Languages() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	langs := make([]string, 0, len(c.messages))
+	for lang := range c.messages {
+		if lang != DefaultLanguage {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	return append([]string{DefaultLanguage}, langs...)
+}
+
+// Keys returns the message keys of lang, sorted.
+func (c *Catalog) Keys(lang string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	keys := make([]string, 0, len(c.messages[lang]))
+	for key := range c.messages[lang] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Text returns the message key in lang filled in with args. Messages
+// missing from lang, or failing to render there, are taken from
+// DefaultLanguage; unknown keys are returned as they are.
+func (c *Catalog) Text(lang, key string, args Args) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, l := range []string{strings.ToLower(lang), DefaultLanguage} {
+		t, ok := c.messages[l][key]
+		if !ok {
+			continue
+		}
+		var b strings.Builder
+		if err := t.Execute(&b, args); err == nil {
+			return b.String()
+		}
+	}
+	return key
+}
+
+// Text returns the message key of the Default catalog in lang.
+func Text(lang, key string, args Args) string {
+	return Default.Text(lang, key, args)
+}
@@ -0,0 +1,103 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sampleArgs fills in every argument the built-in messages refer to.
+var sampleArgs = Args{
+	"Nick": "alice", "Command": "text2image", "Available": "help", "Budget": 2.5,
+	"Reason": "slow down", "Job": 7, "Position": 2, "ETA": "1m", "Time": "12:00",
+	"Model": "flux", "Retention": "kept for a day", "DCR": 0.5, "USD": 10.0,
+}
+
+func TestBuiltinCatalogs(t *testing.T) {
+	c, err := NewCatalog()
+	if err != nil {
+		t.Fatalf("NewCatalog: %v", err)
+	}
+	langs := c.Languages()
+	if len(langs) < 2 || langs[0] != DefaultLanguage {
+		t.Fatalf("Languages = %v, want %s first", langs, DefaultLanguage)
+	}
+	keys := c.Keys(DefaultLanguage)
+	for _, lang := range langs {
+		if got := c.Keys(lang); strings.Join(got, ",") != strings.Join(keys, ",") {
+			t.Errorf("%s has keys %v, want %v", lang, got, keys)
+		}
+		for _, key := range keys {
+			t.Run(lang+"/"+key, func(t *testing.T) {
+				c.mu.RLock()
+				tmpl := c.messages[lang][key]
+				c.mu.RUnlock()
+				var b strings.Builder
+				if err := tmpl.Execute(&b, sampleArgs); err != nil {
+					t.Fatal(err)
+				}
+				if strings.Contains(b.String(), "<no value>") {
+					t.Fatalf("rendered %q", b.String())
+				}
+			})
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	c, err := NewCatalog()
+	if err != nil {
+		t.Fatalf("NewCatalog: %v", err)
+	}
+	if got := c.Text("DE", "tip_thanks", Args{"Nick": "bob"}); got != "🙏 Danke für das Trinkgeld, bob!" {
+		t.Errorf("German tip_thanks = %q", got)
+	}
+	if got := c.Text("xx", "tip_thanks", Args{"Nick": "bob"}); got != "🙏 Thank you for the tip, bob!" {
+		t.Errorf("unknown language = %q, want English", got)
+	}
+	if got := c.Text("en", "tip_balance", Args{"DCR": 1.5}); got != "New balance: 1.50000000 DCR" {
+		t.Errorf("tip_balance = %q", got)
+	}
+	if got := c.Text("en", "no_such_message", nil); got != "no_such_message" {
+		t.Errorf("unknown key = %q", got)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	c, err := NewCatalog()
+	if err != nil {
+		t.Fatalf("NewCatalog: %v", err)
+	}
+	dir := t.TempDir()
+	if _, err := c.LoadDir(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Fatalf("LoadDir of a missing directory = %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, "en.json"), []byte(`{"tip_thanks_anon": "Cheers!"}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "pt-br.json"), []byte(`{"tip_thanks": "🙏 Obrigado pela gorjeta, {{.Nick}}!"}`), 0o644)
+	n, err := c.LoadDir(dir)
+	if err != nil || n != 2 {
+		t.Fatalf("LoadDir = %d, %v; want 2 messages", n, err)
+	}
+	if !c.Has("pt-br") {
+		t.Fatal("pt-br not added")
+	}
+	if got := c.Text("en", "tip_thanks_anon", nil); got != "Cheers!" {
+		t.Errorf("reworded message = %q", got)
+	}
+	if got := c.Text("en", "tip_thanks", Args{"Nick": "bob"}); got != "🙏 Thank you for the tip, bob!" {
+		t.Errorf("LoadDir replaced a message it does not define: %q", got)
+	}
+	// Messages missing from a language, or missing its arguments, fall back
+	if got := c.Text("pt-br", "tip_thanks_anon", nil); got != "Cheers!" {
+		t.Errorf("missing message = %q", got)
+	}
+	if got := c.Text("pt-br", "tip_thanks", nil); got != "tip_thanks" {
+		t.Errorf("message without its arguments = %q", got)
+	}
+
+	os.WriteFile(filepath.Join(dir, "english.json"), []byte(`{}`), 0o644)
+	if _, err := c.LoadDir(dir); err == nil {
+		t.Error("LoadDir accepted a file not named after a language")
+	}
+}
@@ -0,0 +1,128 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/karamble/braibot/internal/debuglog"
+	"github.com/karamble/braibot/internal/jobevents"
+	"github.com/karamble/braibot/internal/svganim"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// maxSVGBytes caps the SVG documents fetched for animation.
+const maxSVGBytes = 2 << 20
+
+// animateSizes are the frame sizes tried, largest first, until the GIF fits
+// the embed limit.
+var animateSizes = []int{svganim.DefaultSize, 360, 240}
+
+// rememberSVG records the last SVG result delivered to a user, so
+// !animate-svg last can pick it up.
+func (s *ImageService) rememberSVG(uid, url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSVG[uid] = url
+}
+
+// LastSVG returns the URL of the last SVG result delivered to a user since
+// the bot started.
+func (s *ImageService) LastSVG(uid string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	url, ok := s.lastSVG[uid]
+	return url, ok
+}
+
+// AnimateSVG renders the SVG at req.ImageURL as a draw-on GIF and sends it.
+// Rendering happens locally, so it is not billed; it still takes an image
+// job slot since large drawings take a few seconds of CPU.
+func (s *ImageService) AnimateSVG(ctx context.Context, req *ImageRequest) error {
+	jobevents.Default.Submit(&req.GenerationRequest)
+
+	release, err := utils.AcquireJobSlot(ctx, s.bot, &req.GenerationRequest)
+	if err != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, err)
+		return err
+	}
+	defer release()
+
+	if err := s.animateSVG(ctx, req); err != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, err)
+		return err
+	}
+	jobevents.Default.EmitDelivered(&req.GenerationRequest, 1)
+	return nil
+}
+
+func (s *ImageService) animateSVG(ctx context.Context, req *ImageRequest) error {
+	svg, err := fetchSVG(ctx, req.ImageURL)
+	if err != nil {
+		return err
+	}
+	debuglog.Debugf(debuglog.Delivery, "Downloaded svg %s (%d bytes) for %s", req.ImageURL, len(svg), req.UserNick)
+
+	var data []byte
+	for _, size := range animateSizes {
+		data, err = svganim.Animate(svg, size)
+		if err != nil {
+			return fmt.Errorf("failed to animate svg: %w", err)
+		}
+		if encodedLen(len(data)) <= s.embedLimit(req.IsPM) {
+			return s.sender.SendEmbed(ctx, req.MessageContext(), "animated svg", "image/gif", data)
+		}
+		debuglog.Debugf(debuglog.Delivery, "Animated svg for %s is %d bytes at %dpx, over the embed limit", req.UserNick, len(data), size)
+	}
+
+	// Still too large at the smallest size: send the full size GIF as a file
+	if !req.IsPM {
+		return s.sender.SendMessage(ctx, req.MessageContext(), "📎 The animation is too large to embed in a group chat. Send !animate-svg to me in a PM to get it as a file.")
+	}
+	data, err = svganim.Animate(svg, animateSizes[0])
+	if err != nil {
+		return fmt.Errorf("failed to animate svg: %w", err)
+	}
+	tmpFile, err := os.CreateTemp("", "animation-*.gif")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write animation: %v", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %v", err)
+	}
+	if err := s.sender.SendFile(ctx, req.MessageContext(), tmpFile.Name()); err != nil {
+		return fmt.Errorf("failed to send file: %v", err)
+	}
+	return nil
+}
+
+// fetchSVG downloads an SVG document of at most maxSVGBytes.
+func fetchSVG(ctx context.Context, url string) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid svg url: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch svg: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch svg: status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSVGBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read svg: %w", err)
+	}
+	if len(data) > maxSVGBytes {
+		return nil, fmt.Errorf("svg is larger than %d KB", maxSVGBytes>>10)
+	}
+	return data, nil
+}
@@ -0,0 +1,53 @@
+package image
+
+import (
+	"strconv"
+
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// aspectImageSizes maps saved aspect ratios to the image_size presets of
+// models that take a preset instead of a ratio.
+var aspectImageSizes = map[string]string{
+	"1:1":  "square_hd",
+	"4:3":  "landscape_4_3",
+	"16:9": "landscape_16_9",
+	"3:4":  "portrait_4_3",
+	"9:16": "portrait_16_9",
+}
+
+// applyUserDefaults fills options the user left out with the defaults they
+// saved with !set. Edits keep the source image's shape, so the aspect
+// default only applies to text2image.
+func (s *ImageService) applyUserDefaults(req *ImageRequest) {
+	settings := utils.LoadUserSettings(s.dbManager, req.UserID.String())
+	applySettings(req, settings)
+}
+
+// applySettings fills the options of req that are unset from settings.
+func applySettings(req *ImageRequest, settings map[string]string) {
+	if len(settings) == 0 {
+		return
+	}
+	if aspect := settings[utils.SettingAspect]; aspect != "" && req.ModelType == "text2image" &&
+		req.AspectRatio == "" && req.ImageSize == "" {
+		req.AspectRatio = aspect
+		req.ImageSize = aspectImageSizes[aspect]
+	}
+	if v := settings[utils.SettingNegativePrompt]; v != "" && req.NegativePrompt == "" {
+		req.NegativePrompt = v
+	}
+	if v := settings[utils.SettingOutputFormat]; v != "" && req.OutputFormat == "" {
+		req.OutputFormat = v
+	}
+	if v := settings[utils.SettingSeed]; v != "" && req.Seed == nil {
+		if seed, err := strconv.Atoi(v); err == nil {
+			req.Seed = &seed
+		}
+	}
+	if checker, tolerance, ok := utils.SafetyLevel(settings[utils.SettingNSFW]); ok &&
+		req.EnableSafetyChecker == nil && req.SafetyTolerance == "" {
+		req.EnableSafetyChecker = &checker
+		req.SafetyTolerance = tolerance
+	}
+}
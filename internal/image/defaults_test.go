@@ -0,0 +1,49 @@
+package image
+
+import "testing"
+
+func TestApplySettings(t *testing.T) {
+	settings := map[string]string{
+		"aspect":          "16:9",
+		"negative_prompt": "blur",
+		"output_format":   "png",
+		"seed":            "42",
+		"nsfw":            "off",
+	}
+
+	req := &ImageRequest{}
+	req.ModelType = "text2image"
+	applySettings(req, settings)
+	if req.AspectRatio != "16:9" || req.ImageSize != "landscape_16_9" {
+		t.Errorf("aspect = %q, size %q; want 16:9, landscape_16_9", req.AspectRatio, req.ImageSize)
+	}
+	if req.NegativePrompt != "blur" || req.OutputFormat != "png" {
+		t.Errorf("negative prompt %q, format %q; want blur, png", req.NegativePrompt, req.OutputFormat)
+	}
+	if req.Seed == nil || *req.Seed != 42 {
+		t.Errorf("seed = %v; want 42", req.Seed)
+	}
+	if req.EnableSafetyChecker == nil || *req.EnableSafetyChecker || req.SafetyTolerance != "5" {
+		t.Errorf("safety = %v, %q; want checker off, tolerance 5", req.EnableSafetyChecker, req.SafetyTolerance)
+	}
+
+	// Flags given with the command win over saved defaults
+	seed, checker := 7, true
+	req = &ImageRequest{ImageSize: "square", NegativePrompt: "text", OutputFormat: "jpeg", Seed: &seed, EnableSafetyChecker: &checker}
+	req.ModelType = "text2image"
+	applySettings(req, settings)
+	if req.AspectRatio != "" || req.ImageSize != "square" || req.NegativePrompt != "text" || req.OutputFormat != "jpeg" || *req.Seed != 7 {
+		t.Errorf("flags overridden by defaults: %+v", req)
+	}
+	if !*req.EnableSafetyChecker || req.SafetyTolerance != "" {
+		t.Errorf("safety = %v, %q; want the flag kept", *req.EnableSafetyChecker, req.SafetyTolerance)
+	}
+
+	// Edits keep the source image's shape
+	req = &ImageRequest{}
+	req.ModelType = "image2image"
+	applySettings(req, settings)
+	if req.AspectRatio != "" || req.ImageSize != "" {
+		t.Errorf("aspect applied to an edit: %q, %q", req.AspectRatio, req.ImageSize)
+	}
+}
@@ -0,0 +1,130 @@
+package image
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+
+	// Register the decoders for formats fal returns
+	_ "image/gif"
+	_ "image/png"
+)
+
+// DefaultMaxEmbedBytes is the largest base64 embed payload sent inline when
+// maxembedbytes is not configured.
+const DefaultMaxEmbedBytes = 1 << 20
+
+// embedQualitySteps are the JPEG qualities tried at each scale, best first.
+var embedQualitySteps = []int{90, 75, 60, 45}
+
+// embedScaleSteps are the downscale factors tried once quality stepping alone
+// cannot fit the payload.
+var embedScaleSteps = []float64{1, 0.75, 0.5, 0.35, 0.25}
+
+// embedFit describes how an image was made to fit the embed limit.
+type embedFit struct {
+	Data          []byte
+	ContentType   string
+	Compressed    bool
+	Quality       int
+	Width, Height int
+	OriginalBytes int
+}
+
+// encodedLen returns the size of data once base64 encoded into an embed.
+func encodedLen(n int) int {
+	return base64.StdEncoding.EncodedLen(n)
+}
+
+// fitEmbed returns data unchanged when it already fits under maxBytes once
+// encoded. Otherwise it re-encodes the image as JPEG, stepping quality down
+// and then scaling the image down, until the payload fits. It returns an
+// error when the image cannot be decoded or does not fit at any step.
+func fitEmbed(data []byte, contentType string, maxBytes int) (*embedFit, error) {
+	if maxBytes <= 0 || encodedLen(len(data)) <= maxBytes {
+		return &embedFit{Data: data, ContentType: contentType, OriginalBytes: len(data)}, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image for compression: %w", err)
+	}
+
+	for _, scale := range embedScaleSteps {
+		scaled := scaleImage(src, scale)
+		for _, quality := range embedQualitySteps {
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: quality}); err != nil {
+				return nil, fmt.Errorf("failed to encode image: %w", err)
+			}
+			if encodedLen(buf.Len()) <= maxBytes {
+				b := scaled.Bounds()
+				return &embedFit{
+					Data:          buf.Bytes(),
+					ContentType:   "image/jpeg",
+					Compressed:    true,
+					Quality:       quality,
+					Width:         b.Dx(),
+					Height:        b.Dy(),
+					OriginalBytes: len(data),
+				}, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("image does not fit the %d byte embed limit even at lowest quality", maxBytes)
+}
+
+// scaleImage downscales src by factor using box averaging. Transparent areas
+// are flattened onto white since the result is encoded as JPEG.
+func scaleImage(src image.Image, factor float64) image.Image {
+	sb := src.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, sb.Dx(), sb.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, sb.Min, draw.Over)
+	if factor >= 1 {
+		return flat
+	}
+
+	dw := max(1, int(float64(sb.Dx())*factor))
+	dh := max(1, int(float64(sb.Dy())*factor))
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0 := y * sb.Dy() / dh
+		y1 := max(y0+1, (y+1)*sb.Dy()/dh)
+		for x := 0; x < dw; x++ {
+			x0 := x * sb.Dx() / dw
+			x1 := max(x0+1, (x+1)*sb.Dx()/dw)
+			var r, g, b, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					off := flat.PixOffset(sx, sy)
+					r += uint32(flat.Pix[off])
+					g += uint32(flat.Pix[off+1])
+					b += uint32(flat.Pix[off+2])
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), 0xff})
+		}
+	}
+	return dst
+}
+
+// formatEmbedFit describes the compression applied to an image for the user.
+func formatEmbedFit(fit *embedFit, index, total int) string {
+	return fmt.Sprintf("🗜️ Image %d/%d was compressed to fit the message size limit: JPEG quality %d, %dx%d (%s → %s).",
+		index+1, total, fit.Quality, fit.Width, fit.Height, formatSize(fit.OriginalBytes), formatSize(len(fit.Data)))
+}
+
+// formatSize renders a byte count in KB or MB.
+func formatSize(n int) string {
+	if n >= 1<<20 {
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	}
+	return fmt.Sprintf("%d KB", (n+1023)/1024)
+}
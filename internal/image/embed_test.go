@@ -0,0 +1,88 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"testing"
+)
+
+// noisyPNG returns a PNG that compresses poorly so size limits bite.
+func noisyPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFitEmbed(t *testing.T) {
+	data := noisyPNG(t, 256, 256)
+
+	t.Run("fits unchanged", func(t *testing.T) {
+		fit, err := fitEmbed(data, "image/png", encodedLen(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fit.Compressed || !bytes.Equal(fit.Data, data) || fit.ContentType != "image/png" {
+			t.Fatalf("expected original image, got compressed=%v type=%s", fit.Compressed, fit.ContentType)
+		}
+	})
+
+	t.Run("compresses to limit", func(t *testing.T) {
+		limit := encodedLen(len(data)) / 4
+		fit, err := fitEmbed(data, "image/png", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fit.Compressed || fit.ContentType != "image/jpeg" {
+			t.Fatalf("expected JPEG re-encode, got compressed=%v type=%s", fit.Compressed, fit.ContentType)
+		}
+		if encodedLen(len(fit.Data)) > limit {
+			t.Fatalf("payload %d exceeds limit %d", encodedLen(len(fit.Data)), limit)
+		}
+		if _, err := png.Decode(bytes.NewReader(fit.Data)); err == nil {
+			t.Fatal("expected non-PNG output")
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		if _, err := fitEmbed(data, "image/png", 100); err == nil {
+			t.Fatal("expected error for impossible limit")
+		}
+	})
+
+	t.Run("undecodable", func(t *testing.T) {
+		if _, err := fitEmbed(bytes.Repeat([]byte{1}, 4096), "image/webp", 100); err == nil {
+			t.Fatal("expected decode error")
+		}
+	})
+}
+
+func TestEmbedLimit(t *testing.T) {
+	s := &ImageService{maxEmbedBytes: 1000}
+	if got := s.embedLimit(false); got != 1000 {
+		t.Errorf("GC limit without a GC cap = %d, want 1000", got)
+	}
+	s.SetGCMaxEmbedBytes(400)
+	if got := s.embedLimit(false); got != 400 {
+		t.Errorf("GC limit = %d, want 400", got)
+	}
+	if got := s.embedLimit(true); got != 1000 {
+		t.Errorf("PM limit = %d, want 1000", got)
+	}
+	s.SetGCMaxEmbedBytes(5000)
+	if got := s.embedLimit(false); got != 1000 {
+		t.Errorf("GC cap above the PM limit = %d, want 1000", got)
+	}
+}
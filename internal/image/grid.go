@@ -0,0 +1,132 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/karamble/braibot/internal/debuglog"
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+// galleryCellMax caps the width and height of one cell of a gallery grid.
+const galleryCellMax = 768
+
+// galleryGap is the white border between and around the grid cells.
+const galleryGap = 8
+
+// useGallery reports whether n results of req are sent as one grid image.
+// Galleries are on by default in GCs, where separate images flood the chat,
+// and can be turned on or off per request with --gallery.
+func useGallery(req *ImageRequest, n int) bool {
+	if n < 2 {
+		return false
+	}
+	if req.Gallery != nil {
+		return *req.Gallery
+	}
+	return !req.IsPM
+}
+
+// composeGrid lays imgs out in a near-square grid on a white background.
+// Every cell is as large as the largest image, capped at galleryCellMax,
+// and each image is scaled down to fit its cell and centered in it.
+func composeGrid(imgs []image.Image) *image.RGBA {
+	cols := int(math.Ceil(math.Sqrt(float64(len(imgs)))))
+	rows := (len(imgs) + cols - 1) / cols
+
+	var cw, ch int
+	for _, img := range imgs {
+		cw = max(cw, img.Bounds().Dx())
+		ch = max(ch, img.Bounds().Dy())
+	}
+	cw, ch = min(cw, galleryCellMax), min(ch, galleryCellMax)
+
+	grid := image.NewRGBA(image.Rect(0, 0, cols*cw+(cols+1)*galleryGap, rows*ch+(rows+1)*galleryGap))
+	draw.Draw(grid, grid.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	for i, img := range imgs {
+		b := img.Bounds()
+		scaled := scaleImage(img, min(1, float64(cw)/float64(b.Dx()), float64(ch)/float64(b.Dy())))
+		sb := scaled.Bounds()
+		x := galleryGap + (i%cols)*(cw+galleryGap) + (cw-sb.Dx())/2
+		y := galleryGap + (i/cols)*(ch+galleryGap) + (ch-sb.Dy())/2
+		draw.Draw(grid, image.Rect(x, y, x+sb.Dx(), y+sb.Dy()), scaled, sb.Min, draw.Src)
+	}
+	return grid
+}
+
+// sendGallery composites the images into one grid, sends it as a single
+// embed and lists the individual image URLs below it. It returns an error
+// without sending anything when an image cannot be fetched or decoded, so
+// the caller can fall back to sending the images one by one.
+func (s *ImageService) sendGallery(ctx context.Context, req *ImageRequest, imgs []fal.ImageOutput) error {
+	decoded := make([]image.Image, 0, len(imgs))
+	for i, img := range imgs {
+		if img.URL == "" || !strings.HasPrefix(img.ContentType, "image/") || strings.Contains(img.ContentType, "svg") {
+			return fmt.Errorf("image %d/%d cannot be added to a gallery", i+1, len(imgs))
+		}
+		data, err := fetchImage(ctx, img.URL)
+		if err != nil {
+			return fmt.Errorf("failed to fetch image %d/%d: %w", i+1, len(imgs), err)
+		}
+		src, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to decode image %d/%d: %w", i+1, len(imgs), err)
+		}
+		decoded = append(decoded, src)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, composeGrid(decoded), &jpeg.Options{Quality: embedQualitySteps[0]}); err != nil {
+		return fmt.Errorf("failed to encode gallery: %w", err)
+	}
+	fit, err := fitEmbed(buf.Bytes(), "image/jpeg", s.embedLimit(req.IsPM))
+	if err != nil {
+		return fmt.Errorf("gallery too large to embed: %w", err)
+	}
+	debuglog.Debugf(debuglog.Delivery, "Composited %d images into a %d byte gallery for %s", len(imgs), len(fit.Data), req.UserNick)
+
+	alt := fmt.Sprintf("%s gallery of %d images", req.ModelName, len(imgs))
+	if err := s.sender.SendEmbed(ctx, req.MessageContext(), alt, fit.ContentType, fit.Data); err != nil {
+		return err
+	}
+	if err := s.sender.SendMessage(ctx, req.MessageContext(), formatGalleryLinks(imgs)); err != nil {
+		s.jobLog(req).Warnf("Failed to send gallery links: %v", err)
+	}
+	return nil
+}
+
+// formatGalleryLinks lists the full size images of a gallery in grid order.
+func formatGalleryLinks(imgs []fal.ImageOutput) string {
+	var b strings.Builder
+	b.WriteString("🖼️ Full size images, left to right and top to bottom:")
+	for i, img := range imgs {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, img.URL)
+	}
+	return b.String()
+}
+
+// fetchImage downloads the image at url.
+func fetchImage(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,76 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+func TestUseGallery(t *testing.T) {
+	on, off := true, false
+	tests := []struct {
+		name    string
+		isPM    bool
+		gallery *bool
+		n       int
+		want    bool
+	}{
+		{"GC default", false, nil, 4, true},
+		{"PM default", true, nil, 4, false},
+		{"single image", false, &on, 1, false},
+		{"PM opt in", true, &on, 2, true},
+		{"GC opt out", false, &off, 4, false},
+	}
+	for _, tt := range tests {
+		req := &ImageRequest{Gallery: tt.gallery}
+		req.IsPM = tt.isPM
+		if got := useGallery(req, tt.n); got != tt.want {
+			t.Errorf("%s: useGallery = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestComposeGrid(t *testing.T) {
+	solid := func(w, h int, c color.RGBA) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+		return img
+	}
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+
+	// Three images take a 2x2 grid with cells as large as the largest image
+	grid := composeGrid([]image.Image{solid(100, 50, red), solid(100, 100, blue), solid(40, 40, red)})
+	wantW, wantH := 2*100+3*galleryGap, 2*100+3*galleryGap
+	if b := grid.Bounds(); b.Dx() != wantW || b.Dy() != wantH {
+		t.Fatalf("grid is %dx%d, want %dx%d", b.Dx(), b.Dy(), wantW, wantH)
+	}
+	// The wide first image is centered vertically in its cell
+	if got := grid.RGBAAt(galleryGap+50, galleryGap+50); got != red {
+		t.Errorf("center of cell 1 = %v, want red", got)
+	}
+	if got := grid.RGBAAt(galleryGap+50, galleryGap+5); got != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("padding above the wide image = %v, want white", got)
+	}
+	if got := grid.RGBAAt(2*galleryGap+150, galleryGap+50); got != blue {
+		t.Errorf("center of cell 2 = %v, want blue", got)
+	}
+
+	// Large images are scaled down to the cell cap
+	grid = composeGrid([]image.Image{solid(2000, 1000, red), solid(2000, 1000, blue)})
+	if b := grid.Bounds(); b.Dx() != 2*galleryCellMax+3*galleryGap || b.Dy() != galleryCellMax+2*galleryGap {
+		t.Errorf("capped grid is %dx%d", b.Dx(), b.Dy())
+	}
+}
+
+func TestFormatGalleryLinks(t *testing.T) {
+	msg := formatGalleryLinks([]fal.ImageOutput{{URL: "https://x/1.png"}, {URL: "https://x/2.png"}})
+	if !strings.Contains(msg, "1. https://x/1.png") || !strings.Contains(msg, "2. https://x/2.png") {
+		t.Errorf("formatGalleryLinks = %q", msg)
+	}
+}
@@ -0,0 +1,95 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+// NSFWAction decides what happens to an image the model flagged as NSFW.
+type NSFWAction string
+
+// NSFW actions, from the most to the least permissive.
+const (
+	NSFWAllow  NSFWAction = "allow" // Deliver it like any other image
+	NSFWWarn   NSFWAction = "warn"  // Deliver it after a warning; images cannot be blurred
+	NSFWPMOnly NSFWAction = "pm"    // Deliver it only by PM, also for GC requests
+	NSFWBlock  NSFWAction = "block" // Withhold it without charging for it
+)
+
+// NSFWActions lists the valid actions.
+var NSFWActions = []NSFWAction{NSFWAllow, NSFWWarn, NSFWPMOnly, NSFWBlock}
+
+// ParseNSFWAction parses an action name.
+func ParseNSFWAction(s string) (NSFWAction, error) {
+	for _, a := range NSFWActions {
+		if strings.EqualFold(s, string(a)) {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("invalid NSFW policy %q (must be allow, warn, pm or block)", s)
+}
+
+// NSFWPolicy configures how images flagged as NSFW are delivered. GCs may
+// override the GC action with their own, stored in the database.
+type NSFWPolicy struct {
+	PM NSFWAction // Requests made in PMs
+	GC NSFWAction // Requests made in GCs without an action of their own
+}
+
+// DefaultNSFWPolicy warns in PMs and keeps flagged images out of GCs.
+var DefaultNSFWPolicy = NSFWPolicy{PM: NSFWWarn, GC: NSFWPMOnly}
+
+// SetNSFWPolicy replaces the NSFW policy.
+func (s *ImageService) SetNSFWPolicy(p NSFWPolicy) {
+	s.nsfw = p
+}
+
+// nsfwAction returns the action for flagged images of a request.
+func (s *ImageService) nsfwAction(req *ImageRequest) NSFWAction {
+	if req.IsPM {
+		return s.nsfw.PM
+	}
+	if v, err := s.dbManager.GCNSFWPolicy(req.GC); err != nil {
+		s.jobLog(req).Warnf("Failed to get the NSFW policy of the GC: %v", err)
+	} else if a, err := ParseNSFWAction(v); err == nil {
+		return a
+	}
+	return s.nsfw.GC
+}
+
+// nsfwTarget applies the NSFW policy to image i of a response and returns
+// the request to deliver the image for: req itself, a PM copy of it for
+// images that may only be sent by PM, or nil when the image is withheld.
+func (s *ImageService) nsfwTarget(ctx context.Context, req *ImageRequest, resp *fal.ImageResponse, i int) *ImageRequest {
+	if !resp.IsNSFW(i) {
+		return req
+	}
+	label := fmt.Sprintf("Image %d/%d", i+1, len(resp.Images))
+	var note string
+	target := req
+	switch s.nsfwAction(req) {
+	case NSFWAllow:
+		return req
+	case NSFWWarn:
+		note = fmt.Sprintf("⚠️ %s was flagged as NSFW by the model. It cannot be blurred, so it follows as is.", label)
+	case NSFWPMOnly:
+		if req.IsPM {
+			return req
+		}
+		note = fmt.Sprintf("🔞 %s was flagged as NSFW by the model, so it is sent to %s by PM instead.", label, utils.SanitizeUserText(req.UserNick))
+		pm := *req
+		pm.IsPM, pm.GC = true, ""
+		target = &pm
+	default:
+		note = fmt.Sprintf("🚫 %s was flagged as NSFW by the model and withheld. You are not charged for it.", label)
+		target = nil
+	}
+	if err := s.sender.SendMessage(ctx, req.MessageContext(), note); err != nil {
+		s.jobLog(req).Warnf("Failed to send NSFW notice: %v", err)
+	}
+	return target
+}
@@ -0,0 +1,39 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/karamble/braibot/internal/database"
+	braibottypes "github.com/karamble/braibot/internal/types"
+)
+
+func TestNSFWAction(t *testing.T) {
+	db, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer db.Close()
+	s := &ImageService{dbManager: db, nsfw: DefaultNSFWPolicy}
+
+	pm := &ImageRequest{GenerationRequest: braibottypes.GenerationRequest{IsPM: true}}
+	gc := &ImageRequest{GenerationRequest: braibottypes.GenerationRequest{GC: "Art"}}
+	if a := s.nsfwAction(pm); a != NSFWWarn {
+		t.Errorf("PM action = %s, want warn", a)
+	}
+	if a := s.nsfwAction(gc); a != NSFWPMOnly {
+		t.Errorf("GC action = %s, want pm", a)
+	}
+	if err := db.SetGCNSFWPolicy("art", string(NSFWBlock)); err != nil {
+		t.Fatal(err)
+	}
+	if a := s.nsfwAction(gc); a != NSFWBlock {
+		t.Errorf("GC action with override = %s, want block", a)
+	}
+
+	if a, err := ParseNSFWAction("PM"); err != nil || a != NSFWPMOnly {
+		t.Errorf("ParseNSFWAction(PM) = %s, %v", a, err)
+	}
+	if _, err := ParseNSFWAction("blur"); err == nil {
+		t.Error("ParseNSFWAction(blur) succeeded")
+	}
+}
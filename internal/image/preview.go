@@ -0,0 +1,167 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/karamble/braibot/internal/jobevents"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// PreviewPolicy configures the low-res thumbnail pre-pass offered for
+// premium text2image models.
+type PreviewPolicy struct {
+	Enabled     bool
+	Model       string  // Cheap text2image model that renders the thumbnail
+	ImageSize   string  // Thumbnail size preset passed to the preview model
+	PriceUSD    float64 // Charged per preview; 0 means the operator pays
+	DailyLimit  int     // Previews per user per UTC day
+	MinPriceUSD float64 // Previews are offered for models at or above this price
+}
+
+// DefaultPreviewPolicy renders free 512x512 flux/schnell thumbnails, three per
+// user per day, for models costing $0.10 or more. Previews stay off until the
+// operator enables them.
+var DefaultPreviewPolicy = PreviewPolicy{
+	Model:       "flux/schnell",
+	ImageSize:   "square",
+	DailyLimit:  3,
+	MinPriceUSD: 0.10,
+}
+
+// SetPreviewPolicy replaces the preview policy.
+func (s *ImageService) SetPreviewPolicy(p PreviewPolicy) {
+	s.preview = p
+}
+
+// GeneratePreview renders a throwaway low-res thumbnail of a text2image
+// request with the preview model, so the user can check the composition
+// before paying for the premium model. It uses one of the user's daily
+// previews and is only billed when the policy sets a price.
+func (s *ImageService) GeneratePreview(ctx context.Context, req *ImageRequest) (*ImageResult, error) {
+	p := s.preview
+	if !p.Enabled {
+		err := fmt.Errorf("previews are not enabled on this bot")
+		return &ImageResult{Success: false, Error: err}, err
+	}
+	if req.PriceUSD < p.MinPriceUSD {
+		err := fmt.Errorf("previews are only offered for models costing $%.2f or more; %s costs $%.2f", p.MinPriceUSD, req.ModelName, req.PriceUSD)
+		return &ImageResult{Success: false, Error: err}, err
+	}
+
+	s.applyUserDefaults(req)
+
+	jobevents.Default.Submit(&req.GenerationRequest)
+	uid := req.UserID.String()
+	now := time.Now()
+	ok, left, err := s.dbManager.TakePreview(uid, now, p.DailyLimit)
+	if err != nil {
+		return &ImageResult{Success: false, Error: err}, err
+	}
+	if !ok {
+		err := fmt.Errorf("you have used all %d previews for today", p.DailyLimit)
+		return &ImageResult{Success: false, Error: err}, err
+	}
+	delivered := false
+	defer func() {
+		if !delivered {
+			if err := s.dbManager.ReturnPreview(uid, now); err != nil {
+				s.jobLog(req).Warnf("Failed to return the preview: %v", err)
+			}
+		}
+	}()
+
+	// Pick a seed up front so the user can carry it to the full render
+	seed := rand.IntN(1 << 31)
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+	previewReq := &ImageRequest{
+		GenerationRequest:   req.GenerationRequest,
+		Prompt:              req.Prompt,
+		ImageSize:           p.ImageSize,
+		Seed:                &seed,
+		EnableSafetyChecker: req.EnableSafetyChecker,
+	}
+	previewReq.ModelName = p.Model
+	previewReq.PriceUSD = p.PriceUSD
+
+	billed := s.billingEnabled.Load() && p.PriceUSD > 0
+	if billed {
+		if _, _, err := utils.CheckRequestBalance(ctx, s.dbManager, &previewReq.GenerationRequest, p.PriceUSD, s.debug, s.billingEnabled.Load()); err != nil {
+			jobevents.Default.EmitFailed(&previewReq.GenerationRequest, err)
+			return &ImageResult{Success: false, Error: err}, err
+		}
+	}
+
+	infoMsg := fmt.Sprintf("🔍 Rendering a %s preview with %s (seed %d)...", p.ImageSize, p.Model, seed)
+	if req.IsPM {
+		infoMsg += "\nPrompt: " + utils.PreviewUserText(req.Prompt, utils.PromptPreviewRunes)
+	}
+	if err := utils.SendToUser(ctx, s.bot, req.IsPM, req.UserNick, req.GC, infoMsg); err != nil {
+		s.jobLog(req).Warnf("Failed to send preview message: %v", err)
+	}
+
+	falReq, err := createFalImageRequest(previewReq, 1)
+	if err != nil {
+		return &ImageResult{Success: false, Error: err}, err
+	}
+
+	release, slotErr := utils.AcquireJobSlot(ctx, s.bot, &previewReq.GenerationRequest)
+	if slotErr != nil {
+		jobevents.Default.EmitFailed(&previewReq.GenerationRequest, slotErr)
+		return &ImageResult{Success: false, Error: slotErr}, slotErr
+	}
+	defer release()
+
+	imageResp, genErr := s.client.GenerateImage(ctx, falReq)
+	if genErr != nil {
+		jobevents.Default.EmitFailed(&previewReq.GenerationRequest, genErr)
+		return &ImageResult{Success: false, Error: genErr}, genErr
+	}
+	if len(imageResp.Images) == 0 || imageResp.Images[0].URL == "" {
+		genErr = fmt.Errorf("API did not return a preview image")
+		jobevents.Default.EmitFailed(&previewReq.GenerationRequest, genErr)
+		return &ImageResult{Success: false, Error: genErr}, genErr
+	}
+	output := imageResp.Images[0]
+
+	previewReq.ModelName = "preview"
+	if err := s.sendEmbeddedImage(ctx, previewReq, output, 0, 1, s.embedLimit(previewReq.IsPM)); err != nil {
+		jobevents.Default.EmitFailed(&previewReq.GenerationRequest, err)
+		return &ImageResult{Success: false, Error: err}, err
+	}
+	delivered = true
+	jobevents.Default.EmitDelivered(&previewReq.GenerationRequest, 0)
+
+	finalMessage := fmt.Sprintf("🔍 Preview done (seed %d). The preview model is faster and rougher, so details of the full render will differ. "+
+		"Run the command again without --preview and with --seed last to render it with %s for $%.2f per image. Previews left today: %d.",
+		seed, req.ModelName, req.PriceUSD, left)
+	var split *utils.SplitCharge
+	if billed {
+		var chargedDCR, newBalanceDCR float64
+		var err error
+		chargedDCR, newBalanceDCR, split, err = utils.DeductRequestBalance(ctx, s.dbManager, &previewReq.GenerationRequest, p.PriceUSD, s.debug, s.billingEnabled.Load())
+		if err != nil {
+			finalMessage += fmt.Sprintf("\n\nError processing payment for the preview: %v. Please contact support.", err)
+		} else {
+			jobevents.Default.EmitBilled(&previewReq.GenerationRequest, chargedDCR)
+			if split != nil {
+				finalMessage += "\n\n" + utils.FormatSplitPotReceipt(split)
+			} else if req.IsPM {
+				finalMessage += "\n\n" + utils.FormatBillingConfirmation("preview", true, true, true, chargedDCR, p.PriceUSD, newBalanceDCR) + utils.LowBalanceWarning(s.dbManager, req.UserID.String(), newBalanceDCR, chargedDCR, p.PriceUSD)
+			}
+		}
+	}
+	if err := utils.SendToUser(ctx, s.bot, req.IsPM, req.UserNick, req.GC, finalMessage); err != nil {
+		s.jobLog(req).Warnf("Failed to send preview message: %v", err)
+	}
+	// The requester's share and balance are theirs alone
+	if split != nil {
+		s.sender.SendPrivateMessage(ctx, req.MessageContext(), utils.FormatSplitBillingConfirmation(split, p.PriceUSD)+utils.LowBalanceWarning(s.dbManager, req.UserID.String(), split.UserBalanceDCR, split.UserDCR+split.PotDCR, p.PriceUSD))
+	}
+
+	return &ImageResult{ImageURL: output.URL, Seed: uint64(seed), Success: true}, nil
+}
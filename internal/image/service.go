@@ -2,46 +2,115 @@ package image
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/decred/slog"
 	// Keep for PM type reference if needed indirectly
+	"github.com/karamble/braibot/internal/assets"
 	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/debuglog"
 	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/jobevents"
+	"github.com/karamble/braibot/internal/joblog"
+	"github.com/karamble/braibot/internal/pipeline"
+	"github.com/karamble/braibot/internal/reqcache"
+	"github.com/karamble/braibot/internal/templates"
+	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
 	"github.com/karamble/braibot/pkg/fal"
+	"github.com/karamble/braibot/pkg/genai"
 	kit "github.com/vctt94/bisonbotkit"
 )
 
 // ImageService handles image generation
 type ImageService struct {
-	client         *fal.Client
-	dbManager      *database.DBManager
-	bot            *kit.Bot
-	debug          bool
-	billingEnabled bool // Added billing enabled flag
+	client          genai.ImageGenerator // fal, or a router sending some models to other providers
+	dbManager       *database.DBManager
+	bot             *kit.Bot
+	sender          *braibottypes.MessageSender
+	debug           bool
+	billingEnabled  atomic.Bool // Toggled at runtime by !admin billing
+	maxEmbedBytes   int         // Largest inline image embed payload
+	gcMaxEmbedBytes int         // Lower embed cap for GC messages, 0 = maxEmbedBytes
+	preview         PreviewPolicy
+	nsfw            NSFWPolicy
+	log             slog.Logger
+
+	publisher *assets.Publisher // Asset server for GC links, nil when not configured
+
+	mu      sync.Mutex
+	lastSVG map[string]string // Last SVG result URL by user ID
 }
 
-// NewImageService creates a new ImageService
-func NewImageService(client *fal.Client, dbManager *database.DBManager, bot *kit.Bot, debug bool, billingEnabled bool) *ImageService {
-	return &ImageService{
-		client:         client,
-		dbManager:      dbManager,
-		bot:            bot,
-		debug:          debug,
-		billingEnabled: billingEnabled, // Store the flag
+// NewImageService creates a new ImageService generating images with client
+func NewImageService(client genai.ImageGenerator, dbManager *database.DBManager, bot *kit.Bot, debug bool, billingEnabled bool) *ImageService {
+	s := &ImageService{
+		client:        client,
+		dbManager:     dbManager,
+		bot:           bot,
+		debug:         debug,
+		maxEmbedBytes: DefaultMaxEmbedBytes,
+		preview:       DefaultPreviewPolicy,
+		nsfw:          DefaultNSFWPolicy,
+		log:           joblog.New(nil, joblog.Image),
+		lastSVG:       make(map[string]string),
+		sender:        braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot)),
 	}
+	s.billingEnabled.Store(billingEnabled)
+	return s
+}
+
+// SetBillingEnabled turns charging for generations on or off.
+func (s *ImageService) SetBillingEnabled(enabled bool) {
+	s.billingEnabled.Store(enabled)
+}
+
+// SetMaxEmbedBytes sets the largest base64 payload sent as an inline image
+// embed. Larger images are compressed to fit or sent as a file instead.
+func (s *ImageService) SetMaxEmbedBytes(n int) {
+	if n > 0 {
+		s.maxEmbedBytes = n
+	}
+}
+
+// SetGCMaxEmbedBytes caps image embeds posted in group chats below the PM
+// limit. 0 uses the PM limit.
+func (s *ImageService) SetGCMaxEmbedBytes(n int) {
+	if n >= 0 {
+		s.gcMaxEmbedBytes = n
+	}
+}
+
+// SetLogger sets the logger the service logs its jobs to.
+func (s *ImageService) SetLogger(l slog.Logger) {
+	s.log = l
+}
+
+// jobLog returns a logger for the job of req.
+func (s *ImageService) jobLog(req *ImageRequest) joblog.Logger {
+	return joblog.For(s.log, &req.GenerationRequest)
+}
+
+// SetAssetPublisher sets the asset server that GC results go to when the
+// request prefers links over embeds.
+func (s *ImageService) SetAssetPublisher(p *assets.Publisher) {
+	s.publisher = p
 }
 
 // GenerateImage generates an image based on the request, handling billing after successful result sending.
 func (s *ImageService) GenerateImage(ctx context.Context, req *ImageRequest) (*ImageResult, error) {
-	// 1. Validate request
+	// 1. Fill in the user's saved defaults and validate request
+	s.applyUserDefaults(req)
 	if err := s.validateRequest(req); err != nil {
 		return &ImageResult{Success: false, Error: err}, err
 	}
+	jobevents.Default.Submit(&req.GenerationRequest)
 
 	// 2. Calculate TOTAL cost and CHECK balance if billing is enabled
 	numImagesToRequest := req.NumImages
@@ -49,68 +118,129 @@ func (s *ImageService) GenerateImage(ctx context.Context, req *ImageRequest) (*I
 		numImagesToRequest = 1 // Default to 1 if not specified or invalid
 	}
 	totalExpectedCostUSD := req.PriceUSD * float64(numImagesToRequest) // Calculate total cost first
+	if m, ok := faladapter.GetModel(req.ModelName, req.ModelType); ok {
+		totalExpectedCostUSD = faladapter.PriceFor(m, faladapter.PriceParams{NumImages: numImagesToRequest})
+	}
 
 	var requiredDCR, currentBalanceDCR float64
 	var checkErr error
-	if s.billingEnabled {
+	if s.billingEnabled.Load() {
 		// Call CheckBalance with the TOTAL cost
-		requiredDCR, currentBalanceDCR, checkErr = utils.CheckBalance(ctx, s.dbManager, req.UserID[:], totalExpectedCostUSD, s.debug, s.billingEnabled)
+		requiredDCR, currentBalanceDCR, checkErr = utils.CheckRequestBalance(ctx, s.dbManager, &req.GenerationRequest, totalExpectedCostUSD, s.debug, s.billingEnabled.Load())
 		if checkErr != nil {
 			// Return the error (could be ErrInsufficientBalance or another critical error)
 			// The calling layer (main.go) will handle ErrInsufficientBalance specifically.
+			jobevents.Default.EmitFailed(&req.GenerationRequest, checkErr)
 			return &ImageResult{Success: false, Error: checkErr}, checkErr
 		}
 	}
 
 	// 3. Send initial message (adjusted for billing status)
-	var infoMsg string
-	if s.billingEnabled {
-		infoMsg = fmt.Sprintf("Request cost: $%.2f USD (%.8f DCR). Your balance: %.8f DCR. Processing %d image(s)...", totalExpectedCostUSD, requiredDCR, currentBalanceDCR, numImagesToRequest)
-	} else if eb := req.ExternalBilling; eb != nil {
-		infoMsg = fmt.Sprintf("Request cost: $%.2f USD (%.8f DCR). Your balance: %.8f DCR. Processing %d image(s)...", eb.ChargedUSD, eb.ChargedDCR, eb.BalanceDCR, numImagesToRequest)
-	} else {
-		infoMsg = fmt.Sprintf("Processing your request for %d image(s) (billing disabled)...", numImagesToRequest)
+	notice := templates.Data{
+		Task:       "image",
+		Action:     fmt.Sprintf("Processing %d image(s)", numImagesToRequest),
+		CostUSD:    totalExpectedCostUSD,
+		CostDCR:    requiredDCR,
+		BalanceDCR: currentBalanceDCR,
 	}
-	if req.IsPM {
-		s.bot.SendPM(ctx, req.UserNick, infoMsg)
-	} else {
-		s.bot.SendGC(ctx, req.GC, "Processing your image request...")
+	if eb := req.ExternalBilling; eb != nil && !s.billingEnabled.Load() {
+		notice.CostUSD, notice.CostDCR, notice.BalanceDCR = eb.ChargedUSD, eb.ChargedDCR, eb.BalanceDCR
 	}
+	infoMsg := utils.FormatProcessingNotice(&req.GenerationRequest, s.billingEnabled.Load() || req.ExternalBilling != nil, notice)
+	if req.IsPM && req.Prompt != "" {
+		infoMsg += "\nPrompt: " + utils.PreviewUserText(req.Prompt, utils.PromptPreviewRunes)
+	}
+	s.sender.SendMessage(ctx, req.MessageContext(), infoMsg)
 
 	// 4. Create the appropriate FAL request object using the helper function
 	falReq, err := createFalImageRequest(req, numImagesToRequest)
 	if err != nil {
 		// Handle error from request creation (e.g., unsupported model)
-		s.bot.SendPM(ctx, req.UserNick, fmt.Sprintf("Error creating generation request: %v", err))
+		s.sender.SendPrivateMessage(ctx, req.MessageContext(), fmt.Sprintf("Error creating generation request: %v", err))
 		return &ImageResult{Success: false, Error: err}, err // No billing occurred
 	}
 
-	// 5. Generate image using the created request
-	imageResp, genErr := s.client.GenerateImage(ctx, falReq)
-	if genErr != nil {
-		// Log error server-side, do not PM the user here.
-		// Error will be handled by the command handler.
-		// s.bot.SendPM(ctx, req.UserNick, fmt.Sprintf("Image generation failed: %v", genErr))
-		return &ImageResult{Success: false, Error: genErr}, genErr // Return error to command handler
+	// 5. Reuse the response to an identical recent request, else generate
+	// image using the created request
+	var imageResp *fal.ImageResponse
+	cacheKey, cachedAt, cached := reqcache.Default.Lookup(&req.GenerationRequest, falReq, &imageResp)
+	if !cached {
+		// Wait for a free slot for this kind of job
+		release, slotErr := utils.AcquireJobSlot(ctx, s.bot, &req.GenerationRequest)
+		if slotErr != nil {
+			jobevents.Default.EmitFailed(&req.GenerationRequest, slotErr)
+			return &ImageResult{Success: false, Error: slotErr}, slotErr
+		}
+		defer release()
+
+		var genErr error
+		imageResp, genErr = s.client.GenerateImage(ctx, falReq)
+		if genErr != nil {
+			// Log error server-side, do not PM the user here.
+			// Error will be handled by the command handler.
+			// s.bot.SendPM(ctx, req.UserNick, fmt.Sprintf("Image generation failed: %v", genErr))
+			jobevents.Default.EmitFailed(&req.GenerationRequest, genErr)
+			return &ImageResult{Success: false, Error: genErr}, genErr // Return error to command handler
+		}
+		if len(imageResp.Images) > 0 {
+			reqcache.Default.Store(&req.GenerationRequest, cacheKey, imageResp)
+		}
 	}
 
 	// 6. Check if the image URL is empty - check if *any* images were returned
 	if len(imageResp.Images) == 0 {
-		genErr = fmt.Errorf("API did not return any images")
+		genErr := fmt.Errorf("API did not return any images")
+		jobevents.Default.EmitFailed(&req.GenerationRequest, genErr)
 		// Log error server-side, do not PM the user here.
 		// Error will be handled by the command handler.
 		// s.bot.SendPM(ctx, req.UserNick, genErr.Error())
 		return &ImageResult{Success: false, Error: genErr}, genErr // Return error to command handler
 	}
 
-	// 7. Send the image(s) - loop through results
+	// 7. Send the image(s) - as one grid in gallery mode, otherwise loop through results
 	numImagesGenerated := len(imageResp.Images)
 	successfullySentCount := 0
 	var lastSentImageURL string // Keep track of the last URL for the result
-	for i, img := range imageResp.Images {
+	pending := imageResp.Images
+
+	// Apply the NSFW policy: flagged images may go by PM or be withheld
+	targets := make([]*ImageRequest, numImagesGenerated)
+	flagged, withheld := false, 0
+	for i := range imageResp.Images {
+		targets[i] = s.nsfwTarget(ctx, req, imageResp, i)
+		flagged = flagged || targets[i] != req
+		if targets[i] == nil {
+			withheld++
+		}
+	}
+	if withheld > 0 {
+		// Withheld images are not charged
+		totalExpectedCostUSD = req.PriceUSD * float64(numImagesToRequest-withheld)
+		if m, ok := faladapter.GetModel(req.ModelName, req.ModelType); ok {
+			totalExpectedCostUSD = faladapter.PriceFor(m, faladapter.PriceParams{NumImages: numImagesToRequest - withheld})
+		}
+	}
+
+	if !flagged && useGallery(req, numImagesGenerated) && !(req.AssetLink && !req.IsPM && s.publisher != nil) {
+		if err := s.sendGallery(ctx, req, imageResp.Images); err != nil {
+			s.jobLog(req).Warnf("Sending images separately: %v", err)
+		} else {
+			successfullySentCount = numImagesGenerated
+			lastSentImageURL = imageResp.Images[numImagesGenerated-1].URL
+			pending = nil
+			for _, img := range imageResp.Images {
+				utils.RememberResult(s.dbManager, &req.GenerationRequest, database.ResultImage, img.URL)
+			}
+		}
+	}
+	for i, img := range pending {
+		target := targets[i]
+		if target == nil {
+			continue
+		}
 		if img.URL == "" {
 			// Log error, do not PM
-			fmt.Printf("WARN [ImageService] User %s: Skipping image %d/%d: received empty URL from API.\n", req.UserNick, i+1, numImagesGenerated)
+			jobevents.Default.EmitFailed(&req.GenerationRequest, fmt.Errorf("skipping image %d/%d: received empty URL from API", i+1, numImagesGenerated))
 			// s.bot.SendPM(ctx, req.UserNick, fmt.Sprintf("Skipping image %d/%d: received empty URL from API.", i+1, numImagesGenerated))
 			continue
 		}
@@ -120,28 +250,40 @@ func (s *ImageService) GenerateImage(ctx context.Context, req *ImageRequest) (*I
 
 		var sendErr error
 		if strings.Contains(contentType, "svg") || !strings.HasPrefix(contentType, "image/") {
-			// For SVG or non-standard image formats, use SendFile
-			sendErr = utils.SendFileToUser(ctx, s.bot, req.UserNick, img.URL, "image", contentType)
+			// For SVG or non-standard image formats, use SendFile, which
+			// only reaches users, so GCs get a link
+			if target.IsPM {
+				sendErr = utils.SendFileToUser(ctx, s.bot, target.UserNick, img.URL, "image", contentType)
+			} else {
+				sendErr = utils.SendGCLink(ctx, s.bot, s.publisher, target.GC, fmt.Sprintf("Image %d/%d", i+1, numImagesGenerated), img.URL)
+			}
+			if sendErr == nil && strings.Contains(contentType, "svg") {
+				s.rememberSVG(req.UserID.String(), img.URL)
+			}
 		} else {
 			// For standard image formats, use PM embed
-			sendErr = sendEmbeddedImage(ctx, s.bot, req, img, i, numImagesGenerated)
+			sendErr = s.sendImage(ctx, target, img, i, numImagesGenerated)
 		}
 
 		if sendErr != nil {
 			// Log error, do not PM
-			fmt.Printf("ERROR [ImageService] User %s: Failed to send image %d/%d: %v\n", req.UserNick, i+1, numImagesGenerated, sendErr)
+			jobevents.Default.EmitFailed(&req.GenerationRequest, fmt.Errorf("failed to send image %d/%d: %w", i+1, numImagesGenerated, sendErr))
 			// s.bot.SendPM(ctx, req.UserNick, fmt.Sprintf("Failed to send image %d/%d: %v", i+1, numImagesGenerated, sendErr))
 			// Optionally continue to try sending other images
 		} else {
 			successfullySentCount++
+			utils.RememberResult(s.dbManager, &req.GenerationRequest, database.ResultImage, img.URL)
 		}
 	}
+	if successfullySentCount > 0 {
+		jobevents.Default.EmitDelivered(&req.GenerationRequest, successfullySentCount)
+	}
 
 	// Send seed information if available
 	if imageResp.Seed != 0 {
-		seedMsg := fmt.Sprintf("🌱 Seed for the request: %d", imageResp.Seed)
+		seedMsg := fmt.Sprintf("🌱 Seed for the request: %d\nReuse it with --seed last, or get new takes with !variations last.", imageResp.Seed)
 		if err := utils.SendToUser(ctx, s.bot, req.IsPM, req.UserNick, req.GC, seedMsg); err != nil {
-			fmt.Printf("WARN: Failed to send seed message: %v\n", err)
+			s.jobLog(req).Warnf("Failed to send seed message: %v", err)
 		}
 	}
 
@@ -150,44 +292,62 @@ func (s *ImageService) GenerateImage(ctx context.Context, req *ImageRequest) (*I
 	var finalBalanceDCR float64 = currentBalanceDCR // Start with the balance known before potential deduction
 	var billingAttempted bool = false
 	var billingSucceeded bool = false
+	var splitCharge *utils.SplitCharge // Set when the charge was split with a GC pot
 
-	if s.billingEnabled && successfullySentCount > 0 {
+	if s.billingEnabled.Load() && successfullySentCount > 0 && !cached {
 		billingAttempted = true
-		deductChargedDCR, deductNewBalance, deductErr := utils.DeductBalance(ctx, s.dbManager, req.UserID[:], totalExpectedCostUSD, s.debug, s.billingEnabled)
+		deductChargedDCR, deductNewBalance, deductSplit, deductErr := utils.DeductRequestBalance(ctx, s.dbManager, &req.GenerationRequest, totalExpectedCostUSD, s.debug, s.billingEnabled.Load())
 		if deductErr != nil {
 			if req.IsPM {
-				s.bot.SendPM(ctx, req.UserNick, fmt.Sprintf("Error processing payment after sending results: %v. Please contact support.", deductErr))
+				s.sender.SendMessage(ctx, req.MessageContext(), fmt.Sprintf("Error processing payment after sending results: %v. Please contact support.", deductErr))
 			}
 			finalBalanceDCR = currentBalanceDCR
 		} else {
 			billingSucceeded = true
+			splitCharge = deductSplit
 			chargedDCR = deductChargedDCR
 			finalBalanceDCR = deductNewBalance
+			jobevents.Default.EmitBilled(&req.GenerationRequest, chargedDCR)
 		}
-	} else if !s.billingEnabled {
+	} else if !s.billingEnabled.Load() {
 		// fmt.Printf("INFO: Billing is disabled. No charge applied for user %s.\n", req.UserNick) // Already Removed
 	} else {
 		// Billing enabled, but no images sent successfully
 		// fmt.Printf("INFO: No images sent successfully for user %s. No billing occurred.\n", req.UserNick) // Removed
 	}
 
+	s.jobLog(req).Infof("Sent %d of %d image(s), %d withheld, charged %.8f DCR", successfullySentCount, numImagesGenerated, withheld, chargedDCR)
+
 	// 9. Send final confirmation
-	finalMessage := fmt.Sprintf("Finished processing request. Sent %d of %d generated image(s).\n\n", successfullySentCount, numImagesGenerated)
+	finalMessage := utils.FormatFinished(&req.GenerationRequest, templates.Data{Task: "image", Sent: successfullySentCount, Generated: numImagesGenerated}) + "\n\n"
 
 	if req.IsPM {
-		if eb := req.ExternalBilling; eb != nil && !s.billingEnabled {
-			finalMessage += utils.FormatBillingConfirmation("results", true, true, true, eb.ChargedDCR, eb.ChargedUSD, eb.BalanceDCR)
+		if cached {
+			finalMessage += utils.FormatCachedResult(time.Since(cachedAt))
+		} else if eb := req.ExternalBilling; eb != nil && !s.billingEnabled.Load() {
+			finalMessage += utils.FormatBillingConfirmation("results", true, true, true, eb.ChargedDCR, eb.ChargedUSD, eb.BalanceDCR) + utils.LowBalanceWarning(s.dbManager, req.UserID.String(), eb.BalanceDCR, eb.ChargedDCR, eb.ChargedUSD)
 		} else {
-			finalMessage += utils.FormatBillingConfirmation("results", s.billingEnabled, billingAttempted, billingSucceeded, chargedDCR, totalExpectedCostUSD, finalBalanceDCR)
+			finalMessage += utils.FormatBillingConfirmation("results", s.billingEnabled.Load(), billingAttempted, billingSucceeded, chargedDCR, totalExpectedCostUSD, finalBalanceDCR) + utils.LowBalanceWarning(s.dbManager, req.UserID.String(), finalBalanceDCR, chargedDCR, totalExpectedCostUSD)
 		}
-		if err := s.bot.SendPM(ctx, req.UserNick, finalMessage); err != nil {
+		if err := s.sender.SendMessage(ctx, req.MessageContext(), finalMessage); err != nil {
 			// Log error, but don't fail the whole operation just because the final message failed
 			// fmt.Printf("ERROR: Failed to send final confirmation message to %s: %v\n", req.UserNick, err) // Removed
 		}
 	} else {
-		if err := s.bot.SendGC(ctx, req.GC, "Image generation completed."); err != nil {
+		gcMessage := utils.FormatFinished(&req.GenerationRequest, templates.Data{Task: "image", Sent: successfullySentCount, Generated: numImagesGenerated})
+		if cached {
+			gcMessage += "\n\n" + utils.FormatCachedResult(time.Since(cachedAt))
+		}
+		if splitCharge != nil {
+			gcMessage += "\n\n" + utils.FormatSplitPotReceipt(splitCharge)
+		}
+		if err := s.sender.SendMessage(ctx, req.MessageContext(), gcMessage); err != nil {
 			// fmt.Printf("ERROR: Failed to send final confirmation message (image) to GC %s: %v\n", req.GC, err) // Removed
 		}
+		// The requester's share and balance are theirs alone
+		if splitCharge != nil {
+			s.sender.SendPrivateMessage(ctx, req.MessageContext(), utils.FormatSplitBillingConfirmation(splitCharge, totalExpectedCostUSD)+utils.LowBalanceWarning(s.dbManager, req.UserID.String(), splitCharge.UserBalanceDCR, splitCharge.UserDCR+splitCharge.PotDCR, totalExpectedCostUSD))
+		}
 	}
 
 	// Return success if at least one image was generated, using the last URL
@@ -197,15 +357,198 @@ func (s *ImageService) GenerateImage(ctx context.Context, req *ImageRequest) (*I
 		return &ImageResult{
 			ImageURL: lastSentImageURL, // Return the URL of the last image generated/sent
 			Success:  true,             // Represents successful generation from the API
+			Seed:     imageResp.Seed,
 		}, nil
+	} else if withheld == numImagesGenerated {
+		// Every image was withheld by the NSFW policy, which the user was told
+		return &ImageResult{Success: true}, nil
 	} else {
 		// This case should ideally be caught earlier, but as a fallback
 		return &ImageResult{Success: false, Error: fmt.Errorf("no images were generated successfully")}, nil
 	}
 }
 
+// RestoreImage runs a chain of image2image models, feeding each step's output
+// into the next, and sends only the final image. The chain runs as a
+// pipeline under req.CeilingUSD: when the next step would exceed it, the
+// output of the last step that ran is sent. The chain is billed once after
+// the final image was delivered, at req.PriceUSD or, when the ceiling
+// stopped it, at the price of the steps that ran.
+func (s *ImageService) RestoreImage(ctx context.Context, req *RestoreRequest) (*ImageResult, error) {
+	if req.ImageURL == "" {
+		err := fmt.Errorf("image URL is required for restore")
+		return &ImageResult{Success: false, Error: err}, err
+	}
+	if len(req.Steps) == 0 {
+		err := fmt.Errorf("no restore steps configured")
+		return &ImageResult{Success: false, Error: err}, err
+	}
+	jobevents.Default.Submit(&req.GenerationRequest)
+
+	// 1. CHECK balance for the whole chain if billing is enabled
+	var requiredDCR, currentBalanceDCR float64
+	var checkErr error
+	if s.billingEnabled.Load() {
+		requiredDCR, currentBalanceDCR, checkErr = utils.CheckBalance(ctx, s.dbManager, req.UserID[:], req.PriceUSD, s.debug, s.billingEnabled.Load())
+		if checkErr != nil {
+			jobevents.Default.EmitFailed(&req.GenerationRequest, checkErr)
+			return &ImageResult{Success: false, Error: checkErr}, checkErr
+		}
+	}
+
+	// 2. Send initial message (adjusted for billing status)
+	chain := strings.Join(req.Steps, " → ")
+	notice := templates.Data{
+		Task:       "image restoration",
+		Action:     fmt.Sprintf("Restoring your image (%s)", chain),
+		CostUSD:    req.PriceUSD,
+		CostDCR:    requiredDCR,
+		BalanceDCR: currentBalanceDCR,
+	}
+	if eb := req.ExternalBilling; eb != nil && !s.billingEnabled.Load() {
+		notice.CostUSD, notice.CostDCR, notice.BalanceDCR = eb.ChargedUSD, eb.ChargedDCR, eb.BalanceDCR
+	}
+	s.sender.SendMessage(ctx, req.MessageContext(), utils.FormatProcessingNotice(&req.GenerationRequest, s.billingEnabled.Load() || req.ExternalBilling != nil, notice))
+
+	// Wait for a free slot for this kind of job
+	release, slotErr := utils.AcquireJobSlot(ctx, s.bot, &req.GenerationRequest)
+	if slotErr != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, slotErr)
+		return &ImageResult{Success: false, Error: slotErr}, slotErr
+	}
+	defer release()
+
+	// 3. Run each step on the previous step's output, stopping before a step
+	// that would take the chain over its cost ceiling
+	stepReq := req.ImageRequest
+	var output fal.ImageOutput
+	steps := make([]pipeline.Step, len(req.Steps))
+	for i, step := range req.Steps {
+		var price float64
+		if i < len(req.StepPricesUSD) {
+			price = req.StepPricesUSD[i]
+		}
+		steps[i] = pipeline.Step{
+			Name:        step,
+			EstimateUSD: price,
+			Run: func(ctx context.Context) (pipeline.StepResult, error) {
+				falReq, err := restoreStepRequest(stepReq, step)
+				if err != nil {
+					return pipeline.StepResult{}, err
+				}
+				imageResp, err := s.client.GenerateImage(ctx, falReq)
+				if err != nil {
+					return pipeline.StepResult{}, err
+				}
+				if len(imageResp.Images) == 0 || imageResp.Images[0].URL == "" {
+					return pipeline.StepResult{}, fmt.Errorf("no image returned")
+				}
+				output = imageResp.Images[0]
+				stepReq.ImageURL = output.URL
+				return pipeline.StepResult{URL: output.URL, CostUSD: price}, nil
+			},
+		}
+	}
+	chainRes := pipeline.Run(ctx, req.CeilingUSD, steps)
+	if chainRes.Err != nil || len(chainRes.Steps) == 0 {
+		genErr := fmt.Errorf("restore failed at %w", chainRes.Err)
+		if chainRes.Err == nil {
+			genErr = fmt.Errorf("restore stopped: its first step would exceed the cost ceiling of $%.2f", chainRes.CeilingUSD)
+		}
+		jobevents.Default.EmitFailed(&req.GenerationRequest, genErr)
+		return &ImageResult{Success: false, Error: genErr}, genErr
+	}
+	// A chain cut short by the ceiling is billed for the steps that ran
+	priceUSD := req.PriceUSD
+	if chainRes.CeilingHit {
+		priceUSD = chainRes.SpentUSD
+	}
+
+	// 4. Send the final image
+	successfullySent := false
+	finalReq := req.ImageRequest
+	finalReq.ModelName = "restore"
+	if err := s.sendImage(ctx, &finalReq, output, 0, 1); err != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, fmt.Errorf("failed to send restored image: %w", err))
+	} else {
+		successfullySent = true
+		jobevents.Default.EmitDelivered(&req.GenerationRequest, 1)
+		utils.RememberResult(s.dbManager, &finalReq.GenerationRequest, database.ResultImage, output.URL)
+	}
+
+	// 5. Perform Billing *only if* enabled and the image was sent successfully
+	var chargedDCR float64
+	var finalBalanceDCR float64 = currentBalanceDCR
+	var billingAttempted bool = false
+	var billingSucceeded bool = false
+
+	if s.billingEnabled.Load() && successfullySent {
+		billingAttempted = true
+		deductChargedDCR, deductNewBalance, deductErr := utils.DeductBalance(ctx, s.dbManager, req.UserID[:], priceUSD, utils.RequestChargeID(ctx, &req.GenerationRequest), s.debug, s.billingEnabled.Load())
+		if deductErr != nil {
+			if req.IsPM {
+				s.sender.SendMessage(ctx, req.MessageContext(), fmt.Sprintf("Error processing payment after sending results: %v. Please contact support.", deductErr))
+			}
+		} else {
+			billingSucceeded = true
+			chargedDCR = deductChargedDCR
+			finalBalanceDCR = deductNewBalance
+			jobevents.Default.EmitBilled(&req.GenerationRequest, chargedDCR)
+		}
+	}
+
+	// 6. Send final confirmation, with the steps that did not run if the
+	// ceiling stopped the chain
+	finished := templates.Data{Task: "image restoration", Sent: 1, SendFailed: !successfullySent}
+	finalMessage := utils.FormatFinished(&req.GenerationRequest, finished) + "\n\n"
+	if req.IsPM {
+		if chainRes.CeilingHit {
+			finalMessage += chainRes.Summary() + "\n\n"
+		}
+		if eb := req.ExternalBilling; eb != nil && !s.billingEnabled.Load() {
+			finalMessage += utils.FormatBillingConfirmation("results", true, true, true, eb.ChargedDCR, eb.ChargedUSD, eb.BalanceDCR) + utils.LowBalanceWarning(s.dbManager, req.UserID.String(), eb.BalanceDCR, eb.ChargedDCR, eb.ChargedUSD)
+		} else {
+			finalMessage += utils.FormatBillingConfirmation("results", s.billingEnabled.Load(), billingAttempted, billingSucceeded, chargedDCR, priceUSD, finalBalanceDCR) + utils.LowBalanceWarning(s.dbManager, req.UserID.String(), finalBalanceDCR, chargedDCR, priceUSD)
+		}
+		s.sender.SendMessage(ctx, req.MessageContext(), finalMessage)
+	} else {
+		s.sender.SendMessage(ctx, req.MessageContext(), utils.FormatFinished(&req.GenerationRequest, finished))
+		if chainRes.CeilingHit {
+			s.sender.SendPrivateMessage(ctx, req.MessageContext(), chainRes.Summary())
+		}
+	}
+
+	return &ImageResult{
+		ImageURL: output.URL,
+		Success:  true,
+	}, nil
+}
+
+// sendImage sends a generated image embedded in a message, or as an
+// asset-server link for GC requests that prefer one.
+func (s *ImageService) sendImage(ctx context.Context, req *ImageRequest, img fal.ImageOutput, index, total int) error {
+	if req.AssetLink && !req.IsPM && s.publisher != nil {
+		link, expires, err := s.publisher.PublishURL(ctx, img.URL)
+		if err == nil {
+			return s.sender.SendMessage(ctx, req.MessageContext(), utils.FormatAssetLink(fmt.Sprintf("Image %d/%d", index+1, total), link, expires))
+		}
+		s.jobLog(req).Warnf("Failed to publish image %d/%d, embedding it: %v", index+1, total, err)
+	}
+	return s.sendEmbeddedImage(ctx, req, img, index, total, s.embedLimit(req.IsPM))
+}
+
+// embedLimit returns the largest embed payload for a PM or GC message.
+func (s *ImageService) embedLimit(isPM bool) int {
+	if !isPM && s.gcMaxEmbedBytes > 0 && s.gcMaxEmbedBytes < s.maxEmbedBytes {
+		return s.gcMaxEmbedBytes
+	}
+	return s.maxEmbedBytes
+}
+
 // sendEmbeddedImage fetches, encodes, and sends an image embedded in a message.
-func sendEmbeddedImage(ctx context.Context, bot *kit.Bot, req *ImageRequest, img fal.ImageOutput, index, total int) error {
+// Images whose payload exceeds maxEmbedBytes are re-encoded to fit, and sent
+// as a file (PM) or link (GC) when even the lowest quality step is too large.
+func (s *ImageService) sendEmbeddedImage(ctx context.Context, req *ImageRequest, img fal.ImageOutput, index, total, maxEmbedBytes int) error {
 	// Fetch the image data
 	imgDataResp, err := http.Get(img.URL)
 	if err != nil {
@@ -221,23 +564,27 @@ func sendEmbeddedImage(ctx context.Context, bot *kit.Bot, req *ImageRequest, img
 	if err != nil {
 		return fmt.Errorf("failed to read image data %d/%d: %w", index+1, total, err)
 	}
+	debuglog.Debugf(debuglog.Delivery, "Downloaded image %d/%d %s (%d bytes, %s) for %s", index+1, total, img.URL, len(imageData), img.ContentType, req.UserNick)
 
-	// Encode the image data to base64
-	encodedImage := base64.StdEncoding.EncodeToString(imageData)
-
-	// Create the message with embedded image
-	message := fmt.Sprintf("--embed[alt=%s image %d/%d,type=%s,data=%s]--",
-		req.ModelName,
-		index+1,
-		total,
-		img.ContentType,
-		encodedImage)
+	// Shrink the image to the embed limit, falling back to file/link delivery
+	fit, err := fitEmbed(imageData, img.ContentType, maxEmbedBytes)
+	if err != nil {
+		s.jobLog(req).Warnf("Image %d/%d too large to embed: %v", index+1, total, err)
+		if req.IsPM {
+			return utils.SendFileToUser(ctx, s.bot, req.UserNick, img.URL, "image", img.ContentType)
+		}
+		return s.sender.SendMessage(ctx, req.MessageContext(), fmt.Sprintf("📎 Image %d/%d is too large to embed: %s", index+1, total, img.URL))
+	}
 
-	if req.IsPM {
-		return bot.SendPM(ctx, req.UserNick, message)
-	} else {
-		return bot.SendGC(ctx, req.GC, message)
+	// Send the message with embedded image
+	alt := fmt.Sprintf("%s image %d/%d", req.ModelName, index+1, total)
+	err = s.sender.SendEmbed(ctx, req.MessageContext(), alt, fit.ContentType, fit.Data)
+	if err == nil && fit.Compressed {
+		if noteErr := s.sender.SendMessage(ctx, req.MessageContext(), formatEmbedFit(fit, index, total)); noteErr != nil {
+			s.jobLog(req).Warnf("Failed to send compression notice: %v", noteErr)
+		}
 	}
+	return err
 }
 
 // Helper function to safely dereference optional int pointers
@@ -269,9 +616,30 @@ func (s *ImageService) validateRequest(req *ImageRequest) error {
 		return fmt.Errorf("image URL is required for image2image")
 	}
 
+	// ControlNet conditioning needs both a control-capable model and a control image
+	if req.ModelName == "sdxl-controlnet-union" {
+		if req.ControlImageURL == "" {
+			return fmt.Errorf("a control image is required for %s (use --control_image [url])", req.ModelName)
+		}
+	} else if req.ControlType != "" || req.ControlImageURL != "" {
+		return fmt.Errorf("model %s does not support --control; switch with !setmodel text2image sdxl-controlnet-union", req.ModelName)
+	}
+
 	return nil
 }
 
+// restoreStepRequest builds the request of one restore step on
+// req.ImageURL. The face restorer does not upscale inside the chain, so the
+// requested scale is applied once, by the upscaler.
+func restoreStepRequest(req ImageRequest, step string) (interface{}, error) {
+	req.ModelName = step
+	if step == "codeformer" {
+		noUpscale := 1.0
+		req.Scale = &noUpscale
+	}
+	return createFalImageRequest(&req, 1)
+}
+
 // createFalImageRequest constructs the appropriate fal.Model request struct based on the internal ImageRequest.
 func createFalImageRequest(req *ImageRequest, numImagesToRequest int) (interface{}, error) {
 	var falReq interface{}
@@ -452,9 +820,146 @@ func createFalImageRequest(req *ImageRequest, numImagesToRequest int) (interface
 			SafetyTolerance:     req.SafetyTolerance,
 			OutputFormat:        req.OutputFormat,
 		}
+	case "sdxl-controlnet-union":
+		falReq = &fal.ControlNetRequest{
+			BaseImageRequest: fal.BaseImageRequest{
+				Prompt:   req.Prompt,
+				Progress: req.Progress,
+			},
+			ControlType:         req.ControlType,
+			ControlImageURL:     req.ControlImageURL,
+			ConditioningScale:   req.ControlScale,
+			NegativePrompt:      req.NegativePrompt,
+			ImageSize:           req.ImageSize,
+			NumInferenceSteps:   req.NumInferenceSteps,
+			GuidanceScale:       req.GuidanceScale,
+			Seed:                req.Seed,
+			NumImages:           numImagesToRequest,
+			EnableSafetyChecker: req.EnableSafetyChecker,
+			OutputFormat:        req.OutputFormat,
+		}
+	case "birefnet":
+		if req.ImageURL == "" {
+			return nil, fmt.Errorf("image_url is required for birefnet model")
+		}
+		variant := req.Variant
+		if v, ok := fal.BiRefNetVariants[strings.ToLower(variant)]; ok {
+			variant = v
+		}
+		// A saved jpeg default would drop the transparency
+		outputFormat := req.OutputFormat
+		if outputFormat == "jpeg" {
+			outputFormat = ""
+		}
+		falReq = &fal.BiRefNetRequest{
+			BaseImageRequest: fal.BaseImageRequest{
+				ImageURL: req.ImageURL,
+				Progress: req.Progress,
+			},
+			Variant:             variant,
+			OperatingResolution: req.Resolution,
+			OutputFormat:        outputFormat,
+		}
+	case "flux-pro/v1/fill":
+		if req.ImageURL == "" || req.MaskURL == "" {
+			return nil, fmt.Errorf("an image and a mask are required for flux-pro/v1/fill (use !inpaint)")
+		}
+		falReq = &fal.FluxProFillRequest{
+			BaseImageRequest: fal.BaseImageRequest{
+				Prompt:   req.Prompt,
+				ImageURL: req.ImageURL,
+				Progress: req.Progress,
+			},
+			MaskURL:         req.MaskURL,
+			NumImages:       numImagesToRequest,
+			Seed:            req.Seed,
+			SafetyTolerance: req.SafetyTolerance,
+			OutputFormat:    req.OutputFormat,
+		}
+	case "ddcolor":
+		if req.ImageURL == "" {
+			return nil, fmt.Errorf("image_url is required for ddcolor model")
+		}
+		falReq = &fal.DDColorRequest{
+			BaseImageRequest: fal.BaseImageRequest{
+				ImageURL: req.ImageURL,
+				Progress: req.Progress,
+			},
+			Seed: req.Seed,
+		}
+	case "codeformer":
+		if req.ImageURL == "" {
+			return nil, fmt.Errorf("image_url is required for codeformer model")
+		}
+		falReq = &fal.CodeFormerRequest{
+			BaseImageRequest: fal.BaseImageRequest{
+				ImageURL: req.ImageURL,
+				Progress: req.Progress,
+			},
+			Fidelity:  req.Fidelity,
+			Upscaling: req.Scale,
+			Seed:      req.Seed,
+		}
+	case "esrgan":
+		if req.ImageURL == "" {
+			return nil, fmt.Errorf("image_url is required for esrgan model")
+		}
+		falReq = &fal.ESRGANRequest{
+			BaseImageRequest: fal.BaseImageRequest{
+				ImageURL: req.ImageURL,
+				Progress: req.Progress,
+			},
+			Scale:        req.Scale,
+			Face:         req.FaceEnhance,
+			OutputFormat: req.OutputFormat,
+		}
 	// Add cases for other specific image models here
 	default:
+		if model, ok := fal.GetModel(req.ModelName, req.ModelType); ok && model.Generic {
+			return genericImageRequest(req, numImagesToRequest), nil
+		}
 		return nil, fmt.Errorf("unsupported or unhandled model for specific FAL image request creation: %s", req.ModelName)
 	}
 	return falReq, nil
 }
+
+// genericImageRequest builds the request for a model from the model
+// definition file, sending the fields the user set.
+func genericImageRequest(req *ImageRequest, numImagesToRequest int) *fal.GenericRequest {
+	input := map[string]interface{}{"prompt": req.Prompt}
+	for name, value := range map[string]string{
+		"image_url":        req.ImageURL,
+		"image_size":       req.ImageSize,
+		"safety_tolerance": req.SafetyTolerance,
+		"output_format":    req.OutputFormat,
+		"negative_prompt":  req.NegativePrompt,
+		"aspect_ratio":     req.AspectRatio,
+		"acceleration":     req.Acceleration,
+	} {
+		if value != "" {
+			input[name] = value
+		}
+	}
+	if numImagesToRequest > 1 {
+		input["num_images"] = numImagesToRequest
+	}
+	if req.Seed != nil {
+		input["seed"] = *req.Seed
+	}
+	if req.NumInferenceSteps != nil {
+		input["num_inference_steps"] = *req.NumInferenceSteps
+	}
+	if req.EnableSafetyChecker != nil {
+		input["enable_safety_checker"] = *req.EnableSafetyChecker
+	}
+	if req.GuidanceScale != nil {
+		input["guidance_scale"] = *req.GuidanceScale
+	}
+	if req.Raw != nil {
+		input["raw"] = *req.Raw
+	}
+	if req.EnablePromptExpansion != nil {
+		input["enable_prompt_expansion"] = *req.EnablePromptExpansion
+	}
+	return &fal.GenericRequest{Model: req.ModelName, Body: input, Progress: req.Progress}
+}
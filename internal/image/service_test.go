@@ -0,0 +1,33 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+func TestRestoreStepRequest(t *testing.T) {
+	scale := 6.0
+	req := ImageRequest{ImageURL: "https://example.com/old.jpg", Scale: &scale}
+
+	falReq, err := restoreStepRequest(req, "codeformer")
+	if err != nil {
+		t.Fatalf("codeformer step: %v", err)
+	}
+	face, ok := falReq.(*fal.CodeFormerRequest)
+	if !ok || face.Upscaling == nil || *face.Upscaling != 1 {
+		t.Fatalf("codeformer step = %+v; want no upscaling", falReq)
+	}
+
+	falReq, err = restoreStepRequest(req, "esrgan")
+	if err != nil {
+		t.Fatalf("esrgan step: %v", err)
+	}
+	upscale, ok := falReq.(*fal.ESRGANRequest)
+	if !ok || upscale.Scale == nil || *upscale.Scale != 6 {
+		t.Fatalf("esrgan step = %+v; want scale 6", falReq)
+	}
+	if *req.Scale != 6 || req.ModelName != "" {
+		t.Errorf("restore request changed by its steps: %+v", req)
+	}
+}
@@ -24,6 +24,27 @@ type ImageRequest struct {
 	Raw                   *bool    // Optional raw flag (e.g., flux-ultra)
 	Acceleration          string   // Optional acceleration level (e.g., flux-2: none, regular, high)
 	EnablePromptExpansion *bool    // Optional prompt expansion (e.g., flux-2)
+	Fidelity              *float64 // Optional restoration fidelity 0-1 (e.g., codeformer)
+	Scale                 *float64 // Optional upscale factor (e.g., codeformer, esrgan)
+	FaceEnhance           *bool    // Optional face enhancement while upscaling (e.g., esrgan)
+	ControlType           string   // Optional ControlNet conditioning type (canny, depth, pose, ...)
+	ControlImageURL       string   // Reference image for ControlNet conditioning
+	ControlScale          *float64 // Optional ControlNet conditioning strength 0-1
+	Preview               bool     // Render a low-res preview thumbnail instead (text2image)
+	Gallery               *bool    // Send multiple results as one grid image; nil = only in GCs
+	MaskURL               string   // Inpainting mask; white areas are repainted (e.g., flux-pro fill)
+	Variant               string   // Optional model variant (e.g., birefnet: light, heavy, portrait)
+	Resolution            string   // Optional operating resolution (e.g., birefnet: 1024x1024)
+}
+
+// RestoreRequest represents a chained photo restoration request. Each step
+// is an image2image model applied to the previous step's output; options on
+// the embedded ImageRequest are shared by every step that supports them.
+type RestoreRequest struct {
+	ImageRequest
+	Steps         []string  // image2image model names, applied in order
+	StepPricesUSD []float64 // Estimated price of each step
+	CeilingUSD    float64   // Cost ceiling of the chain; 0 means none
 }
 
 // ImageResult represents the result of an image generation
@@ -31,6 +52,7 @@ type ImageResult struct {
 	ImageURL string
 	Success  bool
 	Error    error
+	Seed     uint64 // Seed the provider reported, 0 when unknown
 }
 
 // IsSuccess checks if the image generation was successful.
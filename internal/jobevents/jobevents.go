@@ -0,0 +1,177 @@
+// Package jobevents publishes structured lifecycle events for generation
+// jobs (submitted, queued, progress, delivered, billed, failed) so operators
+// can watch every user's jobs in real time.
+package jobevents
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	braibottypes "github.com/karamble/braibot/internal/types"
+)
+
+// Type is the kind of lifecycle event.
+type Type string
+
+// Lifecycle event types, in the order a job normally passes through them.
+const (
+	Submitted Type = "submitted"
+	Queued    Type = "queued"
+	Progress  Type = "progress"
+	Delivered Type = "delivered"
+	Billed    Type = "billed"
+	Failed    Type = "failed"
+)
+
+// Event is a single job lifecycle event.
+type Event struct {
+	Time     time.Time
+	Job      uint64 // Job id, shared by all events of one request
+	Type     Type
+	User     string
+	UID      string
+	GC       string // Group chat the job was requested in; empty for PMs
+	Command  string // Model type, e.g. text2video
+	Model    string
+	Position int     // Queue position (Queued)
+	Status   string  // Provider status or log line (Progress)
+	Items    int     // Outputs delivered; 0 for throwaway previews (Delivered)
+	CostDCR  float64 // Amount charged to the requester (Billed)
+	Detail   string  // Extra context, e.g. the error of a Failed event
+}
+
+// String formats the event as a single key=value line.
+func (e Event) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "job=%d event=%s user=%s cmd=%s model=%s", e.Job, e.Type, strconv.Quote(e.User), e.Command, e.Model)
+	if e.Position > 0 {
+		fmt.Fprintf(&b, " position=%d", e.Position)
+	}
+	if e.Status != "" {
+		fmt.Fprintf(&b, " status=%s", strconv.Quote(e.Status))
+	}
+	if e.Items > 0 {
+		fmt.Fprintf(&b, " items=%d", e.Items)
+	}
+	if e.CostDCR > 0 {
+		fmt.Fprintf(&b, " dcr=%.8f", e.CostDCR)
+	}
+	if e.Detail != "" {
+		fmt.Fprintf(&b, " detail=%s", strconv.Quote(e.Detail))
+	}
+	return b.String()
+}
+
+// Log fans job events out to subscribers. Slow subscribers drop events
+// rather than block the jobs publishing them.
+type Log struct {
+	mu     sync.Mutex
+	subs   map[int]chan Event
+	nextID int
+	jobs   atomic.Uint64
+}
+
+// NewLog creates an empty event log.
+func NewLog() *Log {
+	return &Log{subs: make(map[int]chan Event)}
+}
+
+// Default is the event log every generation service publishes to.
+var Default = NewLog()
+
+// Subscribe returns a channel receiving every event published from now on,
+// buffered to buf events, and a function that ends the subscription.
+func (l *Log) Subscribe(buf int) (<-chan Event, func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	id := l.nextID
+	l.nextID++
+	ch := make(chan Event, buf)
+	l.subs[id] = ch
+	return ch, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if _, ok := l.subs[id]; ok {
+			delete(l.subs, id)
+			close(ch)
+		}
+	}
+}
+
+// Publish sends an event to every subscriber.
+func (l *Log) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, ch := range l.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Submit assigns the request a job id, publishes its Submitted event and
+// wraps its progress callback so provider updates are published as Progress
+// events. Requests that already have a job id are left alone.
+func (l *Log) Submit(req *braibottypes.GenerationRequest) {
+	if req.JobID != 0 {
+		return
+	}
+	req.JobID = l.jobs.Add(1)
+	if req.Progress != nil {
+		req.Progress = &progressRelay{ProgressCallback: req.Progress, log: l, req: *req}
+	}
+	l.Emit(req, Submitted, "")
+}
+
+// Emit publishes an event of type t for the request.
+func (l *Log) Emit(req *braibottypes.GenerationRequest, t Type, detail string) {
+	l.Publish(newEvent(req, t, detail))
+}
+
+// EmitQueued publishes a Queued event with the job's position in line.
+func (l *Log) EmitQueued(req *braibottypes.GenerationRequest, position int) {
+	e := newEvent(req, Queued, "")
+	e.Position = position
+	l.Publish(e)
+}
+
+// EmitDelivered publishes a Delivered event for items outputs sent to the
+// user.
+func (l *Log) EmitDelivered(req *braibottypes.GenerationRequest, items int) {
+	e := newEvent(req, Delivered, "")
+	e.Items = items
+	l.Publish(e)
+}
+
+// EmitBilled publishes a Billed event for the amount charged to the user.
+func (l *Log) EmitBilled(req *braibottypes.GenerationRequest, chargedDCR float64) {
+	e := newEvent(req, Billed, "")
+	e.CostDCR = chargedDCR
+	l.Publish(e)
+}
+
+// EmitFailed publishes a Failed event for err.
+func (l *Log) EmitFailed(req *braibottypes.GenerationRequest, err error) {
+	l.Emit(req, Failed, err.Error())
+}
+
+func newEvent(req *braibottypes.GenerationRequest, t Type, detail string) Event {
+	return Event{
+		Job:     req.JobID,
+		Type:    t,
+		User:    req.UserNick,
+		UID:     req.UserID.String(),
+		GC:      req.GC,
+		Command: req.ModelType,
+		Model:   req.ModelName,
+		Detail:  detail,
+	}
+}
@@ -0,0 +1,94 @@
+package jobevents
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	braibottypes "github.com/karamble/braibot/internal/types"
+)
+
+// recordingProgress records the calls forwarded by the relay.
+type recordingProgress struct {
+	statuses []string
+}
+
+func (r *recordingProgress) OnQueueUpdate(position int, eta time.Duration) {}
+func (r *recordingProgress) OnLogMessage(message string)                   {}
+func (r *recordingProgress) OnProgress(status string)                      { r.statuses = append(r.statuses, status) }
+func (r *recordingProgress) OnError(err error)                             {}
+
+func drain(ch <-chan Event) []Event {
+	var out []Event
+	for {
+		select {
+		case e := <-ch:
+			out = append(out, e)
+		default:
+			return out
+		}
+	}
+}
+
+func TestJobLifecycle(t *testing.T) {
+	l := NewLog()
+	events, stop := l.Subscribe(16)
+	defer stop()
+
+	rec := &recordingProgress{}
+	req := &braibottypes.GenerationRequest{ModelType: "text2video", ModelName: "kling", UserNick: "alice", Progress: rec}
+	l.Submit(req)
+	if req.JobID == 0 {
+		t.Fatal("Submit did not assign a job id")
+	}
+	id := req.JobID
+	l.Submit(req) // resubmitting keeps the id and publishes nothing
+	if req.JobID != id {
+		t.Fatalf("resubmit changed job id %d -> %d", id, req.JobID)
+	}
+
+	l.EmitQueued(req, 2)
+	req.Progress.OnProgress("IN_PROGRESS")
+	req.Progress.OnProgress("IN_PROGRESS") // duplicate status is not republished
+	l.EmitDelivered(req, 1)
+	l.EmitBilled(req, 0.5)
+
+	got := drain(events)
+	want := []Type{Submitted, Queued, Progress, Delivered, Billed}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events %v, want %v", len(got), got, want)
+	}
+	for i, e := range got {
+		if e.Type != want[i] || e.Job != id || e.User != "alice" || e.Time.IsZero() {
+			t.Errorf("event %d = %+v, want type %s for job %d", i, e, want[i], id)
+		}
+	}
+	if got[1].Position != 2 || got[2].Status != "IN_PROGRESS" || got[4].CostDCR != 0.5 {
+		t.Errorf("event details lost: %v", got)
+	}
+	if len(rec.statuses) != 2 {
+		t.Errorf("relay forwarded %d statuses, want 2", len(rec.statuses))
+	}
+	if s := got[4].String(); !strings.Contains(s, "event=billed") || !strings.Contains(s, "dcr=0.50000000") {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestSlowSubscriberDropsEvents(t *testing.T) {
+	l := NewLog()
+	events, stop := l.Subscribe(1)
+	req := &braibottypes.GenerationRequest{UserNick: "bob"}
+	l.Submit(req)
+	l.EmitFailed(req, errors.New("boom")) // buffer full: dropped, not blocked
+	if got := drain(events); len(got) != 1 || got[0].Type != Submitted {
+		t.Fatalf("got %v, want only the submitted event", got)
+	}
+
+	stop()
+	stop() // stopping twice is harmless
+	if _, ok := <-events; ok {
+		t.Error("channel still open after stop")
+	}
+	l.EmitDelivered(req, 1) // publishing after unsubscribe must not panic
+}
@@ -0,0 +1,40 @@
+package jobevents
+
+import (
+	"fmt"
+	"time"
+
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+// progressRelay forwards provider updates to the wrapped callback and
+// publishes them as events. Repeated statuses are only published once.
+type progressRelay struct {
+	fal.ProgressCallback
+	log        *Log
+	req        braibottypes.GenerationRequest
+	lastStatus string
+}
+
+func (p *progressRelay) OnQueueUpdate(position int, eta time.Duration) {
+	p.ProgressCallback.OnQueueUpdate(position, eta)
+	p.publish(Progress, "provider queue", position)
+}
+
+func (p *progressRelay) OnProgress(status string) {
+	p.ProgressCallback.OnProgress(status)
+	p.publish(Progress, status, 0)
+}
+
+func (p *progressRelay) publish(t Type, status string, position int) {
+	key := fmt.Sprintf("%s/%d", status, position)
+	if key == p.lastStatus {
+		return
+	}
+	p.lastStatus = key
+	e := newEvent(&p.req, t, "")
+	e.Status = status
+	e.Position = position
+	p.log.Publish(e)
+}
@@ -0,0 +1,96 @@
+// Package joblog gives the generation services a logger of the bot's log
+// backend and tags every line they log about a job with the job's fields,
+// so one job can be followed through the log, e.g. with grep job=42.
+package joblog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/decred/slog"
+	"github.com/karamble/braibot/internal/debuglog"
+	braibottypes "github.com/karamble/braibot/internal/types"
+)
+
+// Logger names of the services.
+const (
+	Image      = "IMG"  // Image generation and delivery
+	Video      = "VID"  // Video generation and delivery
+	Speech     = "SPCH" // Speech generation and audio cleanup
+	Transcribe = "STT"  // Audio transcription
+	Chat       = "CHAT" // Language model conversations
+	Vision     = "VIS"  // Image descriptions
+	Model3D    = "3D"   // Image to 3D model generation and delivery
+	Adapter    = "FALA" // Model registry and progress updates
+)
+
+// stdout is used until a service is given the bot's log backend.
+var stdout = slog.NewBackend(os.Stdout)
+
+// New returns the logger of a subsystem from backend. A nil backend, e.g.
+// when commands are set up only to dump them, logs to stdout.
+func New(backend debuglog.Backend, subsys string) slog.Logger {
+	if backend == nil {
+		return stdout.Logger(subsys)
+	}
+	return backend.Logger(subsys)
+}
+
+// Logger logs lines about one job, prefixed with its fields.
+type Logger struct {
+	log    slog.Logger
+	fields string
+}
+
+// For returns a logger for the job of req.
+func For(log slog.Logger, req *braibottypes.GenerationRequest) Logger {
+	return Logger{log: log, fields: Fields(req)}
+}
+
+// Fields formats the fields identifying the job of req, as
+// "job=42 user=alice model=fast-sdxl cost=$0.0200". The cost is the price
+// quoted for the request; fields that are not known are left out.
+func Fields(req *braibottypes.GenerationRequest) string {
+	var b strings.Builder
+	if req.JobID != 0 {
+		fmt.Fprintf(&b, "job=%d ", req.JobID)
+	}
+	fmt.Fprintf(&b, "user=%s", req.UserNick)
+	if !req.IsPM && req.GC != "" {
+		fmt.Fprintf(&b, " gc=%s", req.GC)
+	}
+	if req.ModelName != "" {
+		fmt.Fprintf(&b, " model=%s", req.ModelName)
+	}
+	if req.PriceUSD > 0 {
+		fmt.Fprintf(&b, " cost=$%.4f", req.PriceUSD)
+	}
+	return b.String()
+}
+
+func (l Logger) line(format string, args []interface{}) string {
+	return l.fields + ": " + fmt.Sprintf(format, args...)
+}
+
+// Debugf logs a debug message about the job.
+func (l Logger) Debugf(format string, args ...interface{}) {
+	if l.log.Level() <= slog.LevelDebug {
+		l.log.Debug(l.line(format, args))
+	}
+}
+
+// Infof logs an informational message about the job.
+func (l Logger) Infof(format string, args ...interface{}) {
+	l.log.Info(l.line(format, args))
+}
+
+// Warnf logs a warning about the job.
+func (l Logger) Warnf(format string, args ...interface{}) {
+	l.log.Warn(l.line(format, args))
+}
+
+// Errorf logs an error of the job.
+func (l Logger) Errorf(format string, args ...interface{}) {
+	l.log.Error(l.line(format, args))
+}
@@ -0,0 +1,42 @@
+package joblog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/decred/slog"
+	braibottypes "github.com/karamble/braibot/internal/types"
+)
+
+func TestFields(t *testing.T) {
+	tests := []struct {
+		req  braibottypes.GenerationRequest
+		want string
+	}{
+		{braibottypes.GenerationRequest{JobID: 42, UserNick: "alice", IsPM: true, ModelName: "fast-sdxl", PriceUSD: 0.02}, "job=42 user=alice model=fast-sdxl cost=$0.0200"},
+		{braibottypes.GenerationRequest{UserNick: "bob", GC: "art", ModelName: "kling-video"}, "user=bob gc=art model=kling-video"},
+	}
+	for _, tt := range tests {
+		if got := Fields(&tt.req); got != tt.want {
+			t.Errorf("Fields() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.NewBackend(&buf).Logger(Image)
+	req := &braibottypes.GenerationRequest{JobID: 7, UserNick: "alice", IsPM: true}
+
+	jl := For(l, req)
+	jl.Warnf("failed to send %d", 2)
+	jl.Debugf("hidden")
+	out := buf.String()
+	if !strings.Contains(out, "[WRN] IMG: job=7 user=alice: failed to send 2") {
+		t.Errorf("log = %q, want the warning with the job fields", out)
+	}
+	if strings.Contains(out, "hidden") {
+		t.Errorf("log = %q, debug line logged at info level", out)
+	}
+}
@@ -0,0 +1,513 @@
+// Package jobs runs generation commands on a pool of workers so the bot keeps
+// answering other commands while they run. Queued jobs are persisted in the
+// database: pending jobs are resumed after a restart, running jobs that were
+// waiting for a fal request are run again to pick up its result, and other
+// jobs that were running when the bot stopped are reported as interrupted.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/decred/slog"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/queue"
+)
+
+// Runner executes a queued command.
+type Runner func(ctx context.Context, job database.QueuedJob) error
+
+// Default duration estimates per job kind, used for ETAs until jobs of a
+// command have completed.
+var defaultDurations = map[string]time.Duration{
+	queue.KindVideo:  5 * time.Minute,
+	queue.KindImage:  30 * time.Second,
+	queue.KindSpeech: 30 * time.Second,
+}
+
+// ErrUserLimit is returned by Submit when the user already has the maximum
+// number of jobs queued or running.
+type ErrUserLimit struct {
+	Limit int
+	Kind  string // Job kind the limit is for; empty for the overall limit
+}
+
+func (e *ErrUserLimit) Error() string {
+	if e.Kind != "" {
+		return fmt.Sprintf("you already have %d %s jobs queued or running; wait for one to finish (see !queue)", e.Limit, e.Kind)
+	}
+	return fmt.Sprintf("you already have %d jobs queued or running; wait for one to finish (see !queue)", e.Limit)
+}
+
+// ErrCanceled is the cause of a running job's context when the job was
+// cancelled with Cancel.
+var ErrCanceled = errors.New("job cancelled")
+
+// ErrJobNotFound is returned by Cancel for jobs that are not queued or
+// running for the user.
+var ErrJobNotFound = errors.New("job not found")
+
+// Manager queues jobs in FIFO order and runs them on a fixed number of
+// workers. A queued job whose kind is at its concurrency limit is passed over
+// for the next job of another kind.
+type Manager struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	wg        sync.WaitGroup // Of the workers
+	db        *database.DBManager
+	run       Runner
+	log       slog.Logger
+	workers   int
+	userLimit int
+	kindLimit map[string]int // Per user and job kind
+	kindSlots map[string]int // Per job kind across all users
+	started   bool
+	stopped   bool
+	pending   []database.QueuedJob
+	running   map[int64]database.QueuedJob
+	cancels   map[int64]context.CancelCauseFunc // Of running jobs
+	durations map[string]time.Duration          // Moving average per command
+	onDone    []func(database.QueuedJob, error)
+}
+
+// jobKey is the context key of the id of the job a context runs.
+type jobKey struct{}
+
+// NewManager creates a stopped manager. Submit fails until Start is called.
+func NewManager() *Manager {
+	m := &Manager{
+		log:       slog.NewBackend(os.Stdout).Logger("JOBS"),
+		running:   make(map[int64]database.QueuedJob),
+		kindLimit: make(map[string]int),
+		kindSlots: make(map[string]int),
+		cancels:   make(map[int64]context.CancelCauseFunc),
+		durations: make(map[string]time.Duration),
+	}
+	m.cond = sync.NewCond(&m.mu)
+	return m
+}
+
+// Default is the manager generation commands are queued on.
+var Default = NewManager()
+
+// Start resumes the jobs persisted in db and starts workers running jobs
+// with run until ctx is done. userLimit caps the jobs a user may have queued
+// or running at once (0 = unlimited). Jobs that were running when the bot
+// stopped and had a fal request queued (ResponseURL) are run again first, so
+// run can resume polling that request. Other running jobs are passed to
+// interrupted and dropped, since they may already have been delivered and
+// billed.
+func (m *Manager) Start(ctx context.Context, db *database.DBManager, workers, userLimit int, run Runner, interrupted func(database.QueuedJob)) error {
+	stored, err := db.ListQueuedJobs()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	if m.started {
+		m.mu.Unlock()
+		return fmt.Errorf("job manager already started")
+	}
+	m.started = true
+	m.db = db
+	m.run = run
+	m.workers = max(1, workers)
+	m.userLimit = userLimit
+	var dropped, resumed []database.QueuedJob
+	for _, j := range stored {
+		switch {
+		case j.State == database.JobRunning && j.ResponseURL != "":
+			resumed = append(resumed, j)
+		case j.State == database.JobRunning:
+			dropped = append(dropped, j)
+		default:
+			m.pending = append(m.pending, j)
+		}
+	}
+	m.pending = append(resumed, m.pending...)
+	m.mu.Unlock()
+
+	for _, j := range dropped {
+		if err := db.DeleteQueuedJob(j.ID); err != nil {
+			return err
+		}
+		if interrupted != nil {
+			interrupted(j)
+		}
+	}
+
+	m.wg.Add(m.workers)
+	for i := 0; i < m.workers; i++ {
+		go m.worker(ctx)
+	}
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		m.stopped = true
+		m.cond.Broadcast()
+		m.mu.Unlock()
+	}()
+	return nil
+}
+
+// Wait blocks until the workers have stopped, once the context passed to
+// Start is done, and the jobs they were running have returned.
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// Submit persists and queues a job. The returned status has Position 0 when
+// a worker picks the job up right away.
+func (m *Manager) Submit(job database.QueuedJob) (Status, error) {
+	st, err := m.SubmitBatch(context.Background(), []database.QueuedJob{job})
+	if err != nil {
+		return Status{}, err
+	}
+	return st[0], nil
+}
+
+// SubmitBatch persists and queues jobs in order. Either all of them fit the
+// per-user limits or none is queued. The job ctx runs, if any, does not count
+// against the limits, so a job can hand its work over to the jobs it
+// submits.
+func (m *Manager) SubmitBatch(ctx context.Context, batch []database.QueuedJob) ([]Status, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.started || m.stopped {
+		return nil, fmt.Errorf("job queue is not running")
+	}
+	self, _ := ctx.Value(jobKey{}).(int64)
+	counts := make(map[[2]string]int) // Per user and kind, "" for all kinds
+	for _, job := range batch {
+		kind := queue.KindForCommand(job.Command)
+		for _, key := range [][2]string{{job.UID, ""}, {job.UID, kind}} {
+			if _, counted := counts[key]; !counted {
+				counts[key] = m.userJobs(key[0], key[1])
+				if running, ok := m.running[self]; ok && running.UID == key[0] && (key[1] == "" || queue.KindForCommand(running.Command) == key[1]) {
+					counts[key]--
+				}
+			}
+			counts[key]++
+		}
+		if m.userLimit > 0 && counts[[2]string{job.UID, ""}] > m.userLimit {
+			return nil, &ErrUserLimit{Limit: m.userLimit}
+		}
+		if limit := m.kindLimit[kind]; limit > 0 && counts[[2]string{job.UID, kind}] > limit {
+			return nil, &ErrUserLimit{Limit: limit, Kind: kind}
+		}
+	}
+
+	out := make([]Status, 0, len(batch))
+	for _, job := range batch {
+		job.State = database.JobPending
+		if job.CreatedAt.IsZero() {
+			job.CreatedAt = time.Now()
+		}
+		id, err := m.db.EnqueueJob(job)
+		if err != nil {
+			return out, err
+		}
+		job.ID = id
+		m.pending = append(m.pending, job)
+		m.cond.Signal()
+
+		for _, status := range m.statuses(job.UID) {
+			if status.Job.ID == job.ID {
+				out = append(out, status)
+			}
+		}
+	}
+	return out, nil
+}
+
+// OnDone registers a hook called with each job that finished or was
+// cancelled before it ran, and the error it ended with. Jobs cut short by
+// shutdown are not reported.
+func (m *Manager) OnDone(hook func(job database.QueuedJob, err error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onDone = append(m.onDone, hook)
+}
+
+// done calls the OnDone hooks.
+func (m *Manager) done(job database.QueuedJob, err error) {
+	m.mu.Lock()
+	hooks := m.onDone
+	m.mu.Unlock()
+	for _, hook := range hooks {
+		hook(job, err)
+	}
+}
+
+// SetLogger replaces the logger of the manager, which logs to stdout until
+// then. Call it before Start.
+func (m *Manager) SetLogger(log slog.Logger) {
+	m.log = log
+}
+
+// SetUserKindLimit caps the jobs of a kind (see queue.KindForCommand) each
+// user may have queued or running at once (0 = unlimited). Jobs already
+// queued are kept.
+func (m *Manager) SetUserKindLimit(kind string, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kindLimit[kind] = max(0, n)
+}
+
+// SetKindLimit caps the jobs of a kind (see queue.KindForCommand) running at
+// once across all users (0 = unlimited). Workers pass over queued jobs of a
+// kind at its limit, so e.g. queued videos do not keep image jobs from
+// running. Lowering the limit lets running jobs finish.
+func (m *Manager) SetKindLimit(kind string, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kindSlots[kind] = max(0, n)
+	m.cond.Broadcast()
+}
+
+// userJobs counts the user's queued and running jobs of a kind, or of all
+// kinds when kind is empty. Callers hold mu.
+func (m *Manager) userJobs(uid, kind string) int {
+	n := 0
+	count := func(j database.QueuedJob) {
+		if j.UID == uid && (kind == "" || queue.KindForCommand(j.Command) == kind) {
+			n++
+		}
+	}
+	for _, j := range m.pending {
+		count(j)
+	}
+	for _, j := range m.running {
+		count(j)
+	}
+	return n
+}
+
+// worker runs pending jobs until the manager stops.
+func (m *Manager) worker(ctx context.Context) {
+	defer m.wg.Done()
+	for {
+		m.mu.Lock()
+		i := m.next()
+		for i < 0 && !m.stopped {
+			m.cond.Wait()
+			i = m.next()
+		}
+		if m.stopped {
+			m.mu.Unlock()
+			return
+		}
+		job := m.pending[i]
+		m.pending = append(m.pending[:i], m.pending[i+1:]...)
+		job.State = database.JobRunning
+		job.StartedAt = time.Now()
+		jobCtx, cancel := context.WithCancelCause(ctx)
+		jobCtx = context.WithValue(jobCtx, jobKey{}, job.ID)
+		m.running[job.ID] = job
+		m.cancels[job.ID] = cancel
+		m.mu.Unlock()
+
+		m.runJob(ctx, jobCtx, job)
+		cancel(nil)
+	}
+}
+
+// next returns the index of the first pending job whose kind is below its
+// limit, or -1 if there is none. Callers hold mu.
+func (m *Manager) next() int {
+	running := make(map[string]int)
+	for _, j := range m.running {
+		running[queue.KindForCommand(j.Command)]++
+	}
+	for i, j := range m.pending {
+		kind := queue.KindForCommand(j.Command)
+		if limit := m.kindSlots[kind]; limit == 0 || running[kind] < limit {
+			return i
+		}
+	}
+	return -1
+}
+
+// runJob runs one job and removes it from the queue once it finished. Jobs
+// cut short by shutdown stay persisted as running, to be resumed or reported
+// as interrupted.
+func (m *Manager) runJob(ctx, jobCtx context.Context, job database.QueuedJob) {
+	if err := m.db.MarkJobRunning(job.ID, job.StartedAt); err != nil {
+		m.log.Warnf("job=%d user=%s: %v", job.ID, job.Nick, err)
+	}
+	err := m.run(jobCtx, job)
+	took := time.Since(job.StartedAt)
+
+	m.mu.Lock()
+	delete(m.running, job.ID)
+	delete(m.cancels, job.ID)
+	if err == nil {
+		if avg, ok := m.durations[job.Command]; ok {
+			m.durations[job.Command] = (avg*7 + took*3) / 10
+		} else {
+			m.durations[job.Command] = took
+		}
+	}
+	// A slot of the job's kind is free again
+	m.cond.Broadcast()
+	m.mu.Unlock()
+
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	if err := m.db.DeleteQueuedJob(job.ID); err != nil {
+		m.log.Warnf("job=%d user=%s: %v", job.ID, job.Nick, err)
+	}
+	m.done(job, err)
+}
+
+// Cancel cancels one of the user's jobs. Pending jobs are dropped from the
+// queue; running jobs have their context cancelled with ErrCanceled. It
+// returns the job and whether it was running.
+func (m *Manager) Cancel(uid string, id int64) (database.QueuedJob, bool, error) {
+	m.mu.Lock()
+	if job, ok := m.running[id]; ok && job.UID == uid {
+		m.cancels[id](ErrCanceled)
+		m.mu.Unlock()
+		return job, true, nil
+	}
+	for i, job := range m.pending {
+		if job.ID != id || job.UID != uid {
+			continue
+		}
+		if err := m.db.DeleteQueuedJob(id); err != nil {
+			m.mu.Unlock()
+			return job, false, err
+		}
+		m.pending = append(m.pending[:i], m.pending[i+1:]...)
+		m.mu.Unlock()
+		m.done(job, ErrCanceled)
+		return job, false, nil
+	}
+	m.mu.Unlock()
+	return database.QueuedJob{}, false, ErrJobNotFound
+}
+
+// Status is a user's view of one queued or running job.
+type Status struct {
+	Job      database.QueuedJob
+	Running  bool          // Running, or about to be picked up by a worker
+	Position int           // 1-based place in line; 0 while running
+	ETA      time.Duration // Estimated time until the job is done
+}
+
+// UserStatus returns the user's running and queued jobs, running jobs first.
+func (m *Manager) UserStatus(uid string) []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.statuses(uid)
+}
+
+// statuses estimates the user's jobs by playing the queue forward: running
+// jobs take their expected time left, and each pending job starts once a
+// worker and a slot of its kind are free, the way the workers pick them.
+// Callers hold mu.
+func (m *Manager) statuses(uid string) []Status {
+	now := time.Now()
+	var out []Status
+	workers := make([]time.Duration, max(1, m.workers)) // When each is free
+	slots := make(map[string][]time.Duration)           // Of limited kinds
+	for kind, n := range m.kindSlots {
+		if n > 0 {
+			slots[kind] = make([]time.Duration, n)
+		}
+	}
+	// occupy marks the soonest free of the workers or slots busy until t.
+	occupy := func(free []time.Duration, t time.Duration) {
+		if i := soonest(free); t > free[i] {
+			free[i] = t
+		}
+	}
+	for _, j := range m.running {
+		left := max(0, m.expected(j.Command)-now.Sub(j.StartedAt))
+		occupy(workers, left)
+		if free, ok := slots[queue.KindForCommand(j.Command)]; ok {
+			occupy(free, left)
+		}
+		if j.UID == uid {
+			out = append(out, Status{Job: j, Running: true, ETA: left})
+		}
+	}
+
+	queued := append([]database.QueuedJob(nil), m.pending...)
+	for position := 0; len(queued) > 0; {
+		// The first job that can start soonest on the next free worker
+		w := soonest(workers)
+		next, start := 0, time.Duration(-1)
+		for i, j := range queued {
+			at := workers[w]
+			if free, ok := slots[queue.KindForCommand(j.Command)]; ok {
+				at = max(at, free[soonest(free)])
+			}
+			if start < 0 || at < start {
+				next, start = i, at
+			}
+		}
+		j := queued[next]
+		queued = append(queued[:next], queued[next+1:]...)
+		end := start + m.expected(j.Command)
+		workers[w] = end
+		if free, ok := slots[queue.KindForCommand(j.Command)]; ok {
+			free[soonest(free)] = end
+		}
+		status := Status{Job: j, Running: start == 0, ETA: end}
+		if start > 0 {
+			position++
+			status.Position = position
+		}
+		if j.UID == uid {
+			out = append(out, status)
+		}
+	}
+	return out
+}
+
+// soonest returns the index of the smallest of free.
+func soonest(free []time.Duration) int {
+	i := 0
+	for k, t := range free {
+		if t < free[i] {
+			i = k
+		}
+	}
+	return i
+}
+
+// expected returns how long a job of the command is expected to take.
+// Callers hold mu.
+func (m *Manager) expected(command string) time.Duration {
+	if d, ok := m.durations[command]; ok {
+		return d
+	}
+	return defaultDurations[queue.KindForCommand(command)]
+}
+
+// Counts returns how many jobs are running and how many wait for a worker
+// or a slot of their kind.
+func (m *Manager) Counts() (running, pending int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.running), len(m.pending)
+}
+
+// FormatETA renders an estimate as a short, rounded duration.
+func FormatETA(d time.Duration) string {
+	if d < time.Minute {
+		return "under a minute"
+	}
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("~%d min", int(d.Minutes()))
+	}
+	return fmt.Sprintf("~%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
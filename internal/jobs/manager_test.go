@@ -0,0 +1,369 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/queue"
+)
+
+func newTestDB(t *testing.T) *database.DBManager {
+	t.Helper()
+	db, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// start starts m on db and, when the test ends, stops it and waits for its
+// workers before the test database is closed.
+func start(t *testing.T, m *Manager, db *database.DBManager, workers, userLimit int, run Runner, interrupted func(database.QueuedJob)) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		m.Wait()
+	})
+	if err := m.Start(ctx, db, workers, userLimit, run, interrupted); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+}
+
+// waitStarted waits for the job run sends on started.
+func waitStarted(t *testing.T, started <-chan string, want string) {
+	t.Helper()
+	select {
+	case got := <-started:
+		if got != want {
+			t.Fatalf("started %q, want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("job %q did not start", want)
+	}
+}
+
+func TestManagerQueuesBeyondWorkers(t *testing.T) {
+	db := newTestDB(t)
+	release := make(chan struct{})
+	started := make(chan string, 3)
+	done := make(chan string, 3)
+	m := NewManager()
+	run := func(ctx context.Context, job database.QueuedJob) error {
+		started <- job.Args[0]
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		done <- job.Args[0]
+		return nil
+	}
+	start(t, m, db, 1, 2, run, nil)
+
+	st, err := m.Submit(database.QueuedJob{UID: "alice", Nick: "alice", Command: "text2image", Args: []string{"a"}, IsPM: true})
+	if err != nil || st.Position != 0 {
+		t.Fatalf("first Submit = %+v, %v; want position 0", st, err)
+	}
+	waitStarted(t, started, "a")
+	st, err = m.Submit(database.QueuedJob{UID: "bob", Nick: "bob", Command: "text2video", Args: []string{"b"}, IsPM: true})
+	if err != nil || st.Position != 1 {
+		t.Fatalf("second Submit = %+v, %v; want position 1", st, err)
+	}
+	if st.ETA < 5*time.Minute {
+		t.Errorf("video ETA = %v, want at least the default video duration", st.ETA)
+	}
+	if _, err := m.Submit(database.QueuedJob{UID: "alice", Command: "text2image", Args: []string{"c"}}); err != nil {
+		t.Fatalf("third Submit: %v", err)
+	}
+	if _, err := m.Submit(database.QueuedJob{UID: "alice", Command: "text2image", Args: []string{"d"}}); err == nil {
+		t.Fatal("Submit beyond the per-user limit succeeded")
+	}
+
+	if got := m.UserStatus("bob"); len(got) != 1 || got[0].Position != 1 {
+		t.Fatalf("UserStatus(bob) = %+v, want one job at position 1", got)
+	}
+	if stored, _ := db.ListQueuedJobs(); len(stored) != 3 {
+		t.Fatalf("persisted %d jobs, want 3", len(stored))
+	}
+
+	close(release)
+	for _, want := range []string{"a", "b", "c"} {
+		select {
+		case got := <-done:
+			if got != want {
+				t.Fatalf("ran %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for job %q", want)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stored, _ := db.ListQueuedJobs()
+		if len(stored) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d finished jobs still persisted", len(stored))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManagerUserKindLimit(t *testing.T) {
+	db := newTestDB(t)
+	m := NewManager()
+	m.SetUserKindLimit(queue.KindVideo, 1)
+	run := func(ctx context.Context, job database.QueuedJob) error {
+		<-ctx.Done()
+		return nil
+	}
+	start(t, m, db, 1, 0, run, nil)
+
+	if _, err := m.Submit(database.QueuedJob{UID: "alice", Command: "text2video", Args: []string{"a"}}); err != nil {
+		t.Fatalf("first video: %v", err)
+	}
+	var limitErr *ErrUserLimit
+	if _, err := m.Submit(database.QueuedJob{UID: "alice", Command: "image2video", Args: []string{"b"}}); !errors.As(err, &limitErr) || limitErr.Kind != queue.KindVideo {
+		t.Fatalf("second video = %v, want the video limit", err)
+	}
+	if _, err := m.Submit(database.QueuedJob{UID: "alice", Command: "text2image", Args: []string{"c"}}); err != nil {
+		t.Fatalf("image beside a video: %v", err)
+	}
+	if _, err := m.Submit(database.QueuedJob{UID: "bob", Command: "text2video", Args: []string{"d"}}); err != nil {
+		t.Fatalf("video of another user: %v", err)
+	}
+}
+
+func TestManagerSkipsSaturatedKinds(t *testing.T) {
+	db := newTestDB(t)
+	started := make(chan string, 3)
+	m := NewManager()
+	m.SetKindLimit(queue.KindVideo, 1)
+	run := func(ctx context.Context, job database.QueuedJob) error {
+		started <- job.Args[0]
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	start(t, m, db, 3, 0, run, nil)
+
+	if _, err := m.Submit(database.QueuedJob{UID: "alice", Command: "text2video", Args: []string{"a"}}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	waitStarted(t, started, "a")
+	st, err := m.Submit(database.QueuedJob{UID: "bob", Command: "image2video", Args: []string{"b"}})
+	if err != nil || st.Position != 1 || st.ETA < 9*time.Minute {
+		t.Fatalf("second video = %+v, %v; want position 1 behind the first video", st, err)
+	}
+	// The free workers pass over the video waiting for its slot
+	st, err = m.Submit(database.QueuedJob{UID: "carol", Command: "text2image", Args: []string{"c"}})
+	if err != nil || st.Position != 0 || st.ETA > time.Minute {
+		t.Fatalf("image = %+v, %v; want it to start right away", st, err)
+	}
+	waitStarted(t, started, "c")
+	if got := m.UserStatus("bob"); len(got) != 1 || got[0].Running || got[0].Position != 1 {
+		t.Fatalf("UserStatus(bob) = %+v, want the video waiting at position 1", got)
+	}
+
+	// Raising the limit starts the waiting video
+	m.SetKindLimit(queue.KindVideo, 2)
+	waitStarted(t, started, "b")
+}
+
+func TestManagerRecoversPersistedJobs(t *testing.T) {
+	db := newTestDB(t)
+	pendingID, err := db.EnqueueJob(database.QueuedJob{UID: "alice", Command: "text2image", Args: []string{"pending"}, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("EnqueueJob: %v", err)
+	}
+	runningID, err := db.EnqueueJob(database.QueuedJob{UID: "bob", Command: "text2video", Args: []string{"running"}, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("EnqueueJob: %v", err)
+	}
+	if err := db.MarkJobRunning(runningID, time.Now()); err != nil {
+		t.Fatalf("MarkJobRunning: %v", err)
+	}
+	// A running job waiting for a fal request is run again to resume it
+	resumedID, err := db.EnqueueJob(database.QueuedJob{UID: "carol", Command: "text2video", Args: []string{"resumed"}, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("EnqueueJob: %v", err)
+	}
+	if err := db.MarkJobRunning(resumedID, time.Now()); err != nil {
+		t.Fatalf("MarkJobRunning: %v", err)
+	}
+	if err := db.SetQueuedJobResponseURL(resumedID, "https://queue.fal.run/fal-ai/x/requests/1"); err != nil {
+		t.Fatalf("SetQueuedJobResponseURL: %v", err)
+	}
+
+	ran := make(chan database.QueuedJob, 3)
+	var interrupted []int64
+	m := NewManager()
+	run := func(ctx context.Context, job database.QueuedJob) error {
+		ran <- job
+		return nil
+	}
+	start(t, m, db, 1, 0, run, func(j database.QueuedJob) { interrupted = append(interrupted, j.ID) })
+
+	if len(interrupted) != 1 || interrupted[0] != runningID {
+		t.Errorf("interrupted = %v, want [%d]", interrupted, runningID)
+	}
+	for _, want := range []int64{resumedID, pendingID} {
+		select {
+		case job := <-ran:
+			if job.ID != want {
+				t.Errorf("resumed job %d, want %d", job.ID, want)
+			}
+			if job.ID == resumedID && job.ResponseURL == "" {
+				t.Error("resumed job lost its response URL")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("job %d was not resumed", want)
+		}
+	}
+	select {
+	case job := <-ran:
+		t.Errorf("interrupted job %d was run again", job.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestManagerCancel(t *testing.T) {
+	db := newTestDB(t)
+	started := make(chan string, 1)
+	causes := make(chan error, 1)
+	m := NewManager()
+	run := func(ctx context.Context, job database.QueuedJob) error {
+		started <- job.Args[0]
+		<-ctx.Done()
+		causes <- context.Cause(ctx)
+		return ctx.Err()
+	}
+	start(t, m, db, 1, 0, run, nil)
+	running, _ := m.Submit(database.QueuedJob{UID: "alice", Command: "text2video", Args: []string{"a"}})
+	waitStarted(t, started, "a")
+	pending, _ := m.Submit(database.QueuedJob{UID: "alice", Command: "text2video", Args: []string{"b"}})
+
+	if _, _, err := m.Cancel("bob", pending.Job.ID); err != ErrJobNotFound {
+		t.Errorf("Cancel of another user's job = %v, want ErrJobNotFound", err)
+	}
+	if _, wasRunning, err := m.Cancel("alice", pending.Job.ID); err != nil || wasRunning {
+		t.Fatalf("Cancel(pending) = %v, %v; want not running, nil", wasRunning, err)
+	}
+	if got := m.UserStatus("alice"); len(got) != 1 || !got[0].Running {
+		t.Fatalf("UserStatus after cancelling the pending job = %+v", got)
+	}
+
+	if _, wasRunning, err := m.Cancel("alice", running.Job.ID); err != nil || !wasRunning {
+		t.Fatalf("Cancel(running) = %v, %v; want running, nil", wasRunning, err)
+	}
+	select {
+	case cause := <-causes:
+		if cause != ErrCanceled {
+			t.Errorf("context cause = %v, want ErrCanceled", cause)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("running job was not cancelled")
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if stored, _ := db.ListQueuedJobs(); len(stored) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cancelled job still persisted")
+		}
+	}
+}
+
+func TestManagerSubmitBatch(t *testing.T) {
+	db := newTestDB(t)
+	m := NewManager()
+	errs := make(chan error, 2)
+	release := make(chan struct{})
+	run := func(ctx context.Context, job database.QueuedJob) error {
+		if job.Command != "batch" {
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		// The running batch job does not count against the limit of 3
+		_, err := m.SubmitBatch(ctx, []database.QueuedJob{
+			{UID: "alice", Command: "text2image", Args: []string{"1"}},
+			{UID: "alice", Command: "text2image", Args: []string{"2"}},
+			{UID: "alice", Command: "text2image", Args: []string{"3"}},
+		})
+		errs <- err
+		_, err = m.SubmitBatch(ctx, []database.QueuedJob{{UID: "alice", Command: "text2image", Args: []string{"4"}}})
+		errs <- err
+		return nil
+	}
+	done := make(chan error, 4)
+	m.OnDone(func(job database.QueuedJob, err error) {
+		if job.Command != "batch" {
+			done <- err
+		}
+	})
+	start(t, m, db, 1, 3, run, nil)
+	if _, err := m.Submit(database.QueuedJob{UID: "alice", Command: "batch"}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Fatalf("SubmitBatch: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch job did not run")
+	}
+	var limitErr *ErrUserLimit
+	if err := <-errs; !errors.As(err, &limitErr) || limitErr.Limit != 3 {
+		t.Fatalf("SubmitBatch beyond the limit = %v, want ErrUserLimit", err)
+	}
+	if stored, _ := db.ListQueuedJobs(); len(stored) > 4 {
+		t.Fatalf("persisted %d jobs, want the batch job and its 3 jobs at most", len(stored))
+	}
+
+	// Jobs cancelled before they ran are reported as done too
+	st := m.UserStatus("alice")
+	if _, _, err := m.Cancel("alice", st[len(st)-1].Job.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	close(release)
+	var canceled, finished int
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-done:
+			if err == ErrCanceled {
+				canceled++
+			} else if err == nil {
+				finished++
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for OnDone")
+		}
+	}
+	if canceled != 1 || finished != 2 {
+		t.Fatalf("OnDone reported %d cancelled and %d finished jobs, want 1 and 2", canceled, finished)
+	}
+}
+
+func TestFormatETA(t *testing.T) {
+	tests := map[time.Duration]string{
+		20 * time.Second:               "under a minute",
+		4*time.Minute + 40*time.Second: "~5 min",
+		90 * time.Minute:               "~1h30m",
+	}
+	for d, want := range tests {
+		if got := FormatETA(d); got != want {
+			t.Errorf("FormatETA(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
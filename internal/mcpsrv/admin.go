@@ -279,14 +279,11 @@ func (a *Admin) AttachAdmin(h *server.Harness) {
 			if err != nil {
 				return nil, err
 			}
-			if err := a.db.UpdateBalance(uid, matoms); err != nil {
-				return nil, err
-			}
-			a.alog.append(actor, "admin_credit", in)
-			bal, err := a.db.GetBalance(uid)
+			bal, err := a.db.AdjustBalance(uid, matoms, time.Now())
 			if err != nil {
 				return nil, err
 			}
+			a.alog.append(actor, "admin_credit", in)
 			return map[string]any{"uid": uid, "balance_dcr": matomsToDCR(bal)}, nil
 		})
 
@@ -304,18 +301,11 @@ func (a *Admin) AttachAdmin(h *server.Harness) {
 			if err != nil {
 				return nil, err
 			}
-			raw, err := hex.DecodeString(uid)
+			bal, err := a.db.AdjustBalance(uid, -matoms, time.Now())
 			if err != nil {
 				return nil, err
 			}
-			if ok, err := a.db.CheckAndDeductBalance(raw, matoms, false); !ok {
-				return nil, err
-			}
 			a.alog.append(actor, "admin_debit", in)
-			bal, err := a.db.GetBalance(uid)
-			if err != nil {
-				return nil, err
-			}
 			return map[string]any{"uid": uid, "balance_dcr": matomsToDCR(bal)}, nil
 		})
 
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/companyzero/bisonrelay/zkidentity"
 	"github.com/decred/dcrd/dcrutil/v4"
@@ -26,18 +27,22 @@ import (
 	kit "github.com/vctt94/bisonbotkit"
 
 	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/debuglog"
 	"github.com/karamble/braibot/internal/faladapter"
 	"github.com/karamble/braibot/internal/image"
+	"github.com/karamble/braibot/internal/joblog"
+	"github.com/karamble/braibot/internal/money"
 	"github.com/karamble/braibot/internal/speech"
 	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
 	"github.com/karamble/braibot/internal/video"
 	"github.com/karamble/braibot/pkg/fal"
+	"github.com/karamble/braibot/pkg/genai"
 )
 
 // braibot's balance store keeps milli-atoms (1e11 per DCR); the brmcp
 // Billing contract speaks atoms.
-const matomsPerAtom = 1000
+const matomsPerAtom = money.AtomsPerChainAtom
 
 // Billing adapts braibot's per-user balance database to server.Billing, so
 // harness debits, refunds, tip credits, and invoice settlements all move
@@ -68,8 +73,8 @@ func (b *Billing) Debit(uid string, atoms int64) error {
 	if err != nil {
 		return fmt.Errorf("bad uid: %w", err)
 	}
-	ok, err := b.db.CheckAndDeductBalance(raw, atoms*matomsPerAtom, b.debug)
-	if err != nil && !ok {
+	// The harness debits each call once and offers no id to deduplicate by
+	if _, _, err := b.db.CheckAndDeductBalance(raw, atoms*matomsPerAtom, "", b.debug); err != nil {
 		// The store reports a shortfall as an error string; the harness
 		// needs the sentinel to build payment_required.
 		if strings.Contains(err.Error(), "insufficient balance") {
@@ -77,24 +82,17 @@ func (b *Billing) Debit(uid string, atoms int64) error {
 		}
 		return err
 	}
-	if !ok {
-		return server.ErrInsufficient
-	}
 	return nil
 }
 
 // usdToAtoms quotes a USD price in atoms at the current exchange rate
 // (braibot's cached CoinGecko feed).
 func usdToAtoms(usd float64) (int64, error) {
-	dcr, err := utils.USDToDCR(usd)
+	matoms, err := utils.USDToAtoms(usd)
 	if err != nil {
 		return 0, fmt.Errorf("exchange rate unavailable: %w", err)
 	}
-	amt, err := dcrutil.NewAmount(dcr)
-	if err != nil {
-		return 0, err
-	}
-	return int64(amt), nil
+	return money.AtomsToChainAtoms(matoms), nil
 }
 
 // resolveModel picks the explicit model or the caller's current default for
@@ -117,14 +115,11 @@ func resolveModel(name, commandType, uid string) (faladapter.AppModel, error) {
 // videoPriceUSD prices per-second models by the requested duration
 // (defaulting to 5 seconds, the common fal default), flat otherwise.
 func videoPriceUSD(m faladapter.AppModel, duration string) float64 {
-	if !m.PerSecondPricing {
-		return m.PriceUSD
-	}
 	d, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(duration), "s"))
 	if err != nil || d <= 0 {
 		d = 5
 	}
-	return m.PriceUSD * float64(d)
+	return faladapter.PriceFor(m, faladapter.PriceParams{Seconds: d})
 }
 
 func genReq(commandType string, m faladapter.AppModel, peer string) (braibottypes.GenerationRequest, error) {
@@ -139,7 +134,7 @@ func genReq(commandType string, m faladapter.AppModel, peer string) (braibottype
 		ModelType: commandType,
 		UserNick:  peer,
 		UserID:    uid,
-		PriceUSD:  m.PriceUSD,
+		PriceUSD:  faladapter.PriceFor(m, faladapter.PriceParams{}),
 		IsPM:      true,
 	}, nil
 }
@@ -213,11 +208,16 @@ var exposed = []string{"text2image", "text2video", "image2video", "text2speech"}
 
 // Attach registers braibot's MCP tools on the harness. Services are built
 // with billing DISABLED: the harness already debited the quote, so the
-// service only validates, generates, and delivers over the DM.
-func Attach(h *server.Harness, falClient *fal.Client, db *database.DBManager, bot *kit.Bot, debug bool) {
-	imageSvc := image.NewImageService(falClient, db, bot, debug, false)
+// service only validates, generates, and delivers over the DM. The services
+// log to their loggers of logs. Images are generated with images, the
+// other media with falClient.
+func Attach(h *server.Harness, falClient *fal.Client, images genai.ImageGenerator, db *database.DBManager, bot *kit.Bot, logs debuglog.Backend, debug bool) {
+	imageSvc := image.NewImageService(images, db, bot, debug, false)
+	imageSvc.SetLogger(joblog.New(logs, joblog.Image))
 	videoSvc := video.NewVideoService(falClient, db, bot, debug, false)
+	videoSvc.SetLogger(joblog.New(logs, joblog.Video))
 	speechSvc := speech.NewSpeechService(falClient, db, bot, debug, false)
+	speechSvc.SetLogger(joblog.New(logs, joblog.Speech))
 
 	server.AddTool(h, &mcp.Tool{
 		Name:        "list_models",
@@ -274,7 +274,7 @@ func Attach(h *server.Harness, falClient *fal.Client, db *database.DBManager, bo
 		if n <= 0 {
 			n = 1
 		}
-		return usdToAtoms(m.PriceUSD * float64(n))
+		return usdToAtoms(faladapter.PriceFor(m, faladapter.PriceParams{NumImages: n}))
 	}, func(ctx context.Context, peer string, in text2ImageIn) (any, error) {
 		if strings.TrimSpace(in.Prompt) == "" {
 			return nil, errors.New("prompt is required")
@@ -291,7 +291,7 @@ func Attach(h *server.Harness, falClient *fal.Client, db *database.DBManager, bo
 		if n <= 0 {
 			n = 1
 		}
-		base.ExternalBilling = externalBilling(ctx, db, peer, m.PriceUSD*float64(n))
+		base.ExternalBilling = externalBilling(ctx, db, peer, faladapter.PriceFor(m, faladapter.PriceParams{NumImages: n}))
 		req := &image.ImageRequest{GenerationRequest: base, Prompt: in.Prompt, NumImages: in.NumImages}
 		if _, err := imageSvc.GenerateImage(ctx, req); err != nil {
 			return nil, err
@@ -370,7 +370,7 @@ func Attach(h *server.Harness, falClient *fal.Client, db *database.DBManager, bo
 		if err != nil {
 			return 0, err
 		}
-		return usdToAtoms(m.PriceUSD)
+		return usdToAtoms(faladapter.PriceFor(m, faladapter.PriceParams{TextChars: utf8.RuneCountInString(in.Text)}))
 	}, func(ctx context.Context, peer string, in text2SpeechIn) (any, error) {
 		if strings.TrimSpace(in.Text) == "" {
 			return nil, errors.New("text is required")
@@ -383,7 +383,7 @@ func Attach(h *server.Harness, falClient *fal.Client, db *database.DBManager, bo
 		if err != nil {
 			return nil, err
 		}
-		base.ExternalBilling = externalBilling(ctx, db, peer, m.PriceUSD)
+		base.ExternalBilling = externalBilling(ctx, db, peer, faladapter.PriceFor(m, faladapter.PriceParams{TextChars: utf8.RuneCountInString(in.Text)}))
 		req := &speech.SpeechRequest{GenerationRequest: base, Text: in.Text, VoiceID: in.VoiceID}
 		if _, err := speechSvc.GenerateSpeech(ctx, req); err != nil {
 			return nil, err
@@ -0,0 +1,288 @@
+// Package model3d turns images into 3D models with image23d models hosted
+// on fal and delivers them as GLB or OBJ files.
+package model3d
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/decred/slog"
+	"github.com/karamble/braibot/internal/assets"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/jobevents"
+	"github.com/karamble/braibot/internal/joblog"
+	"github.com/karamble/braibot/internal/transfer"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/pkg/fal"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+// TexturedPriceFactor is how many times the model's price a textured mesh
+// costs.
+const TexturedPriceFactor = 3
+
+// Model3DRequest represents an internal request to turn an image into a 3D
+// model
+type Model3DRequest struct {
+	braibottypes.GenerationRequest
+	ImageURL         string                 // http(s) URL of the image
+	Format           string                 // glb or obj; empty uses the model's default
+	RemoveBackground *bool                  // Cut the object out first; nil uses the model's default
+	Textured         bool                   // Paint textures onto the mesh, for models that can
+	Seed             *int                   // Nil for a random seed
+	Options          map[string]interface{} // Tuning options of the model, as pointers keyed by name
+}
+
+// Model3DResult represents the result of a 3D model generation
+type Model3DResult struct {
+	ModelURL string
+	Format   string
+	Method   transfer.Method // How the file reached a PM requester
+	Success  bool
+	Error    error
+}
+
+// IsSuccess checks if the generation was successful.
+func (r *Model3DResult) IsSuccess() bool {
+	if r == nil {
+		return false
+	}
+	return r.Success
+}
+
+// GetError returns the error from the generation, if any.
+func (r *Model3DResult) GetError() error {
+	if r == nil {
+		return nil
+	}
+	return r.Error
+}
+
+// Model3DService handles 3D model generation
+type Model3DService struct {
+	client         *fal.Client
+	dbManager      *database.DBManager
+	bot            *kit.Bot
+	sender         *braibottypes.MessageSender
+	debug          bool
+	billingEnabled atomic.Bool // Toggled at runtime by !admin billing
+	transfer       *transfer.Sender
+	publisher      *assets.Publisher // Asset server for GC links, nil when not configured
+	log            slog.Logger
+}
+
+// NewModel3DService creates a new Model3DService
+func NewModel3DService(client *fal.Client, dbManager *database.DBManager, bot *kit.Bot, debug bool, billingEnabled bool) *Model3DService {
+	s := &Model3DService{
+		client:    client,
+		dbManager: dbManager,
+		bot:       bot,
+		sender:    braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot)),
+		debug:     debug,
+		transfer:  transfer.NewSender(bot, transfer.DefaultLimits),
+		log:       joblog.New(nil, joblog.Model3D),
+	}
+	s.billingEnabled.Store(billingEnabled)
+	return s
+}
+
+// SetBillingEnabled turns charging for generations on or off.
+func (s *Model3DService) SetBillingEnabled(enabled bool) {
+	s.billingEnabled.Store(enabled)
+}
+
+// SetLogger sets the logger the service logs its jobs to.
+func (s *Model3DService) SetLogger(l slog.Logger) {
+	s.log = l
+}
+
+// SetAssetPublisher sets the asset server that 3D models for group chats
+// are linked from.
+func (s *Model3DService) SetAssetPublisher(p *assets.Publisher) {
+	s.publisher = p
+}
+
+// SetTransferLimits sets the size limits 3D models are delivered under.
+func (s *Model3DService) SetTransferLimits(limits transfer.Limits) {
+	s.transfer = transfer.NewSender(s.bot, limits)
+}
+
+// Price returns what a request for model costs in USD, markup included.
+func Price(model faladapter.AppModel, textured bool) float64 {
+	cost := model.PriceUSD
+	if textured {
+		cost *= TexturedPriceFactor
+	}
+	return faladapter.MarkupFor(model.Name).Apply(cost)
+}
+
+// falRequest builds the fal request for the request's model.
+func falRequest(req *Model3DRequest) (interface{}, error) {
+	base := fal.BaseModel3DRequest{
+		ImageURL: req.ImageURL,
+		Model:    req.ModelName,
+		Progress: req.Progress,
+	}
+	switch req.ModelName {
+	case "triposr":
+		if req.Textured {
+			return nil, fmt.Errorf("%s does not texture its models", req.ModelName)
+		}
+		if req.Seed != nil {
+			return nil, fmt.Errorf("%s does not take a seed", req.ModelName)
+		}
+		return &fal.TripoSRRequest{
+			BaseModel3DRequest: base,
+			OutputFormat:       req.Format,
+			DoRemoveBackground: req.RemoveBackground,
+			ForegroundRatio:    floatOption(req.Options, "foreground_ratio"),
+			MCResolution:       intOption(req.Options, "mc_resolution"),
+		}, nil
+	case "hunyuan3d-v2":
+		if req.Format != "" && req.Format != "glb" {
+			return nil, fmt.Errorf("%s only returns GLB models", req.ModelName)
+		}
+		if req.RemoveBackground != nil {
+			return nil, fmt.Errorf("%s does not remove backgrounds", req.ModelName)
+		}
+		return &fal.Hunyuan3DRequest{
+			BaseModel3DRequest: base,
+			Seed:               req.Seed,
+			NumInferenceSteps:  intOption(req.Options, "num_inference_steps"),
+			GuidanceScale:      floatOption(req.Options, "guidance_scale"),
+			OctreeResolution:   intOption(req.Options, "octree_resolution"),
+			TexturedMesh:       req.Textured,
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported image23d model: %s", req.ModelName)
+}
+
+// intOption returns the option name of opts, or 0 when it is not set.
+func intOption(opts map[string]interface{}, name string) int {
+	if v, ok := opts[name].(*int); ok && v != nil {
+		return *v
+	}
+	return 0
+}
+
+// floatOption returns the option name of opts, or 0 when it is not set.
+func floatOption(opts map[string]interface{}, name string) float64 {
+	if v, ok := opts[name].(*float64); ok && v != nil {
+		return *v
+	}
+	return 0
+}
+
+// Generate turns the request's image into a 3D model, delivers the file to
+// the PM or GC the request came from and bills the model's price.
+func (s *Model3DService) Generate(ctx context.Context, req *Model3DRequest) (*Model3DResult, error) {
+	if req.ImageURL == "" {
+		err := fmt.Errorf("image URL is required")
+		return &Model3DResult{Success: false, Error: err}, err
+	}
+	model, ok := faladapter.GetModel(req.ModelName, "image23d")
+	if !ok {
+		err := fmt.Errorf("model not found: %s", req.ModelName)
+		return &Model3DResult{Success: false, Error: err}, err
+	}
+	falReq, err := falRequest(req)
+	if err != nil {
+		return &Model3DResult{Success: false, Error: err}, err
+	}
+	req.PriceUSD = Price(model, req.Textured)
+	jobevents.Default.Submit(&req.GenerationRequest)
+
+	// 1. CHECK balance if billing is enabled
+	var requiredDCR, currentBalanceDCR float64
+	var checkErr error
+	if s.billingEnabled.Load() {
+		requiredDCR, currentBalanceDCR, checkErr = utils.CheckRequestBalance(ctx, s.dbManager, &req.GenerationRequest, req.PriceUSD, s.debug, s.billingEnabled.Load())
+		if checkErr != nil {
+			jobevents.Default.EmitFailed(&req.GenerationRequest, checkErr)
+			return &Model3DResult{Success: false, Error: checkErr}, checkErr
+		}
+	}
+
+	// 2. Send initial message (adjusted for billing status)
+	if req.IsPM {
+		var infoMsg string
+		if s.billingEnabled.Load() {
+			infoMsg = fmt.Sprintf("Cost: $%.2f USD (%.8f DCR). Your balance: %.8f DCR. Building your 3D model...",
+				req.PriceUSD, requiredDCR, currentBalanceDCR)
+		} else {
+			infoMsg = "Building your 3D model (billing disabled)..."
+		}
+		s.sender.SendMessage(ctx, req.MessageContext(), infoMsg)
+	}
+
+	// Wait for a free slot for this kind of job
+	release, slotErr := utils.AcquireJobSlot(ctx, s.bot, &req.GenerationRequest)
+	if slotErr != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, slotErr)
+		return &Model3DResult{Success: false, Error: slotErr}, slotErr
+	}
+	defer release()
+
+	// 3. Run the model
+	resp, err := s.client.Generate3D(ctx, falReq)
+	if err != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, err)
+		return &Model3DResult{Success: false, Error: err}, err
+	}
+	result := &Model3DResult{ModelURL: resp.ModelURL, Format: resp.Format, Success: true}
+
+	// 4. Deliver the file. Files cannot be sent to group chats, so GC
+	// requests get a link posted in the GC instead
+	if req.IsPM {
+		method, err := s.transfer.SendFile(ctx, req.UserNick, resp.ModelURL, "model."+resp.Format, "3D model")
+		if err != nil {
+			jobevents.Default.EmitFailed(&req.GenerationRequest, err)
+			return &Model3DResult{Success: false, Error: err}, err
+		}
+		result.Method = method
+	} else {
+		label := "3D model for " + utils.SanitizeUserText(req.UserNick)
+		if err := utils.SendGCLink(ctx, s.bot, s.publisher, req.GC, label, resp.ModelURL); err != nil {
+			jobevents.Default.EmitFailed(&req.GenerationRequest, err)
+			return &Model3DResult{Success: false, Error: err}, err
+		}
+	}
+	jobevents.Default.EmitDelivered(&req.GenerationRequest, 1)
+
+	// 5. Perform Billing *only if* enabled
+	var chargedDCR float64
+	var finalBalanceDCR float64 = currentBalanceDCR
+	var billingSucceeded bool
+	var splitCharge *utils.SplitCharge
+	if s.billingEnabled.Load() {
+		deductChargedDCR, deductNewBalance, deductSplit, deductErr := utils.DeductRequestBalance(ctx, s.dbManager, &req.GenerationRequest, req.PriceUSD, s.debug, s.billingEnabled.Load())
+		if deductErr != nil {
+			if req.IsPM {
+				s.sender.SendMessage(ctx, req.MessageContext(), fmt.Sprintf("Error processing payment after sending the 3D model: %v. Please contact support.", deductErr))
+			}
+		} else {
+			billingSucceeded = true
+			chargedDCR = deductChargedDCR
+			finalBalanceDCR = deductNewBalance
+			splitCharge = deductSplit
+			jobevents.Default.EmitBilled(&req.GenerationRequest, chargedDCR)
+		}
+	}
+
+	joblog.For(s.log, &req.GenerationRequest).Infof("Delivered %s model (%d bytes), charged %.8f DCR", resp.Format, resp.FileSize, chargedDCR)
+
+	// 6. Send final confirmation
+	if req.IsPM {
+		s.sender.SendMessage(ctx, req.MessageContext(), utils.FormatBillingConfirmation("3D model", s.billingEnabled.Load(), s.billingEnabled.Load(), billingSucceeded, chargedDCR, req.PriceUSD, finalBalanceDCR)+utils.LowBalanceWarning(s.dbManager, req.UserID.String(), finalBalanceDCR, chargedDCR, req.PriceUSD))
+	} else if splitCharge != nil {
+		// The group sees the pot's share; the requester's share and balance
+		// go by PM
+		s.sender.SendMessage(ctx, req.MessageContext(), utils.FormatSplitPotReceipt(splitCharge))
+		s.sender.SendPrivateMessage(ctx, req.MessageContext(), utils.FormatSplitBillingConfirmation(splitCharge, req.PriceUSD)+utils.LowBalanceWarning(s.dbManager, req.UserID.String(), splitCharge.UserBalanceDCR, splitCharge.UserDCR+splitCharge.PotDCR, req.PriceUSD))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package model3d
+
+import (
+	"testing"
+
+	"github.com/karamble/braibot/internal/faladapter"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+func TestFalRequest(t *testing.T) {
+	resolution, ratio := 512, 0.8
+	req := &Model3DRequest{
+		GenerationRequest: braibottypes.GenerationRequest{ModelName: "triposr"},
+		ImageURL:          "https://example.com/chair.png",
+		Format:            "obj",
+		Options:           map[string]interface{}{"mc_resolution": &resolution, "foreground_ratio": &ratio},
+	}
+	got, err := falRequest(req)
+	if err != nil {
+		t.Fatalf("falRequest: %v", err)
+	}
+	tripo, ok := got.(*fal.TripoSRRequest)
+	if !ok {
+		t.Fatalf("falRequest = %T, want *fal.TripoSRRequest", got)
+	}
+	if tripo.ImageURL != req.ImageURL || tripo.OutputFormat != "obj" || tripo.MCResolution != 512 || tripo.ForegroundRatio != 0.8 {
+		t.Errorf("triposr request = %+v", tripo)
+	}
+
+	seed := 7
+	req = &Model3DRequest{
+		GenerationRequest: braibottypes.GenerationRequest{ModelName: "hunyuan3d-v2"},
+		ImageURL:          "https://example.com/statue.png",
+		Textured:          true,
+		Seed:              &seed,
+	}
+	got, err = falRequest(req)
+	if err != nil {
+		t.Fatalf("falRequest: %v", err)
+	}
+	if h, ok := got.(*fal.Hunyuan3DRequest); !ok || !h.TexturedMesh || h.Seed == nil || *h.Seed != 7 {
+		t.Errorf("hunyuan3d request = %#v", got)
+	}
+
+	for _, bad := range []*Model3DRequest{
+		{GenerationRequest: braibottypes.GenerationRequest{ModelName: "hunyuan3d-v2"}, Format: "obj"},
+		{GenerationRequest: braibottypes.GenerationRequest{ModelName: "triposr"}, Textured: true},
+		{GenerationRequest: braibottypes.GenerationRequest{ModelName: "triposr"}, Seed: &seed},
+		{GenerationRequest: braibottypes.GenerationRequest{ModelName: "flux/dev"}},
+	} {
+		if _, err := falRequest(bad); err == nil {
+			t.Errorf("falRequest(%s, format %q, textured %v) accepted", bad.ModelName, bad.Format, bad.Textured)
+		}
+	}
+}
+
+func TestPrice(t *testing.T) {
+	model := faladapter.AppModel{PriceUSD: 0.16}
+	if got := Price(model, false); got != 0.16 {
+		t.Errorf("Price = %v, want 0.16", got)
+	}
+	if got := Price(model, true); got != 0.48 {
+		t.Errorf("textured Price = %v, want 0.48", got)
+	}
+}
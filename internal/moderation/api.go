@@ -0,0 +1,89 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// API screens prompts with an external moderation service that speaks the
+// OpenAI moderation format: it posts {"input": prompt} and rejects prompts
+// whose first result is flagged.
+type API struct {
+	URL        string
+	Key        string // Sent as a bearer token when set
+	Model      string // Sent as "model" when set
+	HTTPClient *http.Client
+}
+
+// NewAPI creates a checker for the moderation endpoint at url.
+func NewAPI(url, key string) *API {
+	return &API{URL: url, Key: key, HTTPClient: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Check implements Checker.
+func (a *API) Check(ctx context.Context, prompt string) error {
+	body := map[string]string{"input": prompt}
+	if a.Model != "" {
+		body["model"] = a.Model
+	}
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal moderation request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("failed to create moderation request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.Key != "" {
+		req.Header.Set("Authorization", "Bearer "+a.Key)
+	}
+
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("moderation request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read moderation response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("moderation request failed with status %d: %s", resp.StatusCode, string(data))
+	}
+
+	var result struct {
+		Results []struct {
+			Flagged    bool            `json:"flagged"`
+			Categories map[string]bool `json:"categories"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to parse moderation response: %v", err)
+	}
+	if len(result.Results) == 0 {
+		return fmt.Errorf("moderation response has no results")
+	}
+	if r := result.Results[0]; r.Flagged {
+		var categories []string
+		for c, on := range r.Categories {
+			if on {
+				categories = append(categories, c)
+			}
+		}
+		sort.Strings(categories)
+		reason := "flagged"
+		if len(categories) > 0 {
+			reason = "flagged for " + strings.Join(categories, ", ")
+		}
+		return &Rejection{Checker: "moderation API", Reason: reason}
+	}
+	return nil
+}
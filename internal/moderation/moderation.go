@@ -0,0 +1,42 @@
+// Package moderation screens prompts before generation commands submit them,
+// so operators of public bots can turn away abusive requests before they
+// cost anything.
+package moderation
+
+import (
+	"context"
+	"fmt"
+)
+
+// Checker screens a prompt. It returns a *Rejection for prompts that must
+// not be submitted; other errors mean the prompt could not be checked.
+type Checker interface {
+	Check(ctx context.Context, prompt string) error
+}
+
+// Rejection is returned for prompts a checker turned away.
+type Rejection struct {
+	Checker string // Name of the checker, e.g. "wordlist"
+	Reason  string // Why, e.g. the matched rule or flagged categories
+}
+
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("prompt rejected by %s: %s", r.Checker, r.Reason)
+}
+
+// Chain runs checkers in order and stops at the first rejection or error.
+type Chain []Checker
+
+// Check implements Checker.
+func (c Chain) Check(ctx context.Context, prompt string) error {
+	for _, checker := range c {
+		if err := checker.Check(ctx, prompt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Default is the checker generation commands are screened with; nil lets
+// every prompt through.
+var Default Checker
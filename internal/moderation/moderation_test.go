@@ -0,0 +1,83 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWordList(t *testing.T) {
+	wl, err := ParseWordList("# abusive prompts\nbadword\n\nvery  bad phrase\n/\\bkill(ing)? \\w+/\n")
+	if err != nil {
+		t.Fatalf("ParseWordList: %v", err)
+	}
+	if wl.Len() != 3 {
+		t.Fatalf("Len = %d, want 3", wl.Len())
+	}
+	tests := []struct {
+		prompt string
+		reject bool
+	}{
+		{"a cat on the moon", false},
+		{"a BadWord here", true},
+		{"badwords are fine", false},
+		{"a very bad\tphrase", true},
+		{"killing time", true},
+		{"skill building", false},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		err := wl.Check(ctx, tt.prompt)
+		var rej *Rejection
+		if got := errors.As(err, &rej); got != tt.reject || (err != nil && !got) {
+			t.Errorf("Check(%q) = %v, want rejected %v", tt.prompt, err, tt.reject)
+		}
+	}
+
+	if _, err := ParseWordList("/(unclosed/"); err == nil {
+		t.Error("ParseWordList accepted an invalid regexp")
+	}
+}
+
+func TestAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Input string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		flagged := req.Input == "hateful"
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []map[string]interface{}{{
+				"flagged":    flagged,
+				"categories": map[string]bool{"hate": flagged, "violence": false},
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	api := NewAPI(srv.URL, "key")
+	if err := api.Check(ctx, "a cat"); err != nil {
+		t.Errorf("Check(a cat) = %v", err)
+	}
+	var rej *Rejection
+	if err := api.Check(ctx, "hateful"); !errors.As(err, &rej) || rej.Reason != "flagged for hate" {
+		t.Errorf("Check(hateful) = %v, want flagged for hate", err)
+	}
+	// Failures to check are errors, not rejections
+	if err := NewAPI(srv.URL, "wrong").Check(ctx, "a cat"); err == nil || errors.As(err, &rej) {
+		t.Errorf("Check with a bad key = %v, want a plain error", err)
+	}
+
+	wl, _ := ParseWordList("cat")
+	if err := (Chain{api, wl}).Check(ctx, "a cat"); !errors.As(err, &rej) || rej.Checker != "wordlist" {
+		t.Errorf("Chain.Check = %v, want the word list's rejection", err)
+	}
+}
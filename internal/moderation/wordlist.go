@@ -0,0 +1,80 @@
+package moderation
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// WordList rejects prompts containing any of a list of words, phrases or
+// regular expressions.
+type WordList struct {
+	rules []wordRule
+}
+
+type wordRule struct {
+	source string // The line of the rule, reported as the reason
+	re     *regexp.Regexp
+}
+
+// ParseWordList parses a word list: one rule per line, blank lines and lines
+// starting with # ignored. Rules wrapped in slashes, e.g. /kill(ing)? \w+/,
+// are regular expressions; others are words or phrases matched as whole
+// words. Both match case-insensitively.
+func ParseWordList(text string) (*WordList, error) {
+	wl := &WordList{}
+	sc := bufio.NewScanner(strings.NewReader(text))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var expr string
+		if len(line) > 2 && strings.HasPrefix(line, "/") && strings.HasSuffix(line, "/") {
+			expr = line[1 : len(line)-1]
+		} else {
+			words := strings.Fields(line)
+			for i, w := range words {
+				words[i] = regexp.QuoteMeta(w)
+			}
+			expr = `\b` + strings.Join(words, `\s+`) + `\b`
+		}
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		wl.rules = append(wl.rules, wordRule{source: line, re: re})
+	}
+	return wl, sc.Err()
+}
+
+// LoadWordList reads a word list file; see ParseWordList.
+func LoadWordList(path string) (*WordList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read word list: %v", err)
+	}
+	wl, err := ParseWordList(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid word list %s: %v", path, err)
+	}
+	return wl, nil
+}
+
+// Len returns the number of rules.
+func (wl *WordList) Len() int {
+	return len(wl.rules)
+}
+
+// Check implements Checker.
+func (wl *WordList) Check(_ context.Context, prompt string) error {
+	for _, r := range wl.rules {
+		if r.re.MatchString(prompt) {
+			return &Rejection{Checker: "wordlist", Reason: fmt.Sprintf("matches %q", r.source)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Package money converts between USD, DCR and the atoms balances are kept in.
+//
+// Balances are stored as integer atoms of 1e-11 DCR (milli-atoms of the
+// on-chain unit). Every conversion into atoms goes through convert, which
+// computes the exact rational result from the float inputs and rounds it half
+// to even once, so repeated conversions do not drift and no caller truncates.
+package money
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// AtomsPerDCR is the number of balance atoms in one DCR.
+const AtomsPerDCR = 100_000_000_000
+
+// AtomsPerChainAtom is the number of balance atoms in one on-chain atom
+// (1e-8 DCR), the unit tips and invoices use.
+const AtomsPerChainAtom = 1000
+
+var atomsPerDCR = big.NewRat(AtomsPerDCR, 1)
+
+// USDToAtoms converts a USD amount to atoms at dcrPriceUSD dollars per DCR.
+func USDToAtoms(usd, dcrPriceUSD float64) (int64, error) {
+	if !(dcrPriceUSD > 0) || math.IsInf(dcrPriceUSD, 0) {
+		return 0, fmt.Errorf("invalid DCR price %v", dcrPriceUSD)
+	}
+	r, err := rat(usd)
+	if err != nil {
+		return 0, err
+	}
+	price, _ := rat(dcrPriceUSD)
+	r.Mul(r, atomsPerDCR)
+	r.Quo(r, price)
+	return convert(r)
+}
+
+// DCRToAtoms converts a DCR amount to atoms.
+func DCRToAtoms(dcr float64) (int64, error) {
+	r, err := rat(dcr)
+	if err != nil {
+		return 0, err
+	}
+	return convert(r.Mul(r, atomsPerDCR))
+}
+
+// AtomsToDCR converts atoms to DCR for display.
+func AtomsToDCR(atoms int64) float64 {
+	f, _ := new(big.Rat).SetFrac(big.NewInt(atoms), big.NewInt(AtomsPerDCR)).Float64()
+	return f
+}
+
+// AtomsToUSD converts atoms to USD at dcrPriceUSD dollars per DCR.
+func AtomsToUSD(atoms int64, dcrPriceUSD float64) float64 {
+	price, err := rat(dcrPriceUSD)
+	if err != nil {
+		return 0
+	}
+	r := new(big.Rat).SetFrac(big.NewInt(atoms), big.NewInt(AtomsPerDCR))
+	f, _ := r.Mul(r, price).Float64()
+	return f
+}
+
+// AtomsToChainAtoms converts balance atoms to on-chain atoms.
+func AtomsToChainAtoms(atoms int64) int64 {
+	n, _ := convert(big.NewRat(atoms, AtomsPerChainAtom))
+	return n
+}
+
+// rat returns the exact value of f.
+func rat(f float64) (*big.Rat, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("invalid amount %v", f)
+	}
+	return new(big.Rat).SetFloat64(f), nil
+}
+
+// convert rounds r to the nearest integer, ties to even. It fails when the
+// result does not fit in an int64.
+func convert(r *big.Rat) (int64, error) {
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	// Compare twice the remainder with the denominator to find the nearest
+	// integer; QuoRem truncates toward zero, so step away from zero.
+	m.Abs(m).Lsh(m, 1)
+	if c := m.Cmp(r.Denom()); c > 0 || (c == 0 && q.Bit(0) == 1) {
+		if r.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("amount %s out of range", r.FloatString(11))
+	}
+	return q.Int64(), nil
+}
@@ -0,0 +1,104 @@
+package money
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"testing/quick"
+)
+
+func TestConvertRoundsHalfToEven(t *testing.T) {
+	tests := []struct {
+		num, den int64
+		want     int64
+	}{
+		{5, 2, 2},     // 2.5
+		{7, 2, 4},     // 3.5
+		{-5, 2, -2},   // -2.5
+		{-7, 2, -4},   // -3.5
+		{26, 10, 3},   // 2.6
+		{24, 10, 2},   // 2.4
+		{-26, 10, -3}, // -2.6
+		{9, 1, 9},
+	}
+	for _, tt := range tests {
+		got, err := convert(big.NewRat(tt.num, tt.den))
+		if err != nil || got != tt.want {
+			t.Errorf("convert(%d/%d) = %d, %v; want %d", tt.num, tt.den, got, err, tt.want)
+		}
+	}
+}
+
+func TestUSDToAtoms(t *testing.T) {
+	// $0.03 at $25/DCR is exactly 0.0012 DCR, but float truncation
+	// (int64(0.03/25*1e11)) lands one atom short.
+	got, err := USDToAtoms(0.03, 25)
+	if err != nil || got != 120_000_000 {
+		t.Fatalf("USDToAtoms(0.03, 25) = %d, %v; want 120000000", got, err)
+	}
+	// $0.01 at $15/DCR is 66666666.67 atoms and rounds up.
+	if got, _ := USDToAtoms(0.01, 15); got != 66_666_667 {
+		t.Fatalf("USDToAtoms(0.01, 15) = %d; want 66666667", got)
+	}
+	for _, price := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, err := USDToAtoms(1, price); err == nil {
+			t.Errorf("USDToAtoms(1, %v) succeeded", price)
+		}
+	}
+	if _, err := USDToAtoms(math.NaN(), 20); err == nil {
+		t.Error("USDToAtoms(NaN) succeeded")
+	}
+	if _, err := DCRToAtoms(1e300); err == nil {
+		t.Error("DCRToAtoms(1e300) succeeded")
+	}
+}
+
+// maxExactAtoms bounds the round-trip properties: atom amounts up to 2^52
+// (about 45,000 DCR) are represented exactly enough by a float64.
+const maxExactAtoms = 1 << 52
+
+func clampAtoms(a int64) int64 {
+	return a % maxExactAtoms
+}
+
+func absDiff(a, b int64) int64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func TestDCRRoundTripProperty(t *testing.T) {
+	f := func(a int64) bool {
+		a = clampAtoms(a)
+		back, err := DCRToAtoms(AtomsToDCR(a))
+		return err == nil && absDiff(back, a) <= 1
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 20000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUSDRoundTripProperty(t *testing.T) {
+	f := func(a int64, p uint32) bool {
+		a = clampAtoms(a)
+		price := 0.01 + float64(p%100_000)/100 // $0.01 to $1000 per DCR
+		back, err := USDToAtoms(AtomsToUSD(a, price), price)
+		return err == nil && absDiff(back, a) <= 1
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 20000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestChainAtomsProperty(t *testing.T) {
+	f := func(a int64) bool {
+		a = clampAtoms(a)
+		chain := AtomsToChainAtoms(a)
+		// Never more than half a chain atom away from the exact value.
+		return absDiff(chain*AtomsPerChainAtom, a) <= AtomsPerChainAtom/2
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,85 @@
+// Package outbox sends status messages to each chat one at a time. Jobs
+// running side by side report progress to the same user or group chat; the
+// outbox keeps their messages from interleaving and, when updates come
+// faster than they are delivered, only sends the newest one of each kind.
+package outbox
+
+import (
+	"context"
+	"sync"
+)
+
+// SendFunc sends text under ctx.
+type SendFunc func(ctx context.Context, text string) error
+
+// message is a message waiting to be sent.
+type message struct {
+	ctx  context.Context
+	key  string
+	text string
+	send SendFunc
+}
+
+// Outbox queues messages per destination.
+type Outbox struct {
+	mu     sync.Mutex
+	queues map[string][]*message // Waiting messages by destination; present while being sent
+}
+
+// Default is the outbox of progress updates.
+var Default = New()
+
+// New returns an empty outbox.
+func New() *Outbox {
+	return &Outbox{queues: make(map[string][]*message)}
+}
+
+// Queue queues text for dest, e.g. a user or group chat, to be sent with
+// send after the messages queued for dest before it. A message waiting under
+// the same non-empty key is replaced instead, keeping its place in line, so
+// the latest status wins. Messages whose ctx is done when their turn comes
+// are dropped.
+func (o *Outbox) Queue(ctx context.Context, dest, key, text string, send SendFunc) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	q, sending := o.queues[dest]
+	if key != "" {
+		for _, m := range q {
+			if m.key == key {
+				m.ctx, m.text, m.send = ctx, text, send
+				return
+			}
+		}
+	}
+	o.queues[dest] = append(q, &message{ctx: ctx, key: key, text: text, send: send})
+	if !sending {
+		go o.drain(dest)
+	}
+}
+
+// Idle reports whether no message is waiting or being sent.
+func (o *Outbox) Idle() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.queues) == 0
+}
+
+// drain sends the messages of dest until none are left.
+func (o *Outbox) drain(dest string) {
+	for {
+		o.mu.Lock()
+		q := o.queues[dest]
+		if len(q) == 0 {
+			delete(o.queues, dest)
+			o.mu.Unlock()
+			return
+		}
+		m := q[0]
+		o.queues[dest] = q[1:]
+		o.mu.Unlock()
+
+		if m.ctx.Err() == nil {
+			m.send(m.ctx, m.text)
+		}
+	}
+}
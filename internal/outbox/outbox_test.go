@@ -0,0 +1,108 @@
+package outbox
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder records the messages sent, blocking each send until it is
+// released when gated.
+type recorder struct {
+	mu   sync.Mutex
+	sent []string
+	gate chan struct{}
+}
+
+func (r *recorder) send(ctx context.Context, text string) error {
+	if r.gate != nil {
+		<-r.gate
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, text)
+	return nil
+}
+
+func (r *recorder) messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.sent...)
+}
+
+func waitIdle(t *testing.T, o *Outbox) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !o.Idle() {
+		if time.Now().After(deadline) {
+			t.Fatal("outbox not idle after 5s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestOutboxCoalesces(t *testing.T) {
+	o := New()
+	ctx := context.Background()
+	r := &recorder{gate: make(chan struct{})}
+
+	// While the first message is being sent, newer statuses replace the
+	// waiting one in its place in line
+	o.Queue(ctx, "alice", "", "started", r.send)
+	o.Queue(ctx, "alice", "job1/status", "Status: IN_QUEUE", r.send)
+	o.Queue(ctx, "alice", "job1/log", "Log: loading", r.send)
+	o.Queue(ctx, "alice", "job2/status", "Status: IN_QUEUE", r.send)
+	o.Queue(ctx, "alice", "job1/status", "Status: IN_PROGRESS", r.send)
+	o.Queue(ctx, "alice", "", "Error: one", r.send)
+	o.Queue(ctx, "alice", "", "Error: two", r.send)
+	if o.Idle() {
+		t.Error("Idle while sending")
+	}
+	close(r.gate)
+	waitIdle(t, o)
+
+	want := []string{"started", "Status: IN_PROGRESS", "Log: loading", "Status: IN_QUEUE", "Error: one", "Error: two"}
+	if got := r.messages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %q, want %q", got, want)
+	}
+}
+
+func TestOutboxDropsCancelled(t *testing.T) {
+	o := New()
+	r := &recorder{gate: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	o.Queue(context.Background(), "alice", "", "first", r.send)
+	o.Queue(ctx, "alice", "job/status", "Status: IN_PROGRESS", r.send)
+	cancel()
+	close(r.gate)
+	waitIdle(t, o)
+	if got := r.messages(); !reflect.DeepEqual(got, []string{"first"}) {
+		t.Errorf("sent %q, want only the first message", got)
+	}
+}
+
+func TestOutboxDestinations(t *testing.T) {
+	o := New()
+	ctx := context.Background()
+	blocked := &recorder{gate: make(chan struct{})}
+	other := &recorder{}
+
+	// A slow chat does not hold up the others
+	o.Queue(ctx, "gc:slow", "", "slow", blocked.send)
+	o.Queue(ctx, "gc:fast", "", "fast", other.send)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(other.messages()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("fast chat waited for the slow one")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(blocked.gate)
+	waitIdle(t, o)
+	if got := blocked.messages(); !reflect.DeepEqual(got, []string{"slow"}) {
+		t.Errorf("slow chat got %q", got)
+	}
+}
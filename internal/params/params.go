@@ -0,0 +1,457 @@
+// Package params parses the --flag options of the generation commands.
+//
+// Commands declare the flags they take as a list of Flag values, and model
+// specific flags can be derived from a model's options struct with
+// FromOptions. Parse then accepts every flag as --flag value or
+// --flag=value, spelled with underscores or dashes in any case; Extract does
+// the same for flags shared by commands that parse the rest of their
+// arguments their own way. Split turns
+// a command line into arguments, keeping quoted text together so prompts
+// and flag values can contain spaces.
+package params
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Kind is the type of value a flag takes.
+type Kind int
+
+const (
+	// String takes any text and keeps its case.
+	String Kind = iota
+	// Lower takes text and lowercases it, for enum-like values.
+	Lower
+	// Int takes an integer.
+	Int
+	// Float takes a number.
+	Float
+	// Bool takes true or false. The value is optional: a bare --flag is
+	// true.
+	Bool
+	// Percent takes an integer percentage, with or without a trailing %.
+	Percent
+)
+
+// Flag declares a flag a command accepts.
+type Flag struct {
+	Name    string   // Canonical name in snake case, e.g. num_images
+	Aliases []string // Other accepted names, e.g. aspect for aspect_ratio
+	Kind    Kind
+
+	min, max *float64
+	oneOf    []string
+}
+
+// NewFlag returns a flag of the given kind.
+func NewFlag(kind Kind, name string, aliases ...string) Flag {
+	return Flag{Name: name, Aliases: aliases, Kind: kind}
+}
+
+// AtLeast returns a copy of a numeric flag that rejects values below min.
+func (f Flag) AtLeast(min float64) Flag {
+	f.min = &min
+	return f
+}
+
+// Between returns a copy of a numeric flag that rejects values outside
+// min and max.
+func (f Flag) Between(min, max float64) Flag {
+	f.min, f.max = &min, &max
+	return f
+}
+
+// OneOf returns a copy of a text flag that only accepts the given values.
+func (f Flag) OneOf(values ...string) Flag {
+	f.oneOf = values
+	return f
+}
+
+// convert parses a flag's raw value.
+func (f Flag) convert(raw string) (interface{}, error) {
+	switch f.Kind {
+	case Lower:
+		raw = strings.ToLower(raw)
+		fallthrough
+	case String:
+		if len(f.oneOf) > 0 && !slices.Contains(f.oneOf, raw) {
+			return nil, fmt.Errorf("invalid value for --%s: %s (must be one of: %s)", f.Name, raw, strings.Join(f.oneOf, ", "))
+		}
+		return raw, nil
+	case Int, Percent:
+		if f.Kind == Percent {
+			raw = strings.TrimSuffix(raw, "%")
+		}
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for --%s: %s (must be an integer)", f.Name, raw)
+		}
+		if err := f.checkRange(float64(n), raw); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case Float:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for --%s: %s (must be a number)", f.Name, raw)
+		}
+		if err := f.checkRange(v, raw); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case Bool:
+		b, ok := parseBool(raw)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for --%s: %s (must be true or false)", f.Name, raw)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unsupported flag kind %d", f.Kind)
+}
+
+// checkRange applies the AtLeast and Between limits.
+func (f Flag) checkRange(v float64, raw string) error {
+	switch {
+	case f.min != nil && f.max != nil && (v < *f.min || v > *f.max):
+		return fmt.Errorf("invalid value for --%s: %s (must be between %g and %g)", f.Name, raw, *f.min, *f.max)
+	case f.min != nil && v < *f.min:
+		return fmt.Errorf("invalid value for --%s: %s (must be at least %g)", f.Name, raw, *f.min)
+	}
+	return nil
+}
+
+// Spec is the set of flags a command accepts.
+type Spec struct {
+	flags  []Flag
+	byName map[string]int
+}
+
+// NewSpec returns a Spec accepting flags.
+func NewSpec(flags ...Flag) *Spec {
+	s := &Spec{byName: make(map[string]int)}
+	return s.Merge(flags...)
+}
+
+// Merge adds flags to the spec, skipping those whose name or an alias is
+// already taken, so a command's own definitions win over derived ones.
+func (s *Spec) Merge(flags ...Flag) *Spec {
+	for _, f := range flags {
+		names := append([]string{f.Name}, f.Aliases...)
+		taken := false
+		for _, n := range names {
+			if _, ok := s.byName[normalize(n)]; ok {
+				taken = true
+				break
+			}
+		}
+		if taken {
+			continue
+		}
+		s.flags = append(s.flags, f)
+		for _, n := range names {
+			s.byName[normalize(n)] = len(s.flags) - 1
+		}
+	}
+	return s
+}
+
+// Lookup returns the flag a name refers to.
+func (s *Spec) Lookup(name string) (Flag, bool) {
+	i, ok := s.byName[normalize(name)]
+	if !ok {
+		return Flag{}, false
+	}
+	return s.flags[i], true
+}
+
+// Names returns the canonical flag names, sorted.
+func (s *Spec) Names() []string {
+	names := make([]string, len(s.flags))
+	for i, f := range s.flags {
+		names[i] = f.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FromOptions derives flags from the JSON fields of a model's options
+// struct, e.g. Speed *float64 `json:"speed"` becomes a Float flag --speed.
+// It returns nil for models without options.
+func FromOptions(opts interface{}) []Flag {
+	t := reflect.TypeOf(opts)
+	if t == nil {
+		return nil
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var flags []Flag
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch ft.Kind() {
+		case reflect.String:
+			flags = append(flags, NewFlag(String, name))
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			flags = append(flags, NewFlag(Int, name))
+		case reflect.Float32, reflect.Float64:
+			flags = append(flags, NewFlag(Float, name))
+		case reflect.Bool:
+			flags = append(flags, NewFlag(Bool, name))
+		}
+	}
+	return flags
+}
+
+// Result holds parsed arguments.
+type Result struct {
+	Args   []string // Words that are not flags or flag values, in order
+	values map[string]interface{}
+}
+
+// Prompt returns the words that are not flags, joined with spaces.
+func (r *Result) Prompt() string {
+	return strings.Join(r.Args, " ")
+}
+
+// Has reports whether the flag was given.
+func (r *Result) Has(name string) bool {
+	_, ok := r.values[name]
+	return ok
+}
+
+// String returns a String or Lower flag's value, or "" when it was not
+// given.
+func (r *Result) String(name string) string {
+	s, _ := r.values[name].(string)
+	return s
+}
+
+// Int returns an Int or Percent flag's value, or nil when it was not given.
+func (r *Result) Int(name string) *int {
+	n, ok := r.values[name].(int64)
+	if !ok {
+		return nil
+	}
+	v := int(n)
+	return &v
+}
+
+// Int64 returns an Int flag's value, or nil when it was not given.
+func (r *Result) Int64(name string) *int64 {
+	n, ok := r.values[name].(int64)
+	if !ok {
+		return nil
+	}
+	return &n
+}
+
+// Float returns a Float flag's value, or nil when it was not given.
+func (r *Result) Float(name string) *float64 {
+	f, ok := r.values[name].(float64)
+	if !ok {
+		return nil
+	}
+	return &f
+}
+
+// Bool returns a Bool flag's value, or nil when it was not given.
+func (r *Result) Bool(name string) *bool {
+	b, ok := r.values[name].(bool)
+	if !ok {
+		return nil
+	}
+	return &b
+}
+
+// Options returns the given flags keyed by name, with numbers and booleans
+// as pointers, the shape model option maps use.
+func (r *Result) Options() map[string]interface{} {
+	opts := make(map[string]interface{}, len(r.values))
+	for name, v := range r.values {
+		switch v := v.(type) {
+		case int64:
+			n := int(v)
+			opts[name] = &n
+		case float64:
+			opts[name] = &v
+		case bool:
+			opts[name] = &v
+		default:
+			opts[name] = v
+		}
+	}
+	return opts
+}
+
+// Parse splits args into flags declared by spec and the remaining words.
+// Flags not in spec are an error, so typos are reported instead of ending
+// up in the prompt.
+func Parse(args []string, spec *Spec) (*Result, error) {
+	return parse(args, spec, false)
+}
+
+// Extract pulls the flags declared by spec out of args and leaves every
+// other argument, including other flags and their values, in Args for the
+// command's own parsing. It is used for flags shared by commands, such as
+// --split, so they take the same forms everywhere.
+func Extract(args []string, spec *Spec) (*Result, error) {
+	return parse(args, spec, true)
+}
+
+// parse implements Parse and, keeping flags not in spec, Extract.
+func parse(args []string, spec *Spec, keepUnknown bool) (*Result, error) {
+	r := &Result{values: make(map[string]interface{})}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !isFlag(name) {
+			r.Args = append(r.Args, arg)
+			continue
+		}
+		flag, ok := spec.Lookup(name)
+		if !ok && keepUnknown {
+			r.Args = append(r.Args, arg)
+			continue
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown option %s (options: --%s)", strings.ToLower(name), strings.Join(spec.Names(), ", --"))
+		}
+
+		if !hasValue {
+			switch {
+			case flag.Kind == Bool:
+				// The value of a bool flag is optional
+				value = "true"
+				if i+1 < len(args) {
+					if _, ok := parseBool(args[i+1]); ok {
+						value = args[i+1]
+						i++
+					}
+				}
+			case i+1 < len(args) && !isFlag(args[i+1]):
+				value = args[i+1]
+				i++
+			default:
+				return nil, fmt.Errorf("missing value for --%s", flag.Name)
+			}
+		}
+
+		v, err := flag.convert(value)
+		if err != nil {
+			return nil, err
+		}
+		r.values[flag.Name] = v
+	}
+	return r, nil
+}
+
+// quotePairs maps each opening quote to its closing quote. Curly quotes
+// are included because phone keyboards insert them automatically.
+var quotePairs = map[rune]rune{
+	'"':  '"',
+	'\'': '\'',
+	'“':  '”',
+	'‘':  '’',
+}
+
+// Split breaks a command line into arguments at whitespace, shell style.
+// Text in double or single quotes stays one argument without its quotes, so
+// "a red fox" and --negative_prompt="blurry hands, extra fingers" each
+// become a single argument. A quote only opens at the start of an argument
+// or after the = of a flag, so apostrophes in words like don't are kept,
+// and a quote that is never closed is kept as written. A backslash before a
+// quote keeps the quote literally.
+func Split(line string) []string {
+	runes := []rune(line)
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+			continue
+		case c == '\\' && i+1 < len(runes) && isQuote(runes[i+1]):
+			i++
+			cur.WriteRune(runes[i])
+		case isQuote(c) && (!inArg || strings.HasSuffix(cur.String(), "=")):
+			end := closingQuote(runes, i)
+			if end < 0 {
+				cur.WriteRune(c)
+				break
+			}
+			for _, r := range runes[i+1 : end] {
+				cur.WriteRune(r)
+			}
+			i = end
+		default:
+			cur.WriteRune(c)
+		}
+		inArg = true
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args
+}
+
+// closingQuote returns the index of the quote closing the one at open, or
+// -1 when there is none. The closing quote must end an argument.
+func closingQuote(runes []rune, open int) int {
+	want := quotePairs[runes[open]]
+	for i := open + 1; i < len(runes); i++ {
+		if runes[i] == want && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isQuote reports whether c opens a quoted run.
+func isQuote(c rune) bool {
+	_, ok := quotePairs[c]
+	return ok
+}
+
+// isFlag reports whether arg looks like --name.
+func isFlag(arg string) bool {
+	return len(arg) > 2 && strings.HasPrefix(arg, "--") && arg[2] != '-'
+}
+
+// normalize maps --Flag-Name, flag_name and similar spellings to flag_name.
+func normalize(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(name, "--")), "-", "_")
+}
+
+// parseBool accepts true and false in any case.
+func parseBool(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	}
+	return false, false
+}
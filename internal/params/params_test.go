@@ -0,0 +1,176 @@
+package params
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+func testSpec() *Spec {
+	return NewSpec(
+		NewFlag(Int, "num_images").AtLeast(1),
+		NewFlag(Lower, "output_format"),
+		NewFlag(String, "aspect_ratio", "aspect"),
+		NewFlag(Float, "strength").Between(0, 1),
+		NewFlag(Bool, "raw"),
+		NewFlag(Lower, "control").OneOf("canny", "depth"),
+		NewFlag(String, "negative_prompt"),
+	)
+}
+
+func TestParse(t *testing.T) {
+	r, err := Parse(strings.Fields(`a red fox --Num-Images=2 --aspect 16:9 --raw in snow --OUTPUT_FORMAT PNG --strength 0.5`), testSpec())
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := r.Prompt(); got != "a red fox in snow" {
+		t.Errorf("Prompt = %q", got)
+	}
+	if n := r.Int("num_images"); n == nil || *n != 2 {
+		t.Errorf("num_images = %v", n)
+	}
+	if got := r.String("aspect_ratio"); got != "16:9" {
+		t.Errorf("aspect_ratio = %q", got)
+	}
+	if got := r.String("output_format"); got != "png" {
+		t.Errorf("output_format = %q", got)
+	}
+	if b := r.Bool("raw"); b == nil || !*b {
+		t.Errorf("raw = %v", b)
+	}
+	if f := r.Float("strength"); f == nil || *f != 0.5 {
+		t.Errorf("strength = %v", f)
+	}
+	if r.Has("control") || r.Int64("num_images") == nil {
+		t.Errorf("Has/Int64 mismatch: %v", r.values)
+	}
+
+	opts := r.Options()
+	if n, ok := opts["num_images"].(*int); !ok || *n != 2 {
+		t.Errorf("Options num_images = %#v", opts["num_images"])
+	}
+	if s, ok := opts["aspect_ratio"].(string); !ok || s != "16:9" {
+		t.Errorf("Options aspect_ratio = %#v", opts["aspect_ratio"])
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	r, err := Parse([]string{"--raw", "false", "cat"}, testSpec())
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if b := r.Bool("raw"); b == nil || *b {
+		t.Errorf("raw = %v, want false", b)
+	}
+	if got := r.Prompt(); got != "cat" {
+		t.Errorf("Prompt = %q", got)
+	}
+
+	// A bare bool flag does not swallow the next word
+	r, err = Parse([]string{"--raw", "cat"}, testSpec())
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if b := r.Bool("raw"); b == nil || !*b || r.Prompt() != "cat" {
+		t.Errorf("raw = %v, prompt = %q", b, r.Prompt())
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`a red  fox`, []string{"a", "red", "fox"}},
+		{`"a red fox" --seed 1`, []string{"a red fox", "--seed", "1"}},
+		{`cat --negative_prompt "blurry hands, extra fingers"`, []string{"cat", "--negative_prompt", "blurry hands, extra fingers"}},
+		{`cat --negative_prompt="blurry hands, extra fingers" --seed=2`, []string{"cat", "--negative_prompt=blurry hands, extra fingers", "--seed=2"}},
+		{`hi --emotion 'very happy'`, []string{"hi", "--emotion", "very happy"}},
+		{`cat --aspect “4:3” ‘x y’`, []string{"cat", "--aspect", "4:3", "x y"}},
+		{`don't stop`, []string{"don't", "stop"}},
+		{`a sign saying \"hello\"`, []string{"a", "sign", "saying", `"hello"`}},
+		{`an "unclosed quote`, []string{"an", `"unclosed`, "quote"}},
+		{`cat --negative_prompt ""`, []string{"cat", "--negative_prompt", ""}},
+		{`  `, nil},
+	}
+	for _, tt := range tests {
+		if got := Split(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Split(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseQuoted(t *testing.T) {
+	r, err := Parse(Split(`"a red fox" --negative_prompt="blurry, dark" --aspect "4:3"`), testSpec())
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !reflect.DeepEqual(r.Args, []string{"a red fox"}) {
+		t.Errorf("Args = %q", r.Args)
+	}
+	if got := r.String("negative_prompt"); got != "blurry, dark" {
+		t.Errorf("negative_prompt = %q", got)
+	}
+	if got := r.String("aspect_ratio"); got != "4:3" {
+		t.Errorf("aspect_ratio = %q", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		args string
+		want string
+	}{
+		{"cat --num_imgs 2", "unknown option --num_imgs"},
+		{"cat --num_images", "missing value for --num_images"},
+		{"cat --num_images --raw", "missing value for --num_images"},
+		{"cat --num_images two", "must be an integer"},
+		{"cat --num_images 0", "must be at least 1"},
+		{"cat --strength 1.5", "must be between 0 and 1"},
+		{"cat --strength x", "must be a number"},
+		{"cat --raw=maybe", "must be true or false"},
+		{"cat --control sketch", "must be one of: canny, depth"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(strings.Fields(tt.args), testSpec())
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	spec := NewSpec(NewFlag(Percent, "split").Between(1, 100))
+	for _, args := range []string{"a fox --split 50 --seed 3", "a fox --split=50% --seed 3", "a fox --Split 50% --seed 3"} {
+		r, err := Extract(strings.Fields(args), spec)
+		if err != nil {
+			t.Fatalf("Extract(%q): %v", args, err)
+		}
+		if n := r.Int("split"); n == nil || *n != 50 || strings.Join(r.Args, " ") != "a fox --seed 3" {
+			t.Errorf("Extract(%q) = %v, %v", args, n, r.Args)
+		}
+	}
+	for _, args := range []string{"a fox --split", "a fox --split 0", "a fox --split=half"} {
+		if _, err := Extract(strings.Fields(args), spec); err == nil {
+			t.Errorf("Extract(%q) succeeded", args)
+		}
+	}
+}
+
+func TestFromOptions(t *testing.T) {
+	spec := NewSpec(NewFlag(String, "voice_id", "voice")).Merge(FromOptions(&fal.MinimaxTTSOptions{})...)
+	want := []string{"bitrate", "channel", "emotion", "format", "pitch", "sample_rate", "speed", "voice_id", "vol"}
+	if got := spec.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names = %q, want %q", got, want)
+	}
+	for name, kind := range map[string]Kind{"speed": Float, "pitch": Int, "emotion": String} {
+		if f, ok := spec.Lookup(name); !ok || f.Kind != kind {
+			t.Errorf("Lookup(%s) = %+v, %v", name, f, ok)
+		}
+	}
+	if FromOptions(nil) != nil {
+		t.Error("FromOptions(nil) returned flags")
+	}
+}
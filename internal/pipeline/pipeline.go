@@ -0,0 +1,186 @@
+// Package pipeline runs composite jobs, such as the restore → upscale chain
+// of !restore, one step at a time under an aggregate cost ceiling. A step
+// whose estimate would push the total over the ceiling is not started; the
+// steps delivered so far are kept and the estimate of the rest is reported
+// as unspent.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Step is one generation of a composite job.
+type Step struct {
+	Name        string
+	EstimateUSD float64
+	// Run executes the step and returns what it delivered and charged.
+	Run func(ctx context.Context) (StepResult, error)
+}
+
+// StepResult is the outcome of a completed step.
+type StepResult struct {
+	Name    string
+	URL     string
+	CostUSD float64
+}
+
+// Result is the outcome of a composite job.
+type Result struct {
+	Steps      []StepResult
+	SpentUSD   float64
+	CeilingUSD float64 // 0 means no ceiling
+	// UnspentUSD is the estimate of the steps that were not run.
+	UnspentUSD float64
+	Skipped    []string
+	// CeilingHit is set when a step was not started because of the ceiling.
+	CeilingHit bool
+	// Failed is the step that failed, if any.
+	Failed string
+	// Err is the error that stopped the job.
+	Err error
+}
+
+// Complete reports whether every step ran.
+func (r Result) Complete() bool {
+	return len(r.Skipped) == 0 && r.Err == nil
+}
+
+var (
+	mu                sync.RWMutex
+	defaultCeilingUSD float64
+)
+
+// SetDefaultCeiling sets the ceiling applied to composite jobs. 0 disables
+// it.
+func SetDefaultCeiling(usd float64) {
+	if usd < 0 || math.IsNaN(usd) {
+		usd = 0
+	}
+	mu.Lock()
+	defaultCeilingUSD = usd
+	mu.Unlock()
+}
+
+// DefaultCeiling returns the configured ceiling.
+func DefaultCeiling() float64 {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultCeilingUSD
+}
+
+// Ceiling returns the ceiling for a job whose user asked for userUSD. Users
+// can only lower the configured ceiling; 0 keeps it.
+func Ceiling(userUSD float64) float64 {
+	def := DefaultCeiling()
+	switch {
+	case userUSD <= 0:
+		return def
+	case def <= 0:
+		return userUSD
+	default:
+		return math.Min(def, userUSD)
+	}
+}
+
+// Run executes steps in order until all are done, a step fails or the next
+// step's estimate would exceed ceilingUSD. A ceiling of 0 means no ceiling.
+func Run(ctx context.Context, ceilingUSD float64, steps []Step) Result {
+	res := Result{CeilingUSD: ceilingUSD}
+	for i, step := range steps {
+		if res.Err == nil && ctx.Err() != nil {
+			res.Err = context.Cause(ctx)
+		}
+		stop := res.Err != nil
+		// A small tolerance keeps float sums of exact prices from tripping
+		// the ceiling.
+		if !stop && ceilingUSD > 0 && res.SpentUSD+step.EstimateUSD > ceilingUSD+1e-9 {
+			res.CeilingHit = true
+			stop = true
+		}
+		if stop {
+			for _, rest := range steps[i:] {
+				res.Skipped = append(res.Skipped, rest.Name)
+				res.UnspentUSD += rest.EstimateUSD
+			}
+			return res
+		}
+
+		out, err := step.Run(ctx)
+		if err != nil {
+			res.Failed = step.Name
+			res.Err = fmt.Errorf("%s: %w", step.Name, err)
+			// A failed step may still have been charged.
+			res.SpentUSD += out.CostUSD
+			continue
+		}
+		if out.Name == "" {
+			out.Name = step.Name
+		}
+		res.Steps = append(res.Steps, out)
+		res.SpentUSD += out.CostUSD
+	}
+	return res
+}
+
+// Summary formats the result for the user.
+func (r Result) Summary() string {
+	total := len(r.Steps) + len(r.Skipped)
+	if r.Failed != "" {
+		total++
+	}
+	var b strings.Builder
+	switch {
+	case r.Complete():
+		fmt.Fprintf(&b, "✅ All %d steps completed for $%.2f.", len(r.Steps), r.SpentUSD)
+	case r.CeilingHit:
+		fmt.Fprintf(&b, "⚠️ Stopped after %d of %d steps: the next step would exceed the cost ceiling of $%.2f.",
+			len(r.Steps), total, r.CeilingUSD)
+	default:
+		fmt.Fprintf(&b, "❌ Stopped after %d of %d steps: %v", len(r.Steps), total, r.Err)
+	}
+	for _, s := range r.Steps {
+		fmt.Fprintf(&b, "\n• %s ($%.2f)", s.Name, s.CostUSD)
+		if s.URL != "" {
+			fmt.Fprintf(&b, ": %s", s.URL)
+		}
+	}
+	if !r.Complete() {
+		fmt.Fprintf(&b, "\nSpent: $%.2f", r.SpentUSD)
+		if len(r.Skipped) > 0 {
+			fmt.Fprintf(&b, "\nNot run: %s (est. $%.2f unspent)", strings.Join(r.Skipped, ", "), r.UnspentUSD)
+		}
+	}
+	return b.String()
+}
+
+// ExtractMaxCost pulls --max-cost [usd] or --max-cost=[usd] out of a
+// composite command's arguments. It returns 0 when the flag is absent.
+func ExtractMaxCost(args []string) ([]string, float64, error) {
+	var rest []string
+	usd := 0.0
+	for i := 0; i < len(args); i++ {
+		name, value, inline := strings.Cut(args[i], "=")
+		if strings.ToLower(name) != "--max-cost" {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !inline {
+			if i+1 >= len(args) {
+				return nil, 0, fmt.Errorf("missing value for --max-cost")
+			}
+			i++
+			value = args[i]
+		}
+		val, err := strconv.ParseFloat(strings.TrimPrefix(value, "$"), 64)
+		if err != nil || !(val > 0) || math.IsInf(val, 0) {
+			return nil, 0, fmt.Errorf("invalid value for --max-cost: %s (must be a USD amount above 0)", value)
+		}
+		usd = val
+	}
+	return rest, usd, nil
+}
@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func step(name string, usd float64, err error) Step {
+	return Step{
+		Name:        name,
+		EstimateUSD: usd,
+		Run: func(ctx context.Context) (StepResult, error) {
+			if err != nil {
+				return StepResult{}, err
+			}
+			return StepResult{URL: "https://example.com/" + name, CostUSD: usd}, nil
+		},
+	}
+}
+
+func TestRunCeiling(t *testing.T) {
+	steps := []Step{step("scene 1", 0.4, nil), step("scene 2", 0.4, nil), step("scene 3", 0.4, nil)}
+
+	res := Run(context.Background(), 1.0, steps)
+	if len(res.Steps) != 2 || !res.CeilingHit || res.Complete() {
+		t.Fatalf("Run = %+v; want 2 steps and the ceiling hit", res)
+	}
+	if res.SpentUSD != 0.8 || res.UnspentUSD != 0.4 || len(res.Skipped) != 1 || res.Skipped[0] != "scene 3" {
+		t.Errorf("spent %v, unspent %v, skipped %v", res.SpentUSD, res.UnspentUSD, res.Skipped)
+	}
+	if s := res.Summary(); !strings.Contains(s, "Stopped after 2 of 3 steps") || !strings.Contains(s, "est. $0.40 unspent") {
+		t.Errorf("Summary = %q", s)
+	}
+
+	// Exactly reaching the ceiling is allowed.
+	if res := Run(context.Background(), 1.2, steps); !res.Complete() {
+		t.Errorf("Run at the ceiling = %+v; want complete", res)
+	}
+	if res := Run(context.Background(), 0, steps); !res.Complete() || len(res.Steps) != 3 {
+		t.Errorf("Run without ceiling = %+v; want complete", res)
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	boom := errors.New("boom")
+	res := Run(context.Background(), 0, []Step{step("a", 0.1, nil), step("b", 0.2, boom), step("c", 0.3, nil)})
+	if !errors.Is(res.Err, boom) || res.Failed != "b" || len(res.Steps) != 1 {
+		t.Fatalf("Run = %+v; want b to fail after a", res)
+	}
+	if res.UnspentUSD != 0.3 || len(res.Skipped) != 1 {
+		t.Errorf("unspent %v, skipped %v", res.UnspentUSD, res.Skipped)
+	}
+	if s := res.Summary(); !strings.Contains(s, "Stopped after 1 of 3 steps") {
+		t.Errorf("Summary = %q", s)
+	}
+}
+
+func TestCeiling(t *testing.T) {
+	defer SetDefaultCeiling(0)
+
+	SetDefaultCeiling(5)
+	for _, tc := range []struct{ user, want float64 }{{0, 5}, {2, 2}, {10, 5}} {
+		if got := Ceiling(tc.user); got != tc.want {
+			t.Errorf("Ceiling(%v) = %v; want %v", tc.user, got, tc.want)
+		}
+	}
+	SetDefaultCeiling(0)
+	if got := Ceiling(3); got != 3 {
+		t.Errorf("Ceiling(3) without default = %v; want 3", got)
+	}
+}
+
+func TestExtractMaxCost(t *testing.T) {
+	rest, usd, err := ExtractMaxCost([]string{"a", "cat", "--max-cost", "$1.50", "--seed", "3"})
+	if err != nil || usd != 1.5 || strings.Join(rest, " ") != "a cat --seed 3" {
+		t.Fatalf("ExtractMaxCost = %v, %v, %v", rest, usd, err)
+	}
+	if rest, usd, err := ExtractMaxCost([]string{"--max-cost=2", "--scale", "4"}); err != nil || usd != 2 || strings.Join(rest, " ") != "--scale 4" {
+		t.Fatalf("ExtractMaxCost(--max-cost=2) = %v, %v, %v", rest, usd, err)
+	}
+	for _, args := range [][]string{{"--max-cost"}, {"--max-cost", "0"}, {"--max-cost", "abc"}, {"--max-cost="}} {
+		if _, _, err := ExtractMaxCost(args); err == nil {
+			t.Errorf("ExtractMaxCost(%v) succeeded", args)
+		}
+	}
+}
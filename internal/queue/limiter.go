@@ -0,0 +1,220 @@
+// Package queue limits how many generation jobs of each kind run at once.
+// Jobs beyond the limit wait in FIFO order and are told their position.
+package queue
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Job kinds. Video jobs hold provider quota for many minutes, so they get
+// their own, much smaller, limit than image and speech jobs.
+const (
+	KindVideo  = "video"
+	KindImage  = "image"
+	KindSpeech = "speech"
+)
+
+// KindFor maps a model type (text2video, image2image, ...) to its job kind.
+func KindFor(modelType string) string {
+	switch modelType {
+	case "text2video", "image2video", "video2video", "multi2video":
+		return KindVideo
+	case "text2speech", "audio2audio", "audio2text":
+		return KindSpeech
+	default:
+		return KindImage
+	}
+}
+
+// KindForCommand maps a generation command to the kind of jobs it runs.
+func KindForCommand(command string) string {
+	switch command {
+	case "text2video", "image2video", "video2video", "multi2video":
+		return KindVideo
+	case "text2speech", "speech2text", "transcribe", "cleanaudio":
+		return KindSpeech
+	default:
+		return KindImage
+	}
+}
+
+// waiter is a queued job.
+type waiter struct {
+	ready    chan struct{}
+	onQueued func(position int)
+}
+
+// Limiter enforces per-kind concurrency limits. A limit of 0 means unlimited.
+type Limiter struct {
+	mu      sync.Mutex
+	limits  map[string]int
+	running map[string]int
+	waiting map[string][]*waiter
+}
+
+// NewLimiter creates a limiter with the given per-kind limits.
+func NewLimiter(limits map[string]int) *Limiter {
+	l := &Limiter{
+		limits:  make(map[string]int),
+		running: make(map[string]int),
+		waiting: make(map[string][]*waiter),
+	}
+	for kind, n := range limits {
+		l.limits[kind] = n
+	}
+	return l
+}
+
+// Default is the limiter shared by every generation service.
+var Default = NewLimiter(map[string]int{
+	KindVideo:  1,
+	KindImage:  4,
+	KindSpeech: 4,
+})
+
+// SetLimit changes the limit for a kind at runtime. Raising it starts queued
+// jobs immediately; lowering it lets running jobs finish.
+func (l *Limiter) SetLimit(kind string, n int) {
+	if n < 0 {
+		n = 0
+	}
+	l.mu.Lock()
+	l.limits[kind] = n
+	notify := l.dispatch(kind)
+	l.mu.Unlock()
+	notify()
+}
+
+// Limits returns the configured limit and current running and queued job
+// counts per kind.
+func (l *Limiter) Limits() []Status {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	kinds := make(map[string]bool)
+	for k := range l.limits {
+		kinds[k] = true
+	}
+	for k := range l.running {
+		kinds[k] = true
+	}
+	out := make([]Status, 0, len(kinds))
+	for k := range kinds {
+		out = append(out, Status{Kind: k, Limit: l.limits[k], Running: l.running[k], Queued: len(l.waiting[k])})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Kind < out[j].Kind })
+	return out
+}
+
+// Status reports the state of one job kind.
+type Status struct {
+	Kind    string
+	Limit   int // 0 means unlimited
+	Running int
+	Queued  int
+}
+
+// Acquire waits for a free slot for a job of the given kind and returns a
+// function that releases it. While the job waits, onQueued (if set) is
+// called with its 1-based position each time the position changes.
+func (l *Limiter) Acquire(ctx context.Context, kind string, onQueued func(position int)) (func(), error) {
+	l.mu.Lock()
+	if l.hasSlot(kind) && len(l.waiting[kind]) == 0 {
+		l.running[kind]++
+		l.mu.Unlock()
+		return l.releaser(kind), nil
+	}
+	w := &waiter{ready: make(chan struct{}), onQueued: onQueued}
+	l.waiting[kind] = append(l.waiting[kind], w)
+	position := len(l.waiting[kind])
+	l.mu.Unlock()
+
+	if onQueued != nil {
+		onQueued(position)
+	}
+
+	select {
+	case <-w.ready:
+		return l.releaser(kind), nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		queued := l.remove(kind, w)
+		var notify func()
+		if queued {
+			notify = l.positions(kind)
+		}
+		l.mu.Unlock()
+		if !queued {
+			// The slot was granted while we gave up; hand it back.
+			l.releaser(kind)()
+		} else {
+			notify()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// hasSlot reports whether another job of the kind may start. Callers hold mu.
+func (l *Limiter) hasSlot(kind string) bool {
+	limit := l.limits[kind]
+	return limit == 0 || l.running[kind] < limit
+}
+
+// releaser returns an idempotent function that frees a slot of the kind.
+func (l *Limiter) releaser(kind string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.running[kind]--
+			notify := l.dispatch(kind)
+			l.mu.Unlock()
+			notify()
+		})
+	}
+}
+
+// dispatch starts queued jobs while slots are free and returns a function
+// that reports the new positions of the jobs still waiting, if they moved. Callers hold mu
+// and must call the returned function after unlocking.
+func (l *Limiter) dispatch(kind string) func() {
+	started := 0
+	for l.hasSlot(kind) && len(l.waiting[kind]) > 0 {
+		w := l.waiting[kind][0]
+		l.waiting[kind] = l.waiting[kind][1:]
+		l.running[kind]++
+		close(w.ready)
+		started++
+	}
+	if started == 0 {
+		return func() {}
+	}
+	return l.positions(kind)
+}
+
+// positions snapshots the queue of a kind and returns a function reporting
+// each waiter's position. Callers hold mu.
+func (l *Limiter) positions(kind string) func() {
+	waiting := append([]*waiter(nil), l.waiting[kind]...)
+	return func() {
+		for i, w := range waiting {
+			if w.onQueued != nil {
+				w.onQueued(i + 1)
+			}
+		}
+	}
+}
+
+// remove drops a waiter from the queue, reporting whether it was still queued.
+// Callers hold mu.
+func (l *Limiter) remove(kind string, w *waiter) bool {
+	for i, q := range l.waiting[kind] {
+		if q == w {
+			l.waiting[kind] = append(l.waiting[kind][:i], l.waiting[kind][i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterQueuesBeyondLimit(t *testing.T) {
+	l := NewLimiter(map[string]int{KindVideo: 1})
+	ctx := context.Background()
+
+	release1, err := l.Acquire(ctx, KindVideo, nil)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	positions := make(chan int, 4)
+	acquired := make(chan func())
+	go func() {
+		release, err := l.Acquire(ctx, KindVideo, func(p int) { positions <- p })
+		if err != nil {
+			t.Errorf("queued Acquire: %v", err)
+		}
+		acquired <- release
+	}()
+
+	if p := <-positions; p != 1 {
+		t.Fatalf("queued position = %d, want 1", p)
+	}
+	select {
+	case <-acquired:
+		t.Fatal("second video job started while the first was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Other kinds are not affected by the video limit.
+	releaseImg, err := l.Acquire(ctx, KindImage, nil)
+	if err != nil {
+		t.Fatalf("image Acquire: %v", err)
+	}
+	releaseImg()
+
+	release1()
+	release1() // releasing twice must not free a second slot
+	release2 := <-acquired
+	if st := l.Limits(); st[len(st)-1].Kind != KindVideo || st[len(st)-1].Running != 1 {
+		t.Fatalf("unexpected status %+v", st)
+	}
+	release2()
+}
+
+func TestLimiterCancelWhileQueued(t *testing.T) {
+	l := NewLimiter(map[string]int{KindVideo: 1})
+	release, _ := l.Acquire(context.Background(), KindVideo, nil)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := l.Acquire(ctx, KindVideo, nil)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-done; err == nil {
+		t.Fatal("expected cancellation error")
+	}
+	for _, st := range l.Limits() {
+		if st.Kind == KindVideo && st.Queued != 0 {
+			t.Fatalf("cancelled job still queued: %+v", st)
+		}
+	}
+}
+
+func TestLimiterSetLimitStartsQueued(t *testing.T) {
+	l := NewLimiter(map[string]int{KindImage: 1})
+	release, _ := l.Acquire(context.Background(), KindImage, nil)
+	defer release()
+
+	acquired := make(chan struct{})
+	go func() {
+		r, _ := l.Acquire(context.Background(), KindImage, nil)
+		defer r()
+		close(acquired)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	l.SetLimit(KindImage, 2)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("raising the limit did not start the queued job")
+	}
+}
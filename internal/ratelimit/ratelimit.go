@@ -0,0 +1,187 @@
+// Package ratelimit throttles how often generation jobs are started, with a
+// token bucket per user and one shared by all users, so no single user can
+// flood the bot with requests.
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate allows Count jobs per Per on average, and bursts of up to Count jobs.
+// The zero Rate is unlimited.
+type Rate struct {
+	Count int
+	Per   time.Duration
+}
+
+// ParseRate parses a rate written as count/duration, e.g. "5/1m". An empty
+// string or a count of 0 is unlimited.
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Rate{}, nil
+	}
+	count, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid rate %q (want count/duration, e.g. 5/1m)", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n < 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q: bad count", s)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil || d <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q: bad duration", s)
+	}
+	if n == 0 {
+		return Rate{}, nil
+	}
+	return Rate{Count: n, Per: d}, nil
+}
+
+// String formats the rate as ParseRate reads it, or "unlimited".
+func (r Rate) String() string {
+	if r.unlimited() {
+		return "unlimited"
+	}
+	// Drop the zero units of round durations, e.g. 1m0s
+	per := r.Per.String()
+	if strings.HasSuffix(per, "m0s") {
+		per = strings.TrimSuffix(per, "0s")
+	}
+	if strings.HasSuffix(per, "h0m") {
+		per = strings.TrimSuffix(per, "0m")
+	}
+	return fmt.Sprintf("%d/%s", r.Count, per)
+}
+
+func (r Rate) unlimited() bool {
+	return r.Count <= 0 || r.Per <= 0
+}
+
+// bucket is a token bucket that starts full.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens earned since the last refill.
+func (b *bucket) refill(r Rate, now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed.Seconds()*float64(r.Count)/r.Per.Seconds(), float64(r.Count))
+		b.last = now
+	}
+}
+
+// wait returns how long until the bucket holds a token.
+func (b *bucket) wait(r Rate) time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) * float64(r.Per) / float64(r.Count))
+}
+
+// Limiter enforces a per-user and a global rate.
+type Limiter struct {
+	mu        sync.Mutex
+	user      Rate
+	global    Rate
+	users     map[string]*bucket
+	all       bucket
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// NewLimiter creates a limiter allowing each user user and everyone
+// together global.
+func NewLimiter(user, global Rate) *Limiter {
+	l := &Limiter{users: make(map[string]*bucket), now: time.Now}
+	l.SetRates(user, global)
+	return l
+}
+
+// Default is the limiter generation commands are checked against. It is
+// unlimited until configured.
+var Default = NewLimiter(Rate{}, Rate{})
+
+// SetRates changes the per-user and global rates. Buckets start over full.
+func (l *Limiter) SetRates(user, global Rate) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.user, l.global = user, global
+	l.users = make(map[string]*bucket)
+	l.all = bucket{tokens: float64(global.Count), last: l.now()}
+}
+
+// Rates returns the per-user and global rates.
+func (l *Limiter) Rates() (user, global Rate) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.user, l.global
+}
+
+// ErrRateLimited is returned by Allow when a job may not start yet.
+type ErrRateLimited struct {
+	Global bool          // The global rate was hit rather than the user's
+	Wait   time.Duration // Until the next job may start
+}
+
+func (e *ErrRateLimited) Error() string {
+	wait := max(e.Wait.Round(time.Second), time.Second)
+	if e.Global {
+		return fmt.Sprintf("the bot is receiving a lot of requests right now; please try again in %s", wait)
+	}
+	return fmt.Sprintf("you are sending requests faster than allowed; please try again in %s", wait)
+}
+
+// Allow takes a token for a job of the user, or returns an *ErrRateLimited
+// when either the user's or the global bucket is empty. A refused job takes
+// no token.
+func (l *Limiter) Allow(uid string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+
+	var ub *bucket
+	if !l.user.unlimited() {
+		l.prune(now)
+		ub = l.users[uid]
+		if ub == nil {
+			ub = &bucket{tokens: float64(l.user.Count), last: now}
+			l.users[uid] = ub
+		}
+		ub.refill(l.user, now)
+		if wait := ub.wait(l.user); wait > 0 {
+			return &ErrRateLimited{Wait: wait}
+		}
+	}
+	if !l.global.unlimited() {
+		l.all.refill(l.global, now)
+		if wait := l.all.wait(l.global); wait > 0 {
+			return &ErrRateLimited{Global: true, Wait: wait}
+		}
+		l.all.tokens--
+	}
+	if ub != nil {
+		ub.tokens--
+	}
+	return nil
+}
+
+// prune drops the buckets of users that have been idle long enough to be
+// full again, at most once per rate period. Callers hold mu.
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.user.Per {
+		return
+	}
+	l.lastPrune = now
+	for uid, b := range l.users {
+		if now.Sub(b.last) >= l.user.Per {
+			delete(l.users, uid)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Rate
+		wantErr bool
+	}{
+		{"", Rate{}, false},
+		{"5/1m", Rate{Count: 5, Per: time.Minute}, false},
+		{" 30 / 1h ", Rate{Count: 30, Per: time.Hour}, false},
+		{"0/1m", Rate{}, false},
+		{"5", Rate{}, true},
+		{"x/1m", Rate{}, true},
+		{"5/soon", Rate{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRate(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseRate(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+	for in, want := range map[string]string{"5/1m": "5/1m", "30/1h": "30/1h", "2/90s": "2/1m30s", "": "unlimited"} {
+		if r, _ := ParseRate(in); r.String() != want {
+			t.Errorf("ParseRate(%q).String() = %q, want %q", in, r.String(), want)
+		}
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewLimiter(Rate{}, Rate{})
+	l.now = func() time.Time { return now }
+	l.SetRates(Rate{Count: 2, Per: time.Minute}, Rate{Count: 3, Per: time.Minute})
+
+	// Users get a burst of two
+	for i := 0; i < 2; i++ {
+		if err := l.Allow("alice"); err != nil {
+			t.Fatalf("alice job %d: %v", i+1, err)
+		}
+	}
+	var rl *ErrRateLimited
+	if err := l.Allow("alice"); !errors.As(err, &rl) || rl.Global || rl.Wait != 30*time.Second {
+		t.Fatalf("alice job 3 = %v, want user limit with 30s wait", err)
+	}
+
+	// The global bucket has one token left
+	if err := l.Allow("bob"); err != nil {
+		t.Fatalf("bob job 1: %v", err)
+	}
+	if err := l.Allow("bob"); !errors.As(err, &rl) || !rl.Global {
+		t.Fatalf("bob job 2 = %v, want global limit", err)
+	}
+
+	// Refused jobs take no token, so bob goes first once the global bucket
+	// refills
+	now = now.Add(20 * time.Second)
+	if err := l.Allow("bob"); err != nil {
+		t.Fatalf("bob after refill: %v", err)
+	}
+	now = now.Add(20 * time.Second)
+	if err := l.Allow("alice"); err != nil {
+		t.Fatalf("alice after refill: %v", err)
+	}
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	l := NewLimiter(Rate{}, Rate{})
+	for i := 0; i < 100; i++ {
+		if err := l.Allow("alice"); err != nil {
+			t.Fatalf("job %d: %v", i+1, err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package reqcache reuses the responses of identical generation requests,
+// so a user repeating a request within the cache TTL gets the result again
+// instead of the bot paying fal twice.
+package reqcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/decred/slog"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/joblog"
+	braibottypes "github.com/karamble/braibot/internal/types"
+)
+
+// DefaultTTL is how long responses are reused unless requestcachettl= is
+// set.
+const DefaultTTL = 10 * time.Minute
+
+// Default is the cache the generation services use. It is nil when the
+// cache is turned off.
+var Default *Cache
+
+// entry is a cached response.
+type entry struct {
+	response []byte
+	at       time.Time
+}
+
+// Cache keeps the responses of recent requests in memory and in the
+// database, which outlasts restarts.
+type Cache struct {
+	db  *database.DBManager
+	ttl time.Duration
+	log slog.Logger
+	now func() time.Time
+
+	mu  sync.Mutex
+	mem map[string]entry
+}
+
+// New creates a cache reusing responses for ttl. db may be nil to keep
+// responses in memory only. Failures to cache are logged to log, tagged with
+// the fields of the job (see joblog).
+func New(db *database.DBManager, ttl time.Duration, log slog.Logger) *Cache {
+	return &Cache{db: db, ttl: ttl, log: log, now: time.Now, mem: make(map[string]entry)}
+}
+
+// Key returns the hash identifying the request falReq of req: the user,
+// the model and every option sent to fal. Progress callbacks are left out.
+func Key(req *braibottypes.GenerationRequest, falReq interface{}) (string, error) {
+	body, err := json.Marshal(withoutProgress(falReq))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %v", err)
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n", req.UserID, req.ModelType, req.ModelName)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// withoutProgress returns a copy of the struct v points to with its
+// Progress fields, including those of embedded structs, cleared.
+func withoutProgress(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return v
+	}
+	cp := reflect.New(rv.Elem().Type())
+	cp.Elem().Set(rv.Elem())
+	clearProgress(cp.Elem())
+	return cp.Interface()
+}
+
+func clearProgress(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		f, sf := v.Field(i), v.Type().Field(i)
+		switch {
+		case !sf.IsExported():
+		case sf.Name == "Progress":
+			f.Set(reflect.Zero(f.Type()))
+		case sf.Anonymous && f.Kind() == reflect.Struct:
+			clearProgress(f)
+		}
+	}
+}
+
+// Lookup decodes the response to an identical request made within the TTL
+// into resp and returns when it was cached. It also returns the key to
+// Store the new response under, "" when the request is not cached: requests
+// billed by their caller, and requests whose options cannot be hashed. With
+// req.NoCache set nothing is looked up, but the fresh response replaces the
+// cached one.
+func (c *Cache) Lookup(req *braibottypes.GenerationRequest, falReq, resp interface{}) (key string, at time.Time, ok bool) {
+	if c == nil || req.ExternalBilling != nil {
+		return "", time.Time{}, false
+	}
+	key, err := Key(req, falReq)
+	if err != nil {
+		joblog.For(c.log, req).Warnf("Not caching the request: %v", err)
+		return "", time.Time{}, false
+	}
+	if req.NoCache {
+		return key, time.Time{}, false
+	}
+
+	since := c.now().Add(-c.ttl)
+	c.mu.Lock()
+	e, found := c.mem[key]
+	c.mu.Unlock()
+	if !found && c.db != nil {
+		response, storedAt, stored, err := c.db.CachedResponse(key, since)
+		if err != nil {
+			joblog.For(c.log, req).Warnf("%v", err)
+		} else if stored {
+			e, found = entry{response: []byte(response), at: storedAt}, true
+		}
+	}
+	if !found || e.at.Before(since) {
+		return key, time.Time{}, false
+	}
+	if err := json.Unmarshal(e.response, resp); err != nil {
+		joblog.For(c.log, req).Warnf("Failed to decode the cached response: %v", err)
+		return key, time.Time{}, false
+	}
+	return key, e.at, true
+}
+
+// Store caches resp to req under key. An empty key stores nothing.
+func (c *Cache) Store(req *braibottypes.GenerationRequest, key string, resp interface{}) {
+	if c == nil || key == "" {
+		return
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		joblog.For(c.log, req).Warnf("Failed to cache the response: %v", err)
+		return
+	}
+	now := c.now()
+	c.mu.Lock()
+	c.mem[key] = entry{response: body, at: now}
+	c.mu.Unlock()
+	if c.db != nil {
+		if err := c.db.StoreCachedResponse(key, string(body), now); err != nil {
+			joblog.For(c.log, req).Warnf("%v", err)
+		}
+	}
+}
+
+// Purge drops the responses older than the TTL and returns how many were
+// dropped from the database.
+func (c *Cache) Purge() (int64, error) {
+	if c == nil {
+		return 0, nil
+	}
+	since := c.now().Add(-c.ttl)
+	c.mu.Lock()
+	for key, e := range c.mem {
+		if e.at.Before(since) {
+			delete(c.mem, key)
+		}
+	}
+	c.mu.Unlock()
+	if c.db == nil {
+		return 0, nil
+	}
+	return c.db.PurgeCachedResponses(since)
+}
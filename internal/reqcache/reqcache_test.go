@@ -0,0 +1,103 @@
+package reqcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/slog"
+	"github.com/karamble/braibot/internal/database"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+type progress struct{ calls int }
+
+func (p *progress) OnQueueUpdate(position int, eta time.Duration) {}
+func (p *progress) OnLogMessage(message string)                   {}
+func (p *progress) OnProgress(status string)                      { p.calls++ }
+func (p *progress) OnError(err error)                             {}
+
+func TestKey(t *testing.T) {
+	req := &braibottypes.GenerationRequest{ModelType: "text2image", ModelName: "flux/schnell"}
+	a := &fal.FluxSchnellRequest{BaseImageRequest: fal.BaseImageRequest{Prompt: "a fox", Progress: &progress{}}}
+	b := &fal.FluxSchnellRequest{BaseImageRequest: fal.BaseImageRequest{Prompt: "a fox", Progress: &progress{calls: 3}}}
+	ka, err := Key(req, a)
+	if err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+	if kb, _ := Key(req, b); kb != ka {
+		t.Error("progress callbacks changed the key")
+	}
+	if a.Progress == nil {
+		t.Error("Key cleared the progress callback of the request itself")
+	}
+
+	b.Prompt = "a wolf"
+	if kb, _ := Key(req, b); kb == ka {
+		t.Error("a different prompt gave the same key")
+	}
+	other := *req
+	other.UserID = zkidentity.ShortID{1}
+	if ko, _ := Key(&other, a); ko == ka {
+		t.Error("another user's request gave the same key")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	db, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Unix(1_700_000_000, 0)
+	c := New(db, 10*time.Minute, slog.Disabled)
+	c.now = func() time.Time { return now }
+	req := &braibottypes.GenerationRequest{ModelType: "text2image", ModelName: "flux/schnell"}
+	falReq := &fal.FluxSchnellRequest{BaseImageRequest: fal.BaseImageRequest{Prompt: "a fox"}}
+
+	var resp fal.ImageResponse
+	key, _, ok := c.Lookup(req, falReq, &resp)
+	if ok || key == "" {
+		t.Fatalf("Lookup of a new request = %q, %v", key, ok)
+	}
+	c.Store(req, key, &fal.ImageResponse{Images: []fal.ImageOutput{{URL: "https://fal.media/fox.png"}}, Seed: 7})
+
+	now = now.Add(5 * time.Minute)
+	if _, at, ok := c.Lookup(req, falReq, &resp); !ok || resp.Seed != 7 || resp.Images[0].URL != "https://fal.media/fox.png" || !at.Equal(now.Add(-5*time.Minute)) {
+		t.Fatalf("Lookup = %+v, %v, %v", resp, at, ok)
+	}
+
+	// The database keeps responses across restarts
+	restarted := New(db, 10*time.Minute, slog.Disabled)
+	restarted.now = c.now
+	if _, _, ok := restarted.Lookup(req, falReq, &fal.ImageResponse{}); !ok {
+		t.Fatal("Lookup after a restart missed")
+	}
+
+	noCache := *req
+	noCache.NoCache = true
+	if k, _, ok := c.Lookup(&noCache, falReq, &fal.ImageResponse{}); ok || k != key {
+		t.Fatalf("Lookup with NoCache = %q, %v; want a miss with the key", k, ok)
+	}
+	billed := *req
+	billed.ExternalBilling = &braibottypes.ExternalBilling{}
+	if k, _, ok := c.Lookup(&billed, falReq, &fal.ImageResponse{}); ok || k != "" {
+		t.Fatalf("Lookup of an externally billed request = %q, %v; want no caching", k, ok)
+	}
+
+	now = now.Add(6 * time.Minute)
+	if _, _, ok := c.Lookup(req, falReq, &fal.ImageResponse{}); ok {
+		t.Fatal("Lookup after the TTL hit")
+	}
+	if n, err := c.Purge(); err != nil || n != 1 {
+		t.Fatalf("Purge = %d, %v; want 1", n, err)
+	}
+
+	var nilCache *Cache
+	if k, _, ok := nilCache.Lookup(req, falReq, &resp); ok || k != "" {
+		t.Fatal("a nil cache hit")
+	}
+	nilCache.Store(req, "k", &resp)
+}
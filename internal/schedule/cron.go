@@ -0,0 +1,189 @@
+// Package schedule runs generations users scheduled with !schedule on cron
+// schedules.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// aliases are the shorthands accepted for common specs.
+var aliases = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// Spec is a parsed five field cron spec: minute, hour, day of month, month
+// and day of week (0 or 7 is Sunday). Times are in UTC.
+type Spec struct {
+	minute, hour, dom, month, dow uint64 // Bit n is set when n matches
+	anyDOM, anyDOW                bool
+}
+
+// field is the range of one cron field.
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = [5]field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Parse parses a cron spec such as "0 9 * * *" or "*/30 8-18 * * 1-5", or
+// one of @hourly, @daily, @weekly and @monthly.
+func Parse(spec string) (*Spec, error) {
+	spec = strings.TrimSpace(spec)
+	if alias, ok := aliases[strings.ToLower(spec)]; ok {
+		spec = alias
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("a schedule has 5 fields (minute hour day month weekday), e.g. \"0 9 * * *\"")
+	}
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, err
+		}
+		bits[i] = b
+	}
+	s := &Spec{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		anyDOM: parts[2] == "*",
+		anyDOW: parts[4] == "*",
+	}
+	// Sunday is both 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// parseField parses a comma-separated list of *, n, a-b, */step and
+// a-b/step.
+func parseField(part string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(part, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = fieldValue(a, f); err != nil {
+				return 0, err
+			}
+			if hi, err = fieldValue(b, f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid %s range %s", f.name, rng)
+			}
+		default:
+			v, err := fieldValue(rng, f)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			if hasStep {
+				hi = f.max
+			}
+		}
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid %s step %s", f.name, stepStr)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func fieldValue(s string, f field) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q, must be %d to %d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// maxSearch bounds the search for the next run of specs that never match,
+// such as February 30th.
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// Next returns the first time after t the spec matches, in UTC. It returns
+// false when the spec matches no time in the next five years.
+func (s *Spec) Next(t time.Time) (time.Time, bool) {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	end := t.Add(maxSearch)
+	for t.Before(end) {
+		switch {
+		case s.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case s.hour&(1<<t.Hour()) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// matchesDay reports whether the day of t matches. Like cron, a day matches
+// either field when both the day of month and the day of week are
+// restricted.
+func (s *Spec) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case s.anyDOM && s.anyDOW:
+		return true
+	case s.anyDOM:
+		return dow
+	case s.anyDOW:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// MinInterval returns the shortest time between the next n runs after t,
+// or 0 when the spec runs fewer than twice.
+func (s *Spec) MinInterval(t time.Time, n int) time.Duration {
+	var shortest time.Duration
+	prev, ok := s.Next(t)
+	for i := 1; ok && i < n; i++ {
+		var next time.Time
+		if next, ok = s.Next(prev); ok {
+			if gap := next.Sub(prev); shortest == 0 || gap < shortest {
+				shortest = gap
+			}
+			prev = next
+		}
+	}
+	return shortest
+}
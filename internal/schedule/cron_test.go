@@ -0,0 +1,77 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, spec := range []string{"0 9 * * *", "*/15 8-18 * * 1-5", "0 0 1,15 * *", "30 6 * * 7", "@daily", "@HOURLY", "5-55/10 * * 1-6/2 *"} {
+		if _, err := Parse(spec); err != nil {
+			t.Errorf("Parse(%q): %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"", "0 9 * *", "0 9 * * * *", "60 * * * *", "0 24 * * *", "0 0 0 * *", "0 0 * 13 *", "0 0 * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@yearly"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"0 9 * * *", time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 14, 9, 45, 0, 0, time.UTC)},
+		{"0 8 * * 1", time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * 7", time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 2 *", time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted
+		{"0 0 20 * 5", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		spec, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.spec, err)
+		}
+		if got, ok := spec.Next(from); !ok || !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, %v; want %v", tt.spec, got, ok, tt.want)
+		}
+	}
+
+	never, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got, ok := never.Next(from); ok {
+		t.Errorf("Next of February 30th = %v, want none", got)
+	}
+}
+
+func TestMinInterval(t *testing.T) {
+	from := time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Duration
+	}{
+		{"0 9 * * *", 24 * time.Hour},
+		{"*/20 * * * *", 20 * time.Minute},
+		{"0 9,10 * * *", time.Hour},
+		{"0 0 30 2 *", 0},
+	}
+	for _, tt := range tests {
+		spec, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.spec, err)
+		}
+		if got := spec.MinInterval(from, 48); got != tt.want {
+			t.Errorf("MinInterval(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/karamble/braibot/internal/database"
+)
+
+// Runner queues the generations of due schedules and moves the schedules
+// to their next run.
+type Runner struct {
+	db     *database.DBManager
+	submit func(database.QueuedJob) error
+	notify func(uid, msg string)
+}
+
+// NewRunner creates a runner that queues jobs with submit and tells users
+// about their scheduled runs with notify.
+func NewRunner(db *database.DBManager, submit func(database.QueuedJob) error, notify func(uid, msg string)) *Runner {
+	return &Runner{db: db, submit: submit, notify: notify}
+}
+
+// Run runs due schedules every interval until ctx is done. Schedules that
+// came due while the bot was down run once on the first sweep. Failures are
+// reported to logf.
+func (r *Runner) Run(ctx context.Context, interval time.Duration, logf func(format string, args ...interface{})) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := r.Sweep(time.Now(), logf); err != nil {
+			logf("Scheduler: %v", err)
+		} else if n > 0 {
+			logf("Scheduler: queued %d scheduled generations", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep queues the generations of the schedules due at now and returns how
+// many were queued. A schedule is moved to its next run before its job is
+// queued, so a job that cannot be queued is skipped rather than retried.
+func (r *Runner) Sweep(now time.Time, logf func(format string, args ...interface{})) (int, error) {
+	due, err := r.db.DueSchedules(now)
+	if err != nil {
+		return 0, err
+	}
+	queued := 0
+	for _, s := range due {
+		spec, err := Parse(s.Spec)
+		if err != nil {
+			logf("Scheduler: schedule %d of %s: %v", s.ID, s.Nick, err)
+			continue
+		}
+		next, ok := spec.Next(now)
+		if !ok {
+			// Never runs again; keep it listed so the user can remove it
+			next = now.AddDate(100, 0, 0)
+		}
+		if err := r.db.SetScheduleNextRun(s.ID, next); err != nil {
+			logf("Scheduler: schedule %d of %s: %v", s.ID, s.Nick, err)
+			continue
+		}
+
+		err = r.submit(database.QueuedJob{
+			UID:     s.UID,
+			Nick:    s.Nick,
+			Command: s.Command,
+			Args:    s.Args,
+			IsPM:    true,
+		})
+		if err != nil {
+			logf("Scheduler: schedule %d of %s: %v", s.ID, s.Nick, err)
+			r.notify(s.UID, fmt.Sprintf("⏰ Your scheduled !%s (#%d) was skipped: %v. It runs again at %s.", s.Command, s.ID, err, FormatTime(next)))
+			continue
+		}
+		queued++
+		r.notify(s.UID, fmt.Sprintf("⏰ Running your scheduled !%s (#%d). Next run: %s. Remove it with !schedule remove %d.", s.Command, s.ID, FormatTime(next), s.ID))
+	}
+	return queued, nil
+}
+
+// FormatTime renders the time of a run.
+func FormatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04 UTC")
+}
@@ -0,0 +1,62 @@
+package schedule
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/karamble/braibot/internal/database"
+)
+
+func TestSweep(t *testing.T) {
+	db, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	add := func(uid string, next time.Time) int64 {
+		t.Helper()
+		id, err := db.AddSchedule(database.Schedule{UID: uid, Nick: uid, Spec: "0 9 * * *", Command: "text2image",
+			Args: []string{"sunrise"}, NextRun: next, CreatedAt: now}, 5)
+		if err != nil || id == 0 {
+			t.Fatalf("AddSchedule = %d, %v", id, err)
+		}
+		return id
+	}
+	add("alice", now)
+	// Missed while the bot was down, so it runs once
+	add("bob", now.Add(-72*time.Hour))
+	add("carol", now.Add(time.Minute))
+
+	var jobs []database.QueuedJob
+	notices := map[string]string{}
+	r := NewRunner(db, func(job database.QueuedJob) error {
+		if job.UID == "bob" {
+			return errors.New("queue full")
+		}
+		jobs = append(jobs, job)
+		return nil
+	}, func(uid, msg string) { notices[uid] = msg })
+
+	n, err := r.Sweep(now, t.Logf)
+	if err != nil || n != 1 {
+		t.Fatalf("Sweep = %d, %v; want 1", n, err)
+	}
+	if len(jobs) != 1 || jobs[0].UID != "alice" || !jobs[0].IsPM || jobs[0].Command != "text2image" || jobs[0].Args[0] != "sunrise" {
+		t.Fatalf("jobs = %+v", jobs)
+	}
+	if !strings.Contains(notices["alice"], "Next run: 2026-10-15 09:00 UTC") || !strings.Contains(notices["bob"], "skipped: queue full") {
+		t.Fatalf("notices = %q", notices)
+	}
+
+	// Due schedules moved on to tomorrow
+	if n, err := r.Sweep(now, t.Logf); err != nil || n != 0 {
+		t.Fatalf("Sweep again = %d, %v; want 0", n, err)
+	}
+	if due, err := db.DueSchedules(now.Add(24 * time.Hour)); err != nil || len(due) != 3 {
+		t.Fatalf("DueSchedules tomorrow = %+v, %v; want all three", due, err)
+	}
+}
@@ -3,15 +3,29 @@ package speech
 import (
 	"context"
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
+	"github.com/decred/slog"
 	// "github.com/companyzero/bisonrelay/clientrpc/types" // Only needed for old billing call
+	"github.com/karamble/braibot/internal/assets"
+	"github.com/karamble/braibot/internal/audio"
 	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/debuglog"
 	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/jobevents"
+	"github.com/karamble/braibot/internal/joblog"
+	"github.com/karamble/braibot/internal/reqcache"
+	"github.com/karamble/braibot/internal/templates"
+	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
 	"github.com/karamble/braibot/pkg/fal"
 	kit "github.com/vctt94/bisonbotkit"
@@ -19,62 +33,151 @@ import (
 
 // SpeechService handles speech generation
 type SpeechService struct {
-	client         *fal.Client
-	dbManager      *database.DBManager
-	bot            *kit.Bot
-	debug          bool
-	billingEnabled bool // Added billing enabled flag
+	client          *fal.Client
+	dbManager       *database.DBManager
+	bot             *kit.Bot
+	sender          *braibottypes.MessageSender
+	debug           bool
+	billingEnabled  atomic.Bool       // Toggled at runtime by !admin billing
+	maxTextChars    int               // Operator cap on text2speech input, 0 = model caps only
+	publisher       *assets.Publisher // Asset server for GC links, nil when not configured
+	gcMaxEmbedBytes int               // Cap on audio embeds in GCs, 0 = maxAudioEmbedBytes only
+	log             slog.Logger
+
+	mu     sync.Mutex
+	voices map[string]string // Last text2speech voice by user ID
 }
 
 // NewSpeechService creates a new SpeechService
 func NewSpeechService(client *fal.Client, dbManager *database.DBManager, bot *kit.Bot, debug bool, billingEnabled bool) *SpeechService {
-	return &SpeechService{
-		client:         client,
-		dbManager:      dbManager,
-		bot:            bot,
-		debug:          debug,
-		billingEnabled: billingEnabled, // Store the flag
+	s := &SpeechService{
+		client:    client,
+		dbManager: dbManager,
+		bot:       bot,
+		sender:    braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot)),
+		debug:     debug,
+		voices:    make(map[string]string),
+		log:       joblog.New(nil, joblog.Speech),
+	}
+	s.billingEnabled.Store(billingEnabled)
+	return s
+}
+
+// SetBillingEnabled turns charging for generations on or off.
+func (s *SpeechService) SetBillingEnabled(enabled bool) {
+	s.billingEnabled.Store(enabled)
+}
+
+// SetLogger sets the logger the service logs its jobs to.
+func (s *SpeechService) SetLogger(l slog.Logger) {
+	s.log = l
+}
+
+// jobLog returns a logger for the job of req.
+func (s *SpeechService) jobLog(req *braibottypes.GenerationRequest) joblog.Logger {
+	return joblog.For(s.log, req)
+}
+
+// SetMaxTextChars caps the characters accepted by text2speech below the
+// models' own limits. 0 keeps only the model limits.
+func (s *SpeechService) SetMaxTextChars(n int) {
+	if n >= 0 {
+		s.maxTextChars = n
 	}
 }
 
+// SetGCMaxEmbedBytes caps the base64 payload of audio embedded in group
+// chats; larger audio is linked instead. 0 keeps the regular limit.
+func (s *SpeechService) SetGCMaxEmbedBytes(n int) {
+	if n >= 0 {
+		s.gcMaxEmbedBytes = n
+	}
+}
+
+// SetAssetPublisher sets the asset server that GC results go to when the
+// request prefers links over embeds.
+func (s *SpeechService) SetAssetPublisher(p *assets.Publisher) {
+	s.publisher = p
+}
+
+// textLimit returns the most characters m accepts, 0 meaning no limit.
+func (s *SpeechService) textLimit(m faladapter.AppModel) int {
+	limit := m.MaxTextChars
+	if s.maxTextChars > 0 && (limit == 0 || s.maxTextChars < limit) {
+		limit = s.maxTextChars
+	}
+	return limit
+}
+
+// Voice returns the voice the user last chose for text2speech, falling
+// back to the voice_id they saved with !set. It returns "" when neither is
+// known.
+func (s *SpeechService) Voice(userID string) string {
+	s.mu.Lock()
+	voice := s.voices[userID]
+	s.mu.Unlock()
+	if voice == "" {
+		voice = utils.LoadUserSettings(s.dbManager, userID)[utils.SettingVoice]
+	}
+	return voice
+}
+
 // GenerateSpeech generates speech based on the internal request, handling billing conditionally.
 func (s *SpeechService) GenerateSpeech(ctx context.Context, req *SpeechRequest) (*SpeechResult, error) {
-	// Upstream TTS billing is per character while the charged price is per
-	// message, so the model's text cap bounds the input cost. Enforced
-	// before any charge or generation.
-	if m, ok := faladapter.GetModel(req.ModelName, "text2speech"); ok && m.MaxTextChars > 0 && len(req.Text) > m.MaxTextChars {
-		err := fmt.Errorf("text is %d characters; %s accepts at most %d", len(req.Text), req.ModelName, m.MaxTextChars)
-		return &SpeechResult{Success: false, Error: err}, err
+	// Speak in the user's saved voice unless the request picked one
+	if req.VoiceID == "" {
+		req.VoiceID = utils.LoadUserSettings(s.dbManager, req.UserID.String())[utils.SettingVoice]
+	}
+
+	// Upstream TTS billing is per character, so the text cap bounds the
+	// input cost of models charged per message. Enforced before any charge
+	// or generation.
+	chars := utf8.RuneCountInString(req.Text)
+	costText := ""
+	if m, ok := faladapter.GetModel(req.ModelName, "text2speech"); ok {
+		if limit := s.textLimit(m); limit > 0 && chars > limit {
+			err := fmt.Errorf("text is %d characters; %s accepts at most %d", chars, req.ModelName, limit)
+			return &SpeechResult{Success: false, Error: err}, err
+		}
+		req.PriceUSD = faladapter.PriceFor(m, faladapter.PriceParams{TextChars: chars})
+		if m.PerThousandChars {
+			costText = fmt.Sprintf(" for %d characters at $%.2f per 1000", chars, m.PriceUSD)
+		}
 	}
+	jobevents.Default.Submit(&req.GenerationRequest)
 
 	// 1. Calculate cost and CHECK balance if billing is enabled
 	var requiredDCR, currentBalanceDCR float64
 	var checkErr error
-	if s.billingEnabled {
+	if s.billingEnabled.Load() {
 		// Call CheckBalance, which now returns the error directly if insufficient or other issue
-		requiredDCR, currentBalanceDCR, checkErr = utils.CheckBalance(ctx, s.dbManager, req.UserID[:], req.PriceUSD, s.debug, s.billingEnabled)
+		requiredDCR, currentBalanceDCR, checkErr = utils.CheckRequestBalance(ctx, s.dbManager, &req.GenerationRequest, req.PriceUSD, s.debug, s.billingEnabled.Load())
 		if checkErr != nil {
 			// Return the error (could be ErrInsufficientBalance or another critical error)
 			// The calling layer (main.go) will handle ErrInsufficientBalance specifically.
+			jobevents.Default.EmitFailed(&req.GenerationRequest, checkErr)
 			return &SpeechResult{Success: false, Error: checkErr}, checkErr
 		}
 	}
 
 	// 2. Send initial message (adjusted for billing status)
-	var infoMsg string
-	if s.billingEnabled {
-		infoMsg = fmt.Sprintf("Request cost: $%.2f USD (%.8f DCR). Your balance: %.8f DCR. Processing speech request...", req.PriceUSD, requiredDCR, currentBalanceDCR)
-	} else if eb := req.ExternalBilling; eb != nil {
-		infoMsg = fmt.Sprintf("Request cost: $%.2f USD (%.8f DCR). Your balance: %.8f DCR. Processing speech request...", eb.ChargedUSD, eb.ChargedDCR, eb.BalanceDCR)
-	} else {
-		infoMsg = "Processing your speech request (billing disabled)..."
+	notice := templates.Data{
+		Task:        "speech",
+		Action:      "Processing your speech request",
+		CostUSD:     req.PriceUSD,
+		CostDCR:     requiredDCR,
+		CostDetails: costText,
+		BalanceDCR:  currentBalanceDCR,
+	}
+	if eb := req.ExternalBilling; eb != nil && !s.billingEnabled.Load() {
+		notice.CostUSD, notice.CostDCR, notice.BalanceDCR = eb.ChargedUSD, eb.ChargedDCR, eb.BalanceDCR
 	}
 	// Only send balance info in PMs
-	if req.IsPM {
-		s.bot.SendPM(ctx, req.UserNick, infoMsg)
-	} else {
-		s.bot.SendGC(ctx, req.GC, "Processing your speech request...")
+	infoMsg := utils.FormatProcessingNotice(&req.GenerationRequest, s.billingEnabled.Load() || req.ExternalBilling != nil, notice)
+	if req.IsPM && req.Text != "" {
+		infoMsg += "\nPrompt: " + utils.PreviewUserText(req.Text, utils.PromptPreviewRunes)
 	}
+	s.sender.SendMessage(ctx, req.MessageContext(), infoMsg)
 
 	// 3. Create the appropriate FAL request object using the helper function
 	falReq, err := createFalSpeechRequest(req)
@@ -84,30 +187,63 @@ func (s *SpeechService) GenerateSpeech(ctx context.Context, req *SpeechRequest)
 		return &SpeechResult{Success: false, Error: err}, err // Return error to command handler
 	}
 
-	// 4. Generate speech using the created request
-	audioResp, genErr := s.client.GenerateSpeech(ctx, falReq)
-	if genErr != nil {
-		// Log error server-side, do not PM the user here.
-		// Error will be handled by the command handler.
-		return &SpeechResult{Success: false, Error: genErr}, genErr // Return error to command handler
+	// 4. Reuse the response to an identical recent request, else generate
+	// speech using the created request
+	var audioResp *fal.AudioResponse
+	cacheKey, cachedAt, cached := reqcache.Default.Lookup(&req.GenerationRequest, falReq, &audioResp)
+	if !cached {
+		// Wait for a free slot for this kind of job
+		release, slotErr := utils.AcquireJobSlot(ctx, s.bot, &req.GenerationRequest)
+		if slotErr != nil {
+			jobevents.Default.EmitFailed(&req.GenerationRequest, slotErr)
+			return &SpeechResult{Success: false, Error: slotErr}, slotErr
+		}
+		defer release()
+
+		var genErr error
+		audioResp, genErr = s.client.GenerateSpeech(ctx, falReq)
+		if genErr != nil {
+			// Log error server-side, do not PM the user here.
+			// Error will be handled by the command handler.
+			jobevents.Default.EmitFailed(&req.GenerationRequest, genErr)
+			return &SpeechResult{Success: false, Error: genErr}, genErr // Return error to command handler
+		}
+		if audioResp.AudioURL != "" {
+			reqcache.Default.Store(&req.GenerationRequest, cacheKey, audioResp)
+		}
 	}
 
 	// 5. Check if the audio URL is empty
 	if audioResp.AudioURL == "" {
-		genErr = fmt.Errorf("received empty audio URL from API")
+		genErr := fmt.Errorf("received empty audio URL from API")
 		// Log error server-side, do not PM the user here.
 		// Error will be handled by the command handler.
+		jobevents.Default.EmitFailed(&req.GenerationRequest, genErr)
 		return &SpeechResult{Success: false, Error: genErr}, genErr // Return error to command handler
 	}
 
 	// 6. Download and send audio
 	successfullySent := false
-	if err := s.downloadAndSendAudio(ctx, req.UserNick, audioResp.AudioURL, req.ModelName); err != nil {
+	var sendErr error
+	if req.IsPM {
+		sendErr = s.downloadAndSendAudio(ctx, req.MessageContext(), audioResp.AudioURL, req.ModelName)
+	} else {
+		// Files only reach users, so GCs get the audio embedded
+		sendErr = s.sendEmbeddedAudio(ctx, &req.GenerationRequest, audioResp)
+	}
+	if sendErr != nil {
 		// Log download/send error server-side, do not PM the user here.
-		fmt.Printf("ERROR [SpeechService] User %s: Failed to download/send audio: %v\n", req.UserNick, err)
+		jobevents.Default.EmitFailed(&req.GenerationRequest, fmt.Errorf("failed to download/send audio: %w", sendErr))
 		// Continue but mark as not sent for billing purposes
 	} else {
 		successfullySent = true
+		jobevents.Default.EmitDelivered(&req.GenerationRequest, 1)
+		utils.RememberResult(s.dbManager, &req.GenerationRequest, database.ResultAudio, audioResp.AudioURL)
+		if req.VoiceID != "" {
+			s.mu.Lock()
+			s.voices[req.UserID.String()] = req.VoiceID
+			s.mu.Unlock()
+		}
 	}
 
 	// 7. Perform Billing *only if* enabled and audio was sent successfully
@@ -115,44 +251,59 @@ func (s *SpeechService) GenerateSpeech(ctx context.Context, req *SpeechRequest)
 	var finalBalanceDCR float64 = currentBalanceDCR // Use pre-deduction balance (balance from CheckBalance)
 	var billingAttempted bool = false
 	var billingSucceeded bool = false
+	var splitCharge *utils.SplitCharge // Set when the charge was split with a GC pot
 
-	if s.billingEnabled && successfullySent {
+	if s.billingEnabled.Load() && successfullySent && !cached {
 		billingAttempted = true
-		deductChargedDCR, deductNewBalance, deductErr := utils.DeductBalance(ctx, s.dbManager, req.UserID[:], req.PriceUSD, s.debug, s.billingEnabled)
+		deductChargedDCR, deductNewBalance, deductSplit, deductErr := utils.DeductRequestBalance(ctx, s.dbManager, &req.GenerationRequest, req.PriceUSD, s.debug, s.billingEnabled.Load())
 		if deductErr != nil {
 			// Only send billing errors in PMs
 			if req.IsPM {
-				s.bot.SendPM(ctx, req.UserNick, fmt.Sprintf("Error processing payment after sending audio: %v. Please contact support.", deductErr))
+				s.sender.SendMessage(ctx, req.MessageContext(), fmt.Sprintf("Error processing payment after sending audio: %v. Please contact support.", deductErr))
 			}
 			finalBalanceDCR = currentBalanceDCR // Use pre-deduction balance
 		} else {
 			billingSucceeded = true
+			splitCharge = deductSplit
 			chargedDCR = deductChargedDCR
 			finalBalanceDCR = deductNewBalance
+			jobevents.Default.EmitBilled(&req.GenerationRequest, chargedDCR)
 		}
 	}
 
+	s.logDelivery(&req.GenerationRequest, successfullySent, chargedDCR)
+
 	// 8. Send final confirmation
-	finalMessage := "Finished processing speech request.\n\n"
-	if !successfullySent {
-		finalMessage = "Speech generation completed, but failed to send the result.\n\n"
-	}
+	finalMessage := utils.FormatFinished(&req.GenerationRequest, templates.Data{Task: "speech", Sent: 1, SendFailed: !successfullySent}) + "\n\n"
 
 	// Only send billing information in PMs
 	if req.IsPM {
-		if eb := req.ExternalBilling; eb != nil && !s.billingEnabled {
-			finalMessage += utils.FormatBillingConfirmation("audio", true, true, true, eb.ChargedDCR, eb.ChargedUSD, eb.BalanceDCR)
+		if cached {
+			finalMessage += utils.FormatCachedResult(time.Since(cachedAt))
+		} else if eb := req.ExternalBilling; eb != nil && !s.billingEnabled.Load() {
+			finalMessage += utils.FormatBillingConfirmation("audio", true, true, true, eb.ChargedDCR, eb.ChargedUSD, eb.BalanceDCR) + utils.LowBalanceWarning(s.dbManager, req.UserID.String(), eb.BalanceDCR, eb.ChargedDCR, eb.ChargedUSD)
 		} else {
-			finalMessage += utils.FormatBillingConfirmation("audio", s.billingEnabled, billingAttempted, billingSucceeded, chargedDCR, req.PriceUSD, finalBalanceDCR)
+			finalMessage += utils.FormatBillingConfirmation("audio", s.billingEnabled.Load(), billingAttempted, billingSucceeded, chargedDCR, req.PriceUSD, finalBalanceDCR) + utils.LowBalanceWarning(s.dbManager, req.UserID.String(), finalBalanceDCR, chargedDCR, req.PriceUSD)
 		}
-		if err := s.bot.SendPM(ctx, req.UserNick, finalMessage); err != nil {
+		if err := s.sender.SendMessage(ctx, req.MessageContext(), finalMessage); err != nil {
 			// fmt.Printf("ERROR: Failed to send final confirmation message (speech) to %s: %v\n", req.UserNick, err) // Removed
 		}
 	} else {
 		// For group chats, just send a simple completion message
-		if err := s.bot.SendGC(ctx, req.GC, "Speech generation completed."); err != nil {
+		gcMessage := utils.FormatFinished(&req.GenerationRequest, templates.Data{Task: "speech", Sent: 1})
+		if cached {
+			gcMessage += "\n\n" + utils.FormatCachedResult(time.Since(cachedAt))
+		}
+		if splitCharge != nil {
+			gcMessage += "\n\n" + utils.FormatSplitPotReceipt(splitCharge)
+		}
+		if err := s.sender.SendMessage(ctx, req.MessageContext(), gcMessage); err != nil {
 			// fmt.Printf("ERROR: Failed to send final confirmation message (speech) to GC %s: %v\n", req.GC, err) // Removed
 		}
+		// The requester's share and balance are theirs alone
+		if splitCharge != nil {
+			s.sender.SendPrivateMessage(ctx, req.MessageContext(), utils.FormatSplitBillingConfirmation(splitCharge, req.PriceUSD)+utils.LowBalanceWarning(s.dbManager, req.UserID.String(), splitCharge.UserBalanceDCR, splitCharge.UserDCR+splitCharge.PotDCR, req.PriceUSD))
+		}
 	}
 
 	// Return overall success based on generation, even if sending/billing failed
@@ -162,8 +313,354 @@ func (s *SpeechService) GenerateSpeech(ctx context.Context, req *SpeechRequest)
 	}, nil
 }
 
+// maxVoiceNotePCMBytes caps the raw PCM downloaded for a voice note: 24 kHz
+// mono 16-bit audio runs 48000 bytes a second, so this is over five minutes.
+const maxVoiceNotePCMBytes = 16 << 20
+
+// VoiceNote speaks the request's text with minimax-tts and returns it as an
+// Ogg Opus audio note, without messaging or billing the user. The text is
+// cut to the model's text limit; the returned count is of characters spoken.
+func (s *SpeechService) VoiceNote(ctx context.Context, req *SpeechRequest) ([]byte, int, error) {
+	req.ModelName = "minimax-tts/text-to-speech"
+	if m, ok := faladapter.GetModel(req.ModelName, "text2speech"); ok {
+		if limit := s.textLimit(m); limit > 0 && utf8.RuneCountInString(req.Text) > limit {
+			req.Text = string([]rune(req.Text)[:limit])
+		}
+	}
+	// The Opus encoder takes 24 kHz mono PCM
+	req.Format = "pcm"
+	req.SampleRate = "24000"
+	req.Channel = "1"
+
+	falReq, err := createFalSpeechRequest(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	audioResp, err := s.client.GenerateSpeech(ctx, falReq)
+	if err != nil {
+		return nil, 0, err
+	}
+	if audioResp.AudioURL == "" {
+		return nil, 0, fmt.Errorf("received empty audio URL from API")
+	}
+
+	resp, err := http.Get(audioResp.AudioURL)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch audio: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("failed to fetch audio: status code %d", resp.StatusCode)
+	}
+	pcm, err := io.ReadAll(io.LimitReader(resp.Body, maxVoiceNotePCMBytes))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read audio data: %v", err)
+	}
+	debuglog.Debugf(debuglog.Delivery, "Downloaded voice note PCM %s (%d bytes) for %s", audioResp.AudioURL, len(pcm), req.UserNick)
+
+	ogg, err := audio.ConvertPCMToOpus(pcm)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode voice note: %v", err)
+	}
+	return ogg, utf8.RuneCountInString(req.Text), nil
+}
+
+// maxAudioEmbedBytes caps the cleaned audio size sent as an inline embed.
+// Larger results are sent as a file transfer instead.
+const maxAudioEmbedBytes = 1 << 20
+
+// CleanAudio isolates the voice in an audio clip and returns the cleaned
+// audio as an embed, handling billing conditionally.
+func (s *SpeechService) CleanAudio(ctx context.Context, req *CleanAudioRequest) (*SpeechResult, error) {
+	if req.AudioURL == "" {
+		err := fmt.Errorf("audio URL or audio note is required")
+		return &SpeechResult{Success: false, Error: err}, err
+	}
+	jobevents.Default.Submit(&req.GenerationRequest)
+
+	// 1. Calculate cost and CHECK balance if billing is enabled
+	var requiredDCR, currentBalanceDCR float64
+	var checkErr error
+	if s.billingEnabled.Load() {
+		requiredDCR, currentBalanceDCR, checkErr = utils.CheckBalance(ctx, s.dbManager, req.UserID[:], req.PriceUSD, s.debug, s.billingEnabled.Load())
+		if checkErr != nil {
+			jobevents.Default.EmitFailed(&req.GenerationRequest, checkErr)
+			return &SpeechResult{Success: false, Error: checkErr}, checkErr
+		}
+	}
+
+	// 2. Send initial message (adjusted for billing status)
+	notice := templates.Data{Task: "audio cleaning", Action: "Cleaning your audio", CostUSD: req.PriceUSD, CostDCR: requiredDCR, BalanceDCR: currentBalanceDCR}
+	if eb := req.ExternalBilling; eb != nil && !s.billingEnabled.Load() {
+		notice.CostUSD, notice.CostDCR, notice.BalanceDCR = eb.ChargedUSD, eb.ChargedDCR, eb.BalanceDCR
+	}
+	s.sender.SendMessage(ctx, req.MessageContext(), utils.FormatProcessingNotice(&req.GenerationRequest, s.billingEnabled.Load() || req.ExternalBilling != nil, notice))
+
+	// Wait for a free slot for this kind of job
+	release, slotErr := utils.AcquireJobSlot(ctx, s.bot, &req.GenerationRequest)
+	if slotErr != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, slotErr)
+		return &SpeechResult{Success: false, Error: slotErr}, slotErr
+	}
+	defer release()
+
+	// 3. Run the audio isolation model
+	falReq := &fal.AudioIsolationRequest{
+		AudioURL: req.AudioURL,
+		Progress: req.Progress,
+	}
+	audioResp, genErr := s.client.GenerateSpeech(ctx, falReq)
+	if genErr != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, genErr)
+		return &SpeechResult{Success: false, Error: genErr}, genErr
+	}
+	if audioResp.AudioURL == "" {
+		genErr = fmt.Errorf("received empty audio URL from API")
+		jobevents.Default.EmitFailed(&req.GenerationRequest, genErr)
+		return &SpeechResult{Success: false, Error: genErr}, genErr
+	}
+
+	// 4. Send the cleaned audio
+	successfullySent := false
+	if err := s.sendEmbeddedAudio(ctx, &req.GenerationRequest, audioResp); err != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, fmt.Errorf("failed to send cleaned audio: %w", err))
+	} else {
+		successfullySent = true
+		jobevents.Default.EmitDelivered(&req.GenerationRequest, 1)
+		utils.RememberResult(s.dbManager, &req.GenerationRequest, database.ResultAudio, audioResp.AudioURL)
+	}
+
+	// 5. Perform Billing *only if* enabled and audio was sent successfully
+	var chargedDCR float64
+	var finalBalanceDCR float64 = currentBalanceDCR
+	var billingAttempted bool = false
+	var billingSucceeded bool = false
+
+	if s.billingEnabled.Load() && successfullySent {
+		billingAttempted = true
+		deductChargedDCR, deductNewBalance, deductErr := utils.DeductBalance(ctx, s.dbManager, req.UserID[:], req.PriceUSD, utils.RequestChargeID(ctx, &req.GenerationRequest), s.debug, s.billingEnabled.Load())
+		if deductErr != nil {
+			if req.IsPM {
+				s.sender.SendMessage(ctx, req.MessageContext(), fmt.Sprintf("Error processing payment after sending audio: %v. Please contact support.", deductErr))
+			}
+		} else {
+			billingSucceeded = true
+			chargedDCR = deductChargedDCR
+			finalBalanceDCR = deductNewBalance
+			jobevents.Default.EmitBilled(&req.GenerationRequest, chargedDCR)
+		}
+	}
+
+	s.logDelivery(&req.GenerationRequest, successfullySent, chargedDCR)
+
+	// 6. Send final confirmation
+	finished := templates.Data{Task: "audio cleaning", Sent: 1, SendFailed: !successfullySent}
+	finalMessage := utils.FormatFinished(&req.GenerationRequest, finished) + "\n\n"
+	if req.IsPM {
+		if eb := req.ExternalBilling; eb != nil && !s.billingEnabled.Load() {
+			finalMessage += utils.FormatBillingConfirmation("audio", true, true, true, eb.ChargedDCR, eb.ChargedUSD, eb.BalanceDCR) + utils.LowBalanceWarning(s.dbManager, req.UserID.String(), eb.BalanceDCR, eb.ChargedDCR, eb.ChargedUSD)
+		} else {
+			finalMessage += utils.FormatBillingConfirmation("audio", s.billingEnabled.Load(), billingAttempted, billingSucceeded, chargedDCR, req.PriceUSD, finalBalanceDCR) + utils.LowBalanceWarning(s.dbManager, req.UserID.String(), finalBalanceDCR, chargedDCR, req.PriceUSD)
+		}
+		s.sender.SendMessage(ctx, req.MessageContext(), finalMessage)
+	} else {
+		s.sender.SendMessage(ctx, req.MessageContext(), utils.FormatFinished(&req.GenerationRequest, finished))
+	}
+
+	return &SpeechResult{
+		AudioURL: audioResp.AudioURL,
+		Success:  true,
+	}, nil
+}
+
+// VoiceSwap uploads the request's audio note to the voice changer, sends
+// the speech back in the requested voice and bills the note's length.
+func (s *SpeechService) VoiceSwap(ctx context.Context, req *VoiceSwapRequest) (*SpeechResult, error) {
+	if len(req.AudioNote) == 0 {
+		err := fmt.Errorf("audio note is required")
+		return &SpeechResult{Success: false, Error: err}, err
+	}
+	model, ok := faladapter.GetModel(req.ModelName, "audio2audio")
+	if !ok {
+		err := fmt.Errorf("model not found: %s", req.ModelName)
+		return &SpeechResult{Success: false, Error: err}, err
+	}
+	seconds := max(req.Seconds, 1)
+	req.PriceUSD = faladapter.PriceFor(model, faladapter.PriceParams{Seconds: seconds})
+	costText := ""
+	if model.PerSecondPricing {
+		costText = fmt.Sprintf(" for %d seconds at $%.2f per second", seconds, model.PriceUSD)
+	}
+	jobevents.Default.Submit(&req.GenerationRequest)
+
+	// 1. Calculate cost and CHECK balance if billing is enabled
+	var requiredDCR, currentBalanceDCR float64
+	var checkErr error
+	if s.billingEnabled.Load() {
+		requiredDCR, currentBalanceDCR, checkErr = utils.CheckRequestBalance(ctx, s.dbManager, &req.GenerationRequest, req.PriceUSD, s.debug, s.billingEnabled.Load())
+		if checkErr != nil {
+			jobevents.Default.EmitFailed(&req.GenerationRequest, checkErr)
+			return &SpeechResult{Success: false, Error: checkErr}, checkErr
+		}
+	}
+
+	// 2. Send initial message (adjusted for billing status)
+	notice := templates.Data{
+		Task:        "voice swap",
+		Action:      "Swapping the voice of your audio note",
+		CostUSD:     req.PriceUSD,
+		CostDCR:     requiredDCR,
+		CostDetails: costText,
+		BalanceDCR:  currentBalanceDCR,
+	}
+	s.sender.SendMessage(ctx, req.MessageContext(), utils.FormatProcessingNotice(&req.GenerationRequest, s.billingEnabled.Load(), notice))
+
+	// Wait for a free slot for this kind of job
+	release, slotErr := utils.AcquireJobSlot(ctx, s.bot, &req.GenerationRequest)
+	if slotErr != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, slotErr)
+		return &SpeechResult{Success: false, Error: slotErr}, slotErr
+	}
+	defer release()
+
+	// 3. Run the voice changer. The note goes up inline as a data URI, which
+	// fal accepts in place of a hosted file
+	falReq := &fal.ElevenLabsVoiceChangerRequest{
+		AudioURL: "data:audio/ogg;base64," + base64.StdEncoding.EncodeToString(req.AudioNote),
+		Voice:    req.Voice,
+		Progress: req.Progress,
+	}
+	if req.RemoveBackgroundNoise {
+		removeNoise := true
+		falReq.RemoveBackgroundNoise = &removeNoise
+	}
+	audioResp, genErr := s.client.GenerateSpeech(ctx, falReq)
+	if genErr != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, genErr)
+		return &SpeechResult{Success: false, Error: genErr}, genErr
+	}
+	if audioResp.AudioURL == "" {
+		genErr = fmt.Errorf("received empty audio URL from API")
+		jobevents.Default.EmitFailed(&req.GenerationRequest, genErr)
+		return &SpeechResult{Success: false, Error: genErr}, genErr
+	}
+
+	// 4. Send the converted audio
+	successfullySent := false
+	if err := s.sendEmbeddedAudio(ctx, &req.GenerationRequest, audioResp); err != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, fmt.Errorf("failed to send converted audio: %w", err))
+	} else {
+		successfullySent = true
+		jobevents.Default.EmitDelivered(&req.GenerationRequest, 1)
+		utils.RememberResult(s.dbManager, &req.GenerationRequest, database.ResultAudio, audioResp.AudioURL)
+	}
+
+	// 5. Perform Billing *only if* enabled and audio was sent successfully
+	var chargedDCR float64
+	var finalBalanceDCR float64 = currentBalanceDCR
+	var billingAttempted bool = false
+	var billingSucceeded bool = false
+	var splitCharge *utils.SplitCharge
+
+	if s.billingEnabled.Load() && successfullySent {
+		billingAttempted = true
+		deductChargedDCR, deductNewBalance, deductSplit, deductErr := utils.DeductRequestBalance(ctx, s.dbManager, &req.GenerationRequest, req.PriceUSD, s.debug, s.billingEnabled.Load())
+		if deductErr != nil {
+			if req.IsPM {
+				s.sender.SendMessage(ctx, req.MessageContext(), fmt.Sprintf("Error processing payment after sending audio: %v. Please contact support.", deductErr))
+			}
+		} else {
+			billingSucceeded = true
+			splitCharge = deductSplit
+			chargedDCR = deductChargedDCR
+			finalBalanceDCR = deductNewBalance
+			jobevents.Default.EmitBilled(&req.GenerationRequest, chargedDCR)
+		}
+	}
+
+	s.logDelivery(&req.GenerationRequest, successfullySent, chargedDCR)
+
+	// 6. Send final confirmation
+	finished := templates.Data{Task: "voice swap", Sent: 1, SendFailed: !successfullySent}
+	if req.IsPM {
+		finalMessage := utils.FormatFinished(&req.GenerationRequest, finished) + "\n\n"
+		finalMessage += utils.FormatBillingConfirmation("audio", s.billingEnabled.Load(), billingAttempted, billingSucceeded, chargedDCR, req.PriceUSD, finalBalanceDCR) + utils.LowBalanceWarning(s.dbManager, req.UserID.String(), finalBalanceDCR, chargedDCR, req.PriceUSD)
+		s.sender.SendMessage(ctx, req.MessageContext(), finalMessage)
+	} else {
+		gcMessage := utils.FormatFinished(&req.GenerationRequest, finished)
+		if splitCharge != nil {
+			gcMessage += "\n\n" + utils.FormatSplitPotReceipt(splitCharge)
+		}
+		s.sender.SendMessage(ctx, req.MessageContext(), gcMessage)
+		// The requester's share and balance are theirs alone
+		if splitCharge != nil {
+			s.sender.SendPrivateMessage(ctx, req.MessageContext(), utils.FormatSplitBillingConfirmation(splitCharge, req.PriceUSD)+utils.LowBalanceWarning(s.dbManager, req.UserID.String(), splitCharge.UserBalanceDCR, splitCharge.UserDCR+splitCharge.PotDCR, req.PriceUSD))
+		}
+	}
+
+	return &SpeechResult{
+		AudioURL: audioResp.AudioURL,
+		Success:  true,
+	}, nil
+}
+
+// logDelivery logs whether the audio of a job reached the user and what it
+// was charged.
+func (s *SpeechService) logDelivery(req *braibottypes.GenerationRequest, sent bool, chargedDCR float64) {
+	if sent {
+		s.jobLog(req).Infof("Sent the audio, charged %.8f DCR", chargedDCR)
+	} else {
+		s.jobLog(req).Warnf("Failed to send the audio")
+	}
+}
+
+// sendEmbeddedAudio fetches generated audio and sends it inline as an embed
+// to the PM or GC the request came from. Results too large to embed are sent
+// as a file by PM, or posted as a link in the GC the request came from, so a
+// GC request is never answered privately.
+func (s *SpeechService) sendEmbeddedAudio(ctx context.Context, req *braibottypes.GenerationRequest, audioResp *fal.AudioResponse) error {
+	if req.AssetLink && !req.IsPM && s.publisher != nil {
+		link, expires, err := s.publisher.PublishURL(ctx, audioResp.AudioURL)
+		if err == nil {
+			return s.sender.SendMessage(ctx, req.MessageContext(), utils.FormatAssetLink("Audio", link, expires))
+		}
+		s.jobLog(req).Warnf("Failed to publish audio, embedding it: %v", err)
+	}
+	resp, err := http.Get(audioResp.AudioURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch audio: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch audio: status code %d", resp.StatusCode)
+	}
+
+	audioData, err := io.ReadAll(io.LimitReader(resp.Body, maxAudioEmbedBytes+1))
+	if err != nil {
+		return fmt.Errorf("failed to read audio data: %v", err)
+	}
+	debuglog.Debugf(debuglog.Delivery, "Downloaded audio %s (%d bytes, %s) for %s", audioResp.AudioURL, len(audioData), audioResp.ContentType, req.UserNick)
+	tooLarge := len(audioData) > maxAudioEmbedBytes
+	if !req.IsPM && s.gcMaxEmbedBytes > 0 && base64.StdEncoding.EncodedLen(len(audioData)) > s.gcMaxEmbedBytes {
+		tooLarge = true
+	}
+	if tooLarge && !req.IsPM {
+		return utils.SendGCLink(ctx, s.bot, s.publisher, req.GC, "Audio", audioResp.AudioURL)
+	}
+	if tooLarge {
+		return utils.SendFileToUser(ctx, s.bot, req.UserNick, audioResp.AudioURL, "audio", audioResp.ContentType)
+	}
+
+	contentType := audioResp.ContentType
+	if contentType == "" {
+		contentType = "audio/mpeg"
+	}
+	return s.sender.SendEmbed(ctx, req.MessageContext(), req.ModelName+" audio", contentType, audioData)
+}
+
 // downloadAndSendAudio fetches audio, saves to temp file, and sends via SendFile
-func (s *SpeechService) downloadAndSendAudio(ctx context.Context, userNick string, audioURL string, modelName string) error {
+func (s *SpeechService) downloadAndSendAudio(ctx context.Context, msgCtx braibottypes.MessageContext, audioURL string, modelName string) error {
 	// Determine filename/extension (use info from response if available, else default)
 	// For now, defaulting to mp3 based on minimax default format
 	// A more robust approach would pass content_type from fal.AudioResponse
@@ -188,7 +685,7 @@ func (s *SpeechService) downloadAndSendAudio(ctx context.Context, userNick strin
 	defer func() {
 		err := os.Remove(tmpFile.Name())
 		if err != nil && !os.IsNotExist(err) {
-			fmt.Printf("WARN: Failed to remove temp audio file %s: %v\n", tmpFile.Name(), err)
+			s.log.Warnf("Failed to remove temp audio file %s: %v", tmpFile.Name(), err)
 		}
 	}()
 
@@ -204,12 +701,13 @@ func (s *SpeechService) downloadAndSendAudio(ctx context.Context, userNick strin
 	}
 
 	// Copy the downloaded data to the temp file
-	_, err = io.Copy(tmpFile, audioRespHTTP.Body)
+	n, err := io.Copy(tmpFile, audioRespHTTP.Body)
 	if err != nil {
 		// Attempt to close file before returning error
 		_ = tmpFile.Close()
 		return fmt.Errorf("failed to save audio to temp file: %v", err)
 	}
+	debuglog.Debugf(debuglog.Delivery, "Downloaded audio %s (%d bytes) for %s", audioURL, n, msgCtx.Nick)
 
 	// Close the file before sending
 	if err := tmpFile.Close(); err != nil {
@@ -217,7 +715,7 @@ func (s *SpeechService) downloadAndSendAudio(ctx context.Context, userNick strin
 	}
 
 	// Send the file to the user
-	if err := s.bot.SendFile(ctx, userNick, tmpFile.Name()); err != nil {
+	if err := s.sender.SendFile(ctx, msgCtx, tmpFile.Name()); err != nil {
 		return fmt.Errorf("failed to send audio file: %v", err)
 	}
 
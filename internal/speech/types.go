@@ -20,9 +20,42 @@ type SpeechRequest struct {
 	Channel    string
 }
 
+// CleanAudioRequest represents an internal request to isolate the voice in
+// an audio clip and strip background noise from it
+type CleanAudioRequest struct {
+	braibottypes.GenerationRequest
+	AudioURL string // http(s) URL or data URI of the source audio
+}
+
+// VoiceSwapRequest represents an internal request to say the speech of an
+// audio note again in another voice
+type VoiceSwapRequest struct {
+	braibottypes.GenerationRequest
+	AudioNote             []byte // Ogg Opus audio note as sent by the user
+	Seconds               int    // Length of the audio note, billed per second
+	Voice                 string // ElevenLabs voice to speak in
+	RemoveBackgroundNoise bool
+}
+
 // SpeechResult represents the result of a speech generation
 type SpeechResult struct {
 	AudioURL string // URL of the generated audio
 	Success  bool
 	Error    error
 }
+
+// IsSuccess checks if the speech generation was successful.
+func (r *SpeechResult) IsSuccess() bool {
+	if r == nil {
+		return false
+	}
+	return r.Success
+}
+
+// GetError returns the error from the speech generation, if any.
+func (r *SpeechResult) GetError() error {
+	if r == nil {
+		return nil
+	}
+	return r.Error
+}
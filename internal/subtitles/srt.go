@@ -0,0 +1,141 @@
+// Package subtitles times a narration over a video and writes it as SubRip
+// (.srt) subtitles, optionally burning them into the video with ffmpeg.
+package subtitles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Layout of the cues.
+const (
+	maxLineRunes = 42 // Longest subtitle line
+	maxCueLines  = 2  // Lines shown at once
+)
+
+// Cue is one subtitle shown from Start to End.
+type Cue struct {
+	Start, End time.Duration
+	Text       string // Up to maxCueLines lines
+}
+
+// Build splits text into cues and spreads them over a video of length, each
+// cue shown for a share of the time matching its share of the text, which
+// is roughly how long it takes to say it.
+func Build(text string, length time.Duration) []Cue {
+	// Each sentence starts a cue and takes as many as it fills
+	var chunks []string
+	for _, sentence := range sentences(text) {
+		lines := wrap(sentence, maxLineRunes)
+		for len(lines) > 0 {
+			n := min(len(lines), maxCueLines)
+			chunks = append(chunks, strings.Join(lines[:n], "\n"))
+			lines = lines[n:]
+		}
+	}
+	total := 0
+	for _, c := range chunks {
+		total += utf8.RuneCountInString(c)
+	}
+	if total == 0 || length <= 0 {
+		return nil
+	}
+
+	cues := make([]Cue, 0, len(chunks))
+	var start time.Duration
+	done := 0
+	for _, c := range chunks {
+		done += utf8.RuneCountInString(c)
+		// Round to milliseconds, the precision of SubRip times
+		end := (length * time.Duration(done) / time.Duration(total)).Round(time.Millisecond)
+		cues = append(cues, Cue{Start: start, End: end, Text: c})
+		start = end
+	}
+	return cues
+}
+
+// SRT renders cues in the SubRip format.
+func SRT(cues []Cue) string {
+	var b strings.Builder
+	for i, c := range cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, timestamp(c.Start), timestamp(c.End), c.Text)
+	}
+	return b.String()
+}
+
+// timestamp formats d as a SubRip time, hh:mm:ss,mmm.
+func timestamp(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// sentences splits text after sentence-ending punctuation.
+func sentences(text string) []string {
+	var out []string
+	words := strings.Fields(text)
+	start := 0
+	for i, w := range words {
+		if strings.ContainsAny(w[len(w)-1:], ".!?") || i == len(words)-1 {
+			out = append(out, strings.Join(words[start:i+1], " "))
+			start = i + 1
+		}
+	}
+	return out
+}
+
+// wrap breaks text at spaces into pieces of at most width runes. Words
+// longer than width get a piece of their own.
+func wrap(text string, width int) []string {
+	var pieces []string
+	var cur strings.Builder
+	curRunes := 0
+	for _, w := range strings.FieldsFunc(text, unicode.IsSpace) {
+		n := utf8.RuneCountInString(w)
+		if curRunes > 0 && curRunes+1+n > width {
+			pieces = append(pieces, cur.String())
+			cur.Reset()
+			curRunes = 0
+		}
+		if curRunes > 0 {
+			cur.WriteByte(' ')
+			curRunes++
+		}
+		cur.WriteString(w)
+		curRunes += n
+	}
+	if curRunes > 0 {
+		pieces = append(pieces, cur.String())
+	}
+	return pieces
+}
+
+// Burn renders the subtitles of the .srt file at srtPath into the video at
+// src and writes the result to dst, using the ffmpeg binary at ffmpegPath.
+func Burn(ctx context.Context, ffmpegPath, src, srtPath, dst string) error {
+	if ffmpegPath == "" {
+		return errors.New("ffmpeg is disabled")
+	}
+	ffmpeg, err := exec.LookPath(ffmpegPath)
+	if err != nil {
+		return fmt.Errorf("ffmpeg not found: %v", err)
+	}
+	cmd := exec.CommandContext(ctx, ffmpeg, "-y", "-loglevel", "error", "-i", src,
+		"-vf", "subtitles="+filterPath(srtPath), "-c:v", "libx264", "-preset", "veryfast", "-crf", "23",
+		"-c:a", "copy", "-movflags", "+faststart", dst)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg failed: %v: %s", err, out)
+	}
+	return nil
+}
+
+// filterPath escapes a path for use as an ffmpeg filter argument.
+func filterPath(path string) string {
+	r := strings.NewReplacer(`\`, `\\`, `:`, `\:`, `'`, `\'`, `,`, `\,`, `[`, `\[`, `]`, `\]`)
+	return r.Replace(path)
+}
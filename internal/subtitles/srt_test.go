@@ -0,0 +1,56 @@
+package subtitles
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	cues := Build("A fox runs. It jumps over the fence!", 10*time.Second)
+	if len(cues) != 2 {
+		t.Fatalf("got %d cues, want 2: %+v", len(cues), cues)
+	}
+	if cues[0].Text != "A fox runs." || cues[1].Text != "It jumps over the fence!" {
+		t.Errorf("cue texts = %q, %q", cues[0].Text, cues[1].Text)
+	}
+	// 11 of 35 characters
+	if cues[0].Start != 0 || cues[0].End != 3143*time.Millisecond || cues[1].Start != cues[0].End || cues[1].End != 10*time.Second {
+		t.Errorf("cue times = %+v", cues)
+	}
+
+	long := strings.Repeat("word ", 40)
+	for _, c := range Build(long, 20*time.Second) {
+		lines := strings.Split(c.Text, "\n")
+		if len(lines) > maxCueLines {
+			t.Errorf("cue has %d lines: %q", len(lines), c.Text)
+		}
+		for _, l := range lines {
+			if len(l) > maxLineRunes {
+				t.Errorf("line is %d runes: %q", len(l), l)
+			}
+		}
+	}
+
+	if cues := Build("   ", 5*time.Second); cues != nil {
+		t.Errorf("Build of blank text = %+v", cues)
+	}
+}
+
+func TestSRT(t *testing.T) {
+	cues := []Cue{
+		{Start: 0, End: 2500 * time.Millisecond, Text: "Hello"},
+		{Start: 2500 * time.Millisecond, End: 61*time.Minute + 5*time.Second, Text: "World\nagain"},
+	}
+	want := "1\n00:00:00,000 --> 00:00:02,500\nHello\n\n" +
+		"2\n00:00:02,500 --> 01:01:05,000\nWorld\nagain\n\n"
+	if got := SRT(cues); got != want {
+		t.Errorf("SRT = %q, want %q", got, want)
+	}
+}
+
+func TestFilterPath(t *testing.T) {
+	if got := filterPath(`C:\tmp\it's.srt`); got != `C\:\\tmp\\it\'s.srt` {
+		t.Errorf("filterPath = %s", got)
+	}
+}
@@ -0,0 +1,352 @@
+package svganim
+
+import (
+	"math"
+	"strconv"
+)
+
+// Segments used to flatten curves.
+const (
+	cubicSegments = 16
+	quadSegments  = 12
+	arcStep       = math.Pi / 16 // Angle per arc segment
+)
+
+// pathScanner reads the numbers and commands of path data.
+type pathScanner struct {
+	s   string
+	pos int
+}
+
+func (s *pathScanner) skipSeparators() {
+	for s.pos < len(s.s) {
+		switch s.s[s.pos] {
+		case ' ', '\t', '\n', '\r', ',':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// command returns the next command letter, if the next token is one.
+func (s *pathScanner) command() (byte, bool) {
+	s.skipSeparators()
+	if s.pos >= len(s.s) {
+		return 0, false
+	}
+	c := s.s[s.pos]
+	if (c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') && c != 'e' && c != 'E' {
+		s.pos++
+		return c, true
+	}
+	return 0, false
+}
+
+// number reads the next number. Numbers may run together, as in "1.5.5" or
+// "1-2".
+func (s *pathScanner) number() (float64, bool) {
+	s.skipSeparators()
+	start := s.pos
+	i := s.pos
+	if i < len(s.s) && (s.s[i] == '+' || s.s[i] == '-') {
+		i++
+	}
+	digits, dot := false, false
+	for i < len(s.s) {
+		c := s.s[i]
+		if c >= '0' && c <= '9' {
+			digits = true
+		} else if c == '.' && !dot {
+			dot = true
+		} else {
+			break
+		}
+		i++
+	}
+	if !digits {
+		return 0, false
+	}
+	if i < len(s.s) && (s.s[i] == 'e' || s.s[i] == 'E') {
+		j := i + 1
+		if j < len(s.s) && (s.s[j] == '+' || s.s[j] == '-') {
+			j++
+		}
+		if j < len(s.s) && s.s[j] >= '0' && s.s[j] <= '9' {
+			for j < len(s.s) && s.s[j] >= '0' && s.s[j] <= '9' {
+				j++
+			}
+			i = j
+		}
+	}
+	f, err := strconv.ParseFloat(s.s[start:i], 64)
+	if err != nil {
+		return 0, false
+	}
+	s.pos = i
+	return f, true
+}
+
+// flag reads an arc flag, which may be written without a separator.
+func (s *pathScanner) flag() (bool, bool) {
+	s.skipSeparators()
+	if s.pos < len(s.s) && (s.s[s.pos] == '0' || s.s[s.pos] == '1') {
+		s.pos++
+		return s.s[s.pos-1] == '1', true
+	}
+	return false, false
+}
+
+// numbers reads n numbers, reporting false if fewer are available.
+func (s *pathScanner) numbers(n int) ([]float64, bool) {
+	out := make([]float64, n)
+	for i := range out {
+		v, ok := s.number()
+		if !ok {
+			return nil, false
+		}
+		out[i] = v
+	}
+	return out, true
+}
+
+// parsePath flattens path data into polylines. Parsing stops at the first
+// malformed command, keeping what was read so far, as browsers do.
+func parsePath(d string) []subpath {
+	s := &pathScanner{s: d}
+	var subs []subpath
+	var cur subpath
+	var pos, start, ctrl point // ctrl is the last control point, for S and T
+	var prevCmd byte
+
+	flush := func() {
+		if len(cur.pts) > 1 {
+			subs = append(subs, cur)
+		}
+		cur = subpath{}
+	}
+	lineTo := func(p point) {
+		if len(cur.pts) == 0 {
+			cur.pts = append(cur.pts, pos)
+		}
+		cur.pts = append(cur.pts, p)
+		pos = p
+	}
+
+	cmd, ok := s.command()
+	if !ok {
+		return nil
+	}
+	for {
+		rel := cmd >= 'a'
+		upper := cmd &^ 0x20
+		off := func(x, y float64) point {
+			if rel {
+				return point{pos.X + x, pos.Y + y}
+			}
+			return point{x, y}
+		}
+
+		switch upper {
+		case 'Z':
+			if len(cur.pts) > 1 {
+				cur.closed = true
+			}
+			flush()
+			pos = start
+		case 'M':
+			n, ok := s.numbers(2)
+			if !ok {
+				return append(subs, finish(cur)...)
+			}
+			flush()
+			pos = off(n[0], n[1])
+			start = pos
+		case 'L':
+			n, ok := s.numbers(2)
+			if !ok {
+				return append(subs, finish(cur)...)
+			}
+			lineTo(off(n[0], n[1]))
+		case 'H':
+			n, ok := s.numbers(1)
+			if !ok {
+				return append(subs, finish(cur)...)
+			}
+			x := n[0]
+			if rel {
+				x += pos.X
+			}
+			lineTo(point{x, pos.Y})
+		case 'V':
+			n, ok := s.numbers(1)
+			if !ok {
+				return append(subs, finish(cur)...)
+			}
+			y := n[0]
+			if rel {
+				y += pos.Y
+			}
+			lineTo(point{pos.X, y})
+		case 'C', 'S':
+			var c1 point
+			var rest []float64
+			if upper == 'C' {
+				n, ok := s.numbers(6)
+				if !ok {
+					return append(subs, finish(cur)...)
+				}
+				c1, rest = off(n[0], n[1]), n[2:]
+			} else {
+				n, ok := s.numbers(4)
+				if !ok {
+					return append(subs, finish(cur)...)
+				}
+				c1 = pos
+				if prevCmd == 'C' || prevCmd == 'S' {
+					c1 = point{2*pos.X - ctrl.X, 2*pos.Y - ctrl.Y}
+				}
+				rest = n
+			}
+			c2, end := off(rest[0], rest[1]), off(rest[2], rest[3])
+			p0 := pos
+			for i := 1; i <= cubicSegments; i++ {
+				t := float64(i) / cubicSegments
+				mt := 1 - t
+				lineTo(point{
+					mt*mt*mt*p0.X + 3*mt*mt*t*c1.X + 3*mt*t*t*c2.X + t*t*t*end.X,
+					mt*mt*mt*p0.Y + 3*mt*mt*t*c1.Y + 3*mt*t*t*c2.Y + t*t*t*end.Y,
+				})
+			}
+			ctrl = c2
+		case 'Q', 'T':
+			var c point
+			var end point
+			if upper == 'Q' {
+				n, ok := s.numbers(4)
+				if !ok {
+					return append(subs, finish(cur)...)
+				}
+				c, end = off(n[0], n[1]), off(n[2], n[3])
+			} else {
+				n, ok := s.numbers(2)
+				if !ok {
+					return append(subs, finish(cur)...)
+				}
+				c = pos
+				if prevCmd == 'Q' || prevCmd == 'T' {
+					c = point{2*pos.X - ctrl.X, 2*pos.Y - ctrl.Y}
+				}
+				end = off(n[0], n[1])
+			}
+			p0 := pos
+			for i := 1; i <= quadSegments; i++ {
+				t := float64(i) / quadSegments
+				mt := 1 - t
+				lineTo(point{
+					mt*mt*p0.X + 2*mt*t*c.X + t*t*end.X,
+					mt*mt*p0.Y + 2*mt*t*c.Y + t*t*end.Y,
+				})
+			}
+			ctrl = c
+		case 'A':
+			r, ok := s.numbers(3)
+			if !ok {
+				return append(subs, finish(cur)...)
+			}
+			large, ok1 := s.flag()
+			sweep, ok2 := s.flag()
+			n, ok3 := s.numbers(2)
+			if !ok1 || !ok2 || !ok3 {
+				return append(subs, finish(cur)...)
+			}
+			end := off(n[0], n[1])
+			for _, p := range arcPoints(pos, end, r[0], r[1], r[2], large, sweep) {
+				lineTo(p)
+			}
+		default:
+			return append(subs, finish(cur)...)
+		}
+		prevCmd = upper
+		if upper == 'M' {
+			// Further coordinate pairs are implicit line-tos
+			cmd = 'L' | cmd&0x20
+		}
+
+		// A command's parameters may repeat without repeating the letter
+		if next, isCmd := s.command(); isCmd {
+			cmd = next
+		} else if upper == 'Z' || !s.hasNumber() {
+			break
+		}
+	}
+	return append(subs, finish(cur)...)
+}
+
+// hasNumber reports whether a number follows, without consuming it.
+func (s *pathScanner) hasNumber() bool {
+	saved := s.pos
+	_, ok := s.number()
+	s.pos = saved
+	return ok
+}
+
+func finish(sp subpath) []subpath {
+	if len(sp.pts) > 1 {
+		return []subpath{sp}
+	}
+	return nil
+}
+
+// arcPoints flattens an elliptical arc from p0 to p1, following the
+// endpoint to center conversion of the SVG specification. The start point
+// is not included.
+func arcPoints(p0, p1 point, rx, ry, angle float64, large, sweep bool) []point {
+	if p0 == p1 {
+		return nil
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		return []point{p1}
+	}
+	phi := angle * math.Pi / 180
+	cos, sin := math.Cos(phi), math.Sin(phi)
+	dx, dy := (p0.X-p1.X)/2, (p0.Y-p1.Y)/2
+	x1 := cos*dx + sin*dy
+	y1 := -sin*dx + cos*dy
+
+	// Scale radii up when they cannot span the endpoints
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cx1, cy1 := coef*rx*y1/ry, -coef*ry*x1/rx
+	cx := cos*cx1 - sin*cy1 + (p0.X+p1.X)/2
+	cy := sin*cx1 + cos*cy1 + (p0.Y+p1.Y)/2
+
+	vecAngle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := vecAngle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := vecAngle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	n := max(1, int(math.Ceil(math.Abs(delta)/arcStep)))
+	pts := make([]point, 0, n)
+	for i := 1; i < n; i++ {
+		t := theta + delta*float64(i)/float64(n)
+		x, y := rx*math.Cos(t), ry*math.Sin(t)
+		pts = append(pts, point{cos*x - sin*y + cx, sin*x + cos*y + cy})
+	}
+	// End exactly on the endpoint to avoid gaps
+	return append(pts, p1)
+}
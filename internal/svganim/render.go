@@ -0,0 +1,326 @@
+package svganim
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// Animation timing, in GIF delay units of 10ms.
+const (
+	drawFrames = 24  // Frames tracing the outlines
+	fadeFrames = 8   // Frames fading the fills in
+	drawDelay  = 8   // Delay of each draw-on frame
+	fadeDelay  = 6   // Delay of each fade frame
+	holdDelay  = 250 // How long the finished artwork stays up before looping
+)
+
+// DefaultSize is the default length of the longer side of the animation, in
+// pixels.
+const DefaultSize = 480
+
+// Animate renders an SVG document as a looping draw-on GIF whose longer
+// side is size pixels.
+func Animate(svg []byte, size int) ([]byte, error) {
+	d, err := Parse(svg)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, d.GIF(size)); err != nil {
+		return nil, fmt.Errorf("failed to encode gif: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pixelShape is a shape in pixel space.
+type pixelShape struct {
+	subpaths    []subpath
+	fill        color.NRGBA
+	stroke      color.NRGBA
+	strokeWidth float64
+}
+
+// GIF renders the drawing's frames: outlines are traced first, in document
+// order, then the fills fade in while outlines of unstroked shapes fade out,
+// so the last frame is the artwork itself.
+func (d *Drawing) GIF(size int) *gif.GIF {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	vb := d.viewBox
+	scale := float64(size) / math.Max(vb[2], vb[3])
+	w := max(1, int(math.Round(vb[2]*scale)))
+	h := max(1, int(math.Round(vb[3]*scale)))
+
+	shapes := make([]pixelShape, len(d.shapes))
+	total := 0.0
+	for i, sh := range d.shapes {
+		ps := pixelShape{fill: sh.fill, stroke: sh.stroke, strokeWidth: math.Max(1, sh.strokeWidth*scale)}
+		for _, sp := range sh.subpaths {
+			pts := make([]point, len(sp.pts))
+			for j, p := range sp.pts {
+				pts[j] = point{(p.X - vb[0]) * scale, (p.Y - vb[1]) * scale}
+			}
+			if sp.closed {
+				pts = append(pts, pts[0])
+			}
+			ps.subpaths = append(ps.subpaths, subpath{pts: pts, closed: sp.closed})
+			total += length(pts)
+		}
+		shapes[i] = ps
+	}
+
+	r := &renderer{
+		bounds:  image.Rect(0, 0, w, h),
+		outline: math.Max(1.5, float64(size)/160),
+		pal:     gifPalette(d.shapes),
+		index:   make(map[uint32]uint8),
+	}
+	anim := &gif.GIF{}
+	for i := 1; i <= drawFrames; i++ {
+		anim.Image = append(anim.Image, r.frame(shapes, total*float64(i)/drawFrames, 0))
+		anim.Delay = append(anim.Delay, drawDelay)
+	}
+	for i := 1; i <= fadeFrames; i++ {
+		anim.Image = append(anim.Image, r.frame(shapes, math.Inf(1), float64(i)/fadeFrames))
+		anim.Delay = append(anim.Delay, fadeDelay)
+	}
+	anim.Delay[len(anim.Delay)-1] = holdDelay
+	return anim
+}
+
+// renderer draws frames.
+type renderer struct {
+	bounds  image.Rectangle
+	outline float64 // Width of the traced outline of unstroked shapes
+	pal     color.Palette
+	raster  vector.Rasterizer
+	prev    *image.RGBA      // Previous frame, to encode only what changed
+	index   map[uint32]uint8 // Cache of palette lookups
+}
+
+// frame draws the outlines up to budget pixels of length and the fills at
+// the fade opacity.
+func (r *renderer) frame(shapes []pixelShape, budget, fade float64) *image.Paletted {
+	dst := image.NewRGBA(r.bounds)
+	draw.Draw(dst, r.bounds, image.White, image.Point{}, draw.Src)
+
+	for _, sh := range shapes {
+		if sh.fill.A > 0 && fade > 0 {
+			r.fill(dst, sh.subpaths, withOpacity(sh.fill, fade))
+		}
+
+		stroke, width := sh.stroke, sh.strokeWidth
+		if stroke.A == 0 {
+			stroke, width = withOpacity(sh.fill, 1-fade), r.outline
+		}
+		var visible []subpath
+		for _, sp := range sh.subpaths {
+			if budget <= 0 {
+				break
+			}
+			pts, used := truncate(sp.pts, budget)
+			budget -= used
+			visible = append(visible, subpath{pts: pts})
+		}
+		if stroke.A > 0 && len(visible) > 0 {
+			r.stroke(dst, visible, stroke, width)
+		}
+	}
+
+	return r.quantize(dst)
+}
+
+// quantize converts a frame to the palette. After the first frame only the
+// region that changed is kept; the GIF draws it over the previous frame.
+func (r *renderer) quantize(dst *image.RGBA) *image.Paletted {
+	rect := r.bounds
+	if r.prev != nil {
+		rect = changed(r.prev, dst)
+		if rect.Empty() {
+			rect = image.Rect(0, 0, 1, 1)
+		}
+	}
+	r.prev = dst
+
+	img := image.NewPaletted(rect, r.pal)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			i := dst.PixOffset(x, y)
+			p := dst.Pix[i : i+3 : i+3]
+			key := uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+			idx, ok := r.index[key]
+			if !ok {
+				idx = uint8(r.pal.Index(color.RGBA{p[0], p[1], p[2], 0xff}))
+				r.index[key] = idx
+			}
+			img.SetColorIndex(x, y, idx)
+		}
+	}
+	return img
+}
+
+// changed returns the bounds of the pixels that differ between two frames.
+func changed(a, b *image.RGBA) image.Rectangle {
+	var rect image.Rectangle
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row := a.PixOffset(bounds.Min.X, y)
+		end := row + bounds.Dx()*4
+		if bytes.Equal(a.Pix[row:end], b.Pix[row:end]) {
+			continue
+		}
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			i := a.PixOffset(x, y)
+			if !bytes.Equal(a.Pix[i:i+4], b.Pix[i:i+4]) {
+				rect = rect.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return rect
+}
+
+// fill paints the area enclosed by the subpaths.
+func (r *renderer) fill(dst *image.RGBA, subs []subpath, c color.NRGBA) {
+	box, ok := r.box(subs, 0)
+	if !ok {
+		return
+	}
+	r.raster.Reset(box.Dx(), box.Dy())
+	for _, sp := range subs {
+		r.polygon(box, sp.pts)
+	}
+	r.raster.Draw(dst, box, image.NewUniform(c), image.Point{})
+}
+
+// stroke paints the subpaths as lines with round joins and caps. Every
+// segment and joint is added with the same winding, so the overlapping
+// pieces merge instead of cancelling out.
+func (r *renderer) stroke(dst *image.RGBA, subs []subpath, c color.NRGBA, width float64) {
+	half := width / 2
+	box, ok := r.box(subs, half+1)
+	if !ok {
+		return
+	}
+	r.raster.Reset(box.Dx(), box.Dy())
+	for _, sp := range subs {
+		for i := 1; i < len(sp.pts); i++ {
+			p, q := sp.pts[i-1], sp.pts[i]
+			l := math.Hypot(q.X-p.X, q.Y-p.Y)
+			if l == 0 {
+				continue
+			}
+			nx, ny := -(q.Y-p.Y)/l*half, (q.X-p.X)/l*half
+			r.polygon(box, []point{{p.X + nx, p.Y + ny}, {q.X + nx, q.Y + ny}, {q.X - nx, q.Y - ny}, {p.X - nx, p.Y - ny}})
+		}
+		if half >= 1 {
+			for _, p := range sp.pts {
+				r.polygon(box, circle(p, half))
+			}
+		}
+	}
+	r.raster.Draw(dst, box, image.NewUniform(c), image.Point{})
+}
+
+// polygon adds a closed polygon to the rasterizer, relative to box.
+func (r *renderer) polygon(box image.Rectangle, pts []point) {
+	if len(pts) < 2 {
+		return
+	}
+	ox, oy := float64(box.Min.X), float64(box.Min.Y)
+	r.raster.MoveTo(float32(pts[0].X-ox), float32(pts[0].Y-oy))
+	for _, p := range pts[1:] {
+		r.raster.LineTo(float32(p.X-ox), float32(p.Y-oy))
+	}
+	r.raster.ClosePath()
+}
+
+// box returns the pixel bounds of the subpaths grown by pad, clipped to the
+// frame.
+func (r *renderer) box(subs []subpath, pad float64) (image.Rectangle, bool) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, sp := range subs {
+		for _, p := range sp.pts {
+			minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+			minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+		}
+	}
+	if math.IsInf(minX, 0) {
+		return image.Rectangle{}, false
+	}
+	box := image.Rect(int(math.Floor(minX-pad)), int(math.Floor(minY-pad)),
+		int(math.Ceil(maxX+pad))+1, int(math.Ceil(maxY+pad))+1).Intersect(r.bounds)
+	return box, !box.Empty()
+}
+
+// circle returns a polygon approximating a circle, wound like the stroke
+// segments.
+func circle(c point, radius float64) []point {
+	const n = 12
+	pts := make([]point, n)
+	for i := range pts {
+		t := -2 * math.Pi * float64(i) / n
+		pts[i] = point{c.X + radius*math.Cos(t), c.Y + radius*math.Sin(t)}
+	}
+	return pts
+}
+
+// length returns the length of a polyline.
+func length(pts []point) float64 {
+	l := 0.0
+	for i := 1; i < len(pts); i++ {
+		l += math.Hypot(pts[i].X-pts[i-1].X, pts[i].Y-pts[i-1].Y)
+	}
+	return l
+}
+
+// truncate returns the start of a polyline that is at most budget long, and
+// the length used.
+func truncate(pts []point, budget float64) ([]point, float64) {
+	used := 0.0
+	for i := 1; i < len(pts); i++ {
+		seg := math.Hypot(pts[i].X-pts[i-1].X, pts[i].Y-pts[i-1].Y)
+		if used+seg > budget {
+			t := (budget - used) / seg
+			end := point{pts[i-1].X + (pts[i].X-pts[i-1].X)*t, pts[i-1].Y + (pts[i].Y-pts[i-1].Y)*t}
+			return append(pts[:i:i], end), budget
+		}
+		used += seg
+	}
+	return pts, used
+}
+
+// gifPalette keeps the artwork's own colors exact and fills the rest of the
+// palette with a general one for antialiased edges.
+func gifPalette(shapes []shape) color.Palette {
+	pal := color.Palette{color.White}
+	seen := map[color.RGBA]bool{{0xff, 0xff, 0xff, 0xff}: true}
+	add := func(c color.Color) {
+		r, g, b, _ := c.RGBA()
+		rgba := color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 0xff}
+		if len(pal) < 256 && !seen[rgba] {
+			seen[rgba] = true
+			pal = append(pal, rgba)
+		}
+	}
+	for _, sh := range shapes {
+		for _, c := range []color.NRGBA{sh.fill, sh.stroke} {
+			if c.A == 0xff && len(pal) < 128 {
+				add(c)
+			}
+		}
+	}
+	for _, c := range palette.Plan9 {
+		add(c)
+	}
+	return pal
+}
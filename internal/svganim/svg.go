@@ -0,0 +1,408 @@
+// Package svganim turns vector artwork, such as the SVG logos star-vector
+// generates, into a short draw-on animation: the outlines are traced in
+// document order, then the fills fade in. Rendering is done locally, so the
+// animation costs no provider credits.
+package svganim
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxPoints caps the flattened points of a drawing, so hostile input cannot
+// make rendering arbitrarily slow.
+const maxPoints = 200_000
+
+type point struct{ X, Y float64 }
+
+// matrix is an affine transform: x' = a*x + c*y + e, y' = b*x + d*y + f.
+type matrix struct{ a, b, c, d, e, f float64 }
+
+var identity = matrix{a: 1, d: 1}
+
+func (m matrix) apply(p point) point {
+	return point{m.a*p.X + m.c*p.Y + m.e, m.b*p.X + m.d*p.Y + m.f}
+}
+
+// mul returns m applied after n.
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		a: m.a*n.a + m.c*n.b,
+		b: m.b*n.a + m.d*n.b,
+		c: m.a*n.c + m.c*n.d,
+		d: m.b*n.c + m.d*n.d,
+		e: m.a*n.e + m.c*n.f + m.e,
+		f: m.b*n.e + m.d*n.f + m.f,
+	}
+}
+
+// scale is the transform's average linear scale, used for stroke widths.
+func (m matrix) scale() float64 {
+	return math.Sqrt(math.Abs(m.a*m.d - m.b*m.c))
+}
+
+// subpath is a flattened polyline.
+type subpath struct {
+	pts    []point
+	closed bool
+}
+
+// shape is a drawable element with its paint.
+type shape struct {
+	subpaths    []subpath
+	fill        color.NRGBA // Alpha 0 means no fill
+	stroke      color.NRGBA // Alpha 0 means no stroke
+	strokeWidth float64     // In document units
+}
+
+// Drawing is a parsed SVG document.
+type Drawing struct {
+	viewBox [4]float64 // min x, min y, width, height
+	shapes  []shape
+}
+
+// style is the inherited paint state.
+type style struct {
+	fill, stroke color.NRGBA
+	strokeWidth  float64
+	opacity      float64
+	transform    matrix
+}
+
+// Parse reads an SVG document. Paths, basic shapes and groups with
+// transforms are supported; text, images, gradients and filters are not.
+func Parse(data []byte) (*Drawing, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+
+	d := &Drawing{}
+	stack := []style{{fill: color.NRGBA{A: 0xff}, strokeWidth: 1, opacity: 1, transform: identity}}
+	skip := 0 // Depth inside elements whose content is not drawn
+	sawRoot := false
+	npoints := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("invalid svg: %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 {
+				skip++
+				continue
+			}
+			attrs := attrMap(t.Attr)
+			name := t.Name.Local
+			switch name {
+			case "defs", "clipPath", "mask", "pattern", "symbol", "style", "title", "desc", "metadata", "text", "linearGradient", "radialGradient", "filter", "marker":
+				skip = 1
+				continue
+			}
+			st := inherit(stack[len(stack)-1], attrs)
+			stack = append(stack, st)
+
+			if name == "svg" && !sawRoot {
+				sawRoot = true
+				d.viewBox = rootViewBox(attrs)
+				continue
+			}
+			subs := elementSubpaths(name, attrs)
+			if len(subs) == 0 {
+				continue
+			}
+			for i := range subs {
+				for j, p := range subs[i].pts {
+					subs[i].pts[j] = st.transform.apply(p)
+				}
+				npoints += len(subs[i].pts)
+			}
+			if npoints > maxPoints {
+				return nil, fmt.Errorf("svg too complex (more than %d points)", maxPoints)
+			}
+			sh := shape{
+				subpaths:    subs,
+				fill:        withOpacity(st.fill, st.opacity),
+				stroke:      withOpacity(st.stroke, st.opacity),
+				strokeWidth: st.strokeWidth * st.transform.scale(),
+			}
+			if name == "line" || name == "polyline" {
+				sh.fill = color.NRGBA{}
+			}
+			d.shapes = append(d.shapes, sh)
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	if !sawRoot {
+		return nil, fmt.Errorf("not an svg document")
+	}
+	if len(d.shapes) == 0 {
+		return nil, fmt.Errorf("svg has no drawable shapes")
+	}
+	if d.viewBox[2] <= 0 || d.viewBox[3] <= 0 {
+		d.viewBox = d.bounds()
+	}
+	return d, nil
+}
+
+// bounds returns the bounding box of every point as a view box.
+func (d *Drawing) bounds() [4]float64 {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, sh := range d.shapes {
+		for _, sp := range sh.subpaths {
+			for _, p := range sp.pts {
+				minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+				minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+			}
+		}
+	}
+	return [4]float64{minX, minY, math.Max(maxX-minX, 1), math.Max(maxY-minY, 1)}
+}
+
+func attrMap(attrs []xml.Attr) map[string]string {
+	m := make(map[string]string, len(attrs))
+	for _, a := range attrs {
+		m[a.Name.Local] = a.Value
+	}
+	// Inline style declarations override presentation attributes
+	for _, decl := range strings.Split(m["style"], ";") {
+		if k, v, ok := strings.Cut(decl, ":"); ok {
+			m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return m
+}
+
+// inherit applies an element's paint attributes to its parent's style.
+func inherit(st style, attrs map[string]string) style {
+	if v, ok := attrs["fill"]; ok {
+		st.fill = parseColor(v, st.fill)
+	}
+	if v, ok := attrs["stroke"]; ok {
+		st.stroke = parseColor(v, st.stroke)
+	}
+	if v, err := parseLength(attrs["fill-opacity"]); err == nil {
+		st.fill = withOpacity(st.fill, v)
+	}
+	if v, err := parseLength(attrs["stroke-opacity"]); err == nil {
+		st.stroke = withOpacity(st.stroke, v)
+	}
+	if v, err := parseLength(attrs["opacity"]); err == nil {
+		st.opacity *= math.Max(0, math.Min(1, v))
+	}
+	if v, err := parseLength(attrs["stroke-width"]); err == nil && v >= 0 {
+		st.strokeWidth = v
+	}
+	if v := attrs["transform"]; v != "" {
+		st.transform = st.transform.mul(parseTransform(v))
+	}
+	return st
+}
+
+func withOpacity(c color.NRGBA, opacity float64) color.NRGBA {
+	c.A = uint8(math.Round(float64(c.A) * math.Max(0, math.Min(1, opacity))))
+	return c
+}
+
+func rootViewBox(attrs map[string]string) [4]float64 {
+	var vb [4]float64
+	if nums := parseNumbers(attrs["viewBox"]); len(nums) == 4 {
+		copy(vb[:], nums)
+		return vb
+	}
+	w, _ := parseLength(attrs["width"])
+	h, _ := parseLength(attrs["height"])
+	return [4]float64{0, 0, w, h}
+}
+
+// elementSubpaths converts a shape element to polylines.
+func elementSubpaths(name string, a map[string]string) []subpath {
+	num := func(k string) float64 {
+		v, _ := parseLength(a[k])
+		return v
+	}
+	switch name {
+	case "path":
+		return parsePath(a["d"])
+	case "rect":
+		x, y, w, h := num("x"), num("y"), num("width"), num("height")
+		if w <= 0 || h <= 0 {
+			return nil
+		}
+		return []subpath{{pts: []point{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}, closed: true}}
+	case "circle":
+		r := num("r")
+		return ellipse(num("cx"), num("cy"), r, r)
+	case "ellipse":
+		return ellipse(num("cx"), num("cy"), num("rx"), num("ry"))
+	case "line":
+		return []subpath{{pts: []point{{num("x1"), num("y1")}, {num("x2"), num("y2")}}}}
+	case "polyline", "polygon":
+		nums := parseNumbers(a["points"])
+		var pts []point
+		for i := 0; i+1 < len(nums); i += 2 {
+			pts = append(pts, point{nums[i], nums[i+1]})
+		}
+		if len(pts) < 2 {
+			return nil
+		}
+		return []subpath{{pts: pts, closed: name == "polygon"}}
+	}
+	return nil
+}
+
+func ellipse(cx, cy, rx, ry float64) []subpath {
+	if rx <= 0 || ry <= 0 {
+		return nil
+	}
+	const n = 48
+	pts := make([]point, n)
+	for i := range pts {
+		t := 2 * math.Pi * float64(i) / n
+		pts[i] = point{cx + rx*math.Cos(t), cy + ry*math.Sin(t)}
+	}
+	return []subpath{{pts: pts, closed: true}}
+}
+
+// namedColors are the color keywords generated artwork commonly uses.
+var namedColors = map[string]color.NRGBA{
+	"black":  {0, 0, 0, 0xff},
+	"white":  {0xff, 0xff, 0xff, 0xff},
+	"red":    {0xff, 0, 0, 0xff},
+	"green":  {0, 0x80, 0, 0xff},
+	"blue":   {0, 0, 0xff, 0xff},
+	"yellow": {0xff, 0xff, 0, 0xff},
+	"orange": {0xff, 0xa5, 0, 0xff},
+	"purple": {0x80, 0, 0x80, 0xff},
+	"gray":   {0x80, 0x80, 0x80, 0xff},
+	"grey":   {0x80, 0x80, 0x80, 0xff},
+}
+
+// parseColor parses a paint value, keeping inherited for values it does
+// not understand. Gradients and patterns are drawn gray.
+func parseColor(v string, inherited color.NRGBA) color.NRGBA {
+	v = strings.ToLower(strings.TrimSpace(v))
+	switch {
+	case v == "none" || v == "transparent":
+		return color.NRGBA{}
+	case v == "" || v == "inherit" || v == "currentcolor":
+		return inherited
+	case strings.HasPrefix(v, "url("):
+		return color.NRGBA{0x80, 0x80, 0x80, 0xff}
+	case strings.HasPrefix(v, "#"):
+		hex := v[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if n, err := strconv.ParseUint(hex, 16, 32); err == nil && len(hex) == 6 {
+			return color.NRGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 0xff}
+		}
+	case strings.HasPrefix(v, "rgb"):
+		if open, close := strings.IndexByte(v, '('), strings.IndexByte(v, ')'); open >= 0 && close > open {
+			parts := strings.Split(v[open+1:close], ",")
+			if len(parts) >= 3 {
+				var c [3]uint8
+				for i := range c {
+					p := strings.TrimSpace(parts[i])
+					f, err := strconv.ParseFloat(strings.TrimSuffix(p, "%"), 64)
+					if err != nil {
+						return inherited
+					}
+					if strings.HasSuffix(p, "%") {
+						f *= 2.55
+					}
+					c[i] = uint8(math.Max(0, math.Min(255, math.Round(f))))
+				}
+				return color.NRGBA{c[0], c[1], c[2], 0xff}
+			}
+		}
+	default:
+		if c, ok := namedColors[v]; ok {
+			return c
+		}
+	}
+	return inherited
+}
+
+// parseLength parses a number, ignoring px and pt units.
+func parseLength(v string) (float64, error) {
+	v = strings.TrimSpace(v)
+	v = strings.TrimSuffix(strings.TrimSuffix(v, "px"), "pt")
+	if strings.HasSuffix(v, "%") {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+		return f / 100, err
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+// parseNumbers splits a list of numbers separated by commas or whitespace.
+func parseNumbers(v string) []float64 {
+	s := &pathScanner{s: v}
+	var nums []float64
+	for {
+		n, ok := s.number()
+		if !ok {
+			return nums
+		}
+		nums = append(nums, n)
+	}
+}
+
+// parseTransform parses a transform list.
+func parseTransform(v string) matrix {
+	m := identity
+	for {
+		open := strings.IndexByte(v, '(')
+		close := strings.IndexByte(v, ')')
+		if open < 0 || close < open {
+			return m
+		}
+		name := strings.TrimSpace(strings.Trim(v[:open], ", \t\n"))
+		args := parseNumbers(v[open+1 : close])
+		v = v[close+1:]
+
+		t := identity
+		switch {
+		case name == "matrix" && len(args) == 6:
+			t = matrix{args[0], args[1], args[2], args[3], args[4], args[5]}
+		case name == "translate" && len(args) >= 1:
+			t.e = args[0]
+			if len(args) > 1 {
+				t.f = args[1]
+			}
+		case name == "scale" && len(args) >= 1:
+			t.a, t.d = args[0], args[0]
+			if len(args) > 1 {
+				t.d = args[1]
+			}
+		case name == "rotate" && len(args) >= 1:
+			r := args[0] * math.Pi / 180
+			t = matrix{a: math.Cos(r), b: math.Sin(r), c: -math.Sin(r), d: math.Cos(r)}
+			if len(args) == 3 {
+				cx, cy := args[1], args[2]
+				t = matrix{a: 1, d: 1, e: cx, f: cy}.mul(t).mul(matrix{a: 1, d: 1, e: -cx, f: -cy})
+			}
+		case name == "skewX" && len(args) == 1:
+			t.c = math.Tan(args[0] * math.Pi / 180)
+		case name == "skewY" && len(args) == 1:
+			t.b = math.Tan(args[0] * math.Pi / 180)
+		}
+		m = m.mul(t)
+	}
+}
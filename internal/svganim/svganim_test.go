@@ -0,0 +1,117 @@
+package svganim
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"math"
+	"testing"
+)
+
+const testSVG = `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 50" width="200" height="100">
+  <defs><linearGradient id="g"><stop offset="0" stop-color="#fff"/></linearGradient></defs>
+  <rect x="0" y="0" width="50" height="50" fill="#ff0000"/>
+  <g transform="translate(50 0)" style="fill:none;stroke:#0000ff;stroke-width:4">
+    <path d="M10 10 h30 v30 H10 z"/>
+  </g>
+</svg>`
+
+func TestParse(t *testing.T) {
+	d, err := Parse([]byte(testSVG))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if d.viewBox != [4]float64{0, 0, 100, 50} {
+		t.Errorf("viewBox = %v", d.viewBox)
+	}
+	if len(d.shapes) != 2 {
+		t.Fatalf("got %d shapes; want 2", len(d.shapes))
+	}
+	sq := d.shapes[1]
+	if sq.fill.A != 0 || sq.stroke != (color.NRGBA{0, 0, 0xff, 0xff}) || sq.strokeWidth != 4 {
+		t.Errorf("group style not inherited: %+v", sq)
+	}
+	if len(sq.subpaths) != 1 || !sq.subpaths[0].closed || sq.subpaths[0].pts[0] != (point{60, 10}) {
+		t.Errorf("square subpaths = %+v", sq.subpaths)
+	}
+
+	for _, bad := range []string{"", "<html></html>", `<svg viewBox="0 0 10 10"></svg>`} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		d    string
+		subs int
+		last point
+	}{
+		{"M0,0L10,0 10,10z", 1, point{10, 10}},
+		{"m0 0 10 0 0 10", 1, point{10, 10}},
+		{"M0 0 1-1.5.5.5", 1, point{0.5, 0.5}},
+		{"M0 0 C 0 10 10 10 10 0 S 20 -10 20 0", 1, point{20, 0}},
+		{"M0 0 Q 5 10 10 0 T 20 0", 1, point{20, 0}},
+		{"M0 0 A 5 5 0 0 1 10 0", 1, point{10, 0}},
+		{"M0 0 a5 5 0 1010 0", 1, point{10, 0}},
+		{"M0 0 L10 0 M20 20 L30 20", 2, point{30, 20}},
+		{"M0 0 L10 0 L oops", 1, point{10, 0}},
+	}
+	for _, tt := range tests {
+		subs := parsePath(tt.d)
+		if len(subs) != tt.subs {
+			t.Errorf("%q: got %d subpaths; want %d", tt.d, len(subs), tt.subs)
+			continue
+		}
+		pts := subs[len(subs)-1].pts
+		if got := pts[len(pts)-1]; math.Abs(got.X-tt.last.X) > 1e-9 || math.Abs(got.Y-tt.last.Y) > 1e-9 {
+			t.Errorf("%q: ends at %v; want %v", tt.d, got, tt.last)
+		}
+	}
+}
+
+func TestAnimate(t *testing.T) {
+	data, err := Animate([]byte(testSVG), 200)
+	if err != nil {
+		t.Fatalf("Animate: %v", err)
+	}
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeAll: %v", err)
+	}
+	if len(anim.Image) != drawFrames+fadeFrames {
+		t.Fatalf("got %d frames; want %d", len(anim.Image), drawFrames+fadeFrames)
+	}
+	if b := anim.Image[0].Bounds(); b.Dx() != 200 || b.Dy() != 100 {
+		t.Errorf("frame size = %v; want 200x100", b)
+	}
+
+	rgb := func(c color.Color) [3]uint32 {
+		r, g, b, _ := c.RGBA()
+		return [3]uint32{r >> 8, g >> 8, b >> 8}
+	}
+	// Later frames only hold the region that changed
+	first := anim.Image[0]
+	last := image.NewRGBA(first.Bounds())
+	for _, frame := range anim.Image {
+		draw.Draw(last, frame.Bounds(), frame, frame.Bounds().Min, draw.Src)
+	}
+	// The fill only appears once the outlines are drawn
+	if got := rgb(first.At(50, 50)); got != [3]uint32{0xff, 0xff, 0xff} {
+		t.Errorf("first frame fill = %v; want white", got)
+	}
+	if got := rgb(last.At(50, 50)); got != [3]uint32{0xff, 0, 0} {
+		t.Errorf("last frame fill = %v; want red", got)
+	}
+	// Stroke of the square's left edge at x=60 (120px)
+	if got := rgb(last.At(120, 50)); got != [3]uint32{0, 0, 0xff} {
+		t.Errorf("last frame stroke = %v; want blue", got)
+	}
+	if got := rgb(last.At(150, 50)); got != [3]uint32{0xff, 0xff, 0xff} {
+		t.Errorf("last frame inside unfilled square = %v; want white", got)
+	}
+}
@@ -0,0 +1,221 @@
+// Package templates renders the billing summaries, processing notices and
+// final confirmations of generation requests. Each message is a
+// text/template file named after the message, e.g. billing_charged.tmpl.
+// The defaults are embedded; operators change the tone or branding of a
+// message by putting a file of the same name in approot/templates.
+package templates
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Names of the messages.
+const (
+	BillingCharged  = "billing_charged"  // Charge after a delivered request
+	BillingFailed   = "billing_failed"   // Charge failed after delivering
+	BillingNone     = "billing_none"     // Nothing delivered, nothing charged
+	BillingDisabled = "billing_disabled" // Billing turned off
+	BillingSplit    = "billing_split"    // Charge shared with a GC pot, by PM
+	BillingSplitGC  = "billing_split_gc" // The pot's share, in the group chat
+	Processing      = "processing"       // Cost notice when a request starts
+	ProcessingFree  = "processing_free"  // Start notice while billing is off
+	ProcessingGC    = "processing_gc"    // Start notice in a group chat
+	Finished        = "finished"         // Result delivered, by PM
+	FinishedGC      = "finished_gc"      // Result delivered, in a group chat
+)
+
+// builtinTemplates are the default messages.
+//
+//go:embed defaults/*.tmpl
+var builtinTemplates embed.FS
+
+// Data are the values messages refer to, e.g. {{.ChargedDCR}}. Fields a
+// message has no value for are zero.
+type Data struct {
+	Task        string // What was asked for, e.g. "video"
+	Action      string // What the bot is doing, e.g. "Processing your video request"
+	ModelName   string
+	JobID       uint64 // 0 for requests not tracked as jobs
+	Nick        string
+	CostUSD     float64 // Cost of the request
+	CostDCR     float64
+	CostDetails string // How the cost adds up, e.g. " for 120 characters at $0.30 per 1000"
+	ChargedUSD  float64
+	ChargedDCR  float64
+	BalanceDCR  float64 // Balance of the requester, after the charge if any
+	Sent        int     // Results sent
+	Generated   int     // Results generated, set when several were asked for
+	SendFailed  bool    // The result could not be sent
+
+	// Charges split with a GC pot
+	SplitPercent  int // Share paid by the pot
+	UserDCR       float64
+	PotDCR        float64
+	PotBalanceDCR float64
+}
+
+// funcs are the functions messages may call besides the text/template
+// builtins.
+var funcs = template.FuncMap{
+	// dcr formats a DCR amount with all 8 decimals.
+	"dcr": func(v float64) string { return fmt.Sprintf("%.8f", v) },
+	// usd formats a USD amount in cents, or with 4 decimals when
+	// per-character pricing produced a fraction of a cent.
+	"usd": func(v float64) string {
+		if cents := v * 100; math.Abs(cents-math.Round(cents)) > 1e-9 {
+			return fmt.Sprintf("$%.4f", v)
+		}
+		return fmt.Sprintf("$%.2f", v)
+	},
+	// title capitalizes the first letter of s.
+	"title": func(s string) string {
+		if s == "" {
+			return s
+		}
+		r, n := utf8.DecodeRuneInString(s)
+		return string(unicode.ToUpper(r)) + s[n:]
+	},
+	// sub subtracts b from a, e.g. for the share the requester paid.
+	"sub": func(a, b int) int { return a - b },
+}
+
+// Set holds the messages, the defaults and the operator's overrides.
+type Set struct {
+	mu        sync.RWMutex
+	builtin   map[string]*template.Template
+	overrides map[string]*template.Template
+}
+
+// Default is the set of the bot's messages.
+var Default = mustBuiltin()
+
+// NewSet returns a set of the default messages.
+func NewSet() (*Set, error) {
+	s := &Set{
+		builtin:   make(map[string]*template.Template),
+		overrides: make(map[string]*template.Template),
+	}
+	entries, err := fs.ReadDir(builtinTemplates, "defaults")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		data, err := builtinTemplates.ReadFile(path.Join("defaults", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		t, err := parse(e.Name(), data)
+		if err != nil {
+			return nil, err
+		}
+		s.builtin[t.Name()] = t
+	}
+	return s, nil
+}
+
+func mustBuiltin() *Set {
+	s, err := NewSet()
+	if err != nil {
+		panic(fmt.Sprintf("templates: invalid default message: %v", err))
+	}
+	return s
+}
+
+// parse parses the message in data, read from the file name. A trailing
+// newline, which most editors add, is not part of the message.
+func parse(name string, data []byte) (*template.Template, error) {
+	key := strings.TrimSuffix(name, ".tmpl")
+	t, err := template.New(key).Funcs(funcs).Parse(strings.TrimRight(string(data), "\r\n"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return t, nil
+}
+
+// LoadDir replaces the messages with the .tmpl files in dir. Files must be
+// named after a message, so a misspelt file is reported instead of being
+// ignored. It returns the number of messages loaded.
+func (s *Set) LoadDir(dir string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return 0, err
+	}
+	if len(files) == 0 {
+		if _, err := os.Stat(dir); err != nil {
+			return 0, err
+		}
+	}
+	parsed := make(map[string]*template.Template, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return 0, err
+		}
+		t, err := parse(filepath.Base(file), data)
+		if err != nil {
+			return 0, err
+		}
+		if _, ok := s.builtin[t.Name()]; !ok {
+			return 0, fmt.Errorf("%s: no message is named %s; see %s", filepath.Base(file), t.Name(), strings.Join(s.Names(), ", "))
+		}
+		// Catch fields that do not exist now rather than when a user is
+		// waiting for the message
+		if err := t.Execute(&strings.Builder{}, Data{}); err != nil {
+			return 0, fmt.Errorf("%s: %v", filepath.Base(file), err)
+		}
+		parsed[t.Name()] = t
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, t := range parsed {
+		s.overrides[name] = t
+	}
+	return len(parsed), nil
+}
+
+// Names returns the names of the messages, sorted.
+func (s *Set) Names() []string {
+	names := make([]string, 0, len(s.builtin))
+	for name := range s.builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render returns the message name filled in with data. An override that
+// fails to render falls back to the default message; unknown names are
+// returned as they are.
+func (s *Set) Render(name string, data Data) string {
+	s.mu.RLock()
+	override := s.overrides[name]
+	s.mu.RUnlock()
+	for _, t := range []*template.Template{override, s.builtin[name]} {
+		if t == nil {
+			continue
+		}
+		var b strings.Builder
+		if err := t.Execute(&b, data); err == nil {
+			return b.String()
+		}
+	}
+	return name
+}
+
+// Render returns the message name of the Default set.
+func Render(name string, data Data) string {
+	return Default.Render(name, data)
+}
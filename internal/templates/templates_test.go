@@ -0,0 +1,99 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaults(t *testing.T) {
+	s, err := NewSet()
+	if err != nil {
+		t.Fatalf("NewSet: %v", err)
+	}
+	names := []string{BillingCharged, BillingFailed, BillingNone, BillingDisabled, BillingSplit, BillingSplitGC,
+		Processing, ProcessingFree, ProcessingGC, Finished, FinishedGC}
+	if got := s.Names(); len(got) != len(names) {
+		t.Fatalf("Names = %v, want %d messages", got, len(names))
+	}
+	data := Data{Task: "video", Action: "Processing", ChargedDCR: 0.5, ChargedUSD: 10, BalanceDCR: 1.25}
+	for _, name := range names {
+		if got := s.Render(name, data); got == name || strings.Contains(got, "<no value>") || strings.HasSuffix(got, "\n") {
+			t.Errorf("%s rendered %q", name, got)
+		}
+	}
+
+	want := "💰 Billing Information:\n• Charged: 0.50000000 DCR ($10.00 USD)\n• New Balance: 1.25000000 DCR"
+	if got := s.Render(BillingCharged, data); got != want {
+		t.Errorf("billing_charged = %q, want %q", got, want)
+	}
+	notice := Data{Action: "Processing your speech request", CostUSD: 0.0125, CostDCR: 0.001, BalanceDCR: 2, CostDetails: " for 50 characters at $0.25 per 1000"}
+	want = "Request cost: $0.0125 USD for 50 characters at $0.25 per 1000 (0.00100000 DCR). Your balance: 2.00000000 DCR. Processing your speech request..."
+	if got := s.Render(Processing, notice); got != want {
+		t.Errorf("processing = %q, want %q", got, want)
+	}
+	if got := s.Render(Finished, Data{Task: "image", Sent: 2, Generated: 3}); got != "Finished processing request. Sent 2 of 3 generated image(s)." {
+		t.Errorf("finished = %q", got)
+	}
+	if got := s.Render(FinishedGC, Data{Task: "video"}); got != "Video request completed." {
+		t.Errorf("finished_gc = %q", got)
+	}
+	if got := s.Render("nosuchmessage", data); got != "nosuchmessage" {
+		t.Errorf("unknown message = %q", got)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	s, err := NewSet()
+	if err != nil {
+		t.Fatalf("NewSet: %v", err)
+	}
+	if _, err := s.LoadDir(filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Fatalf("LoadDir of a missing directory = %v", err)
+	}
+
+	dir := t.TempDir()
+	write := func(name, text string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("finished.tmpl", "✨ Job #{{.JobID}} by {{.ModelName}} is ready.\n")
+	n, err := s.LoadDir(dir)
+	if err != nil || n != 1 {
+		t.Fatalf("LoadDir = %d, %v", n, err)
+	}
+	if got := s.Render(Finished, Data{JobID: 7, ModelName: "flux"}); got != "✨ Job #7 by flux is ready." {
+		t.Errorf("overridden finished = %q", got)
+	}
+	if got := s.Render(FinishedGC, Data{Task: "image"}); got != "Image request completed." {
+		t.Errorf("finished_gc = %q, want the default", got)
+	}
+
+	// A template rendering only with some values falls back to the default
+	write("billing_none.tmpl", "{{if .Nick}}{{index .Nick 5}}{{end}}")
+	if _, err := s.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+	if got := s.Render(BillingNone, Data{Nick: "bob", BalanceDCR: 1}); got != "No charge was applied. Your balance remains 1.00000000 DCR." {
+		t.Errorf("failing billing_none = %q, want the default", got)
+	}
+
+	for name, text := range map[string]string{
+		"finshed.tmpl":  "typo",
+		"finished.tmpl": "{{.ChargedEUR}}",
+		"billing.tmpl":  "{{.Unclosed",
+	} {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.LoadDir(dir); err == nil {
+			t.Errorf("LoadDir accepted %s: %q", name, text)
+		}
+	}
+	if got := s.Render(Finished, Data{JobID: 7, ModelName: "flux"}); got != "✨ Job #7 by flux is ready." {
+		t.Errorf("finished after failed loads = %q", got)
+	}
+}
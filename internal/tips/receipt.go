@@ -0,0 +1,61 @@
+package tips
+
+import (
+	"strings"
+
+	"github.com/karamble/braibot/internal/i18n"
+	"github.com/karamble/braibot/internal/money"
+	"github.com/karamble/braibot/internal/topup"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// Receipt is the PM confirming a tip.
+type Receipt struct {
+	Nick          string
+	Lang          string // Language of the receipt; see i18n.Catalog.Text
+	TipAtoms      int64
+	BonusAtoms    int64   // Promo code bonus paid out on top of the tip
+	ReferralAtoms int64   // Referral credit paid out with a first tip
+	BalanceAtoms  int64   // Balance after the tip; negative when unknown
+	DCRPriceUSD   float64 // 0 when the exchange rate is unknown
+	Topup         *topup.Invoice
+}
+
+// Format renders the receipt in its language.
+func (r Receipt) Format() string {
+	text := func(key string, args i18n.Args) string {
+		return i18n.Text(r.Lang, key, args)
+	}
+	var b strings.Builder
+	switch {
+	case r.Topup != nil:
+		b.WriteString(text("topup_received", nil))
+	case r.Nick != "":
+		b.WriteString(text("tip_thanks", i18n.Args{"Nick": utils.SanitizeUserText(r.Nick)}))
+	default:
+		b.WriteString(text("tip_thanks_anon", nil))
+	}
+	b.WriteString("\n\n")
+	if r.Topup != nil {
+		b.WriteString("• " + text("topup_requested", i18n.Args{"USD": r.Topup.USD, "DCR": money.AtomsToDCR(r.Topup.Atoms)}) + "\n")
+	}
+	b.WriteString("• " + text("tip_received", i18n.Args{"DCR": money.AtomsToDCR(r.TipAtoms)}))
+	if r.BonusAtoms > 0 {
+		b.WriteString("\n• " + text("tip_bonus", i18n.Args{"DCR": money.AtomsToDCR(r.BonusAtoms)}))
+	}
+	if r.ReferralAtoms > 0 {
+		b.WriteString("\n• " + text("tip_referral", i18n.Args{"DCR": money.AtomsToDCR(r.ReferralAtoms)}))
+	}
+	if r.BalanceAtoms >= 0 {
+		balance := money.AtomsToDCR(r.BalanceAtoms)
+		if r.DCRPriceUSD > 0 {
+			b.WriteString("\n• " + text("tip_balance_usd", i18n.Args{"DCR": balance, "USD": balance * r.DCRPriceUSD}))
+		} else {
+			b.WriteString("\n• " + text("tip_balance", i18n.Args{"DCR": balance}))
+		}
+	}
+	if r.Topup == nil {
+		b.WriteString("\n\n" + text("tip_receipts_hint", nil))
+	}
+	return b.String()
+}
@@ -0,0 +1,161 @@
+// Package tips credits the tips users send the bot to their balances and
+// answers each one with a receipt.
+package tips
+
+import (
+	"context"
+	"time"
+
+	"github.com/companyzero/bisonrelay/clientrpc/types"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/slog"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/i18n"
+	"github.com/karamble/braibot/internal/money"
+	"github.com/karamble/braibot/internal/topup"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// Bot is the part of the Bison Relay client tips are handled with.
+type Bot interface {
+	AckTipReceived(ctx context.Context, sequenceID uint64) error
+	SendPM(ctx context.Context, nick, msg string) error
+}
+
+// Journal reports tips that were credited before tips were recorded in the
+// database.
+type Journal interface {
+	Seen(sequenceID uint64) bool
+}
+
+// Handler credits received tips.
+type Handler struct {
+	db     *database.DBManager
+	bot    Bot
+	legacy Journal
+	topups *topup.Tracker
+	log    slog.Logger
+	price  func() (float64, float64, error) // DCR price in USD and BTC
+}
+
+// NewHandler returns a handler crediting tips in db. legacy may be nil.
+func NewHandler(db *database.DBManager, bot Bot, legacy Journal, topups *topup.Tracker, log slog.Logger) *Handler {
+	return &Handler{db: db, bot: bot, legacy: legacy, topups: topups, log: log, price: utils.GetDCRPrice}
+}
+
+// Handle credits one tip and sends its receipt. A tip redelivered after a
+// crash between the balance update and its acknowledgement must not credit
+// twice, so the sequence id is recorded in the same transaction as the credit
+// and the tip is only acknowledged once that transaction has committed.
+func (h *Handler) Handle(ctx context.Context, tip *types.ReceivedTip) {
+	if h.legacy != nil && h.legacy.Seen(tip.SequenceId) {
+		h.ack(ctx, tip)
+		return
+	}
+	var sender zkidentity.ShortID
+	if err := sender.FromBytes(tip.Uid); err != nil {
+		// Leave it unacknowledged; there is no one to credit
+		h.log.Errorf("Tip %d has an invalid sender id: %v", tip.SequenceId, err)
+		return
+	}
+	uid := sender.String()
+
+	credited, extras, err := h.db.CreditTip(tip.SequenceId, uid, tip.AmountMatoms)
+	if err != nil {
+		// Leave the tip unacknowledged so it is redelivered
+		h.log.Errorf("Failed to credit tip %d: %v", tip.SequenceId, err)
+		return
+	}
+	if !credited {
+		// Already credited; only the acknowledgement was lost.
+		h.log.Infof("Tip %d already credited, acknowledging", tip.SequenceId)
+		h.ack(ctx, tip)
+		return
+	}
+	if err := h.db.TouchActivity(uid, time.Now()); err != nil {
+		h.log.Warnf("Failed to record activity of %s: %v", uid, err)
+	}
+
+	nick, err := h.db.KnownNick(uid)
+	if err != nil {
+		h.log.Warnf("Failed to look up the nick of %s: %v", uid, err)
+	}
+	h.log.Infof("Tip received: %.8f DCR from %s (%s)", money.AtomsToDCR(tip.AmountMatoms), uid, nick)
+	if extras.Bonus > 0 {
+		h.log.Infof("Paid a tip bonus of %.8f DCR to %s", money.AtomsToDCR(extras.Bonus), uid)
+	}
+	if extras.Referral > 0 {
+		h.log.Infof("Paid a referral credit of %.8f DCR to %s and its referrer %s",
+			money.AtomsToDCR(extras.Referral), uid, extras.Referrer)
+	}
+	h.ack(ctx, tip)
+	if extras.Referral > 0 {
+		h.notifyReferrer(ctx, extras)
+	}
+
+	balance, err := h.db.GetBalance(uid)
+	if err != nil {
+		h.log.Warnf("Failed to get the balance of %s for the tip receipt: %v", uid, err)
+		balance = -1
+	}
+	settings := utils.LoadUserSettings(h.db, uid)
+	r := Receipt{
+		Nick:          nick,
+		Lang:          settings[utils.SettingLanguage],
+		TipAtoms:      tip.AmountMatoms,
+		BonusAtoms:    extras.Bonus,
+		ReferralAtoms: extras.Referral,
+		BalanceAtoms:  balance,
+	}
+	if usd, _, err := h.price(); err == nil {
+		r.DCRPriceUSD = usd
+	}
+
+	// A tip paying a !topup gets a receipt even from users who turned
+	// receipts off, since they asked for it
+	var msg string
+	if inv, ok := h.topups.Match(uid, tip.AmountMatoms, time.Now()); ok {
+		h.log.Infof("Tip %d paid the $%.2f top-up of %s", tip.SequenceId, inv.USD, uid)
+		r.Topup = &inv
+		msg = r.Format()
+	} else if settings[utils.SettingTipReceipts] != "off" {
+		muted, err := h.db.GetMuted(uid)
+		if err != nil {
+			h.log.Warnf("Failed to get the mute preference of %s: %v", uid, err)
+		}
+		if !muted {
+			msg = r.Format()
+		}
+	}
+	if msg == "" {
+		return
+	}
+	// Tips are private, so receipts always go to the sender's PM, addressed
+	// by id since nicks need not be unique
+	if err := h.bot.SendPM(ctx, uid, msg); err != nil {
+		h.log.Warnf("Failed to send tip receipt to %s: %v", uid, err)
+	}
+}
+
+// notifyReferrer tells a referrer that the user they referred made their first
+// tip and both were credited.
+func (h *Handler) notifyReferrer(ctx context.Context, extras database.TipExtras) {
+	muted, err := h.db.GetMuted(extras.Referrer)
+	if err != nil {
+		h.log.Warnf("Failed to get the mute preference of %s: %v", extras.Referrer, err)
+	}
+	if muted {
+		return
+	}
+	lang := utils.LoadUserSettings(h.db, extras.Referrer)[utils.SettingLanguage]
+	msg := i18n.Text(lang, "referral_paid", i18n.Args{"DCR": money.AtomsToDCR(extras.Referral)})
+	if err := h.bot.SendPM(ctx, extras.Referrer, msg); err != nil {
+		h.log.Warnf("Failed to notify referrer %s: %v", extras.Referrer, err)
+	}
+}
+
+func (h *Handler) ack(ctx context.Context, tip *types.ReceivedTip) {
+	if err := h.bot.AckTipReceived(ctx, tip.SequenceId); err != nil {
+		h.log.Warnf("Failed to acknowledge tip %d: %v", tip.SequenceId, err)
+	}
+}
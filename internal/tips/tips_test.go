@@ -0,0 +1,99 @@
+package tips
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/clientrpc/types"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/slog"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/money"
+	"github.com/karamble/braibot/internal/topup"
+	"github.com/karamble/braibot/internal/utils"
+)
+
+// fakeBot records acknowledged tips and sent PMs.
+type fakeBot struct {
+	acked []uint64
+	pms   map[string][]string
+}
+
+func (b *fakeBot) AckTipReceived(ctx context.Context, sequenceID uint64) error {
+	b.acked = append(b.acked, sequenceID)
+	return nil
+}
+
+func (b *fakeBot) SendPM(ctx context.Context, nick, msg string) error {
+	b.pms[nick] = append(b.pms[nick], msg)
+	return nil
+}
+
+func TestHandle(t *testing.T) {
+	db, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer db.Close()
+	bot := &fakeBot{pms: make(map[string][]string)}
+	topups := topup.NewTracker(time.Hour)
+	h := NewHandler(db, bot, nil, topups, slog.NewBackend(os.Stdout).Logger("TEST"))
+	h.price = func() (float64, float64, error) { return 20, 0, nil }
+
+	var sender zkidentity.ShortID
+	sender[0] = 1
+	uid := sender.String()
+	db.RememberNick(uid, "alice", time.Now())
+	tip := &types.ReceivedTip{Uid: sender.Bytes(), AmountMatoms: money.AtomsPerDCR / 10, SequenceId: 7}
+
+	h.Handle(context.Background(), tip)
+	h.Handle(context.Background(), tip)
+	if balance, _ := db.GetBalance(uid); balance != money.AtomsPerDCR/10 {
+		t.Fatalf("balance = %d, want the tip credited once", balance)
+	}
+	if len(bot.acked) != 2 {
+		t.Fatalf("acknowledged %v, want the tip and its redelivery", bot.acked)
+	}
+	if len(bot.pms[uid]) != 1 || !strings.Contains(bot.pms[uid][0], "Thank you for the tip, alice!") ||
+		!strings.Contains(bot.pms[uid][0], "New balance: 0.10000000 DCR (about $2.00 USD)") {
+		t.Fatalf("receipts sent by uid = %q", bot.pms[uid])
+	}
+
+	// Opted out users only get receipts of top-ups they asked for
+	db.SetUserSetting(uid, utils.SettingTipReceipts, "off")
+	db.SetUserSetting(uid, utils.SettingLanguage, "de")
+	tip.SequenceId = 8
+	h.Handle(context.Background(), tip)
+	if len(bot.pms[uid]) != 1 {
+		t.Fatalf("opted out user got a receipt: %q", bot.pms[uid][1:])
+	}
+	topups.Open(uid, 5, money.AtomsPerDCR/10, time.Now())
+	tip.SequenceId = 9
+	h.Handle(context.Background(), tip)
+	if len(bot.pms[uid]) != 2 || !strings.Contains(bot.pms[uid][1], "Aufladung erhalten") {
+		t.Fatalf("top-up receipts = %q", bot.pms[uid][1:])
+	}
+}
+
+func TestReceiptFormat(t *testing.T) {
+	r := Receipt{Nick: "bob", Lang: "xx", TipAtoms: money.AtomsPerDCR / 4, BalanceAtoms: money.AtomsPerDCR, DCRPriceUSD: 20}
+	got := r.Format()
+	for _, want := range []string{"Thank you for the tip, bob!", "Received: 0.25000000 DCR", "New balance: 1.00000000 DCR (about $20.00 USD)", "tip_receipts off"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("receipt lacks %q:\n%s", want, got)
+		}
+	}
+	r = Receipt{Lang: "es", TipAtoms: money.AtomsPerDCR / 4, BalanceAtoms: -1,
+		Topup: &topup.Invoice{USD: 5, Atoms: money.AtomsPerDCR / 4}}
+	got = r.Format()
+	if !strings.Contains(got, "Recarga recibida") || !strings.Contains(got, "Solicitado: $5.00 USD") || strings.Contains(got, "saldo") || strings.Contains(got, "tip_receipts") {
+		t.Errorf("top-up receipt = %q", got)
+	}
+	r = Receipt{TipAtoms: money.AtomsPerDCR, BonusAtoms: money.AtomsPerDCR / 10, BalanceAtoms: -1}
+	if got = r.Format(); !strings.Contains(got, "Promo code bonus: 0.10000000 DCR") {
+		t.Errorf("bonus receipt = %q", got)
+	}
+}
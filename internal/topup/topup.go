@@ -0,0 +1,85 @@
+// Package topup tracks the top-ups users ask for with !topup, so the tip
+// that pays one can be recognized and confirmed with a receipt.
+package topup
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTTL is how long a top-up waits for its tip.
+	DefaultTTL = time.Hour
+
+	// tolerancePercent is how far below the requested amount a tip may fall
+	// and still pay the top-up, e.g. when a wallet rounds the amount.
+	tolerancePercent = 1
+)
+
+// Invoice is a top-up a user asked for.
+type Invoice struct {
+	UID       string
+	USD       float64 // Amount asked for
+	Atoms     int64   // DCR equivalent at the rate of CreatedAt
+	CreatedAt time.Time
+}
+
+// Paid reports whether a tip of atoms pays the invoice.
+func (inv Invoice) Paid(atoms int64) bool {
+	return atoms*100 >= inv.Atoms*(100-tolerancePercent)
+}
+
+// Tracker holds the open invoice of every user. A user has at most one; a
+// new !topup replaces it.
+type Tracker struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	invoices map[string]Invoice
+}
+
+// NewTracker returns a tracker whose invoices expire after ttl.
+func NewTracker(ttl time.Duration) *Tracker {
+	return &Tracker{ttl: ttl, invoices: make(map[string]Invoice)}
+}
+
+// Default is the tracker of !topup.
+var Default = NewTracker(DefaultTTL)
+
+// TTL returns how long invoices wait for their tip.
+func (t *Tracker) TTL() time.Duration {
+	return t.ttl
+}
+
+// Open records a top-up of usd, atoms at the current rate, for uid.
+func (t *Tracker) Open(uid string, usd float64, atoms int64, now time.Time) Invoice {
+	inv := Invoice{UID: uid, USD: usd, Atoms: atoms, CreatedAt: now}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expire(now)
+	t.invoices[uid] = inv
+	return inv
+}
+
+// Match closes and returns the open invoice of uid when a tip of atoms pays
+// it. Tips that fall short leave the invoice open.
+func (t *Tracker) Match(uid string, atoms int64, now time.Time) (Invoice, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expire(now)
+	inv, ok := t.invoices[uid]
+	if !ok || !inv.Paid(atoms) {
+		return Invoice{}, false
+	}
+	delete(t.invoices, uid)
+	return inv, true
+}
+
+// expire drops the invoices older than the TTL. t.mu must be held.
+func (t *Tracker) expire(now time.Time) {
+	for uid, inv := range t.invoices {
+		if now.Sub(inv.CreatedAt) > t.ttl {
+			delete(t.invoices, uid)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package topup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	tr := NewTracker(time.Hour)
+	now := time.Now()
+
+	tr.Open("alice", 5, 1_000_000, now)
+	if _, ok := tr.Match("bob", 1_000_000, now); ok {
+		t.Fatal("a tip of another user paid alice's top-up")
+	}
+	if _, ok := tr.Match("alice", 500_000, now); ok {
+		t.Fatal("half the amount paid the top-up")
+	}
+	inv, ok := tr.Match("alice", 995_000, now.Add(time.Minute))
+	if !ok || inv.USD != 5 {
+		t.Fatalf("Match within the tolerance = %+v, %v", inv, ok)
+	}
+	if _, ok := tr.Match("alice", 1_000_000, now); ok {
+		t.Fatal("a paid top-up matched again")
+	}
+
+	// A new top-up replaces the open one and expires after the TTL
+	tr.Open("alice", 5, 1_000_000, now)
+	tr.Open("alice", 10, 2_000_000, now)
+	if _, ok := tr.Match("alice", 1_000_000, now); ok {
+		t.Fatal("the replaced top-up was paid")
+	}
+	if _, ok := tr.Match("alice", 2_000_000, now.Add(2*time.Hour)); ok {
+		t.Fatal("an expired top-up was paid")
+	}
+}
@@ -0,0 +1,103 @@
+package transcribe
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits of the audio notes kept for !transcribe in group chats.
+const (
+	gcNotesKept  = 5         // Newest notes kept per GC
+	gcNoteMaxAge = time.Hour // Notes older than this can no longer be transcribed
+)
+
+// GCNote is an audio note a member posted in a group chat.
+type GCNote struct {
+	Nick     string
+	Data     string // Base64 of the Ogg Opus audio
+	PostedAt time.Time
+}
+
+// GCNotes keeps the newest audio notes of each group chat in memory, so a
+// member can ask for the transcript of one posted before their command.
+type GCNotes struct {
+	mu     sync.Mutex
+	keep   int
+	maxAge time.Duration
+	notes  map[string][]GCNote // GC → notes, oldest first
+}
+
+// DefaultGCNotes holds the audio notes posted in GCs that allow !transcribe.
+var DefaultGCNotes = NewGCNotes(gcNotesKept, gcNoteMaxAge)
+
+// NewGCNotes returns a store keeping up to keep notes per GC for at most
+// maxAge.
+func NewGCNotes(keep int, maxAge time.Duration) *GCNotes {
+	return &GCNotes{keep: keep, maxAge: maxAge, notes: make(map[string][]GCNote)}
+}
+
+// Remember stores a note posted in gc, dropping the GC's oldest note when
+// it holds keep of them, and notes past maxAge.
+func (n *GCNotes) Remember(gc string, note GCNote) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	key := strings.ToLower(gc)
+	notes := append(n.fresh(key, note.PostedAt), note)
+	if len(notes) > n.keep {
+		notes = notes[len(notes)-n.keep:]
+	}
+	n.notes[key] = notes
+}
+
+// Latest returns the newest note posted in gc by nick, or by anyone when
+// nick is empty, that is not past maxAge at now.
+func (n *GCNotes) Latest(gc, nick string, now time.Time) (GCNote, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	notes := n.fresh(strings.ToLower(gc), now)
+	for i := len(notes) - 1; i >= 0; i-- {
+		if nick == "" || strings.EqualFold(notes[i].Nick, nick) {
+			return notes[i], true
+		}
+	}
+	return GCNote{}, false
+}
+
+// Recent returns the notes of gc not past maxAge at now, newest first.
+func (n *GCNotes) Recent(gc string, now time.Time) []GCNote {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	notes := n.fresh(strings.ToLower(gc), now)
+	recent := make([]GCNote, len(notes))
+	for i, note := range notes {
+		recent[len(notes)-1-i] = note
+	}
+	return recent
+}
+
+// Forget drops the notes of gc, e.g. when transcribing is turned off there.
+func (n *GCNotes) Forget(gc string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.notes, strings.ToLower(gc))
+}
+
+// fresh drops the notes of key past maxAge at now and returns the rest.
+// The caller must hold n.mu.
+func (n *GCNotes) fresh(key string, now time.Time) []GCNote {
+	notes := n.notes[key]
+	i := 0
+	for i < len(notes) && now.Sub(notes[i].PostedAt) > n.maxAge {
+		i++
+	}
+	if i == len(notes) {
+		delete(n.notes, key)
+		return nil
+	}
+	n.notes[key] = notes[i:]
+	return notes[i:]
+}
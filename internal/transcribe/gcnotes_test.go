@@ -0,0 +1,42 @@
+package transcribe
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGCNotes(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	n := NewGCNotes(3, time.Hour)
+	for i, nick := range []string{"alice", "bob", "alice", "carol"} {
+		n.Remember("Art", GCNote{Nick: nick, Data: string(rune('a' + i)), PostedAt: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	now := start.Add(5 * time.Minute)
+	if got, ok := n.Latest("art", "", now); !ok || got.Nick != "carol" {
+		t.Errorf("Latest = %+v, %v; want carol's note", got, ok)
+	}
+	if got, ok := n.Latest("ART", "Alice", now); !ok || got.Data != "c" {
+		t.Errorf("Latest of alice = %+v, %v; want her second note", got, ok)
+	}
+	if recent := n.Recent("art", now); len(recent) != 3 || recent[0].Nick != "carol" || recent[2].Nick != "bob" {
+		t.Errorf("Recent = %+v; want the newest 3, newest first", recent)
+	}
+	if _, ok := n.Latest("music", "", now); ok {
+		t.Error("Latest found a note in another GC")
+	}
+
+	// bob's note is past the limit an hour after it was posted
+	later := start.Add(time.Hour + 90*time.Second)
+	if _, ok := n.Latest("art", "bob", later); ok {
+		t.Error("Latest returned an expired note")
+	}
+	if recent := n.Recent("art", later); len(recent) != 2 {
+		t.Errorf("Recent after expiry = %+v", recent)
+	}
+
+	n.Forget("Art")
+	if _, ok := n.Latest("art", "", now); ok {
+		t.Error("Latest found a note after Forget")
+	}
+}
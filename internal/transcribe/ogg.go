@@ -0,0 +1,33 @@
+package transcribe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// opusSampleRate is the granule rate of Ogg Opus streams, which is always
+// 48 kHz whatever the input rate was.
+const opusSampleRate = 48000
+
+// OggOpusSeconds returns the length of an Ogg Opus stream, such as a Bison
+// Relay audio note, rounded up to a whole second. It reads the granule
+// position of the last page and reports false when there is none.
+func OggOpusSeconds(data []byte) (int, bool) {
+	for end := len(data); end > 0; {
+		i := bytes.LastIndex(data[:end], []byte("OggS"))
+		if i < 0 {
+			return 0, false
+		}
+		// Page header: capture pattern, version, header type, then the
+		// 64-bit little-endian granule position.
+		if i+14 <= len(data) {
+			granule := int64(binary.LittleEndian.Uint64(data[i+6 : i+14]))
+			if granule > 0 {
+				return int(math.Ceil(float64(granule) / opusSampleRate)), true
+			}
+		}
+		end = i
+	}
+	return 0, false
+}
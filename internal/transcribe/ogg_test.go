@@ -0,0 +1,40 @@
+package transcribe
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+// oggPage returns a bare Ogg page header with the given granule position.
+func oggPage(granule int64) []byte {
+	page := make([]byte, 27)
+	copy(page, "OggS")
+	binary.LittleEndian.PutUint64(page[6:], uint64(granule))
+	return page
+}
+
+func TestOggOpusSeconds(t *testing.T) {
+	var data []byte
+	data = append(data, oggPage(0)...) // ID header
+	data = append(data, oggPage(48000*3)...)
+	data = append(data, oggPage(48000*7+100)...)
+	data = append(data, oggPage(-1)...) // Page without a finished packet
+	if got, ok := OggOpusSeconds(data); !ok || got != 8 {
+		t.Errorf("OggOpusSeconds = %d, %v; want 8, true", got, ok)
+	}
+	if _, ok := OggOpusSeconds([]byte("not ogg")); ok {
+		t.Error("OggOpusSeconds accepted data without pages")
+	}
+}
+
+func TestTranscribedSeconds(t *testing.T) {
+	resp := &fal.ScribeV2Response{Words: []fal.ScribeWord{{End: 1.2}, {End: 4.01}, {End: 3}}}
+	if got := transcribedSeconds(resp); got != 5 {
+		t.Errorf("transcribedSeconds = %d, want 5", got)
+	}
+	if got := transcribedSeconds(&fal.ScribeV2Response{}); got != 1 {
+		t.Errorf("transcribedSeconds(empty) = %d, want 1", got)
+	}
+}
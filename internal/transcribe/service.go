@@ -0,0 +1,250 @@
+// Package transcribe turns speech in audio clips and audio notes into text.
+package transcribe
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+
+	"github.com/decred/slog"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/jobevents"
+	"github.com/karamble/braibot/internal/joblog"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/pkg/fal"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+// estimateSeconds is the audio length assumed for the balance check when
+// the length of the source audio is unknown, as for URLs.
+const estimateSeconds = 60
+
+// TranscribeRequest represents an internal request to transcribe audio
+type TranscribeRequest struct {
+	braibottypes.GenerationRequest
+	AudioURL string // http(s) URL or data URI of the source audio
+	Language string // Optional ISO 639-1 code, auto-detected when empty
+	Task     string // Optional, "transcribe" (default) or "translate"
+	Speaker  string // Nick of whoever recorded a GC member's audio note, "" for the requester's own audio
+	// EstimatedSeconds is the audio length the balance is checked against
+	// before transcribing; 0 assumes estimateSeconds. The request is
+	// billed for the transcribed length.
+	EstimatedSeconds int
+}
+
+// TranscribeResult represents the result of a transcription
+type TranscribeResult struct {
+	Text     string
+	Language string
+	Seconds  int // Billed audio length
+	Success  bool
+	Error    error
+}
+
+// IsSuccess checks if the transcription was successful.
+func (r *TranscribeResult) IsSuccess() bool {
+	if r == nil {
+		return false
+	}
+	return r.Success
+}
+
+// GetError returns the error from the transcription, if any.
+func (r *TranscribeResult) GetError() error {
+	if r == nil {
+		return nil
+	}
+	return r.Error
+}
+
+// TranscribeService handles audio transcription
+type TranscribeService struct {
+	client         *fal.Client
+	dbManager      *database.DBManager
+	bot            *kit.Bot
+	sender         *braibottypes.MessageSender
+	debug          bool
+	billingEnabled atomic.Bool // Toggled at runtime by !admin billing
+	log            slog.Logger
+}
+
+// NewTranscribeService creates a new TranscribeService
+func NewTranscribeService(client *fal.Client, dbManager *database.DBManager, bot *kit.Bot, debug bool, billingEnabled bool) *TranscribeService {
+	s := &TranscribeService{
+		client:    client,
+		dbManager: dbManager,
+		bot:       bot,
+		sender:    braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot)),
+		debug:     debug,
+		log:       joblog.New(nil, joblog.Transcribe),
+	}
+	s.billingEnabled.Store(billingEnabled)
+	return s
+}
+
+// SetBillingEnabled turns charging for transcriptions on or off.
+func (s *TranscribeService) SetBillingEnabled(enabled bool) {
+	s.billingEnabled.Store(enabled)
+}
+
+// SetLogger sets the logger the service logs its jobs to.
+func (s *TranscribeService) SetLogger(l slog.Logger) {
+	s.log = l
+}
+
+// Transcribe transcribes the request's audio, sends the transcript to the
+// PM or GC the request came from and bills the transcribed length.
+func (s *TranscribeService) Transcribe(ctx context.Context, req *TranscribeRequest) (*TranscribeResult, error) {
+	if req.AudioURL == "" {
+		err := fmt.Errorf("audio URL or audio note is required")
+		return &TranscribeResult{Success: false, Error: err}, err
+	}
+	model, ok := faladapter.GetModel(req.ModelName, "audio2text")
+	if !ok {
+		err := fmt.Errorf("model not found: %s", req.ModelName)
+		return &TranscribeResult{Success: false, Error: err}, err
+	}
+	jobevents.Default.Submit(&req.GenerationRequest)
+
+	// 1. Estimate the cost and CHECK balance if billing is enabled
+	estimate := req.EstimatedSeconds
+	if estimate <= 0 {
+		estimate = estimateSeconds
+	}
+	req.PriceUSD = faladapter.PriceFor(model, faladapter.PriceParams{Seconds: estimate})
+	var requiredDCR, currentBalanceDCR float64
+	var checkErr error
+	if s.billingEnabled.Load() {
+		requiredDCR, currentBalanceDCR, checkErr = utils.CheckRequestBalance(ctx, s.dbManager, &req.GenerationRequest, req.PriceUSD, s.debug, s.billingEnabled.Load())
+		if checkErr != nil {
+			jobevents.Default.EmitFailed(&req.GenerationRequest, checkErr)
+			return &TranscribeResult{Success: false, Error: checkErr}, checkErr
+		}
+	}
+
+	// 2. Send initial message (adjusted for billing status)
+	var infoMsg string
+	if s.billingEnabled.Load() {
+		infoMsg = fmt.Sprintf("Estimated cost: $%.3f USD (%.8f DCR) for about %d seconds of audio at $%.3f per second. Your balance: %.8f DCR. Transcribing...",
+			req.PriceUSD, requiredDCR, estimate, model.PriceUSD, currentBalanceDCR)
+	} else {
+		infoMsg = "Transcribing your audio (billing disabled)..."
+	}
+	if req.IsPM {
+		s.sender.SendMessage(ctx, req.MessageContext(), infoMsg)
+	} else {
+		s.sender.SendMessage(ctx, req.MessageContext(), "Transcribing your audio...")
+	}
+
+	// Wait for a free slot for this kind of job
+	release, slotErr := utils.AcquireJobSlot(ctx, s.bot, &req.GenerationRequest)
+	if slotErr != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, slotErr)
+		return &TranscribeResult{Success: false, Error: slotErr}, slotErr
+	}
+	defer release()
+
+	// 3. Run the transcription model
+	result, genErr := s.Run(ctx, req)
+	if genErr != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, genErr)
+		return result, genErr
+	}
+
+	// 4. Send the transcript, priced by the length of the transcribed audio
+	seconds := result.Seconds
+	req.PriceUSD = faladapter.PriceFor(model, faladapter.PriceParams{Seconds: seconds})
+	s.sendTranscript(ctx, req, result)
+	jobevents.Default.EmitDelivered(&req.GenerationRequest, 1)
+
+	// 5. Perform Billing *only if* enabled
+	var chargedDCR float64
+	var finalBalanceDCR float64 = currentBalanceDCR
+	var billingSucceeded bool
+	var splitCharge *utils.SplitCharge
+	if s.billingEnabled.Load() {
+		deductChargedDCR, deductNewBalance, deductSplit, deductErr := utils.DeductRequestBalance(ctx, s.dbManager, &req.GenerationRequest, req.PriceUSD, s.debug, s.billingEnabled.Load())
+		if deductErr != nil {
+			if req.IsPM {
+				s.sender.SendMessage(ctx, req.MessageContext(), fmt.Sprintf("Error processing payment after sending the transcript: %v. Please contact support.", deductErr))
+			}
+		} else {
+			billingSucceeded = true
+			chargedDCR = deductChargedDCR
+			finalBalanceDCR = deductNewBalance
+			splitCharge = deductSplit
+			jobevents.Default.EmitBilled(&req.GenerationRequest, chargedDCR)
+		}
+	}
+
+	joblog.For(s.log, &req.GenerationRequest).Infof("Transcribed %d seconds of audio, charged %.8f DCR", seconds, chargedDCR)
+
+	// 6. Send final confirmation
+	if req.IsPM {
+		finalMessage := fmt.Sprintf("Transcribed %d seconds of audio.\n\n", seconds)
+		finalMessage += utils.FormatBillingConfirmation("transcript", s.billingEnabled.Load(), s.billingEnabled.Load(), billingSucceeded, chargedDCR, req.PriceUSD, finalBalanceDCR) + utils.LowBalanceWarning(s.dbManager, req.UserID.String(), finalBalanceDCR, chargedDCR, req.PriceUSD)
+		s.sender.SendMessage(ctx, req.MessageContext(), finalMessage)
+	} else if splitCharge != nil {
+		// The group sees the pot's share; the requester's share and balance
+		// go by PM
+		s.sender.SendMessage(ctx, req.MessageContext(), utils.FormatSplitPotReceipt(splitCharge))
+		s.sender.SendPrivateMessage(ctx, req.MessageContext(), utils.FormatSplitBillingConfirmation(splitCharge, req.PriceUSD)+utils.LowBalanceWarning(s.dbManager, req.UserID.String(), splitCharge.UserBalanceDCR, splitCharge.UserDCR+splitCharge.PotDCR, req.PriceUSD))
+	}
+
+	return result, nil
+}
+
+// Run transcribes the request's audio without messaging or billing the
+// user, for callers that bill the transcription as part of a larger job.
+func (s *TranscribeService) Run(ctx context.Context, req *TranscribeRequest) (*TranscribeResult, error) {
+	resp, err := s.client.Transcribe(ctx, &fal.ScribeV2Request{
+		AudioURL: req.AudioURL,
+		Language: req.Language,
+		Task:     req.Task,
+		Progress: req.Progress,
+	})
+	if err != nil {
+		return &TranscribeResult{Success: false, Error: err}, err
+	}
+	return &TranscribeResult{
+		Text:     resp.Text,
+		Language: resp.LanguageCode,
+		Seconds:  transcribedSeconds(resp),
+		Success:  true,
+	}, nil
+}
+
+// sendTranscript sends the transcript to the PM or GC the request came from.
+func (s *TranscribeService) sendTranscript(ctx context.Context, req *TranscribeRequest, result *TranscribeResult) {
+	msg := fmt.Sprintf("📝 Transcript (%ds", result.Seconds)
+	if req.Speaker != "" {
+		msg = fmt.Sprintf("📝 Transcript of %s's audio note (%ds", utils.SanitizeUserText(req.Speaker), result.Seconds)
+	}
+	if result.Language != "" {
+		msg += ", " + utils.SanitizeUserText(result.Language)
+	}
+	msg += "):\n"
+	if result.Text == "" {
+		msg += "(no speech found)"
+	} else {
+		msg += utils.SanitizeUserText(result.Text)
+	}
+	if req.IsPM {
+		s.sender.SendMessage(ctx, req.MessageContext(), msg)
+	} else {
+		s.sender.SendMessage(ctx, req.MessageContext(), fmt.Sprintf("%s, %s", utils.SanitizeUserText(req.UserNick), msg))
+	}
+}
+
+// transcribedSeconds returns the length of the transcribed audio, taken
+// from the end of its last word and rounded up to a whole second.
+func transcribedSeconds(resp *fal.ScribeV2Response) int {
+	var end float64
+	for _, w := range resp.Words {
+		end = math.Max(end, w.End)
+	}
+	return max(int(math.Ceil(end)), 1)
+}
@@ -0,0 +1,286 @@
+// Package transfer delivers large results, such as videos and 3D models, as
+// Bison Relay file transfers. Downloads stream to disk under a size cap, files over the
+// attachment limit are re-encoded with ffmpeg when it is available, and
+// anything still too large is sent as a link instead. Videos can carry
+// subtitles, burnt in or sent alongside as a .srt file.
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/karamble/braibot/internal/assets"
+	"github.com/karamble/braibot/internal/debuglog"
+	"github.com/karamble/braibot/internal/subtitles"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+// Limits configures how results are delivered.
+type Limits struct {
+	// MaxFileBytes caps a download; larger results are only linked.
+	MaxFileBytes int64
+	// AttachLimitBytes is the largest file sent as a Bison Relay transfer.
+	AttachLimitBytes int64
+	// FFmpegPath is the ffmpeg binary used to shrink files over the
+	// attachment limit. Empty disables re-encoding.
+	FFmpegPath string
+	// LinkLifetime is how long provider result URLs are expected to stay
+	// up, quoted to users who get a link instead of a file.
+	LinkLifetime time.Duration
+	// Cache, if set, keeps local copies of the results sent, and serves
+	// them once the provider's URLs expired.
+	Cache *assets.Cache
+}
+
+// DefaultLimits downloads up to 1 GiB, sends files up to 100 MiB and looks
+// up ffmpeg on the PATH.
+var DefaultLimits = Limits{
+	MaxFileBytes:     1 << 30,
+	AttachLimitBytes: 100 << 20,
+	FFmpegPath:       "ffmpeg",
+	LinkLifetime:     24 * time.Hour,
+}
+
+// Method tells how a result reached the user.
+type Method int
+
+const (
+	Sent       Method = iota // Sent as downloaded
+	Compressed               // Re-encoded to fit the attachment limit
+	Linked                   // Too large, sent as a link
+)
+
+// errTooLarge is returned by fetch when a download exceeds MaxFileBytes.
+var errTooLarge = errors.New("file exceeds the download limit")
+
+// Sender delivers results to users.
+type Sender struct {
+	bot    *kit.Bot
+	limits Limits
+	client *http.Client
+}
+
+// NewSender creates a sender with the given limits.
+func NewSender(bot *kit.Bot, limits Limits) *Sender {
+	return &Sender{bot: bot, limits: limits, client: http.DefaultClient}
+}
+
+// SendVideo delivers the video at videoURL to the user, compressing or
+// linking it as the limits require.
+func (s *Sender) SendVideo(ctx context.Context, userNick, videoURL string) (Method, error) {
+	return s.sendVideo(ctx, userNick, videoURL, "", false)
+}
+
+// SendSubtitledVideo delivers the video like SendVideo, with the SubRip
+// subtitles srt burnt into it when burn is set and ffmpeg can do it, and
+// otherwise sent after it as a .srt file.
+func (s *Sender) SendSubtitledVideo(ctx context.Context, userNick, videoURL, srt string, burn bool) (Method, error) {
+	return s.sendVideo(ctx, userNick, videoURL, srt, burn)
+}
+
+func (s *Sender) sendVideo(ctx context.Context, userNick, videoURL, srt string, burn bool) (Method, error) {
+	dir, err := os.MkdirTemp("", "video-")
+	if err != nil {
+		return Sent, fmt.Errorf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var srtPath string
+	if srt != "" {
+		srtPath = filepath.Join(dir, "video.srt")
+		if err := os.WriteFile(srtPath, []byte(srt), 0o600); err != nil {
+			return Sent, fmt.Errorf("failed to write subtitles: %v", err)
+		}
+	}
+
+	path, size, err := s.fetch(ctx, videoURL, filepath.Join(dir, "video.mp4"))
+	if errors.Is(err, errTooLarge) {
+		if err := s.sendLink(ctx, userNick, "video", videoURL, size); err != nil {
+			return Linked, err
+		}
+		return Linked, s.sendSubtitles(ctx, userNick, srtPath)
+	}
+	if err != nil {
+		return Sent, err
+	}
+	debuglog.Debugf(debuglog.Delivery, "Downloaded video %s (%d bytes) for %s", videoURL, size, userNick)
+
+	if srtPath != "" && burn {
+		burnt := filepath.Join(dir, "video-subtitled.mp4")
+		if err := subtitles.Burn(ctx, s.limits.FFmpegPath, path, srtPath, burnt); err != nil {
+			fmt.Printf("WARN: Failed to burn subtitles into the video for %s, sending them as a file: %v\n", userNick, err)
+		} else if fi, err := os.Stat(burnt); err == nil {
+			debuglog.Debugf(debuglog.Delivery, "Burnt subtitles into the video for %s", userNick)
+			path, size, srtPath = burnt, fi.Size(), ""
+		}
+	}
+
+	method := Sent
+	if s.limits.AttachLimitBytes > 0 && size > s.limits.AttachLimitBytes {
+		small, smallSize, err := s.compress(ctx, path, filepath.Join(dir, "video-small.mp4"))
+		if err != nil || smallSize > s.limits.AttachLimitBytes {
+			if err != nil {
+				fmt.Printf("WARN: Failed to compress video for %s: %v\n", userNick, err)
+			}
+			if err := s.sendLink(ctx, userNick, "video", videoURL, size); err != nil {
+				return Linked, err
+			}
+			return Linked, s.sendSubtitles(ctx, userNick, srtPath)
+		}
+		debuglog.Debugf(debuglog.Delivery, "Compressed video for %s from %d to %d bytes", userNick, size, smallSize)
+		path, method = small, Compressed
+	}
+
+	if err := s.bot.SendFile(ctx, userNick, path); err != nil {
+		return method, fmt.Errorf("failed to send video file: %v", err)
+	}
+	debuglog.Debugf(debuglog.Delivery, "Sent video %s to %s", path, userNick)
+	return method, s.sendSubtitles(ctx, userNick, srtPath)
+}
+
+// SendFile delivers the file at fileURL to the user under the name name,
+// sending a link instead when it is over the limits. what names the result
+// in the link message, e.g. "3D model". Files other than videos cannot be
+// re-encoded, so there is no Compressed delivery.
+func (s *Sender) SendFile(ctx context.Context, userNick, fileURL, name, what string) (Method, error) {
+	dir, err := os.MkdirTemp("", "file-")
+	if err != nil {
+		return Sent, fmt.Errorf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path, size, err := s.fetch(ctx, fileURL, filepath.Join(dir, filepath.Base(name)))
+	if errors.Is(err, errTooLarge) || (err == nil && s.limits.AttachLimitBytes > 0 && size > s.limits.AttachLimitBytes) {
+		return Linked, s.sendLink(ctx, userNick, what, fileURL, size)
+	}
+	if err != nil {
+		return Sent, err
+	}
+	debuglog.Debugf(debuglog.Delivery, "Downloaded %s %s (%d bytes) for %s", what, fileURL, size, userNick)
+
+	if err := s.bot.SendFile(ctx, userNick, path); err != nil {
+		return Sent, fmt.Errorf("failed to send %s file: %v", what, err)
+	}
+	debuglog.Debugf(debuglog.Delivery, "Sent %s %s to %s", what, path, userNick)
+	return Sent, nil
+}
+
+// sendSubtitles sends the .srt file at srtPath, if any, to the user.
+func (s *Sender) sendSubtitles(ctx context.Context, userNick, srtPath string) error {
+	if srtPath == "" {
+		return nil
+	}
+	if err := s.bot.SendFile(ctx, userNick, srtPath); err != nil {
+		return fmt.Errorf("failed to send subtitles file: %v", err)
+	}
+	return nil
+}
+
+// open returns the file at fileURL and its size, -1 when unknown, from the
+// cache when there is one and it holds or can hold the file.
+func (s *Sender) open(ctx context.Context, fileURL string) (io.ReadCloser, int64, error) {
+	if s.limits.Cache != nil {
+		f, size, err := s.limits.Cache.Open(ctx, fileURL)
+		if err == nil {
+			debuglog.Debugf(debuglog.Delivery, "Reading %s from the media cache", fileURL)
+			return f, size, nil
+		}
+		debuglog.Debugf(debuglog.Delivery, "Media cache miss for %s: %v", fileURL, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to download file: %v", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to download file: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("failed to download file: status code %d", resp.StatusCode)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// fetch streams fileURL to path. A download over MaxFileBytes is stopped
+// early and reported as errTooLarge, with the size when the server sent it.
+func (s *Sender) fetch(ctx context.Context, fileURL, path string) (string, int64, error) {
+	src, size, err := s.open(ctx, fileURL)
+	if err != nil {
+		return "", 0, err
+	}
+	defer src.Close()
+	limit := s.limits.MaxFileBytes
+	if limit > 0 && size > limit {
+		return "", size, errTooLarge
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create temp file: %v", err)
+	}
+	body := io.Reader(src)
+	if limit > 0 {
+		body = io.LimitReader(src, limit+1)
+	}
+	n, err := io.Copy(f, body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", n, fmt.Errorf("failed to save file: %v", err)
+	}
+	if limit > 0 && n > limit {
+		return "", 0, errTooLarge
+	}
+	return path, n, nil
+}
+
+// compress re-encodes the video at src to a smaller H.264 file at dst,
+// scaled down to at most 1280 pixels wide.
+func (s *Sender) compress(ctx context.Context, src, dst string) (string, int64, error) {
+	if s.limits.FFmpegPath == "" {
+		return "", 0, errors.New("ffmpeg is disabled")
+	}
+	ffmpeg, err := exec.LookPath(s.limits.FFmpegPath)
+	if err != nil {
+		return "", 0, fmt.Errorf("ffmpeg not found: %v", err)
+	}
+	cmd := exec.CommandContext(ctx, ffmpeg, "-y", "-loglevel", "error", "-i", src,
+		"-vf", "scale='min(1280,iw)':-2", "-c:v", "libx264", "-preset", "veryfast", "-crf", "28",
+		"-c:a", "aac", "-b:a", "96k", "-movflags", "+faststart", dst)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", 0, fmt.Errorf("ffmpeg failed: %v: %s", err, out)
+	}
+	fi, err := os.Stat(dst)
+	if err != nil {
+		return "", 0, err
+	}
+	return dst, fi.Size(), nil
+}
+
+// sendLink tells the user the result is too large to send and links it.
+func (s *Sender) sendLink(ctx context.Context, userNick, what, fileURL string, size int64) error {
+	return s.bot.SendPM(ctx, userNick, linkMessage(what, fileURL, size, s.limits.LinkLifetime, time.Now()))
+}
+
+// linkMessage formats the PM sent in place of a file too large to send.
+// what names the result, e.g. "video". size is 0 when the size is unknown.
+func linkMessage(what, fileURL string, size int64, lifetime time.Duration, now time.Time) string {
+	msg := "Your " + what + " is too large to send as a file"
+	if size > 0 {
+		msg += fmt.Sprintf(" (%.1f MB)", float64(size)/(1<<20))
+	}
+	msg += ". Download it here: " + fileURL
+	if lifetime > 0 {
+		msg += "\nThe link is the provider's copy and should work until about " + now.Add(lifetime).UTC().Format("Jan 2 15:04 MST") + "."
+	}
+	return msg
+}
@@ -0,0 +1,50 @@
+package transfer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetch(t *testing.T) {
+	payload := strings.Repeat("v", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			// No Content-Length, so the limit is only hit while copying
+			w.(http.Flusher).Flush()
+		}
+		w.Write([]byte(payload))
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+
+	s := &Sender{limits: Limits{MaxFileBytes: 100}, client: srv.Client()}
+	if _, n, err := s.fetch(t.Context(), srv.URL+"/video", filepath.Join(dir, "a")); err != nil || n != 100 {
+		t.Fatalf("fetch = %d, %v; want 100, nil", n, err)
+	}
+
+	s.limits.MaxFileBytes = 50
+	if _, n, err := s.fetch(t.Context(), srv.URL+"/video", filepath.Join(dir, "b")); !errors.Is(err, errTooLarge) || n != 100 {
+		t.Errorf("fetch over limit = %d, %v; want 100, errTooLarge", n, err)
+	}
+	if _, _, err := s.fetch(t.Context(), srv.URL+"/chunked", filepath.Join(dir, "c")); !errors.Is(err, errTooLarge) {
+		t.Errorf("streamed fetch over limit = %v, want errTooLarge", err)
+	}
+}
+
+func TestLinkMessage(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	got := linkMessage("video", "https://example.com/v.mp4", 150<<20, 24*time.Hour, now)
+	for _, want := range []string{"Your video is too large", "(150.0 MB)", "https://example.com/v.mp4", "Mar 2 12:00 UTC"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("linkMessage = %q, missing %q", got, want)
+		}
+	}
+	if got := linkMessage("3D model", "https://example.com/m.glb", 0, 0, now); strings.Contains(got, "MB") || strings.Contains(got, "until") {
+		t.Errorf("linkMessage without size or lifetime = %q", got)
+	}
+}
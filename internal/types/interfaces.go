@@ -11,6 +11,7 @@ type BotInterface interface {
 	SendPM(ctx context.Context, uid zkidentity.ShortID, msg string) error
 	SendGC(ctx context.Context, gc string, msg string) error
 	SendGCMessage(ctx context.Context, gc string, channel string, msg string) error
+	SendFile(ctx context.Context, uid zkidentity.ShortID, path string) error
 }
 
 // DBManagerInterface defines the interface for database operations
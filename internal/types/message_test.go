@@ -13,6 +13,7 @@ type MockBot struct {
 	lastPM        string
 	lastGC        string
 	lastGCChannel string
+	lastFile      string
 	lastError     error
 }
 
@@ -32,6 +33,11 @@ func (m *MockBot) SendGCMessage(ctx context.Context, gc string, channel string,
 	return m.lastError
 }
 
+func (m *MockBot) SendFile(ctx context.Context, uid zkidentity.ShortID, path string) error {
+	m.lastFile = path
+	return m.lastError
+}
+
 // TestMessageContext tests the MessageContext struct
 func TestMessageContext(t *testing.T) {
 	// Create a PM context
@@ -128,3 +134,25 @@ func TestMessageSender(t *testing.T) {
 		t.Errorf("Expected error to be propagated, got %v", err)
 	}
 }
+
+// TestMessageSenderMedia tests embeds and files in PM and GC contexts
+func TestMessageSenderMedia(t *testing.T) {
+	mockBot := &MockBot{}
+	sender := NewMessageSender(mockBot)
+	gcCtx := MessageContext{Nick: "user2", IsPM: false, GC: "general"}
+
+	if err := sender.SendEmbed(context.Background(), gcCtx, "pic", "image/png", []byte("png")); err != nil {
+		t.Fatalf("SendEmbed failed: %v", err)
+	}
+	if want := "--embed[alt=pic,type=image/png,data=cG5n]--"; mockBot.lastGC != want {
+		t.Errorf("Expected GC embed %q, got %q", want, mockBot.lastGC)
+	}
+
+	// Files cannot go to a GC, so they reach the sender directly
+	if err := sender.SendFile(context.Background(), gcCtx, "/tmp/video.mp4"); err != nil {
+		t.Fatalf("SendFile failed: %v", err)
+	}
+	if mockBot.lastFile != "/tmp/video.mp4" {
+		t.Errorf("Expected file to be sent, got %q", mockBot.lastFile)
+	}
+}
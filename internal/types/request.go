@@ -27,4 +27,21 @@ type GenerationRequest struct {
 	IsPM            bool   // Whether this is a private message
 	GC              string // Group chat name if not PM
 	ExternalBilling *ExternalBilling
+	SplitPercent    int    // Share of the cost (1-100) paid from the GC pot; 0 bills the user alone
+	JobID           uint64 // Event log id, assigned when the job is submitted
+	AssetLink       bool   // Post GC results as asset-server links instead of embeds
+	NoCache         bool   // Generate anew even when an identical request's result is cached
+	ChargeID        string // Ledger id of the request's charge, assigned when it is first charged
+}
+
+// MessageContext returns the context of the message that asked for the
+// request, for replying through a MessageSender.
+func (r *GenerationRequest) MessageContext() MessageContext {
+	return MessageContext{
+		Nick:   r.UserNick,
+		Uid:    r.UserID[:],
+		IsPM:   r.IsPM,
+		Sender: r.UserID,
+		GC:     r.GC,
+	}
 }
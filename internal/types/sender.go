@@ -2,6 +2,7 @@ package braibottypes
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 
 	"github.com/companyzero/bisonrelay/zkidentity"
@@ -10,7 +11,7 @@ import (
 
 // BisonBotAdapter adapts *kit.Bot to the BotInterface
 // This allows us to use *kit.Bot where BotInterface is required
-// and provides the required SendPM, SendGC, and SendGCMessage methods
+// and provides the required SendPM, SendGC, SendGCMessage and SendFile methods
 // with the correct signatures.
 type BisonBotAdapter struct {
 	bot *kit.Bot
@@ -33,6 +34,10 @@ func (a *BisonBotAdapter) SendGCMessage(ctx context.Context, gc string, channel
 	return a.bot.SendGC(ctx, gc, msg)
 }
 
+func (a *BisonBotAdapter) SendFile(ctx context.Context, uid zkidentity.ShortID, path string) error {
+	return a.bot.SendFile(ctx, uid.String(), path)
+}
+
 // MessageSender provides a unified interface for sending messages in both PM and group chat contexts
 type MessageSender struct {
 	bot BotInterface
@@ -51,6 +56,28 @@ func (s *MessageSender) SendMessage(ctx context.Context, msgCtx MessageContext,
 	return s.bot.SendGC(ctx, msgCtx.GC, message)
 }
 
+// SendPrivateMessage sends a message to the user in a PM, also when the
+// context is a group chat
+func (s *MessageSender) SendPrivateMessage(ctx context.Context, msgCtx MessageContext, message string) error {
+	return s.bot.SendPM(ctx, msgCtx.Sender, message)
+}
+
+// SendEmbed sends data inline as an embed in the appropriate context
+func (s *MessageSender) SendEmbed(ctx context.Context, msgCtx MessageContext, alt, contentType string, data []byte) error {
+	return s.SendMessage(ctx, msgCtx, FormatEmbed(alt, contentType, data))
+}
+
+// SendFile sends the file at path to the user. Bison Relay only transfers
+// files to users, so from a group chat the file goes to the sender in a PM.
+func (s *MessageSender) SendFile(ctx context.Context, msgCtx MessageContext, path string) error {
+	return s.bot.SendFile(ctx, msgCtx.Sender, path)
+}
+
+// FormatEmbed formats data as an inline Bison Relay embed
+func FormatEmbed(alt, contentType string, data []byte) string {
+	return fmt.Sprintf("--embed[alt=%s,type=%s,data=%s]--", alt, contentType, base64.StdEncoding.EncodeToString(data))
+}
+
 // SendErrorMessage sends an error message to the user
 func (s *MessageSender) SendErrorMessage(ctx context.Context, msgCtx MessageContext, err error) error {
 	errorMsg := fmt.Sprintf("❌ Error: %v", err)
@@ -2,9 +2,17 @@ package utils
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/debuglog"
+	"github.com/karamble/braibot/internal/joblog"
+	"github.com/karamble/braibot/internal/money"
+	braibottypes "github.com/karamble/braibot/internal/types"
 )
 
 // ErrInsufficientBalance is a custom error type for insufficient funds.
@@ -30,7 +38,7 @@ func CheckBalance(ctx context.Context, dbManager *database.DBManager, userID []b
 		err = fmt.Errorf("failed to get balance: %v", balanceErr)
 		return
 	}
-	currentBalanceDCR = float64(balanceAtoms) / 1e11
+	currentBalanceDCR = money.AtomsToDCR(balanceAtoms)
 
 	// If billing is disabled, return success (nil error)
 	if !billingEnabled {
@@ -40,19 +48,17 @@ func CheckBalance(ctx context.Context, dbManager *database.DBManager, userID []b
 
 	// --- Billing is enabled, perform normal checks ---
 
-	// Convert USD cost to DCR
-	requiredDCR, err = USDToDCR(costUSD)
+	// Convert USD cost to atoms for comparison
+	dcrAtoms, err := USDToAtoms(costUSD)
 	if err != nil {
 		err = fmt.Errorf("failed to convert USD to DCR: %v", err)
 		return
 	}
-
-	// Convert DCR amount to atoms for comparison (1 DCR = 1e11 atoms)
-	dcrAtoms := int64(requiredDCR * 1e11)
+	requiredDCR = money.AtomsToDCR(dcrAtoms)
 
 	// Debug information
-	if debug {
-		fmt.Print(FormatDebugBalanceInfo(userIDStr, balanceAtoms, costUSD, requiredDCR, dcrAtoms))
+	if debuglog.Enabled(debuglog.Billing) {
+		debuglog.Debugf(debuglog.Billing, "%s", FormatDebugBalanceInfo(userIDStr, balanceAtoms, costUSD, requiredDCR, dcrAtoms))
 	}
 
 	// Check if user has sufficient balance
@@ -64,67 +70,270 @@ func CheckBalance(ctx context.Context, dbManager *database.DBManager, userID []b
 		return // Return the insufficient balance error
 	}
 
+	// Public deployments cap what a user may spend, whatever their balance
+	if err = checkSpendLimit(dbManager, userIDStr, costUSD); err != nil {
+		return
+	}
+
 	// Sufficient balance, return success (nil error)
 	return
 }
 
+// NewChargeID returns a random id for the charge of a job.
+func NewChargeID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand does not fail on supported platforms
+		panic(fmt.Sprintf("failed to generate charge id: %v", err))
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// jobChargesKey is the context key of the charges of a queued job's run.
+type jobChargesKey struct{}
+
+// jobCharges numbers the charges made by one run of a queued job.
+type jobCharges struct {
+	jobID int64
+	n     atomic.Int64
+}
+
+// WithJobCharges returns a context whose requests are charged under ids
+// derived from the queued job jobID, numbered in the order they are
+// charged. A job run again after a restart charges its requests under the
+// same ids, so a charge applied before the restart is not applied twice.
+func WithJobCharges(ctx context.Context, jobID int64) context.Context {
+	return context.WithValue(ctx, jobChargesKey{}, &jobCharges{jobID: jobID})
+}
+
+// RequestChargeID returns the id of the charge of a generation request,
+// assigning one on first use so that retrying the charge cannot bill the
+// request twice. Requests of a queued job get the next id of the job (see
+// WithJobCharges), others a random one.
+func RequestChargeID(ctx context.Context, req *braibottypes.GenerationRequest) string {
+	if req.ChargeID == "" {
+		if c, ok := ctx.Value(jobChargesKey{}).(*jobCharges); ok {
+			req.ChargeID = fmt.Sprintf("job-%d-%d", c.jobID, c.n.Add(1))
+		} else {
+			req.ChargeID = NewChargeID()
+		}
+	}
+	return req.ChargeID
+}
+
 // DeductBalance deducts the specified cost in USD from the user's balance.
 // It assumes the balance check has already passed IF billing is enabled.
-// Returns the amount charged in DCR, the new balance in DCR, and any error encountered.
-// If billingEnabled is false, it returns zero charged and the current balance without hitting the DB.
-func DeductBalance(ctx context.Context, dbManager *database.DBManager, userID []byte, costUSD float64, debug bool, billingEnabled bool) (chargedDCR float64, newBalanceDCR float64, err error) {
-	// Get current balance first
-	currentBalanceDCR, balanceErr := dbManager.GetUserBalance(userID) // Assuming GetUserBalance returns DCR
-	if balanceErr != nil {
-		err = fmt.Errorf("failed to get current balance before deduction attempt: %v", balanceErr)
-		return
-	}
+// The cost is converted to atoms once and charged under chargeID, so a
+// charge retried with the same id is not applied twice. Returns the amount
+// charged in DCR, the new balance in DCR, and any error encountered.
+// If billingEnabled is false, it returns zero charged and the current balance.
+func DeductBalance(ctx context.Context, dbManager *database.DBManager, userID []byte, costUSD float64, chargeID string, debug bool, billingEnabled bool) (chargedDCR float64, newBalanceDCR float64, err error) {
+	chargedDCR, newBalanceDCR, _, err = deductBalance(ctx, dbManager, userID, costUSD, chargeID, debug, billingEnabled)
+	return
+}
+
+// deductBalance implements DeductBalance, also reporting whether the charge
+// was found in the ledger, applied by an earlier run of the job.
+func deductBalance(ctx context.Context, dbManager *database.DBManager, userID []byte, costUSD float64, chargeID string, debug bool, billingEnabled bool) (chargedDCR float64, newBalanceDCR float64, repeated bool, err error) {
+	userIDStr := GetUserIDString(userID)
 
 	// If billing is disabled, do nothing and return current balance
 	if !billingEnabled {
-		chargedDCR = 0
-		newBalanceDCR = currentBalanceDCR
+		balanceAtoms, balanceErr := dbManager.GetBalance(userIDStr)
+		if balanceErr != nil {
+			err = fmt.Errorf("failed to get current balance: %v", balanceErr)
+			return
+		}
+		newBalanceDCR = money.AtomsToDCR(balanceAtoms)
 		return // Success (no-op)
 	}
 
 	// --- Billing is enabled, perform deduction ---
 
-	// Convert USD cost to DCR and then to atoms for the database layer
-	chargedDCR, convertErr := USDToDCR(costUSD)
+	// Convert USD cost to atoms for the database layer
+	costAtoms, convertErr := USDToAtoms(costUSD)
 	if convertErr != nil {
 		err = fmt.Errorf("failed to convert USD to DCR: %v", convertErr)
-		newBalanceDCR = currentBalanceDCR
+		if balanceAtoms, balanceErr := dbManager.GetBalance(userIDStr); balanceErr == nil {
+			newBalanceDCR = money.AtomsToDCR(balanceAtoms)
+		}
 		return
 	}
-	costAtoms := int64(chargedDCR * 1e11)
 
-	// Deduct balance using CheckAndDeductBalance (atomic check-and-deduct)
-	hasBalanceAfterDeduct, err := dbManager.CheckAndDeductBalance(userID, costAtoms, debug)
-	if err != nil {
-		err = fmt.Errorf("failed to deduct balance: %v", err)
-		newBalanceDCR = currentBalanceDCR // Return pre-deduction balance on error
-		return
-	}
-	if !hasBalanceAfterDeduct {
-		err = fmt.Errorf("deduction failed despite prior check (insufficient funds or race condition)")
-		newBalanceDCR = currentBalanceDCR // Return pre-deduction balance on error
+	// Check and deduct atomically
+	newBalanceAtoms, applied, deductErr := dbManager.CheckAndDeductBalance(userID, costAtoms, chargeID, debug)
+	if deductErr != nil {
+		err = fmt.Errorf("failed to deduct balance: %v", deductErr)
+		// Return the unchanged balance on error
+		if balanceAtoms, balanceErr := dbManager.GetBalance(userIDStr); balanceErr == nil {
+			newBalanceDCR = money.AtomsToDCR(balanceAtoms)
+		}
 		return
 	}
-
-	// Get updated balance for result
-	finalBalanceDCR, finalBalanceErr := dbManager.GetUserBalance(userID) // Assuming GetUserBalance returns DCR
-	if finalBalanceErr != nil {
-		// The deduction likely succeeded, but we failed to get the final balance.
-		err = fmt.Errorf("deduction likely succeeded, but failed to get updated balance: %v", finalBalanceErr)
-		newBalanceDCR = currentBalanceDCR // Return pre-deduction balance as best guess
-		return
+	chargedDCR = money.AtomsToDCR(costAtoms)
+	newBalanceDCR = money.AtomsToDCR(newBalanceAtoms)
+	// A charge found in the ledger was counted when it was applied
+	repeated = !applied
+	if applied {
+		recordSpend(dbManager, userIDStr, costUSD)
 	}
-	newBalanceDCR = finalBalanceDCR
 
 	// Debug information after deduction
-	if debug {
-		fmt.Print(FormatDebugAfterDeduction(int64(newBalanceDCR * 1e11)))
+	if debuglog.Enabled(debuglog.Billing) {
+		debuglog.Debugf(debuglog.Billing, "%s", FormatDebugAfterDeduction(newBalanceAtoms))
 	}
 
 	return // Success
 }
+
+// SplitCharge is the outcome of a charge split between the requester and a
+// GC pot.
+type SplitCharge struct {
+	GC             string
+	Percent        int // Share of the cost paid by the GC pot
+	UserDCR        float64
+	PotDCR         float64
+	UserBalanceDCR float64 // User balance after the charge
+	PotBalanceDCR  float64 // Pot balance after the charge
+	// Repeated is set when the charge was found in the ledger, applied by
+	// an earlier run of the job
+	Repeated bool
+}
+
+// splitAtoms converts a USD cost to atoms and splits it, the pot paying
+// percent of it and the user the remainder.
+func splitAtoms(costUSD float64, percent int) (userAtoms, potAtoms int64, err error) {
+	total, err := USDToAtoms(costUSD)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to convert USD to DCR: %v", err)
+	}
+	potAtoms = total * int64(percent) / 100
+	return total - potAtoms, potAtoms, nil
+}
+
+// CheckSplitBalance checks that the user and the GC pot can each cover their
+// share of a split request, without deducting. It returns the user's share
+// in DCR and the user's current balance in DCR.
+func CheckSplitBalance(ctx context.Context, dbManager *database.DBManager, userID []byte, gc string, percent int, costUSD float64, debug bool) (requiredDCR float64, currentBalanceDCR float64, err error) {
+	userIDStr := GetUserIDString(userID)
+	balanceAtoms, err := dbManager.GetBalance(userIDStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get balance: %v", err)
+	}
+	currentBalanceDCR = money.AtomsToDCR(balanceAtoms)
+
+	potAtoms, err := dbManager.GetBalance(database.GCPotUID(gc))
+	if err != nil {
+		return 0, currentBalanceDCR, fmt.Errorf("failed to get GC pot balance: %v", err)
+	}
+
+	userShare, potShare, err := splitAtoms(costUSD, percent)
+	if err != nil {
+		return 0, currentBalanceDCR, err
+	}
+	requiredDCR = money.AtomsToDCR(userShare)
+
+	if debuglog.Enabled(debuglog.Billing) {
+		debuglog.Debugf(debuglog.Billing, "%s", FormatDebugBalanceInfo(userIDStr, balanceAtoms, costUSD, requiredDCR, userShare))
+	}
+
+	if potAtoms < potShare {
+		return requiredDCR, currentBalanceDCR, &ErrInsufficientBalance{
+			Message: fmt.Sprintf("The GC pot cannot cover %d%% of this request. Required: %.8f DCR, Pot: %.8f DCR. Fund it with !pot fund [amount].",
+				percent, money.AtomsToDCR(potShare), money.AtomsToDCR(potAtoms)),
+		}
+	}
+	if balanceAtoms < userShare {
+		return requiredDCR, currentBalanceDCR, &ErrInsufficientBalance{
+			Message: FormatInsufficientBalanceMessageWithUSD(requiredDCR, currentBalanceDCR, costUSD*float64(100-percent)/100),
+		}
+	}
+	// Only the user's share counts against their spending limits
+	if err := checkSpendLimit(dbManager, userIDStr, costUSD*float64(100-percent)/100); err != nil {
+		return requiredDCR, currentBalanceDCR, err
+	}
+	return requiredDCR, currentBalanceDCR, nil
+}
+
+// DeductSplitBalance charges a request split between the user and a GC pot.
+// Both shares are deducted atomically under chargeID; if either balance falls
+// short nothing is charged.
+func DeductSplitBalance(ctx context.Context, dbManager *database.DBManager, userID []byte, gc string, percent int, costUSD float64, chargeID string, debug bool) (*SplitCharge, error) {
+	userShare, potShare, err := splitAtoms(costUSD, percent)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDStr := GetUserIDString(userID)
+	potUID := database.GCPotUID(gc)
+	applied, err := dbManager.DeductSplit(userIDStr, potUID, userShare, potShare, chargeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deduct split charge: %v", err)
+	}
+
+	if applied {
+		recordSpend(dbManager, userIDStr, costUSD*float64(100-percent)/100)
+	}
+
+	charge := &SplitCharge{
+		GC:       gc,
+		Percent:  percent,
+		UserDCR:  money.AtomsToDCR(userShare),
+		PotDCR:   money.AtomsToDCR(potShare),
+		Repeated: !applied,
+	}
+	userBalance, err := dbManager.GetBalance(userIDStr)
+	if err == nil {
+		charge.UserBalanceDCR = money.AtomsToDCR(userBalance)
+	}
+	if balance, err := dbManager.GetBalance(potUID); err == nil {
+		charge.PotBalanceDCR = money.AtomsToDCR(balance)
+	}
+
+	if debuglog.Enabled(debuglog.Billing) {
+		debuglog.Debugf(debuglog.Billing, "%s", FormatDebugAfterDeduction(userBalance))
+	}
+	return charge, nil
+}
+
+// CheckRequestBalance checks the balance for a generation request, splitting
+// the check with the GC pot when the request asks for a split.
+func CheckRequestBalance(ctx context.Context, dbManager *database.DBManager, req *braibottypes.GenerationRequest, costUSD float64, debug bool, billingEnabled bool) (requiredDCR float64, currentBalanceDCR float64, err error) {
+	if billingEnabled && req.SplitPercent > 0 {
+		return CheckSplitBalance(ctx, dbManager, req.UserID[:], req.GC, req.SplitPercent, costUSD, debug)
+	}
+	return CheckBalance(ctx, dbManager, req.UserID[:], costUSD, debug, billingEnabled)
+}
+
+// DeductRequestBalance charges a generation request under its charge id.
+// Split requests return the split outcome; chargedDCR and newBalanceDCR
+// always refer to the user. The cost of requests made in a GC counts against
+// the GC's daily budget.
+func DeductRequestBalance(ctx context.Context, dbManager *database.DBManager, req *braibottypes.GenerationRequest, costUSD float64, debug bool, billingEnabled bool) (chargedDCR float64, newBalanceDCR float64, split *SplitCharge, err error) {
+	if billingEnabled && req.SplitPercent > 0 {
+		split, err = DeductSplitBalance(ctx, dbManager, req.UserID[:], req.GC, req.SplitPercent, costUSD, RequestChargeID(ctx, req), debug)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if !split.Repeated {
+			recordGCSpend(dbManager, req, costUSD)
+		}
+		return split.UserDCR, split.UserBalanceDCR, split, nil
+	}
+	chargedDCR, newBalanceDCR, repeated, err := deductBalance(ctx, dbManager, req.UserID[:], costUSD, RequestChargeID(ctx, req), debug, billingEnabled)
+	if err == nil && !repeated {
+		recordGCSpend(dbManager, req, costUSD)
+	}
+	return chargedDCR, newBalanceDCR, nil, err
+}
+
+// recordGCSpend adds the cost of a request made in a GC to the GC's spending.
+// Requests whose results are sent by PM still carry the GC they came from.
+func recordGCSpend(dbManager *database.DBManager, req *braibottypes.GenerationRequest, costUSD float64) {
+	if req.GC == "" || costUSD <= 0 {
+		return
+	}
+	if err := dbManager.AddGCSpend(req.GC, costUSD, time.Now()); err != nil {
+		joblog.For(debuglog.Logger(debuglog.Billing), req).Warnf("Failed to count the cost against %s: %v", req.GC, err)
+	}
+}
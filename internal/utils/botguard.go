@@ -0,0 +1,58 @@
+package utils
+
+import "strings"
+
+// botOutputMarkers are phrases found in status and billing messages that AI
+// bots, braibot included, send after running a command. A command-looking
+// message that contains one of them is most likely another bot echoing
+// output rather than a person typing.
+var botOutputMarkers = []string{
+	"billing information",
+	"request cost:",
+	"your balance:",
+	"seed for the request",
+	"i don't recognize that command",
+	"finished processing request",
+	"use **!help** to see available commands",
+}
+
+// BotGuard recognizes messages sent by other bots so their commands are not
+// executed, which would let bots in a shared GC trigger each other in a loop.
+type BotGuard struct {
+	uids      map[string]bool
+	heuristic bool
+}
+
+// NewBotGuard creates a guard that treats the given user ids (hex encoded)
+// as bots. When heuristic is set, messages that look like bot output are
+// treated as coming from a bot too.
+func NewBotGuard(uids []string, heuristic bool) *BotGuard {
+	g := &BotGuard{uids: make(map[string]bool, len(uids)), heuristic: heuristic}
+	for _, uid := range uids {
+		g.uids[strings.ToLower(strings.TrimSpace(uid))] = true
+	}
+	return g
+}
+
+// IsBot reports whether a message from uid should be ignored as bot traffic.
+func (g *BotGuard) IsBot(uid, message string) bool {
+	if g == nil {
+		return false
+	}
+	if g.uids[strings.ToLower(uid)] {
+		return true
+	}
+	return g.heuristic && LooksLikeBotOutput(message)
+}
+
+// LooksLikeBotOutput reports whether message reads like a bot's status or
+// billing reply.
+func LooksLikeBotOutput(message string) bool {
+	lower := strings.ToLower(message)
+	for _, marker := range botOutputMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
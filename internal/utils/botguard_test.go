@@ -0,0 +1,42 @@
+package utils
+
+import "testing"
+
+func TestBotGuard(t *testing.T) {
+	const botUID = "AB12cd"
+	g := NewBotGuard([]string{" " + botUID + " "}, true)
+
+	tests := []struct {
+		name    string
+		uid     string
+		message string
+		want    bool
+	}{
+		{"known bot uid", "ab12CD", "!help", true},
+		{"person command", "ff00", "!text2image a cat in a hat", false},
+		{"person prompt mentioning balance", "ff00", "!ai what is my balance?", false},
+		{"echoed billing receipt", "ff00", "!balance 💰 Billing Information:\n• Charged: 0.1 DCR", true},
+		{"echoed unknown command reply", "ff00", "👋 Hi bob!\n\nI don't recognize that command. Use **!help** to see available commands.", true},
+		{"echoed cost line", "ff00", "Request cost: $0.02 USD (0.001 DCR). Your balance: 1 DCR.", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.IsBot(tt.uid, tt.message); got != tt.want {
+				t.Errorf("IsBot(%q, %q) = %v, want %v", tt.uid, tt.message, got, tt.want)
+			}
+		})
+	}
+
+	// Without the heuristic only listed uids are ignored.
+	g = NewBotGuard([]string{botUID}, false)
+	if g.IsBot("ff00", "Request cost: $0.02 USD") {
+		t.Error("heuristic applied while disabled")
+	}
+	if !g.IsBot(botUID, "!help") {
+		t.Error("listed bot not ignored")
+	}
+	var nilGuard *BotGuard
+	if nilGuard.IsBot(botUID, "!help") {
+		t.Error("nil guard ignored a message")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/karamble/braibot/internal/money"
 )
 
 var (
@@ -16,10 +18,83 @@ var (
 	rateMutex         sync.RWMutex
 	rateCacheTime     = 10 * time.Minute
 	lastBTCRateUpdate time.Time // Separate cache for BTC price
+
+	fixedDCRUSD    float64   // Rate of the fixed rate mode, 0 in live mode
+	overrideDCRUSD float64   // Rate set by an admin, 0 when none is set
+	overrideUntil  time.Time // Expiry of the admin's rate
 )
 
-// GetDCRPrice gets the current DCR price in USD and BTC from CoinGecko
+// Sources of the DCR/USD rate pricing uses.
+const (
+	RateLive     = "live"     // CoinGecko, refreshed every 10 minutes
+	RateFixed    = "fixed"    // fixed_dcr_usd= with rate_mode=fixed
+	RateOverride = "override" // Set with !admin rate set until it expires
+)
+
+// PricingRate is the DCR/USD rate pricing and billing use.
+type PricingRate struct {
+	USD    float64
+	Source string    // RateLive, RateFixed or RateOverride
+	Until  time.Time // Expiry of an override
+}
+
+// SetFixedDCRRate prices requests at usd per DCR instead of the live rate.
+// 0 goes back to the live rate.
+func SetFixedDCRRate(usd float64) {
+	rateMutex.Lock()
+	defer rateMutex.Unlock()
+	fixedDCRUSD = usd
+}
+
+// SetDCRRateOverride prices requests at usd per DCR until until, over both
+// the live and the fixed rate. 0 clears the override.
+func SetDCRRateOverride(usd float64, until time.Time) {
+	rateMutex.Lock()
+	defer rateMutex.Unlock()
+	overrideDCRUSD, overrideUntil = usd, until
+}
+
+// setRate returns the rate pricing uses at now when it is not the live
+// rate: an unexpired override, or else the fixed rate.
+func setRate(now time.Time) (PricingRate, bool) {
+	rateMutex.RLock()
+	defer rateMutex.RUnlock()
+	switch {
+	case overrideDCRUSD > 0 && now.Before(overrideUntil):
+		return PricingRate{USD: overrideDCRUSD, Source: RateOverride, Until: overrideUntil}, true
+	case fixedDCRUSD > 0:
+		return PricingRate{USD: fixedDCRUSD, Source: RateFixed}, true
+	}
+	return PricingRate{}, false
+}
+
+// GetPricingRate returns the DCR/USD rate pricing uses, fetching the live
+// rate unless an override or a fixed rate is in effect.
+func GetPricingRate() (PricingRate, error) {
+	if rate, ok := setRate(time.Now()); ok {
+		return rate, nil
+	}
+	usd, _, err := LiveDCRPrice()
+	if err != nil {
+		return PricingRate{}, err
+	}
+	return PricingRate{USD: usd, Source: RateLive}, nil
+}
+
+// GetDCRPrice gets the DCR price in USD that pricing uses, and in BTC. With
+// an override or a fixed rate in effect the USD price is that rate, and the
+// BTC price is the last one fetched, 0 if there is none.
 func GetDCRPrice() (float64, float64, error) {
+	if rate, ok := setRate(time.Now()); ok {
+		rateMutex.RLock()
+		defer rateMutex.RUnlock()
+		return rate.USD, dcrBtcRate, nil
+	}
+	return LiveDCRPrice()
+}
+
+// LiveDCRPrice gets the current DCR price in USD and BTC from CoinGecko
+func LiveDCRPrice() (float64, float64, error) {
 	rateMutex.RLock()
 	if time.Since(lastRateUpdate) < rateCacheTime {
 		usdRate := dcrUsdRate
@@ -83,6 +158,17 @@ func GetDCRPrice() (float64, float64, error) {
 	return usdPrice, btcPrice, nil
 }
 
+// DCRPriceAge returns how long ago the DCR price was fetched, and false if
+// it has not been fetched yet.
+func DCRPriceAge() (time.Duration, bool) {
+	rateMutex.RLock()
+	defer rateMutex.RUnlock()
+	if lastRateUpdate.IsZero() {
+		return 0, false
+	}
+	return time.Since(lastRateUpdate), true
+}
+
 // GetBTCPrice gets the current BTC price in USD from CoinGecko
 func GetBTCPrice() (float64, error) {
 	rateMutex.RLock()
@@ -141,8 +227,9 @@ func GetBTCPrice() (float64, error) {
 	return usdPrice, nil
 }
 
-// USDToDCR converts a USD amount to DCR using current exchange rate
-func USDToDCR(usdAmount float64) (float64, error) {
+// USDToAtoms converts a USD amount to balance atoms using the current
+// exchange rate.
+func USDToAtoms(usdAmount float64) (int64, error) {
 	dcrPrice, _, err := GetDCRPrice()
 	if err != nil {
 		return 0, err
@@ -150,8 +237,14 @@ func USDToDCR(usdAmount float64) (float64, error) {
 	if dcrPrice == 0 {
 		return 0, fmt.Errorf("DCR price is zero, cannot convert")
 	}
+	return money.USDToAtoms(usdAmount, dcrPrice)
+}
 
-	// Calculate DCR amount (USD amount / DCR price)
-	dcrAmount := usdAmount / dcrPrice
-	return dcrAmount, nil
+// USDToDCR converts a USD amount to DCR using current exchange rate
+func USDToDCR(usdAmount float64) (float64, error) {
+	atoms, err := USDToAtoms(usdAmount)
+	if err != nil {
+		return 0, err
+	}
+	return money.AtomsToDCR(atoms), nil
 }
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPricingRate(t *testing.T) {
+	defer SetFixedDCRRate(0)
+	defer SetDCRRateOverride(0, time.Time{})
+
+	SetFixedDCRRate(25)
+	if rate, err := GetPricingRate(); err != nil || rate.USD != 25 || rate.Source != RateFixed {
+		t.Fatalf("GetPricingRate with a fixed rate = %+v, %v", rate, err)
+	}
+	if usd, _, err := GetDCRPrice(); err != nil || usd != 25 {
+		t.Fatalf("GetDCRPrice with a fixed rate = %v, %v", usd, err)
+	}
+	if dcr, err := USDToDCR(5); err != nil || dcr != 0.2 {
+		t.Fatalf("USDToDCR(5) = %v, %v; want 0.2", dcr, err)
+	}
+
+	// An override beats the fixed rate until it expires
+	SetDCRRateOverride(50, time.Now().Add(time.Hour))
+	if rate, err := GetPricingRate(); err != nil || rate.USD != 50 || rate.Source != RateOverride {
+		t.Fatalf("GetPricingRate with an override = %+v, %v", rate, err)
+	}
+	SetDCRRateOverride(50, time.Now().Add(-time.Second))
+	if rate, _ := GetPricingRate(); rate.Source != RateFixed {
+		t.Fatalf("GetPricingRate after the override expired = %+v", rate)
+	}
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"fmt"
+
+	"github.com/karamble/braibot/internal/money"
 )
 
 // FormatDebugBalanceInfo formats debug information about a user's balance
@@ -13,7 +15,7 @@ func FormatDebugBalanceInfo(userID string, balanceAtoms int64, costUSD float64,
 		"  Cost in DCR: %.8f\n"+
 		"  Cost in atoms: %d\n"+
 		"  Balance in DCR: %.8f\n",
-		userID, balanceAtoms, costUSD, costDCR, costAtoms, float64(balanceAtoms)/1e11)
+		userID, balanceAtoms, costUSD, costDCR, costAtoms, money.AtomsToDCR(balanceAtoms))
 }
 
 // FormatDebugAfterDeduction formats debug information after balance deduction
@@ -21,7 +23,7 @@ func FormatDebugAfterDeduction(newBalanceAtoms int64) string {
 	return fmt.Sprintf("DEBUG - After deduction:\n"+
 		"  New balance (atoms): %d\n"+
 		"  New balance in DCR: %.8f\n",
-		newBalanceAtoms, float64(newBalanceAtoms)/1e11)
+		newBalanceAtoms, money.AtomsToDCR(newBalanceAtoms))
 }
 
 // FormatDebugCommandInfo formats debug information for a command
@@ -33,5 +35,5 @@ func FormatDebugCommandInfo(commandName string, userID string, balanceAtoms int6
 		"  Cost in DCR: %.8f\n"+
 		"  Cost in atoms: %d\n"+
 		"  Balance in DCR: %.8f\n",
-		commandName, userID, balanceAtoms, costUSD, costDCR, costAtoms, float64(balanceAtoms)/1e11)
+		commandName, userID, balanceAtoms, costUSD, costDCR, costAtoms, money.AtomsToDCR(balanceAtoms))
 }
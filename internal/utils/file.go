@@ -8,6 +8,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/karamble/braibot/internal/debuglog"
 	kit "github.com/vctt94/bisonbotkit"
 )
 
@@ -45,9 +46,11 @@ func SendFileToUser(ctx context.Context, bot *kit.Bot, userNick string, fileURL
 	defer resp.Body.Close()
 
 	// Copy the data to the temp file
-	if _, err := io.Copy(tmpFile, resp.Body); err != nil {
+	n, err := io.Copy(tmpFile, resp.Body)
+	if err != nil {
 		return fmt.Errorf("failed to save file: %v", err)
 	}
+	debuglog.Debugf(debuglog.Delivery, "Downloaded %s (%d bytes, status %d) for %s", fileURL, n, resp.StatusCode, userNick)
 
 	// Close the file before sending
 	if err := tmpFile.Close(); err != nil {
@@ -58,6 +61,7 @@ func SendFileToUser(ctx context.Context, bot *kit.Bot, userNick string, fileURL
 	if err := bot.SendFile(ctx, userNick, tmpFile.Name()); err != nil {
 		return fmt.Errorf("failed to send file: %v", err)
 	}
+	debuglog.Debugf(debuglog.Delivery, "Sent %s to %s as a file", tmpFile.Name(), userNick)
 
 	return nil
 }
@@ -4,17 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"reflect"
 	"strings"
+	"time"
 
 	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
 	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/money"
+	"github.com/karamble/braibot/internal/templates"
 	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/pkg/fal"
 	kit "github.com/vctt94/bisonbotkit"
 )
 
-// ServiceResult defines an interface for common fields in service results.
-// Assumes result structs have `Success bool` and `Error error` fields or methods.
+// ServiceResult is implemented by the results the generation services
+// return, so callers can check them without knowing their type.
 type ServiceResult interface {
 	IsSuccess() bool
 	GetError() error
@@ -23,20 +27,29 @@ type ServiceResult interface {
 // HandleServiceResultOrError encapsulates common error handling for service calls.
 // It checks for direct errors (like context cancellation, insufficient balance)
 // and then checks the success status within the result.
-// `result` is expected to be a pointer to a struct with `Success bool` and `Error error` fields.
+// `result` may be nil when the command has no result to check.
 // Returns nil if the error was handled (PM sent/logged appropriately), otherwise returns the error to propagate.
-func HandleServiceResultOrError(ctx context.Context, bot *kit.Bot, msgCtx braibottypes.MessageContext, commandName string, result interface{}, err error) error {
+func HandleServiceResultOrError(ctx context.Context, bot *kit.Bot, msgCtx braibottypes.MessageContext, commandName string, result ServiceResult, err error) error {
 	// Create message sender
 	sender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
 
 	// 1. Check direct error from the service call
 	if err != nil {
 		var insufficientBalanceErr *ErrInsufficientBalance // Use utils.ErrInsufficientBalance
+		var validationErr *fal.ValidationError
+		var apiErr *fal.APIError
 		switch {
 		case errors.As(err, &insufficientBalanceErr):
 			pmMsg := fmt.Sprintf("%s generation failed: %s", commandName, insufficientBalanceErr.Error())
 			_ = sender.SendMessage(ctx, msgCtx, pmMsg)
 			return nil // Error handled (user notified)
+		case errors.As(err, &validationErr):
+			_ = sender.SendMessage(ctx, msgCtx, FormatValidationError(commandName, validationErr))
+			return nil // Error handled (user notified)
+		case errors.As(err, &apiErr) && apiErr.Kind != nil:
+			fmt.Printf("INFO [%s] User %s: Request rejected by fal: %v\n", commandName, msgCtx.Nick, err)
+			_ = sender.SendMessage(ctx, msgCtx, FormatAPIError(commandName, apiErr))
+			return nil // Error handled (user notified)
 		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
 			fmt.Printf("INFO [%s] User %s: Context canceled/deadline exceeded: %v\n", commandName, msgCtx.Nick, err)
 			return nil // Error handled (clean termination)
@@ -47,64 +60,82 @@ func HandleServiceResultOrError(ctx context.Context, bot *kit.Bot, msgCtx braibo
 	}
 
 	// 2. Check if the operation failed internally within the service
-	// We need to use reflection to check common fields `Success` and `Error`
-	if result != nil {
-		val := reflect.ValueOf(result)
-		if val.Kind() == reflect.Ptr {
-			val = val.Elem()
-		}
-
-		if val.Kind() == reflect.Struct {
-			successField := val.FieldByName("Success")
-			errorField := val.FieldByName("Error")
-
-			if successField.IsValid() && successField.Kind() == reflect.Bool && !successField.Bool() {
-				var internalErr error
-				if errorField.IsValid() && !errorField.IsNil() {
-					if e, ok := errorField.Interface().(error); ok {
-						internalErr = e
-					}
-				}
-
-				errMsg := fmt.Sprintf("ERROR [%s internal] User %s: %s generation failed internally", commandName, msgCtx.Nick, commandName)
-				if internalErr != nil {
-					errMsg += fmt.Sprintf(": %v", internalErr)
-					fmt.Println(errMsg)
-					return fmt.Errorf("%s generation failed: %w", commandName, internalErr)
-				} else {
-					fmt.Println(errMsg)
-					return fmt.Errorf("%s generation failed internally", commandName)
-				}
-			}
+	if result != nil && !result.IsSuccess() {
+		internalErr := result.GetError()
+		errMsg := fmt.Sprintf("ERROR [%s internal] User %s: %s generation failed internally", commandName, msgCtx.Nick, commandName)
+		if internalErr != nil {
+			errMsg += fmt.Sprintf(": %v", internalErr)
+			fmt.Println(errMsg)
+			return fmt.Errorf("%s generation failed: %w", commandName, internalErr)
 		}
+		fmt.Println(errMsg)
+		return fmt.Errorf("%s generation failed internally", commandName)
 	}
 
 	// Success
 	return nil
 }
 
-// FormatCommandHelpHeader generates the standard header for command help messages.
-func FormatCommandHelpHeader(commandName string, model faladapter.AppModel, userID zkidentity.ShortID, dbManager braibottypes.DBManagerInterface) string {
-	// Get user's balance
-	userIDStr := userID.String()
-	balance, err := dbManager.GetBalance(userIDStr)
-	if err != nil {
-		fmt.Printf("ERROR [FormatCommandHelpHeader] Failed to get balance for %s: %v\n", userIDStr, err)
-		balance = 0
+// FormatValidationError renders a rejected model option as guidance for the
+// user, naming the option and the values it accepts.
+func FormatValidationError(commandName string, verr *fal.ValidationError) string {
+	msg := fmt.Sprintf("%s: invalid value %s for %s.", commandName, SanitizeUserText(fmt.Sprintf("%q", fmt.Sprint(verr.Value))), verr.Field)
+	switch {
+	case len(verr.Allowed) > 0:
+		msg += " Allowed values: " + strings.Join(verr.Allowed, ", ") + "."
+	case verr.Constraint != "":
+		msg += " The value " + verr.Constraint + "."
 	}
-	balanceDCR := float64(balance) / 1e11
+	return msg
+}
 
-	// Get current exchange rate for USD value
-	dcrPrice, _, err := GetDCRPrice()
-	if err != nil {
-		fmt.Printf("ERROR [FormatCommandHelpHeader] Failed to convert USD to DCR: %v\n", err)
-		dcrPrice = 0
+// FormatAPIError tells the user why fal rejected their request and what to
+// do about it. Failed requests are not billed, which it says too.
+func FormatAPIError(commandName string, apiErr *fal.APIError) string {
+	var msg string
+	switch {
+	case errors.Is(apiErr, fal.ErrContentPolicy):
+		msg = fmt.Sprintf("%s: the request was blocked by the provider's content policy. Rephrase the prompt or use a different input.", commandName)
+	case errors.Is(apiErr, fal.ErrInvalidParameter):
+		field := "a parameter"
+		if apiErr.Field != "" {
+			field = apiErr.Field
+		}
+		msg = fmt.Sprintf("%s: the provider rejected %s: %s. See !help %s for the accepted options.", commandName, field, SanitizeUserText(apiErr.Message), commandName)
+	case errors.Is(apiErr, fal.ErrQuotaExceeded):
+		msg = fmt.Sprintf("%s is unavailable right now because the provider's usage limit was reached. Try again later.", commandName)
+	case errors.Is(apiErr, fal.ErrColdStartTimeout):
+		msg = fmt.Sprintf("%s: the model took too long to start. Try again in a minute, when it is warmed up.", commandName)
+	default:
+		msg = fmt.Sprintf("%s generation failed: %s.", commandName, SanitizeUserText(apiErr.Message))
 	}
-	usdValue := balanceDCR * dcrPrice
+	return msg + " You were not charged."
+}
 
-	// Format header
+// FormatCommandHelpHeader generates the standard header for command help
+// messages. The user's balance is only shown in PMs, as help asked for in a
+// group chat is posted there.
+func FormatCommandHelpHeader(commandName string, model faladapter.AppModel, userID zkidentity.ShortID, isPM bool, dbManager braibottypes.DBManagerInterface) string {
 	header := fmt.Sprintf("🤖 **%s Model Help**\n\n", strings.Title(commandName))
-	header += fmt.Sprintf("💰 **Your Balance:** %.8f DCR ($%.2f USD)\n\n", balanceDCR, usdValue)
+	if isPM {
+		// Get user's balance
+		userIDStr := userID.String()
+		balance, err := dbManager.GetBalance(userIDStr)
+		if err != nil {
+			fmt.Printf("ERROR [FormatCommandHelpHeader] Failed to get balance for %s: %v\n", userIDStr, err)
+			balance = 0
+		}
+		balanceDCR := money.AtomsToDCR(balance)
+
+		// Get current exchange rate for USD value
+		dcrPrice, _, err := GetDCRPrice()
+		if err != nil {
+			fmt.Printf("ERROR [FormatCommandHelpHeader] Failed to convert USD to DCR: %v\n", err)
+			dcrPrice = 0
+		}
+		usdValue := balanceDCR * dcrPrice
+		header += fmt.Sprintf("💰 **Your Balance:** %.8f DCR ($%.2f USD)\n\n", balanceDCR, usdValue)
+	}
 	header += fmt.Sprintf("🎯 **Model:** %s\n", model.Name)
 	header += fmt.Sprintf("💵 **Price:** $%.2f USD\n\n", model.PriceUSD)
 
@@ -132,18 +163,135 @@ func FormatBillingMessage(chargedDCR float64, chargedUSD float64, remainingBalan
 //   - billing disabled: show "billing is disabled"
 //
 // taskName is the content type shown in the failure message (e.g. "video", "results", "audio").
+// The messages are the billing templates of templates.Default.
 func FormatBillingConfirmation(taskName string, billingEnabled bool, billingAttempted bool, billingSucceeded bool, chargedDCR float64, chargedUSD float64, finalBalanceDCR float64) string {
-	if !billingEnabled {
-		return "Billing is disabled. No charge was applied."
+	data := templates.Data{Task: taskName, ChargedDCR: chargedDCR, ChargedUSD: chargedUSD, BalanceDCR: finalBalanceDCR}
+	switch {
+	case !billingEnabled:
+		return templates.Render(templates.BillingDisabled, data)
+	case billingAttempted && billingSucceeded:
+		return templates.Render(templates.BillingCharged, data)
+	case billingAttempted:
+		return templates.Render(templates.BillingFailed, data)
+	}
+	return templates.Render(templates.BillingNone, data)
+}
+
+// FormatCachedResult is the receipt for a request answered with the cached
+// result of the same request made age ago, which is not charged.
+func FormatCachedResult(age time.Duration) string {
+	age = age.Round(time.Second)
+	if age < time.Second {
+		age = time.Second
 	}
-	if billingAttempted && billingSucceeded {
-		return fmt.Sprintf("💰 Billing Information:\n• Charged: %.8f DCR ($%.2f USD)\n• New Balance: %.8f DCR",
-			chargedDCR, chargedUSD, finalBalanceDCR)
+	return fmt.Sprintf("♻️ This is the result of your identical request from %s ago, so it was not charged. Add --no-cache to generate it again.", age)
+}
+
+// FormatSplitBillingConfirmation builds the requester's receipt for a
+// request split with a GC pot, showing both shares and balances. It is sent
+// by PM; the group chat gets FormatSplitPotReceipt.
+func FormatSplitBillingConfirmation(charge *SplitCharge, chargedUSD float64) string {
+	return templates.Render(templates.BillingSplit, templates.Data{
+		ChargedDCR:    charge.UserDCR + charge.PotDCR,
+		ChargedUSD:    chargedUSD,
+		BalanceDCR:    charge.UserBalanceDCR,
+		SplitPercent:  charge.Percent,
+		UserDCR:       charge.UserDCR,
+		PotDCR:        charge.PotDCR,
+		PotBalanceDCR: charge.PotBalanceDCR,
+	})
+}
+
+// FormatSplitPotReceipt builds the receipt posted in the group chat for a
+// request split with its pot: only the pot's share and what is left in it.
+func FormatSplitPotReceipt(charge *SplitCharge) string {
+	return templates.Render(templates.BillingSplitGC, templates.Data{
+		SplitPercent:  charge.Percent,
+		PotDCR:        charge.PotDCR,
+		PotBalanceDCR: charge.PotBalanceDCR,
+	})
+}
+
+// DefaultLowBalanceUSD is the balance, in USD, under which receipts warn
+// that the balance is running low.
+const DefaultLowBalanceUSD = 1.0
+
+// lowBalanceUSD is the low balance threshold in effect, set at startup.
+var lowBalanceUSD = DefaultLowBalanceUSD
+
+// SetLowBalanceThreshold sets the balance, in USD, under which receipts
+// warn that the balance is running low. 0 turns the warning off.
+func SetLowBalanceThreshold(usd float64) {
+	lowBalanceUSD = usd
+}
+
+// LowBalanceWarning is the warning appended to the receipt a user gets by
+// PM when the balance left after a charge is worth less than the threshold.
+// The balance is valued at the rate of the charge, so no rate needs to be
+// fetched. It is empty when the balance is not low, the charge was free, or
+// the user muted the bot's non-essential messages with !mute. It must not be
+// added to messages posted in group chats.
+func LowBalanceWarning(prefs MutePrefs, uid string, balanceDCR, chargedDCR, chargedUSD float64) string {
+	if lowBalanceUSD <= 0 || chargedDCR <= 0 || chargedUSD <= 0 {
+		return ""
+	}
+	balanceUSD := balanceDCR * chargedUSD / chargedDCR
+	if balanceUSD >= lowBalanceUSD {
+		return ""
+	}
+	if prefs != nil {
+		if muted, err := prefs.GetMuted(uid); err != nil || muted {
+			return ""
+		}
+	}
+	return fmt.Sprintf("\n🔋 Your balance is running low (about $%.2f USD left). Use !topup [usd_amount] for tip instructions, or tip the bot any amount, to keep generating.", balanceUSD)
+}
+
+// FormatProcessingNotice builds the message sent when a request starts: its
+// cost and the requester's balance by PM, or a short notice in group chats.
+// The cost in data is left out when the request is not billed.
+func FormatProcessingNotice(req *braibottypes.GenerationRequest, billed bool, data templates.Data) string {
+	data.ModelName = req.ModelName
+	data.JobID = req.JobID
+	data.Nick = req.UserNick
+	switch {
+	case !req.IsPM:
+		return templates.Render(templates.ProcessingGC, data)
+	case billed:
+		return templates.Render(templates.Processing, data)
+	}
+	return templates.Render(templates.ProcessingFree, data)
+}
+
+// FormatFinished builds the first line of the message confirming a request
+// was delivered, by PM or in its group chat.
+func FormatFinished(req *braibottypes.GenerationRequest, data templates.Data) string {
+	data.ModelName = req.ModelName
+	data.JobID = req.JobID
+	data.Nick = req.UserNick
+	if !req.IsPM {
+		return templates.Render(templates.FinishedGC, data)
+	}
+	return templates.Render(templates.Finished, data)
+}
+
+// FormatAssetLink formats a result posted as an asset-server link, with
+// the time the link stops working.
+func FormatAssetLink(label, link string, expires time.Time) string {
+	return fmt.Sprintf("🔗 %s: %s\n(link expires %s)", label, link, expires.UTC().Format("Jan 2 15:04 MST"))
+}
+
+// FormatJobRetention tells the user how to re-deliver a finished job and
+// until when it is kept.
+func FormatJobRetention(job *database.Job) string {
+	msg := fmt.Sprintf("📦 Job #%d: use !redeliver %d to get it again.", job.ID, job.ID)
+	if !job.ExpiresAt.IsZero() {
+		msg = fmt.Sprintf("📦 Job #%d: use !redeliver %d to get it again until %s.", job.ID, job.ID, job.ExpiresAt.UTC().Format("Jan 2 15:04 MST"))
 	}
-	if billingAttempted && !billingSucceeded {
-		return fmt.Sprintf("⚠️ Billing failed after sending %s. Your balance remains %.8f DCR. Please contact support.", taskName, finalBalanceDCR)
+	if job.ChargedAtoms > 0 {
+		msg += fmt.Sprintf("\nNot what you asked for? Use !refund %d [reason] to request a refund.", job.ID)
 	}
-	return fmt.Sprintf("No charge was applied. Your balance remains %.8f DCR.", finalBalanceDCR)
+	return msg
 }
 
 // FormatThousands formats a float64 with commas as thousands separators, rounded to the nearest integer.
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/karamble/braibot/internal/templates"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+func TestFormatAPIError(t *testing.T) {
+	tests := []struct {
+		err  *fal.APIError
+		want string
+	}{
+		{&fal.APIError{Kind: fal.ErrContentPolicy, Message: "flagged"}, "content policy"},
+		{&fal.APIError{Kind: fal.ErrInvalidParameter, Field: "image_size", Message: "unexpected *value*"}, `rejected image_size: unexpected \*value\*`},
+		{&fal.APIError{Kind: fal.ErrQuotaExceeded}, "usage limit"},
+		{&fal.APIError{Kind: fal.ErrColdStartTimeout}, "too long to start"},
+	}
+	for _, tt := range tests {
+		got := FormatAPIError("text2image", tt.err)
+		if !strings.Contains(got, tt.want) || !strings.HasSuffix(got, "You were not charged.") {
+			t.Errorf("FormatAPIError(%v) = %q, want it to mention %q and the charge", tt.err.Kind, got, tt.want)
+		}
+	}
+}
+
+type testResult struct {
+	success bool
+	err     error
+}
+
+func (r testResult) IsSuccess() bool { return r.success }
+func (r testResult) GetError() error { return r.err }
+
+func TestHandleServiceResultOrError(t *testing.T) {
+	ctx := context.Background()
+	var msgCtx braibottypes.MessageContext
+	internal := errors.New("upload failed")
+
+	if err := HandleServiceResultOrError(ctx, nil, msgCtx, "text2image", nil, nil); err != nil {
+		t.Errorf("nil result: got %v, want nil", err)
+	}
+	if err := HandleServiceResultOrError(ctx, nil, msgCtx, "text2image", testResult{success: true}, nil); err != nil {
+		t.Errorf("successful result: got %v, want nil", err)
+	}
+	err := HandleServiceResultOrError(ctx, nil, msgCtx, "text2image", testResult{err: internal}, nil)
+	if !errors.Is(err, internal) {
+		t.Errorf("failed result: got %v, want it to wrap %v", err, internal)
+	}
+	if err := HandleServiceResultOrError(ctx, nil, msgCtx, "text2image", testResult{}, nil); err == nil {
+		t.Error("failed result without an error: got nil, want an error")
+	}
+}
+
+func TestFormatBillingConfirmation(t *testing.T) {
+	tests := []struct {
+		enabled, attempted, succeeded bool
+		want                          string
+	}{
+		{false, false, false, "Billing is disabled. No charge was applied."},
+		{true, true, true, "💰 Billing Information:\n• Charged: 0.10000000 DCR ($2.00 USD)\n• New Balance: 0.90000000 DCR"},
+		{true, true, false, "⚠️ Billing failed after sending video. Your balance remains 0.90000000 DCR. Please contact support."},
+		{true, false, false, "No charge was applied. Your balance remains 0.90000000 DCR."},
+	}
+	for _, tt := range tests {
+		if got := FormatBillingConfirmation("video", tt.enabled, tt.attempted, tt.succeeded, 0.1, 2, 0.9); got != tt.want {
+			t.Errorf("FormatBillingConfirmation(%v, %v, %v) = %q, want %q", tt.enabled, tt.attempted, tt.succeeded, got, tt.want)
+		}
+	}
+
+	split := &SplitCharge{Percent: 25, UserDCR: 0.075, PotDCR: 0.025, UserBalanceDCR: 0.5, PotBalanceDCR: 1}
+	want := "💰 Billing Information (split with GC pot):\n• Total: 0.10000000 DCR ($2.00 USD)\n• You paid: 0.07500000 DCR (75%)\n• GC pot paid: 0.02500000 DCR (25%)\n• New Balance: 0.50000000 DCR\n• GC pot balance: 1.00000000 DCR"
+	if got := FormatSplitBillingConfirmation(split, 2); got != want {
+		t.Errorf("FormatSplitBillingConfirmation = %q, want %q", got, want)
+	}
+	want = "🫙 The GC pot paid 0.02500000 DCR (25%) of this request.\n• Left in the pot: 1.00000000 DCR"
+	if got := FormatSplitPotReceipt(split); got != want {
+		t.Errorf("FormatSplitPotReceipt = %q, want %q", got, want)
+	}
+}
+
+// mutePrefs maps uids to their !mute preference.
+type mutePrefs map[string]bool
+
+func (p mutePrefs) GetMuted(uid string) (bool, error) { return p[uid], nil }
+
+func TestLowBalanceWarning(t *testing.T) {
+	defer SetLowBalanceThreshold(DefaultLowBalanceUSD)
+	SetLowBalanceThreshold(1)
+	prefs := mutePrefs{"muted": true}
+
+	// 0.01 DCR left at $20 per DCR is $0.20
+	if got := LowBalanceWarning(prefs, "alice", 0.01, 0.1, 2); !strings.Contains(got, "🔋 Your balance is running low (about $0.20 USD left)") {
+		t.Errorf("low balance warning = %q", got)
+	}
+	if got := LowBalanceWarning(prefs, "alice", 0.5, 0.1, 2); got != "" {
+		t.Errorf("warning above the threshold: %q", got)
+	}
+	if got := LowBalanceWarning(prefs, "muted", 0.01, 0.1, 2); got != "" {
+		t.Errorf("warning for a muted user: %q", got)
+	}
+	// Receipts leave the warning to the caller, who knows where they go
+	if got := FormatBillingConfirmation("video", true, true, true, 0.1, 2, 0.01); strings.Contains(got, "🔋") {
+		t.Errorf("receipt with a low balance warning: %q", got)
+	}
+	SetLowBalanceThreshold(0)
+	if got := LowBalanceWarning(prefs, "alice", 0.01, 0.1, 2); got != "" {
+		t.Errorf("warning with the threshold off: %q", got)
+	}
+}
+
+func TestFormatProcessingNotice(t *testing.T) {
+	req := &braibottypes.GenerationRequest{ModelName: "flux", IsPM: true}
+	data := templates.Data{Task: "image", Action: "Processing 2 image(s)", CostUSD: 0.1, CostDCR: 0.005, BalanceDCR: 1}
+	if got := FormatProcessingNotice(req, true, data); got != "Request cost: $0.10 USD (0.00500000 DCR). Your balance: 1.00000000 DCR. Processing 2 image(s)..." {
+		t.Errorf("billed notice = %q", got)
+	}
+	if got := FormatProcessingNotice(req, false, data); got != "Processing 2 image(s) (billing disabled)..." {
+		t.Errorf("unbilled notice = %q", got)
+	}
+	req.IsPM = false
+	if got := FormatProcessingNotice(req, true, data); got != "Processing your image request..." {
+		t.Errorf("GC notice = %q", got)
+	}
+}
+
+func TestFormatCachedResult(t *testing.T) {
+	if msg := FormatCachedResult(4*time.Minute + 20*time.Second); !strings.Contains(msg, "4m20s ago") || !strings.Contains(msg, "--no-cache") {
+		t.Errorf("FormatCachedResult = %q", msg)
+	}
+	if msg := FormatCachedResult(0); !strings.Contains(msg, "1s ago") {
+		t.Errorf("FormatCachedResult(0) = %q", msg)
+	}
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/internal/assets"
+	"github.com/karamble/braibot/internal/database"
+	braibottypes "github.com/karamble/braibot/internal/types"
+)
+
+// RememberResult records a result delivered for req, so the user can pass
+// it to the next command as "last", and copies it into the media cache when
+// there is one. Only http(s) URLs are kept; a failure is logged and
+// otherwise ignored.
+func RememberResult(dbManager *database.DBManager, req *braibottypes.GenerationRequest, kind, url string) {
+	if dbManager == nil {
+		return
+	}
+	lower := strings.ToLower(url)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return
+	}
+	if err := dbManager.RecordResult(req.UserID.String(), kind, req.ModelName, url, time.Now()); err != nil {
+		fmt.Printf("WARN: Failed to remember result for %s: %v\n", req.UserNick, err)
+	}
+	if cache := assets.DefaultCache; cache != nil {
+		go func() {
+			if _, err := cache.Add(context.Background(), url); err != nil && !errors.Is(err, assets.ErrNotCacheable) {
+				fmt.Printf("WARN: Failed to cache result for %s: %v\n", req.UserNick, err)
+			}
+		}()
+	}
+}
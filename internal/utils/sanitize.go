@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// markdownEscaper backslash-escapes the characters Bison Relay's markdown
+// renderer treats as formatting.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"~", `\~`,
+	"#", `\#`,
+	"|", `\|`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	">", `\>`,
+)
+
+// SanitizeUserText makes user-provided text safe to echo back inside a bot
+// message. Markdown is escaped, line breaks and control characters are
+// flattened to spaces so the text cannot start headers or table rows, and
+// embed markers ("--embed[") are broken up so the text can never be rendered
+// as an inline attachment.
+func SanitizeUserText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '\u2028' || r == '\u2029' {
+			return ' '
+		}
+		return r
+	}, s)
+	s = markdownEscaper.Replace(s)
+	// "[" is already escaped, but a renderer that ignores escapes must still
+	// not see the marker, so also separate the dashes.
+	for strings.Contains(s, "--") {
+		s = strings.ReplaceAll(s, "--", "-\u200b-")
+	}
+	return s
+}
+
+// PromptPreviewRunes is how much of a prompt is echoed back in confirmations.
+const PromptPreviewRunes = 200
+
+// PreviewUserText sanitizes user-provided text and shortens it to at most
+// maxRunes runes of the original, for echoing prompts in confirmations.
+func PreviewUserText(s string, maxRunes int) string {
+	runes := []rune(s)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		return SanitizeUserText(string(runes[:maxRunes])) + "…"
+	}
+	return SanitizeUserText(s)
+}
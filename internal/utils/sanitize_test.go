@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeUserText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain", "a cat on the moon", "a cat on the moon"},
+		{"markdown", "**bold** _it_ `code` # head", `\*\*bold\*\* \_it\_ \` + "`code\\`" + ` \# head`},
+		{"table row", "| a | b |", `\| a \| b \|`},
+		{"newlines", "line1\n## line2\r\nline3", `line1 \#\# line2  line3`},
+		{"link", "[click](http://x)", `\[click\](http://x)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeUserText(tt.input); got != tt.want {
+				t.Errorf("SanitizeUserText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeUserTextEmbedInjection(t *testing.T) {
+	attempts := []string{
+		"--embed[alt=x,type=image/png,data=AAAA]--",
+		"hi --embed[type=audio/ogg,data=AAAA]-- there",
+		"---embed[type=text/plain,data=QQ==]---",
+		"-\n-embed[type=image/png,data=AAAA]--",
+		"--EMBED[type=image/png,data=AAAA]--",
+		"--embed [type=image/png,data=AAAA]--",
+	}
+	for _, in := range attempts {
+		got := SanitizeUserText(in)
+		if strings.Contains(strings.ToLower(got), "--embed") || strings.Contains(got, "--") {
+			t.Errorf("SanitizeUserText(%q) = %q still contains an embed marker", in, got)
+		}
+		if strings.ContainsAny(got, "\n\r\u2028") {
+			t.Errorf("SanitizeUserText(%q) = %q still contains a line break", in, got)
+		}
+		if strings.Contains(got, "[") && !strings.Contains(got, `\[`) {
+			t.Errorf("SanitizeUserText(%q) = %q has an unescaped bracket", in, got)
+		}
+	}
+}
+
+func TestPreviewUserText(t *testing.T) {
+	if got := PreviewUserText("short", 10); got != "short" {
+		t.Errorf("PreviewUserText short = %q", got)
+	}
+	if got := PreviewUserText("a very long *prompt*", 8); got != "a very l…" {
+		t.Errorf("PreviewUserText long = %q", got)
+	}
+	if got := PreviewUserText("ab--embed[", 4); got != "ab-\u200b-…" {
+		t.Errorf("PreviewUserText embed = %q", got)
+	}
+}
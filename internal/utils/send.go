@@ -2,7 +2,12 @@ package utils
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/karamble/braibot/internal/assets"
+	"github.com/karamble/braibot/internal/jobevents"
+	"github.com/karamble/braibot/internal/queue"
+	braibottypes "github.com/karamble/braibot/internal/types"
 	kit "github.com/vctt94/bisonbotkit"
 )
 
@@ -13,3 +18,54 @@ func SendToUser(ctx context.Context, bot *kit.Bot, isPM bool, nick, gc, msg stri
 	}
 	return bot.SendGC(ctx, gc, msg)
 }
+
+// SendGCLink posts a result in a group chat as a link, copied to the asset
+// server when one is configured and pointing at the provider otherwise.
+func SendGCLink(ctx context.Context, bot *kit.Bot, publisher *assets.Publisher, gc, label, resultURL string) error {
+	if publisher != nil {
+		link, expires, err := publisher.PublishURL(ctx, resultURL)
+		if err == nil {
+			return bot.SendGC(ctx, gc, FormatAssetLink(label, link, expires))
+		}
+		fmt.Printf("WARN: Failed to publish %s to the asset server: %v\n", label, err)
+	}
+	return bot.SendGC(ctx, gc, fmt.Sprintf("📎 %s: %s", label, resultURL))
+}
+
+// MutePrefs reads the preference users set with !mute.
+type MutePrefs interface {
+	GetMuted(uid string) (bool, error)
+}
+
+// PMSender sends PMs by nick or uid, like the Bison Relay client.
+type PMSender interface {
+	SendPM(ctx context.Context, nick, msg string) error
+}
+
+// SendNoticePM sends a non-essential PM, such as a welcome prompt, tip thank
+// you or job notification, unless the user muted the bot with !mute. Command
+// results must not go through here.
+func SendNoticePM(ctx context.Context, bot PMSender, prefs MutePrefs, uid, msg string) error {
+	if prefs != nil {
+		muted, err := prefs.GetMuted(uid)
+		if err != nil {
+			return err
+		}
+		if muted {
+			return nil
+		}
+	}
+	return bot.SendPM(ctx, uid, msg)
+}
+
+// AcquireJobSlot waits for a free concurrency slot for the request's job
+// kind, telling the user their queue position while they wait. The returned
+// function releases the slot.
+func AcquireJobSlot(ctx context.Context, bot *kit.Bot, req *braibottypes.GenerationRequest) (func(), error) {
+	kind := queue.KindFor(req.ModelType)
+	return queue.Default.Acquire(ctx, kind, func(position int) {
+		jobevents.Default.EmitQueued(req, position)
+		SendToUser(ctx, bot, req.IsPM, req.UserID.String(), req.GC,
+			fmt.Sprintf("⏳ All %s slots are busy. %s, your job is #%d in line and will start automatically.", kind, SanitizeUserText(req.UserNick), position))
+	})
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/i18n"
+)
+
+// Keys of the generation defaults users save with !set. Services fill in
+// a saved default only when the matching flag is absent from the request.
+const (
+	SettingAspect         = "aspect"          // Aspect ratio, e.g. 16:9
+	SettingNegativePrompt = "negative_prompt" // Negative prompt for models that take one
+	SettingVoice          = "voice_id"        // text2speech voice
+	SettingNSFW           = "nsfw"            // strict, relaxed or off
+	SettingOutputFormat   = "output_format"   // Image format: jpeg, png or webp
+	SettingSeed           = "seed"            // Fixed seed; unset for a random one
+	SettingLanguage       = "language"        // Language of the bot's messages
+	SettingTipReceipts    = "tip_receipts"    // on or off
+	SettingWeeklySummary  = "weekly_summary"  // on or off; off when unset
+)
+
+// maxSettingRunes caps the length of a saved setting value.
+const maxSettingRunes = 500
+
+// UserSettingHelp describes each setting, in the order !settings lists them.
+var UserSettingHelp = []struct{ Key, Help string }{
+	{SettingAspect, "aspect ratio for images and videos, e.g. 16:9, 1:1, 9:16"},
+	{SettingNegativePrompt, "things to avoid, for models that take a negative prompt"},
+	{SettingVoice, "text2speech voice, e.g. Wise_Woman"},
+	{SettingNSFW, "image safety filter: strict, relaxed or off"},
+	{SettingOutputFormat, "image format: jpeg, png or webp"},
+	{SettingSeed, "fixed seed for reproducible results (unset for random)"},
+	{SettingLanguage, "language of the bot's messages, e.g. en, de, es or fr"},
+	{SettingTipReceipts, "receipts for your tips: on or off"},
+	{SettingWeeklySummary, "a weekly PM of your spending and balance: on or off"},
+}
+
+// NormalizeUserSetting checks value for the setting key and returns it in
+// the form it is stored.
+func NormalizeUserSetting(key, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("a value is required for %s", key)
+	}
+	if utf8.RuneCountInString(value) > maxSettingRunes {
+		return "", fmt.Errorf("%s is limited to %d characters", key, maxSettingRunes)
+	}
+	switch key {
+	case SettingAspect:
+		w, h, ok := strings.Cut(value, ":")
+		wn, werr := strconv.Atoi(w)
+		hn, herr := strconv.Atoi(h)
+		if !ok || werr != nil || herr != nil || wn <= 0 || hn <= 0 || wn > 21 || hn > 21 {
+			return "", fmt.Errorf("aspect must be a ratio such as 16:9")
+		}
+		return fmt.Sprintf("%d:%d", wn, hn), nil
+	case SettingNegativePrompt:
+		return value, nil
+	case SettingVoice:
+		if strings.ContainsAny(value, " \t") {
+			return "", fmt.Errorf("voice_id must be a single word, e.g. Wise_Woman")
+		}
+		return value, nil
+	case SettingNSFW:
+		value = strings.ToLower(value)
+		if value != "strict" && value != "relaxed" && value != "off" {
+			return "", fmt.Errorf("nsfw must be strict, relaxed or off")
+		}
+		return value, nil
+	case SettingOutputFormat:
+		value = strings.ToLower(value)
+		if value == "jpg" {
+			value = "jpeg"
+		}
+		if value != "jpeg" && value != "png" && value != "webp" {
+			return "", fmt.Errorf("output_format must be jpeg, png or webp")
+		}
+		return value, nil
+	case SettingSeed:
+		seed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || seed < 0 {
+			return "", fmt.Errorf("seed must be a whole number from 0 to %d", int32(^uint32(0)>>1))
+		}
+		return strconv.FormatInt(seed, 10), nil
+	case SettingLanguage:
+		value = strings.ToLower(value)
+		if !i18n.Default.Has(value) {
+			return "", fmt.Errorf("language must be one of %s", strings.Join(i18n.Default.Languages(), ", "))
+		}
+		return value, nil
+	case SettingTipReceipts, SettingWeeklySummary:
+		value = strings.ToLower(value)
+		if value != "on" && value != "off" {
+			return "", fmt.Errorf("%s must be on or off", key)
+		}
+		return value, nil
+	}
+	return "", fmt.Errorf("unknown setting %s", key)
+}
+
+// LoadUserSettings returns the user's saved defaults. A lookup failure is
+// logged and treated as no defaults, so generation goes ahead without them.
+func LoadUserSettings(dbManager *database.DBManager, uid string) map[string]string {
+	if dbManager == nil {
+		return nil
+	}
+	settings, err := dbManager.GetUserSettings(uid)
+	if err != nil {
+		fmt.Printf("WARN: Failed to load settings for %s: %v\n", uid, err)
+		return nil
+	}
+	return settings
+}
+
+// SafetyLevel maps the nsfw setting to the safety checker switch and the
+// safety tolerance of models that take one. Tolerances stay within 1-5,
+// the range every such model accepts. ok is false for an unknown level.
+func SafetyLevel(level string) (checker bool, tolerance string, ok bool) {
+	switch level {
+	case "strict":
+		return true, "1", true
+	case "relaxed":
+		return true, "4", true
+	case "off":
+		return false, "5", true
+	}
+	return false, "", false
+}
+
+// UserLanguage returns the language uid set with !set language, or
+// i18n.DefaultLanguage.
+func UserLanguage(dbManager *database.DBManager, uid string) string {
+	if lang := LoadUserSettings(dbManager, uid)[SettingLanguage]; lang != "" {
+		return lang
+	}
+	return i18n.DefaultLanguage
+}
+
+// UserText returns the message key in the language of uid.
+func UserText(dbManager *database.DBManager, uid, key string, args i18n.Args) string {
+	return i18n.Text(UserLanguage(dbManager, uid), key, args)
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/karamble/braibot/internal/database"
+)
+
+// SpendLimits caps what a user may be charged for generations in USD over
+// the last 24 hours and the last 7 days. A zero limit is unlimited.
+type SpendLimits struct {
+	DailyUSD  float64
+	WeeklyUSD float64
+}
+
+var (
+	spendLimitsMu      sync.RWMutex
+	defaultSpendLimits SpendLimits
+)
+
+// SetSpendLimits sets the limits of users without limits of their own.
+// Negative or NaN limits are treated as unlimited.
+func SetSpendLimits(l SpendLimits) {
+	l.DailyUSD, l.WeeklyUSD = validLimit(l.DailyUSD), validLimit(l.WeeklyUSD)
+	spendLimitsMu.Lock()
+	defaultSpendLimits = l
+	spendLimitsMu.Unlock()
+}
+
+// DefaultSpendLimits returns the limits of users without limits of their
+// own.
+func DefaultSpendLimits() SpendLimits {
+	spendLimitsMu.RLock()
+	defer spendLimitsMu.RUnlock()
+	return defaultSpendLimits
+}
+
+func validLimit(usd float64) float64 {
+	if usd < 0 || math.IsNaN(usd) {
+		return 0
+	}
+	return usd
+}
+
+// SpendAllowance is what a user spent against their limits.
+type SpendAllowance struct {
+	Limits    SpendLimits
+	SpentDay  float64 // USD charged in the last 24 hours
+	SpentWeek float64 // USD charged in the last 7 days
+}
+
+// RemainingDay returns what the user may still spend before hitting the
+// daily limit, or -1 when the day is unlimited.
+func (a SpendAllowance) RemainingDay() float64 {
+	return remaining(a.Limits.DailyUSD, a.SpentDay)
+}
+
+// RemainingWeek returns what the user may still spend before hitting the
+// weekly limit, or -1 when the week is unlimited.
+func (a SpendAllowance) RemainingWeek() float64 {
+	return remaining(a.Limits.WeeklyUSD, a.SpentWeek)
+}
+
+func remaining(limit, spent float64) float64 {
+	if limit == 0 {
+		return -1
+	}
+	return math.Max(0, limit-spent)
+}
+
+// GetSpendAllowance returns the limits in effect for uid, the defaults with
+// the user's overrides applied, and what the user spent against them.
+func GetSpendAllowance(dbManager *database.DBManager, uid string, now time.Time) (SpendAllowance, error) {
+	a := SpendAllowance{Limits: DefaultSpendLimits()}
+	o, err := dbManager.SpendLimitOverride(uid)
+	if err != nil {
+		return a, err
+	}
+	if o.DailyUSD != nil {
+		a.Limits.DailyUSD = *o.DailyUSD
+	}
+	if o.WeeklyUSD != nil {
+		a.Limits.WeeklyUSD = *o.WeeklyUSD
+	}
+	if a.Limits.DailyUSD > 0 {
+		if a.SpentDay, err = dbManager.SpentSince(uid, now.Add(-24*time.Hour)); err != nil {
+			return a, err
+		}
+	}
+	if a.Limits.WeeklyUSD > 0 {
+		if a.SpentWeek, err = dbManager.SpentSince(uid, now.Add(-database.SpendRetention)); err != nil {
+			return a, err
+		}
+	}
+	return a, nil
+}
+
+// checkSpendLimit returns an ErrInsufficientBalance when charging costUSD
+// would take uid over one of their spending limits.
+func checkSpendLimit(dbManager *database.DBManager, uid string, costUSD float64) error {
+	a, err := GetSpendAllowance(dbManager, uid, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to check spending limits: %v", err)
+	}
+	if left := a.RemainingDay(); left >= 0 && costUSD > left {
+		return &ErrInsufficientBalance{
+			Message: fmt.Sprintf("This request ($%.2f) would exceed your daily spending limit of $%.2f; $%.2f is left for the last 24 hours. See !limits.",
+				costUSD, a.Limits.DailyUSD, left),
+		}
+	}
+	if left := a.RemainingWeek(); left >= 0 && costUSD > left {
+		return &ErrInsufficientBalance{
+			Message: fmt.Sprintf("This request ($%.2f) would exceed your weekly spending limit of $%.2f; $%.2f is left for the last 7 days. See !limits.",
+				costUSD, a.Limits.WeeklyUSD, left),
+		}
+	}
+	return nil
+}
+
+// recordSpend counts a charge of uid against their spending limits.
+func recordSpend(dbManager *database.DBManager, uid string, costUSD float64) {
+	if costUSD <= 0 {
+		return
+	}
+	if err := dbManager.RecordSpend(uid, costUSD, time.Now()); err != nil {
+		fmt.Printf("WARN: Failed to count a charge of %s against their spending limits: %v\n", uid, err)
+	}
+}
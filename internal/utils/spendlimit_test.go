@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/money"
+	braibottypes "github.com/karamble/braibot/internal/types"
+)
+
+func TestCheckSpendLimit(t *testing.T) {
+	dm, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+	SetSpendLimits(SpendLimits{DailyUSD: 1, WeeklyUSD: 3})
+	defer SetSpendLimits(SpendLimits{})
+
+	now := time.Now()
+	dm.RecordSpend("alice", 0.75, now.Add(-time.Hour))
+	dm.RecordSpend("alice", 2, now.Add(-48*time.Hour))
+
+	a, err := GetSpendAllowance(dm, "alice", now)
+	if err != nil {
+		t.Fatalf("GetSpendAllowance: %v", err)
+	}
+	if a.RemainingDay() != 0.25 || a.RemainingWeek() != 0.25 {
+		t.Fatalf("remaining = %v/day, %v/week; want 0.25 each", a.RemainingDay(), a.RemainingWeek())
+	}
+	if err := checkSpendLimit(dm, "alice", 0.2); err != nil {
+		t.Fatalf("checkSpendLimit within the limits: %v", err)
+	}
+	var limitErr *ErrInsufficientBalance
+	if err := checkSpendLimit(dm, "alice", 0.5); !errors.As(err, &limitErr) {
+		t.Fatalf("checkSpendLimit over the daily limit = %v", err)
+	}
+
+	// An unlimited day still leaves the week
+	unlimited := 0.0
+	dm.SetSpendLimitOverride(database.SpendLimitOverride{UID: "alice", DailyUSD: &unlimited})
+	a, _ = GetSpendAllowance(dm, "alice", now)
+	if a.RemainingDay() != -1 || a.RemainingWeek() != 0.25 {
+		t.Fatalf("remaining with override = %v/day, %v/week", a.RemainingDay(), a.RemainingWeek())
+	}
+	if err := checkSpendLimit(dm, "alice", 0.5); !errors.As(err, &limitErr) {
+		t.Fatalf("checkSpendLimit over the weekly limit = %v", err)
+	}
+	if err := checkSpendLimit(dm, "bob", 0.5); err != nil {
+		t.Fatalf("checkSpendLimit of a user without charges: %v", err)
+	}
+}
+
+func TestRepeatedChargeSpend(t *testing.T) {
+	dm, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer dm.Close()
+	SetFixedDCRRate(20)
+	defer SetFixedDCRRate(0)
+	SetSpendLimits(SpendLimits{DailyUSD: 10})
+	defer SetSpendLimits(SpendLimits{})
+
+	req := &braibottypes.GenerationRequest{UserID: zkidentity.ShortID{1}, GC: "lounge"}
+	uid := req.UserID.String()
+	if err := dm.UpdateBalance(uid, money.AtomsPerDCR); err != nil {
+		t.Fatalf("UpdateBalance: %v", err)
+	}
+
+	// Both runs of queued job 7 charge the request under the same id, so
+	// the second run neither charges again nor counts the cost again
+	for run := 0; run < 2; run++ {
+		ctx := WithJobCharges(context.Background(), 7)
+		runReq := *req
+		if id := RequestChargeID(ctx, &runReq); id != "job-7-1" {
+			t.Fatalf("RequestChargeID = %q, want job-7-1", id)
+		}
+		if _, _, _, err := DeductRequestBalance(ctx, dm, &runReq, 2, false, true); err != nil {
+			t.Fatalf("DeductRequestBalance run %d: %v", run+1, err)
+		}
+	}
+	if balance, _ := dm.GetBalance(uid); balance != money.AtomsPerDCR*9/10 {
+		t.Errorf("balance = %d, want %d", balance, money.AtomsPerDCR*9/10)
+	}
+	now := time.Now()
+	if a, _ := GetSpendAllowance(dm, uid, now); a.RemainingDay() != 8 {
+		t.Errorf("remaining = %v/day, want 8", a.RemainingDay())
+	}
+	if spent, _ := dm.GCSpend("lounge", now); spent != 2 {
+		t.Errorf("GC spend = %v, want 2", spent)
+	}
+}
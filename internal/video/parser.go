@@ -4,14 +4,18 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/karamble/braibot/internal/params"
 )
 
 // ParseResult holds the result of parsing command arguments.
 // All parse functions return this struct; unused fields are zero-valued.
 type ParseResult struct {
 	Prompt          string
-	ImageURL        string   // image2video only
-	VideoURL        string   // video2video only
+	ImageURL        string // image2video / video2video motion control
+	VideoURL        string // video2video only
+	AudioURL        string // video2video lipsync only
+	SecondURL       string // video2video: URL given right after the video URL
 	Duration        string
 	AspectRatio     string
 	NegativePrompt  string
@@ -25,6 +29,14 @@ type ParseResult struct {
 	ImageURLs       []string // multi2video / video2video
 	VideoURLs       []string // multi2video only
 	AudioURLs       []string // multi2video only
+	Factor          int      // video2video frame interpolation only
+	SlowMotion      *bool    // video2video frame interpolation only
+	Orientation     string   // video2video motion control only
+	ProviderModel   string   // video2video upscale / lipsync only
+	OutputType      string   // video2video upscale / lipsync only
+	Subtitles       bool     // text2video / image2video: send .srt subtitles
+	BurnSubtitles   bool     // text2video / image2video: burn the subtitles in
+	Script          string   // text2video / image2video: subtitle text, default: the prompt
 }
 
 // ArgumentParser parses command arguments for video generation
@@ -35,244 +47,70 @@ func NewArgumentParser() *ArgumentParser {
 	return &ArgumentParser{}
 }
 
-// ParseDuration parses the duration argument
-func (p *ArgumentParser) ParseDuration(args []string) string {
-	for i, arg := range args {
-		if arg == "--duration" && i+1 < len(args) {
-			return strings.TrimSuffix(args[i+1], "s")
-		}
-	}
-	return "5" // Default duration
-}
+// Defaults of text2video and image2video requests. The parser leaves the
+// aspect ratio and negative prompt empty when they are not given, so the
+// service can fill in the user's saved defaults first.
+const (
+	DefaultAspectRatio    = "16:9"
+	DefaultNegativePrompt = "blur, distort, and low quality"
+)
 
-// ParseAspectRatio parses the aspect ratio argument
-func (p *ArgumentParser) ParseAspectRatio(args []string) string {
-	for i, arg := range args {
-		if arg == "--aspect" && i+1 < len(args) {
-			return args[i+1]
-		}
-	}
-	return "16:9" // Default aspect ratio
+// videoFlags are the options of text2video and image2video.
+var videoFlags = []params.Flag{
+	params.NewFlag(params.Lower, "duration"),
+	params.NewFlag(params.String, "aspect_ratio", "aspect"),
+	params.NewFlag(params.String, "negative_prompt"),
+	params.NewFlag(params.Float, "cfg_scale"),
+	params.NewFlag(params.Bool, "prompt_optimizer"),
+	params.NewFlag(params.String, "resolution"),
+	params.NewFlag(params.Bool, "generate_audio", "audio"),
+	params.NewFlag(params.String, "end_image", "end_image_url"),
+	params.NewFlag(params.Int, "seed"),
+	params.NewFlag(params.Bool, "subtitles"),
+	params.NewFlag(params.Bool, "burn_subtitles"),
+	params.NewFlag(params.String, "script"),
 }
 
-// ParseNegativePrompt parses the negative prompt argument
-func (p *ArgumentParser) ParseNegativePrompt(args []string) string {
-	for i, arg := range args {
-		if arg == "--negative_prompt" && i+1 < len(args) {
-			return args[i+1]
-		}
-	}
-	return "blur, distort, and low quality" // Default negative prompt
-}
-
-// ParseCFGScale parses the CFG scale argument, returning a pointer or nil
-func (p *ArgumentParser) ParseCFGScale(args []string) *float64 {
-	for i, arg := range args {
-		if arg == "--cfg_scale" && i+1 < len(args) {
-			if scale, err := strconv.ParseFloat(args[i+1], 64); err == nil {
-				return &scale
-			}
-		}
-	}
-	return nil // Return nil if not found or invalid
-}
-
-// ParsePromptOptimizer parses the prompt optimizer flag, returning a pointer or nil
-func (p *ArgumentParser) ParsePromptOptimizer(args []string) *bool {
-	for i, arg := range args {
-		flag := strings.ToLower(arg)
-		if (flag == "--prompt_optimizer" || flag == "--prompt-optimizer") && i+1 < len(args) {
-			valStr := strings.ToLower(args[i+1])
-			if valStr == "true" {
-				result := true
-				return &result
-			} else if valStr == "false" {
-				result := false
-				return &result
-			} else {
-				// Invalid value, return nil or handle error as needed
-				return nil
-			}
-		}
-	}
-	return nil // Flag not found
+// Flags returns the options text2video and image2video accept.
+func Flags() []params.Flag {
+	return videoFlags
 }
 
 // Parse parses all arguments, separating prompt, image URL (optional), and options.
 func (p *ArgumentParser) Parse(args []string, expectImageURL bool) (*ParseResult, error) {
-	r := &ParseResult{
-		Duration:       "5",
-		AspectRatio:    "16:9",
-		NegativePrompt: "blur, distort, and low quality",
+	parsed, err := params.Parse(args, params.NewSpec(videoFlags...))
+	if err != nil {
+		return nil, err
 	}
-	var promptParts []string
-
-	parsedArgs := make(map[int]bool) // Track indices consumed by flags
-	currentIndex := 0
 
-	// First pass: Handle image URL if expected
-	if expectImageURL {
-		if len(args) > 0 && !strings.HasPrefix(args[0], "--") {
-			r.ImageURL = args[0]
-			parsedArgs[0] = true
-			currentIndex = 1
-		} else {
-			return nil, fmt.Errorf("image URL is required as the first argument for this command")
-		}
+	r := &ParseResult{
+		Duration:        "5",
+		AspectRatio:     parsed.String("aspect_ratio"),
+		NegativePrompt:  parsed.String("negative_prompt"),
+		CFGScale:        parsed.Float("cfg_scale"),
+		PromptOptimizer: parsed.Bool("prompt_optimizer"),
+		Resolution:      parsed.String("resolution"),
+		GenerateAudio:   parsed.Bool("generate_audio"),
+		EndImageURL:     parsed.String("end_image"),
+		Seed:            parsed.Int64("seed"),
+		Script:          parsed.String("script"),
 	}
-
-	// Second pass: Parse flags
-	localArgs := args[currentIndex:] // Only parse flags after potential image URL
-	localIndexOffset := currentIndex
-	i := 0
-	for i < len(localArgs) {
-		arg := localArgs[i]
-		if !strings.HasPrefix(arg, "--") {
-			i++
-			continue // Skip non-flags in this pass
-		}
-
-		flag := strings.ToLower(arg)
-		originalIndex := i + localIndexOffset
-
-		if parsedArgs[originalIndex] {
-			i++
-			continue // Already processed (e.g., was image URL)
-		}
-
-		// Check for value
-		var value string
-		if i+1 < len(localArgs) {
-			value = localArgs[i+1]
-		}
-
-		switch flag {
-		case "--duration":
-			if value != "" {
-				r.Duration = strings.TrimSuffix(value, "s")
-				parsedArgs[originalIndex] = true
-				parsedArgs[originalIndex+1] = true
-				i += 2
-			} else {
-				return nil, fmt.Errorf("missing value for %s", flag)
-			}
-		case "--aspect":
-			if value != "" {
-				r.AspectRatio = value
-				parsedArgs[originalIndex] = true
-				parsedArgs[originalIndex+1] = true
-				i += 2
-			} else {
-				return nil, fmt.Errorf("missing value for %s", flag)
-			}
-		case "--negative_prompt", "--negative-prompt":
-			if value != "" {
-				r.NegativePrompt = value
-				parsedArgs[originalIndex] = true
-				parsedArgs[originalIndex+1] = true
-				i += 2
-			} else {
-				return nil, fmt.Errorf("missing value for %s", flag)
-			}
-		case "--cfg_scale", "--cfg-scale":
-			if value != "" {
-				if scale, parseErr := strconv.ParseFloat(value, 64); parseErr == nil {
-					r.CFGScale = &scale
-					parsedArgs[originalIndex] = true
-					parsedArgs[originalIndex+1] = true
-					i += 2
-				} else {
-					return nil, fmt.Errorf("invalid value for %s: %s", flag, value)
-				}
-			} else {
-				return nil, fmt.Errorf("missing value for %s", flag)
-			}
-		case "--prompt_optimizer", "--prompt-optimizer":
-			if value != "" {
-				valStr := strings.ToLower(value)
-				if valStr == "true" {
-					result := true
-					r.PromptOptimizer = &result
-					parsedArgs[originalIndex] = true
-					parsedArgs[originalIndex+1] = true
-					i += 2
-				} else if valStr == "false" {
-					result := false
-					r.PromptOptimizer = &result
-					parsedArgs[originalIndex] = true
-					parsedArgs[originalIndex+1] = true
-					i += 2
-				} else {
-					return nil, fmt.Errorf("invalid value for %s: %s (must be true or false)", flag, value)
-				}
-			} else {
-				return nil, fmt.Errorf("missing value for %s", flag)
-			}
-		case "--resolution":
-			if value != "" {
-				r.Resolution = value
-				parsedArgs[originalIndex] = true
-				parsedArgs[originalIndex+1] = true
-				i += 2
-			} else {
-				return nil, fmt.Errorf("missing value for %s", flag)
-			}
-		case "--audio":
-			if value != "" {
-				valStr := strings.ToLower(value)
-				if valStr == "true" {
-					result := true
-					r.GenerateAudio = &result
-					parsedArgs[originalIndex] = true
-					parsedArgs[originalIndex+1] = true
-					i += 2
-				} else if valStr == "false" {
-					result := false
-					r.GenerateAudio = &result
-					parsedArgs[originalIndex] = true
-					parsedArgs[originalIndex+1] = true
-					i += 2
-				} else {
-					return nil, fmt.Errorf("invalid value for %s: %s (must be true or false)", flag, value)
-				}
-			} else {
-				return nil, fmt.Errorf("missing value for %s", flag)
-			}
-		case "--end_image", "--end-image":
-			if value != "" {
-				r.EndImageURL = value
-				parsedArgs[originalIndex] = true
-				parsedArgs[originalIndex+1] = true
-				i += 2
-			} else {
-				return nil, fmt.Errorf("missing value for %s", flag)
-			}
-		case "--seed":
-			if value != "" {
-				s, parseErr := strconv.ParseInt(value, 10, 64)
-				if parseErr != nil {
-					return nil, fmt.Errorf("invalid value for %s: %s (must be an integer)", flag, value)
-				}
-				r.Seed = &s
-				parsedArgs[originalIndex] = true
-				parsedArgs[originalIndex+1] = true
-				i += 2
-			} else {
-				return nil, fmt.Errorf("missing value for %s", flag)
-			}
-		default:
-			// Unknown flag, treat as part of prompt later or ignore
-			i++
-		}
+	if d := parsed.String("duration"); d != "" {
+		r.Duration = strings.TrimSuffix(d, "s")
 	}
+	// Burning in or a script imply subtitles
+	r.BurnSubtitles = derefBoolPtrOrDefault(parsed.Bool("burn_subtitles"), false)
+	r.Subtitles = derefBoolPtrOrDefault(parsed.Bool("subtitles"), false) || r.BurnSubtitles || r.Script != ""
 
-	// Third pass: Collect prompt parts
-	for i, arg := range args {
-		if !parsedArgs[i] {
-			promptParts = append(promptParts, arg)
+	words := parsed.Args
+	if expectImageURL {
+		// The image URL comes first, ahead of any flags
+		if len(args) == 0 || strings.HasPrefix(args[0], "--") || len(words) == 0 {
+			return nil, fmt.Errorf("image URL is required as the first argument for this command")
 		}
+		r.ImageURL, words = words[0], words[1:]
 	}
-	r.Prompt = strings.Join(promptParts, " ")
+	r.Prompt = strings.Join(words, " ")
 
 	return r, nil
 }
@@ -410,7 +248,8 @@ func (p *ArgumentParser) ParseMulti2Video(args []string) (*ParseResult, error) {
 }
 
 // ParseVideo2Video parses arguments for the video2video command.
-// Usage: !video2video [video_url] [prompt text] [--keep_audio true|false] [--image1 url] ... [--image4 url] [--duration N]
+// Usage: !video2video [video_url] [audio_url|image_url] [prompt text] [--keep_audio true|false] [--image1 url] ... [--image4 url] [--duration N] [--factor 2|4] [--slowmo true|false]
+// [--audio url] [--image url] [--orientation image|video] [--model name] [--output_type mp4|mov|webm]
 func (p *ArgumentParser) ParseVideo2Video(args []string) (*ParseResult, error) {
 	if len(args) == 0 {
 		return nil, fmt.Errorf("video URL is required as the first argument")
@@ -430,6 +269,13 @@ func (p *ArgumentParser) ParseVideo2Video(args []string) (*ParseResult, error) {
 	parsedArgs := make(map[int]bool)
 	parsedArgs[0] = true // video URL consumed
 
+	// Lipsync and motion control take a second media URL, the audio or
+	// the reference image
+	if len(args) > 1 && isURL(args[1]) {
+		r.SecondURL = args[1]
+		parsedArgs[1] = true
+	}
+
 	// Parse flags
 	i := 1
 	for i < len(args) {
@@ -448,7 +294,7 @@ func (p *ArgumentParser) ParseVideo2Video(args []string) (*ParseResult, error) {
 		}
 
 		switch flag {
-		case "--keep_audio", "--keep-audio":
+		case "--keep_audio", "--keep-audio", "--keep_sound", "--keep-sound":
 			if value != "" {
 				valStr := strings.ToLower(value)
 				if valStr == "true" {
@@ -466,6 +312,25 @@ func (p *ArgumentParser) ParseVideo2Video(args []string) (*ParseResult, error) {
 			} else {
 				return nil, fmt.Errorf("missing value for %s", flag)
 			}
+		case "--audio", "--image", "--orientation", "--model", "--output_type", "--output-type":
+			if value == "" {
+				return nil, fmt.Errorf("missing value for %s", flag)
+			}
+			switch flag {
+			case "--audio":
+				r.AudioURL = value
+			case "--image":
+				r.ImageURL = value
+			case "--orientation":
+				r.Orientation = strings.ToLower(value)
+			case "--model":
+				r.ProviderModel = value
+			default:
+				r.OutputType = strings.ToLower(value)
+			}
+			parsedArgs[i] = true
+			parsedArgs[i+1] = true
+			i += 2
 		case "--image1", "--image2", "--image3", "--image4":
 			if value != "" {
 				r.ImageURLs = append(r.ImageURLs, value)
@@ -484,6 +349,37 @@ func (p *ArgumentParser) ParseVideo2Video(args []string) (*ParseResult, error) {
 			} else {
 				return nil, fmt.Errorf("missing value for %s", flag)
 			}
+		case "--factor":
+			if value != "" {
+				factor, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(value), "x"))
+				if err != nil || (factor != 2 && factor != 4) {
+					return nil, fmt.Errorf("invalid value for %s: %s (must be 2 or 4)", flag, value)
+				}
+				r.Factor = factor
+				parsedArgs[i] = true
+				parsedArgs[i+1] = true
+				i += 2
+			} else {
+				return nil, fmt.Errorf("missing value for %s", flag)
+			}
+		case "--slowmo", "--slow_motion", "--slow-motion":
+			if value != "" {
+				valStr := strings.ToLower(value)
+				if valStr == "true" {
+					result := true
+					r.SlowMotion = &result
+				} else if valStr == "false" {
+					result := false
+					r.SlowMotion = &result
+				} else {
+					return nil, fmt.Errorf("invalid value for %s: %s (must be true or false)", flag, value)
+				}
+				parsedArgs[i] = true
+				parsedArgs[i+1] = true
+				i += 2
+			} else {
+				return nil, fmt.Errorf("missing value for %s", flag)
+			}
 		default:
 			// Unknown flag, treat as part of prompt
 			i++
@@ -500,3 +396,9 @@ func (p *ArgumentParser) ParseVideo2Video(args []string) (*ParseResult, error) {
 
 	return r, nil
 }
+
+// isURL reports whether arg is an http(s) URL.
+func isURL(arg string) bool {
+	lower := strings.ToLower(arg)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
@@ -3,74 +3,124 @@ package video
 import (
 	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/decred/slog"
 	// "github.com/companyzero/bisonrelay/clientrpc/types" // Only needed for the old billing call
+	"github.com/karamble/braibot/internal/assets"
 	"github.com/karamble/braibot/internal/database"
 	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/i18n"
+	"github.com/karamble/braibot/internal/jobevents"
+	"github.com/karamble/braibot/internal/joblog"
+	"github.com/karamble/braibot/internal/money"
+	"github.com/karamble/braibot/internal/reqcache"
+	"github.com/karamble/braibot/internal/subtitles"
+	"github.com/karamble/braibot/internal/templates"
+	"github.com/karamble/braibot/internal/transfer"
+	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
 	"github.com/karamble/braibot/pkg/fal"
 	kit "github.com/vctt94/bisonbotkit"
 )
 
+// readyNotifyAfter is how long a job must run before opted-in users get a
+// separate "job ready" PM.
+const readyNotifyAfter = 2 * time.Minute
+
 // VideoService handles video generation
 type VideoService struct {
 	client         *fal.Client
 	dbManager      *database.DBManager
 	bot            *kit.Bot
+	sender         *braibottypes.MessageSender
 	debug          bool
-	billingEnabled bool // Added billing enabled flag
+	billingEnabled atomic.Bool // Toggled at runtime by !admin billing
+	transfer       *transfer.Sender
+	publisher      *assets.Publisher // Asset server for GC links, nil when not configured
+	log            slog.Logger
 }
 
 // NewVideoService creates a new VideoService
 func NewVideoService(client *fal.Client, dbManager *database.DBManager, bot *kit.Bot, debug bool, billingEnabled bool) *VideoService {
-	return &VideoService{
-		client:         client,
-		dbManager:      dbManager,
-		bot:            bot,
-		debug:          debug,
-		billingEnabled: billingEnabled, // Store the flag
+	s := &VideoService{
+		client:    client,
+		dbManager: dbManager,
+		bot:       bot,
+		sender:    braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot)),
+		debug:     debug,
+		transfer:  transfer.NewSender(bot, transfer.DefaultLimits),
+		log:       joblog.New(nil, joblog.Video),
 	}
+	s.billingEnabled.Store(billingEnabled)
+	return s
+}
+
+// SetBillingEnabled turns charging for generations on or off.
+func (s *VideoService) SetBillingEnabled(enabled bool) {
+	s.billingEnabled.Store(enabled)
+}
+
+// SetLogger sets the logger the service logs its jobs to.
+func (s *VideoService) SetLogger(l slog.Logger) {
+	s.log = l
+}
+
+// jobLog returns a logger for the job of req.
+func (s *VideoService) jobLog(req *VideoRequest) joblog.Logger {
+	return joblog.For(s.log, &req.GenerationRequest)
+}
+
+// SetAssetPublisher sets the asset server that videos for group chats are
+// linked from.
+func (s *VideoService) SetAssetPublisher(p *assets.Publisher) {
+	s.publisher = p
+}
+
+// SetTransferLimits sets the size limits videos are delivered under.
+func (s *VideoService) SetTransferLimits(limits transfer.Limits) {
+	s.transfer = transfer.NewSender(s.bot, limits)
 }
 
 // GenerateVideo generates a video based on the request, handling billing conditionally.
 func (s *VideoService) GenerateVideo(ctx context.Context, req *VideoRequest) (*VideoResult, error) {
-	// 1. Validate request
+	startedAt := time.Now()
+
+	// 1. Fill in the user's saved defaults and validate request
+	s.applyUserDefaults(req)
 	if err := s.validateRequest(req); err != nil {
 		return &VideoResult{Success: false, Error: err}, err
 	}
+	jobevents.Default.Submit(&req.GenerationRequest)
 
 	// 2. Calculate cost and CHECK balance if billing is enabled
+	s.priceRequest(req)
 	var requiredDCR, currentBalanceDCR float64
 	var checkErr error
-	if s.billingEnabled {
+	if s.billingEnabled.Load() {
 		// Call CheckBalance, which now returns the error directly if insufficient or other issue
-		requiredDCR, currentBalanceDCR, checkErr = utils.CheckBalance(ctx, s.dbManager, req.UserID[:], req.PriceUSD, s.debug, s.billingEnabled)
+		requiredDCR, currentBalanceDCR, checkErr = utils.CheckRequestBalance(ctx, s.dbManager, &req.GenerationRequest, req.PriceUSD, s.debug, s.billingEnabled.Load())
 		if checkErr != nil {
 			// Return the error (could be ErrInsufficientBalance or another critical error)
 			// The calling layer (main.go) will handle ErrInsufficientBalance specifically.
+			jobevents.Default.EmitFailed(&req.GenerationRequest, checkErr)
 			return &VideoResult{Success: false, Error: checkErr}, checkErr
 		}
 	}
 
 	// 3. Send initial message (adjusted for billing status)
-	var infoMsg string
-	if s.billingEnabled {
-		infoMsg = fmt.Sprintf("Request cost: $%.2f USD (%.8f DCR). Your balance: %.8f DCR. Processing...", req.PriceUSD, requiredDCR, currentBalanceDCR)
-	} else if eb := req.ExternalBilling; eb != nil {
-		infoMsg = fmt.Sprintf("Request cost: $%.2f USD (%.8f DCR). Your balance: %.8f DCR. Processing...", eb.ChargedUSD, eb.ChargedDCR, eb.BalanceDCR)
-	} else {
-		infoMsg = "Processing your request (billing disabled)..."
+	notice := templates.Data{Task: "video", Action: "Processing your video request", CostUSD: req.PriceUSD, CostDCR: requiredDCR, BalanceDCR: currentBalanceDCR}
+	if eb := req.ExternalBilling; eb != nil && !s.billingEnabled.Load() {
+		notice.CostUSD, notice.CostDCR, notice.BalanceDCR = eb.ChargedUSD, eb.ChargedDCR, eb.BalanceDCR
 	}
-	if req.IsPM {
-		s.bot.SendPM(ctx, req.UserID.String(), infoMsg)
-	} else {
-		s.bot.SendGC(ctx, req.GC, "Processing your video request...")
+	infoMsg := utils.FormatProcessingNotice(&req.GenerationRequest, s.billingEnabled.Load() || req.ExternalBilling != nil, notice)
+	if req.IsPM && req.Prompt != "" {
+		infoMsg += "\nPrompt: " + utils.PreviewUserText(req.Prompt, utils.PromptPreviewRunes)
 	}
+	s.sender.SendMessage(ctx, req.MessageContext(), infoMsg)
 
 	// 4. Get current model name
 	var model faladapter.AppModel
@@ -95,19 +145,38 @@ func (s *VideoService) GenerateVideo(ctx context.Context, req *VideoRequest) (*V
 		return &VideoResult{Success: false, Error: err}, err // No billing occurred
 	}
 
-	// 6. Generate video using the created request
-	videoResp, genErr := s.client.GenerateVideo(ctx, falReq)
-	if genErr != nil {
-		// Log error server-side, do not PM the user here.
-		// Error will be handled by the command handler (logged and nil returned).
-		// s.bot.SendPM(ctx, req.UserNick, fmt.Sprintf("Video generation failed: %v", genErr))
-		return &VideoResult{Success: false, Error: genErr}, genErr // Return error to command handler
+	// 6. Reuse the response to an identical recent request, else generate
+	// video using the created request
+	var videoResp *fal.VideoResponse
+	cacheKey, cachedAt, cached := reqcache.Default.Lookup(&req.GenerationRequest, falReq, &videoResp)
+	if !cached {
+		// Wait for a free slot for this kind of job
+		release, slotErr := utils.AcquireJobSlot(ctx, s.bot, &req.GenerationRequest)
+		if slotErr != nil {
+			jobevents.Default.EmitFailed(&req.GenerationRequest, slotErr)
+			return &VideoResult{Success: false, Error: slotErr}, slotErr
+		}
+		defer release()
+
+		var genErr error
+		videoResp, genErr = s.client.GenerateVideo(ctx, falReq)
+		if genErr != nil {
+			// Log error server-side, do not PM the user here.
+			// Error will be handled by the command handler (logged and nil returned).
+			// s.bot.SendPM(ctx, req.UserNick, fmt.Sprintf("Video generation failed: %v", genErr))
+			jobevents.Default.EmitFailed(&req.GenerationRequest, genErr)
+			return &VideoResult{Success: false, Error: genErr}, genErr // Return error to command handler
+		}
+		if videoResp.GetURL() != "" {
+			reqcache.Default.Store(&req.GenerationRequest, cacheKey, videoResp)
+		}
 	}
 
 	// 7. Check if URL is present and attempt to send
 	videoURL := videoResp.GetURL()
 	if videoURL == "" {
-		genErr = fmt.Errorf("API did not return a video URL")
+		genErr := fmt.Errorf("API did not return a video URL")
+		jobevents.Default.EmitFailed(&req.GenerationRequest, genErr)
 		// Log error server-side, do not PM the user here.
 		// Error will be handled by the command handler.
 		// s.bot.SendPM(ctx, req.UserNick, genErr.Error())
@@ -115,10 +184,12 @@ func (s *VideoService) GenerateVideo(ctx context.Context, req *VideoRequest) (*V
 	}
 
 	successfullySent := false
-	if err := s.downloadAndSendVideo(ctx, req.UserNick, videoURL); err != nil {
-		fmt.Printf("ERROR [VideoService] User %s: Failed to download/send video: %v\n", req.UserNick, err)
+	if err := s.deliverVideo(ctx, req, videoURL); err != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, fmt.Errorf("failed to download/send video: %w", err))
 	} else {
 		successfullySent = true
+		jobevents.Default.EmitDelivered(&req.GenerationRequest, 1)
+		utils.RememberResult(s.dbManager, &req.GenerationRequest, database.ResultVideo, videoURL)
 	}
 
 	// 8. Perform Billing *only if* enabled and video was sent successfully
@@ -126,58 +197,169 @@ func (s *VideoService) GenerateVideo(ctx context.Context, req *VideoRequest) (*V
 	var finalBalanceDCR float64 = currentBalanceDCR // Use balance from initial check
 	var billingAttempted bool = false
 	var billingSucceeded bool = false
+	var splitCharge *utils.SplitCharge // Set when the charge was split with a GC pot
 
-	if s.billingEnabled && successfullySent {
+	if s.billingEnabled.Load() && successfullySent && !cached {
 		billingAttempted = true
-		deductChargedDCR, deductNewBalance, deductErr := utils.DeductBalance(ctx, s.dbManager, req.UserID[:], req.PriceUSD, s.debug, s.billingEnabled)
+		deductChargedDCR, deductNewBalance, deductSplit, deductErr := utils.DeductRequestBalance(ctx, s.dbManager, &req.GenerationRequest, req.PriceUSD, s.debug, s.billingEnabled.Load())
 		if deductErr != nil {
 			if req.IsPM {
-				s.bot.SendPM(ctx, req.UserID.String(), fmt.Sprintf("Error processing payment after sending video: %v. Please contact support.", deductErr))
+				s.sender.SendMessage(ctx, req.MessageContext(), fmt.Sprintf("Error processing payment after sending video: %v. Please contact support.", deductErr))
 			}
 			finalBalanceDCR = currentBalanceDCR
 		} else {
 			billingSucceeded = true
+			splitCharge = deductSplit
 			chargedDCR = deductChargedDCR
 			finalBalanceDCR = deductNewBalance
+			jobevents.Default.EmitBilled(&req.GenerationRequest, chargedDCR)
 		}
-	} else if !s.billingEnabled {
+	} else if !s.billingEnabled.Load() {
 		// fmt.Printf("INFO: Billing disabled. No charge for video for user %s.\n", req.UserNick) // Already Removed
 	} else {
 		// Billing enabled, but not sent successfully
 		// fmt.Printf("INFO: Video not sent successfully for user %s. No billing occurred.\n", req.UserNick) // Removed
 	}
 
-	// 9. Send final confirmation
-	finalMessage := "Finished processing video request.\n\n"
-	if !successfullySent {
-		finalMessage = "Video generation completed, but failed to send the result.\n\n"
+	if successfullySent {
+		s.jobLog(req).Infof("Sent the video, charged %.8f DCR", chargedDCR)
+	} else {
+		s.jobLog(req).Warnf("Failed to send the video %s", videoURL)
+	}
+
+	// 9. Record the job for re-delivery and send final confirmation
+	var job *database.Job
+	if successfullySent {
+		job = s.recordJob(req, model.Name, videoURL, startedAt)
+	}
+	if job != nil && billingSucceeded {
+		// Remember the user's share of the charge so it can be refunded
+		userDCR := chargedDCR
+		if splitCharge != nil {
+			userDCR = splitCharge.UserDCR
+		}
+		if atoms, err := money.DCRToAtoms(userDCR); err == nil && atoms > 0 {
+			if err := s.dbManager.SetJobCharge(job.ID, atoms); err != nil {
+				s.jobLog(req).Errorf("Failed to record the charge: %v", err)
+			} else {
+				job.ChargedAtoms = atoms
+			}
+		}
+	}
+	finalMessage := utils.FormatFinished(&req.GenerationRequest, templates.Data{Task: "video", Sent: 1, SendFailed: !successfullySent}) + "\n\n"
+	if job != nil {
+		finalMessage += utils.FormatJobRetention(job) + "\n\n"
 	}
 	if req.IsPM {
-		if eb := req.ExternalBilling; eb != nil && !s.billingEnabled {
-			finalMessage += utils.FormatBillingConfirmation("video", true, true, true, eb.ChargedDCR, eb.ChargedUSD, eb.BalanceDCR)
+		if cached {
+			finalMessage += utils.FormatCachedResult(time.Since(cachedAt))
+		} else if eb := req.ExternalBilling; eb != nil && !s.billingEnabled.Load() {
+			finalMessage += utils.FormatBillingConfirmation("video", true, true, true, eb.ChargedDCR, eb.ChargedUSD, eb.BalanceDCR) + utils.LowBalanceWarning(s.dbManager, req.UserID.String(), eb.BalanceDCR, eb.ChargedDCR, eb.ChargedUSD)
 		} else {
-			finalMessage += utils.FormatBillingConfirmation("video", s.billingEnabled, billingAttempted, billingSucceeded, chargedDCR, req.PriceUSD, finalBalanceDCR)
+			finalMessage += utils.FormatBillingConfirmation("video", s.billingEnabled.Load(), billingAttempted, billingSucceeded, chargedDCR, req.PriceUSD, finalBalanceDCR) + utils.LowBalanceWarning(s.dbManager, req.UserID.String(), finalBalanceDCR, chargedDCR, req.PriceUSD)
 		}
-		if err := s.bot.SendPM(ctx, req.UserID.String(), finalMessage); err != nil {
+		if err := s.sender.SendMessage(ctx, req.MessageContext(), finalMessage); err != nil {
 			// fmt.Printf("ERROR: Failed to send final confirmation message (video) to %s: %v\n", req.UserNick, err) // Removed
 		}
 	} else {
-		if err := s.bot.SendGC(ctx, req.GC, "Video generation completed."); err != nil {
+		gcMessage := utils.FormatFinished(&req.GenerationRequest, templates.Data{Task: "video", Sent: 1})
+		if cached {
+			gcMessage += "\n\n" + utils.FormatCachedResult(time.Since(cachedAt))
+		}
+		if splitCharge != nil {
+			gcMessage += "\n\n" + utils.FormatSplitPotReceipt(splitCharge)
+		}
+		if err := s.sender.SendMessage(ctx, req.MessageContext(), gcMessage); err != nil {
 			// fmt.Printf("ERROR: Failed to send final confirmation message (video) to GC %s: %v\n", req.GC, err) // Removed
 		}
+		// The requester's share and balance are theirs alone
+		if splitCharge != nil {
+			s.sender.SendPrivateMessage(ctx, req.MessageContext(), utils.FormatSplitBillingConfirmation(splitCharge, req.PriceUSD)+utils.LowBalanceWarning(s.dbManager, req.UserID.String(), splitCharge.UserBalanceDCR, splitCharge.UserDCR+splitCharge.PotDCR, req.PriceUSD))
+		}
+	}
+
+	// 10. Notify users who opted in when a long-running job is ready, since
+	// the result may have been buried
+	if job != nil {
+		s.notifyJobReady(ctx, job)
 	}
 
 	// Return overall success based on generation, even if sending/billing failed
-	return &VideoResult{
+	result := &VideoResult{
 		VideoURL: videoURL,
 		Success:  true, // Represents successful generation
-	}, nil
+	}
+	if job != nil {
+		result.JobID = job.ID
+	}
+	return result, nil
+}
+
+// priceRequest sets the request's price from its model's pricing. Per-second
+// models are billed for BilledSeconds, falling back to the requested duration
+// and then to 5 seconds, the common fal default.
+func (s *VideoService) priceRequest(req *VideoRequest) {
+	model, exists := faladapter.GetCurrentModel(req.ModelType, "")
+	if req.ModelName != "" {
+		model, exists = faladapter.GetModel(req.ModelName, req.ModelType)
+	}
+	if !exists {
+		return
+	}
+	req.PriceUSD = faladapter.PriceFor(model, faladapter.PriceParams{Seconds: billedSeconds(req)})
+}
+
+// billedSeconds returns the length of the requested video.
+func billedSeconds(req *VideoRequest) int {
+	seconds := req.BilledSeconds
+	if seconds <= 0 {
+		seconds, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(req.Duration), "s"))
+	}
+	if seconds <= 0 {
+		seconds = 5
+	}
+	return seconds
+}
+
+// applyUserDefaults fills options the user left out with the defaults they
+// saved with !set. The aspect default applies to text2video only, and only
+// for the ratios every video model accepts.
+func (s *VideoService) applyUserDefaults(req *VideoRequest) {
+	settings := utils.LoadUserSettings(s.dbManager, req.UserID.String())
+	switch aspect := settings[utils.SettingAspect]; aspect {
+	case "16:9", "9:16", "1:1":
+		if req.ModelType == "text2video" && req.AspectRatio == "" {
+			req.AspectRatio = aspect
+		}
+	}
+	if v := settings[utils.SettingNegativePrompt]; v != "" && req.NegativePrompt == "" {
+		req.NegativePrompt = v
+	}
+	if v := settings[utils.SettingSeed]; v != "" && req.Seed == nil {
+		if seed, err := strconv.ParseInt(v, 10, 64); err == nil {
+			req.Seed = &seed
+		}
+	}
+
+	// Without a flag or a saved default, fall back to the usual defaults
+	if req.ModelType == "text2video" || req.ModelType == "image2video" {
+		if req.AspectRatio == "" {
+			req.AspectRatio = DefaultAspectRatio
+		}
+		if req.NegativePrompt == "" {
+			req.NegativePrompt = DefaultNegativePrompt
+		}
+	}
 }
 
 // validateRequest validates the video request and formats duration based on model
 func (s *VideoService) validateRequest(req *VideoRequest) error {
-	// Check if model exists and get its details
+	// Check if model exists and get its details. Commands pass the user's
+	// selected model, which may differ from the global default.
 	model, exists := faladapter.GetCurrentModel(req.ModelType, "")
+	if req.ModelName != "" {
+		model, exists = faladapter.GetModel(req.ModelName, req.ModelType)
+	}
 	if !exists {
 		return fmt.Errorf("no default model found for %s", req.ModelType)
 	}
@@ -210,12 +392,19 @@ func (s *VideoService) validateRequest(req *VideoRequest) error {
 		// Optional: Add validation that it's a number if needed
 	}
 
-	// For video2video, check if the required video URL field is provided
+	// For video2video, check that every input the model needs is provided
 	if req.ModelType == "video2video" {
+		if req.VideoURL == "" {
+			return fmt.Errorf("video URL is required for model %s", model.Name)
+		}
 		switch model.Name {
-		case "kling-video-o3-edit", "kling-video-o3-pro-edit":
-			if req.VideoURL == "" {
-				return fmt.Errorf("video URL is required for model %s", model.Name)
+		case "sync-lipsync-v2":
+			if req.AudioURL == "" {
+				return fmt.Errorf("audio URL is required for model %s", model.Name)
+			}
+		case "kling-video-v26-motion-control":
+			if req.ImageURL == "" {
+				return fmt.Errorf("reference image URL is required for model %s", model.Name)
 			}
 		}
 	}
@@ -267,38 +456,69 @@ func (s *VideoService) validateRequest(req *VideoRequest) error {
 	return nil
 }
 
-// downloadAndSendVideo downloads a video from a URL, sends it to the user, and cleans up
-func (s *VideoService) downloadAndSendVideo(ctx context.Context, userNick string, videoURL string) error {
-	// Create a temporary file
-	tmpFile, err := os.CreateTemp("", "video-*.mp4")
-	if err != nil {
-		return fmt.Errorf("failed to create temp file: %v", err)
+// recordJob stores a finished job so it can be re-delivered until its
+// retention period ends. It returns nil when the job could not be recorded.
+func (s *VideoService) recordJob(req *VideoRequest, modelName, videoURL string, startedAt time.Time) *database.Job {
+	if s.dbManager == nil {
+		return nil
 	}
-	defer os.Remove(tmpFile.Name()) // Clean up the temp file when done
-
-	// Download the video
-	resp, err := http.Get(videoURL)
+	job, err := s.dbManager.RecordJob(req.UserID.String(), req.ModelType, modelName, videoURL, startedAt)
 	if err != nil {
-		return fmt.Errorf("failed to download video: %v", err)
+		s.jobLog(req).Errorf("Failed to record the job: %v", err)
+		return nil
 	}
-	defer resp.Body.Close()
-
-	// Copy the video data to the temp file
-	if _, err := io.Copy(tmpFile, resp.Body); err != nil {
-		return fmt.Errorf("failed to save video: %v", err)
+	if err := s.dbManager.SetJobPrompt(job.ID, req.Prompt, req.Seed); err != nil {
+		s.jobLog(req).Errorf("Failed to record the prompt: %v", err)
+	} else {
+		job.Prompt, job.Seed = req.Prompt, req.Seed
 	}
+	return &job
+}
 
-	// Close the file before sending
-	if err := tmpFile.Close(); err != nil {
-		return fmt.Errorf("failed to close temp file: %v", err)
+// notifyJobReady sends a PM pointing at a finished job when the user has
+// opted in with !notify and the job ran for at least readyNotifyAfter. The
+// PM is sent even when the request came from a group chat, but not to users
+// who muted the bot.
+func (s *VideoService) notifyJobReady(ctx context.Context, job *database.Job) {
+	if time.Since(job.CreatedAt) < readyNotifyAfter {
+		return
 	}
+	notify, err := s.dbManager.GetNotifyReady(job.UID)
+	if err != nil || !notify {
+		return
+	}
+	utils.SendNoticePM(ctx, s.bot, s.dbManager, job.UID, utils.UserText(s.dbManager, job.UID, "job_ready", i18n.Args{
+		"Job": job.ID, "Time": job.CreatedAt.Format("15:04"), "Model": job.Model, "Retention": utils.FormatJobRetention(job),
+	}))
+}
 
-	// Send the file to the user
-	if err := s.bot.SendFile(ctx, userNick, tmpFile.Name()); err != nil {
-		return fmt.Errorf("failed to send video file: %v", err)
+// RedeliverVideo downloads a previously generated video again and sends it to the user.
+func (s *VideoService) RedeliverVideo(ctx context.Context, userNick string, videoURL string) error {
+	return s.downloadAndSendVideo(ctx, userNick, videoURL)
+}
+
+// deliverVideo sends the video to the requester by PM, with the requested
+// subtitles. Files cannot be sent to group chats, so GC requests get a link
+// posted in the GC instead.
+func (s *VideoService) deliverVideo(ctx context.Context, req *VideoRequest, videoURL string) error {
+	if req.IsPM && req.Subtitles != "" {
+		length := time.Duration(billedSeconds(req)) * time.Second
+		srt := subtitles.SRT(subtitles.Build(req.Subtitles, length))
+		_, err := s.transfer.SendSubtitledVideo(ctx, req.UserNick, videoURL, srt, req.BurnSubtitles)
+		return err
+	}
+	if req.IsPM {
+		return s.downloadAndSendVideo(ctx, req.UserNick, videoURL)
 	}
+	label := "Video for " + utils.SanitizeUserText(req.UserNick)
+	return utils.SendGCLink(ctx, s.bot, s.publisher, req.GC, label, videoURL)
+}
 
-	return nil
+// downloadAndSendVideo streams a video to the user as a file, compressing
+// it or sending a link instead when it is too large.
+func (s *VideoService) downloadAndSendVideo(ctx context.Context, userNick string, videoURL string) error {
+	_, err := s.transfer.SendVideo(ctx, userNick, videoURL)
+	return err
 }
 
 // Helper function to safely dereference optional float64 pointers
@@ -566,7 +786,86 @@ func createFalVideoRequest(req *VideoRequest, modelName string) (interface{}, er
 		falReq.BaseVideoRequest.Model = modelName
 		falReq.BaseVideoRequest.ImageURL = "" // Not used for video2video edit
 		return falReq, nil
+	case "rife-video", "film-video":
+		if req.VideoURL == "" {
+			return nil, fmt.Errorf("video_url is required for %s model", modelName)
+		}
+		falReq := &fal.FrameInterpolationRequest{
+			BaseVideoRequest: base,
+			VideoURL:         req.VideoURL,
+			Factor:           req.Factor,
+			SlowMotion:       req.SlowMotion,
+		}
+		falReq.BaseVideoRequest.Model = modelName
+		falReq.BaseVideoRequest.Prompt = ""   // Interpolation takes no prompt
+		falReq.BaseVideoRequest.ImageURL = "" // Not used for frame interpolation
+		return falReq, nil
+	case "topaz-upscale-video":
+		return &fal.TopazUpscaleVideoRequest{
+			VideoURL:   req.VideoURL,
+			Model:      req.ProviderModel,
+			OutputType: req.OutputType,
+			Progress:   req.Progress,
+		}, nil
+	case "sync-lipsync-v2":
+		if req.AudioURL == "" {
+			return nil, fmt.Errorf("audio_url is required for %s model", modelName)
+		}
+		return &fal.SyncLipsyncV2Request{
+			VideoURL:   req.VideoURL,
+			AudioURL:   req.AudioURL,
+			Model:      req.ProviderModel,
+			OutputType: req.OutputType,
+			Progress:   req.Progress,
+		}, nil
+	case "kling-video-v26-motion-control":
+		orientation := req.CharacterOrientation
+		if orientation == "" {
+			orientation = "video" // Allows the longest motion reference
+		}
+		return &fal.KlingVideoV26MotionControlRequest{
+			ImageURL:             req.ImageURL,
+			VideoURL:             req.VideoURL,
+			Prompt:               req.Prompt,
+			CharacterOrientation: orientation,
+			KeepOriginalSound:    req.KeepAudio,
+			Progress:             req.Progress,
+		}, nil
 	default:
+		if model, ok := fal.GetModel(modelName, req.ModelType); ok && model.Generic {
+			return genericVideoRequest(req, modelName), nil
+		}
 		return nil, fmt.Errorf("unsupported or unhandled model for specific FAL video request creation: %s", modelName)
 	}
 }
+
+// genericVideoRequest builds the request for a model from the model
+// definition file, sending the fields the user set.
+func genericVideoRequest(req *VideoRequest, modelName string) *fal.GenericRequest {
+	input := map[string]interface{}{"prompt": req.Prompt}
+	for name, value := range map[string]string{
+		"image_url":       req.ImageURL,
+		"end_image_url":   req.EndImageURL,
+		"duration":        req.Duration,
+		"aspect_ratio":    req.AspectRatio,
+		"resolution":      req.Resolution,
+		"negative_prompt": req.NegativePrompt,
+	} {
+		if value != "" {
+			input[name] = value
+		}
+	}
+	if req.CFGScale != nil {
+		input["cfg_scale"] = *req.CFGScale
+	}
+	if req.PromptOptimizer != nil {
+		input["prompt_optimizer"] = *req.PromptOptimizer
+	}
+	if req.GenerateAudio != nil {
+		input["generate_audio"] = *req.GenerateAudio
+	}
+	if req.Seed != nil {
+		input["seed"] = *req.Seed
+	}
+	return &fal.GenericRequest{Model: modelName, Body: input, Progress: req.Progress}
+}
@@ -11,6 +11,7 @@ type VideoRequest struct {
 	ImageURL                 string   // Optional, used by some image2video models (Veo2, Kling)
 	SubjectReferenceImageURL string   // Optional, used by minimax-subject-reference
 	Duration                 string   // Optional, defaults handled by FAL
+	BilledSeconds            int      // Duration the request is priced at, resolved by the caller
 	AspectRatio              string   // Optional, defaults handled by FAL
 	Resolution               string   // Optional, defaults handled by FAL
 	NegativePrompt           string   // Optional, defaults handled by FAL
@@ -24,6 +25,14 @@ type VideoRequest struct {
 	VideoURLs                []string // Optional, up to 3 reference videos for Seedance multi2video
 	AudioURLs                []string // Optional, up to 3 reference audio files for Seedance multi2video
 	Seed                     *int64   // Optional, for reproducibility (Seedance 2.0)
+	Factor                   int      // Optional, frame multiplier (2 or 4) for frame interpolation models
+	SlowMotion               *bool    // Optional, keep source fps for frame interpolation models
+	AudioURL                 string   // Required for lipsync
+	CharacterOrientation     string   // Optional, "image" or "video" for Kling motion control
+	ProviderModel            string   // Optional, provider-side model for Topaz upscale / Sync lipsync
+	OutputType               string   // Optional, container for Topaz upscale / Sync lipsync
+	Subtitles                string   // Optional, narration sent as .srt subtitles with the video
+	BurnSubtitles            bool     // Burn the subtitles into the video instead
 }
 
 // VideoResult represents the result of a video generation
@@ -31,4 +40,21 @@ type VideoResult struct {
 	VideoURL string
 	Success  bool
 	Error    error
+	JobID    int64 // Id of the re-deliverable job, 0 when none was recorded
+}
+
+// IsSuccess checks if the video generation was successful.
+func (r *VideoResult) IsSuccess() bool {
+	if r == nil {
+		return false
+	}
+	return r.Success
+}
+
+// GetError returns the error from the video generation, if any.
+func (r *VideoResult) GetError() error {
+	if r == nil {
+		return nil
+	}
+	return r.Error
 }
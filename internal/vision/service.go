@@ -0,0 +1,246 @@
+// Package vision describes images with vision language models hosted on fal,
+// giving a caption and tags that can seed further prompts.
+package vision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/decred/slog"
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/jobevents"
+	"github.com/karamble/braibot/internal/joblog"
+	braibottypes "github.com/karamble/braibot/internal/types"
+	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/pkg/fal"
+	kit "github.com/vctt94/bisonbotkit"
+)
+
+const (
+	// describePrompt asks the model for a caption followed by a line of
+	// tags, the format ParseDescription reads.
+	describePrompt = "Describe this image in one or two sentences that could be used as a prompt to generate it. " +
+		"Then write a new line starting with \"Tags:\" followed by up to ten comma-separated keywords for its subject, style and mood."
+
+	// describeMaxTokens bounds the length of the model's answer.
+	describeMaxTokens = 200
+
+	// maxTags is the most tags kept of the model's answer.
+	maxTags = 10
+)
+
+// DescribeRequest represents an internal request to describe an image
+type DescribeRequest struct {
+	braibottypes.GenerationRequest
+	ImageURL string // http(s) URL of the image
+}
+
+// DescribeResult represents the description of an image
+type DescribeResult struct {
+	Caption string
+	Tags    []string
+	Success bool
+	Error   error
+}
+
+// IsSuccess checks if the description was successful.
+func (r *DescribeResult) IsSuccess() bool {
+	if r == nil {
+		return false
+	}
+	return r.Success
+}
+
+// GetError returns the error from the description, if any.
+func (r *DescribeResult) GetError() error {
+	if r == nil {
+		return nil
+	}
+	return r.Error
+}
+
+// VisionService handles image descriptions
+type VisionService struct {
+	client         *fal.Client
+	dbManager      *database.DBManager
+	bot            *kit.Bot
+	sender         *braibottypes.MessageSender
+	debug          bool
+	billingEnabled atomic.Bool // Toggled at runtime by !admin billing
+	log            slog.Logger
+}
+
+// NewVisionService creates a new VisionService
+func NewVisionService(client *fal.Client, dbManager *database.DBManager, bot *kit.Bot, debug bool, billingEnabled bool) *VisionService {
+	s := &VisionService{
+		client:    client,
+		dbManager: dbManager,
+		bot:       bot,
+		sender:    braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot)),
+		debug:     debug,
+		log:       joblog.New(nil, joblog.Vision),
+	}
+	s.billingEnabled.Store(billingEnabled)
+	return s
+}
+
+// SetBillingEnabled turns charging for descriptions on or off.
+func (s *VisionService) SetBillingEnabled(enabled bool) {
+	s.billingEnabled.Store(enabled)
+}
+
+// SetLogger sets the logger the service logs its jobs to.
+func (s *VisionService) SetLogger(l slog.Logger) {
+	s.log = l
+}
+
+// Describe captions and tags the request's image, sends the description to
+// the PM or GC the request came from and bills the model's flat price.
+func (s *VisionService) Describe(ctx context.Context, req *DescribeRequest) (*DescribeResult, error) {
+	if req.ImageURL == "" {
+		err := fmt.Errorf("image URL is required")
+		return &DescribeResult{Success: false, Error: err}, err
+	}
+	model, ok := faladapter.GetModel(req.ModelName, "image2text")
+	if !ok {
+		err := fmt.Errorf("model not found: %s", req.ModelName)
+		return &DescribeResult{Success: false, Error: err}, err
+	}
+	req.PriceUSD = faladapter.PriceFor(model, faladapter.PriceParams{})
+	jobevents.Default.Submit(&req.GenerationRequest)
+
+	// 1. CHECK balance if billing is enabled
+	var requiredDCR, currentBalanceDCR float64
+	var checkErr error
+	if s.billingEnabled.Load() {
+		requiredDCR, currentBalanceDCR, checkErr = utils.CheckRequestBalance(ctx, s.dbManager, &req.GenerationRequest, req.PriceUSD, s.debug, s.billingEnabled.Load())
+		if checkErr != nil {
+			jobevents.Default.EmitFailed(&req.GenerationRequest, checkErr)
+			return &DescribeResult{Success: false, Error: checkErr}, checkErr
+		}
+	}
+
+	// 2. Send initial message (adjusted for billing status)
+	if req.IsPM {
+		var infoMsg string
+		if s.billingEnabled.Load() {
+			infoMsg = fmt.Sprintf("Cost: $%.2f USD (%.8f DCR). Your balance: %.8f DCR. Looking at your image...",
+				req.PriceUSD, requiredDCR, currentBalanceDCR)
+		} else {
+			infoMsg = "Looking at your image (billing disabled)..."
+		}
+		s.sender.SendMessage(ctx, req.MessageContext(), infoMsg)
+	}
+
+	// Wait for a free slot for this kind of job
+	release, slotErr := utils.AcquireJobSlot(ctx, s.bot, &req.GenerationRequest)
+	if slotErr != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, slotErr)
+		return &DescribeResult{Success: false, Error: slotErr}, slotErr
+	}
+	defer release()
+
+	// 3. Run the vision model
+	resp, err := s.client.DescribeImage(ctx, &fal.VisionRequest{
+		ImageURL:  req.ImageURL,
+		Prompt:    describePrompt,
+		MaxTokens: describeMaxTokens,
+		Model:     model.Name,
+		Progress:  req.Progress,
+	})
+	if err != nil {
+		jobevents.Default.EmitFailed(&req.GenerationRequest, err)
+		return &DescribeResult{Success: false, Error: err}, err
+	}
+	caption, tags := ParseDescription(resp.Output)
+	if caption == "" {
+		err := fmt.Errorf("the model returned no description")
+		jobevents.Default.EmitFailed(&req.GenerationRequest, err)
+		return &DescribeResult{Success: false, Error: err}, err
+	}
+	result := &DescribeResult{Caption: caption, Tags: tags, Success: true}
+
+	// 4. Send the description
+	msg := FormatDescription(caption, tags)
+	if req.IsPM {
+		s.sender.SendMessage(ctx, req.MessageContext(), msg)
+	} else {
+		s.sender.SendMessage(ctx, req.MessageContext(), fmt.Sprintf("%s, %s", utils.SanitizeUserText(req.UserNick), msg))
+	}
+	jobevents.Default.EmitDelivered(&req.GenerationRequest, 1)
+
+	// 5. Perform Billing *only if* enabled
+	var chargedDCR float64
+	var finalBalanceDCR float64 = currentBalanceDCR
+	var billingSucceeded bool
+	var splitCharge *utils.SplitCharge
+	if s.billingEnabled.Load() {
+		deductChargedDCR, deductNewBalance, deductSplit, deductErr := utils.DeductRequestBalance(ctx, s.dbManager, &req.GenerationRequest, req.PriceUSD, s.debug, s.billingEnabled.Load())
+		if deductErr != nil {
+			if req.IsPM {
+				s.sender.SendMessage(ctx, req.MessageContext(), fmt.Sprintf("Error processing payment after sending the description: %v. Please contact support.", deductErr))
+			}
+		} else {
+			billingSucceeded = true
+			chargedDCR = deductChargedDCR
+			finalBalanceDCR = deductNewBalance
+			splitCharge = deductSplit
+			jobevents.Default.EmitBilled(&req.GenerationRequest, chargedDCR)
+		}
+	}
+
+	joblog.For(s.log, &req.GenerationRequest).Infof("Described image with %d tags, charged %.8f DCR", len(tags), chargedDCR)
+
+	// 6. Send final confirmation
+	if req.IsPM {
+		s.sender.SendMessage(ctx, req.MessageContext(), utils.FormatBillingConfirmation("description", s.billingEnabled.Load(), s.billingEnabled.Load(), billingSucceeded, chargedDCR, req.PriceUSD, finalBalanceDCR)+utils.LowBalanceWarning(s.dbManager, req.UserID.String(), finalBalanceDCR, chargedDCR, req.PriceUSD))
+	} else if splitCharge != nil {
+		// The group sees the pot's share; the requester's share and balance
+		// go by PM
+		s.sender.SendMessage(ctx, req.MessageContext(), utils.FormatSplitPotReceipt(splitCharge))
+		s.sender.SendPrivateMessage(ctx, req.MessageContext(), utils.FormatSplitBillingConfirmation(splitCharge, req.PriceUSD)+utils.LowBalanceWarning(s.dbManager, req.UserID.String(), splitCharge.UserBalanceDCR, splitCharge.UserDCR+splitCharge.PotDCR, req.PriceUSD))
+	}
+
+	return result, nil
+}
+
+// ParseDescription splits a model's answer into the caption and the tags of
+// its "Tags:" line. Answers without a tags line are all caption.
+func ParseDescription(output string) (caption string, tags []string) {
+	var captionLines []string
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		label, rest, found := strings.Cut(trimmed, ":")
+		if found && strings.EqualFold(strings.Trim(label, "*# "), "tags") {
+			seen := make(map[string]bool)
+			for _, tag := range strings.Split(rest, ",") {
+				tag = strings.ToLower(strings.Trim(tag, " .#*\"'"))
+				if tag == "" || seen[tag] || len(tags) == maxTags {
+					continue
+				}
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+			continue
+		}
+		if found && strings.EqualFold(strings.Trim(label, "*# "), "caption") {
+			trimmed = strings.Trim(rest, "* ")
+		}
+		if trimmed != "" {
+			captionLines = append(captionLines, trimmed)
+		}
+	}
+	return strings.Join(captionLines, " "), tags
+}
+
+// FormatDescription renders a caption and its tags for the chat.
+func FormatDescription(caption string, tags []string) string {
+	msg := "🔍 " + utils.SanitizeUserText(caption)
+	if len(tags) > 0 {
+		msg += "\n🏷️ Tags: " + utils.SanitizeUserText(strings.Join(tags, ", "))
+	}
+	return msg + "\n\nUse it as a prompt, e.g. !text2image " + utils.SanitizeUserText(caption)
+}
@@ -0,0 +1,60 @@
+package vision
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDescription(t *testing.T) {
+	tests := []struct {
+		name, output string
+		caption      string
+		tags         []string
+	}{
+		{
+			name:    "caption and tags",
+			output:  "A red fox sitting in fresh snow at dawn.\nTags: fox, snow, Winter, dawn, wildlife.",
+			caption: "A red fox sitting in fresh snow at dawn.",
+			tags:    []string{"fox", "snow", "winter", "dawn", "wildlife"},
+		},
+		{
+			name:    "labelled markdown",
+			output:  "**Caption:** A lighthouse on a cliff.\n\n**Tags:** lighthouse, cliff, lighthouse, sea",
+			caption: "A lighthouse on a cliff.",
+			tags:    []string{"lighthouse", "cliff", "sea"},
+		},
+		{
+			name:    "no tags line",
+			output:  "A city street at night,\nlit by neon signs.",
+			caption: "A city street at night, lit by neon signs.",
+		},
+		{
+			name:    "tags capped",
+			output:  "Shapes.\nTags: a, b, c, d, e, f, g, h, i, j, k, l",
+			caption: "Shapes.",
+			tags:    []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"},
+		},
+	}
+	for _, tt := range tests {
+		caption, tags := ParseDescription(tt.output)
+		if caption != tt.caption {
+			t.Errorf("%s: caption = %q, want %q", tt.name, caption, tt.caption)
+		}
+		if !reflect.DeepEqual(tags, tt.tags) {
+			t.Errorf("%s: tags = %q, want %q", tt.name, tags, tt.tags)
+		}
+	}
+}
+
+func TestFormatDescription(t *testing.T) {
+	msg := FormatDescription("A red fox in the snow.", []string{"fox", "snow"})
+	for _, want := range []string{"A red fox in the snow.", "Tags: fox, snow", "!text2image A red fox in the snow."} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("description %q lacks %q", msg, want)
+		}
+	}
+	if strings.Contains(FormatDescription("A fox.", nil), "Tags:") {
+		t.Error("description without tags lists tags")
+	}
+}
@@ -0,0 +1,245 @@
+// Package withdraw pays unused balance back to users as Bison Relay tips.
+// A withdrawal is asked for, confirmed by the user and then paid out by a
+// single worker, one at a time, so a burst of withdrawals cannot drain the
+// bot's wallet faster than an operator can react.
+package withdraw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/money"
+)
+
+const (
+	// DefaultMinAtoms is the smallest withdrawal, 0.001 DCR.
+	DefaultMinAtoms = money.AtomsPerDCR / 1000
+
+	// DefaultCooldown is how long a user waits between withdrawals.
+	DefaultCooldown = time.Hour
+
+	// confirmTTL is how long a withdrawal waits for its confirmation.
+	confirmTTL = 5 * time.Minute
+
+	// payTimeout is how long a payout waits for its tip to settle.
+	payTimeout = 2 * time.Minute
+
+	// queueSize caps the confirmed withdrawals waiting for the worker.
+	queueSize = 64
+)
+
+// ErrNoWithdrawal is returned when confirming without a withdrawal waiting
+// for confirmation.
+var ErrNoWithdrawal = errors.New("no withdrawal is waiting for confirmation")
+
+// Payer sends a tip of atoms on-chain atoms (1e-8 DCR) to uid and returns
+// once it settled or failed.
+type Payer interface {
+	Pay(ctx context.Context, uid string, atoms int64) error
+}
+
+// Config sets the limits of withdrawals.
+type Config struct {
+	MinAtoms int64         // Smallest withdrawal in balance atoms
+	Cooldown time.Duration // Time between withdrawals of a user
+}
+
+// Request is a withdrawal of Atoms balance atoms by UID.
+type Request struct {
+	UID       string
+	Atoms     int64
+	CreatedAt time.Time
+}
+
+// Service takes withdrawal requests and pays them out.
+type Service struct {
+	db     *database.DBManager
+	payer  Payer
+	cfg    Config
+	notify func(uid, msg string)
+	logf   func(format string, args ...interface{})
+	queue  chan Request
+
+	mu      sync.Mutex
+	pending map[string]Request   // Waiting for confirmation, by uid
+	last    map[string]time.Time // Last confirmed withdrawal, by uid
+}
+
+// Default is the service of !withdraw. It is nil while withdrawals are
+// disabled.
+var Default *Service
+
+// New returns a withdrawal service paying with payer. notify sends a user
+// the outcome of their withdrawal and logf logs payouts.
+func New(db *database.DBManager, payer Payer, cfg Config, notify func(uid, msg string), logf func(format string, args ...interface{})) *Service {
+	return &Service{
+		db:      db,
+		payer:   payer,
+		cfg:     cfg,
+		notify:  notify,
+		logf:    logf,
+		queue:   make(chan Request, queueSize),
+		pending: make(map[string]Request),
+		last:    make(map[string]time.Time),
+	}
+}
+
+// MinAtoms returns the smallest withdrawal in balance atoms.
+func (s *Service) MinAtoms() int64 {
+	return s.cfg.MinAtoms
+}
+
+// Withdrawable returns uid's balance and the part of it they can withdraw.
+func (s *Service) Withdrawable(uid string) (int64, int64, error) {
+	return s.db.WithdrawableBalance(uid)
+}
+
+// ParseAmount parses a withdrawal amount in DCR, or "all" for the whole
+// balance, into balance atoms rounded down to what a tip can carry.
+func ParseAmount(arg string, balance int64) (int64, error) {
+	atoms := balance
+	if !strings.EqualFold(arg, "all") {
+		dcr, err := strconv.ParseFloat(arg, 64)
+		if err != nil || dcr <= 0 {
+			return 0, fmt.Errorf("invalid amount: %s (must be a DCR amount or all)", arg)
+		}
+		if atoms, err = money.DCRToAtoms(dcr); err != nil {
+			return 0, fmt.Errorf("invalid amount: %v", err)
+		}
+	}
+	return atoms / money.AtomsPerChainAtom * money.AtomsPerChainAtom, nil
+}
+
+// Prepare checks a withdrawal of atoms by uid against the withdrawable part
+// of their balance (see database.WithdrawableBalance) and holds it until
+// Confirm. It replaces a withdrawal uid prepared before.
+func (s *Service) Prepare(uid string, atoms int64, now time.Time) (Request, error) {
+	if atoms < s.cfg.MinAtoms {
+		return Request{}, fmt.Errorf("the smallest withdrawal is %.8f DCR", money.AtomsToDCR(s.cfg.MinAtoms))
+	}
+	balance, withdrawable, err := s.db.WithdrawableBalance(uid)
+	if err != nil {
+		return Request{}, err
+	}
+	if atoms > balance {
+		return Request{}, fmt.Errorf("your balance is only %.8f DCR", money.AtomsToDCR(balance))
+	}
+	if atoms > withdrawable {
+		return Request{}, fmt.Errorf("only %.8f DCR of your balance can be withdrawn; promo, referral and admin credit can only be spent",
+			money.AtomsToDCR(withdrawable))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.cooldownErr(uid, now); err != nil {
+		return Request{}, err
+	}
+	req := Request{UID: uid, Atoms: atoms, CreatedAt: now}
+	s.pending[uid] = req
+	return req, nil
+}
+
+// Cancel drops the withdrawal uid prepared, reporting whether there was one.
+func (s *Service) Cancel(uid string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.pending[uid]
+	delete(s.pending, uid)
+	return ok
+}
+
+// Confirm queues the withdrawal uid prepared for payout. It returns the
+// number of withdrawals ahead of it.
+func (s *Service) Confirm(uid string, now time.Time) (Request, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	req, ok := s.pending[uid]
+	if !ok || now.Sub(req.CreatedAt) > confirmTTL {
+		delete(s.pending, uid)
+		return Request{}, 0, ErrNoWithdrawal
+	}
+	if err := s.cooldownErr(uid, now); err != nil {
+		return Request{}, 0, err
+	}
+	ahead := len(s.queue)
+	select {
+	case s.queue <- req:
+	default:
+		return Request{}, 0, errors.New("too many withdrawals are being processed, please try again later")
+	}
+	delete(s.pending, uid)
+	s.last[uid] = now
+	return req, ahead, nil
+}
+
+// cooldownErr returns an error while uid has to wait for their next
+// withdrawal. s.mu must be held.
+func (s *Service) cooldownErr(uid string, now time.Time) error {
+	if last, ok := s.last[uid]; ok && now.Sub(last) < s.cfg.Cooldown {
+		wait := s.cfg.Cooldown - now.Sub(last)
+		return fmt.Errorf("you can withdraw again in %d minutes", int(wait.Minutes())+1)
+	}
+	return nil
+}
+
+// Run pays out confirmed withdrawals one at a time until ctx is done.
+func (s *Service) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-s.queue:
+			s.payout(ctx, req)
+		}
+	}
+}
+
+// payout debits a withdrawal and tips it to the user, crediting it back
+// when the tip fails.
+func (s *Service) payout(ctx context.Context, req Request) {
+	dcr := money.AtomsToDCR(req.Atoms)
+	balance, err := s.db.DebitWithdrawal(req.UID, req.Atoms, time.Now())
+	if err != nil {
+		s.logf("Withdrawal of %.8f DCR by %s not paid: %v", dcr, req.UID, err)
+		s.resetCooldown(req.UID)
+		s.notify(req.UID, fmt.Sprintf("❌ Your withdrawal of %.8f DCR was not sent: %v", dcr, err))
+		return
+	}
+
+	payCtx, cancel := context.WithTimeout(ctx, payTimeout)
+	err = s.payer.Pay(payCtx, req.UID, req.Atoms/money.AtomsPerChainAtom)
+	timedOut := payCtx.Err() != nil
+	cancel()
+	switch {
+	case err == nil:
+		s.logf("Withdrawal of %.8f DCR paid to %s", dcr, req.UID)
+		s.notify(req.UID, fmt.Sprintf("✅ Sent you %.8f DCR. Your balance is now %.8f DCR.", dcr, money.AtomsToDCR(balance)))
+	case timedOut:
+		// The tip keeps being attempted, so the balance stays debited
+		s.logf("Withdrawal of %.8f DCR to %s not confirmed yet: %v", dcr, req.UID, err)
+		s.notify(req.UID, fmt.Sprintf("⏳ Your withdrawal of %.8f DCR is still being sent. Contact the bot's operator if it does not arrive.", dcr))
+	default:
+		s.logf("Withdrawal of %.8f DCR to %s failed: %v", dcr, req.UID, err)
+		if _, rerr := s.db.ReverseWithdrawal(req.UID, req.Atoms, time.Now()); rerr != nil {
+			s.logf("Failed to credit back withdrawal of %.8f DCR to %s: %v", dcr, req.UID, rerr)
+			s.notify(req.UID, fmt.Sprintf("❌ Your withdrawal of %.8f DCR failed and could not be credited back. Please contact the bot's operator.", dcr))
+			return
+		}
+		s.resetCooldown(req.UID)
+		s.notify(req.UID, fmt.Sprintf("❌ Your withdrawal of %.8f DCR failed and was credited back to your balance. Please try again later.", dcr))
+	}
+}
+
+// resetCooldown lets uid withdraw again right away after a withdrawal that
+// was not paid.
+func (s *Service) resetCooldown(uid string) {
+	s.mu.Lock()
+	delete(s.last, uid)
+	s.mu.Unlock()
+}
@@ -0,0 +1,146 @@
+package withdraw
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/money"
+)
+
+// fakePayer records payouts and fails while err is set.
+type fakePayer struct {
+	err  error
+	paid []int64
+}
+
+func (p *fakePayer) Pay(ctx context.Context, uid string, atoms int64) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.paid = append(p.paid, atoms)
+	return nil
+}
+
+func TestWithdraw(t *testing.T) {
+	db, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer db.Close()
+	if err := db.UpdateBalance("alice", money.AtomsPerDCR); err != nil {
+		t.Fatalf("UpdateBalance: %v", err)
+	}
+
+	payer := &fakePayer{}
+	var notices []string
+	s := New(db, payer, Config{MinAtoms: DefaultMinAtoms, Cooldown: time.Hour},
+		func(uid, msg string) { notices = append(notices, msg) }, t.Logf)
+	now := time.Now()
+
+	if _, err := s.Prepare("alice", DefaultMinAtoms/2, now); err == nil {
+		t.Fatal("a withdrawal below the minimum was accepted")
+	}
+	if _, err := s.Prepare("alice", 2*money.AtomsPerDCR, now); err == nil {
+		t.Fatal("a withdrawal over the balance was accepted")
+	}
+	if _, _, err := s.Confirm("alice", now); !errors.Is(err, ErrNoWithdrawal) {
+		t.Fatalf("Confirm without Prepare = %v", err)
+	}
+
+	atoms, err := ParseAmount("0.25", money.AtomsPerDCR)
+	if err != nil || atoms != money.AtomsPerDCR/4 {
+		t.Fatalf("ParseAmount = %d, %v", atoms, err)
+	}
+	if _, err := s.Prepare("alice", atoms, now); err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	if _, _, err := s.Confirm("alice", now.Add(10*time.Minute)); !errors.Is(err, ErrNoWithdrawal) {
+		t.Fatalf("Confirm after the confirmation expired = %v", err)
+	}
+	s.Prepare("alice", atoms, now)
+	if _, _, err := s.Confirm("alice", now); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	s.payout(context.Background(), <-s.queue)
+	if len(payer.paid) != 1 || payer.paid[0] != atoms/money.AtomsPerChainAtom {
+		t.Fatalf("paid %v, want one tip of %d", payer.paid, atoms/money.AtomsPerChainAtom)
+	}
+	if balance, _ := db.GetBalance("alice"); balance != money.AtomsPerDCR-atoms {
+		t.Fatalf("balance after withdrawal = %d", balance)
+	}
+	if _, err := s.Prepare("alice", atoms, now.Add(time.Minute)); err == nil {
+		t.Fatal("a second withdrawal within the cooldown was accepted")
+	}
+
+	// A failed tip is credited back and lifts the cooldown
+	payer.err = errors.New("no route")
+	later := now.Add(2 * time.Hour)
+	all, _ := ParseAmount("all", money.AtomsPerDCR-atoms)
+	s.Prepare("alice", all, later)
+	if _, _, err := s.Confirm("alice", later); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	s.payout(context.Background(), <-s.queue)
+	if balance, _ := db.GetBalance("alice"); balance != money.AtomsPerDCR-atoms {
+		t.Fatalf("balance after failed withdrawal = %d", balance)
+	}
+	if _, err := s.Prepare("alice", all, later); err != nil {
+		t.Fatalf("Prepare after failed withdrawal: %v", err)
+	}
+	ledger, _ := db.GetLedger("alice")
+	var reasons []string
+	for _, e := range ledger {
+		reasons = append(reasons, e.Reason)
+	}
+	want := []string{database.LedgerCredit, database.LedgerWithdrawal, database.LedgerWithdrawal, database.LedgerWithdrawalReversal}
+	if len(reasons) != len(want) || reasons[0] != want[0] || reasons[1] != want[1] || reasons[2] != want[2] || reasons[3] != want[3] {
+		t.Fatalf("ledger = %v, want %v", reasons, want)
+	}
+	if len(notices) != 2 {
+		t.Fatalf("notices = %q", notices)
+	}
+}
+
+func TestPromoCreditNotWithdrawable(t *testing.T) {
+	db, err := database.NewDBManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDBManager: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	if err := db.AddPromoCode(database.PromoCode{Code: "FREE", Kind: database.PromoCredit, Amount: money.AtomsPerDCR, CreatedAt: now}); err != nil {
+		t.Fatalf("AddPromoCode: %v", err)
+	}
+	if _, err := db.RedeemPromoCode("alice", "FREE", now); err != nil {
+		t.Fatalf("RedeemPromoCode: %v", err)
+	}
+	s := New(db, &fakePayer{}, Config{MinAtoms: DefaultMinAtoms, Cooldown: time.Hour},
+		func(uid, msg string) {}, t.Logf)
+	if _, err := s.Prepare("alice", money.AtomsPerDCR/2, now); err == nil {
+		t.Fatal("a withdrawal of promo credit was accepted")
+	}
+
+	// Only the tipped part can be withdrawn, less what was spent
+	tip := int64(money.AtomsPerDCR / 2)
+	if _, _, err := db.CreditTip(1, "alice", tip); err != nil {
+		t.Fatalf("CreditTip: %v", err)
+	}
+	if _, _, err := db.ChargeBalance("alice", tip/5, ""); err != nil {
+		t.Fatalf("ChargeBalance: %v", err)
+	}
+	balance, withdrawable, err := s.Withdrawable("alice")
+	if err != nil || balance != money.AtomsPerDCR+tip-tip/5 || withdrawable != tip-tip/5 {
+		t.Fatalf("Withdrawable = %d, %d, %v; want %d, %d", balance, withdrawable, err, money.AtomsPerDCR+tip-tip/5, tip-tip/5)
+	}
+	if _, err := s.Prepare("alice", tip, now); err == nil {
+		t.Fatal("a withdrawal over the tipped balance was accepted")
+	}
+	all, _ := ParseAmount("all", withdrawable)
+	if _, err := s.Prepare("alice", all, now); err != nil {
+		t.Fatalf("Prepare of the tipped balance: %v", err)
+	}
+}
@@ -16,19 +16,42 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/companyzero/bisonrelay/clientrpc/types"
 	"github.com/companyzero/bisonrelay/zkidentity"
 	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/karamble/braibot/internal/assets"
+	"github.com/karamble/braibot/internal/catalog"
 	"github.com/karamble/braibot/internal/commands"
 	braiconfig "github.com/karamble/braibot/internal/config"
+	"github.com/karamble/braibot/internal/confirm"
 	"github.com/karamble/braibot/internal/database"
+	"github.com/karamble/braibot/internal/debuglog"
+	"github.com/karamble/braibot/internal/dispatcher"
+	"github.com/karamble/braibot/internal/faladapter"
+	"github.com/karamble/braibot/internal/falhook"
 	"github.com/karamble/braibot/internal/fmp"
+	"github.com/karamble/braibot/internal/i18n"
+	"github.com/karamble/braibot/internal/jobevents"
+	"github.com/karamble/braibot/internal/jobs"
 	"github.com/karamble/braibot/internal/mcpsrv"
+	"github.com/karamble/braibot/internal/moderation"
+	"github.com/karamble/braibot/internal/money"
+	"github.com/karamble/braibot/internal/pipeline"
+	"github.com/karamble/braibot/internal/queue"
+	"github.com/karamble/braibot/internal/ratelimit"
+	"github.com/karamble/braibot/internal/reqcache"
+	"github.com/karamble/braibot/internal/schedule"
+	"github.com/karamble/braibot/internal/templates"
+	"github.com/karamble/braibot/internal/tips"
+	"github.com/karamble/braibot/internal/topup"
+	"github.com/karamble/braibot/internal/transcribe"
 	braibottypes "github.com/karamble/braibot/internal/types"
 	"github.com/karamble/braibot/internal/utils"
+	"github.com/karamble/braibot/internal/withdraw"
 	"github.com/karamble/braibot/pkg/fal"
 	"github.com/karamble/brmcp"
 	"github.com/karamble/brmcp/bridge"
@@ -45,16 +68,32 @@ import (
 var (
 	flagAppRoot = flag.String("approot", "~/.braibot", "Path to application data directory")
 	flagDebug   = flag.Bool("debug", false, "Enable debug mode")
-	dbManager   *database.DBManager     // Database manager for user balances
-	debug       bool                    // Debug mode flag
-	welcomeSent = make(map[string]bool) // Track users who have received welcome message
+	flagLogLvl  = flag.String("debuglevel", "info", "Log level, or comma-separated subsystem=level overrides, e.g. info,IMG=debug")
+	flagDumpCmd = flag.Bool("dump-commands", false, "Print all commands, models and flags as JSON and exit")
+	flagMigrate = flag.Bool("migrate-only", false, "Apply pending database migrations and exit")
+	dbManager   *database.DBManager // Database manager for user balances
+	debug       bool                // Debug mode flag
 )
 
 func realMain() error {
 	flag.Parse()
 
-	// Set debug mode
+	// Set debug mode; --debug turns on every debug subsystem
 	debug = *flagDebug
+	if debug {
+		debuglog.SetAll(true)
+	}
+
+	// Dump command metadata for docs and tooling without starting the bot
+	if *flagDumpCmd {
+		registry := commands.InitializeCommands(nil, &botkitconfig.BotConfig{ExtraConfig: map[string]string{}}, nil, nil, debug)
+		data, err := commands.DumpCommands(registry)
+		if err != nil {
+			return fmt.Errorf("failed to dump commands: %v", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
 
 	// Expand and clean the app root path
 	appRoot := botkitutils.CleanAndExpandPath(*flagAppRoot)
@@ -67,6 +106,20 @@ func realMain() error {
 	}
 	defer dbManager.Close()
 
+	// Upgrade the database schema without starting the bot, e.g. before a
+	// deploy
+	if *flagMigrate {
+		for _, m := range dbManager.Migrations() {
+			fmt.Printf("Applied migration %s\n", m)
+		}
+		version, err := dbManager.SchemaVersion()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Database schema is at version %d\n", version)
+		return nil
+	}
+
 	// Initialize logging
 	logBackend, err := logging.NewLogBackend(logging.LogConfig{
 		LogFile:        filepath.Join(appRoot, "logs", "braibot.log"),
@@ -78,9 +131,18 @@ func realMain() error {
 		return fmt.Errorf("failed to initialize logging: %v", err)
 	}
 	defer logBackend.Close()
+	for _, lvl := range strings.Split(*flagLogLvl, ",") {
+		if err := logBackend.SetLogLevel(strings.TrimSpace(lvl)); err != nil {
+			return fmt.Errorf("invalid --debuglevel: %v", err)
+		}
+	}
 
 	// Get a logger for the application
 	log := logBackend.Logger("BraiBot")
+	debuglog.Attach(logBackend)
+	for _, m := range dbManager.Migrations() {
+		log.Infof("Applied database migration %s", m)
+	}
 
 	// Load bot configuration
 	cfg, err := botkitconfig.LoadBotConfig(appRoot, "braibot.conf")
@@ -132,13 +194,431 @@ func realMain() error {
 		return fmt.Errorf("failed to create bot: %v", err)
 	}
 
-	// Initialize command registry
-	commandRegistry := commands.InitializeCommands(dbManager, cfg, bot, debug)
+	// Turn on debug logging for some subsystems only, e.g.
+	// debugsubsystems=fal,billing. !admin debug toggles them at runtime.
+	if err := debuglog.Configure(cfg.ExtraConfig["debugsubsystems"]); err != nil {
+		log.Warnf("Ignoring debugsubsystems: %v", err)
+	}
+
+	// Add models and change built-in ones from the model definition file,
+	// models.json in the app root unless modelsfile= names another. It is
+	// loaded before the commands so they list the new models.
+	modelsFile := strings.TrimSpace(cfg.ExtraConfig["modelsfile"])
+	if modelsFile == "" {
+		modelsFile = filepath.Join(appRoot, "models.json")
+	}
+	if added, changed, err := faladapter.LoadDefinitions(modelsFile); err != nil {
+		log.Warnf("Model definitions: %v", err)
+	} else if added+changed > 0 {
+		log.Infof("Model definitions: %d models added, %d changed", added, changed)
+	}
 
 	// Set up context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Let fal post completed requests to falwebhookurl=, a public URL
+	// forwarded to the listener on falwebhooklisten= (e.g. :8089), instead
+	// of polling every few seconds. Requests still poll every
+	// falwebhookpoll (default 1m) in case a webhook never arrives.
+	if hookURL := strings.TrimSpace(cfg.ExtraConfig["falwebhookurl"]); hookURL != "" {
+		addr := strings.TrimSpace(cfg.ExtraConfig["falwebhooklisten"])
+		poll := extraDuration(cfg.ExtraConfig, "falwebhookpoll", fal.DefaultWebhookPollInterval)
+		if err := falhook.Start(ctx, addr, hookURL, poll, log.Warnf); err != nil {
+			log.Warnf("Fal webhooks off, polling instead: %v", err)
+		} else {
+			log.Infof("Fal webhooks posted to %s are served on %s", hookURL, addr)
+		}
+	}
+
+	// Operators add languages or reword the bot's messages with catalogs
+	// such as de.json in the locales directory of the app root.
+	if n, err := i18n.Default.LoadDir(filepath.Join(appRoot, "locales")); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warnf("Locales: %v", err)
+	} else if n > 0 {
+		log.Infof("Locales: %d messages loaded, languages %s", n, strings.Join(i18n.Default.Languages(), ", "))
+	}
+	// Billing and result messages are reworded with files such as
+	// billing_charged.tmpl in the templates directory of the app root.
+	if n, err := templates.Default.LoadDir(filepath.Join(appRoot, "templates")); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warnf("Templates: %v", err)
+	} else if n > 0 {
+		log.Infof("Templates: %d messages loaded", n)
+	}
+
+	// Keep local copies of delivered results under approot/media, up to
+	// mediacachemb MiB, so they can be sent again once the provider's URLs
+	// expired. The least recently used results are removed first.
+	if mb := extraInt(cfg.ExtraConfig, "mediacachemb", 0); mb > 0 {
+		cache, err := assets.NewCache(filepath.Join(appRoot, "media"), mb<<20)
+		if err != nil {
+			return err
+		}
+		assets.DefaultCache = cache
+		log.Infof("Media cache: up to %d MiB in %s", mb, filepath.Join(appRoot, "media"))
+	}
+
+	// Identical requests of a user within requestcachettl (e.g. 30m) get the
+	// earlier result again, free of charge, instead of a new generation. 0
+	// turns this off.
+	if ttl := extraDuration(cfg.ExtraConfig, "requestcachettl", reqcache.DefaultTTL); ttl > 0 {
+		reqcache.Default = reqcache.New(dbManager, ttl, logBackend.Logger("CACH"))
+	}
+
+	// rate_mode=fixed prices requests at fixed_dcr_usd= USD per DCR instead
+	// of the live rate, so prices do not move with the market. !admin rate
+	// set overrides either rate for a while.
+	switch mode := strings.ToLower(strings.TrimSpace(cfg.ExtraConfig["rate_mode"])); mode {
+	case "", utils.RateLive:
+	case utils.RateFixed:
+		usd := extraFloat(cfg.ExtraConfig, "fixed_dcr_usd", 0)
+		if usd <= 0 {
+			return fmt.Errorf("rate_mode=fixed needs fixed_dcr_usd set to the USD price of one DCR")
+		}
+		utils.SetFixedDCRRate(usd)
+		log.Infof("Pricing at a fixed $%.2f USD per DCR", usd)
+	default:
+		return fmt.Errorf("invalid rate_mode: %q (must be live or fixed)", mode)
+	}
+
+	// markuppercent= and markupfeeusd= add a service fee to what every model
+	// costs at fal, e.g. 10 for 10% plus 0.01 per request. The model
+	// definition file can set markup_percent and markup_fee_usd per model.
+	markup := faladapter.Markup{
+		Percent: extraFloat(cfg.ExtraConfig, "markuppercent", 0),
+		FeeUSD:  extraFloat(cfg.ExtraConfig, "markupfeeusd", 0),
+	}
+	faladapter.SetMarkup(markup)
+	if !markup.IsZero() {
+		log.Infof("Charging a %s on top of model costs", markup)
+	}
+
+	// Receipts warn when the balance left is worth less than lowbalanceusd=
+	// USD; 0 turns the warning off.
+	utils.SetLowBalanceThreshold(extraFloat(cfg.ExtraConfig, "lowbalanceusd", utils.DefaultLowBalanceUSD))
+
+	// Initialize command registry
+	commandRegistry := commands.InitializeCommands(dbManager, cfg, bot, logBackend, debug)
+
+	// Apply the job retention tiers and reap expired jobs in the background.
+	// retentionfree/retentionfunded take Go durations (e.g. 24h, 720h); 0
+	// keeps jobs forever.
+	dbManager.SetRetentionPolicy(database.RetentionPolicy{
+		Free:   extraDuration(cfg.ExtraConfig, "retentionfree", database.DefaultRetentionPolicy.Free),
+		Funded: extraDuration(cfg.ExtraConfig, "retentionfunded", database.DefaultRetentionPolicy.Funded),
+	})
+	// Balances of users inactive for balanceexpiry (e.g. 8760h) expire after
+	// two warning PMs, balanceexpirywarn1 and balanceexpirywarn2 ahead. Unset
+	// or 0 never expires balances.
+	dbManager.SetExpiryPolicy(database.ExpiryPolicy{
+		After:         extraDuration(cfg.ExtraConfig, "balanceexpiry", database.DefaultExpiryPolicy.After),
+		FirstWarning:  extraDuration(cfg.ExtraConfig, "balanceexpirywarn1", database.DefaultExpiryPolicy.FirstWarning),
+		SecondWarning: extraDuration(cfg.ExtraConfig, "balanceexpirywarn2", database.DefaultExpiryPolicy.SecondWarning),
+	})
+	jobs.Default.SetLogger(logBackend.Logger("JOBS"))
+	// Initial per-kind concurrency limits; admins can change them at runtime
+	// with !admin setlimit. The job manager applies them when picking queued
+	// jobs, so workers are not held by jobs waiting for a slot.
+	for kind, def := range map[string]int64{queue.KindVideo: 1, queue.KindImage: 4, queue.KindSpeech: 4} {
+		n := int(extraInt(cfg.ExtraConfig, "max"+kind+"jobs", def))
+		queue.Default.SetLimit(kind, n)
+		jobs.Default.SetKindLimit(kind, n)
+	}
+	// Per-user caps on queued or running jobs of each kind
+	// (maxuservideojobs=, ...), and how often each user (ratelimit=) and all
+	// users together (globalratelimit=) may start generations, e.g. 5/1m.
+	// All are off unless set.
+	for _, kind := range []string{queue.KindVideo, queue.KindImage, queue.KindSpeech} {
+		jobs.Default.SetUserKindLimit(kind, int(extraInt(cfg.ExtraConfig, "maxuser"+kind+"jobs", 0)))
+	}
+	userRate, rateErr := ratelimit.ParseRate(cfg.ExtraConfig["ratelimit"])
+	if rateErr != nil {
+		log.Warnf("Ignoring ratelimit: %v", rateErr)
+	}
+	globalRate, rateErr := ratelimit.ParseRate(cfg.ExtraConfig["globalratelimit"])
+	if rateErr != nil {
+		log.Warnf("Ignoring globalratelimit: %v", rateErr)
+	}
+	ratelimit.Default.SetRates(userRate, globalRate)
+
+	// Screen the prompts of generation commands before they are queued with
+	// the word list in moderationwordlist= (default moderation.txt in the app
+	// root, if present) and the OpenAI-style moderation API at moderationurl=.
+	var checkers moderation.Chain
+	wordListPath := cfg.ExtraConfig["moderationwordlist"]
+	if wordListPath == "" {
+		wordListPath = filepath.Join(appRoot, "moderation.txt")
+	}
+	if wl, err := moderation.LoadWordList(wordListPath); err == nil {
+		log.Infof("Moderation word list: %d rules from %s", wl.Len(), wordListPath)
+		checkers = append(checkers, wl)
+	} else if cfg.ExtraConfig["moderationwordlist"] != "" || !errors.Is(err, os.ErrNotExist) {
+		log.Warnf("Moderation word list not loaded: %v", err)
+	}
+	if u := cfg.ExtraConfig["moderationurl"]; u != "" {
+		api := moderation.NewAPI(u, cfg.ExtraConfig["moderationkey"])
+		api.Model = cfg.ExtraConfig["moderationmodel"]
+		checkers = append(checkers, api)
+	}
+	if len(checkers) > 0 {
+		moderation.Default = checkers
+	}
+	moderationFailClosed := strings.EqualFold(cfg.ExtraConfig["moderationfailclosed"], "true")
+	// Chained jobs such as !restore stop before their total would exceed
+	// pipelinemaxusd; users may lower it with --max-cost.
+	pipeline.SetDefaultCeiling(extraFloat(cfg.ExtraConfig, "pipelinemaxusd", 5))
+	// Users may be charged at most dailyspendlimit USD per 24 hours and
+	// weeklyspendlimit USD per 7 days (0 = unlimited); admins may set other
+	// limits per user with !admin spendlimit.
+	utils.SetSpendLimits(utils.SpendLimits{
+		DailyUSD:  extraFloat(cfg.ExtraConfig, "dailyspendlimit", 0),
+		WeeklyUSD: extraFloat(cfg.ExtraConfig, "weeklyspendlimit", 0),
+	})
+	// Generations costing more than confirmaboveusd USD wait for the user's
+	// !confirm (0 = never ask).
+	if threshold := extraFloat(cfg.ExtraConfig, "confirmaboveusd", 0); threshold > 0 {
+		confirm.Default = confirm.New(threshold)
+	}
+
+	// Pin models to another fal endpoint revision without a release, e.g.
+	// endpoint.kling-video-text=/kling-video/v2/master/text-to-video
+	for key, endpoint := range cfg.ExtraConfig {
+		name, ok := strings.CutPrefix(key, "endpoint.")
+		if !ok {
+			continue
+		}
+		endpoint = strings.TrimSpace(endpoint)
+		if err := fal.SetEndpointOverride(name, endpoint); err != nil {
+			log.Warnf("Ignoring %s: %v", key, err)
+			continue
+		}
+		if endpoint != "" {
+			log.Infof("Model %s pinned to endpoint %s", name, endpoint)
+		}
+	}
+
+	// Update model prices and metadata from a model catalog, a URL or file
+	// set with modelcatalog=, and the operator's own modelcatalogoverrides=
+	// file. The catalog is fetched again every modelcatalogrefresh (default
+	// 6h, 0 = only at startup) and with !admin models reload.
+	catalog.Default.Configure(strings.TrimSpace(cfg.ExtraConfig["modelcatalog"]), strings.TrimSpace(cfg.ExtraConfig["modelcatalogoverrides"]))
+	if catalog.Default.Configured() {
+		if sum, err := catalog.Default.Reload(ctx); err != nil {
+			log.Warnf("Model catalog: %v", err)
+		} else {
+			log.Infof("Model catalog: %d models updated, %d disabled", sum.Models, sum.Disabled)
+			if len(sum.Unknown) > 0 {
+				log.Warnf("Model catalog: skipped unknown models %s", strings.Join(sum.Unknown, ", "))
+			}
+		}
+		if interval := extraDuration(cfg.ExtraConfig, "modelcatalogrefresh", 6*time.Hour); interval > 0 {
+			go catalog.Default.Run(ctx, interval, log.Infof)
+		}
+	}
+
+	// Stream job lifecycle events for every user to the console unless
+	// jobconsole=false.
+	if !strings.EqualFold(cfg.ExtraConfig["jobconsole"], "false") {
+		events, _ := jobevents.Default.Subscribe(256)
+		go func() {
+			for e := range events {
+				log.Infof("[job] %s", e)
+			}
+		}()
+	}
+
+	// Record every delivered job in the generations ledger that !leaderboard
+	// is computed from.
+	generations, _ := jobevents.Default.Subscribe(1024)
+	go func() {
+		for e := range generations {
+			if e.Type != jobevents.Delivered || e.Items == 0 {
+				continue
+			}
+			err := dbManager.RecordGeneration(database.Generation{
+				UID:       e.UID,
+				Nick:      e.User,
+				GC:        e.GC,
+				Command:   e.Command,
+				Model:     e.Model,
+				Items:     e.Items,
+				CreatedAt: e.Time,
+			})
+			if err != nil {
+				log.Warnf("Failed to record generation of job %d: %v", e.Job, err)
+			}
+		}
+	}()
+
+	// Bots sharing a GC can echo each other's commands into a loop. Commands
+	// from the uids in botuids, and from messages that read like bot output
+	// (unless botheuristic=false), are never executed.
+	botGuard := utils.NewBotGuard(splitCSV(cfg.ExtraConfig["botuids"]), !strings.EqualFold(cfg.ExtraConfig["botheuristic"], "false"))
+
+	msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
+
+	// Outbound tips, directory payments and withdrawals, are settled by the
+	// matching terminal tip-progress events.
+	tipper := &tipPayer{bot: bot, matcher: bridge.NewTipMatcher()}
+
+	// Tips credited before the database record existed are still in the
+	// legacy JSON journal, which is only consulted, never written.
+	legacyTips, err := server.OpenTipJournal(filepath.Join(appRoot, "data", "tips.json"))
+	if err != nil {
+		return fmt.Errorf("failed to open tip journal: %v", err)
+	}
+
+	// The router dispatches received PMs, GC messages and tips. Users
+	// without a balance get a funding walkthrough instead of an
+	// insufficient balance error for paid commands unless guestmode=false.
+	// Users are welcomed once, or again after rewelcome (e.g. 720h) without
+	// a welcome or command.
+	router := dispatcher.NewMessageRouter(dispatcher.Config{
+		Bot:                  bot,
+		DB:                   dbManager,
+		Registry:             commandRegistry,
+		Sender:               msgSender,
+		Guard:                botGuard,
+		Guests:               commands.NewGuestMode(cfg.ExtraConfig),
+		Tips:                 tips.NewHandler(dbManager, bot, legacyTips, topup.Default, logBackend.Logger("TIPS")),
+		TipResolver:          tipper.matcher,
+		Log:                  log,
+		ModerationFailClosed: moderationFailClosed,
+		WelcomeEvery:         extraDuration(cfg.ExtraConfig, "rewelcome", 0),
+		GCNotes:              transcribe.DefaultGCNotes,
+	})
+
+	// Generation commands run on jobworkers workers so the bot keeps
+	// answering other commands meanwhile. Each user may have maxuserjobs
+	// generations queued or running (0 = unlimited).
+	rootCtx := ctx
+	runQueuedJob := func(ctx context.Context, job database.QueuedJob) error {
+		command, exists := commandRegistry.Get(job.Command)
+		if !exists {
+			return fmt.Errorf("unknown command %q", job.Command)
+		}
+		msgCtx, err := queuedMessageContext(job)
+		if err != nil {
+			return err
+		}
+		debuglog.Debugf(debuglog.Dispatch, "Running job %d (!%s for %s)", job.ID, job.Command, job.Nick)
+		// Remember the job's first fal request, and leave it running at
+		// shutdown, so the job can pick up its result after a restart
+		// instead of being charged at fal twice
+		if job.ResponseURL != "" {
+			log.Infof("Resuming job %d (!%s for %s) after a restart", job.ID, job.Command, job.Nick)
+			msgSender.SendMessage(ctx, msgCtx, utils.UserText(dbManager, job.UID, "job_resumed", i18n.Args{"Command": job.Command}))
+		}
+		var tracked atomic.Bool
+		tracked.Store(job.ResponseURL != "")
+		// Charges are numbered after the job, so a resumed job is not
+		// charged again for what it was charged before the restart
+		ctx = utils.WithJobCharges(ctx, job.ID)
+		ctx = fal.WithResume(ctx, fal.ResumeOptions{
+			URL: job.ResponseURL,
+			OnQueued: func(responseURL string) {
+				if !tracked.CompareAndSwap(false, true) {
+					return
+				}
+				if err := dbManager.SetQueuedJobResponseURL(job.ID, responseURL); err != nil {
+					log.Warnf("Job %d: %v", job.ID, err)
+				}
+			},
+			Shutdown: rootCtx,
+		})
+		handleErr := command.Handler.Handle(ctx, msgCtx, job.Args, msgSender, dbManager)
+		debuglog.Debugf(debuglog.Dispatch, "Job %d finished: %v", job.ID, handleErr)
+		if handleErr != nil && ctx.Err() == nil {
+			router.ReportCommandError(ctx, msgCtx, job.Command, handleErr)
+		}
+		return handleErr
+	}
+	jobInterrupted := func(job database.QueuedJob) {
+		msgCtx, err := queuedMessageContext(job)
+		if err != nil {
+			log.Warnf("Dropping interrupted job %d: %v", job.ID, err)
+			return
+		}
+		log.Infof("Job %d (!%s for %s) was interrupted by a restart", job.ID, job.Command, job.Nick)
+		msg := utils.UserText(dbManager, job.UID, "job_interrupted", i18n.Args{"Command": job.Command})
+		if err := msgSender.SendMessage(ctx, msgCtx, msg); err != nil {
+			log.Warnf("Failed to notify %s of interrupted job %d: %v", job.Nick, job.ID, err)
+		}
+	}
+	err = jobs.Default.Start(ctx, dbManager, int(extraInt(cfg.ExtraConfig, "jobworkers", 4)),
+		int(extraInt(cfg.ExtraConfig, "maxuserjobs", 5)), runQueuedJob, jobInterrupted)
+	if err != nil {
+		return fmt.Errorf("failed to start job queue: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			if n, err := dbManager.PurgeExpiredJobs(time.Now()); err != nil {
+				log.Warnf("Job reaper: %v", err)
+			} else if n > 0 {
+				log.Infof("Job reaper: removed %d expired jobs", n)
+			}
+			if _, err := reqcache.Default.Purge(); err != nil {
+				log.Warnf("Request cache: %v", err)
+			}
+			notices, err := dbManager.SweepInactiveBalances(time.Now())
+			if err != nil {
+				log.Warnf("Balance expiry: %v", err)
+			}
+			for _, n := range notices {
+				if n.Warning == 0 {
+					log.Infof("Balance expiry: expired %.8f DCR of inactive user %s", money.AtomsToDCR(n.Balance), n.UID)
+				}
+				if err := bot.SendPM(ctx, n.UID, formatExpiryNotice(n)); err != nil {
+					log.Warnf("Failed to send balance expiry notice to %s: %v", n.UID, err)
+				}
+			}
+			summaries, err := dbManager.DueWeeklySummaries(utils.SettingWeeklySummary, time.Now())
+			if err != nil {
+				log.Warnf("Weekly summaries: %v", err)
+			}
+			for _, s := range summaries {
+				dcrUSD, _, _ := utils.GetDCRPrice()
+				if err := bot.SendPM(ctx, s.UID, formatWeeklySummary(s, dcrUSD)); err != nil {
+					log.Warnf("Failed to send weekly summary to %s: %v", s.UID, err)
+					continue
+				}
+				if err := dbManager.MarkWeeklySummarySent(s.UID, time.Now()); err != nil {
+					log.Warnf("Weekly summaries: %v", err)
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	// Queue the generations users scheduled with !schedule as they come
+	// due. Runs missed while the bot was down are made up once.
+	scheduleLog := logBackend.Logger("SCHD")
+	scheduler := schedule.NewRunner(dbManager, func(job database.QueuedJob) error {
+		_, err := jobs.Default.Submit(job)
+		return err
+	}, func(uid, msg string) {
+		if err := bot.SendPM(ctx, uid, msg); err != nil {
+			scheduleLog.Warnf("Failed to notify %s of their scheduled run: %v", uid, err)
+		}
+	})
+	go scheduler.Run(ctx, time.Minute, scheduleLog.Infof)
+
+	// Copy delivered results into the operator's asset store so !redeliver
+	// keeps working after the provider's URLs expire. Enabled by setting
+	// assetdir= and the URL it is served at, assetbaseurl=.
+	if dir, base := cfg.ExtraConfig["assetdir"], cfg.ExtraConfig["assetbaseurl"]; dir != "" && base != "" {
+		mirror := assets.NewMirror(dbManager, assets.DirStore{Dir: dir, BaseURL: base})
+		mirror.SetCache(assets.DefaultCache)
+		go mirror.Run(ctx, extraDuration(cfg.ExtraConfig, "assetmirrorinterval", time.Minute), log.Infof)
+	}
+
 	// Handle shutdown signals
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -149,13 +629,32 @@ func realMain() error {
 		bot.Close()
 	}()
 
+	// Users get unused balance back as a tip with !withdraw unless
+	// withdrawenabled=false. Payouts are sent one at a time; each user may
+	// withdraw at least withdrawmin DCR once per withdrawcooldown.
+	if !strings.EqualFold(cfg.ExtraConfig["withdrawenabled"], "false") {
+		minAtoms, err := money.DCRToAtoms(extraFloat(cfg.ExtraConfig, "withdrawmin", money.AtomsToDCR(withdraw.DefaultMinAtoms)))
+		if err != nil {
+			return fmt.Errorf("invalid withdrawmin: %v", err)
+		}
+		withdrawLog := logBackend.Logger("WDRL")
+		withdraw.Default = withdraw.New(dbManager, tipper, withdraw.Config{
+			MinAtoms: minAtoms,
+			Cooldown: extraDuration(cfg.ExtraConfig, "withdrawcooldown", withdraw.DefaultCooldown),
+		}, func(uid, msg string) {
+			if err := bot.SendPM(ctx, uid, msg); err != nil {
+				withdrawLog.Warnf("Failed to notify %s of their withdrawal: %v", uid, err)
+			}
+		}, withdrawLog.Infof)
+		go withdraw.Default.Run(ctx)
+	}
+
 	// MCP over Bison Relay: serve the generation tools to MCP agents when
 	// mcpenabled=1 is set in braibot.conf. braibot is an open service, so
 	// any KX'd caller may connect; balances and rate limits do the gating.
 	var mcpRouter *brmcp.Router
-	var dirMatcher *bridge.TipMatcher
 	if v := strings.ToLower(cfg.ExtraConfig["mcpenabled"]); v == "1" || v == "true" {
-		falClient := fal.NewClient(cfg.ExtraConfig["falapikey"], fal.WithDebug(debug))
+		falClient := fal.NewClient(cfg.ExtraConfig["falapikey"], commands.FalClientOptions(cfg.ExtraConfig)...)
 		adminUIDs := splitCSV(cfg.ExtraConfig["adminuids"])
 		dirUIDs := splitCSV(cfg.ExtraConfig["directoryuids"])
 		adm, err := mcpsrv.NewAdmin(dbManager, filepath.Join(appRoot, "mcp"), adminUIDs, dirUIDs)
@@ -176,7 +675,7 @@ func realMain() error {
 		if err != nil {
 			return fmt.Errorf("failed to init MCP harness: %v", err)
 		}
-		mcpsrv.Attach(h, falClient, dbManager, bot, debug)
+		mcpsrv.Attach(h, falClient, commands.ImageGeneratorFromConfig(cfg.ExtraConfig, falClient), dbManager, bot, logBackend, debug)
 		// Stock market tools ride the same harness when an FMP key is
 		// configured; without one they are simply not registered.
 		if fmpKey := cfg.ExtraConfig["fmpapikey"]; fmpKey != "" {
@@ -210,6 +709,7 @@ func realMain() error {
 			log.Infof("Admin tools enabled (%d admins)", len(adminUIDs))
 		}
 		mcpRouter = h.Start(ctx, mcpSender{bot: bot})
+		router.SetMCPRouter(mcpRouter)
 		log.Infof("MCP over Bison Relay enabled")
 
 		// Directory presence: register the tools at brmcpdir directories
@@ -222,7 +722,6 @@ func realMain() error {
 			if len(uids) == 0 || desc == "" {
 				return fmt.Errorf("directoryenabled requires directoryuids and directorydescription in braibot.conf")
 			}
-			dirMatcher = bridge.NewTipMatcher()
 			autoFund := directory.AutoFund{
 				Enabled:              true,
 				MaxAtomsPerRequest:   extraInt(cfg.ExtraConfig, "autofundmaxatoms", 1_000_000),
@@ -240,7 +739,7 @@ func realMain() error {
 				AutoFund: autoFund,
 				DataDir:  filepath.Join(appRoot, "mcp"),
 				Router:   mcpRouter,
-				Payer:    &tipPayer{bot: bot, matcher: dirMatcher},
+				Payer:    tipper,
 				Name:     "braibot",
 				Logf:     logBackend.Logger("DIR").Infof,
 			})
@@ -267,162 +766,6 @@ func realMain() error {
 		}
 	}
 
-	// Add a goroutine to handle PMs using our bidirectional channel
-	go func() {
-		for pm := range pmChan {
-			// During shutdown, keep draining the channel so bisonbotkit
-			// handlers don't block on their unbuffered sends, but skip
-			// processing.
-			if ctx.Err() != nil {
-				continue
-			}
-			// MCP envelope frames are harness protocol traffic, not
-			// chat: route them and skip command parsing and welcomes.
-			if mcpRouter != nil && brmcp.IsEnvelope(pm.Msg.Message) {
-				mcpRouter.HandlePM(utils.GetUserIDString(pm.Uid), pm.Msg.Message)
-				continue
-			}
-
-			log.Infof("Received PM from %s: %s", pm.Nick, pm.Msg.Message)
-
-			// Convert UID to string ID for tracking
-			userIDStr := utils.GetUserIDString(pm.Uid)
-
-			// Check if the message is a command
-			if cmd, args, isCmd := commands.IsCommand(pm.Msg.Message); isCmd {
-				// Mark welcome as sent when user sends any command
-				welcomeSent[userIDStr] = true
-
-				if command, exists := commandRegistry.Get(cmd); exists {
-					// Construct MessageContext for PM
-					var senderID zkidentity.ShortID
-					senderID.FromBytes(pm.Uid)
-					msgCtx := braibottypes.MessageContext{
-						Nick:    pm.Nick,
-						Uid:     pm.Uid,
-						Message: pm.Msg.Message,
-						IsPM:    true,
-						Sender:  senderID,
-					}
-					msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
-					handleErr := command.Handler.Handle(ctx, msgCtx, args, msgSender, dbManager)
-					if handleErr != nil {
-						// Check if the error is specifically ErrInsufficientBalance
-						var insufErr *utils.ErrInsufficientBalance
-						if errors.Is(handleErr, insufErr) {
-							// Send the specific error message as PM, don't log as warning
-							if pmErr := bot.SendPM(ctx, pm.Nick, handleErr.Error()); pmErr != nil {
-								log.Warnf("Failed to send insufficient balance PM to %s: %v", pm.Nick, pmErr)
-							}
-						} else {
-							// Send user-friendly error PM
-							bot.SendPM(ctx, pm.Nick, "Your request could not be processed by the AI datacenter. Please try again later.")
-							log.Warnf("Error executing command %s for user %s: %v", cmd, pm.Nick, handleErr)
-						}
-					}
-				} else {
-					// Send error message for unknown command
-					bot.SendPM(ctx, pm.Nick, fmt.Sprintf("👋 Hi %s!\n\nI don't recognize that command. Use **!help** to see available commands.", pm.Nick))
-				}
-			} else if utils.IsAudioNote(pm.Msg.Message) {
-				// Handle audio note
-				audioData, err := utils.ExtractAudioNoteData(pm.Msg.Message)
-				if err != nil {
-					log.Warnf("Failed to extract audio data from message: %v", err)
-					bot.SendPM(ctx, pm.Nick, "Sorry, I couldn't process your audio note. Please try again.")
-					continue
-				}
-
-				// Construct MessageContext for PM
-				var senderID zkidentity.ShortID
-				senderID.FromBytes(pm.Uid)
-				msgCtx := braibottypes.MessageContext{
-					Nick:    pm.Nick,
-					Uid:     pm.Uid,
-					Message: pm.Msg.Message,
-					IsPM:    true,
-					Sender:  senderID,
-				}
-
-				// Create a message sender
-				msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
-
-				// Get the AI command handler
-				aiCommand, exists := commandRegistry.Get("ai")
-				if !exists {
-					log.Warnf("AI command not found in registry")
-					bot.SendPM(ctx, pm.Nick, "Sorry, the AI processing feature is currently unavailable.")
-					continue
-				}
-
-				// Execute the AI command with the audio data
-				handleErr := aiCommand.Handler.Handle(ctx, msgCtx, []string{audioData}, msgSender, dbManager)
-				if handleErr != nil {
-					log.Warnf("Error processing audio note: %v", handleErr)
-					bot.SendPM(ctx, pm.Nick, "Sorry, I couldn't process your audio note. Please try again.")
-				}
-			} else if !welcomeSent[userIDStr] {
-				// Send welcome message for non-command messages if not sent before
-				welcomeMsg := fmt.Sprintf("👋 Hi %s! I'm BraiBot, your AI assistant powered by Decred.\n\n"+
-					"To get started, use **!help** to see available commands.\n"+
-					"You can also send me a tip to use AI features or\ncheck your balance with **!balance**.",
-					pm.Nick)
-
-				if err := bot.SendPM(ctx, pm.Nick, welcomeMsg); err != nil {
-					log.Warnf("Error sending welcome message: %v", err)
-				} else {
-					// Mark welcome as sent for this user
-					welcomeSent[userIDStr] = true
-				}
-			}
-		}
-	}()
-
-	// Add a goroutine to handle group chat messages
-	go func() {
-		for gc := range gcChan {
-			if ctx.Err() != nil {
-				continue
-			}
-			log.Infof("Received GC message from %s in %s: %s", gc.Nick, gc.GcAlias, gc.Msg.Message)
-
-			// Check if the message is a command
-			if cmd, args, isCmd := commands.IsCommand(gc.Msg.Message); isCmd {
-				if command, exists := commandRegistry.Get(cmd); exists {
-					var senderID zkidentity.ShortID
-					senderID.FromBytes(gc.Uid)
-					msgCtx := braibottypes.MessageContext{
-						Nick:    gc.Nick,
-						Uid:     gc.Uid,
-						Message: gc.Msg.Message,
-						IsPM:    false,
-						Sender:  senderID,
-						GC:      gc.GcAlias,
-					}
-					msgSender := braibottypes.NewMessageSender(braibottypes.NewBisonBotAdapter(bot))
-					handleErr := command.Handler.Handle(ctx, msgCtx, args, msgSender, dbManager)
-					if handleErr != nil {
-						// Check if the error is specifically ErrInsufficientBalance
-						var insufErr *utils.ErrInsufficientBalance
-						if errors.Is(handleErr, insufErr) {
-							// Send the specific error message to the group chat
-							if gcErr := bot.SendGC(ctx, gc.GcAlias, handleErr.Error()); gcErr != nil {
-								log.Warnf("Failed to send insufficient balance message to GC %s: %v", gc.GcAlias, gcErr)
-							}
-						} else {
-							// Send user-friendly error message to GC
-							bot.SendGC(ctx, gc.GcAlias, fmt.Sprintf("%s, your request could not be processed by the AI datacenter. Please try again later.", gc.Nick))
-							log.Warnf("Error executing command %s for user %s in GC %s: %v", cmd, gc.Nick, gc.GcAlias, handleErr)
-						}
-					}
-				} else {
-					// Send error message for unknown command to the group chat
-					bot.SendGC(ctx, gc.GcAlias, fmt.Sprintf("👋 Hi %s!\n\nI don't recognize that command. Use **!help** to see available commands.", gc.Nick))
-				}
-			}
-		}
-	}()
-
 	// Add input handling goroutine
 	go func() {
 		scanner := bufio.NewScanner(os.Stdin)
@@ -451,68 +794,12 @@ func realMain() error {
 		}
 	}()
 
-	// Tip progress events resolve outbound directory payments; everything
-	// else is drained so bisonbotkit handlers don't block.
-	go func() {
-		for ev := range tipProgressChan {
-			if dirMatcher == nil || ev == nil {
-				continue
-			}
-			if ev.Completed || !ev.WillRetry {
-				var res error
-				if !ev.Completed {
-					res = errors.New(ev.AttemptErr)
-				}
-				dirMatcher.Resolve(utils.GetUserIDString(ev.Uid), ev.AmountMatoms, res)
-			}
-		}
-	}()
-
-	// Handle received tips. A tip redelivered after a crash between the
-	// balance update and its acknowledgement must not credit twice, so
-	// credited sequence ids are journaled (credit, record, ack).
-	tipJournal, err := server.OpenTipJournal(filepath.Join(appRoot, "data", "tips.json"))
-	if err != nil {
-		return fmt.Errorf("failed to open tip journal: %v", err)
-	}
-	go func() {
-		for tip := range tipChan {
-			if ctx.Err() != nil {
-				continue
-			}
-			if tipJournal.Seen(tip.SequenceId) {
-				// Already credited; only the acknowledgement was lost.
-				bot.AckTipReceived(ctx, tip.SequenceId)
-				continue
-			}
-			// Convert UID to string ID for database
-			userIDStr := utils.GetUserIDString(tip.Uid)
-
-			// Update user's balance in the database
-			err = dbManager.UpdateBalance(userIDStr, tip.AmountMatoms)
-			if err != nil {
-				log.Errorf("Failed to update balance: %v", err)
-				continue
-			}
-			if err := tipJournal.Record(tip.SequenceId); err != nil {
-				log.Errorf("Failed to record tip %d: %v", tip.SequenceId, err)
-			}
-
-			// Convert to DCR for display
-			dcrAmount := float64(tip.AmountMatoms) / 1e11
-
-			log.Infof("Tip received: %.8f DCR from %s",
-				dcrAmount,
-				userIDStr)
-
-			// Send thank you message
-			bot.SendPM(ctx, userIDStr,
-				fmt.Sprintf("Thank you for the tip of %.8f DCR!", dcrAmount))
-
-			// Acknowledge the tip
-			bot.AckTipReceived(ctx, tip.SequenceId)
-		}
-	}()
+	router.Start(ctx, dispatcher.Channels{
+		PMs:         pmChan,
+		GCs:         gcChan,
+		Tips:        tipChan,
+		TipProgress: tipProgressChan,
+	})
 
 	// Run the bot
 	err = bot.Run(ctx)
@@ -549,8 +836,8 @@ func (w satLogWriter) Write(p []byte) (int, error) {
 	return len(p), nil
 }
 
-// tipPayer settles directory payments as Bison Relay tips, resolved by the
-// matching terminal tip-progress events.
+// tipPayer settles directory payments and withdrawals as Bison Relay tips,
+// resolved by the matching terminal tip-progress events.
 type tipPayer struct {
 	bot     *kit.Bot
 	matcher *bridge.TipMatcher
@@ -579,6 +866,51 @@ func (p *tipPayer) Pay(ctx context.Context, payeeUID string, atoms int64) error
 	}
 }
 
+// formatExpiryNotice renders the PM sent for a balance expiry warning or
+// expiry.
+func formatExpiryNotice(n database.ExpiryNotice) string {
+	dcr := money.AtomsToDCR(n.Balance)
+	if n.Warning == 0 {
+		return fmt.Sprintf("Your balance of %.8f DCR has expired after a long period of inactivity.", dcr)
+	}
+	return fmt.Sprintf("Reminder %d/2: your balance of %.8f DCR will expire on %s because you have been inactive. "+
+		"Send any message or command to keep it.", n.Warning, dcr, n.ExpiresAt.UTC().Format("2006-01-02"))
+}
+
+// formatWeeklySummary is the weekly PM of a user's spending and balance.
+// Amounts are shown in USD too when dcrUSD, the DCR price, is known.
+func formatWeeklySummary(s database.WeeklySummary, dcrUSD float64) string {
+	spent, balance := money.AtomsToDCR(s.Spent), money.AtomsToDCR(s.Balance)
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 Your summary since %s:\n", s.Since.UTC().Format("2006-01-02"))
+	if dcrUSD > 0 {
+		fmt.Fprintf(&b, "• Spent: %.8f DCR ($%.2f USD) on %d requests\n", spent, spent*dcrUSD, s.Jobs)
+		fmt.Fprintf(&b, "• Balance: %.8f DCR ($%.2f USD)\n", balance, balance*dcrUSD)
+	} else {
+		fmt.Fprintf(&b, "• Spent: %.8f DCR on %d requests\n", spent, s.Jobs)
+		fmt.Fprintf(&b, "• Balance: %.8f DCR\n", balance)
+	}
+	b.WriteString("Top up with !topup [usd_amount]. Turn these summaries off with !set weekly_summary off.")
+	return b.String()
+}
+
+// queuedMessageContext rebuilds the context of the message a queued job was
+// requested with.
+func queuedMessageContext(job database.QueuedJob) (braibottypes.MessageContext, error) {
+	var senderID zkidentity.ShortID
+	if err := senderID.FromString(job.UID); err != nil {
+		return braibottypes.MessageContext{}, fmt.Errorf("invalid uid of job %d: %v", job.ID, err)
+	}
+	return braibottypes.MessageContext{
+		Nick:    job.Nick,
+		Uid:     senderID.Bytes(),
+		Message: job.Message,
+		IsPM:    job.IsPM,
+		Sender:  senderID,
+		GC:      job.GC,
+	}, nil
+}
+
 // splitCSV parses a comma-separated config value into trimmed entries.
 func splitCSV(s string) []string {
 	var out []string
@@ -590,6 +922,22 @@ func splitCSV(s string) []string {
 	return out
 }
 
+// extraDuration reads a duration config key, falling back when absent or invalid.
+func extraDuration(extra map[string]string, key string, def time.Duration) time.Duration {
+	if v, err := time.ParseDuration(extra[key]); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
+
+// extraFloat reads a float config key, falling back when absent or invalid.
+func extraFloat(extra map[string]string, key string, def float64) float64 {
+	if v, err := strconv.ParseFloat(extra[key], 64); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
+
 // extraInt reads an integer config key, falling back when absent or invalid.
 func extraInt(extra map[string]string, key string, def int64) int64 {
 	if v, err := strconv.ParseInt(extra[key], 10, 64); err == nil && v > 0 {
@@ -0,0 +1,102 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Kinds of errors fal reports, for use with errors.Is on an *APIError.
+var (
+	// ErrContentPolicy means the input or output was flagged by fal's
+	// content checker.
+	ErrContentPolicy = errors.New("content policy violation")
+	// ErrInvalidParameter means fal rejected a parameter of the request.
+	ErrInvalidParameter = errors.New("invalid parameter")
+	// ErrQuotaExceeded means the account is rate limited or out of balance.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrColdStartTimeout means the model did not start or answer in time.
+	ErrColdStartTimeout = errors.New("model start timed out")
+)
+
+// APIError is an error response from fal.
+type APIError struct {
+	StatusCode int    // HTTP status of the response
+	Kind       error  // One of the Err* kinds above; nil for other errors
+	Type       string // fal's error type, e.g. "content_policy_violation"
+	Field      string // Parameter the error is about, if fal named one
+	Message    string // fal's description of the error, or the raw body
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+}
+
+// Unwrap returns the kind of the error.
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// apiErrorDetail is one entry of the detail list of fal's error bodies.
+type apiErrorDetail struct {
+	Loc  []interface{} `json:"loc"`
+	Msg  string        `json:"msg"`
+	Type string        `json:"type"`
+}
+
+// newAPIError parses the body of a failed fal response into an *APIError.
+// Bodies fal did not write, e.g. from a proxy, keep their raw text.
+func newAPIError(statusCode int, body []byte) *APIError {
+	e := &APIError{StatusCode: statusCode, Message: strings.TrimSpace(string(body))}
+
+	var resp struct {
+		Detail json.RawMessage `json:"detail"`
+		Error  string          `json:"error"`
+	}
+	if json.Unmarshal(body, &resp) == nil {
+		var details []apiErrorDetail
+		var detail string
+		switch {
+		case json.Unmarshal(resp.Detail, &details) == nil && len(details) > 0:
+			d := details[0]
+			e.Type, e.Message = d.Type, d.Msg
+			// loc is the path of the parameter, e.g. ["body", "image_size"]
+			if n := len(d.Loc); n > 0 {
+				if field, ok := d.Loc[n-1].(string); ok && field != "body" {
+					e.Field = field
+				}
+			}
+		case json.Unmarshal(resp.Detail, &detail) == nil && detail != "":
+			e.Message = detail
+		case resp.Error != "":
+			e.Message = resp.Error
+		}
+	}
+	e.Kind = errorKind(e)
+	return e
+}
+
+// errorKind classifies an error response.
+func errorKind(e *APIError) error {
+	msg := strings.ToLower(e.Message)
+	switch {
+	case e.Type == "content_policy_violation":
+		return ErrContentPolicy
+	case strings.Contains(e.Type, "timeout"), e.StatusCode == http.StatusGatewayTimeout:
+		return ErrColdStartTimeout
+	case e.StatusCode == http.StatusTooManyRequests, e.StatusCode == http.StatusPaymentRequired:
+		return ErrQuotaExceeded
+	case e.StatusCode == http.StatusForbidden && (strings.Contains(msg, "balance") || strings.Contains(msg, "locked")):
+		// fal locks accounts that ran out of balance
+		return ErrQuotaExceeded
+	case e.StatusCode == http.StatusUnprocessableEntity, e.StatusCode == http.StatusBadRequest:
+		return ErrInvalidParameter
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package fal
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantKind  error
+		wantField string
+		wantMsg   string
+	}{
+		{
+			name:      "content policy",
+			status:    422,
+			body:      `{"detail":[{"loc":["body","prompt"],"msg":"flagged by a content checker","type":"content_policy_violation"}]}`,
+			wantKind:  ErrContentPolicy,
+			wantField: "prompt",
+			wantMsg:   "flagged by a content checker",
+		},
+		{
+			name:      "invalid parameter",
+			status:    422,
+			body:      `{"detail":[{"loc":["body","image_size"],"msg":"unexpected value","type":"value_error"}]}`,
+			wantKind:  ErrInvalidParameter,
+			wantField: "image_size",
+			wantMsg:   "unexpected value",
+		},
+		{
+			name:     "exhausted balance",
+			status:   403,
+			body:     `{"detail":"User is locked. Reason: Exhausted balance."}`,
+			wantKind: ErrQuotaExceeded,
+			wantMsg:  "User is locked. Reason: Exhausted balance.",
+		},
+		{
+			name:     "rate limited",
+			status:   429,
+			body:     `{"detail":"Too many requests"}`,
+			wantKind: ErrQuotaExceeded,
+			wantMsg:  "Too many requests",
+		},
+		{
+			name:     "cold start",
+			status:   504,
+			body:     `<html>gateway timeout</html>`,
+			wantKind: ErrColdStartTimeout,
+			wantMsg:  "<html>gateway timeout</html>",
+		},
+		{
+			name:    "bad key",
+			status:  401,
+			body:    `{"detail":"Invalid key"}`,
+			wantMsg: "Invalid key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newAPIError(tt.status, []byte(tt.body))
+			if e.Kind != tt.wantKind || e.Field != tt.wantField || e.Message != tt.wantMsg {
+				t.Errorf("got kind %v, field %q, message %q; want %v, %q, %q", e.Kind, e.Field, e.Message, tt.wantKind, tt.wantField, tt.wantMsg)
+			}
+			wrapped := fmt.Errorf("initial request failed: %w", e)
+			var apiErr *APIError
+			if !errors.As(wrapped, &apiErr) || (tt.wantKind != nil && !errors.Is(wrapped, tt.wantKind)) {
+				t.Errorf("wrapped error %v does not match its kind", wrapped)
+			}
+		})
+	}
+}
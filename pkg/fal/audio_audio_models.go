@@ -24,6 +24,21 @@ func (m *elevenlabsVoiceChangerModel) Define() Model {
 	}
 }
 
+// --- elevenlabs-audio-isolation ---
+
+type elevenlabsAudioIsolationModel struct{}
+
+func (m *elevenlabsAudioIsolationModel) Define() Model {
+	return Model{
+		Name:        "elevenlabs-audio-isolation",
+		Description: "ElevenLabs Audio Isolation - Isolate voices and remove background noise",
+		Type:        "audio2audio",
+		Endpoint:    "/elevenlabs/audio-isolation",
+		Options:     &AudioIsolationOptions{},
+	}
+}
+
 func init() {
 	registerModel(&elevenlabsVoiceChangerModel{})
+	registerModel(&elevenlabsAudioIsolationModel{})
 }
@@ -0,0 +1,98 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// streamBaseURL is where streamed requests are sent. They are answered
+// directly instead of through the queue.
+var streamBaseURL = "https://fal.run/fal-ai"
+
+// maxStreamEvent caps the size of a single streamed event.
+const maxStreamEvent = 1 << 20
+
+// Chat sends a prompt to a language model of any-llm and returns its
+// reply. The reply is streamed: onPartial, if not nil, is called with the
+// reply written so far each time the model adds to it.
+func (c *Client) Chat(ctx context.Context, req *ChatRequest, onPartial func(output string)) (*ChatResponse, error) {
+	const modelName = "any-llm"
+
+	modelDef, modelExists := GetModel(modelName, "text2text")
+	if !modelExists {
+		return nil, fmt.Errorf("model not found: %s", modelName)
+	}
+	if req.Prompt == "" {
+		return nil, fmt.Errorf("prompt is required")
+	}
+	opts := AnyLLMOptions{Model: req.Model}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", modelName, err)
+	}
+
+	reqBody := map[string]interface{}{
+		"prompt": req.Prompt,
+	}
+	if req.SystemPrompt != "" {
+		reqBody["system_prompt"] = req.SystemPrompt
+	}
+	if req.Model != "" {
+		reqBody["model"] = req.Model
+	}
+
+	url := modelDef.Endpoint + "/stream"
+	if strings.HasPrefix(modelDef.Endpoint, "/") {
+		url = streamBaseURL + url
+	}
+	if req.Progress != nil {
+		req.Progress.OnProgress("IN_PROGRESS")
+	}
+	resp, err := c.makeRequest(ctx, "POST", url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make chat request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return nil, fmt.Errorf("chat request failed: %w", newAPIError(resp.StatusCode, bodyBytes))
+	}
+
+	// The reply arrives as server-sent events, each holding the whole reply
+	// written so far
+	var last *ChatResponse
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxStreamEvent)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var event ChatResponse
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			return nil, fmt.Errorf("failed to parse chat response: %w. Body: %s", err, data)
+		}
+		if event.Error != "" {
+			return nil, fmt.Errorf("chat failed: %s", event.Error)
+		}
+		last = &event
+		if onPartial != nil {
+			onPartial(event.Output)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read chat response: %w", err)
+	}
+	if last == nil {
+		return nil, fmt.Errorf("chat response was empty")
+	}
+	last.Partial = false
+	return last, nil
+}
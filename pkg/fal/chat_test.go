@@ -0,0 +1,50 @@
+package fal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChat(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/any-llm/stream" {
+			t.Errorf("requested %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, out := range []string{"Hello", "Hello there", "Hello there!"} {
+			fmt.Fprintf(w, "data: {\"output\": %q, \"partial\": true}\n\n", out)
+		}
+		fmt.Fprint(w, "data: {\"output\": \"Hello there!\", \"partial\": false, \"usage\": {\"prompt_tokens\": 12, \"completion_tokens\": 3}}\n\n")
+	}))
+	defer srv.Close()
+	defer func(u string) { streamBaseURL = u }(streamBaseURL)
+	streamBaseURL = srv.URL
+
+	c := NewClient("key", WithHTTPClient(srv.Client()))
+	var partials []string
+	resp, err := c.Chat(context.Background(), &ChatRequest{Prompt: "Hi", SystemPrompt: "Be brief"}, func(output string) {
+		partials = append(partials, output)
+	})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Output != "Hello there!" || resp.Usage == nil || resp.Usage.PromptTokens != 12 {
+		t.Errorf("Chat = %+v", resp)
+	}
+	if len(partials) != 4 || partials[0] != "Hello" {
+		t.Errorf("partials = %q", partials)
+	}
+	if body["prompt"] != "Hi" || body["system_prompt"] != "Be brief" {
+		t.Errorf("sent %v", body)
+	}
+
+	if _, err := c.Chat(context.Background(), &ChatRequest{Prompt: "Hi", Model: "no/such-model"}, nil); err == nil {
+		t.Error("an unknown language model was accepted")
+	}
+}
@@ -21,9 +21,15 @@ const (
 
 // Client represents a Fal.ai API client
 type Client struct {
-	apiKey     string
-	httpClient *http.Client
-	debug      bool
+	apiKey         string
+	httpClient     *http.Client
+	debug          bool
+	debugOn        func() bool                              // Overrides debug when set
+	debugLog       func(format string, args ...interface{}) // Debug output; stdout when nil
+	webhook        *WebhookReceiver                         // Completion webhooks; nil polls
+	webhookPoll    time.Duration                            // Status check interval while waiting for a webhook
+	retry          RetryPolicy                              // Retries after transient failures
+	requestTimeout time.Duration                            // Timeout of a single request; 0 keeps the HTTP client's
 }
 
 // ClientOption is a function that configures a Client
@@ -36,6 +42,32 @@ func WithDebug(debug bool) ClientOption {
 	}
 }
 
+// WithDebugLogger routes debug output to logf and decides per call whether
+// to log with enabled, so debugging can be toggled while the client is in use.
+func WithDebugLogger(enabled func() bool, logf func(format string, args ...interface{})) ClientOption {
+	return func(c *Client) {
+		c.debugOn = enabled
+		c.debugLog = logf
+	}
+}
+
+// debugEnabled reports whether debug output is on.
+func (c *Client) debugEnabled() bool {
+	if c.debugOn != nil {
+		return c.debugOn()
+	}
+	return c.debug
+}
+
+// debugf writes debug output.
+func (c *Client) debugf(format string, args ...interface{}) {
+	if c.debugLog != nil {
+		c.debugLog(strings.TrimSuffix(format, "\n"), args...)
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
 // WithHTTPClient sets a custom HTTP client
 func WithHTTPClient(httpClient *http.Client) ClientOption {
 	return func(c *Client) {
@@ -48,13 +80,20 @@ func NewClient(apiKey string, opts ...ClientOption) *Client {
 	client := &Client{
 		apiKey: apiKey,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: DefaultRequestTimeout,
 		},
+		retry: DefaultRetryPolicy,
 	}
 
 	for _, opt := range opts {
 		opt(client)
 	}
+	if client.requestTimeout > 0 {
+		// Copy the HTTP client so one passed with WithHTTPClient is not changed
+		httpClient := *client.httpClient
+		httpClient.Timeout = client.requestTimeout
+		client.httpClient = &httpClient
+	}
 
 	return client
 }
@@ -78,35 +117,65 @@ func (c *Client) makeRequest(ctx context.Context, method, path string, body inte
 		fullURL = baseURL + path
 	}
 
-	if c.debug {
-		fmt.Printf("DEBUG - Making request to Fal.ai API:\n")
-		fmt.Printf("  URL: %s\n", fullURL)
-		fmt.Printf("  Method: %s\n", method)
+	if c.debugEnabled() {
+		c.debugf("DEBUG - Making request to Fal.ai API:\n")
+		c.debugf("  URL: %s\n", fullURL)
+		c.debugf("  Method: %s\n", method)
 		if body != nil {
-			fmt.Printf("  Request Body: %s\n", string(reqBody))
+			c.debugf("  Request Body: %s\n", string(reqBody))
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, fullURL, bytes.NewBuffer(reqBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
+	// Transient failures are retried with backoff. Network errors of POST
+	// requests are not: the request may have been queued already, and
+	// sending it again would run, and charge for, the job twice.
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, fullURL, bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %v", err)
+		}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Key "+c.apiKey)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Key "+c.apiKey)
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %v", err)
-	}
+		var wait time.Duration
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil || method == http.MethodPost || attempt >= c.retry.MaxAttempts {
+				return nil, fmt.Errorf("failed to make request: %v", err)
+			}
+			wait = c.retry.backoff(attempt)
+			if c.debugEnabled() {
+				c.debugf("DEBUG - Request to Fal.ai API failed: %v\n", err)
+			}
+		} else {
+			if c.debugEnabled() {
+				c.debugf("DEBUG - Response from Fal.ai API:\n")
+				c.debugf("  Status Code: %d\n", resp.StatusCode)
+				c.debugf("  Status: %s\n", resp.Status)
+			}
+			if !retryableStatus(resp.StatusCode) || attempt >= c.retry.MaxAttempts {
+				return resp, nil
+			}
+			wait = c.retry.backoff(attempt)
+			if d, ok := retryAfter(resp, time.Now()); ok {
+				if c.retry.MaxDelay > 0 && d > c.retry.MaxDelay {
+					// fal asks for a longer pause than the client retries for
+					return resp, nil
+				}
+				wait = d
+			}
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
 
-	if c.debug {
-		fmt.Printf("DEBUG - Response from Fal.ai API:\n")
-		fmt.Printf("  Status Code: %d\n", resp.StatusCode)
-		fmt.Printf("  Status: %s\n", resp.Status)
+		if c.debugEnabled() {
+			c.debugf("DEBUG - Retrying in %v (attempt %d of %d)\n", wait, attempt+1, c.retry.MaxAttempts)
+		}
+		if err := sleepCtx(ctx, wait); err != nil {
+			return nil, fmt.Errorf("failed to make request: %w", err)
+		}
 	}
-
-	return resp, nil
 }
 
 // FinalResponseDecoder defines the function signature for decoding the final successful response
@@ -125,37 +194,46 @@ func (c *Client) executeAsyncWorkflow(ctx context.Context, path string, reqBody
 // executeAsyncWorkflowWithCallback is like executeAsyncWorkflow but calls queueCallback when queue info is available
 // This enables storing queue info for recovery before polling starts
 func (c *Client) executeAsyncWorkflowWithCallback(ctx context.Context, path string, reqBody interface{}, progress ProgressCallback, decodeFinalResponse FinalResponseDecoder, queueCallback QueueInfoCallback) (interface{}, error) {
-	// 1. Make initial POST request
-	initialResp, err := c.makeRequest(ctx, "POST", path, reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make initial request: %w", err)
-	}
-	defer initialResp.Body.Close()
+	// 1. Make initial POST request, asking for a webhook if the client uses
+	// them, unless the request was queued before a restart
+	var queueResp QueueResponse
+	if resumeURL := takeResumeURL(ctx); resumeURL != "" {
+		if c.debugEnabled() {
+			c.debugf("DEBUG - Resuming queued request %s\n", resumeURL)
+		}
+		queueResp = QueueResponse{ResponseURL: resumeURL}
+	} else {
+		initialResp, err := c.makeRequest(ctx, "POST", c.withWebhookParam(path), reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to make initial request: %w", err)
+		}
+		defer initialResp.Body.Close()
 
-	if initialResp.StatusCode < 200 || initialResp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(initialResp.Body)
-		return nil, fmt.Errorf("initial request failed with status %d: %s", initialResp.StatusCode, string(bodyBytes))
-	}
+		if initialResp.StatusCode < 200 || initialResp.StatusCode >= 300 {
+			bodyBytes, _ := io.ReadAll(initialResp.Body)
+			return nil, fmt.Errorf("initial request failed: %w", newAPIError(initialResp.StatusCode, bodyBytes))
+		}
 
-	// 2. Parse initial QueueResponse
-	var queueResp QueueResponse
-	if err := json.NewDecoder(initialResp.Body).Decode(&queueResp); err != nil {
-		// Attempt to read body for better error message if decode fails
-		initialResp.Body.Close() // Close previous reader
-		bodyBytes, readErr := io.ReadAll(initialResp.Body)
-		if readErr == nil {
-			return nil, fmt.Errorf("failed to decode initial queue response: %w. Body: %s", err, string(bodyBytes))
+		// 2. Parse initial QueueResponse
+		if err := json.NewDecoder(initialResp.Body).Decode(&queueResp); err != nil {
+			// Attempt to read body for better error message if decode fails
+			initialResp.Body.Close() // Close previous reader
+			bodyBytes, readErr := io.ReadAll(initialResp.Body)
+			if readErr == nil {
+				return nil, fmt.Errorf("failed to decode initial queue response: %w. Body: %s", err, string(bodyBytes))
+			}
+			return nil, fmt.Errorf("failed to decode initial queue response: %w", err)
 		}
-		return nil, fmt.Errorf("failed to decode initial queue response: %w", err)
-	}
 
-	if queueResp.ResponseURL == "" {
-		return nil, fmt.Errorf("initial queue response did not contain a response URL")
-	}
+		if queueResp.ResponseURL == "" {
+			return nil, fmt.Errorf("initial queue response did not contain a response URL")
+		}
 
-	// 2.5 Call queue callback if provided (for recovery purposes)
-	if queueCallback != nil {
-		queueCallback(queueResp.QueueID, queueResp.ResponseURL)
+		// 2.5 Call queue callbacks if provided (for recovery purposes)
+		if queueCallback != nil {
+			queueCallback(queueResp.QueueID, queueResp.ResponseURL)
+		}
+		notifyQueued(ctx, queueResp.ResponseURL)
 	}
 
 	// 3. Notify initial queue position
@@ -180,11 +258,11 @@ func (c *Client) executeAsyncWorkflowWithCallback(ctx context.Context, path stri
 	}
 
 	if finalRespRaw.StatusCode < 200 || finalRespRaw.StatusCode >= 300 {
-		return nil, fmt.Errorf("final result request failed with status %d: %s", finalRespRaw.StatusCode, string(finalBytes))
+		return nil, fmt.Errorf("final result request failed: %w", newAPIError(finalRespRaw.StatusCode, finalBytes))
 	}
 
-	if c.debug {
-		fmt.Printf("DEBUG - Final response body: %s\n", string(finalBytes))
+	if c.debugEnabled() {
+		c.debugf("DEBUG - Final response body: %s\n", string(finalBytes))
 	}
 
 	// 6. Decode final response using the provided decoder function
@@ -211,17 +289,11 @@ func (c *Client) CheckJobStatus(ctx context.Context, responseURL string) (*JobSt
 	}
 
 	statusURL := responseURL + "/status"
-	if c.debug {
-		fmt.Printf("DEBUG - Checking job status at: %s\n", statusURL)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", statusURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create status request: %w", err)
+	if c.debugEnabled() {
+		c.debugf("DEBUG - Checking job status at: %s\n", statusURL)
 	}
-	req.Header.Set("Authorization", "Key "+c.apiKey)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.makeRequest(ctx, "GET", statusURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check status: %w", err)
 	}
@@ -232,8 +304,8 @@ func (c *Client) CheckJobStatus(ctx context.Context, responseURL string) (*JobSt
 		return nil, fmt.Errorf("failed to read status response: %w", err)
 	}
 
-	if c.debug {
-		fmt.Printf("DEBUG - Status response: %s\n", string(body))
+	if c.debugEnabled() {
+		c.debugf("DEBUG - Status response: %s\n", string(body))
 	}
 
 	// Handle 404 - job not found (expired or invalid)
@@ -242,7 +314,7 @@ func (c *Client) CheckJobStatus(ctx context.Context, responseURL string) (*JobSt
 	}
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
-		return nil, fmt.Errorf("status check failed with code %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("status check failed: %w", newAPIError(resp.StatusCode, body))
 	}
 
 	var statusResp struct {
@@ -268,8 +340,8 @@ func (c *Client) GetJobResult(ctx context.Context, responseURL string) (*VideoRe
 		return nil, fmt.Errorf("response URL is required")
 	}
 
-	if c.debug {
-		fmt.Printf("DEBUG - Getting job result from: %s\n", responseURL)
+	if c.debugEnabled() {
+		c.debugf("DEBUG - Getting job result from: %s\n", responseURL)
 	}
 
 	resp, err := c.makeRequest(ctx, "GET", responseURL, nil)
@@ -284,7 +356,7 @@ func (c *Client) GetJobResult(ctx context.Context, responseURL string) (*VideoRe
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("get result failed with code %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("get result failed: %w", newAPIError(resp.StatusCode, body))
 	}
 
 	var videoResp VideoResponse
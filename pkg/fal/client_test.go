@@ -0,0 +1,420 @@
+package fal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeResponse is a canned response of the fake fal server.
+type fakeResponse struct {
+	code int
+	body string
+}
+
+// fakeFal is an httptest server speaking fal's queue API: requests POSTed
+// to any path are queued, their status walks through statuses with their
+// logs, and result is served once they complete. Failures are injected by
+// queueing responses that are sent instead of the normal ones.
+type fakeFal struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	statuses   []string       // Statuses reported before COMPLETED
+	logs       [][]string     // Logs sent with each of statuses
+	result     string         // Body of the result
+	submitFail []fakeResponse // Sent instead of queueing the next POSTs
+	statusFail []fakeResponse // Sent instead of the next statuses
+	resultFail []fakeResponse // Sent instead of the result
+	posts      []string       // Paths requests were POSTed to
+	bodies     []map[string]interface{}
+	polls      map[string]int // Status polls by request id
+}
+
+func newFakeFal(t *testing.T) *fakeFal {
+	f := &fakeFal{polls: make(map[string]int)}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// pop removes the first response of *rs, if any.
+func pop(rs *[]fakeResponse) (fakeResponse, bool) {
+	if len(*rs) == 0 {
+		return fakeResponse{}, false
+	}
+	r := (*rs)[0]
+	*rs = (*rs)[1:]
+	return r, true
+}
+
+func (f *fakeFal) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	reply := func(code int, body string) {
+		w.WriteHeader(code)
+		w.Write([]byte(body))
+	}
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/requests/"), "/status")
+
+	switch {
+	case r.Method == http.MethodPost:
+		f.posts = append(f.posts, r.URL.Path)
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.bodies = append(f.bodies, body)
+		if resp, ok := pop(&f.submitFail); ok {
+			reply(resp.code, resp.body)
+			return
+		}
+		id := fmt.Sprintf("req-%d", len(f.posts))
+		reply(http.StatusOK, fmt.Sprintf(`{"request_id": %q, "response_url": "%s/requests/%s", "status": "IN_QUEUE", "position": %d, "eta": 10}`,
+			id, f.URL, id, len(f.statuses)))
+	case r.Method == http.MethodPut:
+		reply(http.StatusAccepted, `{"status": "CANCELLATION_REQUESTED"}`)
+	case strings.HasSuffix(r.URL.Path, "/status"):
+		if resp, ok := pop(&f.statusFail); ok {
+			reply(resp.code, resp.body)
+			return
+		}
+		poll := f.polls[id]
+		f.polls[id]++
+		if poll >= len(f.statuses) {
+			reply(http.StatusOK, `{"status": "COMPLETED"}`)
+			return
+		}
+		status := map[string]interface{}{"status": f.statuses[poll], "position": len(f.statuses) - poll - 1}
+		if poll < len(f.logs) {
+			var logs []map[string]string
+			for _, msg := range f.logs[poll] {
+				logs = append(logs, map[string]string{"message": msg, "level": "INFO"})
+			}
+			status["logs"] = logs
+		}
+		body, _ := json.Marshal(status)
+		reply(http.StatusAccepted, string(body))
+	case strings.HasPrefix(r.URL.Path, "/requests/"):
+		if resp, ok := pop(&f.resultFail); ok {
+			reply(resp.code, resp.body)
+			return
+		}
+		reply(http.StatusOK, f.result)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// client returns a client for the fake server that polls and retries
+// quickly.
+func (f *fakeFal) client(t *testing.T) *Client {
+	// A webhook receiver nothing posts to makes the client poll quickly
+	recv, err := NewWebhookReceiver("https://bot.example.com/fal/webhook")
+	if err != nil {
+		t.Fatalf("NewWebhookReceiver: %v", err)
+	}
+	return NewClient("key", WithHTTPClient(f.Client()), WithWebhook(recv, 5*time.Millisecond),
+		WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}))
+}
+
+// recordingProgress records the progress callbacks of a request.
+type recordingProgress struct {
+	mu       sync.Mutex
+	queue    []int
+	statuses []string
+	logs     []string
+}
+
+func (p *recordingProgress) OnQueueUpdate(position int, eta time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queue = append(p.queue, position)
+}
+
+func (p *recordingProgress) OnLogMessage(message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.logs = append(p.logs, message)
+}
+
+func (p *recordingProgress) OnProgress(status string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statuses = append(p.statuses, status)
+}
+
+func (p *recordingProgress) OnError(err error) {}
+
+// testCtx returns a context that ends the test if the fake server hangs.
+func testCtx(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestGenerateImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   string
+		wantURLs []string
+		wantSeed uint64
+		wantErr  bool
+	}{
+		{name: "images", result: `{"images": [{"url": "https://x/1.png", "width": 8}, {"url": "https://x/2.png"}], "seed": 7}`,
+			wantURLs: []string{"https://x/1.png", "https://x/2.png"}, wantSeed: 7},
+		{name: "single image", result: `{"image": {"url": "https://x/1.png", "content_type": "image/png"}, "seed": 18446744073709551615}`,
+			wantURLs: []string{"https://x/1.png"}, wantSeed: 18446744073709551615},
+		{name: "no image", result: `{"images": [], "seed": 1}`, wantErr: true},
+		{name: "not JSON", result: `<html>`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFal(t)
+			f.result = tt.result
+			resp, err := f.client(t).GenerateImage(testCtx(t), &GenericRequest{Endpoint: f.URL + "/fal-ai/test", Type: "text2image", Body: map[string]interface{}{"prompt": "x"}})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("GenerateImage = %+v, want an error", resp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateImage: %v", err)
+			}
+			var urls []string
+			for _, img := range resp.Images {
+				urls = append(urls, img.URL)
+			}
+			if !reflect.DeepEqual(urls, tt.wantURLs) || resp.Seed != tt.wantSeed {
+				t.Errorf("GenerateImage = %v seed %d, want %v seed %d", urls, resp.Seed, tt.wantURLs, tt.wantSeed)
+			}
+		})
+	}
+
+	// Built-in models are sent to their endpoint with the body of their
+	// request type
+	f := newFakeFal(t)
+	f.result = `{"images": [{"url": "https://x/1.png"}]}`
+	if err := SetEndpointOverride("flux/schnell", f.URL+"/fal-ai/flux/schnell"); err != nil {
+		t.Fatalf("SetEndpointOverride: %v", err)
+	}
+	defer SetEndpointOverride("flux/schnell", "")
+	req := &FluxSchnellRequest{BaseImageRequest: BaseImageRequest{Prompt: "a cat"}, NumImages: 2}
+	if _, err := f.client(t).GenerateImage(testCtx(t), req); err != nil {
+		t.Fatalf("GenerateImage(flux/schnell): %v", err)
+	}
+	if f.posts[0] != "/fal-ai/flux/schnell" || f.bodies[0]["prompt"] != "a cat" || f.bodies[0]["num_images"] != 2.0 {
+		t.Errorf("flux/schnell sent %v to %s", f.bodies[0], f.posts[0])
+	}
+}
+
+func TestGenerateVideo(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  string
+		wantURL string
+	}{
+		{name: "video object", result: `{"video": {"url": "https://x/1.mp4", "content_type": "video/mp4"}}`, wantURL: "https://x/1.mp4"},
+		{name: "url", result: `{"url": "https://x/2.mp4"}`, wantURL: "https://x/2.mp4"},
+		{name: "video_url", result: `{"video_url": "https://x/3.mp4"}`, wantURL: "https://x/3.mp4"},
+		{name: "no video", result: `{"seed": 1}`},
+		{name: "not JSON", result: `oops`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFal(t)
+			f.result = tt.result
+			var queued string
+			req := &KlingVideoRequest{BaseVideoRequest: BaseVideoRequest{Prompt: "waves", QueueInfo: func(_, responseURL string) { queued = responseURL }}, Duration: "5"}
+			if err := SetEndpointOverride("kling-video-text", f.URL+"/fal-ai/kling-video"); err != nil {
+				t.Fatalf("SetEndpointOverride: %v", err)
+			}
+			defer SetEndpointOverride("kling-video-text", "")
+			resp, err := f.client(t).GenerateVideo(testCtx(t), req)
+			if tt.wantURL == "" {
+				if err == nil {
+					t.Fatalf("GenerateVideo = %+v, want an error", resp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateVideo: %v", err)
+			}
+			if resp.GetURL() != tt.wantURL {
+				t.Errorf("GenerateVideo URL = %s, want %s", resp.GetURL(), tt.wantURL)
+			}
+			if queued != f.URL+"/requests/req-1" {
+				t.Errorf("QueueInfo got response URL %q", queued)
+			}
+			if f.posts[0] != "/fal-ai/kling-video" || f.bodies[0]["prompt"] != "waves" {
+				t.Errorf("kling-video-text sent %v to %s", f.bodies[0], f.posts[0])
+			}
+		})
+	}
+}
+
+func TestGenerateSpeech(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   string
+		want     AudioResponse
+		wantCode string // Code of the *Error, if one is wanted
+		wantErr  bool
+	}{
+		{name: "audio", result: `{"audio": {"url": "https://x/1.wav", "content_type": "audio/wav", "file_name": "1.wav", "file_size": 10}, "duration": 1.5}`,
+			want: AudioResponse{AudioURL: "https://x/1.wav", ContentType: "audio/wav", FileName: "1.wav", FileSize: 10, Duration: 1.5}},
+		{name: "default content type", result: `{"audio": {"url": "https://x/1.mp3"}}`,
+			want: AudioResponse{AudioURL: "https://x/1.mp3", ContentType: "audio/mpeg"}},
+		{name: "no audio", result: `{"duration": 1}`, wantCode: "NO_AUDIO_URL", wantErr: true},
+		{name: "not JSON", result: `[`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFal(t)
+			f.result = tt.result
+			if err := SetEndpointOverride("minimax-tts/text-to-speech", f.URL+"/fal-ai/minimax-tts"); err != nil {
+				t.Fatalf("SetEndpointOverride: %v", err)
+			}
+			defer SetEndpointOverride("minimax-tts/text-to-speech", "")
+			req := &MinimaxTTSRequest{BaseSpeechRequest: BaseSpeechRequest{Text: "hello"}, VoiceID: "Wise_Woman"}
+			resp, err := f.client(t).GenerateSpeech(testCtx(t), req)
+			if tt.wantErr {
+				var falErr *Error
+				if err == nil || (tt.wantCode != "" && (!errors.As(err, &falErr) || falErr.Code != tt.wantCode)) {
+					t.Fatalf("GenerateSpeech = %+v, %v; want error %s", resp, err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateSpeech: %v", err)
+			}
+			if *resp != tt.want {
+				t.Errorf("GenerateSpeech = %+v, want %+v", *resp, tt.want)
+			}
+			if f.bodies[0]["text"] != "hello" {
+				t.Errorf("minimax-tts sent %v", f.bodies[0])
+			}
+		})
+	}
+}
+
+func TestAsyncWorkflowFailures(t *testing.T) {
+	const contentPolicy = `{"detail": [{"loc": ["body", "prompt"], "msg": "flagged", "type": "content_policy_violation"}]}`
+	tests := []struct {
+		name       string
+		statuses   []string
+		submitFail []fakeResponse
+		statusFail []fakeResponse
+		resultFail []fakeResponse
+		wantPosts  int
+		wantKind   error  // Kind of the *APIError, if one is wanted
+		wantCode   string // Code of the *Error, if one is wanted
+		wantErr    string // Part of the error message
+	}{
+		{name: "ok", statuses: []string{"IN_QUEUE", "IN_PROGRESS"}, wantPosts: 1},
+		{name: "submit retried", submitFail: []fakeResponse{{503, "busy"}, {429, `{"detail": "slow down"}`}}, wantPosts: 3},
+		{name: "submit rejected", submitFail: []fakeResponse{{422, `{"detail": [{"loc": ["body", "image_size"], "msg": "bad size", "type": "value_error"}]}`}},
+			wantPosts: 1, wantKind: ErrInvalidParameter, wantErr: "bad size"},
+		{name: "submit keeps failing", submitFail: []fakeResponse{{503, ""}, {503, ""}, {503, ""}},
+			wantPosts: 3, wantErr: "initial request failed: status 503"},
+		{name: "queue response without URL", submitFail: []fakeResponse{{200, `{"request_id": "x"}`}},
+			wantPosts: 1, wantErr: "did not contain a response URL"},
+		{name: "status retried", statuses: []string{"IN_PROGRESS"}, statusFail: []fakeResponse{{502, ""}, {504, ""}}, wantPosts: 1},
+		{name: "status not found", statusFail: []fakeResponse{{404, `{"detail": "Request not found"}`}},
+			wantPosts: 1, wantErr: "queue status check failed: status 404: Request not found"},
+		{name: "generation failed", statuses: []string{"IN_QUEUE", "FAILED"}, wantPosts: 1, wantCode: "GENERATION_FAILED"},
+		{name: "result retried", resultFail: []fakeResponse{{500, ""}}, wantPosts: 1},
+		{name: "result flagged", resultFail: []fakeResponse{{422, contentPolicy}},
+			wantPosts: 1, wantKind: ErrContentPolicy, wantErr: "final result request failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFal(t)
+			f.statuses, f.result = tt.statuses, `{"images": [{"url": "https://x/1.png"}]}`
+			f.submitFail, f.statusFail, f.resultFail = tt.submitFail, tt.statusFail, tt.resultFail
+			resp, err := f.client(t).GenerateImage(testCtx(t), &GenericRequest{Endpoint: f.URL + "/fal-ai/test", Type: "text2image", Body: map[string]interface{}{"prompt": "x"}})
+			if len(f.posts) != tt.wantPosts {
+				t.Errorf("%d requests were submitted, want %d", len(f.posts), tt.wantPosts)
+			}
+			if tt.wantKind == nil && tt.wantCode == "" && tt.wantErr == "" {
+				if err != nil || len(resp.Images) != 1 {
+					t.Fatalf("GenerateImage = %+v, %v", resp, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("GenerateImage = %+v, want an error", resp)
+			}
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("error %v is not %v", err, tt.wantKind)
+			}
+			var falErr *Error
+			if tt.wantCode != "" && (!errors.As(err, &falErr) || falErr.Code != tt.wantCode) {
+				t.Errorf("error %v, want code %s", err, tt.wantCode)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %q does not contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAsyncWorkflowProgress(t *testing.T) {
+	f := newFakeFal(t)
+	f.statuses = []string{"IN_QUEUE", "IN_QUEUE", "IN_PROGRESS"}
+	f.logs = [][]string{nil, {"loading model"}, {"step 1", "step 2"}}
+	f.result = `{"images": [{"url": "https://x/1.png"}]}`
+
+	progress := &recordingProgress{}
+	req := &GenericRequest{Endpoint: f.URL + "/fal-ai/test", Type: "image2image", Body: map[string]interface{}{"image_url": "https://x/in.png"}, Progress: progress}
+	if _, err := f.client(t).GenerateImage(testCtx(t), req); err != nil {
+		t.Fatalf("GenerateImage: %v", err)
+	}
+	if want := []string{"IN_QUEUE", "IN_QUEUE", "IN_PROGRESS"}; !reflect.DeepEqual(progress.statuses, want) {
+		t.Errorf("statuses = %v, want %v", progress.statuses, want)
+	}
+	if want := []string{"loading model", "step 1", "step 2"}; !reflect.DeepEqual(progress.logs, want) {
+		t.Errorf("logs = %v, want %v", progress.logs, want)
+	}
+	// The position from the queue response, then each change
+	if want := []int{3, 2, 1, 0}; !reflect.DeepEqual(progress.queue, want) {
+		t.Errorf("queue positions = %v, want %v", progress.queue, want)
+	}
+	if f.bodies[0]["image_url"] != "https://x/in.png" {
+		t.Errorf("sent %v", f.bodies[0])
+	}
+}
+
+func TestAsyncWorkflowCancel(t *testing.T) {
+	f := newFakeFal(t)
+	f.statuses = make([]string, 1000)
+	for i := range f.statuses {
+		f.statuses[i] = "IN_PROGRESS"
+	}
+	cancelled := make(chan struct{})
+	f.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			close(cancelled)
+		}
+		f.serve(w, r)
+	})
+
+	// A request the caller gives up on is cancelled at fal
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := f.client(t).GenerateImage(ctx, &GenericRequest{Endpoint: f.URL + "/fal-ai/test", Type: "text2image", Body: map[string]interface{}{"prompt": "x"}}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GenerateImage = %v, want the deadline", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Error("the request was not cancelled at fal")
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+import (
+	"fmt"
+	"slices"
+)
+
+// genericModelTypes are the model types RegisterModel accepts. Their
+// responses share one format per type, so a request body is all a new
+// model needs.
+var genericModelTypes = []string{"text2image", "image2image", "text2video", "image2video"}
+
+// GenericOptions holds the option defaults of a model registered with
+// RegisterModel, keyed by the name fal.ai expects. They are sent with every
+// request unless the request sets the option itself.
+type GenericOptions map[string]interface{}
+
+// GetDefaultValues returns a copy of the option defaults.
+func (o GenericOptions) GetDefaultValues() map[string]interface{} {
+	defaults := make(map[string]interface{}, len(o))
+	for k, v := range o {
+		defaults[k] = v
+	}
+	return defaults
+}
+
+// Validate accepts any value; fal.ai validates the options of models it
+// does not have code for.
+func (o GenericOptions) Validate() error {
+	return nil
+}
+
+// RegisterModel adds a model defined at runtime, e.g. from an operator's
+// model definition file, so a model that takes a prompt and returns images
+// or a video needs no code. Its Options, if any, must be GenericOptions.
+// Models are meant to be registered at startup, before requests are made.
+func RegisterModel(model Model) error {
+	if err := CheckGenericModel(model); err != nil {
+		return err
+	}
+	model.Generic = true
+	allModels[model.Name] = model
+	return nil
+}
+
+// CheckGenericModel reports why RegisterModel would reject model, without
+// registering it.
+func CheckGenericModel(model Model) error {
+	if model.Name == "" {
+		return fmt.Errorf("model name is required")
+	}
+	if _, exists := allModels[model.Name]; exists {
+		return fmt.Errorf("model already registered: %s", model.Name)
+	}
+	if !slices.Contains(genericModelTypes, model.Type) {
+		return invalidEnum("type", model.Type, genericModelTypes...)
+	}
+	if !validEndpoint(model.Endpoint) {
+		return fmt.Errorf("invalid endpoint for %s: %q (must start with / or be a full URL)", model.Name, model.Endpoint)
+	}
+	if _, ok := model.Options.(GenericOptions); !ok && model.Options != nil {
+		return fmt.Errorf("options of model %s must be GenericOptions, got %T", model.Name, model.Options)
+	}
+	return nil
+}
+
+// GenericRequest is a request to a model without a request type of its
+// own: either a model registered with RegisterModel, named by Model, or any
+// fal endpoint, named by Endpoint and Type, e.g. to try an experimental model.
+// Body is sent as the request body, on top of a registered model's option
+// defaults. Pass it to GenerateImage, GenerateVideo or GenerateSpeech
+// according to the model's type.
+type GenericRequest struct {
+	Model    string                 // Name of a registered model
+	Endpoint string                 // Endpoint path or full URL, when Model is empty
+	Type     string                 // Model type of Endpoint, e.g. "text2image"
+	Body     map[string]interface{} // Request body, e.g. prompt and image_url
+	Progress ProgressCallback
+}
+
+// GetProgress returns the progress callback
+func (r *GenericRequest) GetProgress() ProgressCallback {
+	return r.Progress
+}
+
+func init() {
+	mustRegisterRequestHandler(&GenericRequest{}, buildGeneric)
+}
+
+// buildGeneric builds the request body of a GenericRequest. A registered
+// model's option defaults are sent unless the body sets them.
+func buildGeneric(req interface{}) (*RequestSpec, error) {
+	r := req.(*GenericRequest)
+	spec := &RequestSpec{Body: make(map[string]interface{})}
+	if r.Model == "" {
+		if !validEndpoint(r.Endpoint) {
+			return nil, fmt.Errorf("invalid endpoint: %q (must start with / or be a full URL)", r.Endpoint)
+		}
+		if r.Type == "" {
+			return nil, fmt.Errorf("type is required for requests to %s", r.Endpoint)
+		}
+		spec.Model, spec.Type, spec.Endpoint = r.Endpoint, r.Type, r.Endpoint
+	} else {
+		model, exists := allModels[r.Model]
+		if !exists || !model.Generic {
+			return nil, fmt.Errorf("model %s is not a registered generic model", r.Model)
+		}
+		spec.Model = model.Name
+		if defaults, ok := model.Options.(GenericOptions); ok {
+			for k, v := range defaults {
+				spec.Body[k] = v
+			}
+		}
+	}
+	for k, v := range r.Body {
+		spec.Body[k] = v
+	}
+	return spec, nil
+}
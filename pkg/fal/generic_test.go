@@ -0,0 +1,69 @@
+package fal
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRegisterModel(t *testing.T) {
+	model := Model{
+		Name:     "generic-test-image",
+		Type:     "text2image",
+		Endpoint: "/fal-ai/generic-test",
+		Options:  GenericOptions{"image_size": "square", "num_images": 1},
+	}
+	if err := RegisterModel(model); err != nil {
+		t.Fatalf("RegisterModel: %v", err)
+	}
+	if err := RegisterModel(model); err == nil {
+		t.Error("RegisterModel accepted a model twice")
+	}
+	if m, ok := GetModel(model.Name, "text2image"); !ok || !m.Generic {
+		t.Errorf("GetModel = %+v, %v; want the generic model", m, ok)
+	}
+
+	for name, bad := range map[string]Model{
+		"no name":     {Type: "text2image", Endpoint: "/fal-ai/x"},
+		"bad type":    {Name: "generic-bad", Type: "text2speech", Endpoint: "/fal-ai/x"},
+		"no endpoint": {Name: "generic-bad", Type: "text2image"},
+		"options":     {Name: "generic-bad", Type: "text2image", Endpoint: "/fal-ai/x", Options: &FluxSchnellOptions{}},
+	} {
+		if err := CheckGenericModel(bad); err == nil {
+			t.Errorf("%s: CheckGenericModel accepted %+v", name, bad)
+		}
+	}
+
+	req := &GenericRequest{Model: model.Name, Body: map[string]interface{}{"prompt": "a cat", "image_size": "portrait_4_3"}}
+	spec, _, err := buildRequest(req, "image", imageModelTypes, decodeImageResponse)
+	if err != nil {
+		t.Fatalf("buildRequest: %v", err)
+	}
+	if spec.Model != model.Name || spec.Endpoint != model.Endpoint || spec.Body["prompt"] != "a cat" || spec.Body["image_size"] != "portrait_4_3" || spec.Body["num_images"] != 1 {
+		t.Errorf("spec = %+v; want the body on top of the defaults", spec)
+	}
+	if _, _, err := buildRequest(req, "video", videoModelTypes, decodeVideoResponse); err == nil {
+		t.Error("buildRequest accepted a text2image model for a video request")
+	}
+	if _, _, err := buildRequest(&GenericRequest{Model: "flux/schnell"}, "image", imageModelTypes, decodeImageResponse); err == nil {
+		t.Error("buildRequest accepted a built-in model")
+	}
+}
+
+func TestGenericRequestEndpoint(t *testing.T) {
+	req := &GenericRequest{Endpoint: "/fal-ai/experimental", Type: "text2video", Body: map[string]interface{}{"prompt": "waves"}}
+	spec, _, err := buildRequest(req, "video", videoModelTypes, decodeVideoResponse)
+	if err != nil || spec.Endpoint != "/fal-ai/experimental" || spec.Body["prompt"] != "waves" || len(spec.Body) != 1 {
+		t.Errorf("buildRequest = %+v, %v; want the endpoint and body as given", spec, err)
+	}
+	// The body is a copy
+	spec.Body["seed"] = 1
+	if _, ok := req.Body["seed"]; ok {
+		t.Error("changing the body changed the request")
+	}
+	if _, _, err := buildRequest(&GenericRequest{Endpoint: "fal-ai/x", Type: "text2video"}, "video", videoModelTypes, decodeVideoResponse); err == nil {
+		t.Error("buildRequest accepted a relative endpoint")
+	}
+	if _, err := NewClient("key").GenerateImage(context.Background(), req); err == nil {
+		t.Error("GenerateImage accepted a text2video request")
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Model types each Generate function accepts. Their responses share one
+// format per function.
+var (
+	imageModelTypes  = []string{"text2image", "image2image"}
+	videoModelTypes  = []string{"text2video", "image2video", "video2video", "multi2video"}
+	speechModelTypes = []string{"text2speech", "audio2audio", "text2music"}
+	model3DTypes     = []string{"image23d"}
+)
+
+// RequestSpec is what a request handler builds from a request: the model it
+// is for and the body to send.
+type RequestSpec struct {
+	Model    string                 // Name of the model, or the endpoint for unregistered ones
+	Type     string                 // Model type; empty uses the registered model's type
+	Endpoint string                 // Endpoint path or URL; empty uses the registered model's endpoint
+	Body     map[string]interface{} // Request body
+}
+
+// RequestHandler turns requests of one type into fal requests.
+type RequestHandler struct {
+	// Build validates the request, applies the model's defaults and returns
+	// the request to send. It may fill in defaults on the request itself.
+	Build func(req interface{}) (*RequestSpec, error)
+	// Decode parses the final response into the response type of the
+	// Generate function the model's type belongs to, e.g. *ImageResponse.
+	// Nil uses that function's decoder.
+	Decode FinalResponseDecoder
+}
+
+// requestHandlers maps request type → handler.
+var requestHandlers = make(map[reflect.Type]RequestHandler)
+
+// RegisterRequestHandler makes GenerateImage, GenerateVideo, GenerateSpeech
+// and Generate3D accept requests of the same type as sample, a pointer to a
+// request struct, using h. Which of them accepts a request depends on the
+// type of the model it is for. Handlers are meant to be registered at
+// startup, before requests are made; registering a type again replaces its
+// handler.
+func RegisterRequestHandler(sample interface{}, h RequestHandler) error {
+	t := reflect.TypeOf(sample)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return fmt.Errorf("request type must be a pointer, got %T", sample)
+	}
+	if h.Build == nil {
+		return fmt.Errorf("handler for %T has no Build function", sample)
+	}
+	requestHandlers[t] = h
+	return nil
+}
+
+// mustRegisterRequestHandler registers the handlers of the built-in models.
+func mustRegisterRequestHandler(sample interface{}, build func(req interface{}) (*RequestSpec, error)) {
+	if err := RegisterRequestHandler(sample, RequestHandler{Build: build}); err != nil {
+		panic(err)
+	}
+}
+
+// buildRequest runs the handler of req and resolves the model's type and
+// endpoint. kind names the calling Generate function in errors, modelTypes
+// are the types it accepts and decode is its default decoder.
+func buildRequest(req interface{}, kind string, modelTypes []string, decode FinalResponseDecoder) (*RequestSpec, FinalResponseDecoder, error) {
+	h, ok := requestHandlers[reflect.TypeOf(req)]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported %s request type: %T", kind, req)
+	}
+	spec, err := h.Build(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if spec.Type == "" || spec.Endpoint == "" {
+		model, exists := allModels[spec.Model]
+		if !exists || (spec.Type != "" && model.Type != spec.Type) {
+			return nil, nil, &Error{
+				Code:    "INVALID_MODEL",
+				Message: fmt.Sprintf("model %s not found or not of expected type %s", spec.Model, spec.Type),
+			}
+		}
+		if spec.Type == "" {
+			spec.Type = model.Type
+		}
+		if spec.Endpoint == "" {
+			spec.Endpoint = withEndpointOverride(model).Endpoint
+		}
+	}
+	if !slices.Contains(modelTypes, spec.Type) {
+		return nil, nil, fmt.Errorf("unsupported %s request type: %T for %s model %s", kind, req, spec.Type, spec.Model)
+	}
+
+	// Add any additional generic options from the request
+	if spec.Body == nil {
+		spec.Body = make(map[string]interface{})
+	}
+	if optionsGetter, ok := req.(interface{ GetOptions() map[string]interface{} }); ok {
+		for k, v := range optionsGetter.GetOptions() {
+			// Avoid overwriting fields already set by specific request types
+			if _, exists := spec.Body[k]; !exists {
+				spec.Body[k] = v
+			}
+		}
+	}
+
+	if h.Decode != nil {
+		decode = h.Decode
+	}
+	return spec, decode, nil
+}
@@ -0,0 +1,64 @@
+package fal
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testUpscaleRequest struct {
+	BaseImageRequest
+	Factor int
+}
+
+func TestRegisterRequestHandler(t *testing.T) {
+	if err := RegisterRequestHandler(testUpscaleRequest{}, RequestHandler{Build: buildFastSDXL}); err == nil {
+		t.Error("RegisterRequestHandler accepted a non-pointer request type")
+	}
+	if err := RegisterRequestHandler(&testUpscaleRequest{}, RequestHandler{}); err == nil {
+		t.Error("RegisterRequestHandler accepted a handler without Build")
+	}
+
+	decoded := false
+	err := RegisterRequestHandler(&testUpscaleRequest{}, RequestHandler{
+		Build: func(req interface{}) (*RequestSpec, error) {
+			r := req.(*testUpscaleRequest)
+			return &RequestSpec{Model: "esrgan", Body: map[string]interface{}{"image_url": r.ImageURL, "scale": r.Factor}}, nil
+		},
+		Decode: func(data []byte) (interface{}, error) {
+			decoded = true
+			return &ImageResponse{}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("RegisterRequestHandler: %v", err)
+	}
+	defer delete(requestHandlers, reflect.TypeOf(&testUpscaleRequest{}))
+
+	req := &testUpscaleRequest{
+		BaseImageRequest: BaseImageRequest{ImageURL: "https://example.com/a.png", Options: map[string]interface{}{"scale": 8, "face": true}},
+		Factor:           2,
+	}
+	spec, decode, err := buildRequest(req, "image", imageModelTypes, decodeImageResponse)
+	if err != nil {
+		t.Fatalf("buildRequest: %v", err)
+	}
+	esrgan, _ := GetModel("esrgan", "image2image")
+	if spec.Type != "image2image" || spec.Endpoint != esrgan.Endpoint {
+		t.Errorf("spec = %+v; want the type and endpoint of esrgan", spec)
+	}
+	// Options fill in fields the handler did not set
+	if spec.Body["scale"] != 2 || spec.Body["face"] != true {
+		t.Errorf("body = %v; want scale 2 and the face option", spec.Body)
+	}
+	decode(nil)
+	if !decoded {
+		t.Error("buildRequest did not return the handler's decoder")
+	}
+
+	if _, _, err := buildRequest(req, "video", videoModelTypes, decodeVideoResponse); err == nil {
+		t.Error("buildRequest accepted an image model for a video request")
+	}
+	if _, _, err := buildRequest(&struct{}{}, "image", imageModelTypes, decodeImageResponse); err == nil {
+		t.Error("buildRequest accepted an unregistered request type")
+	}
+}
@@ -12,537 +12,879 @@ import (
 )
 
 // GenerateImage generates an image from a text prompt or image url
-// It accepts specific request types like *FastSDXLRequest or *GhiblifyRequest.
+// It accepts specific request types like *FastSDXLRequest or *GhiblifyRequest,
+// and any request type registered with RegisterRequestHandler for an image
+// model.
 func (c *Client) GenerateImage(ctx context.Context, req interface{}) (*ImageResponse, error) {
-	var modelName string
-	var modelType string
-	var endpoint string
-	var reqBody map[string]interface{}
 	var progress ProgressCallback
-	var baseReq *BaseImageRequest
-
-	// Extract progress callback and base request details
 	if progressable, ok := req.(Progressable); ok {
 		progress = progressable.GetProgress()
 	}
 
-	// Determine model name, endpoint and create request body based on request type
-	switch r := req.(type) {
-	case *FastSDXLRequest:
-		modelName = "fast-sdxl"
-		modelType = "text2image"
-		baseReq = &r.BaseImageRequest
-		if r.NumImages < 0 || r.NumImages > 4 {
-			return nil, fmt.Errorf("invalid num_images: %d (must be 1-4)", r.NumImages)
-		}
-		reqBody = map[string]interface{}{
-			"prompt": r.Prompt,
-		}
-		if r.NumImages > 0 {
-			reqBody["num_images"] = r.NumImages
-		}
-		r.Model = modelName // Set model name internally
-	case *GhiblifyRequest:
-		modelName = "ghiblify"
-		modelType = "image2image"
-		baseReq = &r.BaseImageRequest
-		if r.ImageURL == "" {
-			return nil, fmt.Errorf("image_url is required for %s model", modelName)
-		}
-		reqBody = map[string]interface{}{
-			"image_url": r.ImageURL,
-		}
-		// Ghiblify might optionally use prompt, add if present
-		if r.Prompt != "" {
-			reqBody["prompt"] = r.Prompt
-		}
-		r.Model = modelName // Set model name internally
-	case *FluxSchnellRequest:
-		modelName = "flux/schnell"
-		modelType = "text2image"
-		baseReq = &r.BaseImageRequest
-		// Validate specific options
-		opts := FluxSchnellOptions{
-			ImageSize:           r.ImageSize,
-			NumInferenceSteps:   r.NumInferenceSteps,
-			Seed:                r.Seed,
-			SyncMode:            r.SyncMode,
-			NumImages:           r.NumImages,
-			EnableSafetyChecker: r.EnableSafetyChecker,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		// Build request body from the specific request struct
-		reqBody = map[string]interface{}{
-			"prompt": r.Prompt, // From BaseImageRequest
-		}
-		if r.ImageSize != "" {
-			reqBody["image_size"] = r.ImageSize
-		}
-		if r.NumInferenceSteps > 0 { // Only include if non-default might be intended
-			reqBody["num_inference_steps"] = r.NumInferenceSteps
-		}
-		if r.Seed != nil {
-			reqBody["seed"] = *r.Seed
-		}
-		if r.SyncMode { // Only include if true
-			reqBody["sync_mode"] = r.SyncMode
-		}
-		if r.NumImages > 0 { // Only include if non-default might be intended
-			reqBody["num_images"] = r.NumImages
-		}
-		if r.EnableSafetyChecker != nil { // Include if explicitly set
-			reqBody["enable_safety_checker"] = *r.EnableSafetyChecker
-		}
-		r.Model = modelName // Set model name internally
-	case *FluxProV1_1Request:
-		modelName = "flux-pro/v1.1"
-		modelType = "text2image"
-		baseReq = &r.BaseImageRequest
-		// Validate specific options
-		opts := FluxProV1_1Options{
-			ImageSize:           r.ImageSize,
-			Seed:                r.Seed,
-			SyncMode:            r.SyncMode,
-			NumImages:           r.NumImages,
-			EnableSafetyChecker: r.EnableSafetyChecker,
-			SafetyTolerance:     r.SafetyTolerance,
-			OutputFormat:        r.OutputFormat,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		// Build request body
-		reqBody = map[string]interface{}{
-			"prompt": r.Prompt,
-		}
-		if r.ImageSize != "" {
-			reqBody["image_size"] = r.ImageSize
-		}
-		if r.Seed != nil {
-			reqBody["seed"] = *r.Seed
-		}
-		if r.SyncMode {
-			reqBody["sync_mode"] = r.SyncMode
-		}
-		if r.NumImages > 0 {
-			reqBody["num_images"] = r.NumImages
-		}
-		if r.EnableSafetyChecker != nil {
-			reqBody["enable_safety_checker"] = *r.EnableSafetyChecker
-		}
-		if r.SafetyTolerance != "" {
-			reqBody["safety_tolerance"] = r.SafetyTolerance
-		}
-		if r.OutputFormat != "" {
-			reqBody["output_format"] = r.OutputFormat
-		}
-		r.Model = modelName // Set model name internally
-	// HiDream Models (assuming shared parameters)
-	case *HiDreamI1FullRequest, *HiDreamI1DevRequest, *HiDreamI1FastRequest:
-		// Determine modelName and get concrete request struct pointer
-		var concreteReq *HiDreamI1FullRequest
-		switch reqTyped := req.(type) { // Use new variable reqTyped
-		case *HiDreamI1FullRequest:
-			modelName = "hidream-i1-full"
-			concreteReq = reqTyped
-		case *HiDreamI1DevRequest:
-			modelName = "hidream-i1-dev"
-			concreteReq = &reqTyped.HiDreamI1FullRequest
-		case *HiDreamI1FastRequest:
-			modelName = "hidream-i1-fast"
-			concreteReq = &reqTyped.HiDreamI1FullRequest
-		default: // Should not happen due to outer case
-			return nil, fmt.Errorf("unexpected type within HiDream case: %T", req)
-		}
-		modelType = "text2image"
-		baseReq = &concreteReq.BaseImageRequest
-		// Validate specific options
-		opts := HiDreamOptions{
-			NegativePrompt:      concreteReq.NegativePrompt,
-			ImageSize:           concreteReq.ImageSize,
-			NumInferenceSteps:   concreteReq.NumInferenceSteps,
-			Seed:                concreteReq.Seed,
-			GuidanceScale:       concreteReq.GuidanceScale,
-			SyncMode:            concreteReq.SyncMode,
-			NumImages:           concreteReq.NumImages,
-			EnableSafetyChecker: concreteReq.EnableSafetyChecker,
-			OutputFormat:        concreteReq.OutputFormat,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		// Build request body
-		reqBody = map[string]interface{}{"prompt": concreteReq.Prompt}
-		if concreteReq.NegativePrompt != "" {
-			reqBody["negative_prompt"] = concreteReq.NegativePrompt
-		}
-		if concreteReq.ImageSize != "" {
-			reqBody["image_size"] = concreteReq.ImageSize
-		}
-		if concreteReq.NumInferenceSteps != nil {
-			reqBody["num_inference_steps"] = *concreteReq.NumInferenceSteps
-		}
-		if concreteReq.Seed != nil {
-			reqBody["seed"] = *concreteReq.Seed
-		}
-		// Only include guidance_scale for the 'full' model
-		if modelName == "hidream-i1-full" && concreteReq.GuidanceScale != nil {
-			reqBody["guidance_scale"] = *concreteReq.GuidanceScale
-		}
-		if concreteReq.SyncMode {
-			reqBody["sync_mode"] = concreteReq.SyncMode
-		}
-		if concreteReq.NumImages > 0 {
-			reqBody["num_images"] = concreteReq.NumImages
-		}
-		if concreteReq.EnableSafetyChecker != nil {
-			reqBody["enable_safety_checker"] = *concreteReq.EnableSafetyChecker
-		}
-		if concreteReq.OutputFormat != "" {
-			reqBody["output_format"] = concreteReq.OutputFormat
-		}
-		concreteReq.Model = modelName
-	case *Flux2Request:
-		modelName = "flux-2"
-		modelType = "text2image"
-		baseReq = &r.BaseImageRequest
-		// Validate specific options
-		opts := Flux2Options{
-			ImageSize:             r.ImageSize,
-			GuidanceScale:         r.GuidanceScale,
-			NumInferenceSteps:     r.NumInferenceSteps,
-			Seed:                  r.Seed,
-			NumImages:             r.NumImages,
-			Acceleration:          r.Acceleration,
-			EnablePromptExpansion: r.EnablePromptExpansion,
-			SyncMode:              r.SyncMode,
-			EnableSafetyChecker:   r.EnableSafetyChecker,
-			OutputFormat:          r.OutputFormat,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		// Build request body
-		reqBody = map[string]interface{}{
-			"prompt": r.Prompt,
-		}
-		if r.ImageSize != "" {
-			reqBody["image_size"] = r.ImageSize
-		}
-		if r.GuidanceScale > 0 {
-			reqBody["guidance_scale"] = r.GuidanceScale
-		}
-		if r.NumInferenceSteps > 0 {
-			reqBody["num_inference_steps"] = r.NumInferenceSteps
-		}
-		if r.Seed != nil {
-			reqBody["seed"] = *r.Seed
-		}
-		if r.NumImages > 0 {
-			reqBody["num_images"] = r.NumImages
-		}
-		if r.Acceleration != "" {
-			reqBody["acceleration"] = r.Acceleration
-		}
-		if r.EnablePromptExpansion != nil {
-			reqBody["enable_prompt_expansion"] = *r.EnablePromptExpansion
-		}
-		if r.SyncMode {
-			reqBody["sync_mode"] = r.SyncMode
-		}
-		if r.EnableSafetyChecker != nil {
-			reqBody["enable_safety_checker"] = *r.EnableSafetyChecker
-		}
-		if r.OutputFormat != "" {
-			reqBody["output_format"] = r.OutputFormat
-		}
-		r.Model = modelName
-	case *Flux2ProRequest:
-		modelName = "flux-2-pro"
-		modelType = "text2image"
-		baseReq = &r.BaseImageRequest
-		// Validate specific options
-		opts := Flux2ProOptions{
-			ImageSize:           r.ImageSize,
-			Seed:                r.Seed,
-			SyncMode:            r.SyncMode,
-			EnableSafetyChecker: r.EnableSafetyChecker,
-			SafetyTolerance:     r.SafetyTolerance,
-			OutputFormat:        r.OutputFormat,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		// Build request body
-		reqBody = map[string]interface{}{
-			"prompt": r.Prompt,
-		}
-		if r.ImageSize != "" {
-			reqBody["image_size"] = r.ImageSize
-		}
-		if r.Seed != nil {
-			reqBody["seed"] = *r.Seed
-		}
-		if r.SyncMode {
-			reqBody["sync_mode"] = r.SyncMode
-		}
-		if r.EnableSafetyChecker != nil {
-			reqBody["enable_safety_checker"] = *r.EnableSafetyChecker
-		}
-		if r.SafetyTolerance != "" {
-			reqBody["safety_tolerance"] = r.SafetyTolerance
-		}
-		if r.OutputFormat != "" {
-			reqBody["output_format"] = r.OutputFormat
-		}
-		r.Model = modelName
-	case *FluxProV1_1UltraRequest:
-		modelName = "flux-pro/v1.1-ultra"
-		modelType = "text2image"
-		baseReq = &r.BaseImageRequest
-		// Validate specific options
-		opts := FluxProV1_1UltraOptions{
-			Seed:                r.Seed,
-			SyncMode:            r.SyncMode,
-			NumImages:           r.NumImages,
-			EnableSafetyChecker: r.EnableSafetyChecker,
-			SafetyTolerance:     r.SafetyTolerance,
-			OutputFormat:        r.OutputFormat,
-			AspectRatio:         r.AspectRatio,
-			Raw:                 r.Raw,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		// Build request body
-		reqBody = map[string]interface{}{"prompt": r.Prompt}
-		if r.Seed != nil {
-			reqBody["seed"] = *r.Seed
-		}
-		if r.SyncMode {
-			reqBody["sync_mode"] = r.SyncMode
-		}
-		if r.NumImages > 0 {
-			reqBody["num_images"] = r.NumImages
-		}
-		if r.EnableSafetyChecker != nil {
-			reqBody["enable_safety_checker"] = *r.EnableSafetyChecker
-		}
-		if r.SafetyTolerance != "" {
-			reqBody["safety_tolerance"] = r.SafetyTolerance
-		}
-		if r.OutputFormat != "" {
-			reqBody["output_format"] = r.OutputFormat
-		}
-		if r.AspectRatio != "" {
-			reqBody["aspect_ratio"] = r.AspectRatio
-		}
-		if r.Raw != nil {
-			reqBody["raw"] = *r.Raw
-		}
-		r.Model = modelName
-	case *Flux2ProEditRequest:
-		modelName = "flux-2-pro/edit"
-		modelType = "image2image"
-		baseReq = &r.BaseImageRequest
-		if len(r.ImageURLs) == 0 {
-			return nil, fmt.Errorf("image_urls is required for %s model", modelName)
-		}
-		// Validate specific options
-		opts := Flux2ProEditOptions{
-			ImageSize:           r.ImageSize,
-			Seed:                r.Seed,
-			SyncMode:            r.SyncMode,
-			EnableSafetyChecker: r.EnableSafetyChecker,
-			SafetyTolerance:     r.SafetyTolerance,
-			OutputFormat:        r.OutputFormat,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		// Build request body
-		reqBody = map[string]interface{}{
-			"prompt":     r.Prompt,
-			"image_urls": r.ImageURLs,
-		}
-		if r.ImageSize != "" {
-			reqBody["image_size"] = r.ImageSize
-		}
-		if r.Seed != nil {
-			reqBody["seed"] = *r.Seed
-		}
-		if r.SyncMode {
-			reqBody["sync_mode"] = r.SyncMode
-		}
-		if r.EnableSafetyChecker != nil {
-			reqBody["enable_safety_checker"] = *r.EnableSafetyChecker
-		}
-		if r.SafetyTolerance != "" {
-			reqBody["safety_tolerance"] = r.SafetyTolerance
-		}
-		if r.OutputFormat != "" {
-			reqBody["output_format"] = r.OutputFormat
-		}
-		r.Model = modelName
-	case *Flux2EditRequest:
-		modelName = "flux-2/edit"
-		modelType = "image2image"
-		baseReq = &r.BaseImageRequest
-		if len(r.ImageURLs) == 0 {
-			return nil, fmt.Errorf("image_urls is required for %s model", modelName)
-		}
-		opts := Flux2EditOptions{
-			ImageSize:             r.ImageSize,
-			GuidanceScale:         r.GuidanceScale,
-			NumInferenceSteps:     r.NumInferenceSteps,
-			Seed:                  r.Seed,
-			NumImages:             r.NumImages,
-			Acceleration:          r.Acceleration,
-			EnablePromptExpansion: r.EnablePromptExpansion,
-			SyncMode:              r.SyncMode,
-			EnableSafetyChecker:   r.EnableSafetyChecker,
-			OutputFormat:          r.OutputFormat,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		reqBody = map[string]interface{}{
-			"prompt":     r.Prompt,
-			"image_urls": r.ImageURLs,
-		}
-		if r.ImageSize != "" {
-			reqBody["image_size"] = r.ImageSize
-		}
-		if r.GuidanceScale > 0 {
-			reqBody["guidance_scale"] = r.GuidanceScale
-		}
-		if r.NumInferenceSteps > 0 {
-			reqBody["num_inference_steps"] = r.NumInferenceSteps
-		}
-		if r.Seed != nil {
-			reqBody["seed"] = *r.Seed
-		}
-		if r.NumImages > 0 {
-			reqBody["num_images"] = r.NumImages
-		}
-		if r.Acceleration != "" {
-			reqBody["acceleration"] = r.Acceleration
-		}
-		if r.EnablePromptExpansion != nil {
-			reqBody["enable_prompt_expansion"] = *r.EnablePromptExpansion
-		}
-		if r.SyncMode {
-			reqBody["sync_mode"] = r.SyncMode
-		}
-		if r.EnableSafetyChecker != nil {
-			reqBody["enable_safety_checker"] = *r.EnableSafetyChecker
-		}
-		if r.OutputFormat != "" {
-			reqBody["output_format"] = r.OutputFormat
-		}
-		r.Model = modelName
-	// Image2Image Models
-	case *CartoonifyRequest:
-		modelName = "cartoonify"
-		modelType = "image2image"
-		baseReq = &r.BaseImageRequest
-		if r.ImageURL == "" {
-			return nil, fmt.Errorf("image_url required for %s", modelName)
-		}
-		// Validate specific options (none currently)
-		opts := CartoonifyOptions{}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		// Build request body
-		reqBody = map[string]interface{}{"image_url": r.ImageURL}
-		if r.Prompt != "" {
-			reqBody["prompt"] = r.Prompt
-		} // Allow optional prompt
-		r.Model = modelName
-	// case *OtherImageRequest:
-	// ...
-	default:
-		return nil, fmt.Errorf("unsupported image request type: %T", req)
+	spec, decode, err := buildRequest(req, "image", imageModelTypes, decodeImageResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	// Execute the workflow
+	result, err := c.executeAsyncWorkflow(ctx, spec.Endpoint, spec.Body, progress, decode)
+	if err != nil {
+		return nil, err // Error already wrapped
+	}
+	resp, ok := result.(*ImageResponse)
+	if !ok {
+		return nil, fmt.Errorf("decoder for %T returned %T, want *ImageResponse", req, result)
+	}
+	return resp, nil
+}
+
+// BuildImageRequest returns the model and body GenerateImage would send for
+// req, without sending it, so other providers can run the same request.
+func BuildImageRequest(req interface{}) (*RequestSpec, error) {
+	spec, _, err := buildRequest(req, "image", imageModelTypes, decodeImageResponse)
+	return spec, err
+}
+
+// decodeImageResponse parses the final response of an image model.
+func decodeImageResponse(data []byte) (interface{}, error) {
+	var response ImageResponse
+
+	// Try parsing as standard response (includes images array and top-level seed)
+	if err := json.Unmarshal(data, &response); err == nil && len(response.Images) > 0 {
+		// Seed is already captured in 'response' by the unmarshal
+		return &response, nil
+	}
+
+	// If that fails, try parsing as response with single 'image' or 'svg' field
+	// We also need to capture the top-level seed in this case.
+	var singleImageResp struct {
+		Image struct {
+			URL         string `json:"url"`
+			ContentType string `json:"content_type"`
+			Width       int    `json:"width"`
+			Height      int    `json:"height"`
+		} `json:"image"`
+		Seed uint64 `json:"seed"` // Changed from int to uint64
+	}
+
+	if err := json.Unmarshal(data, &singleImageResp); err != nil {
+		// If both attempts fail, return the original unmarshal error
+		return nil, fmt.Errorf("failed to parse final response as known image format: %w. Body: %s", err, string(data))
+	}
+
+	// Convert single image/svg response to ImageResponse format
+	imgURL := singleImageResp.Image.URL
+	imgContentType := singleImageResp.Image.ContentType
+	imgWidth := singleImageResp.Image.Width
+	imgHeight := singleImageResp.Image.Height
+
+	if imgURL == "" {
+		return nil, fmt.Errorf("final response did not contain a valid image URL. Body: %s", string(data))
+	}
+
+	// Use the named struct ImageOutput when assigning the slice
+	response.Images = []ImageOutput{
+		{
+			URL:         imgURL,
+			ContentType: imgContentType,
+			Width:       imgWidth,
+			Height:      imgHeight,
+		},
+	}
+	response.Seed = singleImageResp.Seed // Assign the captured seed
+	return &response, nil
+}
+
+func init() {
+	mustRegisterRequestHandler(&FastSDXLRequest{}, buildFastSDXL)
+	mustRegisterRequestHandler(&GhiblifyRequest{}, buildGhiblify)
+	mustRegisterRequestHandler(&FluxSchnellRequest{}, buildFluxSchnell)
+	mustRegisterRequestHandler(&FluxProV1_1Request{}, buildFluxProV1_1)
+	mustRegisterRequestHandler(&HiDreamI1FullRequest{}, buildHiDream)
+	mustRegisterRequestHandler(&HiDreamI1DevRequest{}, buildHiDream)
+	mustRegisterRequestHandler(&HiDreamI1FastRequest{}, buildHiDream)
+	mustRegisterRequestHandler(&ControlNetRequest{}, buildControlNet)
+	mustRegisterRequestHandler(&Flux2Request{}, buildFlux2)
+	mustRegisterRequestHandler(&Flux2ProRequest{}, buildFlux2Pro)
+	mustRegisterRequestHandler(&FluxProV1_1UltraRequest{}, buildFluxProV1_1Ultra)
+	mustRegisterRequestHandler(&Flux2ProEditRequest{}, buildFlux2ProEdit)
+	mustRegisterRequestHandler(&Flux2EditRequest{}, buildFlux2Edit)
+	mustRegisterRequestHandler(&CartoonifyRequest{}, buildCartoonify)
+	mustRegisterRequestHandler(&DDColorRequest{}, buildDDColor)
+	mustRegisterRequestHandler(&CodeFormerRequest{}, buildCodeFormer)
+	mustRegisterRequestHandler(&ESRGANRequest{}, buildESRGAN)
+	mustRegisterRequestHandler(&BiRefNetRequest{}, buildBiRefNet)
+	mustRegisterRequestHandler(&FluxProFillRequest{}, buildFluxProFill)
+}
+
+// buildFastSDXL validates a FastSDXLRequest and builds its request body.
+func buildFastSDXL(req interface{}) (*RequestSpec, error) {
+	r := req.(*FastSDXLRequest)
+	spec := &RequestSpec{}
+	spec.Model = "fast-sdxl"
+	spec.Type = "text2image"
+	if r.NumImages < 0 || r.NumImages > 4 {
+		return nil, invalidValue("num_images", r.NumImages, "must be 1-4")
+	}
+	spec.Body = map[string]interface{}{
+		"prompt": r.Prompt,
+	}
+	if r.NumImages > 0 {
+		spec.Body["num_images"] = r.NumImages
+	}
+	r.Model = spec.Model // Set model name internally
+	return spec, nil
+}
+
+// buildGhiblify validates a GhiblifyRequest and builds its request body.
+func buildGhiblify(req interface{}) (*RequestSpec, error) {
+	r := req.(*GhiblifyRequest)
+	spec := &RequestSpec{}
+	spec.Model = "ghiblify"
+	spec.Type = "image2image"
+	if r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url is required for %s model", spec.Model)
+	}
+	spec.Body = map[string]interface{}{
+		"image_url": r.ImageURL,
+	}
+	// Ghiblify might optionally use prompt, add if present
+	if r.Prompt != "" {
+		spec.Body["prompt"] = r.Prompt
+	}
+	r.Model = spec.Model // Set model name internally
+	return spec, nil
+}
+
+// buildFluxSchnell validates a FluxSchnellRequest and builds its request body.
+func buildFluxSchnell(req interface{}) (*RequestSpec, error) {
+	r := req.(*FluxSchnellRequest)
+	spec := &RequestSpec{}
+	spec.Model = "flux/schnell"
+	spec.Type = "text2image"
+	// Validate specific options
+	opts := FluxSchnellOptions{
+		ImageSize:           r.ImageSize,
+		NumInferenceSteps:   r.NumInferenceSteps,
+		Seed:                r.Seed,
+		SyncMode:            r.SyncMode,
+		NumImages:           r.NumImages,
+		EnableSafetyChecker: r.EnableSafetyChecker,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// Build request body from the specific request struct
+	spec.Body = map[string]interface{}{
+		"prompt": r.Prompt, // From BaseImageRequest
+	}
+	if r.ImageSize != "" {
+		spec.Body["image_size"] = r.ImageSize
+	}
+	if r.NumInferenceSteps > 0 { // Only include if non-default might be intended
+		spec.Body["num_inference_steps"] = r.NumInferenceSteps
+	}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	if r.SyncMode { // Only include if true
+		spec.Body["sync_mode"] = r.SyncMode
+	}
+	if r.NumImages > 0 { // Only include if non-default might be intended
+		spec.Body["num_images"] = r.NumImages
+	}
+	if r.EnableSafetyChecker != nil { // Include if explicitly set
+		spec.Body["enable_safety_checker"] = *r.EnableSafetyChecker
+	}
+	r.Model = spec.Model // Set model name internally
+	return spec, nil
+}
+
+// buildFluxProV1_1 validates a FluxProV1_1Request and builds its request body.
+func buildFluxProV1_1(req interface{}) (*RequestSpec, error) {
+	r := req.(*FluxProV1_1Request)
+	spec := &RequestSpec{}
+	spec.Model = "flux-pro/v1.1"
+	spec.Type = "text2image"
+	// Validate specific options
+	opts := FluxProV1_1Options{
+		ImageSize:           r.ImageSize,
+		Seed:                r.Seed,
+		SyncMode:            r.SyncMode,
+		NumImages:           r.NumImages,
+		EnableSafetyChecker: r.EnableSafetyChecker,
+		SafetyTolerance:     r.SafetyTolerance,
+		OutputFormat:        r.OutputFormat,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"prompt": r.Prompt,
 	}
+	if r.ImageSize != "" {
+		spec.Body["image_size"] = r.ImageSize
+	}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	if r.SyncMode {
+		spec.Body["sync_mode"] = r.SyncMode
+	}
+	if r.NumImages > 0 {
+		spec.Body["num_images"] = r.NumImages
+	}
+	if r.EnableSafetyChecker != nil {
+		spec.Body["enable_safety_checker"] = *r.EnableSafetyChecker
+	}
+	if r.SafetyTolerance != "" {
+		spec.Body["safety_tolerance"] = r.SafetyTolerance
+	}
+	if r.OutputFormat != "" {
+		spec.Body["output_format"] = r.OutputFormat
+	}
+	r.Model = spec.Model // Set model name internally
+	return spec, nil
+}
+
+// buildHiDream validates a request for one of the HiDream models, which
+// share their parameters, and builds its request body.
+func buildHiDream(req interface{}) (*RequestSpec, error) {
+	spec := &RequestSpec{}
+	// Determine model name and get concrete request struct pointer
+	var concreteReq *HiDreamI1FullRequest
+	switch reqTyped := req.(type) { // Use new variable reqTyped
+	case *HiDreamI1FullRequest:
+		spec.Model = "hidream-i1-full"
+		concreteReq = reqTyped
+	case *HiDreamI1DevRequest:
+		spec.Model = "hidream-i1-dev"
+		concreteReq = &reqTyped.HiDreamI1FullRequest
+	case *HiDreamI1FastRequest:
+		spec.Model = "hidream-i1-fast"
+		concreteReq = &reqTyped.HiDreamI1FullRequest
+	default: // Should not happen, only HiDream requests are registered
+		return nil, fmt.Errorf("unexpected HiDream request type: %T", req)
+	}
+	spec.Type = "text2image"
+	// Validate specific options
+	opts := HiDreamOptions{
+		NegativePrompt:      concreteReq.NegativePrompt,
+		ImageSize:           concreteReq.ImageSize,
+		NumInferenceSteps:   concreteReq.NumInferenceSteps,
+		Seed:                concreteReq.Seed,
+		GuidanceScale:       concreteReq.GuidanceScale,
+		SyncMode:            concreteReq.SyncMode,
+		NumImages:           concreteReq.NumImages,
+		EnableSafetyChecker: concreteReq.EnableSafetyChecker,
+		OutputFormat:        concreteReq.OutputFormat,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// Build request body
+	spec.Body = map[string]interface{}{"prompt": concreteReq.Prompt}
+	if concreteReq.NegativePrompt != "" {
+		spec.Body["negative_prompt"] = concreteReq.NegativePrompt
+	}
+	if concreteReq.ImageSize != "" {
+		spec.Body["image_size"] = concreteReq.ImageSize
+	}
+	if concreteReq.NumInferenceSteps != nil {
+		spec.Body["num_inference_steps"] = *concreteReq.NumInferenceSteps
+	}
+	if concreteReq.Seed != nil {
+		spec.Body["seed"] = *concreteReq.Seed
+	}
+	// Only include guidance_scale for the 'full' model
+	if spec.Model == "hidream-i1-full" && concreteReq.GuidanceScale != nil {
+		spec.Body["guidance_scale"] = *concreteReq.GuidanceScale
+	}
+	if concreteReq.SyncMode {
+		spec.Body["sync_mode"] = concreteReq.SyncMode
+	}
+	if concreteReq.NumImages > 0 {
+		spec.Body["num_images"] = concreteReq.NumImages
+	}
+	if concreteReq.EnableSafetyChecker != nil {
+		spec.Body["enable_safety_checker"] = *concreteReq.EnableSafetyChecker
+	}
+	if concreteReq.OutputFormat != "" {
+		spec.Body["output_format"] = concreteReq.OutputFormat
+	}
+	concreteReq.Model = spec.Model
+	return spec, nil
+}
 
-	// Validate the model existence and set endpoint from model definition
-	modelDef, exists := GetModel(modelName, modelType)
+// buildControlNet validates a ControlNetRequest and builds its request body.
+func buildControlNet(req interface{}) (*RequestSpec, error) {
+	r := req.(*ControlNetRequest)
+	spec := &RequestSpec{}
+	spec.Model = "sdxl-controlnet-union"
+	spec.Type = "text2image"
+	model, exists := GetModel(spec.Model, spec.Type)
 	if !exists {
-		return nil, &Error{
-			Code:    "INVALID_MODEL",
-			Message: fmt.Sprintf("model %s not found or not of expected type %s", modelName, modelType),
-		}
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	options, ok := model.Options.(*ControlNetOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+	if r.Prompt == "" {
+		return nil, fmt.Errorf("prompt is required for %s", spec.Model)
+	}
+	if r.ControlImageURL == "" {
+		return nil, fmt.Errorf("control image URL is required for %s", spec.Model)
+	}
+	// Set defaults if not provided
+	if r.ControlType == "" {
+		r.ControlType = options.ControlType
+	}
+	if r.Preprocess == nil {
+		r.Preprocess = options.Preprocess
+	}
+	if r.ConditioningScale == nil {
+		r.ConditioningScale = options.ConditioningScale
+	}
+	opts := ControlNetOptions{
+		ControlType:         r.ControlType,
+		ConditioningScale:   r.ConditioningScale,
+		ImageSize:           r.ImageSize,
+		NumImages:           r.NumImages,
+		EnableSafetyChecker: r.EnableSafetyChecker,
+		OutputFormat:        r.OutputFormat,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// The union endpoint takes one image field per control type
+	prefix := ControlNetTypes[r.ControlType]
+	spec.Body = map[string]interface{}{
+		"prompt":              r.Prompt,
+		prefix + "_image_url": r.ControlImageURL,
+	}
+	if r.Preprocess != nil {
+		spec.Body[prefix+"_preprocess"] = *r.Preprocess
+	}
+	if r.ConditioningScale != nil {
+		spec.Body["controlnet_conditioning_scale"] = *r.ConditioningScale
+	}
+	if r.NegativePrompt != "" {
+		spec.Body["negative_prompt"] = r.NegativePrompt
+	}
+	if r.ImageSize != "" {
+		spec.Body["image_size"] = r.ImageSize
+	}
+	if r.NumInferenceSteps != nil {
+		spec.Body["num_inference_steps"] = *r.NumInferenceSteps
+	}
+	if r.GuidanceScale != nil {
+		spec.Body["guidance_scale"] = *r.GuidanceScale
+	}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	if r.NumImages > 0 {
+		spec.Body["num_images"] = r.NumImages
+	}
+	if r.EnableSafetyChecker != nil {
+		spec.Body["enable_safety_checker"] = *r.EnableSafetyChecker
+	}
+	if r.OutputFormat != "" {
+		spec.Body["output_format"] = r.OutputFormat
+	}
+	r.Model = spec.Model
+	return spec, nil
+}
+
+// buildFlux2 validates a Flux2Request and builds its request body.
+func buildFlux2(req interface{}) (*RequestSpec, error) {
+	r := req.(*Flux2Request)
+	spec := &RequestSpec{}
+	spec.Model = "flux-2"
+	spec.Type = "text2image"
+	// Validate specific options
+	opts := Flux2Options{
+		ImageSize:             r.ImageSize,
+		GuidanceScale:         r.GuidanceScale,
+		NumInferenceSteps:     r.NumInferenceSteps,
+		Seed:                  r.Seed,
+		NumImages:             r.NumImages,
+		Acceleration:          r.Acceleration,
+		EnablePromptExpansion: r.EnablePromptExpansion,
+		SyncMode:              r.SyncMode,
+		EnableSafetyChecker:   r.EnableSafetyChecker,
+		OutputFormat:          r.OutputFormat,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"prompt": r.Prompt,
+	}
+	if r.ImageSize != "" {
+		spec.Body["image_size"] = r.ImageSize
+	}
+	if r.GuidanceScale > 0 {
+		spec.Body["guidance_scale"] = r.GuidanceScale
+	}
+	if r.NumInferenceSteps > 0 {
+		spec.Body["num_inference_steps"] = r.NumInferenceSteps
+	}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	if r.NumImages > 0 {
+		spec.Body["num_images"] = r.NumImages
+	}
+	if r.Acceleration != "" {
+		spec.Body["acceleration"] = r.Acceleration
 	}
-	endpoint = modelDef.Endpoint
+	if r.EnablePromptExpansion != nil {
+		spec.Body["enable_prompt_expansion"] = *r.EnablePromptExpansion
+	}
+	if r.SyncMode {
+		spec.Body["sync_mode"] = r.SyncMode
+	}
+	if r.EnableSafetyChecker != nil {
+		spec.Body["enable_safety_checker"] = *r.EnableSafetyChecker
+	}
+	if r.OutputFormat != "" {
+		spec.Body["output_format"] = r.OutputFormat
+	}
+	r.Model = spec.Model
+	return spec, nil
+}
 
-	// Add any additional generic options from the base request
-	if baseReq != nil && baseReq.Options != nil {
-		for k, v := range baseReq.Options {
-			// Avoid overwriting fields already set by specific request types
-			if _, exists := reqBody[k]; !exists {
-				reqBody[k] = v
-			}
-		}
+// buildFlux2Pro validates a Flux2ProRequest and builds its request body.
+func buildFlux2Pro(req interface{}) (*RequestSpec, error) {
+	r := req.(*Flux2ProRequest)
+	spec := &RequestSpec{}
+	spec.Model = "flux-2-pro"
+	spec.Type = "text2image"
+	// Validate specific options
+	opts := Flux2ProOptions{
+		ImageSize:           r.ImageSize,
+		Seed:                r.Seed,
+		SyncMode:            r.SyncMode,
+		EnableSafetyChecker: r.EnableSafetyChecker,
+		SafetyTolerance:     r.SafetyTolerance,
+		OutputFormat:        r.OutputFormat,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"prompt": r.Prompt,
+	}
+	if r.ImageSize != "" {
+		spec.Body["image_size"] = r.ImageSize
+	}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	if r.SyncMode {
+		spec.Body["sync_mode"] = r.SyncMode
+	}
+	if r.EnableSafetyChecker != nil {
+		spec.Body["enable_safety_checker"] = *r.EnableSafetyChecker
+	}
+	if r.SafetyTolerance != "" {
+		spec.Body["safety_tolerance"] = r.SafetyTolerance
+	}
+	if r.OutputFormat != "" {
+		spec.Body["output_format"] = r.OutputFormat
 	}
+	r.Model = spec.Model
+	return spec, nil
+}
 
-	// Define the decoder for the final image response
-	decodeFunc := func(data []byte) (interface{}, error) {
-		var response ImageResponse
+// buildFluxProV1_1Ultra validates a FluxProV1_1UltraRequest and builds its request body.
+func buildFluxProV1_1Ultra(req interface{}) (*RequestSpec, error) {
+	r := req.(*FluxProV1_1UltraRequest)
+	spec := &RequestSpec{}
+	spec.Model = "flux-pro/v1.1-ultra"
+	spec.Type = "text2image"
+	// Validate specific options
+	opts := FluxProV1_1UltraOptions{
+		Seed:                r.Seed,
+		SyncMode:            r.SyncMode,
+		NumImages:           r.NumImages,
+		EnableSafetyChecker: r.EnableSafetyChecker,
+		SafetyTolerance:     r.SafetyTolerance,
+		OutputFormat:        r.OutputFormat,
+		AspectRatio:         r.AspectRatio,
+		Raw:                 r.Raw,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// Build request body
+	spec.Body = map[string]interface{}{"prompt": r.Prompt}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	if r.SyncMode {
+		spec.Body["sync_mode"] = r.SyncMode
+	}
+	if r.NumImages > 0 {
+		spec.Body["num_images"] = r.NumImages
+	}
+	if r.EnableSafetyChecker != nil {
+		spec.Body["enable_safety_checker"] = *r.EnableSafetyChecker
+	}
+	if r.SafetyTolerance != "" {
+		spec.Body["safety_tolerance"] = r.SafetyTolerance
+	}
+	if r.OutputFormat != "" {
+		spec.Body["output_format"] = r.OutputFormat
+	}
+	if r.AspectRatio != "" {
+		spec.Body["aspect_ratio"] = r.AspectRatio
+	}
+	if r.Raw != nil {
+		spec.Body["raw"] = *r.Raw
+	}
+	r.Model = spec.Model
+	return spec, nil
+}
 
-		// Try parsing as standard response (includes images array and top-level seed)
-		if err := json.Unmarshal(data, &response); err == nil && len(response.Images) > 0 {
-			// Seed is already captured in 'response' by the unmarshal
-			return &response, nil
-		}
+// buildFlux2ProEdit validates a Flux2ProEditRequest and builds its request body.
+func buildFlux2ProEdit(req interface{}) (*RequestSpec, error) {
+	r := req.(*Flux2ProEditRequest)
+	spec := &RequestSpec{}
+	spec.Model = "flux-2-pro/edit"
+	spec.Type = "image2image"
+	if len(r.ImageURLs) == 0 {
+		return nil, fmt.Errorf("image_urls is required for %s model", spec.Model)
+	}
+	// Validate specific options
+	opts := Flux2ProEditOptions{
+		ImageSize:           r.ImageSize,
+		Seed:                r.Seed,
+		SyncMode:            r.SyncMode,
+		EnableSafetyChecker: r.EnableSafetyChecker,
+		SafetyTolerance:     r.SafetyTolerance,
+		OutputFormat:        r.OutputFormat,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"prompt":     r.Prompt,
+		"image_urls": r.ImageURLs,
+	}
+	if r.ImageSize != "" {
+		spec.Body["image_size"] = r.ImageSize
+	}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	if r.SyncMode {
+		spec.Body["sync_mode"] = r.SyncMode
+	}
+	if r.EnableSafetyChecker != nil {
+		spec.Body["enable_safety_checker"] = *r.EnableSafetyChecker
+	}
+	if r.SafetyTolerance != "" {
+		spec.Body["safety_tolerance"] = r.SafetyTolerance
+	}
+	if r.OutputFormat != "" {
+		spec.Body["output_format"] = r.OutputFormat
+	}
+	r.Model = spec.Model
+	return spec, nil
+}
 
-		// If that fails, try parsing as response with single 'image' or 'svg' field
-		// We also need to capture the top-level seed in this case.
-		var singleImageResp struct {
-			Image struct {
-				URL         string `json:"url"`
-				ContentType string `json:"content_type"`
-				Width       int    `json:"width"`
-				Height      int    `json:"height"`
-			} `json:"image"`
-			Seed uint64 `json:"seed"` // Changed from int to uint64
-		}
+// buildFlux2Edit validates a Flux2EditRequest and builds its request body.
+func buildFlux2Edit(req interface{}) (*RequestSpec, error) {
+	r := req.(*Flux2EditRequest)
+	spec := &RequestSpec{}
+	spec.Model = "flux-2/edit"
+	spec.Type = "image2image"
+	if len(r.ImageURLs) == 0 {
+		return nil, fmt.Errorf("image_urls is required for %s model", spec.Model)
+	}
+	opts := Flux2EditOptions{
+		ImageSize:             r.ImageSize,
+		GuidanceScale:         r.GuidanceScale,
+		NumInferenceSteps:     r.NumInferenceSteps,
+		Seed:                  r.Seed,
+		NumImages:             r.NumImages,
+		Acceleration:          r.Acceleration,
+		EnablePromptExpansion: r.EnablePromptExpansion,
+		SyncMode:              r.SyncMode,
+		EnableSafetyChecker:   r.EnableSafetyChecker,
+		OutputFormat:          r.OutputFormat,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	spec.Body = map[string]interface{}{
+		"prompt":     r.Prompt,
+		"image_urls": r.ImageURLs,
+	}
+	if r.ImageSize != "" {
+		spec.Body["image_size"] = r.ImageSize
+	}
+	if r.GuidanceScale > 0 {
+		spec.Body["guidance_scale"] = r.GuidanceScale
+	}
+	if r.NumInferenceSteps > 0 {
+		spec.Body["num_inference_steps"] = r.NumInferenceSteps
+	}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	if r.NumImages > 0 {
+		spec.Body["num_images"] = r.NumImages
+	}
+	if r.Acceleration != "" {
+		spec.Body["acceleration"] = r.Acceleration
+	}
+	if r.EnablePromptExpansion != nil {
+		spec.Body["enable_prompt_expansion"] = *r.EnablePromptExpansion
+	}
+	if r.SyncMode {
+		spec.Body["sync_mode"] = r.SyncMode
+	}
+	if r.EnableSafetyChecker != nil {
+		spec.Body["enable_safety_checker"] = *r.EnableSafetyChecker
+	}
+	if r.OutputFormat != "" {
+		spec.Body["output_format"] = r.OutputFormat
+	}
+	r.Model = spec.Model
+	return spec, nil
+}
 
-		if err := json.Unmarshal(data, &singleImageResp); err != nil {
-			// If both attempts fail, return the original unmarshal error
-			return nil, fmt.Errorf("failed to parse final response as known image format: %w. Body: %s", err, string(data))
-		}
+// buildCartoonify validates a CartoonifyRequest and builds its request body.
+func buildCartoonify(req interface{}) (*RequestSpec, error) {
+	r := req.(*CartoonifyRequest)
+	spec := &RequestSpec{}
+	// Image2Image Models
+	spec.Model = "cartoonify"
+	spec.Type = "image2image"
+	if r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url required for %s", spec.Model)
+	}
+	// Validate specific options (none currently)
+	opts := CartoonifyOptions{}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// Build request body
+	spec.Body = map[string]interface{}{"image_url": r.ImageURL}
+	if r.Prompt != "" {
+		spec.Body["prompt"] = r.Prompt
+	} // Allow optional prompt
+	r.Model = spec.Model
+	return spec, nil
+}
 
-		// Convert single image/svg response to ImageResponse format
-		imgURL := singleImageResp.Image.URL
-		imgContentType := singleImageResp.Image.ContentType
-		imgWidth := singleImageResp.Image.Width
-		imgHeight := singleImageResp.Image.Height
+// buildDDColor validates a DDColorRequest and builds its request body.
+func buildDDColor(req interface{}) (*RequestSpec, error) {
+	r := req.(*DDColorRequest)
+	spec := &RequestSpec{}
+	spec.Model = "ddcolor"
+	spec.Type = "image2image"
+	if r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url required for %s", spec.Model)
+	}
+	spec.Body = map[string]interface{}{"image_url": r.ImageURL}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	r.Model = spec.Model
+	return spec, nil
+}
 
-		if imgURL == "" {
-			return nil, fmt.Errorf("final response did not contain a valid image URL. Body: %s", string(data))
-		}
+// buildCodeFormer validates a CodeFormerRequest and builds its request body.
+func buildCodeFormer(req interface{}) (*RequestSpec, error) {
+	r := req.(*CodeFormerRequest)
+	spec := &RequestSpec{}
+	spec.Model = "codeformer"
+	spec.Type = "image2image"
+	if r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url required for %s", spec.Model)
+	}
+	model, exists := GetModel(spec.Model, spec.Type)
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	options, ok := model.Options.(*CodeFormerOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+	// Set defaults if not provided
+	if r.Fidelity == nil {
+		r.Fidelity = &options.Fidelity
+	}
+	if r.Upscaling == nil {
+		r.Upscaling = &options.Upscaling
+	}
+	if r.FaceUpscale == nil {
+		r.FaceUpscale = options.FaceUpscale
+	}
+	if r.OnlyCenterFace == nil {
+		r.OnlyCenterFace = options.OnlyCenterFace
+	}
+	opts := CodeFormerOptions{
+		Fidelity:       *r.Fidelity,
+		Upscaling:      *r.Upscaling,
+		FaceUpscale:    r.FaceUpscale,
+		OnlyCenterFace: r.OnlyCenterFace,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	spec.Body = map[string]interface{}{
+		"image_url": r.ImageURL,
+		"fidelity":  *r.Fidelity,
+		"upscaling": *r.Upscaling,
+	}
+	if r.FaceUpscale != nil {
+		spec.Body["face_upscale"] = *r.FaceUpscale
+	}
+	if r.OnlyCenterFace != nil {
+		spec.Body["only_center_face"] = *r.OnlyCenterFace
+	}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	r.Model = spec.Model
+	return spec, nil
+}
 
-		// Use the named struct ImageOutput when assigning the slice
-		response.Images = []ImageOutput{
-			{
-				URL:         imgURL,
-				ContentType: imgContentType,
-				Width:       imgWidth,
-				Height:      imgHeight,
-			},
-		}
-		response.Seed = singleImageResp.Seed // Assign the captured seed
-		return &response, nil
+// buildESRGAN validates an ESRGANRequest and builds its request body.
+func buildESRGAN(req interface{}) (*RequestSpec, error) {
+	r := req.(*ESRGANRequest)
+	spec := &RequestSpec{}
+	spec.Model = "esrgan"
+	spec.Type = "image2image"
+	if r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url required for %s", spec.Model)
+	}
+	model, exists := GetModel(spec.Model, spec.Type)
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	options, ok := model.Options.(*ESRGANOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+	// Set defaults if not provided
+	if r.Scale == nil {
+		r.Scale = &options.Scale
 	}
+	if r.UpscaleModel == "" {
+		r.UpscaleModel = options.Model
+	}
+	if r.Face == nil {
+		r.Face = options.Face
+	}
+	if r.OutputFormat == "" {
+		r.OutputFormat = options.OutputFormat
+	}
+	opts := ESRGANOptions{
+		Scale:        *r.Scale,
+		Model:        r.UpscaleModel,
+		Face:         r.Face,
+		OutputFormat: r.OutputFormat,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	spec.Body = map[string]interface{}{
+		"image_url":     r.ImageURL,
+		"scale":         *r.Scale,
+		"model":         r.UpscaleModel,
+		"output_format": r.OutputFormat,
+	}
+	if r.Face != nil {
+		spec.Body["face"] = *r.Face
+	}
+	r.Model = spec.Model
+	return spec, nil
+}
 
-	// Execute the workflow
-	result, err := c.executeAsyncWorkflow(ctx, endpoint, reqBody, progress, decodeFunc)
-	if err != nil {
-		return nil, err // Error already wrapped by executeAsyncWorkflow
+// buildBiRefNet validates a BiRefNetRequest and builds its request body.
+func buildBiRefNet(req interface{}) (*RequestSpec, error) {
+	r := req.(*BiRefNetRequest)
+	spec := &RequestSpec{}
+	spec.Model = "birefnet"
+	spec.Type = "image2image"
+	if r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url required for %s", spec.Model)
+	}
+	opts := BiRefNetOptions{
+		Model:               r.Variant,
+		OperatingResolution: r.OperatingResolution,
+		OutputFormat:        r.OutputFormat,
+		RefineForeground:    r.RefineForeground,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	spec.Body = map[string]interface{}{"image_url": r.ImageURL}
+	if r.Variant != "" {
+		spec.Body["model"] = r.Variant
 	}
+	if r.OperatingResolution != "" {
+		spec.Body["operating_resolution"] = r.OperatingResolution
+	}
+	if r.OutputFormat != "" {
+		spec.Body["output_format"] = r.OutputFormat
+	}
+	if r.RefineForeground != nil {
+		spec.Body["refine_foreground"] = *r.RefineForeground
+	}
+	r.Model = spec.Model
+	return spec, nil
+}
 
-	return result.(*ImageResponse), nil
+// buildFluxProFill validates a FluxProFillRequest and builds its request body.
+func buildFluxProFill(req interface{}) (*RequestSpec, error) {
+	r := req.(*FluxProFillRequest)
+	spec := &RequestSpec{}
+	spec.Model = "flux-pro/v1/fill"
+	spec.Type = "image2image"
+	if r.ImageURL == "" || r.MaskURL == "" {
+		return nil, fmt.Errorf("image_url and mask_url required for %s", spec.Model)
+	}
+	if r.Prompt == "" {
+		return nil, fmt.Errorf("prompt required for %s", spec.Model)
+	}
+	opts := FluxProFillOptions{
+		NumImages:       r.NumImages,
+		Seed:            r.Seed,
+		SafetyTolerance: r.SafetyTolerance,
+		OutputFormat:    r.OutputFormat,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	spec.Body = map[string]interface{}{
+		"prompt":    r.Prompt,
+		"image_url": r.ImageURL,
+		"mask_url":  r.MaskURL,
+	}
+	if r.NumImages > 0 {
+		spec.Body["num_images"] = r.NumImages
+	}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	if r.SafetyTolerance != "" {
+		spec.Body["safety_tolerance"] = r.SafetyTolerance
+	}
+	if r.OutputFormat != "" {
+		spec.Body["output_format"] = r.OutputFormat
+	}
+	r.Model = spec.Model
+	return spec, nil
 }
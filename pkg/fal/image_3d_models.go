@@ -0,0 +1,54 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+// --- triposr ---
+
+type tripoSRModel struct{}
+
+func (m *tripoSRModel) Define() Model {
+	defaultOpts := &TripoSROptions{}
+	defaults := defaultOpts.GetDefaultValues()
+
+	return Model{
+		Name:        "triposr",
+		Description: "TripoSR - Fast 3D object reconstruction from a single image, as GLB or OBJ",
+		Type:        "image23d",
+		Endpoint:    "/triposr",
+		Options: &TripoSROptions{
+			OutputFormat:       defaults["output_format"].(string),
+			DoRemoveBackground: defaults["do_remove_background"].(*bool),
+			ForegroundRatio:    defaults["foreground_ratio"].(float64),
+			MCResolution:       defaults["mc_resolution"].(int),
+		},
+	}
+}
+
+// --- hunyuan3d-v2 ---
+
+type hunyuan3DModel struct{}
+
+func (m *hunyuan3DModel) Define() Model {
+	defaultOpts := &Hunyuan3DOptions{}
+	defaults := defaultOpts.GetDefaultValues()
+
+	return Model{
+		Name:        "hunyuan3d-v2",
+		Description: "Hunyuan3D 2.0 - Detailed 3D models from a single image, optionally textured, as GLB",
+		Type:        "image23d",
+		Endpoint:    "/hunyuan3d/v2",
+		Options: &Hunyuan3DOptions{
+			NumInferenceSteps: defaults["num_inference_steps"].(int),
+			GuidanceScale:     defaults["guidance_scale"].(float64),
+			OctreeResolution:  defaults["octree_resolution"].(int),
+			TexturedMesh:      defaults["textured_mesh"].(bool),
+		},
+	}
+}
+
+func init() {
+	registerModel(&tripoSRModel{})
+	registerModel(&hunyuan3DModel{})
+}
@@ -76,12 +76,118 @@ func (m *nanoBanana2EditModel) Define() Model {
 	}
 }
 
+// --- ddcolor ---
+
+type ddcolorModel struct{}
+
+func (m *ddcolorModel) Define() Model {
+	return Model{
+		Name:        "ddcolor",
+		Description: "DDColor - Colorize black and white photos",
+		Type:        "image2image",
+		Endpoint:    "/ddcolor",
+		Options:     &DDColorOptions{},
+	}
+}
+
+// --- codeformer ---
+
+type codeformerModel struct{}
+
+func (m *codeformerModel) Define() Model {
+	defaultOpts := &CodeFormerOptions{}
+	defaults := defaultOpts.GetDefaultValues()
+
+	return Model{
+		Name:        "codeformer",
+		Description: "CodeFormer - Restore faces in old, blurry or damaged photos",
+		Type:        "image2image",
+		Endpoint:    "/codeformer",
+		Options: &CodeFormerOptions{
+			Fidelity:       defaults["fidelity"].(float64),
+			Upscaling:      defaults["upscaling"].(float64),
+			FaceUpscale:    defaults["face_upscale"].(*bool),
+			OnlyCenterFace: defaults["only_center_face"].(*bool),
+		},
+	}
+}
+
+// --- esrgan ---
+
+type esrganModel struct{}
+
+func (m *esrganModel) Define() Model {
+	defaultOpts := &ESRGANOptions{}
+	defaults := defaultOpts.GetDefaultValues()
+
+	return Model{
+		Name:        "esrgan",
+		Description: "Real-ESRGAN - Upscale images up to 8x",
+		Type:        "image2image",
+		Endpoint:    "/esrgan",
+		Options: &ESRGANOptions{
+			Scale:        defaults["scale"].(float64),
+			Model:        defaults["model"].(string),
+			Face:         defaults["face"].(*bool),
+			OutputFormat: defaults["output_format"].(string),
+		},
+	}
+}
+
+// --- birefnet ---
+
+type birefnetModel struct{}
+
+func (m *birefnetModel) Define() Model {
+	defaultOpts := &BiRefNetOptions{}
+	defaults := defaultOpts.GetDefaultValues()
+
+	return Model{
+		Name:        "birefnet",
+		Description: "BiRefNet - Remove the background from an image",
+		Type:        "image2image",
+		Endpoint:    "/birefnet/v2",
+		Options: &BiRefNetOptions{
+			Model:               defaults["model"].(string),
+			OperatingResolution: defaults["operating_resolution"].(string),
+			OutputFormat:        defaults["output_format"].(string),
+			RefineForeground:    defaults["refine_foreground"].(*bool),
+		},
+	}
+}
+
+// --- flux-pro/v1/fill ---
+
+type fluxProFillModel struct{}
+
+func (m *fluxProFillModel) Define() Model {
+	defaultOpts := &FluxProFillOptions{}
+	defaults := defaultOpts.GetDefaultValues()
+
+	return Model{
+		Name:        "flux-pro/v1/fill",
+		Description: "FLUX.1 Pro Fill - Repaint the masked part of an image from a prompt",
+		Type:        "image2image",
+		Endpoint:    "/flux-pro/v1/fill",
+		Options: &FluxProFillOptions{
+			NumImages:       defaults["num_images"].(int),
+			SafetyTolerance: defaults["safety_tolerance"].(string),
+			OutputFormat:    defaults["output_format"].(string),
+		},
+	}
+}
+
 func init() {
 	registerModel(&ghiblifyModel{})
 	registerModel(&cartoonifyModel{})
 	registerModel(&flux2ProEditModel{})
 	registerModel(&flux2EditModel{})
 	registerModel(&nanoBanana2EditModel{})
+	registerModel(&ddcolorModel{})
+	registerModel(&codeformerModel{})
+	registerModel(&esrganModel{})
+	registerModel(&birefnetModel{})
+	registerModel(&fluxProFillModel{})
 }
 
 // --- flux-2/edit ---
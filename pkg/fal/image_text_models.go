@@ -0,0 +1,30 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+// --- llava-next ---
+
+type llavaNextModel struct{}
+
+func (m *llavaNextModel) Define() Model {
+	defaultOpts := &LlavaNextOptions{}
+	defaults := defaultOpts.GetDefaultValues()
+
+	return Model{
+		Name:        "llava-next",
+		Description: "LLaVA Next - Vision language model that answers questions about an image",
+		Type:        "image2text",
+		Endpoint:    "/llava-next",
+		Options: &LlavaNextOptions{
+			MaxTokens:   defaults["max_tokens"].(int),
+			Temperature: defaults["temperature"].(float64),
+			TopP:        defaults["top_p"].(float64),
+		},
+	}
+}
+
+func init() {
+	registerModel(&llavaNextModel{})
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Generate3D turns an image into a 3D model using the specified model.
+// It accepts *TripoSRRequest, *Hunyuan3DRequest and any request type
+// registered with RegisterRequestHandler for an image23d model.
+func (c *Client) Generate3D(ctx context.Context, req interface{}) (*Model3DResponse, error) {
+	var progress ProgressCallback
+	if progressable, ok := req.(Progressable); ok {
+		progress = progressable.GetProgress()
+	}
+
+	spec, decode, err := buildRequest(req, "3D", model3DTypes, decodeModel3DResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.executeAsyncWorkflow(ctx, spec.Endpoint, spec.Body, progress, decode)
+	if err != nil {
+		return nil, err // Error already wrapped
+	}
+	resp, ok := result.(*Model3DResponse)
+	if !ok {
+		return nil, fmt.Errorf("decoder for %T returned %T, want *Model3DResponse", req, result)
+	}
+	return resp, nil
+}
+
+// decodeModel3DResponse parses the final response of an image23d model.
+func decodeModel3DResponse(data []byte) (interface{}, error) {
+	var response struct {
+		ModelMesh struct {
+			URL         string `json:"url"`
+			ContentType string `json:"content_type"`
+			FileName    string `json:"file_name"`
+			FileSize    int    `json:"file_size"`
+		} `json:"model_mesh"`
+	}
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse final 3D model response: %w. Body: %s", err, string(data))
+	}
+
+	if response.ModelMesh.URL == "" {
+		return nil, &Error{
+			Code:    "NO_MODEL_URL",
+			Message: "no 3D model URL found in response",
+		}
+	}
+
+	mesh := response.ModelMesh
+	return &Model3DResponse{
+		ModelURL:    mesh.URL,
+		ContentType: mesh.ContentType,
+		FileName:    mesh.FileName,
+		FileSize:    mesh.FileSize,
+		Format:      model3DFormat(mesh.FileName, mesh.URL, mesh.ContentType),
+	}, nil
+}
+
+// model3DFormat tells a 3D file's format from its name, URL or content
+// type, defaulting to GLB, the format every image23d model can return.
+func model3DFormat(fileName, fileURL, contentType string) string {
+	for _, name := range []string{fileName, fileURL} {
+		ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+		if Model3DFormats[ext] {
+			return ext
+		}
+	}
+	if strings.Contains(contentType, "obj") {
+		return "obj"
+	}
+	return "glb"
+}
+
+func init() {
+	mustRegisterRequestHandler(&TripoSRRequest{}, buildTripoSR)
+	mustRegisterRequestHandler(&Hunyuan3DRequest{}, buildHunyuan3D)
+}
+
+// buildTripoSR validates a TripoSRRequest and builds its request body.
+func buildTripoSR(req interface{}) (*RequestSpec, error) {
+	r := req.(*TripoSRRequest)
+	spec := &RequestSpec{Model: "triposr"}
+
+	if r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url is required for %s", spec.Model)
+	}
+	currentOpts := TripoSROptions{
+		OutputFormat:       r.OutputFormat,
+		DoRemoveBackground: r.DoRemoveBackground,
+		ForegroundRatio:    r.ForegroundRatio,
+		MCResolution:       r.MCResolution,
+	}
+	if err := currentOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+
+	spec.Body = map[string]interface{}{
+		"image_url": r.ImageURL,
+	}
+	if r.OutputFormat != "" {
+		spec.Body["output_format"] = r.OutputFormat
+	}
+	if r.DoRemoveBackground != nil {
+		spec.Body["do_remove_background"] = *r.DoRemoveBackground
+	}
+	if r.ForegroundRatio != 0 {
+		spec.Body["foreground_ratio"] = r.ForegroundRatio
+	}
+	if r.MCResolution != 0 {
+		spec.Body["mc_resolution"] = r.MCResolution
+	}
+
+	r.Model = spec.Model
+	return spec, nil
+}
+
+// buildHunyuan3D validates a Hunyuan3DRequest and builds its request body.
+func buildHunyuan3D(req interface{}) (*RequestSpec, error) {
+	r := req.(*Hunyuan3DRequest)
+	spec := &RequestSpec{Model: "hunyuan3d-v2"}
+
+	if r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url is required for %s", spec.Model)
+	}
+	currentOpts := Hunyuan3DOptions{
+		Seed:              r.Seed,
+		NumInferenceSteps: r.NumInferenceSteps,
+		GuidanceScale:     r.GuidanceScale,
+		OctreeResolution:  r.OctreeResolution,
+		TexturedMesh:      r.TexturedMesh,
+	}
+	if err := currentOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+
+	// The endpoint names the image input_image_url
+	spec.Body = map[string]interface{}{
+		"input_image_url": r.ImageURL,
+	}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	if r.NumInferenceSteps != 0 {
+		spec.Body["num_inference_steps"] = r.NumInferenceSteps
+	}
+	if r.GuidanceScale != 0 {
+		spec.Body["guidance_scale"] = r.GuidanceScale
+	}
+	if r.OctreeResolution != 0 {
+		spec.Body["octree_resolution"] = r.OctreeResolution
+	}
+	if r.TexturedMesh {
+		spec.Body["textured_mesh"] = true
+	}
+
+	r.Model = spec.Model
+	return spec, nil
+}
@@ -0,0 +1,87 @@
+package fal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGenerate3D(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			var body map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decoding request: %v", err)
+			}
+			if body["image_url"] != "https://example.com/chair.png" || body["output_format"] != "obj" || body["do_remove_background"] != false {
+				t.Errorf("request body = %v", body)
+			}
+			w.Write([]byte(`{"request_id": "req-1", "response_url": "` + srv.URL + `/requests/req-1"}`))
+		case r.URL.Path == "/requests/req-1/status":
+			w.Write([]byte(`{"status": "COMPLETED"}`))
+		case r.URL.Path == "/requests/req-1":
+			w.Write([]byte(`{"model_mesh": {"url": "https://cdn.example.com/chair.obj", "content_type": "application/octet-stream", "file_name": "chair.obj", "file_size": 1234}}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	if err := SetEndpointOverride("triposr", srv.URL+"/fal-ai/triposr"); err != nil {
+		t.Fatalf("SetEndpointOverride: %v", err)
+	}
+	defer SetEndpointOverride("triposr", "")
+
+	recv, err := NewWebhookReceiver("https://bot.example.com/fal/webhook")
+	if err != nil {
+		t.Fatalf("NewWebhookReceiver: %v", err)
+	}
+	c := NewClient("key", WithHTTPClient(srv.Client()), WithWebhook(recv, 10*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	removeBackground := false
+	resp, err := c.Generate3D(ctx, &TripoSRRequest{
+		BaseModel3DRequest: BaseModel3DRequest{ImageURL: "https://example.com/chair.png"},
+		OutputFormat:       "obj",
+		DoRemoveBackground: &removeBackground,
+	})
+	if err != nil {
+		t.Fatalf("Generate3D: %v", err)
+	}
+	if resp.ModelURL != "https://cdn.example.com/chair.obj" || resp.Format != "obj" || resp.FileSize != 1234 {
+		t.Errorf("response = %+v", resp)
+	}
+
+	bad := &TripoSRRequest{BaseModel3DRequest: BaseModel3DRequest{ImageURL: "https://example.com/chair.png"}, OutputFormat: "stl"}
+	if _, err := c.Generate3D(ctx, bad); err == nil {
+		t.Error("output format stl accepted")
+	}
+	if _, err := c.Generate3D(ctx, &Hunyuan3DRequest{}); err == nil {
+		t.Error("request without image accepted")
+	}
+	if _, err := c.Generate3D(ctx, &VisionRequest{ImageURL: "https://example.com/chair.png"}); err == nil {
+		t.Error("vision request accepted by Generate3D")
+	}
+}
+
+func TestModel3DFormat(t *testing.T) {
+	tests := []struct {
+		fileName, fileURL, contentType, want string
+	}{
+		{"mesh.obj", "", "", "obj"},
+		{"", "https://cdn.example.com/a/mesh.GLB", "", "glb"},
+		{"", "https://cdn.example.com/a/mesh", "model/obj", "obj"},
+		{"", "https://cdn.example.com/a/mesh", "", "glb"},
+	}
+	for _, tt := range tests {
+		if got := model3DFormat(tt.fileName, tt.fileURL, tt.contentType); got != tt.want {
+			t.Errorf("model3DFormat(%q, %q, %q) = %q, want %q", tt.fileName, tt.fileURL, tt.contentType, got, tt.want)
+		}
+	}
+}
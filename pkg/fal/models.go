@@ -4,9 +4,18 @@
 
 package fal
 
+import (
+	"fmt"
+	"strings"
+)
+
 var (
 	// allModels stores all registered models
 	allModels = make(map[string]Model)
+
+	// endpointOverrides maps a model name to the endpoint used in place of
+	// its registered one
+	endpointOverrides = make(map[string]string)
 )
 
 // registerModel registers a model defined by a ModelDefinition
@@ -28,7 +37,13 @@ func GetModel(name, modelType string) (Model, bool) {
 	if model.Type != modelType {
 		return Model{}, false
 	}
-	return model, true
+	return withEndpointOverride(model), true
+}
+
+// IsRegistered reports whether a model of any type is registered as name
+func IsRegistered(name string) bool {
+	_, exists := allModels[name]
+	return exists
 }
 
 // GetModels returns all available models for a command type
@@ -36,8 +51,52 @@ func GetModels(commandType string) (map[string]Model, bool) {
 	models := make(map[string]Model)
 	for name, model := range allModels {
 		if model.Type == commandType {
-			models[name] = model
+			models[name] = withEndpointOverride(model)
 		}
 	}
 	return models, len(models) > 0
 }
+
+// ModelCounts returns the number of registered models for each model type
+func ModelCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, model := range allModels {
+		counts[model.Type]++
+	}
+	return counts
+}
+
+// SetEndpointOverride routes a registered model to endpoint instead of the
+// endpoint it was registered with, e.g. to pin "/kling-video/v2/master/..."
+// while a newer revision is broken upstream. The endpoint is a path under
+// the fal queue or a full URL, like Model.Endpoint. An empty endpoint removes
+// the override. Overrides are meant to be set at startup, before requests are
+// made.
+func SetEndpointOverride(name, endpoint string) error {
+	if _, exists := allModels[name]; !exists {
+		return fmt.Errorf("model not found: %s", name)
+	}
+	if endpoint == "" {
+		delete(endpointOverrides, name)
+		return nil
+	}
+	if !validEndpoint(endpoint) {
+		return fmt.Errorf("invalid endpoint for %s: %s (must start with / or be a full URL)", name, endpoint)
+	}
+	endpointOverrides[name] = endpoint
+	return nil
+}
+
+// validEndpoint reports whether endpoint is a path under the fal queue or a
+// full URL.
+func validEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "/") || strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://")
+}
+
+// withEndpointOverride returns model with its endpoint override applied.
+func withEndpointOverride(model Model) Model {
+	if endpoint, ok := endpointOverrides[model.Name]; ok {
+		model.Endpoint = endpoint
+	}
+	return model
+}
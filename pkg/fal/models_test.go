@@ -0,0 +1,36 @@
+package fal
+
+import "testing"
+
+func TestSetEndpointOverride(t *testing.T) {
+	const name, pinned = "kling-video-text", "/kling-video/v2/master/text-to-video-pinned"
+	orig, ok := GetModel(name, "text2video")
+	if !ok {
+		t.Fatalf("model %s not registered", name)
+	}
+	defer SetEndpointOverride(name, "")
+
+	if err := SetEndpointOverride(name, pinned); err != nil {
+		t.Fatalf("SetEndpointOverride: %v", err)
+	}
+	if m, _ := GetModel(name, "text2video"); m.Endpoint != pinned {
+		t.Errorf("GetModel endpoint = %s, want %s", m.Endpoint, pinned)
+	}
+	if models, _ := GetModels("text2video"); models[name].Endpoint != pinned {
+		t.Errorf("GetModels endpoint = %s, want %s", models[name].Endpoint, pinned)
+	}
+
+	if err := SetEndpointOverride(name, ""); err != nil {
+		t.Fatalf("clearing override: %v", err)
+	}
+	if m, _ := GetModel(name, "text2video"); m.Endpoint != orig.Endpoint {
+		t.Errorf("endpoint after clear = %s, want %s", m.Endpoint, orig.Endpoint)
+	}
+
+	if err := SetEndpointOverride("no-such-model", pinned); err == nil {
+		t.Error("override accepted for unknown model")
+	}
+	if err := SetEndpointOverride(name, "kling-video/v2"); err == nil {
+		t.Error("override accepted for relative endpoint")
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// OptionInfo describes one option of a model's options struct.
+type OptionInfo struct {
+	Name       string   // Option name as sent to fal.ai, e.g. "aspect_ratio"
+	Type       string   // "text", "integer", "number" or "true/false"
+	Default    string   // The model's default value, empty when it has none
+	Allowed    []string // Accepted values when the option is an enum
+	Constraint string   // Rule for other values, e.g. "must be between 0 and 1"
+}
+
+// DescribeOptions lists the options of a model's options struct, such as
+// Model.Options, in field order. Defaults are taken from the struct's values
+// and GetDefaultValues, and the accepted values are found by passing
+// out-of-range values to Validate, so the description follows the code
+// instead of a hand-written help text. Models registered with RegisterModel
+// list their option defaults. It returns nil for models without options.
+func DescribeOptions(opts interface{}) []OptionInfo {
+	if generic, ok := opts.(GenericOptions); ok {
+		return describeGenericOptions(generic)
+	}
+	v := reflect.ValueOf(opts)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var defaults map[string]interface{}
+	if mo, ok := opts.(ModelOptions); ok {
+		defaults = mo.GetDefaultValues()
+	}
+
+	var infos []OptionInfo
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		info := OptionInfo{Name: name, Type: optionType(field.Type)}
+		if info.Type == "" {
+			continue
+		}
+		if fv := v.Field(i); !fv.IsZero() {
+			info.Default = formatOptionValue(fv.Interface())
+		} else if d, ok := defaults[name]; ok {
+			info.Default = formatOptionValue(d)
+		}
+		info.Allowed, info.Constraint = probeOption(v, i, name)
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// describeGenericOptions lists the defaults of a model registered with
+// RegisterModel, sorted by name.
+func describeGenericOptions(opts GenericOptions) []OptionInfo {
+	infos := make([]OptionInfo, 0, len(opts))
+	for name, v := range opts {
+		info := OptionInfo{Name: name, Default: formatOptionValue(v)}
+		if v != nil {
+			info.Type = optionType(reflect.TypeOf(v))
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// optionType names the kind of value a field takes, or returns "" for
+// fields that are not plain options.
+func optionType(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "text"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "true/false"
+	}
+	return ""
+}
+
+// probeOption sets field i of a copy of opts to values Validate should
+// reject and returns the accepted values or rule from the resulting
+// ValidationError.
+func probeOption(opts reflect.Value, i int, name string) ([]string, string) {
+	if _, ok := reflect.New(opts.Type()).Interface().(ModelOptions); !ok {
+		return nil, ""
+	}
+	t := opts.Field(i).Type()
+	elem := t
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+
+	var probes []reflect.Value
+	switch elem.Kind() {
+	case reflect.String:
+		probes = append(probes, reflect.ValueOf("\x00").Convert(elem))
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		probes = append(probes, reflect.ValueOf(-1<<30).Convert(elem), reflect.ValueOf(1<<30).Convert(elem))
+	case reflect.Float32, reflect.Float64:
+		probes = append(probes, reflect.ValueOf(-1e9).Convert(elem), reflect.ValueOf(1e9).Convert(elem))
+	}
+
+	for _, probe := range probes {
+		cp := reflect.New(opts.Type())
+		cp.Elem().Set(opts)
+		if t.Kind() == reflect.Ptr {
+			p := reflect.New(elem)
+			p.Elem().Set(probe)
+			probe = p
+		}
+		cp.Elem().Field(i).Set(probe)
+
+		var verr *ValidationError
+		if err := cp.Interface().(ModelOptions).Validate(); errors.As(err, &verr) && verr.Field == name {
+			return verr.Allowed, verr.Constraint
+		}
+	}
+	return nil, ""
+}
+
+// formatOptionValue renders a default value, following pointers.
+func formatOptionValue(val interface{}) string {
+	rv := reflect.ValueOf(val)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(rv.Interface())
+}
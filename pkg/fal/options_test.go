@@ -0,0 +1,39 @@
+package fal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDescribeOptions(t *testing.T) {
+	speed := 1.0
+	infos := DescribeOptions(&MinimaxTTSOptions{Speed: &speed, Format: "mp3"})
+	byName := make(map[string]OptionInfo)
+	var names []string
+	for _, info := range infos {
+		byName[info.Name] = info
+		names = append(names, info.Name)
+	}
+	want := []string{"speed", "vol", "pitch", "emotion", "sample_rate", "bitrate", "format", "channel"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("names = %v, want %v", names, want)
+	}
+
+	if got := byName["speed"]; got.Type != "number" || got.Default != "1" || got.Constraint != "must be between 0.5 and 2.0" {
+		t.Errorf("speed = %+v", got)
+	}
+	if got := byName["pitch"]; got.Type != "integer" || got.Default != "" || got.Constraint != "must be between -12 and 12" {
+		t.Errorf("pitch = %+v", got)
+	}
+	// Defaults missing from the struct come from GetDefaultValues
+	if got := byName["channel"]; got.Default != "1" || !reflect.DeepEqual(got.Allowed, []string{"1", "2"}) {
+		t.Errorf("channel = %+v", got)
+	}
+	if got := byName["format"]; got.Default != "mp3" || !reflect.DeepEqual(got.Allowed, []string{"flac", "mp3", "pcm"}) {
+		t.Errorf("format = %+v", got)
+	}
+
+	if DescribeOptions(nil) != nil || DescribeOptions((*MinimaxTTSOptions)(nil)) != nil {
+		t.Error("DescribeOptions returned options for a model without options")
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// pingURL is the URL Ping requests; any answer from fal's queue will do.
+var pingURL = "https://queue.fal.run/"
+
+// Ping checks that fal's API is reachable and returns how long it took to
+// answer. It sends one request without retries and starts no job, so it costs
+// nothing. Only network errors and 5xx responses count as unreachable.
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pingURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Key "+c.apiKey)
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	resp.Body.Close()
+	elapsed := time.Since(start)
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return elapsed, fmt.Errorf("fal answered with status %d", resp.StatusCode)
+	}
+	return elapsed, nil
+}
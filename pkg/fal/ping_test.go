@@ -0,0 +1,31 @@
+package fal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	status := http.StatusNotFound
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	defer func(u string) { pingURL = u }(pingURL)
+	pingURL = srv.URL
+
+	c := NewClient("key", WithHTTPClient(srv.Client()))
+	if _, err := c.Ping(context.Background()); err != nil {
+		t.Errorf("Ping answered with 404 = %v, want nil", err)
+	}
+	status = http.StatusServiceUnavailable
+	if _, err := c.Ping(context.Background()); err == nil {
+		t.Error("Ping answered with 503 succeeded")
+	}
+	srv.Close()
+	if _, err := c.Ping(context.Background()); err == nil {
+		t.Error("Ping of a closed server succeeded")
+	}
+}
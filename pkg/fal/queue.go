@@ -16,136 +16,212 @@ import (
 
 // pollQueueStatus polls the queue status until completion or error
 func (c *Client) pollQueueStatus(ctx context.Context, queueResp QueueResponse, progress ProgressCallback) (*QueueResponse, error) {
-	ticker := time.NewTicker(5 * time.Second)
+	// With webhooks, polling is only the fallback for a webhook that never
+	// arrives
+	interval := 5 * time.Second
+	var completed <-chan struct{}
+	if c.webhook != nil {
+		var stop func()
+		completed, stop = c.webhook.wait(queueResp.requestID())
+		defer stop()
+		interval = c.webhookPoll
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	lastPosition := queueResp.Position
 	lastETA := queueResp.ETA
 
-	// Construct the status URL with logs parameter
-	statusURL := queueResp.ResponseURL + "/status?logs=1"
-	if c.debug {
-		fmt.Printf("DEBUG - Initial status URL: %s\n", statusURL)
+	statusURL := queueResp.statusURL()
+	if c.debugEnabled() {
+		c.debugf("DEBUG - Initial status URL: %s\n", statusURL)
 	}
 
 	for {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return nil, c.abandon(ctx, queueResp)
+		case <-completed:
+			// fal posted the webhook; check the status now, and keep polling if
+			// the request did not complete after all
+			completed = nil
 		case <-ticker.C:
-			// Create request to check status
-			req, err := http.NewRequestWithContext(ctx, "GET", statusURL, nil)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create status request: %v", err)
-			}
-			req.Header.Set("Authorization", "Key "+c.apiKey)
-
-			// Make request
-			resp, err := c.httpClient.Do(req)
-			if err != nil {
-				return nil, fmt.Errorf("failed to check status: %v", err)
-			}
+		}
 
-			if c.debug {
-				fmt.Printf("DEBUG - Queue Status Poll:\n")
-				fmt.Printf("  URL: %s\n", statusURL)
-				fmt.Printf("  Status Code: %d\n", resp.StatusCode)
-			}
+		// Check status; transient failures are retried by makeRequest
+		resp, err := c.makeRequest(ctx, "GET", statusURL, nil)
+		if err != nil && ctx.Err() != nil {
+			return nil, c.abandon(ctx, queueResp)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to check status: %v", err)
+		}
 
-			// Read the response body
-			body, err := io.ReadAll(resp.Body)
-			resp.Body.Close()
-			if err != nil {
-				return nil, fmt.Errorf("failed to read response body: %v", err)
-			}
+		if c.debugEnabled() {
+			c.debugf("DEBUG - Queue Status Poll:\n")
+			c.debugf("  URL: %s\n", statusURL)
+			c.debugf("  Status Code: %d\n", resp.StatusCode)
+		}
 
-			if c.debug {
-				fmt.Printf("  Response Body: %s\n", string(body))
-			}
+		// Read the response body
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %v", err)
+		}
 
-			// Check for HTTP errors (excluding 202 Accepted)
-			if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
-				return nil, fmt.Errorf("queue status check failed with status code: %d, response: %s", resp.StatusCode, string(body))
-			}
+		if c.debugEnabled() {
+			c.debugf("  Response Body: %s\n", string(body))
+		}
 
-			// Parse response
-			var statusResp struct {
-				QueueResponse
-				Logs []struct {
-					Message   string `json:"message"`
-					Level     string `json:"level"`
-					Source    string `json:"source"`
-					Timestamp string `json:"timestamp"`
-				} `json:"logs"`
-			}
-			if err := json.Unmarshal(body, &statusResp); err != nil {
-				return nil, fmt.Errorf("failed to decode status response: %v", err)
-			}
+		// Check for HTTP errors (excluding 202 Accepted)
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+			return nil, fmt.Errorf("queue status check failed: %w", newAPIError(resp.StatusCode, body))
+		}
 
-			if c.debug {
-				fmt.Printf("  Queue ID: %s\n", statusResp.QueueID)
-				fmt.Printf("  Status: %s\n", statusResp.Status)
-				fmt.Printf("  Position: %d\n", statusResp.Position)
-				fmt.Printf("  ETA: %d seconds\n", statusResp.ETA)
-				if len(statusResp.Logs) > 0 {
-					fmt.Printf("  Logs:\n")
-					for _, log := range statusResp.Logs {
-						fmt.Printf("    [%s] %s: %s\n", log.Timestamp, log.Level, log.Message)
-					}
-				}
-			}
+		// Parse response
+		var statusResp struct {
+			QueueResponse
+			Logs []struct {
+				Message   string `json:"message"`
+				Level     string `json:"level"`
+				Source    string `json:"source"`
+				Timestamp string `json:"timestamp"`
+			} `json:"logs"`
+		}
+		if err := json.Unmarshal(body, &statusResp); err != nil {
+			return nil, fmt.Errorf("failed to decode status response: %v", err)
+		}
 
-			// Send log messages to the progress callback
-			if progress != nil && len(statusResp.Logs) > 0 {
+		if c.debugEnabled() {
+			c.debugf("  Queue ID: %s\n", statusResp.QueueID)
+			c.debugf("  Status: %s\n", statusResp.Status)
+			c.debugf("  Position: %d\n", statusResp.Position)
+			c.debugf("  ETA: %d seconds\n", statusResp.ETA)
+			if len(statusResp.Logs) > 0 {
+				c.debugf("  Logs:\n")
 				for _, log := range statusResp.Logs {
-					progress.OnLogMessage(log.Message)
+					c.debugf("    [%s] %s: %s\n", log.Timestamp, log.Level, log.Message)
 				}
 			}
+		}
 
-			// Check for completion
-			if statusResp.Status == "COMPLETED" {
-				if c.debug {
-					fmt.Printf("DEBUG - Queue completed successfully\n")
-				}
-				// Set the base URL for fetching the final result
-				statusResp.ResponseURL = strings.TrimSuffix(statusURL, "/status?logs=1")
-				return &statusResp.QueueResponse, nil
+		// Send log messages to the progress callback
+		if progress != nil && len(statusResp.Logs) > 0 {
+			for _, log := range statusResp.Logs {
+				progress.OnLogMessage(log.Message)
 			}
+		}
 
-			// Check for error
-			if statusResp.Status == "FAILED" {
-				if c.debug {
-					fmt.Printf("DEBUG - Queue failed\n")
-				}
-				return nil, &Error{
-					Code:    "GENERATION_FAILED",
-					Message: "image generation failed",
-				}
+		// Check for completion
+		if statusResp.Status == "COMPLETED" {
+			if c.debugEnabled() {
+				c.debugf("DEBUG - Queue completed successfully\n")
 			}
+			// Fetch the final result from the URL the request was queued under
+			statusResp.ResponseURL = queueResp.ResponseURL
+			return &statusResp.QueueResponse, nil
+		}
 
-			// Notify about status changes
-			if progress != nil {
-				progress.OnProgress(statusResp.Status)
+		// Check for error
+		if statusResp.Status == "FAILED" {
+			if c.debugEnabled() {
+				c.debugf("DEBUG - Queue failed\n")
+			}
+			return nil, &Error{
+				Code:    "GENERATION_FAILED",
+				Message: "image generation failed",
 			}
+		}
 
-			// Notify progress if position or ETA changed
-			if progress != nil && (statusResp.Position != lastPosition || statusResp.ETA != lastETA) {
-				if c.debug {
-					fmt.Printf("DEBUG - Queue progress update:\n")
-					fmt.Printf("  Position changed: %d -> %d\n", lastPosition, statusResp.Position)
-					fmt.Printf("  ETA changed: %d -> %d seconds\n", lastETA, statusResp.ETA)
-				}
-				progress.OnQueueUpdate(statusResp.Position, time.Duration(statusResp.ETA)*time.Second)
-				lastPosition = statusResp.Position
-				lastETA = statusResp.ETA
+		// Notify about status changes
+		if progress != nil {
+			progress.OnProgress(statusResp.Status)
+		}
+
+		// Notify progress if position or ETA changed
+		if progress != nil && (statusResp.Position != lastPosition || statusResp.ETA != lastETA) {
+			if c.debugEnabled() {
+				c.debugf("DEBUG - Queue progress update:\n")
+				c.debugf("  Position changed: %d -> %d\n", lastPosition, statusResp.Position)
+				c.debugf("  ETA changed: %d -> %d seconds\n", lastETA, statusResp.ETA)
 			}
+			progress.OnQueueUpdate(statusResp.Position, time.Duration(statusResp.ETA)*time.Second)
+			lastPosition = statusResp.Position
+			lastETA = statusResp.ETA
 		}
 	}
 }
 
+// abandon stops the request at fal once ctx is done, so an abandoned job
+// does not keep running, and costing, without anyone waiting for it. It
+// returns the error of ctx.
+func (c *Client) abandon(ctx context.Context, queueResp QueueResponse) error {
+	if leftForResume(ctx) {
+		// The caller shuts down and resumes the request after its restart
+		return ctx.Err()
+	}
+	cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 15*time.Second)
+	defer cancel()
+	if err := c.Cancel(cancelCtx, queueResp.ResponseURL); err != nil && c.debugEnabled() {
+		c.debugf("DEBUG - Failed to cancel abandoned request: %v\n", err)
+	}
+	return ctx.Err()
+}
+
+// statusURL returns the URL to poll the status of the request at, with its
+// logs. fal names it in the queue response; older responses only carry the
+// response URL, which it is derived from.
+func (q QueueResponse) statusURL() string {
+	statusURL := q.StatusURL
+	if statusURL == "" {
+		statusURL = q.ResponseURL + "/status"
+	}
+	sep := "?"
+	if strings.Contains(statusURL, "?") {
+		sep = "&"
+	}
+	return statusURL + sep + "logs=1"
+}
+
 // notifyQueuePosition sends a queue position update through the progress callback
 func (c *Client) notifyQueuePosition(_ context.Context, queueResp QueueResponse, progress ProgressCallback) {
 	if progress != nil {
 		progress.OnQueueUpdate(queueResp.Position, time.Duration(queueResp.ETA)*time.Second)
 	}
 }
+
+// Cancel asks fal to cancel a queued request, given the response URL from its
+// queue response. Requests that already completed return an *Error with code
+// ALREADY_COMPLETED. Requests that are already running may still finish.
+func (c *Client) Cancel(ctx context.Context, responseURL string) error {
+	if responseURL == "" {
+		return fmt.Errorf("response URL is required")
+	}
+
+	resp, err := c.makeRequest(ctx, "PUT", responseURL+"/cancel", nil)
+	if err != nil {
+		return fmt.Errorf("failed to cancel request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read cancel response: %w", err)
+	}
+	if c.debugEnabled() {
+		c.debugf("DEBUG - Cancel response: %s\n", string(body))
+	}
+
+	var cancelResp struct {
+		Status string `json:"status"`
+	}
+	json.Unmarshal(body, &cancelResp)
+	if cancelResp.Status == "ALREADY_COMPLETED" {
+		return &Error{Code: cancelResp.Status, Message: "request already completed"}
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("cancel failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package fal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("method = %s, want PUT", r.Method)
+		}
+		switch r.URL.Path {
+		case "/requests/queued/cancel":
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"status":"CANCELLATION_REQUESTED"}`))
+		case "/requests/done/cancel":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"ALREADY_COMPLETED"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient("key", WithHTTPClient(srv.Client()))
+	ctx := context.Background()
+	if err := c.Cancel(ctx, srv.URL+"/requests/queued"); err != nil {
+		t.Errorf("Cancel(queued) = %v, want nil", err)
+	}
+	var falErr *Error
+	if err := c.Cancel(ctx, srv.URL+"/requests/done"); !errors.As(err, &falErr) || falErr.Code != "ALREADY_COMPLETED" {
+		t.Errorf("Cancel(done) = %v, want ALREADY_COMPLETED", err)
+	}
+	if err := c.Cancel(ctx, srv.URL+"/requests/missing"); err == nil {
+		t.Error("Cancel(missing) succeeded")
+	}
+}
+
+func TestPollStatusURL(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			// The status is named separately from the response, as fal
+			// does for models on a subpath
+			w.Write([]byte(`{"request_id": "req-1", "response_url": "` + srv.URL + `/requests/req-1", "status_url": "` + srv.URL + `/queue/req-1/status"}`))
+		case r.URL.Path == "/queue/req-1/status":
+			if r.URL.Query().Get("logs") != "1" {
+				t.Errorf("status polled without logs: %s", r.URL)
+			}
+			w.Write([]byte(`{"status": "COMPLETED"}`))
+		case r.URL.Path == "/requests/req-1":
+			w.Write([]byte(`{"images": [{"url": "https://example.com/out.png"}]}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// A webhook receiver nothing posts to makes the client poll quickly
+	recv, err := NewWebhookReceiver("https://bot.example.com/fal/webhook")
+	if err != nil {
+		t.Fatalf("NewWebhookReceiver: %v", err)
+	}
+	c := NewClient("key", WithHTTPClient(srv.Client()), WithWebhook(recv, 10*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := c.GenerateImage(ctx, &GenericRequest{Endpoint: srv.URL + "/fal-ai/test/dev", Type: "text2image", Body: map[string]interface{}{"prompt": "x"}})
+	if err != nil {
+		t.Fatalf("GenerateImage: %v", err)
+	}
+	if len(resp.Images) != 1 || resp.Images[0].URL != "https://example.com/out.png" {
+		t.Errorf("images = %+v", resp.Images)
+	}
+}
+
+func TestQueueStatusURL(t *testing.T) {
+	tests := []struct {
+		q    QueueResponse
+		want string
+	}{
+		{QueueResponse{ResponseURL: "https://queue.fal.run/fal-ai/x/requests/1"}, "https://queue.fal.run/fal-ai/x/requests/1/status?logs=1"},
+		{QueueResponse{ResponseURL: "https://queue.fal.run/fal-ai/x/requests/1", StatusURL: "https://queue.fal.run/fal-ai/x/requests/1/status"}, "https://queue.fal.run/fal-ai/x/requests/1/status?logs=1"},
+		{QueueResponse{StatusURL: "https://queue.fal.run/s?id=1"}, "https://queue.fal.run/s?id=1&logs=1"},
+	}
+	for _, tt := range tests {
+		if got := tt.q.statusURL(); got != tt.want {
+			t.Errorf("statusURL(%+v) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
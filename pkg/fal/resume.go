@@ -0,0 +1,79 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+import (
+	"context"
+	"sync"
+)
+
+// ResumeOptions lets the requests made with a context outlive a restart of
+// the caller: their response URLs are reported so they can be stored, and a
+// stored one is polled again instead of sending the request anew.
+type ResumeOptions struct {
+	// URL is the response URL of a request queued before the restart. The
+	// first request made with the context polls it for its result instead
+	// of being sent again, so it is neither run nor charged twice.
+	URL string
+	// OnQueued, if set, is called with the response URL of every request
+	// fal queues.
+	OnQueued func(responseURL string)
+	// Shutdown is done when the caller shuts down. Requests whose context
+	// ends because of it are left running at fal, to be resumed, instead of
+	// being cancelled.
+	Shutdown context.Context
+}
+
+type resumeKey struct{}
+
+// resumeState is the ResumeOptions of a context, with whether URL was used.
+type resumeState struct {
+	opts ResumeOptions
+
+	mu      sync.Mutex
+	resumed bool
+}
+
+// WithResume returns a context whose requests are resumable as described by
+// opts.
+func WithResume(ctx context.Context, opts ResumeOptions) context.Context {
+	return context.WithValue(ctx, resumeKey{}, &resumeState{opts: opts})
+}
+
+// resumeOf returns the resume state of ctx, or nil.
+func resumeOf(ctx context.Context) *resumeState {
+	s, _ := ctx.Value(resumeKey{}).(*resumeState)
+	return s
+}
+
+// takeResumeURL returns the response URL the next request should poll
+// instead of being sent, once.
+func takeResumeURL(ctx context.Context) string {
+	s := resumeOf(ctx)
+	if s == nil || s.opts.URL == "" {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resumed {
+		return ""
+	}
+	s.resumed = true
+	return s.opts.URL
+}
+
+// notifyQueued reports the response URL of a queued request.
+func notifyQueued(ctx context.Context, responseURL string) {
+	if s := resumeOf(ctx); s != nil && s.opts.OnQueued != nil {
+		s.opts.OnQueued(responseURL)
+	}
+}
+
+// leftForResume reports whether a request whose context is done should be
+// left running at fal because the caller is shutting down.
+func leftForResume(ctx context.Context) bool {
+	s := resumeOf(ctx)
+	return s != nil && s.opts.Shutdown != nil && s.opts.Shutdown.Err() != nil
+}
@@ -0,0 +1,82 @@
+package fal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResume(t *testing.T) {
+	var posts, cancels atomic.Int32
+	var done atomic.Bool
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			posts.Add(1)
+			w.Write([]byte(`{"request_id": "new", "response_url": "` + srv.URL + `/requests/new"}`))
+		case r.Method == http.MethodPut:
+			cancels.Add(1)
+			w.Write([]byte(`{"status": "CANCELLATION_REQUESTED"}`))
+		case r.URL.Path == "/requests/old/status", r.URL.Path == "/requests/new/status":
+			if done.Load() {
+				w.Write([]byte(`{"status": "COMPLETED"}`))
+			} else {
+				w.Write([]byte(`{"status": "IN_PROGRESS"}`))
+			}
+		default:
+			w.Write([]byte(`{"images": [{"url": "https://example.com` + r.URL.Path + `.png"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	// A webhook receiver nothing posts to makes the client poll quickly
+	recv, err := NewWebhookReceiver("https://bot.example.com/fal/webhook")
+	if err != nil {
+		t.Fatalf("NewWebhookReceiver: %v", err)
+	}
+	c := NewClient("key", WithHTTPClient(srv.Client()), WithWebhook(recv, 10*time.Millisecond))
+	req := func() *GenericRequest {
+		return &GenericRequest{Endpoint: srv.URL + "/fal-ai/test", Type: "text2image", Body: map[string]interface{}{"prompt": "x"}}
+	}
+
+	// A request left running at shutdown is not cancelled
+	var queued string
+	shutdown, stop := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(WithResume(context.Background(), ResumeOptions{
+		OnQueued: func(url string) { queued = url },
+		Shutdown: shutdown,
+	}))
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		stop()
+		cancel()
+	}()
+	if _, err := c.GenerateImage(ctx, req()); err == nil {
+		t.Fatal("GenerateImage succeeded after shutdown")
+	}
+	if queued != srv.URL+"/requests/new" {
+		t.Errorf("OnQueued got %q", queued)
+	}
+	if cancels.Load() != 0 {
+		t.Error("request was cancelled at shutdown")
+	}
+
+	// After the restart the stored request is polled, not sent again
+	done.Store(true)
+	ctx = WithResume(context.Background(), ResumeOptions{URL: srv.URL + "/requests/old"})
+	resp, err := c.GenerateImage(ctx, req())
+	if err != nil {
+		t.Fatalf("resumed GenerateImage: %v", err)
+	}
+	if posts.Load() != 1 || resp.Images[0].URL != "https://example.com/requests/old.png" {
+		t.Errorf("posts = %d, images = %+v, want the result of the resumed request", posts.Load(), resp.Images)
+	}
+	// Later requests with the context are sent as usual
+	if _, err := c.GenerateImage(ctx, req()); err != nil || posts.Load() != 2 {
+		t.Errorf("second GenerateImage: %v, posts = %d", err, posts.Load())
+	}
+}
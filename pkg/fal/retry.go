@@ -0,0 +1,109 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how requests to fal are retried after transient
+// failures: 429 and 5xx responses, and network errors of requests that are
+// safe to repeat.
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts per request, including the first; 1 disables retries
+	BaseDelay   time.Duration // Delay before the first retry, doubled for each further one
+	MaxDelay    time.Duration // Longest delay between attempts
+}
+
+// DefaultRetryPolicy is the retry policy of clients created without
+// WithRetry.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   time.Second,
+	MaxDelay:    30 * time.Second,
+}
+
+// DefaultRequestTimeout is how long a single HTTP request to fal may take,
+// unless changed with WithRequestTimeout. It bounds each attempt, not the
+// job, which runs until its context is done.
+const DefaultRequestTimeout = 30 * time.Second
+
+// WithRetry sets how the client retries requests after transient failures.
+func WithRetry(p RetryPolicy) ClientOption {
+	return func(c *Client) {
+		if p.MaxAttempts < 1 {
+			p.MaxAttempts = 1
+		}
+		c.retry = p
+	}
+}
+
+// WithRequestTimeout sets how long a single HTTP request to fal may take,
+// including reading its response. It applies to the HTTP client set with
+// WithHTTPClient too.
+func WithRequestTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.requestTimeout = d
+	}
+}
+
+// retryableStatus reports whether a response status is worth retrying.
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before retry number attempt (1 for the first
+// retry): the base delay doubled per retry, capped at the maximum, with
+// jitter so clients that failed together do not retry together.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryAfter returns the delay a response asks for in its Retry-After
+// header, either in seconds or as a date.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// sleepCtx waits for d or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
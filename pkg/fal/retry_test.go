@@ -0,0 +1,106 @@
+package fal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMakeRequestRetry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		switch r.URL.Path {
+		case "/flaky":
+			if n < 3 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{}`))
+		case "/bad":
+			w.WriteHeader(http.StatusBadRequest)
+		case "/slow":
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	policy := RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+	c := NewClient("key", WithHTTPClient(srv.Client()), WithRetry(policy))
+	ctx := context.Background()
+
+	tests := []struct {
+		path      string
+		wantCode  int
+		wantCalls int32
+	}{
+		{"/flaky", http.StatusOK, 3},
+		{"/bad", http.StatusBadRequest, 1},
+		// Retry-After beyond MaxDelay gives up instead of waiting less
+		{"/slow", http.StatusTooManyRequests, 1},
+	}
+	for _, tt := range tests {
+		calls.Store(0)
+		resp, err := c.makeRequest(ctx, "POST", srv.URL+tt.path, map[string]string{"prompt": "x"})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantCode || calls.Load() != tt.wantCalls {
+			t.Errorf("%s: status %d after %d calls, want %d after %d", tt.path, resp.StatusCode, calls.Load(), tt.wantCode, tt.wantCalls)
+		}
+	}
+
+	// Without retries a transient failure is returned as is
+	calls.Store(0)
+	c = NewClient("key", WithHTTPClient(srv.Client()), WithRetry(RetryPolicy{MaxAttempts: 1}))
+	resp, err := c.makeRequest(ctx, "GET", srv.URL+"/flaky", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Errorf("no retries: status %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"7", 7 * time.Second, true},
+		{now.Add(time.Minute).Format(http.TimeFormat), time.Minute, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+		got, ok := retryAfter(resp, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 6: 5 * time.Second} {
+		for i := 0; i < 20; i++ {
+			if d := p.backoff(attempt); d < want/2 || d > want {
+				t.Errorf("backoff(%d) = %v, want within [%v, %v]", attempt, d, want/2, want)
+			}
+		}
+	}
+}
@@ -11,256 +11,271 @@ import (
 )
 
 // GenerateSpeech generates speech from text using the specified model
-// It accepts specific request types like *MinimaxTTSRequest.
+// It accepts specific request types like *MinimaxTTSRequest, and any request
+// type registered with RegisterRequestHandler for a speech or audio model.
 func (c *Client) GenerateSpeech(ctx context.Context, req interface{}) (*AudioResponse, error) {
-	var modelName string
-	var endpoint string
-	var reqBody map[string]interface{}
 	var progress ProgressCallback
-
-	// Extract progress callback if available
 	if progressable, ok := req.(Progressable); ok {
 		progress = progressable.GetProgress()
 	}
 
-	// Determine model name, endpoint and create request body based on request type
-	switch r := req.(type) {
-	case *MinimaxTTSRequest:
-		modelName = "minimax-tts/text-to-speech"
-
-		// Validate options based on request values
-		currentOpts := MinimaxTTSOptions{
-			Speed:      r.Speed,
-			Vol:        r.Vol,
-			Pitch:      r.Pitch,
-			Emotion:    r.Emotion,
-			SampleRate: r.SampleRate,
-			Bitrate:    r.Bitrate,
-			Format:     r.Format,
-			Channel:    r.Channel,
-		}
-		if err := currentOpts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
+	spec, decode, err := buildRequest(req, "speech", speechModelTypes, decodeAudioResponse)
+	if err != nil {
+		return nil, err
+	}
 
-		// Build nested request body structure
-		voiceSetting := make(map[string]interface{})
-		voiceSetting["voice_id"] = r.VoiceID // Always include voice ID
-		if r.Speed != nil {
-			voiceSetting["speed"] = *r.Speed
-		}
-		if r.Vol != nil {
-			voiceSetting["vol"] = *r.Vol
-		}
-		if r.Pitch != nil {
-			voiceSetting["pitch"] = *r.Pitch
-		}
-		if r.Emotion != "" {
-			voiceSetting["emotion"] = r.Emotion
-		}
+	// Execute the workflow
+	result, err := c.executeAsyncWorkflow(ctx, spec.Endpoint, spec.Body, progress, decode)
+	if err != nil {
+		return nil, err // Error already wrapped
+	}
+	resp, ok := result.(*AudioResponse)
+	if !ok {
+		return nil, fmt.Errorf("decoder for %T returned %T, want *AudioResponse", req, result)
+	}
+	return resp, nil
+}
 
-		audioSetting := make(map[string]interface{})
-		if r.SampleRate != "" {
-			audioSetting["sample_rate"] = r.SampleRate
-		}
-		if r.Bitrate != "" {
-			audioSetting["bitrate"] = r.Bitrate
-		}
-		if r.Format != "" {
-			audioSetting["format"] = r.Format
-		}
-		if r.Channel != "" {
-			audioSetting["channel"] = r.Channel
-		}
+// decodeAudioResponse parses the final response of a speech model.
+func decodeAudioResponse(data []byte) (interface{}, error) {
+	var response struct {
+		Audio struct {
+			URL         string `json:"url"`
+			ContentType string `json:"content_type"`
+			FileName    string `json:"file_name"`
+			FileSize    int    `json:"file_size"`
+		} `json:"audio"`
+		Duration float64 `json:"duration"` // Fal uses duration now
+	}
 
-		reqBody = map[string]interface{}{"text": r.Text}
-		if len(voiceSetting) > 1 { // Only add if more than just voice_id
-			reqBody["voice_setting"] = voiceSetting
-		}
-		if len(audioSetting) > 0 {
-			reqBody["audio_setting"] = audioSetting
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse final audio response: %w. Body: %s", err, string(data))
+	}
+
+	if response.Audio.URL == "" {
+		return nil, &Error{
+			Code:    "NO_AUDIO_URL",
+			Message: "no audio URL found in response",
 		}
+	}
 
-		r.Model = modelName // Set model name internally
+	contentType := response.Audio.ContentType
+	if contentType == "" {
+		contentType = "audio/mpeg" // Default if missing
+	}
 
-	case *ElevenLabsTTSRequest:
-		modelName = "elevenlabs/tts/turbo-v2.5"
+	return &AudioResponse{
+		AudioURL:    response.Audio.URL,
+		ContentType: contentType,
+		FileName:    response.Audio.FileName,
+		FileSize:    response.Audio.FileSize,
+		Duration:    response.Duration, // Use the float duration
+	}, nil
+}
 
-		// Validate options
-		currentOpts := ElevenLabsTTSOptions{
-			Voice:           r.Voice,
-			Stability:       r.Stability,
-			SimilarityBoost: r.SimilarityBoost,
-			Style:           r.Style,
-			Speed:           r.Speed,
-			Timestamps:      r.Timestamps,
-			LanguageCode:    r.LanguageCode,
-		}
-		if err := currentOpts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
+func init() {
+	mustRegisterRequestHandler(&MinimaxTTSRequest{}, buildMinimaxTTS)
+	mustRegisterRequestHandler(&ElevenLabsTTSRequest{}, buildElevenLabsTTS)
+	mustRegisterRequestHandler(&ElevenLabsVoiceChangerRequest{}, buildElevenLabsVoiceChanger)
+	mustRegisterRequestHandler(&AudioIsolationRequest{}, buildAudioIsolation)
+}
 
-		// Build request body
-		reqBody = map[string]interface{}{
-			"text": r.Text,
-		}
+// buildMinimaxTTS validates a MinimaxTTSRequest and builds its request body.
+func buildMinimaxTTS(req interface{}) (*RequestSpec, error) {
+	r := req.(*MinimaxTTSRequest)
+	spec := &RequestSpec{}
+	spec.Model = "minimax-tts/text-to-speech"
+
+	// Validate options based on request values
+	currentOpts := MinimaxTTSOptions{
+		Speed:      r.Speed,
+		Vol:        r.Vol,
+		Pitch:      r.Pitch,
+		Emotion:    r.Emotion,
+		SampleRate: r.SampleRate,
+		Bitrate:    r.Bitrate,
+		Format:     r.Format,
+		Channel:    r.Channel,
+	}
+	if err := currentOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
 
-		// Add voice (required)
-		if r.Voice != "" {
-			reqBody["voice"] = r.Voice
-		} else {
-			reqBody["voice"] = "Rachel" // Default voice
-		}
+	// Build nested request body structure
+	voiceSetting := make(map[string]interface{})
+	voiceSetting["voice_id"] = r.VoiceID // Always include voice ID
+	if r.Speed != nil {
+		voiceSetting["speed"] = *r.Speed
+	}
+	if r.Vol != nil {
+		voiceSetting["vol"] = *r.Vol
+	}
+	if r.Pitch != nil {
+		voiceSetting["pitch"] = *r.Pitch
+	}
+	if r.Emotion != "" {
+		voiceSetting["emotion"] = r.Emotion
+	}
 
-		// Add optional parameters
-		if r.Stability != nil {
-			reqBody["stability"] = *r.Stability
-		}
-		if r.SimilarityBoost != nil {
-			reqBody["similarity_boost"] = *r.SimilarityBoost
-		}
-		if r.Style != nil {
-			reqBody["style"] = *r.Style
-		}
-		if r.Speed != nil {
-			reqBody["speed"] = *r.Speed
-		}
-		if r.Timestamps != nil {
-			reqBody["timestamps"] = *r.Timestamps
-		}
-		if r.LanguageCode != "" {
-			reqBody["language_code"] = r.LanguageCode
-		}
-		if r.PreviousText != "" {
-			reqBody["previous_text"] = r.PreviousText
-		}
-		if r.NextText != "" {
-			reqBody["next_text"] = r.NextText
-		}
+	audioSetting := make(map[string]interface{})
+	if r.SampleRate != "" {
+		audioSetting["sample_rate"] = r.SampleRate
+	}
+	if r.Bitrate != "" {
+		audioSetting["bitrate"] = r.Bitrate
+	}
+	if r.Format != "" {
+		audioSetting["format"] = r.Format
+	}
+	if r.Channel != "" {
+		audioSetting["channel"] = r.Channel
+	}
 
-		r.Model = modelName
+	spec.Body = map[string]interface{}{"text": r.Text}
+	if len(voiceSetting) > 1 { // Only add if more than just voice_id
+		spec.Body["voice_setting"] = voiceSetting
+	}
+	if len(audioSetting) > 0 {
+		spec.Body["audio_setting"] = audioSetting
+	}
 
-	case *ElevenLabsVoiceChangerRequest:
-		modelName = "elevenlabs-voice-changer"
+	r.Model = spec.Model // Set model name internally
+	return spec, nil
+}
 
-		// Validate required field
-		if r.AudioURL == "" {
-			return nil, fmt.Errorf("audio_url is required for %s", modelName)
-		}
+// buildElevenLabsTTS validates an ElevenLabsTTSRequest and builds its request body.
+func buildElevenLabsTTS(req interface{}) (*RequestSpec, error) {
+	r := req.(*ElevenLabsTTSRequest)
+	spec := &RequestSpec{}
+	spec.Model = "elevenlabs/tts/turbo-v2.5"
+
+	// Validate options
+	currentOpts := ElevenLabsTTSOptions{
+		Voice:           r.Voice,
+		Stability:       r.Stability,
+		SimilarityBoost: r.SimilarityBoost,
+		Style:           r.Style,
+		Speed:           r.Speed,
+		Timestamps:      r.Timestamps,
+		LanguageCode:    r.LanguageCode,
+	}
+	if err := currentOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
 
-		// Validate options
-		opts := ElevenLabsVoiceChangerOptions{
-			Voice:                 r.Voice,
-			RemoveBackgroundNoise: r.RemoveBackgroundNoise,
-			OutputFormat:          r.OutputFormat,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"text": r.Text,
+	}
 
-		// Get model defaults
-		model, exists := GetModel(modelName, "audio2audio")
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		options, ok := model.Options.(*ElevenLabsVoiceChangerOptions)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
+	// Add voice (required)
+	if r.Voice != "" {
+		spec.Body["voice"] = r.Voice
+	} else {
+		spec.Body["voice"] = "Rachel" // Default voice
+	}
 
-		// Set defaults if not provided
-		if r.Voice == "" {
-			r.Voice = options.Voice
-		}
-		if r.OutputFormat == "" {
-			r.OutputFormat = options.OutputFormat
-		}
+	// Add optional parameters
+	if r.Stability != nil {
+		spec.Body["stability"] = *r.Stability
+	}
+	if r.SimilarityBoost != nil {
+		spec.Body["similarity_boost"] = *r.SimilarityBoost
+	}
+	if r.Style != nil {
+		spec.Body["style"] = *r.Style
+	}
+	if r.Speed != nil {
+		spec.Body["speed"] = *r.Speed
+	}
+	if r.Timestamps != nil {
+		spec.Body["timestamps"] = *r.Timestamps
+	}
+	if r.LanguageCode != "" {
+		spec.Body["language_code"] = r.LanguageCode
+	}
+	if r.PreviousText != "" {
+		spec.Body["previous_text"] = r.PreviousText
+	}
+	if r.NextText != "" {
+		spec.Body["next_text"] = r.NextText
+	}
 
-		// Build request body
-		reqBody = map[string]interface{}{
-			"audio_url": r.AudioURL,
-			"voice":     r.Voice,
-		}
+	r.Model = spec.Model
+	return spec, nil
+}
 
-		// Add optional fields
-		if r.RemoveBackgroundNoise != nil {
-			reqBody["remove_background_noise"] = *r.RemoveBackgroundNoise
-		}
-		if r.Seed != nil {
-			reqBody["seed"] = *r.Seed
-		}
-		if r.OutputFormat != "" {
-			reqBody["output_format"] = r.OutputFormat
-		}
+// buildElevenLabsVoiceChanger validates an ElevenLabsVoiceChangerRequest and builds its request body.
+func buildElevenLabsVoiceChanger(req interface{}) (*RequestSpec, error) {
+	r := req.(*ElevenLabsVoiceChangerRequest)
+	spec := &RequestSpec{}
+	spec.Model = "elevenlabs-voice-changer"
 
-	default:
-		return nil, fmt.Errorf("unsupported speech request type: %T", req)
+	// Validate required field
+	if r.AudioURL == "" {
+		return nil, fmt.Errorf("audio_url is required for %s", spec.Model)
 	}
 
-	// Validate the requested model and set endpoint from model definition
-	if modelDef, exists := GetModel(modelName, "text2speech"); exists {
-		endpoint = modelDef.Endpoint
-	} else if modelDef, exists := GetModel(modelName, "audio2audio"); exists {
-		endpoint = modelDef.Endpoint
-	} else {
-		return nil, &Error{
-			Code:    "INVALID_MODEL",
-			Message: fmt.Sprintf("invalid or unsupported model %s", modelName),
-		}
+	// Validate options
+	opts := ElevenLabsVoiceChangerOptions{
+		Voice:                 r.Voice,
+		RemoveBackgroundNoise: r.RemoveBackgroundNoise,
+		OutputFormat:          r.OutputFormat,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
 	}
 
-	// Add any additional generic options from the request
-	if optionsGetter, ok := req.(interface{ GetOptions() map[string]interface{} }); ok {
-		for k, v := range optionsGetter.GetOptions() {
-			// Avoid overwriting fields already set by specific request types
-			if _, exists := reqBody[k]; !exists {
-				reqBody[k] = v
-			}
-		}
+	// Get model defaults
+	model, exists := GetModel(spec.Model, "audio2audio")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	options, ok := model.Options.(*ElevenLabsVoiceChangerOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
 	}
 
-	// Define the decoder for the final audio response
-	decodeFunc := func(data []byte) (interface{}, error) {
-		var response struct {
-			Audio struct {
-				URL         string `json:"url"`
-				ContentType string `json:"content_type"`
-				FileName    string `json:"file_name"`
-				FileSize    int    `json:"file_size"`
-			} `json:"audio"`
-			Duration float64 `json:"duration"` // Fal uses duration now
-		}
+	// Set defaults if not provided
+	if r.Voice == "" {
+		r.Voice = options.Voice
+	}
+	if r.OutputFormat == "" {
+		r.OutputFormat = options.OutputFormat
+	}
 
-		if err := json.Unmarshal(data, &response); err != nil {
-			return nil, fmt.Errorf("failed to parse final audio response: %w. Body: %s", err, string(data))
-		}
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"audio_url": r.AudioURL,
+		"voice":     r.Voice,
+	}
 
-		if response.Audio.URL == "" {
-			return nil, &Error{
-				Code:    "NO_AUDIO_URL",
-				Message: "no audio URL found in response",
-			}
-		}
+	// Add optional fields
+	if r.RemoveBackgroundNoise != nil {
+		spec.Body["remove_background_noise"] = *r.RemoveBackgroundNoise
+	}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	if r.OutputFormat != "" {
+		spec.Body["output_format"] = r.OutputFormat
+	}
+	return spec, nil
+}
 
-		contentType := response.Audio.ContentType
-		if contentType == "" {
-			contentType = "audio/mpeg" // Default if missing
-		}
+// buildAudioIsolation validates an AudioIsolationRequest and builds its request body.
+func buildAudioIsolation(req interface{}) (*RequestSpec, error) {
+	r := req.(*AudioIsolationRequest)
+	spec := &RequestSpec{}
+	spec.Model = "elevenlabs-audio-isolation"
 
-		return &AudioResponse{
-			AudioURL:    response.Audio.URL,
-			ContentType: contentType,
-			FileName:    response.Audio.FileName,
-			FileSize:    response.Audio.FileSize,
-			Duration:    response.Duration, // Use the float duration
-		}, nil
+	// Validate required field
+	if r.AudioURL == "" {
+		return nil, fmt.Errorf("audio_url is required for %s", spec.Model)
 	}
 
-	// Execute the workflow
-	result, err := c.executeAsyncWorkflow(ctx, endpoint, reqBody, progress, decodeFunc)
-	if err != nil {
-		return nil, err // Error already wrapped
+	spec.Body = map[string]interface{}{
+		"audio_url": r.AudioURL,
 	}
-
-	return result.(*AudioResponse), nil
+	return spec, nil
 }
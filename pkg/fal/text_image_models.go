@@ -302,6 +302,34 @@ func (m *nanoBanana2Model) Define() Model {
 	}
 }
 
+// --- sdxl-controlnet-union ---
+
+type sdxlControlNetUnionModel struct{}
+
+func (m *sdxlControlNetUnionModel) Define() Model {
+	defaultOpts := &ControlNetOptions{}
+	defaults := defaultOpts.GetDefaultValues()
+
+	return Model{
+		Name:        "sdxl-controlnet-union",
+		Description: "SDXL ControlNet Union - Guide compositions with canny, depth or pose maps from a reference image",
+		Type:        "text2image",
+		Endpoint:    "/sdxl-controlnet-union",
+		Options: &ControlNetOptions{
+			ControlType:         defaults["control_type"].(string),
+			Preprocess:          defaults["preprocess"].(*bool),
+			ConditioningScale:   defaults["controlnet_conditioning_scale"].(*float64),
+			NegativePrompt:      defaults["negative_prompt"].(string),
+			ImageSize:           defaults["image_size"].(string),
+			NumInferenceSteps:   defaults["num_inference_steps"].(*int),
+			GuidanceScale:       defaults["guidance_scale"].(*float64),
+			NumImages:           defaults["num_images"].(int),
+			EnableSafetyChecker: defaults["enable_safety_checker"].(*bool),
+			OutputFormat:        defaults["output_format"].(string),
+		},
+	}
+}
+
 func init() {
 	registerModel(&fastSDXLModel{})
 	registerModel(&hidreamI1FullModel{})
@@ -315,4 +343,5 @@ func init() {
 	registerModel(&flux2ProModel{})
 	registerModel(&flux2Model{})
 	registerModel(&nanoBanana2Model{})
+	registerModel(&sdxlControlNetUnionModel{})
 }
@@ -0,0 +1,28 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+// --- any-llm ---
+
+type anyLLMModel struct{}
+
+func (m *anyLLMModel) Define() Model {
+	defaultOpts := &AnyLLMOptions{}
+	defaults := defaultOpts.GetDefaultValues()
+
+	return Model{
+		Name:        "any-llm",
+		Description: "Any LLM - Chat with hosted language models such as Gemini, Claude and Llama",
+		Type:        "text2text",
+		Endpoint:    "/any-llm",
+		Options: &AnyLLMOptions{
+			Model: defaults["model"].(string),
+		},
+	}
+}
+
+func init() {
+	registerModel(&anyLLMModel{})
+}
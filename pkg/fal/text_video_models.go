@@ -4,10 +4,6 @@
 
 package fal
 
-import (
-	"fmt"
-)
-
 // --- kling-video-text ---
 
 type klingVideoTextModel struct{}
@@ -94,7 +90,7 @@ func (o *MinimaxHailuo02Options) GetDefaultValues() map[string]interface{} {
 
 func (o *MinimaxHailuo02Options) Validate() error {
 	if o.Duration != "" && o.Duration != "6" && o.Duration != "10" {
-		return fmt.Errorf("invalid duration: %s (must be 6 or 10)", o.Duration)
+		return invalidEnum("duration", o.Duration, "6", "10")
 	}
 	return nil
 }
@@ -35,7 +35,7 @@ func (c *Client) Transcribe(ctx context.Context, req *ScribeV2Request) (*ScribeV
 		NumSpeakers: req.NumSpeakers,
 	}
 	if err := currentOpts.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
+		return nil, fmt.Errorf("invalid options for %s: %w", modelName, err)
 	}
 
 	// Extract progress callback
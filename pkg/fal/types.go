@@ -5,7 +5,6 @@
 package fal
 
 import (
-	"fmt"
 	"strconv"
 	"time"
 )
@@ -60,10 +59,10 @@ func (o *Veo2Options) Validate() error {
 	}
 
 	if o.AspectRatio != "" && !validAspectRatios[o.AspectRatio] {
-		return fmt.Errorf("invalid aspect ratio: %s (must be one of: auto, auto_prefer_portrait, 16:9, 9:16)", o.AspectRatio)
+		return invalidEnum("aspect_ratio", o.AspectRatio, "auto", "auto_prefer_portrait", "16:9", "9:16")
 	}
 	if o.Duration != "" && !validDurations[o.Duration] {
-		return fmt.Errorf("invalid duration: %s (must be one of: 5s, 6s, 7s, 8s)", o.Duration)
+		return invalidEnum("duration", o.Duration, "5s", "6s", "7s", "8s")
 	}
 	return nil
 }
@@ -95,16 +94,16 @@ func (o *KlingVideoOptions) Validate() error {
 	}
 
 	if o.AspectRatio != "" && !validAspectRatios[o.AspectRatio] {
-		return fmt.Errorf("invalid aspect ratio: %s", o.AspectRatio)
+		return invalidEnum("aspect_ratio", o.AspectRatio, allowedValues(validAspectRatios)...)
 	}
 	if o.Duration != "" {
 		dur, err := strconv.Atoi(o.Duration)
 		if err != nil || dur < 5 || dur > 10 {
-			return fmt.Errorf("invalid duration: %s (must be 5-10 seconds)", o.Duration)
+			return invalidValue("duration", o.Duration, "must be 5-10 seconds")
 		}
 	}
 	if o.CFGScale < 0 || o.CFGScale > 1 {
-		return fmt.Errorf("invalid cfg_scale: %f (must be between 0 and 1)", o.CFGScale)
+		return invalidValue("cfg_scale", o.CFGScale, "must be between 0 and 1")
 	}
 	return nil
 }
@@ -165,13 +164,13 @@ func (o *FluxSchnellOptions) Validate() error {
 
 	if o.ImageSize != "" && !validImageSizes[o.ImageSize] {
 		// TODO: Add support for {width, height} object validation if needed
-		return fmt.Errorf("invalid image_size: %s (must be one of: square_hd, square, portrait_4_3, portrait_16_9, landscape_4_3, landscape_16_9)", o.ImageSize)
+		return invalidEnum("image_size", o.ImageSize, "square_hd", "square", "portrait_4_3", "portrait_16_9", "landscape_4_3", "landscape_16_9")
 	}
 	if o.NumInferenceSteps < 0 {
-		return fmt.Errorf("num_inference_steps cannot be negative: %d", o.NumInferenceSteps)
+		return invalidValue("num_inference_steps", o.NumInferenceSteps, "cannot be negative")
 	}
 	if o.NumImages < 0 || o.NumImages > 4 {
-		return fmt.Errorf("invalid num_images: %d (must be 1-4)", o.NumImages)
+		return invalidValue("num_images", o.NumImages, "must be 1-4")
 	}
 
 	return nil
@@ -219,16 +218,16 @@ func (o *FluxProV1_1Options) Validate() error {
 	}
 
 	if o.ImageSize != "" && !validImageSizes[o.ImageSize] {
-		return fmt.Errorf("invalid image_size: %s", o.ImageSize)
+		return invalidEnum("image_size", o.ImageSize, allowedValues(validImageSizes)...)
 	}
 	if o.NumImages < 0 || o.NumImages > 4 {
-		return fmt.Errorf("invalid num_images: %d (must be 1-4)", o.NumImages)
+		return invalidValue("num_images", o.NumImages, "must be 1-4")
 	}
 	if o.SafetyTolerance != "" && !validSafetyTolerances[o.SafetyTolerance] {
-		return fmt.Errorf("invalid safety_tolerance: %s (must be 1-6)", o.SafetyTolerance)
+		return invalidEnum("safety_tolerance", o.SafetyTolerance, allowedValues(validSafetyTolerances)...)
 	}
 	if o.OutputFormat != "" && !validOutputFormats[o.OutputFormat] {
-		return fmt.Errorf("invalid output_format: %s (must be jpeg or png)", o.OutputFormat)
+		return invalidEnum("output_format", o.OutputFormat, "jpeg", "png")
 	}
 	return nil
 }
@@ -268,34 +267,34 @@ func (o *MinimaxTTSOptions) GetDefaultValues() map[string]interface{} {
 func (o *MinimaxTTSOptions) Validate() error {
 	// Voice Settings Validation
 	if o.Speed != nil && (*o.Speed < 0.5 || *o.Speed > 2.0) {
-		return fmt.Errorf("invalid speed: %f (must be between 0.5 and 2.0)", *o.Speed)
+		return invalidValue("speed", *o.Speed, "must be between 0.5 and 2.0")
 	}
 	if o.Vol != nil && (*o.Vol < 0 || *o.Vol > 10.0) {
-		return fmt.Errorf("invalid vol: %f (must be between 0 and 10)", *o.Vol)
+		return invalidValue("vol", *o.Vol, "must be between 0 and 10")
 	}
 	if o.Pitch != nil && (*o.Pitch < -12 || *o.Pitch > 12) {
-		return fmt.Errorf("invalid pitch: %d (must be between -12 and 12)", *o.Pitch)
+		return invalidValue("pitch", *o.Pitch, "must be between -12 and 12")
 	}
 	validEmotions := map[string]bool{"happy": true, "sad": true, "angry": true, "fearful": true, "disgusted": true, "surprised": true, "neutral": true, "": true}
 	if !validEmotions[o.Emotion] {
-		return fmt.Errorf("invalid emotion: %s", o.Emotion)
+		return invalidEnum("emotion", o.Emotion, allowedValues(validEmotions)...)
 	}
 	// Audio Settings Validation
 	validSampleRates := map[string]bool{"8000": true, "16000": true, "22050": true, "24000": true, "32000": true, "44100": true, "": true}
 	if !validSampleRates[o.SampleRate] {
-		return fmt.Errorf("invalid sample_rate: %s", o.SampleRate)
+		return invalidEnum("sample_rate", o.SampleRate, allowedValues(validSampleRates)...)
 	}
 	validBitrates := map[string]bool{"32000": true, "64000": true, "128000": true, "256000": true, "": true}
 	if !validBitrates[o.Bitrate] {
-		return fmt.Errorf("invalid bitrate: %s", o.Bitrate)
+		return invalidEnum("bitrate", o.Bitrate, allowedValues(validBitrates)...)
 	}
 	validFormats := map[string]bool{"mp3": true, "pcm": true, "flac": true, "": true}
 	if !validFormats[o.Format] {
-		return fmt.Errorf("invalid format: %s", o.Format)
+		return invalidEnum("format", o.Format, allowedValues(validFormats)...)
 	}
 	validChannels := map[string]bool{"1": true, "2": true, "": true}
 	if !validChannels[o.Channel] {
-		return fmt.Errorf("invalid channel: %s", o.Channel)
+		return invalidEnum("channel", o.Channel, allowedValues(validChannels)...)
 	}
 	return nil
 }
@@ -314,6 +313,7 @@ type Model struct {
 	Type        string
 	Endpoint    string      // API endpoint path (e.g. "/veo2/image-to-video") or full URL
 	Options     interface{} // Model-specific options
+	Generic     bool        // Registered with RegisterModel; requests are GenericRequests
 }
 
 // BaseImageRequest represents the base fields for an image generation request
@@ -419,22 +419,22 @@ func (o *Flux2Options) Validate() error {
 	}
 
 	if o.ImageSize != "" && !validImageSizes[o.ImageSize] {
-		return fmt.Errorf("invalid image_size: %s", o.ImageSize)
+		return invalidEnum("image_size", o.ImageSize, allowedValues(validImageSizes)...)
 	}
 	if o.GuidanceScale < 0 {
-		return fmt.Errorf("guidance_scale cannot be negative: %f", o.GuidanceScale)
+		return invalidValue("guidance_scale", o.GuidanceScale, "cannot be negative")
 	}
 	if o.NumInferenceSteps < 0 {
-		return fmt.Errorf("num_inference_steps cannot be negative: %d", o.NumInferenceSteps)
+		return invalidValue("num_inference_steps", o.NumInferenceSteps, "cannot be negative")
 	}
 	if o.NumImages < 0 || o.NumImages > 4 {
-		return fmt.Errorf("invalid num_images: %d (must be 1-4)", o.NumImages)
+		return invalidValue("num_images", o.NumImages, "must be 1-4")
 	}
 	if o.Acceleration != "" && !validAccelerations[o.Acceleration] {
-		return fmt.Errorf("invalid acceleration: %s (must be none, regular, or high)", o.Acceleration)
+		return invalidEnum("acceleration", o.Acceleration, "none", "regular", "high")
 	}
 	if o.OutputFormat != "" && !validOutputFormats[o.OutputFormat] {
-		return fmt.Errorf("invalid output_format: %s (must be jpeg, png, or webp)", o.OutputFormat)
+		return invalidEnum("output_format", o.OutputFormat, "jpeg", "png", "webp")
 	}
 	return nil
 }
@@ -493,13 +493,13 @@ func (o *Flux2ProOptions) Validate() error {
 	}
 
 	if o.ImageSize != "" && !validImageSizes[o.ImageSize] {
-		return fmt.Errorf("invalid image_size: %s", o.ImageSize)
+		return invalidEnum("image_size", o.ImageSize, allowedValues(validImageSizes)...)
 	}
 	if o.SafetyTolerance != "" && !validSafetyTolerances[o.SafetyTolerance] {
-		return fmt.Errorf("invalid safety_tolerance: %s (must be 1-5)", o.SafetyTolerance)
+		return invalidEnum("safety_tolerance", o.SafetyTolerance, allowedValues(validSafetyTolerances)...)
 	}
 	if o.OutputFormat != "" && !validOutputFormats[o.OutputFormat] {
-		return fmt.Errorf("invalid output_format: %s (must be jpeg or png)", o.OutputFormat)
+		return invalidEnum("output_format", o.OutputFormat, "jpeg", "png")
 	}
 	return nil
 }
@@ -538,19 +538,19 @@ func (o *NanoBanana2Options) Validate() error {
 	validSafetyTolerances := map[string]bool{"1": true, "2": true, "3": true, "4": true, "5": true, "6": true}
 
 	if o.AspectRatio != "" && !validAspectRatios[o.AspectRatio] {
-		return fmt.Errorf("invalid aspect_ratio: %s", o.AspectRatio)
+		return invalidEnum("aspect_ratio", o.AspectRatio, allowedValues(validAspectRatios)...)
 	}
 	if o.NumImages < 0 || o.NumImages > 4 {
-		return fmt.Errorf("invalid num_images: %d (must be 1-4)", o.NumImages)
+		return invalidValue("num_images", o.NumImages, "must be 1-4")
 	}
 	if o.Resolution != "" && !validResolutions[o.Resolution] {
-		return fmt.Errorf("invalid resolution: %s (must be 0.5K, 1K, 2K, or 4K)", o.Resolution)
+		return invalidEnum("resolution", o.Resolution, "0.5K", "1K", "2K", "4K")
 	}
 	if o.OutputFormat != "" && !validOutputFormats[o.OutputFormat] {
-		return fmt.Errorf("invalid output_format: %s (must be png, jpeg, or webp)", o.OutputFormat)
+		return invalidEnum("output_format", o.OutputFormat, "png", "jpeg", "webp")
 	}
 	if o.SafetyTolerance != "" && !validSafetyTolerances[o.SafetyTolerance] {
-		return fmt.Errorf("invalid safety_tolerance: %s (must be 1-6)", o.SafetyTolerance)
+		return invalidEnum("safety_tolerance", o.SafetyTolerance, allowedValues(validSafetyTolerances)...)
 	}
 	return nil
 }
@@ -606,13 +606,13 @@ func (o *Flux2ProEditOptions) Validate() error {
 	}
 
 	if o.ImageSize != "" && !validImageSizes[o.ImageSize] {
-		return fmt.Errorf("invalid image_size: %s", o.ImageSize)
+		return invalidEnum("image_size", o.ImageSize, allowedValues(validImageSizes)...)
 	}
 	if o.SafetyTolerance != "" && !validSafetyTolerances[o.SafetyTolerance] {
-		return fmt.Errorf("invalid safety_tolerance: %s (must be 1-5)", o.SafetyTolerance)
+		return invalidEnum("safety_tolerance", o.SafetyTolerance, allowedValues(validSafetyTolerances)...)
 	}
 	if o.OutputFormat != "" && !validOutputFormats[o.OutputFormat] {
-		return fmt.Errorf("invalid output_format: %s (must be jpeg or png)", o.OutputFormat)
+		return invalidEnum("output_format", o.OutputFormat, "jpeg", "png")
 	}
 	return nil
 }
@@ -677,22 +677,22 @@ func (o *Flux2EditOptions) Validate() error {
 	}
 
 	if o.ImageSize != "" && !validImageSizes[o.ImageSize] {
-		return fmt.Errorf("invalid image_size: %s", o.ImageSize)
+		return invalidEnum("image_size", o.ImageSize, allowedValues(validImageSizes)...)
 	}
 	if o.GuidanceScale < 0 {
-		return fmt.Errorf("guidance_scale cannot be negative: %f", o.GuidanceScale)
+		return invalidValue("guidance_scale", o.GuidanceScale, "cannot be negative")
 	}
 	if o.NumInferenceSteps < 0 {
-		return fmt.Errorf("num_inference_steps cannot be negative: %d", o.NumInferenceSteps)
+		return invalidValue("num_inference_steps", o.NumInferenceSteps, "cannot be negative")
 	}
 	if o.NumImages < 0 || o.NumImages > 4 {
-		return fmt.Errorf("invalid num_images: %d (must be 1-4)", o.NumImages)
+		return invalidValue("num_images", o.NumImages, "must be 1-4")
 	}
 	if o.Acceleration != "" && !validAccelerations[o.Acceleration] {
-		return fmt.Errorf("invalid acceleration: %s (must be none, regular, or high)", o.Acceleration)
+		return invalidEnum("acceleration", o.Acceleration, "none", "regular", "high")
 	}
 	if o.OutputFormat != "" && !validOutputFormats[o.OutputFormat] {
-		return fmt.Errorf("invalid output_format: %s (must be jpeg, png, or webp)", o.OutputFormat)
+		return invalidEnum("output_format", o.OutputFormat, "jpeg", "png", "webp")
 	}
 	return nil
 }
@@ -723,11 +723,17 @@ type ImageOutput struct {
 
 // ImageResponse represents the response from an image generation request
 type ImageResponse struct {
-	Images      []ImageOutput `json:"images"`
-	NSFW        bool          `json:"nsfw"`
-	CreatedAt   time.Time     `json:"created_at"`
-	CompletedAt time.Time     `json:"completed_at"`
-	Seed        uint64        `json:"seed"`
+	Images          []ImageOutput `json:"images"`
+	NSFW            bool          `json:"nsfw"`
+	HasNSFWConcepts []bool        `json:"has_nsfw_concepts"` // Per image, from models with a safety checker
+	CreatedAt       time.Time     `json:"created_at"`
+	CompletedAt     time.Time     `json:"completed_at"`
+	Seed            uint64        `json:"seed"`
+}
+
+// IsNSFW reports whether the model flagged the image at index i as NSFW.
+func (r *ImageResponse) IsNSFW(i int) bool {
+	return r.NSFW || (i < len(r.HasNSFWConcepts) && r.HasNSFWConcepts[i])
 }
 
 // BaseSpeechRequest represents the base fields for a speech generation request
@@ -850,7 +856,9 @@ func (r *VideoResponse) GetURL() string {
 
 // QueueResponse represents the response from a queue request
 type QueueResponse struct {
+	RequestID   string `json:"request_id"`
 	ResponseURL string `json:"response_url"`
+	StatusURL   string `json:"status_url"` // Empty in older responses; derived from ResponseURL
 	QueueID     string `json:"queue_id"`
 	Status      string `json:"status"`
 	Position    int    `json:"position"`
@@ -909,19 +917,19 @@ func (o *HiDreamOptions) Validate() error {
 	validOutputFormats := map[string]bool{"jpeg": true, "png": true, "": true}
 
 	if o.ImageSize != "" && !validImageSizes[o.ImageSize] {
-		return fmt.Errorf("invalid image_size: %s", o.ImageSize)
+		return invalidEnum("image_size", o.ImageSize, allowedValues(validImageSizes)...)
 	}
 	if o.NumInferenceSteps != nil && *o.NumInferenceSteps <= 0 {
-		return fmt.Errorf("num_inference_steps must be positive: %d", *o.NumInferenceSteps)
+		return invalidValue("num_inference_steps", *o.NumInferenceSteps, "must be positive")
 	}
 	if o.GuidanceScale != nil && *o.GuidanceScale < 0 {
-		return fmt.Errorf("guidance_scale cannot be negative: %f", *o.GuidanceScale)
+		return invalidValue("guidance_scale", *o.GuidanceScale, "cannot be negative")
 	}
 	if o.NumImages < 0 || o.NumImages > 4 {
-		return fmt.Errorf("invalid num_images: %d (must be 1-4)", o.NumImages)
+		return invalidValue("num_images", o.NumImages, "must be 1-4")
 	}
 	if o.OutputFormat != "" && !validOutputFormats[o.OutputFormat] {
-		return fmt.Errorf("invalid output_format: %s", o.OutputFormat)
+		return invalidEnum("output_format", o.OutputFormat, allowedValues(validOutputFormats)...)
 	}
 	return nil
 }
@@ -950,6 +958,97 @@ type HiDreamI1FastRequest struct {
 	HiDreamI1FullRequest // Assuming same params as full
 }
 
+// ControlNetTypes maps the user-facing --control types to the input field
+// prefix used by fal-ai/sdxl-controlnet-union (e.g. "depth" → depth_image_url).
+var ControlNetTypes = map[string]string{
+	"canny":        "canny",
+	"depth":        "depth",
+	"pose":         "openpose",
+	"openpose":     "openpose",
+	"normal":       "normal",
+	"segmentation": "segmentation",
+	"teed":         "teed",
+}
+
+// ControlNetOptions represents options for ControlNet conditioned text2image models
+type ControlNetOptions struct {
+	ControlType         string   `json:"control_type,omitempty"`                  // canny, depth, pose, normal, segmentation, teed
+	Preprocess          *bool    `json:"preprocess,omitempty"`                    // Derive the control map from a regular photo. Default: true
+	ConditioningScale   *float64 `json:"controlnet_conditioning_scale,omitempty"` // 0-1. Default: 0.5
+	NegativePrompt      string   `json:"negative_prompt,omitempty"`
+	ImageSize           string   `json:"image_size,omitempty"`          // Default: square_hd
+	NumInferenceSteps   *int     `json:"num_inference_steps,omitempty"` // Default: 35
+	GuidanceScale       *float64 `json:"guidance_scale,omitempty"`      // Default: 7.5
+	NumImages           int      `json:"num_images,omitempty"`          // 1-4. Default: 1
+	EnableSafetyChecker *bool    `json:"enable_safety_checker,omitempty"`
+	OutputFormat        string   `json:"output_format,omitempty"` // jpeg, png. Default: jpeg
+}
+
+// GetDefaultValues returns the default values for ControlNet options
+func (o *ControlNetOptions) GetDefaultValues() map[string]interface{} {
+	defaultPreprocess := true
+	defaultConditioningScale := 0.5
+	defaultSteps := 35
+	defaultGuidance := 7.5
+	defaultSafetyChecker := true
+	return map[string]interface{}{
+		"control_type":                  "canny",
+		"preprocess":                    &defaultPreprocess,
+		"controlnet_conditioning_scale": &defaultConditioningScale,
+		"negative_prompt":               "",
+		"image_size":                    "square_hd",
+		"num_inference_steps":           &defaultSteps,
+		"guidance_scale":                &defaultGuidance,
+		"num_images":                    1,
+		"enable_safety_checker":         &defaultSafetyChecker,
+		"output_format":                 "jpeg",
+	}
+}
+
+// Validate validates ControlNet options
+func (o *ControlNetOptions) Validate() error {
+	if o.ControlType != "" {
+		if _, ok := ControlNetTypes[o.ControlType]; !ok {
+			return invalidEnum("control_type", o.ControlType, "canny", "depth", "pose", "normal", "segmentation", "teed")
+		}
+	}
+	if o.ConditioningScale != nil && (*o.ConditioningScale < 0 || *o.ConditioningScale > 1) {
+		return invalidValue("controlnet_conditioning_scale", *o.ConditioningScale, "must be between 0 and 1")
+	}
+	validSizes := map[string]bool{
+		"square_hd": true, "square": true, "portrait_4_3": true, "portrait_16_9": true,
+		"landscape_4_3": true, "landscape_16_9": true, "": true,
+	}
+	if !validSizes[o.ImageSize] {
+		return invalidEnum("image_size", o.ImageSize, allowedValues(validSizes)...)
+	}
+	if o.NumImages < 0 || o.NumImages > 4 {
+		return invalidValue("num_images", o.NumImages, "must be 1-4")
+	}
+	validFormats := map[string]bool{"jpeg": true, "png": true, "": true}
+	if !validFormats[o.OutputFormat] {
+		return invalidEnum("output_format", o.OutputFormat, "jpeg", "png")
+	}
+	return nil
+}
+
+// ControlNetRequest represents a request for fal-ai/sdxl-controlnet-union
+type ControlNetRequest struct {
+	BaseImageRequest
+	ControlType         string   `json:"-"` // Selects which <type>_image_url field receives ControlImageURL
+	ControlImageURL     string   `json:"-"`
+	Preprocess          *bool    `json:"-"`
+	ConditioningScale   *float64 `json:"controlnet_conditioning_scale,omitempty"`
+	NegativePrompt      string   `json:"negative_prompt,omitempty"`
+	ImageSize           string   `json:"image_size,omitempty"`
+	NumInferenceSteps   *int     `json:"num_inference_steps,omitempty"`
+	GuidanceScale       *float64 `json:"guidance_scale,omitempty"`
+	Seed                *int     `json:"seed,omitempty"`
+	NumImages           int      `json:"num_images,omitempty"`
+	EnableSafetyChecker *bool    `json:"enable_safety_checker,omitempty"`
+	OutputFormat        string   `json:"output_format,omitempty"`
+}
+
 // FluxProV1_1UltraOptions represents options for fal-ai/flux-pro/v1.1-ultra
 type FluxProV1_1UltraOptions struct {
 	Seed                *int   `json:"seed,omitempty"`
@@ -986,16 +1085,16 @@ func (o *FluxProV1_1UltraOptions) Validate() error {
 	}
 
 	if o.NumImages < 0 || o.NumImages > 4 {
-		return fmt.Errorf("invalid num_images: %d (must be 1-4)", o.NumImages)
+		return invalidValue("num_images", o.NumImages, "must be 1-4")
 	}
 	if o.SafetyTolerance != "" && !validSafetyTolerances[o.SafetyTolerance] {
-		return fmt.Errorf("invalid safety_tolerance: %s (must be 1-6)", o.SafetyTolerance)
+		return invalidEnum("safety_tolerance", o.SafetyTolerance, allowedValues(validSafetyTolerances)...)
 	}
 	if o.OutputFormat != "" && !validOutputFormats[o.OutputFormat] {
-		return fmt.Errorf("invalid output_format: %s (must be jpeg or png)", o.OutputFormat)
+		return invalidEnum("output_format", o.OutputFormat, "jpeg", "png")
 	}
 	if o.AspectRatio != "" && !validAspectRatios[o.AspectRatio] {
-		return fmt.Errorf("invalid aspect_ratio: %s", o.AspectRatio)
+		return invalidEnum("aspect_ratio", o.AspectRatio, allowedValues(validAspectRatios)...)
 	}
 	return nil
 }
@@ -1037,16 +1136,16 @@ func (o *FluxDevOptions) Validate() error {
 	}
 
 	if o.ImageSize != "" && !validImageSizes[o.ImageSize] {
-		return fmt.Errorf("invalid image_size: %s (must be one of: square_hd, square, portrait_4_3, portrait_16_9, landscape_4_3, landscape_16_9)", o.ImageSize)
+		return invalidEnum("image_size", o.ImageSize, "square_hd", "square", "portrait_4_3", "portrait_16_9", "landscape_4_3", "landscape_16_9")
 	}
 	if o.NumInferenceSteps < 0 {
-		return fmt.Errorf("num_inference_steps cannot be negative: %d", o.NumInferenceSteps)
+		return invalidValue("num_inference_steps", o.NumInferenceSteps, "cannot be negative")
 	}
 	if o.GuidanceScale < 0 {
-		return fmt.Errorf("guidance_scale cannot be negative: %f", o.GuidanceScale)
+		return invalidValue("guidance_scale", o.GuidanceScale, "cannot be negative")
 	}
 	if o.NumImages < 0 || o.NumImages > 4 {
-		return fmt.Errorf("invalid num_images: %d (must be 1-4)", o.NumImages)
+		return invalidValue("num_images", o.NumImages, "must be 1-4")
 	}
 
 	return nil
@@ -1107,19 +1206,19 @@ func (o *StableDiffusionV35LargeOptions) Validate() error {
 	validOutputFormats := map[string]bool{"jpeg": true, "png": true, "": true}
 
 	if o.ImageSize != "" && !validImageSizes[o.ImageSize] {
-		return fmt.Errorf("invalid image_size: %s", o.ImageSize)
+		return invalidEnum("image_size", o.ImageSize, allowedValues(validImageSizes)...)
 	}
 	if o.NumInferenceSteps < 0 {
-		return fmt.Errorf("num_inference_steps cannot be negative: %d", o.NumInferenceSteps)
+		return invalidValue("num_inference_steps", o.NumInferenceSteps, "cannot be negative")
 	}
 	if o.GuidanceScale < 0 {
-		return fmt.Errorf("guidance_scale cannot be negative: %f", o.GuidanceScale)
+		return invalidValue("guidance_scale", o.GuidanceScale, "cannot be negative")
 	}
 	if o.NumImages < 0 || o.NumImages > 4 {
-		return fmt.Errorf("invalid num_images: %d (must be 1-4)", o.NumImages)
+		return invalidValue("num_images", o.NumImages, "must be 1-4")
 	}
 	if o.OutputFormat != "" && !validOutputFormats[o.OutputFormat] {
-		return fmt.Errorf("invalid output_format: %s (must be jpeg or png)", o.OutputFormat)
+		return invalidEnum("output_format", o.OutputFormat, "jpeg", "png")
 	}
 	return nil
 }
@@ -1148,6 +1247,215 @@ func (o *CartoonifyOptions) GetDefaultValues() map[string]interface{} {
 }
 func (o *CartoonifyOptions) Validate() error { return nil }
 
+// DDColorOptions represents options for the ddcolor colorization model.
+type DDColorOptions struct {
+	// No tunable options besides the seed
+}
+
+// GetDefaultValues returns the default values for DDColor options
+func (o *DDColorOptions) GetDefaultValues() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+// Validate validates DDColor options
+func (o *DDColorOptions) Validate() error { return nil }
+
+// DDColorRequest represents a request for fal-ai/ddcolor
+type DDColorRequest struct {
+	BaseImageRequest      // Requires ImageURL to be set
+	Seed             *int `json:"seed,omitempty"`
+}
+
+// CodeFormerOptions represents options for the codeformer face restoration model.
+type CodeFormerOptions struct {
+	Fidelity       float64 `json:"fidelity,omitempty"`         // 0 (quality) to 1 (fidelity). Default: 0.5
+	Upscaling      float64 `json:"upscaling,omitempty"`        // 1-4. Default: 2
+	FaceUpscale    *bool   `json:"face_upscale,omitempty"`     // Default: true
+	OnlyCenterFace *bool   `json:"only_center_face,omitempty"` // Default: false
+}
+
+// GetDefaultValues returns the default values for CodeFormer options
+func (o *CodeFormerOptions) GetDefaultValues() map[string]interface{} {
+	defaultFaceUpscale := true
+	defaultOnlyCenterFace := false
+	return map[string]interface{}{
+		"fidelity":         0.5,
+		"upscaling":        2.0,
+		"face_upscale":     &defaultFaceUpscale,
+		"only_center_face": &defaultOnlyCenterFace,
+	}
+}
+
+// Validate validates CodeFormer options
+func (o *CodeFormerOptions) Validate() error {
+	if o.Fidelity < 0 || o.Fidelity > 1 {
+		return invalidValue("fidelity", o.Fidelity, "must be between 0 and 1")
+	}
+	if o.Upscaling != 0 && (o.Upscaling < 1 || o.Upscaling > 4) {
+		return invalidValue("upscaling", o.Upscaling, "must be between 1 and 4")
+	}
+	return nil
+}
+
+// CodeFormerRequest represents a request for fal-ai/codeformer
+type CodeFormerRequest struct {
+	BaseImageRequest          // Requires ImageURL to be set
+	Fidelity         *float64 `json:"fidelity,omitempty"`
+	Upscaling        *float64 `json:"upscaling,omitempty"`
+	FaceUpscale      *bool    `json:"face_upscale,omitempty"`
+	OnlyCenterFace   *bool    `json:"only_center_face,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+}
+
+// ESRGANOptions represents options for the esrgan upscaling model.
+type ESRGANOptions struct {
+	Scale        float64 `json:"scale,omitempty"`         // 1-8. Default: 2
+	Model        string  `json:"model,omitempty"`         // Default: RealESRGAN_x4plus
+	Face         *bool   `json:"face,omitempty"`          // Apply face enhancement. Default: false
+	OutputFormat string  `json:"output_format,omitempty"` // png, jpeg. Default: png
+}
+
+// GetDefaultValues returns the default values for ESRGAN options
+func (o *ESRGANOptions) GetDefaultValues() map[string]interface{} {
+	defaultFace := false
+	return map[string]interface{}{
+		"scale":         2.0,
+		"model":         "RealESRGAN_x4plus",
+		"face":          &defaultFace,
+		"output_format": "png",
+	}
+}
+
+// Validate validates ESRGAN options
+func (o *ESRGANOptions) Validate() error {
+	validModels := map[string]bool{
+		"RealESRGAN_x4plus": true, "RealESRGAN_x2plus": true, "RealESRGAN_x4plus_anime_6B": true,
+		"RealESRGAN_x4_v3": true, "RealESRGAN_x4_wdn_v3": true, "RealESRGAN_x4_anime_v3": true, "": true,
+	}
+	validFormats := map[string]bool{"png": true, "jpeg": true, "": true}
+	if o.Scale != 0 && (o.Scale < 1 || o.Scale > 8) {
+		return invalidValue("scale", o.Scale, "must be between 1 and 8")
+	}
+	if !validModels[o.Model] {
+		return invalidEnum("model", o.Model, allowedValues(validModels)...)
+	}
+	if !validFormats[o.OutputFormat] {
+		return invalidEnum("output_format", o.OutputFormat, "png", "jpeg")
+	}
+	return nil
+}
+
+// ESRGANRequest represents a request for fal-ai/esrgan
+type ESRGANRequest struct {
+	BaseImageRequest          // Requires ImageURL to be set
+	Scale            *float64 `json:"scale,omitempty"`
+	UpscaleModel     string   `json:"model,omitempty"`
+	Face             *bool    `json:"face,omitempty"`
+	OutputFormat     string   `json:"output_format,omitempty"`
+}
+
+// BiRefNetVariants maps the short variant names users type to the BiRefNet
+// model variants fal accepts.
+var BiRefNetVariants = map[string]string{
+	"light":    "General Use (Light)",
+	"light2k":  "General Use (Light 2K)",
+	"heavy":    "General Use (Heavy)",
+	"matting":  "Matting",
+	"portrait": "Portrait",
+}
+
+// BiRefNetOptions represents options for the birefnet background removal model.
+type BiRefNetOptions struct {
+	Model               string `json:"model,omitempty"`                // Variant, see BiRefNetVariants. Default: General Use (Light)
+	OperatingResolution string `json:"operating_resolution,omitempty"` // 1024x1024 or 2048x2048. Default: 1024x1024
+	OutputFormat        string `json:"output_format,omitempty"`        // png, webp or gif. Default: png
+	RefineForeground    *bool  `json:"refine_foreground,omitempty"`    // Default: true
+}
+
+// GetDefaultValues returns the default values for BiRefNet options
+func (o *BiRefNetOptions) GetDefaultValues() map[string]interface{} {
+	defaultRefine := true
+	return map[string]interface{}{
+		"model":                "General Use (Light)",
+		"operating_resolution": "1024x1024",
+		"output_format":        "png",
+		"refine_foreground":    &defaultRefine,
+	}
+}
+
+// Validate validates BiRefNet options
+func (o *BiRefNetOptions) Validate() error {
+	validModels := map[string]bool{"": true}
+	for _, v := range BiRefNetVariants {
+		validModels[v] = true
+	}
+	validResolutions := map[string]bool{"1024x1024": true, "2048x2048": true, "": true}
+	validFormats := map[string]bool{"png": true, "webp": true, "gif": true, "": true}
+	if !validModels[o.Model] {
+		return invalidEnum("model", o.Model, allowedValues(validModels)...)
+	}
+	if !validResolutions[o.OperatingResolution] {
+		return invalidEnum("operating_resolution", o.OperatingResolution, "1024x1024", "2048x2048")
+	}
+	if !validFormats[o.OutputFormat] {
+		return invalidEnum("output_format", o.OutputFormat, "png", "webp", "gif")
+	}
+	return nil
+}
+
+// BiRefNetRequest represents a request for fal-ai/birefnet/v2
+type BiRefNetRequest struct {
+	BaseImageRequest           // Requires ImageURL to be set
+	Variant             string `json:"model,omitempty"`
+	OperatingResolution string `json:"operating_resolution,omitempty"`
+	OutputFormat        string `json:"output_format,omitempty"`
+	RefineForeground    *bool  `json:"refine_foreground,omitempty"`
+}
+
+// FluxProFillOptions represents options for the flux-pro/v1/fill inpainting model.
+type FluxProFillOptions struct {
+	NumImages       int    `json:"num_images,omitempty"`       // 1-4. Default: 1
+	Seed            *int   `json:"seed,omitempty"`             // Optional
+	SafetyTolerance string `json:"safety_tolerance,omitempty"` // 1-6. Default: 2
+	OutputFormat    string `json:"output_format,omitempty"`    // jpeg or png. Default: jpeg
+}
+
+// GetDefaultValues returns the default values for FLUX Pro Fill options
+func (o *FluxProFillOptions) GetDefaultValues() map[string]interface{} {
+	return map[string]interface{}{
+		"num_images":       1,
+		"safety_tolerance": "2",
+		"output_format":    "jpeg",
+	}
+}
+
+// Validate validates FLUX Pro Fill options
+func (o *FluxProFillOptions) Validate() error {
+	validTolerances := map[string]bool{"1": true, "2": true, "3": true, "4": true, "5": true, "6": true, "": true}
+	validFormats := map[string]bool{"jpeg": true, "png": true, "": true}
+	if o.NumImages < 0 || o.NumImages > 4 {
+		return invalidValue("num_images", o.NumImages, "must be 1-4")
+	}
+	if !validTolerances[o.SafetyTolerance] {
+		return invalidEnum("safety_tolerance", o.SafetyTolerance, "1", "2", "3", "4", "5", "6")
+	}
+	if !validFormats[o.OutputFormat] {
+		return invalidEnum("output_format", o.OutputFormat, "jpeg", "png")
+	}
+	return nil
+}
+
+// FluxProFillRequest represents a request for fal-ai/flux-pro/v1/fill. The
+// white areas of the mask are repainted from the prompt.
+type FluxProFillRequest struct {
+	BaseImageRequest        // Requires Prompt and ImageURL to be set
+	MaskURL          string `json:"mask_url"`
+	NumImages        int    `json:"num_images,omitempty"`
+	Seed             *int   `json:"seed,omitempty"`
+	SafetyTolerance  string `json:"safety_tolerance,omitempty"`
+	OutputFormat     string `json:"output_format,omitempty"`
+}
+
 // FluxProV1_1UltraRequest represents a request for fal-ai/flux-pro/v1.1-ultra
 type FluxProV1_1UltraRequest struct {
 	BaseImageRequest
@@ -1298,13 +1606,13 @@ func (o *Veo3Options) Validate() error {
 	}
 
 	if !validAspectRatios[o.AspectRatio] {
-		return fmt.Errorf("invalid aspect_ratio: %s (must be one of: auto, 16:9, 9:16)", o.AspectRatio)
+		return invalidEnum("aspect_ratio", o.AspectRatio, "auto", "16:9", "9:16")
 	}
 	if !validDurations[o.Duration] {
-		return fmt.Errorf("invalid duration: %s (must be one of: 4s, 6s, 8s)", o.Duration)
+		return invalidEnum("duration", o.Duration, "4s", "6s", "8s")
 	}
 	if !validResolutions[o.Resolution] {
-		return fmt.Errorf("invalid resolution: %s (must be one of: 720p, 1080p)", o.Resolution)
+		return invalidEnum("resolution", o.Resolution, "720p", "1080p")
 	}
 	return nil
 }
@@ -1363,13 +1671,13 @@ func (o *Veo31FastOptions) Validate() error {
 	}
 
 	if !validAspectRatios[o.AspectRatio] {
-		return fmt.Errorf("invalid aspect_ratio: %s (must be one of: auto, 16:9, 9:16)", o.AspectRatio)
+		return invalidEnum("aspect_ratio", o.AspectRatio, "auto", "16:9", "9:16")
 	}
 	if !validDurations[o.Duration] {
-		return fmt.Errorf("invalid duration: %s (must be one of: 4s, 6s, 8s)", o.Duration)
+		return invalidEnum("duration", o.Duration, "4s", "6s", "8s")
 	}
 	if !validResolutions[o.Resolution] {
-		return fmt.Errorf("invalid resolution: %s (must be one of: 720p, 1080p, 4k)", o.Resolution)
+		return invalidEnum("resolution", o.Resolution, "720p", "1080p", "4k")
 	}
 	return nil
 }
@@ -1420,13 +1728,13 @@ func (o *HunyuanVideoOptions) Validate() error {
 	}
 
 	if !validAspectRatios[o.AspectRatio] {
-		return fmt.Errorf("invalid aspect_ratio: %s", o.AspectRatio)
+		return invalidEnum("aspect_ratio", o.AspectRatio, allowedValues(validAspectRatios)...)
 	}
 	if !validResolutions[o.Resolution] {
-		return fmt.Errorf("invalid resolution: %s", o.Resolution)
+		return invalidEnum("resolution", o.Resolution, allowedValues(validResolutions)...)
 	}
 	if !validVideoLengths[o.VideoLength] {
-		return fmt.Errorf("invalid video_length: %s", o.VideoLength)
+		return invalidEnum("video_length", o.VideoLength, allowedValues(validVideoLengths)...)
 	}
 	return nil
 }
@@ -1465,13 +1773,13 @@ func (o *KlingVideoV25Options) Validate() error {
 	validAspectRatios := map[string]bool{"16:9": true, "9:16": true, "1:1": true, "": true}
 
 	if !validDurations[o.Duration] {
-		return fmt.Errorf("invalid duration: %s (must be 5 or 10)", o.Duration)
+		return invalidEnum("duration", o.Duration, "5", "10")
 	}
 	if !validAspectRatios[o.AspectRatio] {
-		return fmt.Errorf("invalid aspect_ratio: %s", o.AspectRatio)
+		return invalidEnum("aspect_ratio", o.AspectRatio, allowedValues(validAspectRatios)...)
 	}
 	if o.CFGScale < 0 || o.CFGScale > 1 {
-		return fmt.Errorf("invalid cfg_scale: %f (must be between 0 and 1)", o.CFGScale)
+		return invalidValue("cfg_scale", o.CFGScale, "must be between 0 and 1")
 	}
 	return nil
 }
@@ -1512,16 +1820,16 @@ func (o *LTXVideo13BOptions) GetDefaultValues() map[string]interface{} {
 // Validate validates LTX Video 13B options
 func (o *LTXVideo13BOptions) Validate() error {
 	if o.NumFrames < 0 {
-		return fmt.Errorf("num_frames cannot be negative: %d", o.NumFrames)
+		return invalidValue("num_frames", o.NumFrames, "cannot be negative")
 	}
 	if o.FrameRate < 0 {
-		return fmt.Errorf("frame_rate cannot be negative: %d", o.FrameRate)
+		return invalidValue("frame_rate", o.FrameRate, "cannot be negative")
 	}
 	if o.NumInferenceSteps < 0 {
-		return fmt.Errorf("num_inference_steps cannot be negative: %d", o.NumInferenceSteps)
+		return invalidValue("num_inference_steps", o.NumInferenceSteps, "cannot be negative")
 	}
 	if o.GuidanceScale < 0 {
-		return fmt.Errorf("guidance_scale cannot be negative: %f", o.GuidanceScale)
+		return invalidValue("guidance_scale", o.GuidanceScale, "cannot be negative")
 	}
 	return nil
 }
@@ -1555,7 +1863,7 @@ func (o *TopazUpscaleVideoOptions) GetDefaultValues() map[string]interface{} {
 func (o *TopazUpscaleVideoOptions) Validate() error {
 	validOutputTypes := map[string]bool{"mp4": true, "mov": true, "": true}
 	if !validOutputTypes[o.OutputType] {
-		return fmt.Errorf("invalid output_type: %s (must be mp4 or mov)", o.OutputType)
+		return invalidEnum("output_type", o.OutputType, "mp4", "mov")
 	}
 	return nil
 }
@@ -1592,10 +1900,10 @@ func (o *SyncLipsyncV2Options) Validate() error {
 	validModels := map[string]bool{"lipsync-2": true, "lipsync-2-pro": true, "": true}
 	validOutputTypes := map[string]bool{"mp4": true, "webm": true, "": true}
 	if !validModels[o.Model] {
-		return fmt.Errorf("invalid model: %s", o.Model)
+		return invalidEnum("model", o.Model, allowedValues(validModels)...)
 	}
 	if !validOutputTypes[o.OutputType] {
-		return fmt.Errorf("invalid output_type: %s", o.OutputType)
+		return invalidEnum("output_type", o.OutputType, allowedValues(validOutputTypes)...)
 	}
 	return nil
 }
@@ -1631,10 +1939,10 @@ func (o *MMAudioV2Options) GetDefaultValues() map[string]interface{} {
 // Validate validates MMAudio V2 options
 func (o *MMAudioV2Options) Validate() error {
 	if o.Duration < 0 {
-		return fmt.Errorf("duration cannot be negative: %f", o.Duration)
+		return invalidValue("duration", o.Duration, "cannot be negative")
 	}
 	if o.NumInferenceSteps < 0 {
-		return fmt.Errorf("num_inference_steps cannot be negative: %d", o.NumInferenceSteps)
+		return invalidValue("num_inference_steps", o.NumInferenceSteps, "cannot be negative")
 	}
 	return nil
 }
@@ -1669,7 +1977,7 @@ func (o *MinimaxMusicV2Options) GetDefaultValues() map[string]interface{} {
 // Validate validates Minimax Music V2 options
 func (o *MinimaxMusicV2Options) Validate() error {
 	if o.Duration < 1 || o.Duration > 300 {
-		return fmt.Errorf("duration must be between 1 and 300 seconds: %d", o.Duration)
+		return invalidValue("duration", o.Duration, "must be between 1 and 300 seconds")
 	}
 	return nil
 }
@@ -1707,11 +2015,11 @@ func (o *StableAudio25Options) GetDefaultValues() map[string]interface{} {
 // Validate validates Stable Audio 2.5 options
 func (o *StableAudio25Options) Validate() error {
 	if o.Duration < 1 || o.Duration > 180 {
-		return fmt.Errorf("duration must be between 1 and 180 seconds: %f", o.Duration)
+		return invalidValue("duration", o.Duration, "must be between 1 and 180 seconds")
 	}
 	validFormats := map[string]bool{"wav": true, "mp3": true, "ogg": true, "": true}
 	if !validFormats[o.OutputFormat] {
-		return fmt.Errorf("invalid output_format: %s", o.OutputFormat)
+		return invalidEnum("output_format", o.OutputFormat, allowedValues(validFormats)...)
 	}
 	return nil
 }
@@ -1747,10 +2055,10 @@ func (o *ChatterboxTTSOptions) GetDefaultValues() map[string]interface{} {
 // Validate validates Chatterbox TTS options
 func (o *ChatterboxTTSOptions) Validate() error {
 	if o.Exaggeration < 0 || o.Exaggeration > 1 {
-		return fmt.Errorf("exaggeration must be between 0 and 1: %f", o.Exaggeration)
+		return invalidValue("exaggeration", o.Exaggeration, "must be between 0 and 1")
 	}
 	if o.CFGWeight < 0 || o.CFGWeight > 1 {
-		return fmt.Errorf("cfg_weight must be between 0 and 1: %f", o.CFGWeight)
+		return invalidValue("cfg_weight", o.CFGWeight, "must be between 0 and 1")
 	}
 	return nil
 }
@@ -1790,10 +2098,10 @@ func (o *ElevenLabsDialogOptions) GetDefaultValues() map[string]interface{} {
 // Validate validates ElevenLabs Dialog options
 func (o *ElevenLabsDialogOptions) Validate() error {
 	if o.Stability < 0 || o.Stability > 1 {
-		return fmt.Errorf("stability must be between 0 and 1: %f", o.Stability)
+		return invalidValue("stability", o.Stability, "must be between 0 and 1")
 	}
 	if o.SimilarityBoost < 0 || o.SimilarityBoost > 1 {
-		return fmt.Errorf("similarity_boost must be between 0 and 1: %f", o.SimilarityBoost)
+		return invalidValue("similarity_boost", o.SimilarityBoost, "must be between 0 and 1")
 	}
 	return nil
 }
@@ -1844,16 +2152,16 @@ func (o *ElevenLabsTTSOptions) GetDefaultValues() map[string]interface{} {
 // Validate validates ElevenLabs TTS options
 func (o *ElevenLabsTTSOptions) Validate() error {
 	if o.Stability != nil && (*o.Stability < 0 || *o.Stability > 1) {
-		return fmt.Errorf("stability must be between 0 and 1: %f", *o.Stability)
+		return invalidValue("stability", *o.Stability, "must be between 0 and 1")
 	}
 	if o.SimilarityBoost != nil && (*o.SimilarityBoost < 0 || *o.SimilarityBoost > 1) {
-		return fmt.Errorf("similarity_boost must be between 0 and 1: %f", *o.SimilarityBoost)
+		return invalidValue("similarity_boost", *o.SimilarityBoost, "must be between 0 and 1")
 	}
 	if o.Style != nil && (*o.Style < 0 || *o.Style > 1) {
-		return fmt.Errorf("style must be between 0 and 1: %f", *o.Style)
+		return invalidValue("style", *o.Style, "must be between 0 and 1")
 	}
 	if o.Speed != nil && (*o.Speed < 0.25 || *o.Speed > 4.0) {
-		return fmt.Errorf("speed must be between 0.25 and 4.0: %f", *o.Speed)
+		return invalidValue("speed", *o.Speed, "must be between 0.25 and 4.0")
 	}
 	return nil
 }
@@ -1904,13 +2212,13 @@ func (o *ScribeV2Options) Validate() error {
 	validChunkLevels := map[string]bool{"segment": true, "word": true, "": true}
 
 	if !validTasks[o.Task] {
-		return fmt.Errorf("invalid task: %s (must be transcribe or translate)", o.Task)
+		return invalidEnum("task", o.Task, "transcribe", "translate")
 	}
 	if !validChunkLevels[o.ChunkLevel] {
-		return fmt.Errorf("invalid chunk_level: %s (must be segment or word)", o.ChunkLevel)
+		return invalidEnum("chunk_level", o.ChunkLevel, "segment", "word")
 	}
 	if o.NumSpeakers != nil && (*o.NumSpeakers < 1 || *o.NumSpeakers > 50) {
-		return fmt.Errorf("invalid num_speakers: %d (must be between 1 and 50)", *o.NumSpeakers)
+		return invalidValue("num_speakers", *o.NumSpeakers, "must be between 1 and 50")
 	}
 	return nil
 }
@@ -1950,6 +2258,16 @@ type ScribeV2Response struct {
 
 // ==================== ElevenLabs Voice Changer ====================
 
+// ElevenLabsVoices are the premade ElevenLabs voices the voice changer can
+// speak in.
+var ElevenLabsVoices = map[string]bool{
+	"Aria": true, "Roger": true, "Sarah": true, "Laura": true, "Charlie": true,
+	"George": true, "Callum": true, "River": true, "Liam": true, "Charlotte": true,
+	"Alice": true, "Matilda": true, "Will": true, "Jessica": true, "Eric": true,
+	"Chris": true, "Brian": true, "Daniel": true, "Lily": true, "Bill": true,
+	"Rachel": true,
+}
+
 // ElevenLabsVoiceChangerOptions represents options for elevenlabs/voice-changer
 type ElevenLabsVoiceChangerOptions struct {
 	Voice                 string `json:"voice,omitempty"`                   // Default: Rachel
@@ -1977,7 +2295,10 @@ func (o *ElevenLabsVoiceChangerOptions) Validate() error {
 		"opus_48000_128": true, "opus_48000_192": true, "": true,
 	}
 	if !validFormats[o.OutputFormat] {
-		return fmt.Errorf("invalid output_format: %s", o.OutputFormat)
+		return invalidEnum("output_format", o.OutputFormat, allowedValues(validFormats)...)
+	}
+	if o.Voice != "" && !ElevenLabsVoices[o.Voice] {
+		return invalidEnum("voice", o.Voice, allowedValues(ElevenLabsVoices)...)
 	}
 	return nil
 }
@@ -1997,6 +2318,33 @@ func (r *ElevenLabsVoiceChangerRequest) GetProgress() ProgressCallback {
 	return r.Progress
 }
 
+// ==================== ElevenLabs Audio Isolation ====================
+
+// AudioIsolationOptions represents options for elevenlabs/audio-isolation.
+// The endpoint takes no tunable parameters.
+type AudioIsolationOptions struct{}
+
+// GetDefaultValues returns default values for Audio Isolation options
+func (o *AudioIsolationOptions) GetDefaultValues() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+// Validate validates Audio Isolation options
+func (o *AudioIsolationOptions) Validate() error {
+	return nil
+}
+
+// AudioIsolationRequest represents a request for elevenlabs/audio-isolation
+type AudioIsolationRequest struct {
+	AudioURL string           `json:"audio_url"` // Required, http(s) URL or data URI
+	Progress ProgressCallback `json:"-"`
+}
+
+// GetProgress returns the progress callback
+func (r *AudioIsolationRequest) GetProgress() ProgressCallback {
+	return r.Progress
+}
+
 // ==================== Kling Video v2.6 Motion Control ====================
 
 // KlingVideoV26MotionControlOptions represents options for kling-video v2.6 motion control
@@ -2018,7 +2366,7 @@ func (o *KlingVideoV26MotionControlOptions) GetDefaultValues() map[string]interf
 func (o *KlingVideoV26MotionControlOptions) Validate() error {
 	validOrientations := map[string]bool{"image": true, "video": true, "": true}
 	if !validOrientations[o.CharacterOrientation] {
-		return fmt.Errorf("invalid character_orientation: %s (must be 'image' or 'video')", o.CharacterOrientation)
+		return invalidEnum("character_orientation", o.CharacterOrientation, "image", "video")
 	}
 	return nil
 }
@@ -2065,18 +2413,18 @@ func (o *GrokImagineVideoOptions) GetDefaultValues() map[string]interface{} {
 // Validate validates the Grok Imagine Video options
 func (o *GrokImagineVideoOptions) Validate() error {
 	if o.Duration != 0 && (o.Duration < 1 || o.Duration > 15) {
-		return fmt.Errorf("invalid duration: %d (must be between 1 and 15)", o.Duration)
+		return invalidValue("duration", o.Duration, "must be between 1 and 15")
 	}
 	validAspectRatios := map[string]bool{
 		"auto": true, "16:9": true, "4:3": true, "3:2": true,
 		"1:1": true, "2:3": true, "3:4": true, "9:16": true, "": true,
 	}
 	if !validAspectRatios[o.AspectRatio] {
-		return fmt.Errorf("invalid aspect_ratio: %s (must be auto, 16:9, 4:3, 3:2, 1:1, 2:3, 3:4, or 9:16)", o.AspectRatio)
+		return invalidEnum("aspect_ratio", o.AspectRatio, "auto", "16:9", "4:3", "3:2", "1:1", "2:3", "3:4", "9:16")
 	}
 	validResolutions := map[string]bool{"480p": true, "720p": true, "": true}
 	if !validResolutions[o.Resolution] {
-		return fmt.Errorf("invalid resolution: %s (must be 480p or 720p)", o.Resolution)
+		return invalidEnum("resolution", o.Resolution, "480p", "720p")
 	}
 	return nil
 }
@@ -2108,18 +2456,18 @@ func (o *GrokImagineVideoTextOptions) GetDefaultValues() map[string]interface{}
 // Validate validates the Grok Imagine Video Text options
 func (o *GrokImagineVideoTextOptions) Validate() error {
 	if o.Duration != 0 && (o.Duration < 1 || o.Duration > 15) {
-		return fmt.Errorf("invalid duration: %d (must be between 1 and 15)", o.Duration)
+		return invalidValue("duration", o.Duration, "must be between 1 and 15")
 	}
 	validAspectRatios := map[string]bool{
 		"16:9": true, "4:3": true, "3:2": true,
 		"1:1": true, "2:3": true, "3:4": true, "9:16": true, "": true,
 	}
 	if !validAspectRatios[o.AspectRatio] {
-		return fmt.Errorf("invalid aspect_ratio: %s (must be 16:9, 4:3, 3:2, 1:1, 2:3, 3:4, or 9:16)", o.AspectRatio)
+		return invalidEnum("aspect_ratio", o.AspectRatio, "16:9", "4:3", "3:2", "1:1", "2:3", "3:4", "9:16")
 	}
 	validResolutions := map[string]bool{"480p": true, "720p": true, "": true}
 	if !validResolutions[o.Resolution] {
-		return fmt.Errorf("invalid resolution: %s (must be 480p or 720p)", o.Resolution)
+		return invalidEnum("resolution", o.Resolution, "480p", "720p")
 	}
 	return nil
 }
@@ -2160,16 +2508,16 @@ func (o *KlingVideoV3Options) Validate() error {
 	validAspectRatios := map[string]bool{"16:9": true, "9:16": true, "1:1": true, "": true}
 
 	if !validAspectRatios[o.AspectRatio] {
-		return fmt.Errorf("invalid aspect_ratio: %s (must be 16:9, 9:16, or 1:1)", o.AspectRatio)
+		return invalidEnum("aspect_ratio", o.AspectRatio, "16:9", "9:16", "1:1")
 	}
 	if o.Duration != "" {
 		dur, err := strconv.Atoi(o.Duration)
 		if err != nil || dur < 3 || dur > 15 {
-			return fmt.Errorf("invalid duration: %s (must be between 3 and 15 seconds)", o.Duration)
+			return invalidValue("duration", o.Duration, "must be between 3 and 15 seconds")
 		}
 	}
 	if o.CFGScale < 0 || o.CFGScale > 1 {
-		return fmt.Errorf("invalid cfg_scale: %f (must be between 0 and 1)", o.CFGScale)
+		return invalidValue("cfg_scale", o.CFGScale, "must be between 0 and 1")
 	}
 	return nil
 }
@@ -2209,12 +2557,12 @@ func (o *KlingVideoO3TextOptions) Validate() error {
 	validAspectRatios := map[string]bool{"16:9": true, "9:16": true, "1:1": true, "": true}
 
 	if !validAspectRatios[o.AspectRatio] {
-		return fmt.Errorf("invalid aspect_ratio: %s (must be 16:9, 9:16, or 1:1)", o.AspectRatio)
+		return invalidEnum("aspect_ratio", o.AspectRatio, "16:9", "9:16", "1:1")
 	}
 	if o.Duration != "" {
 		dur, err := strconv.Atoi(o.Duration)
 		if err != nil || dur < 3 || dur > 15 {
-			return fmt.Errorf("invalid duration: %s (must be between 3 and 15 seconds)", o.Duration)
+			return invalidValue("duration", o.Duration, "must be between 3 and 15 seconds")
 		}
 	}
 	return nil
@@ -2254,6 +2602,40 @@ type KlingVideoO3EditRequest struct {
 	KeepAudio        *bool          `json:"keep_audio,omitempty"`
 }
 
+// ==================== Frame Interpolation (RIFE / FILM, Video2Video) ====================
+
+// FrameInterpolationOptions represents options for fal-ai/rife/video and fal-ai/film/video
+type FrameInterpolationOptions struct {
+	Factor     int   `json:"factor,omitempty"`      // Frame multiplier: 2 or 4. Default: 2
+	SlowMotion *bool `json:"slow_motion,omitempty"` // Keep the source fps so the clip plays slower. Default: false
+}
+
+// GetDefaultValues returns the default values for frame interpolation options
+func (o *FrameInterpolationOptions) GetDefaultValues() map[string]interface{} {
+	defaultSlowMotion := false
+	return map[string]interface{}{
+		"factor":      2,
+		"slow_motion": &defaultSlowMotion,
+	}
+}
+
+// Validate validates frame interpolation options
+func (o *FrameInterpolationOptions) Validate() error {
+	validFactors := map[int]bool{2: true, 4: true, 0: true}
+	if !validFactors[o.Factor] {
+		return invalidEnum("factor", o.Factor, "2", "4")
+	}
+	return nil
+}
+
+// FrameInterpolationRequest represents a request for frame interpolation video2video models
+type FrameInterpolationRequest struct {
+	BaseVideoRequest        // Embeds Progress, QueueInfo, Model
+	VideoURL         string `json:"video_url"`
+	Factor           int    `json:"-"`
+	SlowMotion       *bool  `json:"-"`
+}
+
 // ==================== Seedance 2.0 (ByteDance, Text2Video + Image2Video) ====================
 
 // SeedanceOptions represents options for bytedance/seedance-2.0 text-to-video and image-to-video
@@ -2282,16 +2664,16 @@ func (o *SeedanceOptions) Validate() error {
 		"1:1": true, "3:4": true, "9:16": true, "": true,
 	}
 	if !validAspectRatios[o.AspectRatio] {
-		return fmt.Errorf("invalid aspect_ratio: %s (must be auto, 21:9, 16:9, 4:3, 1:1, 3:4, or 9:16)", o.AspectRatio)
+		return invalidEnum("aspect_ratio", o.AspectRatio, "auto", "21:9", "16:9", "4:3", "1:1", "3:4", "9:16")
 	}
 	validResolutions := map[string]bool{"480p": true, "720p": true, "1080p": true, "4k": true, "": true}
 	if !validResolutions[o.Resolution] {
-		return fmt.Errorf("invalid resolution: %s (must be 480p, 720p, 1080p, or 4k)", o.Resolution)
+		return invalidEnum("resolution", o.Resolution, "480p", "720p", "1080p", "4k")
 	}
 	if o.Duration != "" && o.Duration != "auto" {
 		dur, err := strconv.Atoi(o.Duration)
 		if err != nil || dur < 4 || dur > 15 {
-			return fmt.Errorf("invalid duration: %s (must be auto or integer between 4 and 15 seconds)", o.Duration)
+			return invalidValue("duration", o.Duration, "must be auto or integer between 4 and 15 seconds")
 		}
 	}
 	return nil
@@ -2337,16 +2719,16 @@ func (o *SeedanceReferenceOptions) Validate() error {
 		"1:1": true, "3:4": true, "9:16": true, "": true,
 	}
 	if !validAspectRatios[o.AspectRatio] {
-		return fmt.Errorf("invalid aspect_ratio: %s (must be auto, 21:9, 16:9, 4:3, 1:1, 3:4, or 9:16)", o.AspectRatio)
+		return invalidEnum("aspect_ratio", o.AspectRatio, "auto", "21:9", "16:9", "4:3", "1:1", "3:4", "9:16")
 	}
 	validResolutions := map[string]bool{"480p": true, "720p": true, "1080p": true, "4k": true, "": true}
 	if !validResolutions[o.Resolution] {
-		return fmt.Errorf("invalid resolution: %s (must be 480p, 720p, 1080p, or 4k)", o.Resolution)
+		return invalidEnum("resolution", o.Resolution, "480p", "720p", "1080p", "4k")
 	}
 	if o.Duration != "" && o.Duration != "auto" {
 		dur, err := strconv.Atoi(o.Duration)
 		if err != nil || dur < 4 || dur > 15 {
-			return fmt.Errorf("invalid duration: %s (must be auto or integer between 4 and 15 seconds)", o.Duration)
+			return invalidValue("duration", o.Duration, "must be auto or integer between 4 and 15 seconds")
 		}
 	}
 	return nil
@@ -2365,3 +2747,248 @@ type SeedanceReferenceRequest struct {
 	Seed          *int64   `json:"seed,omitempty"`
 	EndUserID     string   `json:"end_user_id,omitempty"` // Required by ByteDance for copyright tracking
 }
+
+// ==================== Any LLM (Chat) ====================
+
+// anyLLMModels are the language models fal-ai/any-llm routes to.
+var anyLLMModels = map[string]bool{
+	"anthropic/claude-3.5-sonnet":       true,
+	"anthropic/claude-3-5-haiku":        true,
+	"anthropic/claude-3-haiku":          true,
+	"google/gemini-pro-1.5":             true,
+	"google/gemini-flash-1.5":           true,
+	"google/gemini-flash-1.5-8b":        true,
+	"meta-llama/llama-3.2-1b-instruct":  true,
+	"meta-llama/llama-3.2-3b-instruct":  true,
+	"meta-llama/llama-3.1-8b-instruct":  true,
+	"meta-llama/llama-3.1-70b-instruct": true,
+	"openai/gpt-4o-mini":                true,
+	"openai/gpt-4o":                     true,
+	"deepseek/deepseek-r1":              true,
+}
+
+// AnyLLMOptions represents options for fal-ai/any-llm
+type AnyLLMOptions struct {
+	Model string `json:"model,omitempty"` // Language model, default: google/gemini-flash-1.5
+}
+
+// GetDefaultValues returns default values for Any LLM options
+func (o *AnyLLMOptions) GetDefaultValues() map[string]interface{} {
+	return map[string]interface{}{
+		"model": "google/gemini-flash-1.5",
+	}
+}
+
+// Validate validates Any LLM options
+func (o *AnyLLMOptions) Validate() error {
+	if o.Model != "" && !anyLLMModels[o.Model] {
+		return invalidEnum("model", o.Model, allowedValues(anyLLMModels)...)
+	}
+	return nil
+}
+
+// ChatRequest represents a request for fal-ai/any-llm
+type ChatRequest struct {
+	Prompt       string           `json:"prompt"`                  // Required
+	SystemPrompt string           `json:"system_prompt,omitempty"` // Optional
+	Model        string           `json:"model,omitempty"`         // Optional, see AnyLLMOptions
+	Progress     ProgressCallback `json:"-"`
+}
+
+// GetProgress returns the progress callback
+func (r *ChatRequest) GetProgress() ProgressCallback {
+	return r.Progress
+}
+
+// ChatUsage is the number of tokens a chat request used, when the model
+// reports it.
+type ChatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// ChatResponse represents a response, or a part of a streamed response, of
+// Any LLM
+type ChatResponse struct {
+	Output    string     `json:"output"`
+	Reasoning string     `json:"reasoning,omitempty"`
+	Partial   bool       `json:"partial"`         // Set while the reply is still being written
+	Error     string     `json:"error,omitempty"` // Set when the model failed
+	Usage     *ChatUsage `json:"usage,omitempty"`
+}
+
+// ==================== LLaVA Next (Vision) ====================
+
+// LlavaNextOptions represents options for fal-ai/llava-next
+type LlavaNextOptions struct {
+	MaxTokens   int     `json:"max_tokens,omitempty"`  // 1 to 1024, default: 64
+	Temperature float64 `json:"temperature,omitempty"` // 0 to 1, default: 0.2
+	TopP        float64 `json:"top_p,omitempty"`       // 0 to 1, default: 1
+}
+
+// GetDefaultValues returns default values for LLaVA Next options
+func (o *LlavaNextOptions) GetDefaultValues() map[string]interface{} {
+	return map[string]interface{}{
+		"max_tokens":  64,
+		"temperature": 0.2,
+		"top_p":       1.0,
+	}
+}
+
+// Validate validates LLaVA Next options
+func (o *LlavaNextOptions) Validate() error {
+	if o.MaxTokens < 0 || o.MaxTokens > 1024 {
+		return invalidValue("max_tokens", o.MaxTokens, "must be between 1 and 1024")
+	}
+	if o.Temperature < 0 || o.Temperature > 1 {
+		return invalidValue("temperature", o.Temperature, "must be between 0 and 1")
+	}
+	if o.TopP < 0 || o.TopP > 1 {
+		return invalidValue("top_p", o.TopP, "must be between 0 and 1")
+	}
+	return nil
+}
+
+// VisionRequest represents a request to describe an image with an image2text
+// model
+type VisionRequest struct {
+	ImageURL    string           `json:"image_url"`             // Required
+	Prompt      string           `json:"prompt"`                // Required, what to ask about the image
+	MaxTokens   int              `json:"max_tokens,omitempty"`  // Optional
+	Temperature float64          `json:"temperature,omitempty"` // Optional
+	Model       string           `json:"-"`                     // Internal use: model name
+	Progress    ProgressCallback `json:"-"`
+}
+
+// GetProgress returns the progress callback
+func (r *VisionRequest) GetProgress() ProgressCallback {
+	return r.Progress
+}
+
+// VisionResponse represents the response of an image2text model
+type VisionResponse struct {
+	Output  string `json:"output"`
+	Partial bool   `json:"partial"`
+}
+
+// ==================== Image to 3D ====================
+
+// Model3DFormats are the 3D file formats image23d models can return.
+var Model3DFormats = map[string]bool{
+	"glb": true,
+	"obj": true,
+}
+
+// TripoSROptions represents options for fal-ai/triposr
+type TripoSROptions struct {
+	OutputFormat       string  `json:"output_format,omitempty"`        // glb, obj. Default: glb
+	DoRemoveBackground *bool   `json:"do_remove_background,omitempty"` // Default: true
+	ForegroundRatio    float64 `json:"foreground_ratio,omitempty"`     // 0.5 to 1, default: 0.9
+	MCResolution       int     `json:"mc_resolution,omitempty"`        // 32 to 1024, default: 256
+}
+
+// GetDefaultValues returns default values for TripoSR options
+func (o *TripoSROptions) GetDefaultValues() map[string]interface{} {
+	removeBackground := true
+	return map[string]interface{}{
+		"output_format":        "glb",
+		"do_remove_background": &removeBackground,
+		"foreground_ratio":     0.9,
+		"mc_resolution":        256,
+	}
+}
+
+// Validate validates TripoSR options
+func (o *TripoSROptions) Validate() error {
+	if o.OutputFormat != "" && !Model3DFormats[o.OutputFormat] {
+		return invalidEnum("output_format", o.OutputFormat, allowedValues(Model3DFormats)...)
+	}
+	if o.ForegroundRatio != 0 && (o.ForegroundRatio < 0.5 || o.ForegroundRatio > 1) {
+		return invalidValue("foreground_ratio", o.ForegroundRatio, "must be between 0.5 and 1")
+	}
+	if o.MCResolution != 0 && (o.MCResolution < 32 || o.MCResolution > 1024) {
+		return invalidValue("mc_resolution", o.MCResolution, "must be between 32 and 1024")
+	}
+	return nil
+}
+
+// Hunyuan3DOptions represents options for fal-ai/hunyuan3d/v2
+type Hunyuan3DOptions struct {
+	Seed              *int    `json:"seed,omitempty"`                // Optional
+	NumInferenceSteps int     `json:"num_inference_steps,omitempty"` // 1 to 50, default: 50
+	GuidanceScale     float64 `json:"guidance_scale,omitempty"`      // 0 to 20, default: 7.5
+	OctreeResolution  int     `json:"octree_resolution,omitempty"`   // 1 to 1024, default: 256
+	TexturedMesh      bool    `json:"textured_mesh,omitempty"`       // Default: false, textures cost three times as much
+}
+
+// GetDefaultValues returns default values for Hunyuan3D options
+func (o *Hunyuan3DOptions) GetDefaultValues() map[string]interface{} {
+	return map[string]interface{}{
+		"num_inference_steps": 50,
+		"guidance_scale":      7.5,
+		"octree_resolution":   256,
+		"textured_mesh":       false,
+	}
+}
+
+// Validate validates Hunyuan3D options
+func (o *Hunyuan3DOptions) Validate() error {
+	if o.NumInferenceSteps != 0 && (o.NumInferenceSteps < 1 || o.NumInferenceSteps > 50) {
+		return invalidValue("num_inference_steps", o.NumInferenceSteps, "must be between 1 and 50")
+	}
+	if o.GuidanceScale < 0 || o.GuidanceScale > 20 {
+		return invalidValue("guidance_scale", o.GuidanceScale, "must be between 0 and 20")
+	}
+	if o.OctreeResolution != 0 && (o.OctreeResolution < 1 || o.OctreeResolution > 1024) {
+		return invalidValue("octree_resolution", o.OctreeResolution, "must be between 1 and 1024")
+	}
+	return nil
+}
+
+// BaseModel3DRequest represents the base fields for an image23d request
+type BaseModel3DRequest struct {
+	ImageURL string                 `json:"image_url"` // Required
+	Model    string                 `json:"-"`         // Internal use: model name
+	Options  map[string]interface{} `json:"-"`         // Fallback for generic options
+	Progress ProgressCallback       `json:"-"`
+}
+
+// GetProgress returns the progress callback
+func (r *BaseModel3DRequest) GetProgress() ProgressCallback {
+	return r.Progress
+}
+
+// GetOptions returns the options map
+func (r *BaseModel3DRequest) GetOptions() map[string]interface{} {
+	return r.Options
+}
+
+// TripoSRRequest represents a request to turn an image into a 3D model with
+// triposr
+type TripoSRRequest struct {
+	BaseModel3DRequest
+	OutputFormat       string  `json:"output_format,omitempty"`        // glb or obj
+	DoRemoveBackground *bool   `json:"do_remove_background,omitempty"` // Optional
+	ForegroundRatio    float64 `json:"foreground_ratio,omitempty"`     // Optional
+	MCResolution       int     `json:"mc_resolution,omitempty"`        // Optional
+}
+
+// Hunyuan3DRequest represents a request to turn an image into a 3D model
+// with hunyuan3d-v2. It always returns GLB.
+type Hunyuan3DRequest struct {
+	BaseModel3DRequest
+	Seed              *int    `json:"seed,omitempty"`                // Optional
+	NumInferenceSteps int     `json:"num_inference_steps,omitempty"` // Optional
+	GuidanceScale     float64 `json:"guidance_scale,omitempty"`      // Optional
+	OctreeResolution  int     `json:"octree_resolution,omitempty"`   // Optional
+	TexturedMesh      bool    `json:"textured_mesh,omitempty"`       // Optional
+}
+
+// Model3DResponse represents the 3D model file an image23d model returns
+type Model3DResponse struct {
+	ModelURL    string `json:"model_url"`
+	ContentType string `json:"content_type"`
+	FileName    string `json:"file_name"`
+	FileSize    int    `json:"file_size"`
+	Format      string `json:"format"` // glb or obj
+}
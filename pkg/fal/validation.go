@@ -0,0 +1,56 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationError is returned by ModelOptions.Validate when an option has a
+// value the model does not accept. Callers can unwrap it with errors.As to
+// render per-field guidance instead of the raw message.
+type ValidationError struct {
+	Field      string      // Option name as sent to fal.ai, e.g. "aspect_ratio"
+	Value      interface{} // The rejected value
+	Allowed    []string    // Accepted values when the option is an enum
+	Constraint string      // Human readable rule when it is not, e.g. "must be between 0 and 1"
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	msg := fmt.Sprintf("invalid %s: %v", e.Field, e.Value)
+	switch {
+	case len(e.Allowed) > 0:
+		msg += fmt.Sprintf(" (must be one of: %s)", strings.Join(e.Allowed, ", "))
+	case e.Constraint != "":
+		msg += fmt.Sprintf(" (%s)", e.Constraint)
+	}
+	return msg
+}
+
+// invalidEnum reports a value that is not one of the allowed values.
+func invalidEnum(field string, value interface{}, allowed ...string) *ValidationError {
+	return &ValidationError{Field: field, Value: value, Allowed: allowed}
+}
+
+// invalidValue reports a value that breaks a non-enum constraint.
+func invalidValue(field string, value interface{}, constraint string) *ValidationError {
+	return &ValidationError{Field: field, Value: value, Constraint: constraint}
+}
+
+// allowedValues returns the accepted values of a validation set in sorted
+// order, leaving out the empty value that marks an option as optional.
+func allowedValues(valid map[string]bool) []string {
+	allowed := make([]string, 0, len(valid))
+	for v, ok := range valid {
+		if ok && v != "" {
+			allowed = append(allowed, v)
+		}
+	}
+	sort.Strings(allowed)
+	return allowed
+}
@@ -0,0 +1,55 @@
+package fal
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestValidationErrors(t *testing.T) {
+	speed := 3.0
+	tests := []struct {
+		name       string
+		opts       ModelOptions
+		field      string
+		value      interface{}
+		allowed    []string
+		constraint string
+	}{
+		{"listed enum", &Veo2Options{AspectRatio: "4:3"}, "aspect_ratio", "4:3",
+			[]string{"auto", "auto_prefer_portrait", "16:9", "9:16"}, ""},
+		{"enum from set", &MinimaxTTSOptions{Channel: "3"}, "channel", "3", []string{"1", "2"}, ""},
+		{"range", &MinimaxTTSOptions{Speed: &speed}, "speed", 3.0, nil, "must be between 0.5 and 2.0"},
+		{"negative", &FluxSchnellOptions{NumInferenceSteps: -1}, "num_inference_steps", -1, nil, "cannot be negative"},
+		{"transparent format", &BiRefNetOptions{OutputFormat: "jpeg"}, "output_format", "jpeg", []string{"png", "webp", "gif"}, ""},
+		{"fill tolerance", &FluxProFillOptions{SafetyTolerance: "7"}, "safety_tolerance", "7",
+			[]string{"1", "2", "3", "4", "5", "6"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("invalid options for test: %w", tt.opts.Validate())
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("error %v is not a *ValidationError", err)
+			}
+			if verr.Field != tt.field || verr.Value != tt.value || verr.Constraint != tt.constraint {
+				t.Errorf("got %+v, want field %s value %v constraint %q", verr, tt.field, tt.value, tt.constraint)
+			}
+			if !reflect.DeepEqual(verr.Allowed, tt.allowed) {
+				t.Errorf("allowed = %v, want %v", verr.Allowed, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := invalidEnum("output_format", "gif", "jpeg", "png")
+	if got, want := err.Error(), "invalid output_format: gif (must be one of: jpeg, png)"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	err = invalidValue("num_images", 9, "must be 1-4")
+	if got, want := err.Error(), "invalid num_images: 9 (must be 1-4)"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
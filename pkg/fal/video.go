@@ -9,11 +9,10 @@ import (
 	// "time" // Removed, no longer needed
 )
 
-// GenerateVideo sends a request to the video model and returns the video URL
+// GenerateVideo sends a request to the video model and returns the video URL.
+// It accepts the request type of every built-in video model and any request
+// type registered with RegisterRequestHandler for a video model.
 func (c *Client) GenerateVideo(ctx context.Context, req interface{}) (*VideoResponse, error) {
-	var modelName string
-	var endpoint string
-	var reqBody map[string]interface{}
 	var progress ProgressCallback
 	var queueInfo QueueInfoCallback
 
@@ -27,935 +26,1198 @@ func (c *Client) GenerateVideo(ctx context.Context, req interface{}) (*VideoResp
 		queueInfo = queueInfoable.GetQueueInfo()
 	}
 
-	// Determine model name, endpoint and create request body based on request type
-	switch r := req.(type) {
-	case *Veo2Request:
-		modelName = "veo2"
-		// Get model options
-		model, exists := GetModel(modelName, "image2video") // Veo2 is image2video
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
-		options, ok := model.Options.(*Veo2Options)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
-		// Validate options before proceeding
-		if err := options.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		// Set default values from model options if not provided in request
-		if r.AspectRatio == "" {
-			r.AspectRatio = options.AspectRatio
-		}
-		if r.Duration == "" {
-			r.Duration = options.Duration
-		}
-		reqBody = map[string]interface{}{
-			"prompt":       r.Prompt,
-			"image_url":    r.ImageURL,
-			"aspect_ratio": r.AspectRatio,
-			"duration":     r.Duration,
-		}
-	case *KlingVideoRequest:
-		if r.BaseVideoRequest.Model == "" { // Determine model based on fields if not set
-			if r.BaseVideoRequest.ImageURL != "" {
-				r.BaseVideoRequest.Model = "kling-video-image"
-			} else {
-				r.BaseVideoRequest.Model = "kling-video-text"
-			}
-		}
-		modelName = r.BaseVideoRequest.Model
-		model, exists := GetModel(modelName, "text2video") // Check both types
-		if !exists {
-			model, exists = GetModel(modelName, "image2video")
-		}
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
+	spec, decode, err := buildRequest(req, "video", videoModelTypes, decodeVideoResponse)
+	if err != nil {
+		return nil, err
+	}
 
-		// Get model options for validation and defaults
-		options, ok := model.Options.(*KlingVideoOptions)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
-		// Validate options before proceeding
-		klingOpts := KlingVideoOptions{
-			Duration:       r.Duration,
-			AspectRatio:    r.AspectRatio,
-			NegativePrompt: r.NegativePrompt,
-			CFGScale:       r.CFGScale,
-		}
-		if err := klingOpts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
+	// Execute the workflow
+	result, err := c.executeAsyncWorkflowWithCallback(ctx, spec.Endpoint, spec.Body, progress, decode, queueInfo)
+	if err != nil {
+		return nil, err // Error already wrapped
+	}
+	resp, ok := result.(*VideoResponse)
+	if !ok {
+		return nil, fmt.Errorf("decoder for %T returned %T, want *VideoResponse", req, result)
+	}
+	return resp, nil
+}
 
-		// Set default values if not provided
-		if r.Duration == "" {
-			r.Duration = options.Duration
-		}
-		if r.AspectRatio == "" {
-			r.AspectRatio = options.AspectRatio
-		}
-		if r.NegativePrompt == "" {
-			r.NegativePrompt = options.NegativePrompt
-		}
-		if r.CFGScale == 0 {
-			r.CFGScale = options.CFGScale
-		}
-		reqBody = map[string]interface{}{
-			"prompt":          r.Prompt,   // May be empty for image2video
-			"image_url":       r.ImageURL, // May be empty for text2video
-			"duration":        r.Duration,
-			"aspect_ratio":    r.AspectRatio,
-			"negative_prompt": r.NegativePrompt,
-			"cfg_scale":       r.CFGScale,
-		}
-		// Remove empty fields that are not applicable
-		if r.ImageURL == "" {
-			delete(reqBody, "image_url")
-		}
-		if r.Prompt == "" {
-			delete(reqBody, "prompt")
-		}
-		// Remove empty fields only if ImageURL was expected but not provided
-		// Use the original modelType for this check (image2video)
-		var originalModelType string
+// decodeVideoResponse parses the final response of a video model.
+func decodeVideoResponse(data []byte) (interface{}, error) {
+	var videoResp VideoResponse
+	if err := json.Unmarshal(data, &videoResp); err != nil {
+		return nil, fmt.Errorf("failed to parse final video response: %w, body: %s", err, string(data))
+	}
+	// Check if any of the video URL fields are populated
+	if videoResp.GetURL() == "" {
+		return nil, fmt.Errorf("no video URL found in the response: %s", string(data))
+	}
+	return &videoResp, nil
+}
+
+func init() {
+	mustRegisterRequestHandler(&Veo2Request{}, buildVeo2)
+	mustRegisterRequestHandler(&KlingVideoRequest{}, buildKlingVideo)
+	mustRegisterRequestHandler(&MinimaxDirectorRequest{}, buildMinimaxDirector)
+	mustRegisterRequestHandler(&MinimaxSubjectReferenceRequest{}, buildMinimaxSubjectReference)
+	mustRegisterRequestHandler(&MinimaxLiveRequest{}, buildMinimaxLive)
+	mustRegisterRequestHandler(&MinimaxVideo01Request{}, buildMinimaxVideo01)
+	mustRegisterRequestHandler(&BaseVideoRequest{}, buildBaseVideo)
+	mustRegisterRequestHandler(&MinimaxHailuo02Request{}, buildMinimaxHailuo02)
+	mustRegisterRequestHandler(&Veo3Request{}, buildVeo3)
+	mustRegisterRequestHandler(&Veo31FastRequest{}, buildVeo31Fast)
+	mustRegisterRequestHandler(&KlingVideoV26MotionControlRequest{}, buildKlingVideoV26MotionControl)
+	mustRegisterRequestHandler(&GrokImagineVideoRequest{}, buildGrokImagineVideo)
+	mustRegisterRequestHandler(&KlingVideoV3Request{}, buildKlingVideoV3)
+	mustRegisterRequestHandler(&SeedanceRequest{}, buildSeedance)
+	mustRegisterRequestHandler(&SeedanceReferenceRequest{}, buildSeedanceReference)
+	mustRegisterRequestHandler(&KlingVideoO3TextRequest{}, buildKlingVideoO3Text)
+	mustRegisterRequestHandler(&KlingVideoO3EditRequest{}, buildKlingVideoO3Edit)
+	mustRegisterRequestHandler(&TopazUpscaleVideoRequest{}, buildTopazUpscaleVideo)
+	mustRegisterRequestHandler(&SyncLipsyncV2Request{}, buildSyncLipsyncV2)
+	mustRegisterRequestHandler(&FrameInterpolationRequest{}, buildFrameInterpolation)
+	mustRegisterRequestHandler(&GrokImagineVideoTextRequest{}, buildGrokImagineVideoText)
+}
+
+// buildVeo2 validates a Veo2Request and builds its request body.
+func buildVeo2(req interface{}) (*RequestSpec, error) {
+	r := req.(*Veo2Request)
+	spec := &RequestSpec{}
+	spec.Model = "veo2"
+	// Get model options
+	model, exists := GetModel(spec.Model, "image2video") // Veo2 is image2video
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*Veo2Options)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+	// Validate options before proceeding
+	if err := options.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// Set default values from model options if not provided in request
+	if r.AspectRatio == "" {
+		r.AspectRatio = options.AspectRatio
+	}
+	if r.Duration == "" {
+		r.Duration = options.Duration
+	}
+	spec.Body = map[string]interface{}{
+		"prompt":       r.Prompt,
+		"image_url":    r.ImageURL,
+		"aspect_ratio": r.AspectRatio,
+		"duration":     r.Duration,
+	}
+	return spec, nil
+}
+
+// buildKlingVideo validates a KlingVideoRequest and builds its request body.
+func buildKlingVideo(req interface{}) (*RequestSpec, error) {
+	r := req.(*KlingVideoRequest)
+	spec := &RequestSpec{}
+	if r.BaseVideoRequest.Model == "" { // Determine model based on fields if not set
 		if r.BaseVideoRequest.ImageURL != "" {
-			originalModelType = "image2video"
+			r.BaseVideoRequest.Model = "kling-video-image"
 		} else {
-			originalModelType = "text2video"
-		}
-		if originalModelType == "image2video" && r.ImageURL == "" {
-			delete(reqBody, "image_url") // Should ideally be caught earlier
-		}
-	case *MinimaxDirectorRequest:
-		modelName = "minimax/video-01-director"
-		model, exists := GetModel(modelName, "text2video")
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
-		options, ok := model.Options.(*MinimaxDirectorOptions)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
-		// Validate options
-		miniOpts := MinimaxDirectorOptions{
-			PromptOptimizer: r.PromptOptimizer,
-		}
-		if err := miniOpts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
+			r.BaseVideoRequest.Model = "kling-video-text"
 		}
+	}
+	spec.Model = r.BaseVideoRequest.Model
+	model, exists := GetModel(spec.Model, "text2video") // Check both types
+	if !exists {
+		model, exists = GetModel(spec.Model, "image2video")
+	}
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
 
-		// Set default values if not provided
-		// Need pointer comparison for boolean option
-		if r.PromptOptimizer == nil {
-			r.PromptOptimizer = options.PromptOptimizer // Get default from model definition
-		}
+	// Get model options for validation and defaults
+	options, ok := model.Options.(*KlingVideoOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+	// Validate options before proceeding
+	klingOpts := KlingVideoOptions{
+		Duration:       r.Duration,
+		AspectRatio:    r.AspectRatio,
+		NegativePrompt: r.NegativePrompt,
+		CFGScale:       r.CFGScale,
+	}
+	if err := klingOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
 
-		reqBody = map[string]interface{}{
-			"prompt":           r.Prompt,
-			"prompt_optimizer": r.PromptOptimizer,
-		}
-		if r.Prompt == "" {
-			return nil, fmt.Errorf("prompt cannot be empty for %s", modelName)
-		}
-	case *MinimaxSubjectReferenceRequest:
-		modelName = "minimax/video-01-subject-reference"
-		model, exists := GetModel(modelName, "image2video")
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
-		options, ok := model.Options.(*MinimaxSubjectReferenceOptions)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
-		// Validate options
-		subRefOpts := MinimaxSubjectReferenceOptions{
-			PromptOptimizer: r.PromptOptimizer,
-		}
-		if err := subRefOpts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		// Set default values if not provided
-		if r.PromptOptimizer == nil {
-			r.PromptOptimizer = options.PromptOptimizer
-		}
-		reqBody = map[string]interface{}{
-			"prompt":                      r.Prompt,
-			"subject_reference_image_url": r.SubjectReferenceImageURL,
-			"prompt_optimizer":            r.PromptOptimizer,
-		}
-		if r.Prompt == "" {
-			return nil, fmt.Errorf("prompt cannot be empty for %s", modelName)
-		}
-		if r.SubjectReferenceImageURL == "" {
-			return nil, fmt.Errorf("subject_reference_image_url cannot be empty for %s", modelName)
-		}
-	case *MinimaxLiveRequest:
-		modelName = "minimax/video-01-live"
-		model, exists := GetModel(modelName, "image2video")
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
-		options, ok := model.Options.(*MinimaxLiveOptions)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
-		// Validate options
-		liveOpts := MinimaxLiveOptions{
-			PromptOptimizer: r.PromptOptimizer,
-		}
-		if err := liveOpts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		// Set default values if not provided
-		if r.PromptOptimizer == nil {
-			r.PromptOptimizer = options.PromptOptimizer
-		}
-		reqBody = map[string]interface{}{
-			"prompt":           r.Prompt,
-			"image_url":        r.ImageURL,
-			"prompt_optimizer": r.PromptOptimizer,
-		}
-		if r.Prompt == "" {
-			return nil, fmt.Errorf("prompt cannot be empty for %s", modelName)
-		}
-		if r.ImageURL == "" {
-			return nil, fmt.Errorf("image_url cannot be empty for %s", modelName)
-		}
-	case *MinimaxVideo01Request:
-		modelName = "minimax/video-01"
-		model, exists := GetModel(modelName, "text2video")
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
-		options, ok := model.Options.(*MinimaxVideo01Options)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
-		// Validate options
-		vidOpts := MinimaxVideo01Options{
-			PromptOptimizer: r.PromptOptimizer,
-		}
-		if err := vidOpts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		// Set default values if not provided
-		if r.PromptOptimizer == nil {
-			r.PromptOptimizer = options.PromptOptimizer
-		}
-		reqBody = map[string]interface{}{
-			"prompt":           r.Prompt,
-			"prompt_optimizer": r.PromptOptimizer,
-		}
-		if r.Prompt == "" {
-			return nil, fmt.Errorf("prompt cannot be empty for %s", modelName)
-		}
-		// Ensure ImageURL is not included (should be empty in BaseVideoRequest for text2video)
-		delete(reqBody, "image_url")
-	case *BaseVideoRequest: // Handle potentially ambiguous base request
-		modelName = r.Model
-		model, exists := GetModel(modelName, "image2video")
+	// Set default values if not provided
+	if r.Duration == "" {
+		r.Duration = options.Duration
+	}
+	if r.AspectRatio == "" {
+		r.AspectRatio = options.AspectRatio
+	}
+	if r.NegativePrompt == "" {
+		r.NegativePrompt = options.NegativePrompt
+	}
+	if r.CFGScale == 0 {
+		r.CFGScale = options.CFGScale
+	}
+	spec.Body = map[string]interface{}{
+		"prompt":          r.Prompt,   // May be empty for image2video
+		"image_url":       r.ImageURL, // May be empty for text2video
+		"duration":        r.Duration,
+		"aspect_ratio":    r.AspectRatio,
+		"negative_prompt": r.NegativePrompt,
+		"cfg_scale":       r.CFGScale,
+	}
+	// Remove empty fields that are not applicable
+	if r.ImageURL == "" {
+		delete(spec.Body, "image_url")
+	}
+	if r.Prompt == "" {
+		delete(spec.Body, "prompt")
+	}
+	// Remove empty fields only if ImageURL was expected but not provided
+	// Use the original modelType for this check (image2video)
+	var originalModelType string
+	if r.BaseVideoRequest.ImageURL != "" {
+		originalModelType = "image2video"
+	} else {
+		originalModelType = "text2video"
+	}
+	if originalModelType == "image2video" && r.ImageURL == "" {
+		delete(spec.Body, "image_url") // Should ideally be caught earlier
+	}
+	return spec, nil
+}
+
+// buildMinimaxDirector validates a MinimaxDirectorRequest and builds its request body.
+func buildMinimaxDirector(req interface{}) (*RequestSpec, error) {
+	r := req.(*MinimaxDirectorRequest)
+	spec := &RequestSpec{}
+	spec.Model = "minimax/video-01-director"
+	model, exists := GetModel(spec.Model, "text2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*MinimaxDirectorOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+	// Validate options
+	miniOpts := MinimaxDirectorOptions{
+		PromptOptimizer: r.PromptOptimizer,
+	}
+	if err := miniOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+
+	// Set default values if not provided
+	// Need pointer comparison for boolean option
+	if r.PromptOptimizer == nil {
+		r.PromptOptimizer = options.PromptOptimizer // Get default from model definition
+	}
+
+	spec.Body = map[string]interface{}{
+		"prompt":           r.Prompt,
+		"prompt_optimizer": r.PromptOptimizer,
+	}
+	if r.Prompt == "" {
+		return nil, fmt.Errorf("prompt cannot be empty for %s", spec.Model)
+	}
+	return spec, nil
+}
+
+// buildMinimaxSubjectReference validates a MinimaxSubjectReferenceRequest and builds its request body.
+func buildMinimaxSubjectReference(req interface{}) (*RequestSpec, error) {
+	r := req.(*MinimaxSubjectReferenceRequest)
+	spec := &RequestSpec{}
+	spec.Model = "minimax/video-01-subject-reference"
+	model, exists := GetModel(spec.Model, "image2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*MinimaxSubjectReferenceOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+	// Validate options
+	subRefOpts := MinimaxSubjectReferenceOptions{
+		PromptOptimizer: r.PromptOptimizer,
+	}
+	if err := subRefOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// Set default values if not provided
+	if r.PromptOptimizer == nil {
+		r.PromptOptimizer = options.PromptOptimizer
+	}
+	spec.Body = map[string]interface{}{
+		"prompt":                      r.Prompt,
+		"subject_reference_image_url": r.SubjectReferenceImageURL,
+		"prompt_optimizer":            r.PromptOptimizer,
+	}
+	if r.Prompt == "" {
+		return nil, fmt.Errorf("prompt cannot be empty for %s", spec.Model)
+	}
+	if r.SubjectReferenceImageURL == "" {
+		return nil, fmt.Errorf("subject_reference_image_url cannot be empty for %s", spec.Model)
+	}
+	return spec, nil
+}
+
+// buildMinimaxLive validates a MinimaxLiveRequest and builds its request body.
+func buildMinimaxLive(req interface{}) (*RequestSpec, error) {
+	r := req.(*MinimaxLiveRequest)
+	spec := &RequestSpec{}
+	spec.Model = "minimax/video-01-live"
+	model, exists := GetModel(spec.Model, "image2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*MinimaxLiveOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+	// Validate options
+	liveOpts := MinimaxLiveOptions{
+		PromptOptimizer: r.PromptOptimizer,
+	}
+	if err := liveOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// Set default values if not provided
+	if r.PromptOptimizer == nil {
+		r.PromptOptimizer = options.PromptOptimizer
+	}
+	spec.Body = map[string]interface{}{
+		"prompt":           r.Prompt,
+		"image_url":        r.ImageURL,
+		"prompt_optimizer": r.PromptOptimizer,
+	}
+	if r.Prompt == "" {
+		return nil, fmt.Errorf("prompt cannot be empty for %s", spec.Model)
+	}
+	if r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url cannot be empty for %s", spec.Model)
+	}
+	return spec, nil
+}
+
+// buildMinimaxVideo01 validates a MinimaxVideo01Request and builds its request body.
+func buildMinimaxVideo01(req interface{}) (*RequestSpec, error) {
+	r := req.(*MinimaxVideo01Request)
+	spec := &RequestSpec{}
+	spec.Model = "minimax/video-01"
+	model, exists := GetModel(spec.Model, "text2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*MinimaxVideo01Options)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+	// Validate options
+	vidOpts := MinimaxVideo01Options{
+		PromptOptimizer: r.PromptOptimizer,
+	}
+	if err := vidOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// Set default values if not provided
+	if r.PromptOptimizer == nil {
+		r.PromptOptimizer = options.PromptOptimizer
+	}
+	spec.Body = map[string]interface{}{
+		"prompt":           r.Prompt,
+		"prompt_optimizer": r.PromptOptimizer,
+	}
+	if r.Prompt == "" {
+		return nil, fmt.Errorf("prompt cannot be empty for %s", spec.Model)
+	}
+	// Ensure ImageURL is not included (should be empty in BaseVideoRequest for text2video)
+	delete(spec.Body, "image_url")
+	return spec, nil
+}
+
+// buildBaseVideo validates a BaseVideoRequest and builds its request body.
+func buildBaseVideo(req interface{}) (*RequestSpec, error) {
+	r := req.(*BaseVideoRequest)
+	spec := &RequestSpec{}
+	// Handle potentially ambiguous base request
+	spec.Model = r.Model
+	model, exists := GetModel(spec.Model, "image2video")
+	if !exists {
+		model, exists = GetModel(spec.Model, "text2video")
 		if !exists {
-			model, exists = GetModel(modelName, "text2video")
+			model, exists = GetModel(spec.Model, "video2video")
 			if !exists {
-				model, exists = GetModel(modelName, "video2video")
-				if !exists {
-					return nil, fmt.Errorf("model not found: %s", modelName)
-				}
+				return nil, fmt.Errorf("model not found: %s", spec.Model)
 			}
 		}
-		// Use endpoint from model definition
-		endpoint = model.Endpoint
-		reqBody = map[string]interface{}{ // Assume base fields
-			"prompt":    r.Prompt,
-			"image_url": r.ImageURL,
-		}
-		// Remove empty fields
-		if r.ImageURL == "" {
-			delete(reqBody, "image_url")
-		}
-		if r.Prompt == "" {
-			delete(reqBody, "prompt")
-		}
-	case *MinimaxHailuo02Request:
-		modelName = "minimax/hailuo-02"
-		model, exists := GetModel(modelName, "text2video")
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
-		options, ok := model.Options.(*MinimaxHailuo02Options)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
-		// Set defaults from options if not provided
-		defaults := options.GetDefaultValues()
-		if r.Duration == "" {
-			r.Duration = defaults["duration"].(string)
-		}
-		if r.PromptOptimizer == nil {
-			r.PromptOptimizer = defaults["prompt_optimizer"].(*bool)
-		}
-		// Validate using the options struct
-		opts := MinimaxHailuo02Options{
-			Duration:        r.Duration,
-			PromptOptimizer: r.PromptOptimizer,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		reqBody = map[string]interface{}{
-			"prompt":           r.Prompt,
-			"duration":         r.Duration,
-			"prompt_optimizer": r.PromptOptimizer,
-		}
-		if r.Prompt == "" {
-			return nil, fmt.Errorf("prompt cannot be empty for %s", modelName)
-		}
-	case *Veo3Request:
-		modelName = "veo3"
-		model, exists := GetModel(modelName, "image2video")
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
-		options, ok := model.Options.(*Veo3Options)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
-		// Validate request options
-		veo3Opts := Veo3Options{
-			AspectRatio:   r.AspectRatio,
-			Duration:      r.Duration,
-			Resolution:    r.Resolution,
-			GenerateAudio: r.GenerateAudio,
-			AutoFix:       r.AutoFix,
-		}
-		if err := veo3Opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		// Set default values from model options if not provided in request
-		if r.AspectRatio == "" {
-			r.AspectRatio = options.AspectRatio
-		}
-		if r.Duration == "" {
-			r.Duration = options.Duration
-		}
-		if r.Resolution == "" {
-			r.Resolution = options.Resolution
-		}
-		if r.GenerateAudio == nil {
-			r.GenerateAudio = options.GenerateAudio
-		}
-		if r.AutoFix == nil {
-			r.AutoFix = options.AutoFix
-		}
-		// Validate image_url is required
-		if r.ImageURL == "" {
-			return nil, fmt.Errorf("image_url is required for %s", modelName)
-		}
-		// Build request body
-		reqBody = map[string]interface{}{
-			"prompt":    r.Prompt,
-			"image_url": r.ImageURL,
-		}
-		// Add optional fields only if they have values
-		if r.AspectRatio != "" {
-			reqBody["aspect_ratio"] = r.AspectRatio
-		}
-		if r.Duration != "" {
-			reqBody["duration"] = r.Duration
-		}
-		if r.Resolution != "" {
-			reqBody["resolution"] = r.Resolution
-		}
-		if r.GenerateAudio != nil {
-			reqBody["generate_audio"] = *r.GenerateAudio
-		}
-		if r.AutoFix != nil {
-			reqBody["auto_fix"] = *r.AutoFix
-		}
-	case *Veo31FastRequest:
-		modelName = "veo31fast"
-		model, exists := GetModel(modelName, "image2video")
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
-		options, ok := model.Options.(*Veo31FastOptions)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
-		// Validate request options
-		veo31FastOpts := Veo31FastOptions{
-			AspectRatio:   r.AspectRatio,
-			Duration:      r.Duration,
-			Resolution:    r.Resolution,
-			GenerateAudio: r.GenerateAudio,
-			AutoFix:       r.AutoFix,
-		}
-		if err := veo31FastOpts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
-		// Set default values from model options if not provided in request
-		if r.AspectRatio == "" {
-			r.AspectRatio = options.AspectRatio
-		}
-		if r.Duration == "" {
-			r.Duration = options.Duration
-		}
-		if r.Resolution == "" {
-			r.Resolution = options.Resolution
-		}
-		if r.GenerateAudio == nil {
-			r.GenerateAudio = options.GenerateAudio
-		}
-		if r.AutoFix == nil {
-			r.AutoFix = options.AutoFix
-		}
-		// Validate image_url is required
-		if r.ImageURL == "" {
-			return nil, fmt.Errorf("image_url is required for %s", modelName)
-		}
-		// Build request body
-		reqBody = map[string]interface{}{
-			"prompt":    r.Prompt,
-			"image_url": r.ImageURL,
-		}
-		// Add optional fields only if they have values
-		if r.AspectRatio != "" {
-			reqBody["aspect_ratio"] = r.AspectRatio
-		}
-		if r.Duration != "" {
-			reqBody["duration"] = r.Duration
-		}
-		if r.Resolution != "" {
-			reqBody["resolution"] = r.Resolution
-		}
-		if r.GenerateAudio != nil {
-			reqBody["generate_audio"] = *r.GenerateAudio
-		}
-		if r.AutoFix != nil {
-			reqBody["auto_fix"] = *r.AutoFix
-		}
-	case *KlingVideoV26MotionControlRequest:
-		modelName = "kling-video-v26-motion-control"
-		model, exists := GetModel(modelName, "video2video")
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
+	}
+	// Use endpoint from model definition
+	spec.Endpoint = model.Endpoint
+	spec.Body = map[string]interface{}{ // Assume base fields
+		"prompt":    r.Prompt,
+		"image_url": r.ImageURL,
+	}
+	// Remove empty fields
+	if r.ImageURL == "" {
+		delete(spec.Body, "image_url")
+	}
+	if r.Prompt == "" {
+		delete(spec.Body, "prompt")
+	}
+	return spec, nil
+}
 
-		options, ok := model.Options.(*KlingVideoV26MotionControlOptions)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
+// buildMinimaxHailuo02 validates a MinimaxHailuo02Request and builds its request body.
+func buildMinimaxHailuo02(req interface{}) (*RequestSpec, error) {
+	r := req.(*MinimaxHailuo02Request)
+	spec := &RequestSpec{}
+	spec.Model = "minimax/hailuo-02"
+	model, exists := GetModel(spec.Model, "text2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*MinimaxHailuo02Options)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+	// Set defaults from options if not provided
+	defaults := options.GetDefaultValues()
+	if r.Duration == "" {
+		r.Duration = defaults["duration"].(string)
+	}
+	if r.PromptOptimizer == nil {
+		r.PromptOptimizer = defaults["prompt_optimizer"].(*bool)
+	}
+	// Validate using the options struct
+	opts := MinimaxHailuo02Options{
+		Duration:        r.Duration,
+		PromptOptimizer: r.PromptOptimizer,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	spec.Body = map[string]interface{}{
+		"prompt":           r.Prompt,
+		"duration":         r.Duration,
+		"prompt_optimizer": r.PromptOptimizer,
+	}
+	if r.Prompt == "" {
+		return nil, fmt.Errorf("prompt cannot be empty for %s", spec.Model)
+	}
+	return spec, nil
+}
 
-		// Validate required fields
-		if r.ImageURL == "" {
-			return nil, fmt.Errorf("image_url is required for %s", modelName)
-		}
-		if r.VideoURL == "" {
-			return nil, fmt.Errorf("video_url is required for %s", modelName)
-		}
-		if r.CharacterOrientation == "" {
-			return nil, fmt.Errorf("character_orientation is required for %s (must be 'image' or 'video')", modelName)
-		}
+// buildVeo3 validates a Veo3Request and builds its request body.
+func buildVeo3(req interface{}) (*RequestSpec, error) {
+	r := req.(*Veo3Request)
+	spec := &RequestSpec{}
+	spec.Model = "veo3"
+	model, exists := GetModel(spec.Model, "image2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*Veo3Options)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+	// Validate request options
+	veo3Opts := Veo3Options{
+		AspectRatio:   r.AspectRatio,
+		Duration:      r.Duration,
+		Resolution:    r.Resolution,
+		GenerateAudio: r.GenerateAudio,
+		AutoFix:       r.AutoFix,
+	}
+	if err := veo3Opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// Set default values from model options if not provided in request
+	if r.AspectRatio == "" {
+		r.AspectRatio = options.AspectRatio
+	}
+	if r.Duration == "" {
+		r.Duration = options.Duration
+	}
+	if r.Resolution == "" {
+		r.Resolution = options.Resolution
+	}
+	if r.GenerateAudio == nil {
+		r.GenerateAudio = options.GenerateAudio
+	}
+	if r.AutoFix == nil {
+		r.AutoFix = options.AutoFix
+	}
+	// Validate image_url is required
+	if r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url is required for %s", spec.Model)
+	}
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"prompt":    r.Prompt,
+		"image_url": r.ImageURL,
+	}
+	// Add optional fields only if they have values
+	if r.AspectRatio != "" {
+		spec.Body["aspect_ratio"] = r.AspectRatio
+	}
+	if r.Duration != "" {
+		spec.Body["duration"] = r.Duration
+	}
+	if r.Resolution != "" {
+		spec.Body["resolution"] = r.Resolution
+	}
+	if r.GenerateAudio != nil {
+		spec.Body["generate_audio"] = *r.GenerateAudio
+	}
+	if r.AutoFix != nil {
+		spec.Body["auto_fix"] = *r.AutoFix
+	}
+	return spec, nil
+}
 
-		// Validate options
-		opts := KlingVideoV26MotionControlOptions{
-			CharacterOrientation: r.CharacterOrientation,
-			KeepOriginalSound:    r.KeepOriginalSound,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
+// buildVeo31Fast validates a Veo31FastRequest and builds its request body.
+func buildVeo31Fast(req interface{}) (*RequestSpec, error) {
+	r := req.(*Veo31FastRequest)
+	spec := &RequestSpec{}
+	spec.Model = "veo31fast"
+	model, exists := GetModel(spec.Model, "image2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*Veo31FastOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+	// Validate request options
+	veo31FastOpts := Veo31FastOptions{
+		AspectRatio:   r.AspectRatio,
+		Duration:      r.Duration,
+		Resolution:    r.Resolution,
+		GenerateAudio: r.GenerateAudio,
+		AutoFix:       r.AutoFix,
+	}
+	if err := veo31FastOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+	// Set default values from model options if not provided in request
+	if r.AspectRatio == "" {
+		r.AspectRatio = options.AspectRatio
+	}
+	if r.Duration == "" {
+		r.Duration = options.Duration
+	}
+	if r.Resolution == "" {
+		r.Resolution = options.Resolution
+	}
+	if r.GenerateAudio == nil {
+		r.GenerateAudio = options.GenerateAudio
+	}
+	if r.AutoFix == nil {
+		r.AutoFix = options.AutoFix
+	}
+	// Validate image_url is required
+	if r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url is required for %s", spec.Model)
+	}
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"prompt":    r.Prompt,
+		"image_url": r.ImageURL,
+	}
+	// Add optional fields only if they have values
+	if r.AspectRatio != "" {
+		spec.Body["aspect_ratio"] = r.AspectRatio
+	}
+	if r.Duration != "" {
+		spec.Body["duration"] = r.Duration
+	}
+	if r.Resolution != "" {
+		spec.Body["resolution"] = r.Resolution
+	}
+	if r.GenerateAudio != nil {
+		spec.Body["generate_audio"] = *r.GenerateAudio
+	}
+	if r.AutoFix != nil {
+		spec.Body["auto_fix"] = *r.AutoFix
+	}
+	return spec, nil
+}
 
-		// Set defaults if not provided
-		if r.KeepOriginalSound == nil {
-			r.KeepOriginalSound = options.KeepOriginalSound
-		}
+// buildKlingVideoV26MotionControl validates a KlingVideoV26MotionControlRequest and builds its request body.
+func buildKlingVideoV26MotionControl(req interface{}) (*RequestSpec, error) {
+	r := req.(*KlingVideoV26MotionControlRequest)
+	spec := &RequestSpec{}
+	spec.Model = "kling-video-v26-motion-control"
+	model, exists := GetModel(spec.Model, "video2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
 
-		// Build request body
-		reqBody = map[string]interface{}{
-			"image_url":             r.ImageURL,
-			"video_url":             r.VideoURL,
-			"character_orientation": r.CharacterOrientation,
-		}
+	options, ok := model.Options.(*KlingVideoV26MotionControlOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
 
-		// Add optional fields
-		if r.Prompt != "" {
-			reqBody["prompt"] = r.Prompt
-		}
-		if r.KeepOriginalSound != nil {
-			reqBody["keep_original_sound"] = *r.KeepOriginalSound
-		}
-	case *GrokImagineVideoRequest:
-		modelName = "grok-imagine-video"
-		model, exists := GetModel(modelName, "image2video")
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
+	// Validate required fields
+	if r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url is required for %s", spec.Model)
+	}
+	if r.VideoURL == "" {
+		return nil, fmt.Errorf("video_url is required for %s", spec.Model)
+	}
+	if r.CharacterOrientation == "" {
+		return nil, fmt.Errorf("character_orientation is required for %s (must be 'image' or 'video')", spec.Model)
+	}
 
-		options, ok := model.Options.(*GrokImagineVideoOptions)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
+	// Validate options
+	opts := KlingVideoV26MotionControlOptions{
+		CharacterOrientation: r.CharacterOrientation,
+		KeepOriginalSound:    r.KeepOriginalSound,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
 
-		// Validate required fields
-		if r.Prompt == "" {
-			return nil, fmt.Errorf("prompt is required for %s", modelName)
-		}
-		if r.ImageURL == "" {
-			return nil, fmt.Errorf("image_url is required for %s", modelName)
-		}
+	// Set defaults if not provided
+	if r.KeepOriginalSound == nil {
+		r.KeepOriginalSound = options.KeepOriginalSound
+	}
 
-		// Validate options
-		opts := GrokImagineVideoOptions{
-			Duration:    r.Duration,
-			AspectRatio: r.AspectRatio,
-			Resolution:  r.Resolution,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"image_url":             r.ImageURL,
+		"video_url":             r.VideoURL,
+		"character_orientation": r.CharacterOrientation,
+	}
 
-		// Set defaults if not provided
-		if r.Duration == 0 {
-			r.Duration = options.Duration
-		}
-		if r.AspectRatio == "" {
-			r.AspectRatio = options.AspectRatio
-		}
-		if r.Resolution == "" {
-			r.Resolution = options.Resolution
-		}
+	// Add optional fields
+	if r.Prompt != "" {
+		spec.Body["prompt"] = r.Prompt
+	}
+	if r.KeepOriginalSound != nil {
+		spec.Body["keep_original_sound"] = *r.KeepOriginalSound
+	}
+	return spec, nil
+}
 
-		// Build request body
-		reqBody = map[string]interface{}{
-			"prompt":       r.Prompt,
-			"image_url":    r.ImageURL,
-			"duration":     r.Duration,
-			"aspect_ratio": r.AspectRatio,
-			"resolution":   r.Resolution,
-		}
-	case *KlingVideoV3Request:
-		modelName = r.BaseVideoRequest.Model
-		// Determine model type for lookup
-		modelType := "text2video"
-		if modelName == "kling-video-v3-image" || modelName == "kling-video-v3-pro-image" {
-			modelType = "image2video"
-		}
+// buildGrokImagineVideo validates a GrokImagineVideoRequest and builds its request body.
+func buildGrokImagineVideo(req interface{}) (*RequestSpec, error) {
+	r := req.(*GrokImagineVideoRequest)
+	spec := &RequestSpec{}
+	spec.Model = "grok-imagine-video"
+	model, exists := GetModel(spec.Model, "image2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
 
-		model, exists := GetModel(modelName, modelType)
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
-		options, ok := model.Options.(*KlingVideoV3Options)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
+	options, ok := model.Options.(*GrokImagineVideoOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
 
-		// Validate options
-		v3Opts := KlingVideoV3Options{
-			Duration:       r.Duration,
-			AspectRatio:    r.AspectRatio,
-			NegativePrompt: r.NegativePrompt,
-			CFGScale:       r.CFGScale,
-			GenerateAudio:  r.GenerateAudio,
-		}
-		if err := v3Opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
+	// Validate required fields
+	if r.Prompt == "" {
+		return nil, fmt.Errorf("prompt is required for %s", spec.Model)
+	}
+	if r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url is required for %s", spec.Model)
+	}
 
-		// Set default values from model options if not provided in request
-		if r.Duration == "" {
-			r.Duration = options.Duration
-		}
-		if r.AspectRatio == "" {
-			r.AspectRatio = options.AspectRatio
-		}
-		if r.NegativePrompt == "" {
-			r.NegativePrompt = options.NegativePrompt
-		}
-		if r.CFGScale == 0 {
-			r.CFGScale = options.CFGScale
-		}
-		if r.GenerateAudio == nil {
-			r.GenerateAudio = options.GenerateAudio
-		}
+	// Validate options
+	opts := GrokImagineVideoOptions{
+		Duration:    r.Duration,
+		AspectRatio: r.AspectRatio,
+		Resolution:  r.Resolution,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
 
-		// Validate prompt for text2video
-		if modelType == "text2video" && r.Prompt == "" {
-			return nil, fmt.Errorf("prompt is required for %s", modelName)
-		}
-		// Validate image for image2video
-		if modelType == "image2video" && r.ImageURL == "" {
-			return nil, fmt.Errorf("image_url is required for %s", modelName)
-		}
+	// Set defaults if not provided
+	if r.Duration == 0 {
+		r.Duration = options.Duration
+	}
+	if r.AspectRatio == "" {
+		r.AspectRatio = options.AspectRatio
+	}
+	if r.Resolution == "" {
+		r.Resolution = options.Resolution
+	}
 
-		// Build request body
-		reqBody = map[string]interface{}{
-			"prompt":          r.Prompt,
-			"duration":        r.Duration,
-			"aspect_ratio":    r.AspectRatio,
-			"negative_prompt": r.NegativePrompt,
-			"cfg_scale":       r.CFGScale,
-		}
-		if r.GenerateAudio != nil {
-			reqBody["generate_audio"] = *r.GenerateAudio
-		}
-		// Add image fields for image2video
-		if modelType == "image2video" {
-			reqBody["start_image_url"] = r.ImageURL
-			if r.EndImageURL != "" {
-				reqBody["end_image_url"] = r.EndImageURL
-			}
-		}
-		// Remove empty fields
-		if r.Prompt == "" {
-			delete(reqBody, "prompt")
-		}
-	case *SeedanceRequest:
-		modelName = r.BaseVideoRequest.Model
-		// Determine modality from model name
-		var modelType string
-		switch modelName {
-		case "seedance-2.0-image":
-			modelType = "image2video"
-		case "seedance-2.0-text":
-			modelType = "text2video"
-		default:
-			return nil, fmt.Errorf("unsupported Seedance model: %s", modelName)
-		}
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"prompt":       r.Prompt,
+		"image_url":    r.ImageURL,
+		"duration":     r.Duration,
+		"aspect_ratio": r.AspectRatio,
+		"resolution":   r.Resolution,
+	}
+	return spec, nil
+}
 
-		model, exists := GetModel(modelName, modelType)
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
-		options, ok := model.Options.(*SeedanceOptions)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
+// buildKlingVideoV3 validates a KlingVideoV3Request and builds its request body.
+func buildKlingVideoV3(req interface{}) (*RequestSpec, error) {
+	r := req.(*KlingVideoV3Request)
+	spec := &RequestSpec{}
+	spec.Model = r.BaseVideoRequest.Model
+	// Determine model type for lookup
+	modelType := "text2video"
+	if spec.Model == "kling-video-v3-image" || spec.Model == "kling-video-v3-pro-image" {
+		modelType = "image2video"
+	}
 
-		// Validate required fields
-		if r.Prompt == "" {
-			return nil, fmt.Errorf("prompt is required for %s", modelName)
-		}
-		if modelType == "image2video" && r.ImageURL == "" {
-			return nil, fmt.Errorf("image_url is required for %s", modelName)
-		}
-		if r.EndUserID == "" {
-			return nil, fmt.Errorf("end_user_id is required for %s (ByteDance tracking)", modelName)
-		}
+	model, exists := GetModel(spec.Model, modelType)
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*KlingVideoV3Options)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
 
-		// Validate options
-		opts := SeedanceOptions{
-			Duration:      r.Duration,
-			AspectRatio:   r.AspectRatio,
-			Resolution:    r.Resolution,
-			GenerateAudio: r.GenerateAudio,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
+	// Validate options
+	v3Opts := KlingVideoV3Options{
+		Duration:       r.Duration,
+		AspectRatio:    r.AspectRatio,
+		NegativePrompt: r.NegativePrompt,
+		CFGScale:       r.CFGScale,
+		GenerateAudio:  r.GenerateAudio,
+	}
+	if err := v3Opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
 
-		// Apply defaults from model options if not provided in request
-		if r.Duration == "" {
-			r.Duration = options.Duration
-		}
-		if r.AspectRatio == "" {
-			r.AspectRatio = options.AspectRatio
-		}
-		if r.Resolution == "" {
-			r.Resolution = options.Resolution
-		}
-		if r.GenerateAudio == nil {
-			r.GenerateAudio = options.GenerateAudio
-		}
+	// Set default values from model options if not provided in request
+	if r.Duration == "" {
+		r.Duration = options.Duration
+	}
+	if r.AspectRatio == "" {
+		r.AspectRatio = options.AspectRatio
+	}
+	if r.NegativePrompt == "" {
+		r.NegativePrompt = options.NegativePrompt
+	}
+	if r.CFGScale == 0 {
+		r.CFGScale = options.CFGScale
+	}
+	if r.GenerateAudio == nil {
+		r.GenerateAudio = options.GenerateAudio
+	}
 
-		// Build request body
-		reqBody = map[string]interface{}{
-			"prompt":       r.Prompt,
-			"duration":     r.Duration,
-			"aspect_ratio": r.AspectRatio,
-			"resolution":   r.Resolution,
-			"end_user_id":  r.EndUserID,
-		}
-		if r.GenerateAudio != nil {
-			reqBody["generate_audio"] = *r.GenerateAudio
-		}
-		if r.Seed != nil {
-			reqBody["seed"] = *r.Seed
-		}
-		// Image2video-specific fields
-		if modelType == "image2video" {
-			reqBody["image_url"] = r.ImageURL
-			if r.EndImageURL != "" {
-				reqBody["end_image_url"] = r.EndImageURL
-			}
-		}
-	case *SeedanceReferenceRequest:
-		modelName = "seedance-2.0-reference"
-		model, exists := GetModel(modelName, "multi2video")
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
-		options, ok := model.Options.(*SeedanceReferenceOptions)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
+	// Validate prompt for text2video
+	if modelType == "text2video" && r.Prompt == "" {
+		return nil, fmt.Errorf("prompt is required for %s", spec.Model)
+	}
+	// Validate image for image2video
+	if modelType == "image2video" && r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url is required for %s", spec.Model)
+	}
 
-		// Validate required fields
-		if r.Prompt == "" {
-			return nil, fmt.Errorf("prompt is required for %s", modelName)
-		}
-		if r.EndUserID == "" {
-			return nil, fmt.Errorf("end_user_id is required for %s (ByteDance tracking)", modelName)
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"prompt":          r.Prompt,
+		"duration":        r.Duration,
+		"aspect_ratio":    r.AspectRatio,
+		"negative_prompt": r.NegativePrompt,
+		"cfg_scale":       r.CFGScale,
+	}
+	if r.GenerateAudio != nil {
+		spec.Body["generate_audio"] = *r.GenerateAudio
+	}
+	// Add image fields for image2video
+	if modelType == "image2video" {
+		spec.Body["start_image_url"] = r.ImageURL
+		if r.EndImageURL != "" {
+			spec.Body["end_image_url"] = r.EndImageURL
 		}
+	}
+	// Remove empty fields
+	if r.Prompt == "" {
+		delete(spec.Body, "prompt")
+	}
+	return spec, nil
+}
 
-		// Validate reference input constraints
-		totalRefs := len(r.ImageURLs) + len(r.VideoURLs) + len(r.AudioURLs)
-		if totalRefs == 0 {
-			return nil, fmt.Errorf("at least one reference input (image, video, or audio) is required for %s", modelName)
-		}
-		if totalRefs > 12 {
-			return nil, fmt.Errorf("total reference files must not exceed 12 (got %d)", totalRefs)
-		}
-		if len(r.ImageURLs) > 9 {
-			return nil, fmt.Errorf("max 9 reference images (got %d)", len(r.ImageURLs))
-		}
-		if len(r.VideoURLs) > 3 {
-			return nil, fmt.Errorf("max 3 reference videos (got %d)", len(r.VideoURLs))
-		}
-		if len(r.AudioURLs) > 3 {
-			return nil, fmt.Errorf("max 3 reference audio files (got %d)", len(r.AudioURLs))
-		}
-		if len(r.AudioURLs) > 0 && len(r.ImageURLs)+len(r.VideoURLs) == 0 {
-			return nil, fmt.Errorf("reference audio requires at least one reference image or video")
-		}
+// buildSeedance validates a SeedanceRequest and builds its request body.
+func buildSeedance(req interface{}) (*RequestSpec, error) {
+	r := req.(*SeedanceRequest)
+	spec := &RequestSpec{}
+	spec.Model = r.BaseVideoRequest.Model
+	// Determine modality from model name
+	var modelType string
+	switch spec.Model {
+	case "seedance-2.0-image":
+		modelType = "image2video"
+	case "seedance-2.0-text":
+		modelType = "text2video"
+	default:
+		return nil, fmt.Errorf("unsupported Seedance model: %s", spec.Model)
+	}
 
-		// Validate enum options
-		opts := SeedanceReferenceOptions{
-			Duration:      r.Duration,
-			AspectRatio:   r.AspectRatio,
-			Resolution:    r.Resolution,
-			GenerateAudio: r.GenerateAudio,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
+	model, exists := GetModel(spec.Model, modelType)
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*SeedanceOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
 
-		// Apply defaults from model options if not provided in request
-		if r.Duration == "" {
-			r.Duration = options.Duration
-		}
-		if r.AspectRatio == "" {
-			r.AspectRatio = options.AspectRatio
-		}
-		if r.Resolution == "" {
-			r.Resolution = options.Resolution
-		}
-		if r.GenerateAudio == nil {
-			r.GenerateAudio = options.GenerateAudio
-		}
+	// Validate required fields
+	if r.Prompt == "" {
+		return nil, fmt.Errorf("prompt is required for %s", spec.Model)
+	}
+	if modelType == "image2video" && r.ImageURL == "" {
+		return nil, fmt.Errorf("image_url is required for %s", spec.Model)
+	}
+	if r.EndUserID == "" {
+		return nil, fmt.Errorf("end_user_id is required for %s (ByteDance tracking)", spec.Model)
+	}
 
-		// Build request body
-		reqBody = map[string]interface{}{
-			"prompt":       r.Prompt,
-			"duration":     r.Duration,
-			"aspect_ratio": r.AspectRatio,
-			"resolution":   r.Resolution,
-			"end_user_id":  r.EndUserID,
-		}
-		if len(r.ImageURLs) > 0 {
-			reqBody["image_urls"] = r.ImageURLs
-		}
-		if len(r.VideoURLs) > 0 {
-			reqBody["video_urls"] = r.VideoURLs
-		}
-		if len(r.AudioURLs) > 0 {
-			reqBody["audio_urls"] = r.AudioURLs
-		}
-		if r.GenerateAudio != nil {
-			reqBody["generate_audio"] = *r.GenerateAudio
-		}
-		if r.Seed != nil {
-			reqBody["seed"] = *r.Seed
-		}
-	case *KlingVideoO3TextRequest:
-		modelName = r.BaseVideoRequest.Model
-		model, exists := GetModel(modelName, "text2video")
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
-		options, ok := model.Options.(*KlingVideoO3TextOptions)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
+	// Validate options
+	opts := SeedanceOptions{
+		Duration:      r.Duration,
+		AspectRatio:   r.AspectRatio,
+		Resolution:    r.Resolution,
+		GenerateAudio: r.GenerateAudio,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
 
-		// Validate options
-		o3TextOpts := KlingVideoO3TextOptions{
-			Duration:      r.Duration,
-			AspectRatio:   r.AspectRatio,
-			GenerateAudio: r.GenerateAudio,
-		}
-		if err := o3TextOpts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
+	// Apply defaults from model options if not provided in request
+	if r.Duration == "" {
+		r.Duration = options.Duration
+	}
+	if r.AspectRatio == "" {
+		r.AspectRatio = options.AspectRatio
+	}
+	if r.Resolution == "" {
+		r.Resolution = options.Resolution
+	}
+	if r.GenerateAudio == nil {
+		r.GenerateAudio = options.GenerateAudio
+	}
 
-		// Set default values from model options if not provided
-		if r.Duration == "" {
-			r.Duration = options.Duration
-		}
-		if r.AspectRatio == "" {
-			r.AspectRatio = options.AspectRatio
-		}
-		if r.GenerateAudio == nil {
-			r.GenerateAudio = options.GenerateAudio
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"prompt":       r.Prompt,
+		"duration":     r.Duration,
+		"aspect_ratio": r.AspectRatio,
+		"resolution":   r.Resolution,
+		"end_user_id":  r.EndUserID,
+	}
+	if r.GenerateAudio != nil {
+		spec.Body["generate_audio"] = *r.GenerateAudio
+	}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	// Image2video-specific fields
+	if modelType == "image2video" {
+		spec.Body["image_url"] = r.ImageURL
+		if r.EndImageURL != "" {
+			spec.Body["end_image_url"] = r.EndImageURL
 		}
+	}
+	return spec, nil
+}
 
-		// Validate prompt
-		if r.Prompt == "" {
-			return nil, fmt.Errorf("prompt is required for %s", modelName)
-		}
+// buildSeedanceReference validates a SeedanceReferenceRequest and builds its request body.
+func buildSeedanceReference(req interface{}) (*RequestSpec, error) {
+	r := req.(*SeedanceReferenceRequest)
+	spec := &RequestSpec{}
+	spec.Model = "seedance-2.0-reference"
+	model, exists := GetModel(spec.Model, "multi2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*SeedanceReferenceOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
 
-		// Build request body
-		reqBody = map[string]interface{}{
-			"prompt":       r.Prompt,
-			"duration":     r.Duration,
-			"aspect_ratio": r.AspectRatio,
-		}
-		if r.GenerateAudio != nil {
-			reqBody["generate_audio"] = *r.GenerateAudio
-		}
-	case *KlingVideoO3EditRequest:
-		modelName = r.BaseVideoRequest.Model
-		model, exists := GetModel(modelName, "video2video")
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
-		options, ok := model.Options.(*KlingVideoO3EditOptions)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
+	// Validate required fields
+	if r.Prompt == "" {
+		return nil, fmt.Errorf("prompt is required for %s", spec.Model)
+	}
+	if r.EndUserID == "" {
+		return nil, fmt.Errorf("end_user_id is required for %s (ByteDance tracking)", spec.Model)
+	}
 
-		// Validate required fields
-		if r.Prompt == "" {
-			return nil, fmt.Errorf("prompt is required for %s", modelName)
-		}
-		if r.VideoURL == "" {
-			return nil, fmt.Errorf("video_url is required for %s", modelName)
-		}
+	// Validate reference input constraints
+	totalRefs := len(r.ImageURLs) + len(r.VideoURLs) + len(r.AudioURLs)
+	if totalRefs == 0 {
+		return nil, fmt.Errorf("at least one reference input (image, video, or audio) is required for %s", spec.Model)
+	}
+	if totalRefs > 12 {
+		return nil, fmt.Errorf("total reference files must not exceed 12 (got %d)", totalRefs)
+	}
+	if len(r.ImageURLs) > 9 {
+		return nil, fmt.Errorf("max 9 reference images (got %d)", len(r.ImageURLs))
+	}
+	if len(r.VideoURLs) > 3 {
+		return nil, fmt.Errorf("max 3 reference videos (got %d)", len(r.VideoURLs))
+	}
+	if len(r.AudioURLs) > 3 {
+		return nil, fmt.Errorf("max 3 reference audio files (got %d)", len(r.AudioURLs))
+	}
+	if len(r.AudioURLs) > 0 && len(r.ImageURLs)+len(r.VideoURLs) == 0 {
+		return nil, fmt.Errorf("reference audio requires at least one reference image or video")
+	}
 
-		// Set defaults if not provided
-		if r.KeepAudio == nil {
-			r.KeepAudio = options.KeepAudio
-		}
+	// Validate enum options
+	opts := SeedanceReferenceOptions{
+		Duration:      r.Duration,
+		AspectRatio:   r.AspectRatio,
+		Resolution:    r.Resolution,
+		GenerateAudio: r.GenerateAudio,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
 
-		// Build request body
-		reqBody = map[string]interface{}{
-			"prompt":    r.Prompt,
-			"video_url": r.VideoURL,
-		}
-		if r.KeepAudio != nil {
-			reqBody["keep_audio"] = *r.KeepAudio
-		}
-		if len(r.ImageURLs) > 0 {
-			reqBody["image_urls"] = r.ImageURLs
-		}
-	case *GrokImagineVideoTextRequest:
-		modelName = "grok-imagine-video-text"
-		model, exists := GetModel(modelName, "text2video")
-		if !exists {
-			return nil, fmt.Errorf("model not found: %s", modelName)
-		}
-		endpoint = model.Endpoint
+	// Apply defaults from model options if not provided in request
+	if r.Duration == "" {
+		r.Duration = options.Duration
+	}
+	if r.AspectRatio == "" {
+		r.AspectRatio = options.AspectRatio
+	}
+	if r.Resolution == "" {
+		r.Resolution = options.Resolution
+	}
+	if r.GenerateAudio == nil {
+		r.GenerateAudio = options.GenerateAudio
+	}
 
-		options, ok := model.Options.(*GrokImagineVideoTextOptions)
-		if !ok {
-			return nil, fmt.Errorf("invalid options type for model %s", modelName)
-		}
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"prompt":       r.Prompt,
+		"duration":     r.Duration,
+		"aspect_ratio": r.AspectRatio,
+		"resolution":   r.Resolution,
+		"end_user_id":  r.EndUserID,
+	}
+	if len(r.ImageURLs) > 0 {
+		spec.Body["image_urls"] = r.ImageURLs
+	}
+	if len(r.VideoURLs) > 0 {
+		spec.Body["video_urls"] = r.VideoURLs
+	}
+	if len(r.AudioURLs) > 0 {
+		spec.Body["audio_urls"] = r.AudioURLs
+	}
+	if r.GenerateAudio != nil {
+		spec.Body["generate_audio"] = *r.GenerateAudio
+	}
+	if r.Seed != nil {
+		spec.Body["seed"] = *r.Seed
+	}
+	return spec, nil
+}
 
-		// Validate required fields
-		if r.Prompt == "" {
-			return nil, fmt.Errorf("prompt is required for %s", modelName)
-		}
+// buildKlingVideoO3Text validates a KlingVideoO3TextRequest and builds its request body.
+func buildKlingVideoO3Text(req interface{}) (*RequestSpec, error) {
+	r := req.(*KlingVideoO3TextRequest)
+	spec := &RequestSpec{}
+	spec.Model = r.BaseVideoRequest.Model
+	model, exists := GetModel(spec.Model, "text2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*KlingVideoO3TextOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
 
-		// Validate options
-		opts := GrokImagineVideoTextOptions{
-			Duration:    r.Duration,
-			AspectRatio: r.AspectRatio,
-			Resolution:  r.Resolution,
-		}
-		if err := opts.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid options for %s: %v", modelName, err)
-		}
+	// Validate options
+	o3TextOpts := KlingVideoO3TextOptions{
+		Duration:      r.Duration,
+		AspectRatio:   r.AspectRatio,
+		GenerateAudio: r.GenerateAudio,
+	}
+	if err := o3TextOpts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
 
-		// Set defaults if not provided
-		if r.Duration == 0 {
-			r.Duration = options.Duration
-		}
-		if r.AspectRatio == "" {
-			r.AspectRatio = options.AspectRatio
-		}
-		if r.Resolution == "" {
-			r.Resolution = options.Resolution
-		}
+	// Set default values from model options if not provided
+	if r.Duration == "" {
+		r.Duration = options.Duration
+	}
+	if r.AspectRatio == "" {
+		r.AspectRatio = options.AspectRatio
+	}
+	if r.GenerateAudio == nil {
+		r.GenerateAudio = options.GenerateAudio
+	}
 
-		// Build request body
-		reqBody = map[string]interface{}{
-			"prompt":       r.Prompt,
-			"duration":     r.Duration,
-			"aspect_ratio": r.AspectRatio,
-			"resolution":   r.Resolution,
-		}
-	default:
-		return nil, fmt.Errorf("unsupported request type: %T", req)
+	// Validate prompt
+	if r.Prompt == "" {
+		return nil, fmt.Errorf("prompt is required for %s", spec.Model)
 	}
 
-	// Add any additional generic options from the request
-	if optionsGetter, ok := req.(interface{ GetOptions() map[string]interface{} }); ok {
-		for k, v := range optionsGetter.GetOptions() {
-			// Avoid overwriting fields already set by specific request types
-			if _, exists := reqBody[k]; !exists {
-				reqBody[k] = v
-			}
-		}
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"prompt":       r.Prompt,
+		"duration":     r.Duration,
+		"aspect_ratio": r.AspectRatio,
 	}
+	if r.GenerateAudio != nil {
+		spec.Body["generate_audio"] = *r.GenerateAudio
+	}
+	return spec, nil
+}
 
-	// Define the decoder for the final video response
-	decodeFunc := func(data []byte) (interface{}, error) {
-		var videoResp VideoResponse
-		if err := json.Unmarshal(data, &videoResp); err != nil {
-			return nil, fmt.Errorf("failed to parse final video response: %w, body: %s", err, string(data))
-		}
-		// Check if any of the video URL fields are populated
-		if videoResp.GetURL() == "" {
-			return nil, fmt.Errorf("no video URL found in the response: %s", string(data))
-		}
-		return &videoResp, nil
+// buildKlingVideoO3Edit validates a KlingVideoO3EditRequest and builds its request body.
+func buildKlingVideoO3Edit(req interface{}) (*RequestSpec, error) {
+	r := req.(*KlingVideoO3EditRequest)
+	spec := &RequestSpec{}
+	spec.Model = r.BaseVideoRequest.Model
+	model, exists := GetModel(spec.Model, "video2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*KlingVideoO3EditOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
 	}
 
-	// Execute the workflow (with queue info callback for recovery support)
-	result, err := c.executeAsyncWorkflowWithCallback(ctx, endpoint, reqBody, progress, decodeFunc, queueInfo)
-	if err != nil {
-		return nil, err // Error already wrapped
+	// Validate required fields
+	if r.Prompt == "" {
+		return nil, fmt.Errorf("prompt is required for %s", spec.Model)
 	}
+	if r.VideoURL == "" {
+		return nil, fmt.Errorf("video_url is required for %s", spec.Model)
+	}
+
+	// Set defaults if not provided
+	if r.KeepAudio == nil {
+		r.KeepAudio = options.KeepAudio
+	}
+
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"prompt":    r.Prompt,
+		"video_url": r.VideoURL,
+	}
+	if r.KeepAudio != nil {
+		spec.Body["keep_audio"] = *r.KeepAudio
+	}
+	if len(r.ImageURLs) > 0 {
+		spec.Body["image_urls"] = r.ImageURLs
+	}
+	return spec, nil
+}
+
+// buildTopazUpscaleVideo validates a TopazUpscaleVideoRequest and builds its request body.
+func buildTopazUpscaleVideo(req interface{}) (*RequestSpec, error) {
+	r := req.(*TopazUpscaleVideoRequest)
+	spec := &RequestSpec{}
+	spec.Model = "topaz-upscale-video"
+	model, exists := GetModel(spec.Model, "video2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*TopazUpscaleVideoOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+
+	// Validate required fields
+	if r.VideoURL == "" {
+		return nil, fmt.Errorf("video_url is required for %s", spec.Model)
+	}
+
+	// Validate options
+	opts := TopazUpscaleVideoOptions{Model: r.Model, OutputType: r.OutputType}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+
+	// Set defaults if not provided
+	if r.Model == "" {
+		r.Model = options.Model
+	}
+	if r.OutputType == "" {
+		r.OutputType = options.OutputType
+	}
+
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"video_url":   r.VideoURL,
+		"model":       r.Model,
+		"output_type": r.OutputType,
+	}
+	return spec, nil
+}
+
+// buildSyncLipsyncV2 validates a SyncLipsyncV2Request and builds its request body.
+func buildSyncLipsyncV2(req interface{}) (*RequestSpec, error) {
+	r := req.(*SyncLipsyncV2Request)
+	spec := &RequestSpec{}
+	spec.Model = "sync-lipsync-v2"
+	model, exists := GetModel(spec.Model, "video2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*SyncLipsyncV2Options)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+
+	// Validate required fields
+	if r.VideoURL == "" {
+		return nil, fmt.Errorf("video_url is required for %s", spec.Model)
+	}
+	if r.AudioURL == "" {
+		return nil, fmt.Errorf("audio_url is required for %s", spec.Model)
+	}
+
+	// Validate options
+	opts := SyncLipsyncV2Options{Model: r.Model, OutputType: r.OutputType}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+
+	// Set defaults if not provided
+	if r.Model == "" {
+		r.Model = options.Model
+	}
+	if r.OutputType == "" {
+		r.OutputType = options.OutputType
+	}
+
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"video_url":   r.VideoURL,
+		"audio_url":   r.AudioURL,
+		"model":       r.Model,
+		"output_type": r.OutputType,
+	}
+	return spec, nil
+}
+
+// buildFrameInterpolation validates a FrameInterpolationRequest and builds its request body.
+func buildFrameInterpolation(req interface{}) (*RequestSpec, error) {
+	r := req.(*FrameInterpolationRequest)
+	spec := &RequestSpec{}
+	spec.Model = r.BaseVideoRequest.Model
+	model, exists := GetModel(spec.Model, "video2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+	options, ok := model.Options.(*FrameInterpolationOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+
+	// Validate required fields
+	if r.VideoURL == "" {
+		return nil, fmt.Errorf("video_url is required for %s", spec.Model)
+	}
+
+	// Validate options
+	opts := FrameInterpolationOptions{Factor: r.Factor, SlowMotion: r.SlowMotion}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+
+	// Set defaults if not provided
+	if r.Factor == 0 {
+		r.Factor = options.Factor
+	}
+	if r.SlowMotion == nil {
+		r.SlowMotion = options.SlowMotion
+	}
+	slowMotion := r.SlowMotion != nil && *r.SlowMotion
 
-	return result.(*VideoResponse), nil
+	// num_frames is the number of frames inserted between each source
+	// frame, so a 2x factor inserts one and a 4x factor inserts three.
+	// Smoothing raises the output fps by the same factor; slow motion
+	// keeps the source fps so the clip plays back longer.
+	spec.Body = map[string]interface{}{
+		"video_url":           r.VideoURL,
+		"num_frames":          r.Factor - 1,
+		"use_scene_detection": true,
+		"use_calculated_fps":  !slowMotion,
+	}
+	return spec, nil
+}
+
+// buildGrokImagineVideoText validates a GrokImagineVideoTextRequest and builds its request body.
+func buildGrokImagineVideoText(req interface{}) (*RequestSpec, error) {
+	r := req.(*GrokImagineVideoTextRequest)
+	spec := &RequestSpec{}
+	spec.Model = "grok-imagine-video-text"
+	model, exists := GetModel(spec.Model, "text2video")
+	if !exists {
+		return nil, fmt.Errorf("model not found: %s", spec.Model)
+	}
+	spec.Endpoint = model.Endpoint
+
+	options, ok := model.Options.(*GrokImagineVideoTextOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options type for model %s", spec.Model)
+	}
+
+	// Validate required fields
+	if r.Prompt == "" {
+		return nil, fmt.Errorf("prompt is required for %s", spec.Model)
+	}
+
+	// Validate options
+	opts := GrokImagineVideoTextOptions{
+		Duration:    r.Duration,
+		AspectRatio: r.AspectRatio,
+		Resolution:  r.Resolution,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options for %s: %w", spec.Model, err)
+	}
+
+	// Set defaults if not provided
+	if r.Duration == 0 {
+		r.Duration = options.Duration
+	}
+	if r.AspectRatio == "" {
+		r.AspectRatio = options.AspectRatio
+	}
+	if r.Resolution == "" {
+		r.Resolution = options.Resolution
+	}
+
+	// Build request body
+	spec.Body = map[string]interface{}{
+		"prompt":       r.Prompt,
+		"duration":     r.Duration,
+		"aspect_ratio": r.AspectRatio,
+		"resolution":   r.Resolution,
+	}
+	return spec, nil
 }
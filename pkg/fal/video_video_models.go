@@ -99,10 +99,52 @@ func (m *klingVideoO3ProEditModel) Define() Model {
 	}
 }
 
+// --- rife-video ---
+
+type rifeVideoModel struct{}
+
+func (m *rifeVideoModel) Define() Model {
+	defaultOpts := &FrameInterpolationOptions{}
+	defaults := defaultOpts.GetDefaultValues()
+
+	return Model{
+		Name:        "rife-video",
+		Description: "RIFE Frame Interpolation - Smooth or slow down videos by 2x/4x frame interpolation",
+		Type:        "video2video",
+		Endpoint:    "/rife/video",
+		Options: &FrameInterpolationOptions{
+			Factor:     defaults["factor"].(int),
+			SlowMotion: defaults["slow_motion"].(*bool),
+		},
+	}
+}
+
+// --- film-video ---
+
+type filmVideoModel struct{}
+
+func (m *filmVideoModel) Define() Model {
+	defaultOpts := &FrameInterpolationOptions{}
+	defaults := defaultOpts.GetDefaultValues()
+
+	return Model{
+		Name:        "film-video",
+		Description: "FILM Frame Interpolation - Large-motion aware 2x/4x frame interpolation for videos",
+		Type:        "video2video",
+		Endpoint:    "/film/video",
+		Options: &FrameInterpolationOptions{
+			Factor:     defaults["factor"].(int),
+			SlowMotion: defaults["slow_motion"].(*bool),
+		},
+	}
+}
+
 func init() {
 	registerModel(&topazUpscaleVideoModel{})
 	registerModel(&syncLipsyncV2Model{})
 	registerModel(&klingVideoV26MotionControlModel{})
 	registerModel(&klingVideoO3EditModel{})
 	registerModel(&klingVideoO3ProEditModel{})
+	registerModel(&rifeVideoModel{})
+	registerModel(&filmVideoModel{})
 }
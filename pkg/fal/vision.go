@@ -0,0 +1,70 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// DescribeImage asks an image2text model about an image, e.g. for a caption
+func (c *Client) DescribeImage(ctx context.Context, req *VisionRequest) (*VisionResponse, error) {
+	modelName := req.Model
+	if modelName == "" {
+		modelName = "llava-next"
+	}
+	modelDef, exists := GetModel(modelName, "image2text")
+	if !exists {
+		return nil, &Error{
+			Code:    "INVALID_MODEL",
+			Message: fmt.Sprintf("invalid or unsupported model %s for image2text", modelName),
+		}
+	}
+
+	if req.ImageURL == "" {
+		return nil, fmt.Errorf("image_url is required")
+	}
+	if req.Prompt == "" {
+		return nil, fmt.Errorf("prompt is required")
+	}
+	if opts, ok := modelDef.Options.(*LlavaNextOptions); ok {
+		currentOpts := *opts
+		if req.MaxTokens != 0 {
+			currentOpts.MaxTokens = req.MaxTokens
+		}
+		if req.Temperature != 0 {
+			currentOpts.Temperature = req.Temperature
+		}
+		if err := currentOpts.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid options for %s: %w", modelName, err)
+		}
+	}
+
+	reqBody := map[string]interface{}{
+		"image_url": req.ImageURL,
+		"prompt":    req.Prompt,
+	}
+	if req.MaxTokens != 0 {
+		reqBody["max_tokens"] = req.MaxTokens
+	}
+	if req.Temperature != 0 {
+		reqBody["temperature"] = req.Temperature
+	}
+
+	decodeFunc := func(data []byte) (interface{}, error) {
+		var response VisionResponse
+		if err := json.Unmarshal(data, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse vision response: %w. Body: %s", err, string(data))
+		}
+		return &response, nil
+	}
+
+	result, err := c.executeAsyncWorkflow(ctx, modelDef.Endpoint, reqBody, req.Progress, decodeFunc)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*VisionResponse), nil
+}
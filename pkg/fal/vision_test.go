@@ -0,0 +1,62 @@
+package fal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDescribeImage(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			var body map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decoding request: %v", err)
+			}
+			if body["image_url"] != "https://example.com/cat.png" || body["prompt"] != "Describe it" || body["max_tokens"] != 200.0 {
+				t.Errorf("request body = %v", body)
+			}
+			w.Write([]byte(`{"request_id": "req-1", "response_url": "` + srv.URL + `/requests/req-1"}`))
+		case r.URL.Path == "/requests/req-1/status":
+			w.Write([]byte(`{"status": "COMPLETED"}`))
+		case r.URL.Path == "/requests/req-1":
+			w.Write([]byte(`{"output": "A cat on a sofa.", "partial": false}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	if err := SetEndpointOverride("llava-next", srv.URL+"/fal-ai/llava-next"); err != nil {
+		t.Fatalf("SetEndpointOverride: %v", err)
+	}
+	defer SetEndpointOverride("llava-next", "")
+
+	recv, err := NewWebhookReceiver("https://bot.example.com/fal/webhook")
+	if err != nil {
+		t.Fatalf("NewWebhookReceiver: %v", err)
+	}
+	c := NewClient("key", WithHTTPClient(srv.Client()), WithWebhook(recv, 10*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := c.DescribeImage(ctx, &VisionRequest{ImageURL: "https://example.com/cat.png", Prompt: "Describe it", MaxTokens: 200})
+	if err != nil {
+		t.Fatalf("DescribeImage: %v", err)
+	}
+	if resp.Output != "A cat on a sofa." {
+		t.Errorf("output = %q", resp.Output)
+	}
+
+	if _, err := c.DescribeImage(ctx, &VisionRequest{ImageURL: "https://example.com/cat.png", Prompt: "x", MaxTokens: 5000}); err == nil {
+		t.Error("max_tokens beyond the limit accepted")
+	}
+	if _, err := c.DescribeImage(ctx, &VisionRequest{Prompt: "x"}); err == nil {
+		t.Error("request without image accepted")
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fal
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultWebhookPollInterval is how often a client using webhooks still
+	// checks the status of a request, in case its webhook never arrives.
+	DefaultWebhookPollInterval = time.Minute
+
+	// earlyWebhookTTL is how long a webhook that arrived before its request
+	// started waiting is kept.
+	earlyWebhookTTL = 10 * time.Minute
+
+	// maxWebhookBytes caps the size of a webhook body. The result payload
+	// is fetched from fal, so only the request id is read.
+	maxWebhookBytes = 10 << 20
+)
+
+// WebhookReceiver receives the webhooks fal posts when a request completes,
+// so a client using it learns of results without polling every few seconds.
+// Serve it with an HTTP server at the public URL passed to
+// NewWebhookReceiver and pass it to the client with WithWebhook.
+type WebhookReceiver struct {
+	url   string // Public URL, including the token
+	token string // Secret that keeps others from posting completions
+
+	mu      sync.Mutex
+	waiting map[string]chan struct{} // Request id → closed when it completes
+	early   map[string]time.Time     // Completions no request waits for yet
+}
+
+// NewWebhookReceiver creates a receiver for webhooks posted to publicURL, an
+// http(s) URL fal can reach. A random token is added to the URL so only fal
+// knows where to post completions.
+func NewWebhookReceiver(publicURL string) (*WebhookReceiver, error) {
+	u, err := url.Parse(publicURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL: %q (must be a full http or https URL)", publicURL)
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, fmt.Errorf("failed to create webhook token: %v", err)
+	}
+	token := hex.EncodeToString(b[:])
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return &WebhookReceiver{
+		url:     u.String(),
+		token:   token,
+		waiting: make(map[string]chan struct{}),
+		early:   make(map[string]time.Time),
+	}, nil
+}
+
+// URL returns the URL fal posts completions to.
+func (w *WebhookReceiver) URL() string {
+	return w.url
+}
+
+// ServeHTTP handles a webhook posted by fal.
+func (w *WebhookReceiver) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(w.token)) != 1 {
+		http.Error(rw, "forbidden", http.StatusForbidden)
+		return
+	}
+	var hook struct {
+		RequestID string `json:"request_id"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxWebhookBytes)).Decode(&hook); err != nil || hook.RequestID == "" {
+		http.Error(rw, "invalid webhook", http.StatusBadRequest)
+		return
+	}
+	w.complete(hook.RequestID)
+	rw.WriteHeader(http.StatusOK)
+}
+
+// complete wakes the request waiting for requestID, or keeps the completion
+// for a request that has not started waiting yet.
+func (w *WebhookReceiver) complete(requestID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if ch, ok := w.waiting[requestID]; ok {
+		close(ch)
+		delete(w.waiting, requestID)
+		return
+	}
+	now := time.Now()
+	for id, at := range w.early {
+		if now.Sub(at) > earlyWebhookTTL {
+			delete(w.early, id)
+		}
+	}
+	w.early[requestID] = now
+}
+
+// wait returns a channel that is closed when the webhook of requestID
+// arrives, and a function to call when the request no longer waits.
+func (w *WebhookReceiver) wait(requestID string) (<-chan struct{}, func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ch := make(chan struct{})
+	if _, ok := w.early[requestID]; ok {
+		delete(w.early, requestID)
+		close(ch)
+		return ch, func() {}
+	}
+	w.waiting[requestID] = ch
+	return ch, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.waiting[requestID] == ch {
+			delete(w.waiting, requestID)
+		}
+	}
+}
+
+// WithWebhook makes the client ask fal to post to r when a request
+// completes. The status is still checked every pollInterval (0 uses
+// DefaultWebhookPollInterval), in case a webhook never arrives.
+func WithWebhook(r *WebhookReceiver, pollInterval time.Duration) ClientOption {
+	return func(c *Client) {
+		if pollInterval <= 0 {
+			pollInterval = DefaultWebhookPollInterval
+		}
+		c.webhook = r
+		c.webhookPoll = pollInterval
+	}
+}
+
+// withWebhookParam adds the webhook URL to a request path when the client
+// uses webhooks.
+func (c *Client) withWebhookParam(path string) string {
+	if c.webhook == nil {
+		return path
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "fal_webhook=" + url.QueryEscape(c.webhook.URL())
+}
+
+// requestID returns the id fal reports the request under in webhooks.
+func (q QueueResponse) requestID() string {
+	if q.RequestID != "" {
+		return q.RequestID
+	}
+	// Responses without it carry it as the last element of the response URL
+	return q.ResponseURL[strings.LastIndex(q.ResponseURL, "/")+1:]
+}
@@ -0,0 +1,101 @@
+package fal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookDelivery(t *testing.T) {
+	var recv *WebhookReceiver
+	hooks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recv.ServeHTTP(w, r)
+	}))
+	defer hooks.Close()
+	recv, err := NewWebhookReceiver(hooks.URL + "/fal/webhook")
+	if err != nil {
+		t.Fatalf("NewWebhookReceiver: %v", err)
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			hook := r.URL.Query().Get("fal_webhook")
+			if hook != recv.URL() {
+				t.Errorf("fal_webhook = %q, want %q", hook, recv.URL())
+			}
+			w.Write([]byte(`{"request_id": "req-1", "response_url": "` + srv.URL + `/requests/req-1"}`))
+			// Complete the request right away
+			go http.Post(hook, "application/json", strings.NewReader(`{"request_id": "req-1", "status": "OK"}`))
+		case strings.HasSuffix(r.URL.Path, "/status"):
+			w.Write([]byte(`{"status": "COMPLETED"}`))
+		default:
+			w.Write([]byte(`{"images": [{"url": "https://example.com/out.png"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	// Status is only polled every hour, so the result comes from the webhook
+	c := NewClient("key", WithHTTPClient(srv.Client()), WithWebhook(recv, time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := c.GenerateImage(ctx, &GenericRequest{Endpoint: srv.URL + "/fal-ai/test", Type: "text2image", Body: map[string]interface{}{"prompt": "x"}})
+	if err != nil {
+		t.Fatalf("GenerateImage: %v", err)
+	}
+	if len(resp.Images) != 1 || resp.Images[0].URL != "https://example.com/out.png" {
+		t.Errorf("images = %+v", resp.Images)
+	}
+}
+
+func TestWebhookReceiver(t *testing.T) {
+	if _, err := NewWebhookReceiver("/fal/webhook"); err == nil {
+		t.Error("NewWebhookReceiver accepted a relative URL")
+	}
+	recv, err := NewWebhookReceiver("https://bot.example.com/fal/webhook")
+	if err != nil {
+		t.Fatalf("NewWebhookReceiver: %v", err)
+	}
+	u, _ := url.Parse(recv.URL())
+	if u.Query().Get("token") == "" {
+		t.Fatalf("URL %s has no token", recv.URL())
+	}
+
+	post := func(target, body string) int {
+		rec := httptest.NewRecorder()
+		recv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)))
+		return rec.Code
+	}
+	if code := post("/fal/webhook?token=wrong", `{"request_id": "a"}`); code != http.StatusForbidden {
+		t.Errorf("wrong token: status %d, want 403", code)
+	}
+	if code := post(u.RequestURI(), `{}`); code != http.StatusBadRequest {
+		t.Errorf("missing request id: status %d, want 400", code)
+	}
+
+	// A webhook that arrives before the request waits for it is kept
+	if code := post(u.RequestURI(), `{"request_id": "early"}`); code != http.StatusOK {
+		t.Fatalf("webhook: status %d", code)
+	}
+	done, stop := recv.wait("early")
+	defer stop()
+	select {
+	case <-done:
+	default:
+		t.Error("early webhook was not delivered")
+	}
+
+	late, stopLate := recv.wait("late")
+	defer stopLate()
+	post(u.RequestURI(), `{"request_id": "late"}`)
+	select {
+	case <-late:
+	case <-time.After(time.Second):
+		t.Error("webhook did not wake the waiting request")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package genai runs generation requests on the provider each model is
+// routed to: fal by default, or another backend the operator has credits
+// with. Requests and responses are the model types of pkg/fal, which the
+// other providers translate, so callers and billing do not depend on the
+// backend that runs a job.
+package genai
+
+import (
+	"context"
+	"sync"
+
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+// ImageGenerator generates images for the image requests of pkg/fal.
+// *fal.Client is one.
+type ImageGenerator interface {
+	GenerateImage(ctx context.Context, req interface{}) (*fal.ImageResponse, error)
+}
+
+// Router sends image requests to the generator their model is routed to,
+// and the requests of every other model to a fallback generator.
+type Router struct {
+	fallback ImageGenerator
+
+	mu     sync.RWMutex
+	images map[string]ImageGenerator // Model name → generator
+}
+
+// NewRouter creates a router sending requests to fallback until models are
+// routed elsewhere.
+func NewRouter(fallback ImageGenerator) *Router {
+	return &Router{fallback: fallback, images: make(map[string]ImageGenerator)}
+}
+
+// RouteImages sends the image requests of model to g.
+func (r *Router) RouteImages(model string, g ImageGenerator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.images[model] = g
+}
+
+// GenerateImage runs req on the generator of its model.
+func (r *Router) GenerateImage(ctx context.Context, req interface{}) (*fal.ImageResponse, error) {
+	spec, err := fal.BuildImageRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	g, ok := r.images[spec.Model]
+	r.mu.RUnlock()
+	if !ok {
+		g = r.fallback
+	}
+	return g.GenerateImage(ctx, req)
+}
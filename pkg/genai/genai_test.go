@@ -0,0 +1,124 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+// stubImages returns a fixed image and counts its calls.
+type stubImages struct {
+	url   string
+	calls int
+}
+
+func (s *stubImages) GenerateImage(ctx context.Context, req interface{}) (*fal.ImageResponse, error) {
+	s.calls++
+	return &fal.ImageResponse{Images: []fal.ImageOutput{{URL: s.url}}}, nil
+}
+
+func TestRouter(t *testing.T) {
+	fallback, routed := &stubImages{url: "fal"}, &stubImages{url: "routed"}
+	r := NewRouter(fallback)
+	r.RouteImages("flux/schnell", routed)
+
+	schnell := &fal.FluxSchnellRequest{BaseImageRequest: fal.BaseImageRequest{Prompt: "a fox"}}
+	if resp, err := r.GenerateImage(context.Background(), schnell); err != nil || resp.Images[0].URL != "routed" {
+		t.Fatalf("GenerateImage(flux/schnell) = %+v, %v; want the routed generator", resp, err)
+	}
+	pro := &fal.FluxProV1_1Request{BaseImageRequest: fal.BaseImageRequest{Prompt: "a fox"}}
+	if resp, err := r.GenerateImage(context.Background(), pro); err != nil || resp.Images[0].URL != "fal" {
+		t.Fatalf("GenerateImage(flux-pro) = %+v, %v; want the fallback", resp, err)
+	}
+	if _, err := r.GenerateImage(context.Background(), struct{}{}); err == nil {
+		t.Error("GenerateImage of an unknown request type succeeded")
+	}
+	if fallback.calls != 1 || routed.calls != 1 {
+		t.Errorf("calls = %d fallback, %d routed; want 1 each", fallback.calls, routed.calls)
+	}
+}
+
+func TestReplicateImages(t *testing.T) {
+	var polls atomic.Int32
+	var input map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/models/black-forest-labs/flux-schnell/predictions":
+			var body struct {
+				Input map[string]interface{} `json:"input"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			input = body.Input
+			w.Write([]byte(`{"id": "p1", "status": "starting"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/predictions/p1":
+			if polls.Add(1) < 2 {
+				w.Write([]byte(`{"id": "p1", "status": "processing"}`))
+				return
+			}
+			w.Write([]byte(`{"id": "p1", "status": "succeeded", "output": ["https://replicate.delivery/a.webp", "https://replicate.delivery/b.png"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewReplicate("token")
+	client.baseURL = srv.URL
+	client.pollInterval = time.Millisecond
+	seed := 42
+	safety := false
+	req := &fal.FluxSchnellRequest{
+		BaseImageRequest:    fal.BaseImageRequest{Prompt: "a fox"},
+		ImageSize:           "landscape_16_9",
+		NumImages:           2,
+		Seed:                &seed,
+		EnableSafetyChecker: &safety,
+	}
+	resp, err := client.Images("black-forest-labs/flux-schnell").GenerateImage(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GenerateImage: %v", err)
+	}
+	if len(resp.Images) != 2 || resp.Images[0].ContentType != "image/webp" || resp.Images[1].URL != "https://replicate.delivery/b.png" || resp.Seed != 42 {
+		t.Errorf("GenerateImage = %+v", resp)
+	}
+	if input["prompt"] != "a fox" || input["aspect_ratio"] != "16:9" || input["num_outputs"] != float64(2) || input["disable_safety_checker"] != true {
+		t.Errorf("input = %v", input)
+	}
+	if _, ok := input["num_images"]; ok {
+		t.Errorf("input kept the fal name num_images: %v", input)
+	}
+}
+
+func TestReplicateFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/predictions" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"id": "p2", "status": "failed", "error": "NSFW content detected"}`))
+	}))
+	defer srv.Close()
+
+	client := NewReplicate("token")
+	client.baseURL = srv.URL
+	req := &fal.FluxSchnellRequest{BaseImageRequest: fal.BaseImageRequest{Prompt: "a fox"}}
+	_, err := client.Images("owner/model:abc123").GenerateImage(context.Background(), req)
+	if err == nil || !strings.Contains(err.Error(), "NSFW content detected") {
+		t.Errorf("GenerateImage = %v; want the prediction's error", err)
+	}
+}
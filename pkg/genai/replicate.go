@@ -0,0 +1,268 @@
+// Copyright (c) 2025 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/karamble/braibot/pkg/fal"
+)
+
+const replicateBaseURL = "https://api.replicate.com/v1"
+
+// Replicate is a client of the Replicate prediction API.
+type Replicate struct {
+	token        string
+	baseURL      string
+	httpClient   *http.Client
+	pollInterval time.Duration // Time between two status checks of a prediction
+}
+
+// NewReplicate creates a Replicate client authenticating with token.
+func NewReplicate(token string) *Replicate {
+	return &Replicate{
+		token:        token,
+		baseURL:      replicateBaseURL,
+		httpClient:   &http.Client{Timeout: time.Minute},
+		pollInterval: 2 * time.Second,
+	}
+}
+
+// Images returns a generator running image requests on the Replicate model
+// id: "owner/name" for official models, or "owner/name:version".
+func (r *Replicate) Images(id string) ImageGenerator {
+	return &replicateImages{client: r, model: id}
+}
+
+// prediction is a Replicate prediction as the API returns it.
+type prediction struct {
+	ID     string          `json:"id"`
+	Status string          `json:"status"` // starting, processing, succeeded, failed or canceled
+	Output json.RawMessage `json:"output"`
+	Error  interface{}     `json:"error"`
+	URLs   struct {
+		Get string `json:"get"`
+	} `json:"urls"`
+}
+
+// done reports whether the prediction finished, for better or worse.
+func (p *prediction) done() bool {
+	switch p.Status {
+	case "succeeded", "failed", "canceled":
+		return true
+	}
+	return false
+}
+
+// do sends a request to the API and decodes the prediction it returns.
+func (r *Replicate) do(ctx context.Context, method, endpoint string, body interface{}) (*prediction, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode replicate request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	if !strings.HasPrefix(endpoint, "http") {
+		endpoint = r.baseURL + endpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("replicate request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replicate response: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("replicate request failed: status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var p prediction
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse replicate response: %v", err)
+	}
+	return &p, nil
+}
+
+// predict runs input on the model id and waits for the prediction to
+// finish, reporting its status changes to progress.
+func (r *Replicate) predict(ctx context.Context, id string, input map[string]interface{}, progress fal.ProgressCallback) (*prediction, error) {
+	var p *prediction
+	var err error
+	if _, version, ok := strings.Cut(id, ":"); ok {
+		p, err = r.do(ctx, http.MethodPost, "/predictions", map[string]interface{}{"version": version, "input": input})
+	} else {
+		p, err = r.do(ctx, http.MethodPost, "/models/"+id+"/predictions", map[string]interface{}{"input": input})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := ""
+	for {
+		if p.Status != status {
+			status = p.Status
+			if progress != nil {
+				progress.OnProgress(status)
+			}
+		}
+		if p.done() {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(r.pollInterval):
+		}
+		get := p.URLs.Get
+		if get == "" {
+			get = "/predictions/" + url.PathEscape(p.ID)
+		}
+		if p, err = r.do(ctx, http.MethodGet, get, nil); err != nil {
+			return nil, err
+		}
+	}
+	if p.Status != "succeeded" {
+		if p.Error != nil {
+			return nil, fmt.Errorf("replicate prediction %s %s: %v", p.ID, p.Status, p.Error)
+		}
+		return nil, fmt.Errorf("replicate prediction %s %s", p.ID, p.Status)
+	}
+	return p, nil
+}
+
+// replicateImages runs image requests on one Replicate model.
+type replicateImages struct {
+	client *Replicate
+	model  string
+}
+
+// GenerateImage runs req on the Replicate model, translating the options
+// fal and Replicate name differently.
+func (g *replicateImages) GenerateImage(ctx context.Context, req interface{}) (*fal.ImageResponse, error) {
+	var progress fal.ProgressCallback
+	if progressable, ok := req.(fal.Progressable); ok {
+		progress = progressable.GetProgress()
+	}
+	spec, err := fal.BuildImageRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	input := replicateImageInput(spec.Body)
+	p, err := g.client.predict(ctx, g.model, input, progress)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := replicateImageResponse(p.Output)
+	if err != nil {
+		return nil, fmt.Errorf("replicate prediction %s: %v", p.ID, err)
+	}
+	if seed, ok := input["seed"].(float64); ok && seed > 0 {
+		resp.Seed = uint64(seed)
+	}
+	return resp, nil
+}
+
+// replicateImageKeys are the fal options Replicate's image models know by
+// another name. Other options are passed as they are.
+var replicateImageKeys = map[string]string{
+	"num_images":     "num_outputs",
+	"image_url":      "image",
+	"guidance_scale": "guidance",
+}
+
+// replicateAspectRatios maps fal's image size presets to aspect ratios.
+var replicateAspectRatios = map[string]string{
+	"square_hd":      "1:1",
+	"square":         "1:1",
+	"portrait_4_3":   "3:4",
+	"portrait_16_9":  "9:16",
+	"landscape_4_3":  "4:3",
+	"landscape_16_9": "16:9",
+}
+
+// replicateImageInput translates the body of a fal image request into the
+// input of a Replicate image model.
+func replicateImageInput(body map[string]interface{}) map[string]interface{} {
+	// Round trip through JSON so values are the plain types Replicate's
+	// responses and the seed check expect
+	var plain map[string]interface{}
+	if data, err := json.Marshal(body); err == nil && json.Unmarshal(data, &plain) == nil {
+		body = plain
+	}
+	input := make(map[string]interface{}, len(body))
+	for k, v := range body {
+		switch k {
+		case "enable_safety_checker":
+			if enabled, ok := v.(bool); ok {
+				input["disable_safety_checker"] = !enabled
+			}
+		case "image_size":
+			switch size := v.(type) {
+			case string:
+				if ratio, ok := replicateAspectRatios[size]; ok {
+					input["aspect_ratio"] = ratio
+				}
+			case map[string]interface{}:
+				input["width"], input["height"] = size["width"], size["height"]
+			}
+		default:
+			if name, ok := replicateImageKeys[k]; ok {
+				k = name
+			}
+			input[k] = v
+		}
+	}
+	return input
+}
+
+// replicateImageResponse converts the output of an image prediction, one
+// URL or a list of them, into an image response.
+func replicateImageResponse(output json.RawMessage) (*fal.ImageResponse, error) {
+	var urls []string
+	if err := json.Unmarshal(output, &urls); err != nil {
+		var single string
+		if err := json.Unmarshal(output, &single); err != nil {
+			return nil, fmt.Errorf("unexpected output: %s", output)
+		}
+		urls = []string{single}
+	}
+	resp := &fal.ImageResponse{}
+	for _, u := range urls {
+		if u == "" {
+			continue
+		}
+		contentType := "image/png"
+		if parsed, err := url.Parse(u); err == nil {
+			if t := mime.TypeByExtension(path.Ext(parsed.Path)); t != "" {
+				contentType = t
+			}
+		}
+		resp.Images = append(resp.Images, fal.ImageOutput{URL: u, ContentType: contentType})
+	}
+	if len(resp.Images) == 0 {
+		return nil, fmt.Errorf("no images in the output")
+	}
+	return resp, nil
+}